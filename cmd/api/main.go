@@ -1,6 +1,8 @@
 package main
 
 import (
+	_ "time/tzdata" // часовой пояс клиники в образах без zoneinfo
+
 	"github.com/beercut-team/backend-boilerplate/internal/config"
 	"github.com/beercut-team/backend-boilerplate/internal/server"
 	"github.com/beercut-team/backend-boilerplate/pkg/database"
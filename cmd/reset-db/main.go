@@ -30,18 +30,33 @@ func main() {
 
 	// Drop all tables in reverse order of dependencies
 	tables := []interface{}{
+		&domain.UserToken{},
+		&domain.Invitation{},
+		&domain.Consent{},
+		&domain.Session{},
+		&domain.RecoveryCode{},
+		&domain.IntegrationOutbox{},
+		&domain.SyncTombstone{},
 		&domain.SyncQueue{},
 		&domain.TelegramBinding{},
 		&domain.Notification{},
 		&domain.Comment{},
+		&domain.SurgeonTimeOff{},
+		&domain.SurgeonWorkingHours{},
+		&domain.PostOpVisit{},
 		&domain.Surgery{},
+		&domain.OperatingRoom{},
 		&domain.IOLCalculation{},
+		&domain.IOLLens{},
+		&domain.EyeExam{},
 		&domain.Media{},
 		&domain.ChecklistItem{},
+		&domain.ChecklistTemplateItem{},
 		&domain.ChecklistTemplate{},
 		&domain.PatientStatusHistory{},
 		&domain.Patient{},
 		&domain.AuditLog{},
+		&domain.OperationTypeModel{},
 		&domain.District{},
 		&domain.User{},
 	}
@@ -59,17 +74,32 @@ func main() {
 		&domain.User{},
 		&domain.District{},
 		&domain.AuditLog{},
+		&domain.OperationTypeModel{},
 		&domain.Patient{},
 		&domain.PatientStatusHistory{},
 		&domain.ChecklistTemplate{},
+		&domain.ChecklistTemplateItem{},
 		&domain.ChecklistItem{},
 		&domain.Media{},
+		&domain.EyeExam{},
+		&domain.IOLLens{},
 		&domain.IOLCalculation{},
+		&domain.OperatingRoom{},
 		&domain.Surgery{},
+		&domain.PostOpVisit{},
+		&domain.SurgeonWorkingHours{},
+		&domain.SurgeonTimeOff{},
 		&domain.Comment{},
 		&domain.Notification{},
 		&domain.TelegramBinding{},
 		&domain.SyncQueue{},
+		&domain.SyncTombstone{},
+		&domain.IntegrationOutbox{},
+		&domain.Consent{},
+		&domain.Session{},
+		&domain.RecoveryCode{},
+		&domain.Invitation{},
+		&domain.UserToken{},
 	); err != nil {
 		log.Fatal().Err(err).Msg("не удалось выполнить миграцию")
 	}
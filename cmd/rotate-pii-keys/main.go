@@ -0,0 +1,156 @@
+// Команда rotate-pii-keys перешифровывает ПДн пациентов и секреты 2FA сотрудников
+// активным ключом и пересчитывает слепые индексы.
+//
+// Запускается после включения шифрования (открытые значения шифруются),
+// после смены PII_ACTIVE_KEY и с флагом -all после смены PII_INDEX_KEY.
+// Старый ключ можно убрать из конфигурации только после успешного запуска.
+package main
+
+import (
+	"context"
+	"flag"
+	"sort"
+	"strings"
+
+	"github.com/beercut-team/backend-boilerplate/internal/config"
+	"github.com/beercut-team/backend-boilerplate/internal/domain"
+	"github.com/beercut-team/backend-boilerplate/pkg/database"
+	"github.com/beercut-team/backend-boilerplate/pkg/logger"
+	"github.com/beercut-team/backend-boilerplate/pkg/pii"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+func main() {
+	dryRun := flag.Bool("dry-run", false, "только посчитать записи, которые нужно перешифровать")
+	all := flag.Bool("all", false, "обработать все записи (после смены ключа слепых индексов)")
+	batchSize := flag.Int("batch", 200, "размер пачки")
+	flag.Parse()
+
+	logger.Init()
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal().Err(err).Msg("не удалось загрузить конфигурацию")
+	}
+
+	db, err := database.NewPostgres(cfg)
+	if err != nil {
+		log.Fatal().Err(err).Msg("не удалось подключиться к базе данных")
+	}
+
+	ctx := context.Background()
+
+	// Имена колонок — из схемы GORM: у СНИЛС колонка sni_ls, а не snils
+	piiColumns, hashes, err := domain.PatientColumns()
+	if err != nil {
+		log.Fatal().Err(err).Msg("не удалось определить колонки ПДн")
+	}
+	columns := make([]string, 0, len(piiColumns))
+	for _, column := range piiColumns {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+	hashColumns := make([]string, 0, len(hashes))
+	for _, column := range hashes {
+		hashColumns = append(hashColumns, column)
+	}
+	sort.Strings(hashColumns)
+
+	cipher, err := pii.Default()
+	if err != nil {
+		log.Fatal().Err(err).Msg("шифрование ПДн не настроено")
+	}
+	activeKey := cipher.ActiveKeyID()
+
+	rotateTOTPSecrets(ctx, db, activeKey, *dryRun)
+
+	query := db.WithContext(ctx).Model(&domain.Patient{})
+	if !*all {
+		cond, args := staleCondition(columns, indexedColumns(piiColumns, hashes), activeKey)
+		query = query.Where(cond, args...)
+	}
+	// Запрос переиспользуется для подсчёта и выборки
+	query = query.Session(&gorm.Session{})
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		log.Fatal().Err(err).Msg("не удалось посчитать пациентов")
+	}
+	log.Info().Int64("count", total).Str("active_key", activeKey).Msg("пациентов к перешифрованию")
+	if *dryRun || total == 0 {
+		return
+	}
+
+	// Хуки не вызываются: версия синхронизации и updated_at не меняются,
+	// клиенты не перекачивают неизменившиеся карточки
+	updateColumns := append(append([]string{}, columns...), hashColumns...)
+	updated := 0
+	var patients []domain.Patient
+	err = query.FindInBatches(&patients, *batchSize, func(_ *gorm.DB, batch int) error {
+		return db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			for i := range patients {
+				patients[i].SetBlindIndexes()
+				if err := tx.Model(&patients[i]).Select(updateColumns).UpdateColumns(&patients[i]).Error; err != nil {
+					return err
+				}
+			}
+			updated += len(patients)
+			log.Info().Int("batch", batch).Int("updated", updated).Msg("пачка перешифрована")
+			return nil
+		})
+	}).Error
+	if err != nil {
+		log.Fatal().Err(err).Int("updated", updated).Msg("не удалось перешифровать ПДн")
+	}
+
+	log.Info().Int("updated", updated).Msg("перешифрование завершено")
+}
+
+// staleCondition — запись содержит значение не под активным ключом
+// (открытое или под старым ключом) либо значение без слепого индекса
+func staleCondition(columns []string, indexed [][2]string, activeKey string) (string, []interface{}) {
+	prefix := pii.KeyPrefix(activeKey)
+	var conds []string
+	var args []interface{}
+	for _, column := range columns {
+		conds = append(conds, "(coalesce("+column+", '') <> '' AND left("+column+", ?) <> ?)")
+		args = append(args, len(prefix), prefix)
+	}
+	for _, c := range indexed {
+		conds = append(conds, "(coalesce("+c[0]+", '') <> '' AND coalesce("+c[1]+", '') = '')")
+	}
+	return strings.Join(conds, " OR "), args
+}
+
+// indexedColumns — пары «значение — слепой индекс», как в Patient.SetBlindIndexes
+func indexedColumns(columns, hashes map[string]string) [][2]string {
+	return [][2]string{
+		{columns["snils"], hashes["SNILSHash"]},
+		{columns["oms_policy"], hashes["OMSPolicyHash"]},
+		{columns["policy_number"], hashes["PolicyNumberHash"]},
+		{columns["passport_series"] + " || " + columns["passport_number"], hashes["PassportHash"]},
+		{columns["phone"], hashes["PhoneHash"]},
+	}
+}
+
+// rotateTOTPSecrets перешифровывает секреты TOTP сотрудников; их немного, пачки не нужны
+func rotateTOTPSecrets(ctx context.Context, db *gorm.DB, activeKey string, dryRun bool) {
+	prefix := pii.KeyPrefix(activeKey)
+	var users []domain.User
+	err := db.WithContext(ctx).Select("id", "totp_secret").
+		Where("coalesce(totp_secret, '') <> '' AND left(totp_secret, ?) <> ?", len(prefix), prefix).
+		Find(&users).Error
+	if err != nil {
+		log.Fatal().Err(err).Msg("не удалось найти секреты 2FA")
+	}
+	log.Info().Int("count", len(users)).Str("active_key", activeKey).Msg("секретов 2FA к перешифрованию")
+	if dryRun {
+		return
+	}
+	for i := range users {
+		if err := db.WithContext(ctx).Model(&users[i]).Select("totp_secret").UpdateColumns(&users[i]).Error; err != nil {
+			log.Fatal().Err(err).Uint("user_id", users[i].ID).Msg("не удалось перешифровать секрет 2FA")
+		}
+	}
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"time"
 
 	"github.com/beercut-team/backend-boilerplate/internal/config"
 	"github.com/beercut-team/backend-boilerplate/internal/domain"
@@ -172,18 +173,15 @@ func main() {
 	db.Model(&domain.ChecklistItem{}).Where("patient_id = ?", patients[0].ID).Count(&existingCount)
 
 	if existingCount == 0 {
-		templates := domain.GetChecklistTemplates(patients[0].OperationType)
-		var items []domain.ChecklistItem
-		for _, t := range templates {
-			items = append(items, domain.ChecklistItem{
-				PatientID:   patients[0].ID,
-				Name:        t.Name,
-				Description: t.Description,
-				Category:    t.Category,
-				IsRequired:  t.IsRequired,
-				Status:      domain.ChecklistStatusPending,
-			})
+		// Шаблоны по умолчанию добавляются при миграции, берём активную версию
+		template := domain.DefaultChecklistTemplate(patients[0].OperationType)
+		var active domain.ChecklistTemplate
+		if err := db.WithContext(ctx).Preload("Items").
+			Where("operation_type = ? AND is_active = ?", patients[0].OperationType, true).
+			First(&active).Error; err == nil {
+			template = &active
 		}
+		items := template.BuildItems(&patients[0], time.Now())
 		if len(items) > 0 {
 			db.WithContext(ctx).Create(&items)
 			log.Info().Int("count", len(items)).Msg("чек-лист для пациента 1 добавлен")
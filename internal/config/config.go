@@ -40,6 +40,41 @@ type Config struct {
 	// Storage mode: "minio" or "local"
 	StorageMode     string `mapstructure:"STORAGE_MODE"`
 	LocalUploadPath string `mapstructure:"LOCAL_UPLOAD_PATH"`
+
+	// Integrations (пустой URL — используется локальный фейковый сервер)
+	EMIASBaseURL              string `mapstructure:"EMIAS_BASE_URL"`
+	EMIASToken                string `mapstructure:"EMIAS_TOKEN"`
+	RIAMSBaseURL              string `mapstructure:"RIAMS_BASE_URL"`
+	RIAMSToken                string `mapstructure:"RIAMS_TOKEN"`
+	IntegrationTimeoutSeconds int    `mapstructure:"INTEGRATION_TIMEOUT_SECONDS"`
+
+	// Часовой пояс клиники для расписания операций (IANA)
+	ClinicTimezone string `mapstructure:"CLINIC_TIMEZONE"`
+
+	// Шифрование ПДн пациентов: провайдер ключей "env" или "file"
+	PIIKeyProvider string `mapstructure:"PII_KEY_PROVIDER"`
+	PIIKeys        string `mapstructure:"PII_KEYS"` // id:base64,id:base64
+	PIIActiveKey   string `mapstructure:"PII_ACTIVE_KEY"`
+	PIIIndexKey    string `mapstructure:"PII_INDEX_KEY"`
+	PIIKeyFile     string `mapstructure:"PII_KEY_FILE"`
+
+	// Название сервиса в приложении-аутентификаторе (2FA)
+	TOTPIssuer string `mapstructure:"TOTP_ISSUER"`
+
+	// Вход пациента по коду требует дату рождения
+	PatientLoginBirthDate bool `mapstructure:"PATIENT_LOGIN_BIRTH_DATE"`
+
+	// JSON-файл ключей подписи JWT (kid, RS256/EdDSA/HS256); пусто — HS256 на JWT_*_SECRET
+	JWTKeysFile string `mapstructure:"JWT_KEYS_FILE"`
+	// До какого момента с JWT_KEYS_FILE принимаются старые токены без kid (ГГГГ-ММ-ДД или RFC 3339); пусто — не принимаются
+	JWTLegacyAcceptUntil string `mapstructure:"JWT_LEGACY_ACCEPT_UNTIL"`
+
+	// Почта для приглашений и сброса пароля; без SMTP_HOST письма только пишутся в лог
+	SMTPHost     string `mapstructure:"SMTP_HOST"`
+	SMTPPort     string `mapstructure:"SMTP_PORT"`
+	SMTPUsername string `mapstructure:"SMTP_USERNAME"`
+	SMTPPassword string `mapstructure:"SMTP_PASSWORD"`
+	SMTPFrom     string `mapstructure:"SMTP_FROM"`
 }
 
 func Load() (*Config, error) {
@@ -63,6 +98,12 @@ func Load() (*Config, error) {
 	viper.SetDefault("STORAGE_MODE", "local")
 	viper.SetDefault("LOCAL_UPLOAD_PATH", "./uploads")
 	viper.SetDefault("BASE_URL", "http://localhost:8080")
+	viper.SetDefault("INTEGRATION_TIMEOUT_SECONDS", 30)
+	viper.SetDefault("CLINIC_TIMEZONE", "Europe/Moscow")
+	viper.SetDefault("PII_KEY_PROVIDER", "env")
+	viper.SetDefault("TOTP_ISSUER", "Oculus-Feldsher")
+	viper.SetDefault("SMTP_PORT", "587")
+	viper.SetDefault("SMTP_FROM", "noreply@localhost")
 
 	cfg := &Config{
 		AppPort:             viper.GetString("APP_PORT"),
@@ -89,6 +130,33 @@ func Load() (*Config, error) {
 		BaseURL:             viper.GetString("BASE_URL"),
 		StorageMode:         viper.GetString("STORAGE_MODE"),
 		LocalUploadPath:     viper.GetString("LOCAL_UPLOAD_PATH"),
+
+		EMIASBaseURL:              viper.GetString("EMIAS_BASE_URL"),
+		EMIASToken:                viper.GetString("EMIAS_TOKEN"),
+		RIAMSBaseURL:              viper.GetString("RIAMS_BASE_URL"),
+		RIAMSToken:                viper.GetString("RIAMS_TOKEN"),
+		IntegrationTimeoutSeconds: viper.GetInt("INTEGRATION_TIMEOUT_SECONDS"),
+
+		ClinicTimezone: viper.GetString("CLINIC_TIMEZONE"),
+
+		PIIKeyProvider: viper.GetString("PII_KEY_PROVIDER"),
+		PIIKeys:        viper.GetString("PII_KEYS"),
+		PIIActiveKey:   viper.GetString("PII_ACTIVE_KEY"),
+		PIIIndexKey:    viper.GetString("PII_INDEX_KEY"),
+		PIIKeyFile:     viper.GetString("PII_KEY_FILE"),
+
+		TOTPIssuer: viper.GetString("TOTP_ISSUER"),
+
+		PatientLoginBirthDate: viper.GetBool("PATIENT_LOGIN_BIRTH_DATE"),
+
+		JWTKeysFile:          viper.GetString("JWT_KEYS_FILE"),
+		JWTLegacyAcceptUntil: viper.GetString("JWT_LEGACY_ACCEPT_UNTIL"),
+
+		SMTPHost:     viper.GetString("SMTP_HOST"),
+		SMTPPort:     viper.GetString("SMTP_PORT"),
+		SMTPUsername: viper.GetString("SMTP_USERNAME"),
+		SMTPPassword: viper.GetString("SMTP_PASSWORD"),
+		SMTPFrom:     viper.GetString("SMTP_FROM"),
 	}
 
 	return cfg, nil
@@ -0,0 +1,105 @@
+package domain
+
+// PatientAction — действие над карточкой пациента или связанными с ней данными
+type PatientAction string
+
+const (
+	// Просмотр карточки, чек-листа, файлов, расчётов, комментариев, печать
+	PatientActionRead PatientAction = "read"
+	// Изменение карточки и связанных данных, операции, выгрузка во внешние системы
+	PatientActionWrite PatientAction = "write"
+	// Проверка хирургом: решение по статусу, проверка пунктов чек-листа,
+	// комментарии, назначение операции. Для остальных ролей — как изменение.
+	PatientActionReview PatientAction = "review"
+)
+
+// SurgeonVisibleStatuses — статусы, в которых пациенты доступны всем хирургам
+var SurgeonVisibleStatuses = []PatientStatus{
+	PatientStatusPendingReview,
+	PatientStatusApproved,
+	PatientStatusScheduled,
+	PatientStatusCompleted,
+	PatientStatusNeedsCorrection,
+}
+
+// Actor — пользователь, от имени которого выполняется запрос.
+// Для роли PATIENT ID совпадает с ID пациента.
+type Actor struct {
+	ID         uint
+	Role       Role
+	DistrictID *uint
+}
+
+// CanAccessPatient проверяет доступ пользователя к пациенту:
+//   - администратор — полный доступ;
+//   - участковый врач — полный доступ к своим пациентам, просмотр пациентов своего района;
+//   - хирург — полный доступ к назначенным ему пациентам, просмотр и проверка пациентов
+//     на этапе проверки и лечения;
+//   - колл-центр — только просмотр;
+//   - пациент — только просмотр собственной карточки.
+func CanAccessPatient(actor Actor, patient *Patient, action PatientAction) bool {
+	if patient == nil || !ReadScope(actor).Allows(patient) {
+		return false
+	}
+	if action == PatientActionRead {
+		return true
+	}
+
+	switch actor.Role {
+	case RoleAdmin:
+		return true
+	case RoleDistrictDoctor:
+		return patient.DoctorID == actor.ID
+	case RoleSurgeon:
+		return action == PatientActionReview || (patient.SurgeonID != nil && *patient.SurgeonID == actor.ID)
+	}
+	return false
+}
+
+// PatientScope — пациенты, видимые пользователю (просмотр в CanAccessPatient), в виде
+// условий для запросов к БД. Условия объединяются через ИЛИ; пустой scope — никто.
+type PatientScope struct {
+	All        bool
+	PatientID  *uint
+	DoctorID   *uint
+	DistrictID *uint
+	SurgeonID  *uint
+	Statuses   []PatientStatus
+}
+
+// ReadScope — единственное место, где задано, каких пациентов видит роль
+func ReadScope(actor Actor) PatientScope {
+	id := actor.ID
+	switch actor.Role {
+	case RoleAdmin, RoleCallCenter:
+		return PatientScope{All: true}
+	case RoleDistrictDoctor:
+		return PatientScope{DoctorID: &id, DistrictID: actor.DistrictID}
+	case RoleSurgeon:
+		return PatientScope{SurgeonID: &id, Statuses: SurgeonVisibleStatuses}
+	case RolePatient:
+		return PatientScope{PatientID: &id}
+	}
+	return PatientScope{}
+}
+
+func (s PatientScope) Allows(p *Patient) bool {
+	switch {
+	case s.All:
+		return true
+	case s.PatientID != nil && p.ID == *s.PatientID:
+		return true
+	case s.DoctorID != nil && p.DoctorID == *s.DoctorID:
+		return true
+	case s.DistrictID != nil && p.DistrictID == *s.DistrictID:
+		return true
+	case s.SurgeonID != nil && p.SurgeonID != nil && *p.SurgeonID == *s.SurgeonID:
+		return true
+	}
+	for _, st := range s.Statuses {
+		if p.Status == st {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,99 @@
+package domain
+
+import "testing"
+
+func TestCanAccessPatient(t *testing.T) {
+	doctorID, surgeonID, otherID := uint(10), uint(20), uint(30)
+	district, otherDistrict := uint(1), uint(2)
+
+	own := &Patient{ID: 5, DoctorID: doctorID, DistrictID: district, Status: PatientStatusInProgress}
+	sameDistrict := &Patient{ID: 6, DoctorID: otherID, DistrictID: district, Status: PatientStatusInProgress}
+	foreign := &Patient{ID: 7, DoctorID: otherID, DistrictID: otherDistrict, Status: PatientStatusInProgress}
+	assigned := &Patient{ID: 8, DoctorID: otherID, DistrictID: otherDistrict, SurgeonID: &surgeonID, Status: PatientStatusCancelled}
+	inReview := &Patient{ID: 9, DoctorID: otherID, DistrictID: otherDistrict, Status: PatientStatusPendingReview}
+	otherSurgeon := &Patient{ID: 11, DoctorID: otherID, DistrictID: otherDistrict, SurgeonID: &otherID, Status: PatientStatusDraft}
+	otherInReview := &Patient{ID: 12, DoctorID: otherID, DistrictID: otherDistrict, SurgeonID: &otherID, Status: PatientStatusApproved}
+
+	admin := Actor{ID: 1, Role: RoleAdmin}
+	doctor := Actor{ID: doctorID, Role: RoleDistrictDoctor, DistrictID: &district}
+	doctorNoDistrict := Actor{ID: doctorID, Role: RoleDistrictDoctor}
+	surgeon := Actor{ID: surgeonID, Role: RoleSurgeon}
+	callCenter := Actor{ID: 40, Role: RoleCallCenter}
+	patient := Actor{ID: 5, Role: RolePatient}
+
+	tests := []struct {
+		name    string
+		actor   Actor
+		patient *Patient
+		read    bool
+		write   bool
+		review  bool
+	}{
+		{"admin", admin, foreign, true, true, true},
+		{"doctor own patient", doctor, own, true, true, true},
+		{"doctor same district", doctor, sameDistrict, true, false, false},
+		{"doctor other district", doctor, foreign, false, false, false},
+		{"doctor without district", doctorNoDistrict, sameDistrict, false, false, false},
+		{"surgeon assigned", surgeon, assigned, true, true, true},
+		{"surgeon review status", surgeon, inReview, true, false, true},
+		{"surgeon draft patient", surgeon, foreign, false, false, false},
+		{"surgeon other surgeon's patient", surgeon, otherSurgeon, false, false, false},
+		{"surgeon other surgeon's patient in review", surgeon, otherInReview, true, false, true},
+		{"call center", callCenter, foreign, true, false, false},
+		{"patient self", patient, own, true, false, false},
+		{"patient other", patient, sameDistrict, false, false, false},
+		{"unknown role", Actor{ID: doctorID, Role: Role("GUEST")}, own, false, false, false},
+		{"nil patient", admin, nil, false, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CanAccessPatient(tt.actor, tt.patient, PatientActionRead); got != tt.read {
+				t.Errorf("read = %v, want %v", got, tt.read)
+			}
+			if got := CanAccessPatient(tt.actor, tt.patient, PatientActionWrite); got != tt.write {
+				t.Errorf("write = %v, want %v", got, tt.write)
+			}
+			if got := CanAccessPatient(tt.actor, tt.patient, PatientActionReview); got != tt.review {
+				t.Errorf("review = %v, want %v", got, tt.review)
+			}
+		})
+	}
+}
+
+// Pull синхронизации и списки строятся по ReadScope: он не должен расходиться с проверкой просмотра
+func TestReadScopeMatchesCanAccessPatient(t *testing.T) {
+	district, otherDistrict := uint(1), uint(2)
+	surgeonID, otherID := uint(20), uint(30)
+
+	actors := []Actor{
+		{ID: 1, Role: RoleAdmin},
+		{ID: 10, Role: RoleDistrictDoctor, DistrictID: &district},
+		{ID: 10, Role: RoleDistrictDoctor},
+		{ID: surgeonID, Role: RoleSurgeon},
+		{ID: 40, Role: RoleCallCenter},
+		{ID: 5, Role: RolePatient},
+		{ID: 10, Role: Role("GUEST")},
+	}
+	var patients []*Patient
+	for _, id := range []uint{5, 6} {
+		for _, doctor := range []uint{10, otherID} {
+			for _, d := range []uint{district, otherDistrict} {
+				for _, surgeon := range []*uint{nil, &surgeonID, &otherID} {
+					for _, st := range []PatientStatus{PatientStatusDraft, PatientStatusInProgress, PatientStatusApproved, PatientStatusCancelled} {
+						patients = append(patients, &Patient{ID: id, DoctorID: doctor, DistrictID: d, SurgeonID: surgeon, Status: st})
+					}
+				}
+			}
+		}
+	}
+
+	for _, a := range actors {
+		scope := ReadScope(a)
+		for _, p := range patients {
+			if got, want := scope.Allows(p), CanAccessPatient(a, p, PatientActionRead); got != want {
+				t.Errorf("%s %d: ReadScope.Allows(%+v) = %v, CanAccessPatient = %v", a.Role, a.ID, *p, got, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,179 @@
+package domain
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Сроки действия ссылок из писем
+const (
+	InvitationTTL    = 72 * time.Hour
+	PasswordResetTTL = time.Hour
+	EmailVerifyTTL   = 48 * time.Hour
+)
+
+// Статусы приглашения; хранятся только принятие и отзыв, истечение — по сроку
+const (
+	InvitationPending  = "PENDING"
+	InvitationAccepted = "ACCEPTED"
+	InvitationRevoked  = "REVOKED"
+	InvitationExpired  = "EXPIRED"
+)
+
+// Invitation — приглашение сотрудника: администратор задаёт роль и район,
+// сотрудник по ссылке из письма задаёт пароль
+type Invitation struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	Email      string     `gorm:"type:varchar(255);not null;index" json:"email"`
+	Name       string     `json:"name"`
+	Role       Role       `gorm:"type:varchar(20);not null" json:"role"`
+	DistrictID uint       `gorm:"not null" json:"district_id"`
+	TokenHash  string     `gorm:"type:varchar(64);not null;uniqueIndex" json:"-"`
+	InvitedBy  uint       `gorm:"not null" json:"invited_by"`
+	ExpiresAt  time.Time  `gorm:"not null" json:"expires_at"`
+	AcceptedAt *time.Time `json:"accepted_at,omitempty"`
+	UserID     *uint      `json:"user_id,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+
+	Status string `gorm:"-" json:"status"`
+}
+
+func (i *Invitation) CurrentStatus(now time.Time) string {
+	switch {
+	case i.AcceptedAt != nil:
+		return InvitationAccepted
+	case i.RevokedAt != nil:
+		return InvitationRevoked
+	case !now.Before(i.ExpiresAt):
+		return InvitationExpired
+	}
+	return InvitationPending
+}
+
+// Назначение одноразового токена пользователя
+const (
+	UserTokenPasswordReset = "PASSWORD_RESET"
+	UserTokenEmailVerify   = "EMAIL_VERIFY"
+)
+
+// UserToken — одноразовая ссылка из письма; хранится только хеш
+type UserToken struct {
+	ID        uint      `gorm:"primaryKey"`
+	UserID    uint      `gorm:"not null;index"`
+	Purpose   string    `gorm:"type:varchar(20);not null"`
+	TokenHash string    `gorm:"type:varchar(64);not null;uniqueIndex"`
+	ExpiresAt time.Time `gorm:"not null;index"`
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+// NewLinkToken — секрет для ссылки из письма и его хеш для базы
+func NewLinkToken() (token, hash string, err error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	token = hex.EncodeToString(b)
+	return token, HashToken(token), nil
+}
+
+// NormalizeEmail — адреса сравниваются без учёта регистра и пробелов
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// Парольная политика
+const (
+	PasswordMinLength = 10
+	// bcrypt учитывает только первые 72 байта
+	PasswordMaxBytes = 72
+)
+
+// Самые частые пароли из утечек, которые проходят правила длины и состава
+var commonPasswords = map[string]bool{
+	"password123": true, "password1234": true, "qwerty12345": true, "qwerty123456": true,
+	"1q2w3e4r5t": true, "1q2w3e4r5t6y": true, "zaq12wsxcde": true, "qwertyuiop1": true,
+	"123456789a": true, "a123456789": true, "iloveyou123": true, "admin12345": true,
+	"administrator1": true, "welcome123": true, "parol12345": true, "abc1234567": true,
+}
+
+// ValidatePassword проверяет пароль: не короче 10 символов, буквы и цифры,
+// не из списка частых и без email или имени пользователя
+func ValidatePassword(password string, personal ...string) error {
+	if len([]rune(password)) < PasswordMinLength {
+		return fmt.Errorf("пароль должен содержать не меньше %d символов", PasswordMinLength)
+	}
+	if len(password) > PasswordMaxBytes {
+		return fmt.Errorf("пароль длиннее %d байт", PasswordMaxBytes)
+	}
+
+	var letter, digit bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLetter(r):
+			letter = true
+		case unicode.IsDigit(r):
+			digit = true
+		}
+	}
+	if !letter || !digit {
+		return errors.New("пароль должен содержать буквы и цифры")
+	}
+
+	lower := strings.ToLower(password)
+	if commonPasswords[lower] {
+		return errors.New("пароль слишком распространён, выберите другой")
+	}
+	for _, p := range personal {
+		// Для email проверяется имя до @: «ivanov@clinic.ru» → «ivanov»
+		p, _, _ = strings.Cut(strings.ToLower(strings.TrimSpace(p)), "@")
+		if len([]rune(p)) >= 4 && strings.Contains(lower, p) {
+			return errors.New("пароль не должен содержать email или имя")
+		}
+	}
+	return nil
+}
+
+// --- Requests ---
+
+type CreateInvitationRequest struct {
+	Email      string `json:"email" binding:"required,email"`
+	Name       string `json:"name"`
+	Role       Role   `json:"role" binding:"required"`
+	DistrictID uint   `json:"district_id" binding:"required"`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required"`
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// --- Responses ---
+
+// InvitationInfo — что видит приглашённый до принятия
+type InvitationInfo struct {
+	Email      string    `json:"email"`
+	Name       string    `json:"name"`
+	Role       Role      `json:"role"`
+	DistrictID uint      `json:"district_id"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
@@ -0,0 +1,65 @@
+package domain
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidatePassword(t *testing.T) {
+	tests := []struct {
+		name     string
+		password string
+		personal []string
+		ok       bool
+	}{
+		{"valid", "Oculus2026feld", nil, true},
+		{"cyrillic", "глазноедно42", nil, true},
+		{"too short", "abc12345", nil, false},
+		{"letters only", "abcdefghijkl", nil, false},
+		{"digits only", "123456789012", nil, false},
+		{"common", "Password123", nil, false},
+		{"contains email name", "ivanov2026x", []string{"ivanov@clinic.ru"}, false},
+		{"contains name", "xПетров2026", []string{"Петров"}, false},
+		{"short personal ignored", "Oculus2026ab", []string{"ab@clinic.ru"}, true},
+		{"too long for bcrypt", strings.Repeat("a1", 37), nil, false},
+	}
+	for _, tt := range tests {
+		err := ValidatePassword(tt.password, tt.personal...)
+		if (err == nil) != tt.ok {
+			t.Errorf("%s: ValidatePassword(%q) = %v, want ok=%v", tt.name, tt.password, err, tt.ok)
+		}
+	}
+}
+
+func TestInvitationStatus(t *testing.T) {
+	now := time.Now()
+	past := now.Add(-time.Hour)
+	tests := []struct {
+		inv  Invitation
+		want string
+	}{
+		{Invitation{ExpiresAt: now.Add(time.Hour)}, InvitationPending},
+		{Invitation{ExpiresAt: past}, InvitationExpired},
+		{Invitation{ExpiresAt: past, AcceptedAt: &past}, InvitationAccepted},
+		{Invitation{ExpiresAt: now.Add(time.Hour), RevokedAt: &past}, InvitationRevoked},
+	}
+	for i, tt := range tests {
+		if got := tt.inv.CurrentStatus(now); got != tt.want {
+			t.Errorf("#%d: status = %s, want %s", i, got, tt.want)
+		}
+	}
+}
+
+func TestNewLinkToken(t *testing.T) {
+	token, hash, err := NewLinkToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(token) != 64 || hash != HashToken(token) {
+		t.Errorf("token %q, hash %q", token, hash)
+	}
+	if NormalizeEmail("  Doc@Clinic.RU ") != "doc@clinic.ru" {
+		t.Error("NormalizeEmail")
+	}
+}
@@ -1,15 +1,167 @@
 package domain
 
-import "time"
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"time"
+)
 
 type AuditLog struct {
-	ID         uint      `gorm:"primaryKey" json:"id"`
-	UserID     uint      `gorm:"index" json:"user_id"`
-	Action     string    `gorm:"not null;index" json:"action"`
-	Entity     string    `gorm:"not null;index" json:"entity"`
-	EntityID   uint      `json:"entity_id"`
-	OldValue   string    `gorm:"type:text" json:"old_value,omitempty"`
-	NewValue   string    `gorm:"type:text" json:"new_value,omitempty"`
-	IP         string    `json:"ip"`
-	CreatedAt  time.Time `json:"created_at"`
+	ID     uint `gorm:"primaryKey" json:"id"`
+	UserID uint `gorm:"index" json:"user_id"`
+	// Роль автора: у пациента user_id — это ID карты пациента
+	UserRole Role   `gorm:"type:varchar(30)" json:"user_role,omitempty"`
+	Action   string `gorm:"not null;index" json:"action"`
+	Entity   string `gorm:"not null;index" json:"entity"`
+	EntityID uint   `gorm:"index" json:"entity_id"`
+	OldValue string `gorm:"type:text" json:"old_value,omitempty"`
+	NewValue string `gorm:"type:text" json:"new_value,omitempty"`
+	// Изменённые поля: было → стало; пишется сервисами, значения ПДн скрыты
+	Changes   AuditChanges `gorm:"type:jsonb" json:"changes,omitempty"`
+	IP        string       `json:"ip"`
+	CreatedAt time.Time    `gorm:"index" json:"created_at"`
+}
+
+// Действия в журнале аудита
+const (
+	AuditActionCreate = "CREATE"
+	AuditActionUpdate = "UPDATE"
+	AuditActionDelete = "DELETE"
+	AuditActionRead   = "READ"
+	AuditActionMerge  = "MERGE"
+	// Неудачный вход по коду доступа и блокировка после серии таких попыток
+	AuditActionLoginFailed = "LOGIN_FAILED"
+	AuditActionLockout     = "LOCKOUT"
+	// Смена или сброс пароля; сам пароль в журнал не попадает
+	AuditActionPasswordChange = "PASSWORD_CHANGE"
+	// Управление сотрудниками: смена роли завершает сессии так же, как деактивация
+	AuditActionRoleChange = "ROLE_CHANGE"
+	AuditActionDeactivate = "DEACTIVATE"
+	AuditActionActivate   = "ACTIVATE"
+)
+
+// Сущности журнала; совпадают с сегментом URL, как в записях до появления diff
+const (
+	AuditEntityPatient       = "patients"
+	AuditEntityChecklistItem = "checklists"
+	AuditEntitySurgery       = "surgeries"
+	AuditEntityUser          = "users"
+	AuditEntityAccessCode    = "access_code"
+	AuditEntityInvitation    = "invitations"
+)
+
+// AuditChange — значение поля до и после изменения
+type AuditChange struct {
+	Old interface{} `json:"old"`
+	New interface{} `json:"new"`
+}
+
+// AuditChanges — изменённые поля по ключам JSON
+type AuditChanges map[string]AuditChange
+
+func (c *AuditChanges) Scan(value interface{}) error {
+	if value == nil {
+		*c = nil
+		return nil
+	}
+	bytes, ok := value.([]byte)
+	if !ok {
+		return errors.New("failed to unmarshal JSONB value")
+	}
+	return json.Unmarshal(bytes, c)
+}
+
+func (c AuditChanges) Value() (driver.Value, error) {
+	if len(c) == 0 {
+		return nil, nil
+	}
+	return json.Marshal(c)
+}
+
+// Служебные поля, которые меняются при каждом сохранении и в diff не нужны
+var auditIgnoredFields = map[string]bool{
+	"created_at":   true,
+	"updated_at":   true,
+	"sync_version": true,
+}
+
+// Секреты, которые не пишутся в журнал даже частично
+var auditSecretFields = map[string]bool{
+	"access_code": true,
+}
+
+// AuditDiff сравнивает JSON-представления сущности до и после изменения.
+// before = nil — создание, after = nil — удаление. Значения ПДн и секретов
+// заменяются на RedactedValue: видно, что поле менялось, но не на что.
+func AuditDiff(before, after interface{}) (AuditChanges, error) {
+	oldFields, err := auditFields(before)
+	if err != nil {
+		return nil, err
+	}
+	newFields, err := auditFields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := AuditChanges{}
+	for _, fields := range []map[string]interface{}{oldFields, newFields} {
+		for key := range fields {
+			if _, done := changes[key]; done || auditIgnoredFields[key] || strings.HasSuffix(key, "_display") {
+				continue
+			}
+			oldValue, newValue := oldFields[key], newFields[key]
+			// Связанные записи (врач, район) могут быть не загружены в одном из снимков;
+			// их изменения пишутся в аудит своей сущности
+			if auditAssociation(oldValue) || auditAssociation(newValue) {
+				continue
+			}
+			if reflect.DeepEqual(oldValue, newValue) || (auditEmpty(oldValue) && auditEmpty(newValue)) {
+				continue
+			}
+			if PIIFields[key] || auditSecretFields[key] {
+				oldValue, newValue = auditRedact(oldValue), auditRedact(newValue)
+			}
+			changes[key] = AuditChange{Old: oldValue, New: newValue}
+		}
+	}
+	return changes, nil
+}
+
+func auditFields(v interface{}) (map[string]interface{}, error) {
+	fields := map[string]interface{}{}
+	if v == nil || (reflect.ValueOf(v).Kind() == reflect.Ptr && reflect.ValueOf(v).IsNil()) {
+		return fields, nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+func auditAssociation(v interface{}) bool {
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return false
+	}
+	_, hasID := m["id"]
+	return hasID
+}
+
+// auditEmpty — пустое значение: при создании и удалении такие поля в diff не попадают
+func auditEmpty(v interface{}) bool {
+	return v == nil || v == "" || v == float64(0) || v == false
+}
+
+func auditRedact(v interface{}) interface{} {
+	if auditEmpty(v) {
+		return v
+	}
+	return RedactedValue
 }
@@ -0,0 +1,55 @@
+package domain
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAuditDiff(t *testing.T) {
+	district := District{ID: 3, Name: "Центральный"}
+	tests := []struct {
+		name   string
+		before interface{}
+		after  interface{}
+		want   AuditChanges
+	}{
+		{
+			"update",
+			&Patient{ID: 1, FirstName: "Иван", Diagnosis: "H25", SyncVersion: 1},
+			&Patient{ID: 1, FirstName: "Иван", Diagnosis: "H26.0", SyncVersion: 2, StatusDisplay: "Черновик"},
+			AuditChanges{"diagnosis": {Old: "H25", New: "H26.0"}},
+		},
+		{
+			"pii redacted",
+			&Patient{ID: 1, SNILs: "112-233-445 95", AccessCode: "abc"},
+			&Patient{ID: 1, SNILs: "", AccessCode: "def"},
+			AuditChanges{"snils": {Old: RedactedValue, New: ""}, "access_code": {Old: RedactedValue, New: RedactedValue}},
+		},
+		{
+			"association skipped",
+			&Patient{ID: 1},
+			&Patient{ID: 1, District: &district},
+			AuditChanges{},
+		},
+		{
+			"create",
+			nil,
+			&ChecklistItem{ID: 5, Name: "ОАК", IsRequired: true},
+			AuditChanges{"id": {Old: nil, New: float64(5)}, "name": {Old: nil, New: "ОАК"}, "is_required": {Old: nil, New: true}},
+		},
+	}
+	for _, tt := range tests {
+		got, err := AuditDiff(tt.before, tt.after)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		for key, change := range tt.want {
+			if !reflect.DeepEqual(got[key], change) {
+				t.Errorf("%s: %s = %+v, want %+v", tt.name, key, got[key], change)
+			}
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: got %d changes %v, want %d", tt.name, len(got), got, len(tt.want))
+		}
+	}
+}
@@ -0,0 +1,392 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+type TimeOffType string
+
+const (
+	TimeOffDayOff    TimeOffType = "DAY_OFF"
+	TimeOffVacation  TimeOffType = "VACATION"
+	TimeOffSickLeave TimeOffType = "SICK_LEAVE"
+)
+
+// Рабочая неделя хирурга, если календарь не настроен: Пн–Пт 08:00–16:00
+const (
+	DefaultWorkdayStart       = "08:00"
+	DefaultWorkdayEnd         = "16:00"
+	DefaultSurgeonDailyLimit  = 6
+	DefaultSlotStepMinutes    = 15
+	MaxSlotSearchDays         = 31
+	defaultOperationDurationM = 60
+)
+
+// OperatingRoom — операционная
+type OperatingRoom struct {
+	ID          uint   `gorm:"primaryKey" json:"id"`
+	Name        string `gorm:"not null" json:"name"`
+	Description string `gorm:"type:text" json:"description"`
+	OpensAt     string `gorm:"type:varchar(5);default:'08:00';not null" json:"opens_at"`  // ЧЧ:ММ
+	ClosesAt    string `gorm:"type:varchar(5);default:'16:00';not null" json:"closes_at"` // ЧЧ:ММ
+	// Максимум операций в день, 0 — ограничено только временем работы
+	DailyCapacity int       `gorm:"default:0;not null" json:"daily_capacity"`
+	IsActive      bool      `gorm:"default:true;not null" json:"is_active"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// SurgeonWorkingHours — рабочие часы хирурга в день недели
+type SurgeonWorkingHours struct {
+	ID            uint      `gorm:"primaryKey" json:"id"`
+	SurgeonID     uint      `gorm:"index;not null" json:"surgeon_id"`
+	Weekday       int       `gorm:"not null" json:"weekday"`                    // 1 — понедельник, 7 — воскресенье
+	StartTime     string    `gorm:"type:varchar(5);not null" json:"start_time"` // ЧЧ:ММ
+	EndTime       string    `gorm:"type:varchar(5);not null" json:"end_time"`   // ЧЧ:ММ
+	MaxOperations int       `gorm:"default:0;not null" json:"max_operations"`   // 0 — DefaultSurgeonDailyLimit
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// SurgeonTimeOff — выходной, отпуск или больничный хирурга (даты включительно)
+type SurgeonTimeOff struct {
+	ID        uint        `gorm:"primaryKey" json:"id"`
+	SurgeonID uint        `gorm:"index;not null" json:"surgeon_id"`
+	Type      TimeOffType `gorm:"type:varchar(20);not null" json:"type"`
+	DateFrom  time.Time   `gorm:"type:date;not null" json:"date_from"`
+	DateTo    time.Time   `gorm:"type:date;not null" json:"date_to"`
+	Reason    string      `gorm:"type:text" json:"reason"`
+	CreatedBy uint        `json:"created_by"`
+	CreatedAt time.Time   `json:"created_at"`
+}
+
+// --- Requests ---
+
+type CreateOperatingRoomRequest struct {
+	Name          string `json:"name" binding:"required"`
+	Description   string `json:"description"`
+	OpensAt       string `json:"opens_at"`
+	ClosesAt      string `json:"closes_at"`
+	DailyCapacity int    `json:"daily_capacity"`
+}
+
+type UpdateOperatingRoomRequest struct {
+	Name          *string `json:"name"`
+	Description   *string `json:"description"`
+	OpensAt       *string `json:"opens_at"`
+	ClosesAt      *string `json:"closes_at"`
+	DailyCapacity *int    `json:"daily_capacity"`
+	IsActive      *bool   `json:"is_active"`
+}
+
+type WorkingHoursEntry struct {
+	Weekday       int    `json:"weekday" binding:"required,min=1,max=7"`
+	StartTime     string `json:"start_time" binding:"required"`
+	EndTime       string `json:"end_time" binding:"required"`
+	MaxOperations int    `json:"max_operations"`
+}
+
+type SetWorkingHoursRequest struct {
+	Hours []WorkingHoursEntry `json:"hours"`
+}
+
+type CreateTimeOffRequest struct {
+	Type     TimeOffType `json:"type" binding:"required"`
+	DateFrom string      `json:"date_from" binding:"required"` // ГГГГ-ММ-ДД
+	DateTo   string      `json:"date_to" binding:"required"`   // ГГГГ-ММ-ДД
+	Reason   string      `json:"reason"`
+}
+
+type SurgeonCalendarResponse struct {
+	SurgeonID uint                  `json:"surgeon_id"`
+	Hours     []SurgeonWorkingHours `json:"hours"`
+	TimeOff   []SurgeonTimeOff      `json:"time_off"`
+	// true — часы не настроены, действует рабочая неделя по умолчанию
+	DefaultHours bool `json:"default_hours"`
+}
+
+type AvailableSlot struct {
+	Start   time.Time `json:"start"`
+	End     time.Time `json:"end"`
+	RoomIDs []uint    `json:"room_ids,omitempty"`
+}
+
+type AvailableSlotsResponse struct {
+	SurgeonID       uint            `json:"surgeon_id"`
+	OperationType   OperationType   `json:"operation_type"`
+	DurationMinutes int             `json:"duration_minutes"`
+	Slots           []AvailableSlot `json:"slots"`
+}
+
+// OperationDuration возвращает ожидаемую длительность операции данного типа
+func OperationDuration(opType OperationType) time.Duration {
+	if t, ok := LookupOperationType(opType); ok {
+		return t.Duration()
+	}
+	return builtinOperationDuration(opType)
+}
+
+func builtinOperationDuration(opType OperationType) time.Duration {
+	switch opType {
+	case OperationPhacoemulsification:
+		return 30 * time.Minute
+	case OperationAntiglaucoma:
+		return 45 * time.Minute
+	case OperationVitrectomy:
+		return 90 * time.Minute
+	}
+	return defaultOperationDurationM * time.Minute
+}
+
+func ValidTimeOffType(t TimeOffType) bool {
+	switch t {
+	case TimeOffDayOff, TimeOffVacation, TimeOffSickLeave:
+		return true
+	}
+	return false
+}
+
+// ParseClock разбирает время ЧЧ:ММ в минуты от начала суток
+func ParseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("неверный формат времени %q, используйте ЧЧ:ММ", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// ValidateClockRange проверяет, что интервал ЧЧ:ММ–ЧЧ:ММ корректен и не пуст
+func ValidateClockRange(start, end string) error {
+	from, err := ParseClock(start)
+	if err != nil {
+		return err
+	}
+	to, err := ParseClock(end)
+	if err != nil {
+		return err
+	}
+	if to <= from {
+		return fmt.Errorf("время окончания %s должно быть позже начала %s", end, start)
+	}
+	return nil
+}
+
+// ISOWeekday возвращает день недели 1–7, где 1 — понедельник
+func ISOWeekday(t time.Time) int {
+	if t.Weekday() == time.Sunday {
+		return 7
+	}
+	return int(t.Weekday())
+}
+
+// SurgeonCalendar — данные календаря хирурга для проверки записи
+type SurgeonCalendar struct {
+	Hours     []SurgeonWorkingHours
+	TimeOff   []SurgeonTimeOff
+	Surgeries []Surgery // операции хирурга в проверяемом периоде
+}
+
+// RoomBookings — операционная и операции, уже назначенные в ней
+type RoomBookings struct {
+	Room      OperatingRoom
+	Surgeries []Surgery
+}
+
+// workingDay возвращает рабочие часы хирурга на дату: начало, конец и лимит операций
+func (c SurgeonCalendar) workingDay(day time.Time) (time.Time, time.Time, int, error) {
+	for _, off := range c.TimeOff {
+		if d := dayKey(day); d >= dayKey(off.DateFrom) && d <= dayKey(off.DateTo) {
+			return time.Time{}, time.Time{}, 0, fmt.Errorf("хирург недоступен %s: %s", day.Format("02.01.2006"), timeOffName(off.Type))
+		}
+	}
+
+	start, end, limit := "", "", 0
+	if len(c.Hours) == 0 {
+		if wd := ISOWeekday(day); wd <= 5 {
+			start, end = DefaultWorkdayStart, DefaultWorkdayEnd
+		}
+	} else {
+		for _, h := range c.Hours {
+			if h.Weekday == ISOWeekday(day) {
+				start, end, limit = h.StartTime, h.EndTime, h.MaxOperations
+				break
+			}
+		}
+	}
+	if start == "" {
+		return time.Time{}, time.Time{}, 0, fmt.Errorf("хирург не работает %s", day.Format("02.01.2006"))
+	}
+	if limit <= 0 {
+		limit = DefaultSurgeonDailyLimit
+	}
+
+	from, err := atClock(day, start)
+	if err != nil {
+		return time.Time{}, time.Time{}, 0, err
+	}
+	to, err := atClock(day, end)
+	if err != nil {
+		return time.Time{}, time.Time{}, 0, err
+	}
+	return from, to, limit, nil
+}
+
+// CheckBooking проверяет, можно ли провести операцию [start, start+duration) у хирурга
+// и в операционной room (nil — операционные не ведутся). Операция exceptID не учитывается,
+// что позволяет перенести уже назначенную операцию.
+func CheckBooking(cal SurgeonCalendar, room *RoomBookings, start time.Time, duration time.Duration, exceptID uint, now time.Time) error {
+	end := start.Add(duration)
+	if duration <= 0 {
+		return errors.New("неверная длительность операции")
+	}
+	if start.Before(now) {
+		return errors.New("нельзя запланировать операцию на прошедшее время")
+	}
+	if !sameDay(start, end.Add(-time.Nanosecond)) {
+		return errors.New("операция должна закончиться в тот же день")
+	}
+
+	workFrom, workTo, limit, err := cal.workingDay(start)
+	if err != nil {
+		return err
+	}
+	if start.Before(workFrom) || end.After(workTo) {
+		return fmt.Errorf("время вне рабочих часов хирурга (%s–%s)", workFrom.Format("15:04"), workTo.Format("15:04"))
+	}
+
+	count := 0
+	for _, s := range cal.Surgeries {
+		if !activeBooking(s, exceptID) || !sameDay(start, s.ScheduledDate) {
+			continue
+		}
+		count++
+		if overlaps(s, start, end) {
+			return fmt.Errorf("хирург уже занят в это время (операция #%d в %s)", s.ID, s.ScheduledDate.Format("15:04"))
+		}
+	}
+	if count >= limit {
+		return fmt.Errorf("превышен дневной лимит операций хирурга (%d)", limit)
+	}
+
+	if room == nil {
+		return nil
+	}
+	if !room.Room.IsActive {
+		return fmt.Errorf("операционная %q не используется", room.Room.Name)
+	}
+	roomFrom, err := atClock(start, room.Room.OpensAt)
+	if err != nil {
+		return err
+	}
+	roomTo, err := atClock(start, room.Room.ClosesAt)
+	if err != nil {
+		return err
+	}
+	if start.Before(roomFrom) || end.After(roomTo) {
+		return fmt.Errorf("операционная %q работает с %s до %s", room.Room.Name, room.Room.OpensAt, room.Room.ClosesAt)
+	}
+
+	count = 0
+	for _, s := range room.Surgeries {
+		if !activeBooking(s, exceptID) || !sameDay(start, s.ScheduledDate) {
+			continue
+		}
+		count++
+		if overlaps(s, start, end) {
+			return fmt.Errorf("операционная %q занята в это время (операция #%d в %s)", room.Room.Name, s.ID, s.ScheduledDate.Format("15:04"))
+		}
+	}
+	if room.Room.DailyCapacity > 0 && count >= room.Room.DailyCapacity {
+		return fmt.Errorf("операционная %q заполнена на этот день (%d операций)", room.Room.Name, room.Room.DailyCapacity)
+	}
+	return nil
+}
+
+// FindSlots перебирает время начала с шагом step в днях [from, to] и возвращает
+// свободные слоты. Если операционные заданы, слот включает все свободные операционные.
+// Операция exceptID не учитывается (перенос на другое время).
+func FindSlots(cal SurgeonCalendar, rooms []RoomBookings, from, to time.Time, duration, step time.Duration, exceptID uint, now time.Time) []AvailableSlot {
+	slots := []AvailableSlot{}
+	if step <= 0 {
+		step = DefaultSlotStepMinutes * time.Minute
+	}
+
+	for day := dateOnly(from); !day.After(dateOnly(to)); day = day.AddDate(0, 0, 1) {
+		workFrom, workTo, _, err := cal.workingDay(day)
+		if err != nil {
+			continue
+		}
+		for start := workFrom; !start.Add(duration).After(workTo); start = start.Add(step) {
+			if len(rooms) == 0 {
+				if CheckBooking(cal, nil, start, duration, exceptID, now) == nil {
+					slots = append(slots, AvailableSlot{Start: start, End: start.Add(duration)})
+				}
+				continue
+			}
+
+			var free []uint
+			for i := range rooms {
+				if CheckBooking(cal, &rooms[i], start, duration, exceptID, now) == nil {
+					free = append(free, rooms[i].Room.ID)
+				}
+			}
+			if len(free) > 0 {
+				slots = append(slots, AvailableSlot{Start: start, End: start.Add(duration), RoomIDs: free})
+			}
+		}
+	}
+	return slots
+}
+
+// SurgeryEnd возвращает время окончания операции; для операций без длительности — по типу
+func SurgeryEnd(s Surgery) time.Time {
+	if s.DurationMinutes > 0 {
+		return s.ScheduledDate.Add(time.Duration(s.DurationMinutes) * time.Minute)
+	}
+	return s.ScheduledDate.Add(OperationDuration(s.OperationType))
+}
+
+func activeBooking(s Surgery, exceptID uint) bool {
+	return s.ID != exceptID && s.Status != SurgeryStatusCancelled
+}
+
+func overlaps(s Surgery, start, end time.Time) bool {
+	return s.ScheduledDate.Before(end) && SurgeryEnd(s).After(start)
+}
+
+func atClock(day time.Time, clock string) (time.Time, error) {
+	minutes, err := ParseClock(clock)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return dateOnly(day).Add(time.Duration(minutes) * time.Minute), nil
+}
+
+func dateOnly(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
+
+// dayKey — дата ГГГГММДД в собственном часовом поясе значения
+func dayKey(t time.Time) int {
+	y, m, d := t.Date()
+	return y*10000 + int(m)*100 + d
+}
+
+// sameDay сравнивает даты в часовом поясе a
+func sameDay(a, b time.Time) bool {
+	ay, am, ad := a.Date()
+	by, bm, bd := b.In(a.Location()).Date()
+	return ay == by && am == bm && ad == bd
+}
+
+func timeOffName(t TimeOffType) string {
+	switch t {
+	case TimeOffVacation:
+		return "отпуск"
+	case TimeOffSickLeave:
+		return "больничный"
+	}
+	return "выходной"
+}
@@ -0,0 +1,137 @@
+package domain
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOperationDuration(t *testing.T) {
+	tests := []struct {
+		opType OperationType
+		want   time.Duration
+	}{
+		{OperationPhacoemulsification, 30 * time.Minute},
+		{OperationAntiglaucoma, 45 * time.Minute},
+		{OperationVitrectomy, 90 * time.Minute},
+		{OperationType("OTHER"), 60 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.opType), func(t *testing.T) {
+			if got := OperationDuration(tt.opType); got != tt.want {
+				t.Errorf("OperationDuration() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckBooking(t *testing.T) {
+	loc := time.FixedZone("MSK", 3*60*60)
+	now := time.Date(2026, 3, 1, 0, 0, 0, 0, loc)
+	// 02.03.2026 — понедельник
+	at := func(day, hour, minute int) time.Time { return time.Date(2026, 3, day, hour, minute, 0, 0, loc) }
+	surgery := func(id uint, start time.Time, minutes int) Surgery {
+		return Surgery{ID: id, ScheduledDate: start, DurationMinutes: minutes, Status: SurgeryStatusScheduled}
+	}
+
+	room := RoomBookings{Room: OperatingRoom{ID: 1, Name: "Операционная 1", OpensAt: "09:00", ClosesAt: "15:00", IsActive: true}}
+	busyRoom := RoomBookings{Room: room.Room, Surgeries: []Surgery{surgery(10, at(2, 10, 0), 60)}}
+	fullRoom := RoomBookings{Room: OperatingRoom{ID: 2, Name: "Малая", OpensAt: "08:00", ClosesAt: "16:00", DailyCapacity: 1, IsActive: true},
+		Surgeries: []Surgery{surgery(11, at(2, 8, 0), 30)}}
+	closedRoom := RoomBookings{Room: OperatingRoom{ID: 3, Name: "Резерв", OpensAt: "08:00", ClosesAt: "16:00"}}
+
+	tests := []struct {
+		name    string
+		cal     SurgeonCalendar
+		room    *RoomBookings
+		start   time.Time
+		except  uint
+		wantErr string
+	}{
+		{"default workday", SurgeonCalendar{}, nil, at(2, 9, 0), 0, ""},
+		{"in the past", SurgeonCalendar{}, nil, at(1, 9, 0).AddDate(0, 0, -1), 0, "прошедшее время"},
+		{"weekend by default", SurgeonCalendar{}, nil, at(7, 9, 0), 0, "не работает"},
+		{"before workday", SurgeonCalendar{}, nil, at(2, 7, 30), 0, "вне рабочих часов"},
+		{"ends after workday", SurgeonCalendar{}, nil, at(2, 15, 30), 0, "вне рабочих часов"},
+		{"custom saturday", SurgeonCalendar{Hours: []SurgeonWorkingHours{{Weekday: 6, StartTime: "10:00", EndTime: "14:00"}}}, nil, at(7, 10, 0), 0, ""},
+		{"custom hours replace default", SurgeonCalendar{Hours: []SurgeonWorkingHours{{Weekday: 6, StartTime: "10:00", EndTime: "14:00"}}}, nil, at(2, 9, 0), 0, "не работает"},
+		{"vacation", SurgeonCalendar{TimeOff: []SurgeonTimeOff{{Type: TimeOffVacation, DateFrom: time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC), DateTo: time.Date(2026, 3, 6, 0, 0, 0, 0, time.UTC)}}}, nil, at(4, 9, 0), 0, "отпуск"},
+		{"after vacation", SurgeonCalendar{TimeOff: []SurgeonTimeOff{{Type: TimeOffVacation, DateFrom: time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC), DateTo: time.Date(2026, 3, 6, 0, 0, 0, 0, time.UTC)}}}, nil, at(9, 9, 0), 0, ""},
+		{"surgeon overlap", SurgeonCalendar{Surgeries: []Surgery{surgery(5, at(2, 9, 30), 60)}}, nil, at(2, 10, 0), 0, "хирург уже занят"},
+		{"back to back", SurgeonCalendar{Surgeries: []Surgery{surgery(5, at(2, 9, 0), 60)}}, nil, at(2, 10, 0), 0, ""},
+		{"reschedule itself", SurgeonCalendar{Surgeries: []Surgery{surgery(5, at(2, 9, 30), 60)}}, nil, at(2, 10, 0), 5, ""},
+		{"cancelled ignored", SurgeonCalendar{Surgeries: []Surgery{{ID: 5, ScheduledDate: at(2, 10, 0), DurationMinutes: 60, Status: SurgeryStatusCancelled}}}, nil, at(2, 10, 0), 0, ""},
+		{"daily limit", SurgeonCalendar{Hours: []SurgeonWorkingHours{{Weekday: 1, StartTime: "08:00", EndTime: "16:00", MaxOperations: 1}}, Surgeries: []Surgery{surgery(5, at(2, 8, 0), 30)}}, nil, at(2, 12, 0), 0, "дневной лимит"},
+		{"room free", SurgeonCalendar{}, &room, at(2, 9, 0), 0, ""},
+		{"room hours", SurgeonCalendar{}, &room, at(2, 8, 0), 0, "работает с 09:00"},
+		{"room overlap", SurgeonCalendar{}, &busyRoom, at(2, 10, 30), 0, "занята"},
+		{"room capacity", SurgeonCalendar{}, &fullRoom, at(2, 12, 0), 0, "заполнена"},
+		{"room inactive", SurgeonCalendar{}, &closedRoom, at(2, 9, 0), 0, "не используется"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckBooking(tt.cal, tt.room, tt.start, 60*time.Minute, tt.except, now)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("CheckBooking() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("CheckBooking() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFindSlots(t *testing.T) {
+	loc := time.FixedZone("MSK", 3*60*60)
+	now := time.Date(2026, 3, 1, 0, 0, 0, 0, loc)
+	monday := time.Date(2026, 3, 2, 0, 0, 0, 0, loc)
+	at := func(hour, minute int) time.Time { return time.Date(2026, 3, 2, hour, minute, 0, 0, loc) }
+
+	cal := SurgeonCalendar{
+		Hours:     []SurgeonWorkingHours{{Weekday: 1, StartTime: "09:00", EndTime: "12:00"}},
+		Surgeries: []Surgery{{ID: 1, ScheduledDate: at(10, 0), DurationMinutes: 60, Status: SurgeryStatusScheduled}},
+	}
+
+	t.Run("without rooms", func(t *testing.T) {
+		slots := FindSlots(cal, nil, monday, monday.AddDate(0, 0, 6), time.Hour, 30*time.Minute, 0, now)
+		want := []time.Time{at(9, 0), at(11, 0)}
+		if len(slots) != len(want) {
+			t.Fatalf("FindSlots() = %v, want starts %v", slots, want)
+		}
+		for i, s := range slots {
+			if !s.Start.Equal(want[i]) || !s.End.Equal(want[i].Add(time.Hour)) || len(s.RoomIDs) != 0 {
+				t.Errorf("slot %d = %+v, want start %v", i, s, want[i])
+			}
+		}
+	})
+
+	t.Run("with rooms", func(t *testing.T) {
+		rooms := []RoomBookings{
+			{Room: OperatingRoom{ID: 1, Name: "A", OpensAt: "08:00", ClosesAt: "16:00", IsActive: true},
+				Surgeries: []Surgery{{ID: 2, ScheduledDate: at(9, 0), DurationMinutes: 60, Status: SurgeryStatusScheduled}}},
+			{Room: OperatingRoom{ID: 2, Name: "B", OpensAt: "08:00", ClosesAt: "16:00", IsActive: true}},
+		}
+		slots := FindSlots(cal, rooms, monday, monday, time.Hour, time.Hour, 0, now)
+		if len(slots) != 2 {
+			t.Fatalf("FindSlots() = %+v, want 2 slots", slots)
+		}
+		if len(slots[0].RoomIDs) != 1 || slots[0].RoomIDs[0] != 2 {
+			t.Errorf("09:00 rooms = %v, want [2]", slots[0].RoomIDs)
+		}
+		if len(slots[1].RoomIDs) != 2 {
+			t.Errorf("11:00 rooms = %v, want [1 2]", slots[1].RoomIDs)
+		}
+	})
+
+	t.Run("skips past time", func(t *testing.T) {
+		slots := FindSlots(cal, nil, monday, monday, time.Hour, 30*time.Minute, 0, at(10, 30))
+		if len(slots) != 1 || !slots[0].Start.Equal(at(11, 0)) {
+			t.Fatalf("FindSlots() = %+v, want single 11:00 slot", slots)
+		}
+	})
+}
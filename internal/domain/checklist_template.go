@@ -0,0 +1,450 @@
+package domain
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ChecklistTemplate — версия шаблона чек-листа для типа операции.
+// Активна не более одной версии на тип операции; по ней генерируются чек-листы новых пациентов.
+type ChecklistTemplate struct {
+	ID            uint                    `gorm:"primaryKey" json:"id"`
+	OperationType OperationType           `gorm:"type:varchar(30);not null;uniqueIndex:idx_checklist_template_version" json:"operation_type"`
+	Version       int                     `gorm:"not null;default:1;uniqueIndex:idx_checklist_template_version" json:"version"`
+	Name          string                  `gorm:"not null" json:"name"`
+	Description   string                  `gorm:"type:text" json:"description"`
+	IsActive      bool                    `gorm:"default:false;index" json:"is_active"`
+	CreatedBy     *uint                   `json:"created_by"`
+	Items         []ChecklistTemplateItem `gorm:"foreignKey:TemplateID;constraint:OnDelete:CASCADE" json:"items,omitempty"`
+	CreatedAt     time.Time               `json:"created_at"`
+	UpdatedAt     time.Time               `json:"updated_at"`
+}
+
+// ChecklistTemplateItem — пункт шаблона с условиями включения и обязательности
+type ChecklistTemplateItem struct {
+	ID            uint   `gorm:"primaryKey" json:"id"`
+	TemplateID    uint   `gorm:"index;not null" json:"template_id"`
+	Name          string `gorm:"not null" json:"name"`
+	Description   string `gorm:"type:text" json:"description"`
+	Category      string `gorm:"type:varchar(50)" json:"category"`
+	IsRequired    bool   `json:"is_required"`
+	ExpiresInDays int    `json:"expires_in_days"`
+	SortOrder     int    `json:"sort_order"`
+	// Пункт попадает в чек-лист, только если выполнены все условия; пусто — всегда
+	IncludeWhen ChecklistConditions `gorm:"type:jsonb" json:"include_when"`
+	// Пункт обязателен, если выполнены все условия; пусто — по IsRequired
+	RequiredWhen ChecklistConditions `gorm:"type:jsonb" json:"required_when"`
+}
+
+// --- Conditions ---
+
+// Поля пациента, доступные в условиях
+const (
+	ConditionFieldDiagnosis     = "diagnosis"      // текст диагноза и коды/названия МКБ-10
+	ConditionFieldDiagnosisCode = "diagnosis_code" // коды МКБ-10 из медицинских метаданных
+	ConditionFieldAge           = "age"            // полных лет на дату генерации
+	ConditionFieldGender        = "gender"
+	ConditionFieldEye           = "eye"
+)
+
+// Операторы условий
+const (
+	ConditionOpContains    = "contains"
+	ConditionOpNotContains = "not_contains"
+	ConditionOpEquals      = "equals"
+	ConditionOpPrefix      = "prefix"
+	ConditionOpGTE         = "gte"
+	ConditionOpLTE         = "lte"
+	ConditionOpGT          = "gt"
+	ConditionOpLT          = "lt"
+)
+
+// ChecklistCondition — условие на данные пациента. Для строковых операторов
+// достаточно совпадения с любым из значений; числовые берут первое значение.
+type ChecklistCondition struct {
+	Field  string   `json:"field"`
+	Op     string   `json:"op"`
+	Values []string `json:"values"`
+}
+
+// ChecklistConditions — набор условий, объединённых через И
+type ChecklistConditions []ChecklistCondition
+
+func (c *ChecklistConditions) Scan(value interface{}) error {
+	if value == nil {
+		*c = nil
+		return nil
+	}
+
+	bytes, ok := value.([]byte)
+	if !ok {
+		return errors.New("failed to unmarshal JSONB value")
+	}
+
+	return json.Unmarshal(bytes, c)
+}
+
+func (c ChecklistConditions) Value() (driver.Value, error) {
+	if len(c) == 0 {
+		return nil, nil
+	}
+	return json.Marshal(c)
+}
+
+// Validate проверяет поля, операторы и значения условий
+func (c ChecklistConditions) Validate() error {
+	for _, cond := range c {
+		if len(cond.Values) == 0 {
+			return fmt.Errorf("условие по полю %q без значений", cond.Field)
+		}
+		switch cond.Field {
+		case ConditionFieldAge:
+			switch cond.Op {
+			case ConditionOpEquals, ConditionOpGTE, ConditionOpLTE, ConditionOpGT, ConditionOpLT:
+			default:
+				return fmt.Errorf("оператор %q недопустим для поля age", cond.Op)
+			}
+			if _, err := strconv.Atoi(cond.Values[0]); err != nil {
+				return fmt.Errorf("возраст в условии должен быть целым числом: %q", cond.Values[0])
+			}
+		case ConditionFieldDiagnosis, ConditionFieldDiagnosisCode, ConditionFieldGender, ConditionFieldEye:
+			switch cond.Op {
+			case ConditionOpContains, ConditionOpNotContains, ConditionOpEquals, ConditionOpPrefix:
+			default:
+				return fmt.Errorf("оператор %q недопустим для поля %s", cond.Op, cond.Field)
+			}
+		default:
+			return fmt.Errorf("неизвестное поле условия: %q", cond.Field)
+		}
+	}
+	return nil
+}
+
+// ChecklistFacts — данные пациента, по которым вычисляются условия шаблона
+type ChecklistFacts struct {
+	Diagnosis      string
+	DiagnosisCodes []string
+	Age            *int
+	Gender         string
+	Eye            string
+}
+
+// ChecklistFactsFor собирает данные пациента на момент now. Без даты рождения возраст неизвестен,
+// и возрастные условия не выполняются.
+func ChecklistFactsFor(p *Patient, now time.Time) ChecklistFacts {
+	facts := ChecklistFacts{
+		Diagnosis: p.Diagnosis,
+		Gender:    p.Gender,
+		Eye:       p.Eye,
+	}
+	if p.MedicalMetadata != nil {
+		for _, code := range p.MedicalMetadata.DiagnosisCodes {
+			facts.DiagnosisCodes = append(facts.DiagnosisCodes, code.Code)
+			facts.Diagnosis += " " + code.Code + " " + code.Display
+		}
+	}
+	if !p.DateOfBirth.IsZero() {
+		age := now.Year() - p.DateOfBirth.Year()
+		if now.Month() < p.DateOfBirth.Month() || (now.Month() == p.DateOfBirth.Month() && now.Day() < p.DateOfBirth.Day()) {
+			age--
+		}
+		facts.Age = &age
+	}
+	return facts
+}
+
+// Matches сообщает, выполнены ли все условия для пациента
+func (c ChecklistConditions) Matches(f ChecklistFacts) bool {
+	for _, cond := range c {
+		if !cond.matches(f) {
+			return false
+		}
+	}
+	return true
+}
+
+func (cond ChecklistCondition) matches(f ChecklistFacts) bool {
+	switch cond.Field {
+	case ConditionFieldAge:
+		if f.Age == nil || len(cond.Values) == 0 {
+			return false
+		}
+		limit, err := strconv.Atoi(cond.Values[0])
+		if err != nil {
+			return false
+		}
+		switch cond.Op {
+		case ConditionOpEquals:
+			return *f.Age == limit
+		case ConditionOpGTE:
+			return *f.Age >= limit
+		case ConditionOpLTE:
+			return *f.Age <= limit
+		case ConditionOpGT:
+			return *f.Age > limit
+		case ConditionOpLT:
+			return *f.Age < limit
+		}
+		return false
+	case ConditionFieldDiagnosis:
+		return matchText(cond.Op, []string{f.Diagnosis}, cond.Values)
+	case ConditionFieldDiagnosisCode:
+		return matchText(cond.Op, f.DiagnosisCodes, cond.Values)
+	case ConditionFieldGender:
+		return matchText(cond.Op, []string{f.Gender}, cond.Values)
+	case ConditionFieldEye:
+		return matchText(cond.Op, []string{f.Eye}, cond.Values)
+	}
+	return false
+}
+
+// matchText сравнивает без учёта регистра; условие выполнено, если совпал любой из образцов
+func matchText(op string, subjects, patterns []string) bool {
+	if op == ConditionOpNotContains {
+		return !matchText(ConditionOpContains, subjects, patterns)
+	}
+	for _, s := range subjects {
+		s = strings.ToLower(strings.TrimSpace(s))
+		for _, p := range patterns {
+			p = strings.ToLower(strings.TrimSpace(p))
+			switch op {
+			case ConditionOpContains:
+				if p != "" && strings.Contains(s, p) {
+					return true
+				}
+			case ConditionOpEquals:
+				if s == p {
+					return true
+				}
+			case ConditionOpPrefix:
+				if p != "" && strings.HasPrefix(s, p) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// --- Generation ---
+
+// BuildItems формирует пункты чек-листа пациента по шаблону с учётом условий
+func (t *ChecklistTemplate) BuildItems(patient *Patient, now time.Time) []ChecklistItem {
+	facts := ChecklistFactsFor(patient, now)
+
+	templateItems := make([]ChecklistTemplateItem, len(t.Items))
+	copy(templateItems, t.Items)
+	sort.SliceStable(templateItems, func(i, j int) bool { return templateItems[i].SortOrder < templateItems[j].SortOrder })
+
+	var items []ChecklistItem
+	for _, ti := range templateItems {
+		if !ti.IncludeWhen.Matches(facts) {
+			continue
+		}
+		item := ChecklistItem{
+			PatientID:   patient.ID,
+			Name:        ti.Name,
+			Description: ti.Description,
+			Category:    ti.Category,
+			IsRequired:  ti.requiredFor(facts),
+			Status:      ChecklistStatusPending,
+		}
+		if t.ID != 0 {
+			templateID := t.ID
+			item.TemplateID = &templateID
+		}
+		if ti.ID != 0 {
+			itemID := ti.ID
+			item.TemplateItemID = &itemID
+		}
+		if ti.ExpiresInDays > 0 {
+			exp := now.AddDate(0, 0, ti.ExpiresInDays)
+			item.ExpiresAt = &exp
+		}
+		items = append(items, item)
+	}
+	return items
+}
+
+func (ti ChecklistTemplateItem) requiredFor(f ChecklistFacts) bool {
+	if len(ti.RequiredWhen) > 0 {
+		return ti.RequiredWhen.Matches(f)
+	}
+	return ti.IsRequired
+}
+
+// ChecklistUpgradePlan — изменения чек-листа при переходе на другую версию шаблона
+type ChecklistUpgradePlan struct {
+	Create []ChecklistItem
+	Update []ChecklistItem
+	Delete []ChecklistItem
+}
+
+// PlanChecklistUpgrade сопоставляет текущие пункты с пунктами новой версии по названию.
+// Совпавшие пункты сохраняют статус, результат и файлы; недостающие добавляются.
+// Пункты прежних шаблонов, которых нет в новой версии, удаляются, если по ним ещё ничего
+// не сделано, иначе становятся необязательными. Пункты, добавленные вручную, не меняются.
+func PlanChecklistUpgrade(existing []ChecklistItem, target []ChecklistItem) ChecklistUpgradePlan {
+	var plan ChecklistUpgradePlan
+
+	byName := make(map[string]int, len(existing))
+	for i, item := range existing {
+		key := checklistItemKey(item.Name)
+		if _, ok := byName[key]; !ok {
+			byName[key] = i
+		}
+	}
+
+	matched := make(map[int]bool, len(existing))
+	for _, t := range target {
+		i, ok := byName[checklistItemKey(t.Name)]
+		if !ok || matched[i] {
+			plan.Create = append(plan.Create, t)
+			continue
+		}
+		matched[i] = true
+
+		item := existing[i]
+		if sameUint(item.TemplateID, t.TemplateID) && sameUint(item.TemplateItemID, t.TemplateItemID) &&
+			item.IsRequired == t.IsRequired && item.Description == t.Description && item.Category == t.Category {
+			continue
+		}
+		item.TemplateID = t.TemplateID
+		item.TemplateItemID = t.TemplateItemID
+		item.IsRequired = t.IsRequired
+		item.Description = t.Description
+		item.Category = t.Category
+		item.Template = nil
+		plan.Update = append(plan.Update, item)
+	}
+
+	for i, item := range existing {
+		if matched[i] || item.TemplateID == nil {
+			continue
+		}
+		if item.Status == ChecklistStatusPending && item.Result == "" && item.MediaID == nil {
+			plan.Delete = append(plan.Delete, item)
+			continue
+		}
+		if item.IsRequired {
+			item.IsRequired = false
+			item.Template = nil
+			plan.Update = append(plan.Update, item)
+		}
+	}
+
+	return plan
+}
+
+func checklistItemKey(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
+
+func sameUint(a, b *uint) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+// --- Defaults ---
+
+// DefaultChecklistTemplate — первая версия шаблона для типа операции. Используется
+// для начального заполнения БД и если активного шаблона нет.
+func DefaultChecklistTemplate(opType OperationType) *ChecklistTemplate {
+	diabetes := ChecklistConditions{
+		{Field: ConditionFieldDiagnosis, Op: ConditionOpContains, Values: []string{"диабет", "E10", "E11", "E13", "E14"}},
+	}
+
+	items := []ChecklistTemplateItem{
+		{Name: "Общий анализ крови", Description: "Клинический анализ крови", Category: "Анализы", IsRequired: true, ExpiresInDays: 14, SortOrder: 1},
+		{Name: "Общий анализ мочи", Description: "Общий анализ мочи", Category: "Анализы", IsRequired: true, ExpiresInDays: 14, SortOrder: 2},
+		{Name: "Биохимический анализ крови", Description: "Глюкоза, АЛТ, АСТ, билирубин, креатинин, мочевина", Category: "Анализы", IsRequired: true, ExpiresInDays: 14, SortOrder: 3},
+		{Name: "Коагулограмма", Description: "МНО, АЧТВ, фибриноген", Category: "Анализы", IsRequired: true, ExpiresInDays: 14, SortOrder: 4},
+		{Name: "Анализ на ВИЧ", Description: "Anti-HIV 1/2", Category: "Анализы", IsRequired: true, ExpiresInDays: 90, SortOrder: 5},
+		{Name: "Анализ на гепатит B", Description: "HBsAg", Category: "Анализы", IsRequired: true, ExpiresInDays: 90, SortOrder: 6},
+		{Name: "Анализ на гепатит C", Description: "Anti-HCV", Category: "Анализы", IsRequired: true, ExpiresInDays: 90, SortOrder: 7},
+		{Name: "Анализ на сифилис", Description: "RW", Category: "Анализы", IsRequired: true, ExpiresInDays: 90, SortOrder: 8},
+		{Name: "ЭКГ", Description: "Электрокардиограмма с заключением", Category: "Обследования", IsRequired: true, ExpiresInDays: 14, SortOrder: 9},
+		{Name: "Флюорография", Description: "Или рентген грудной клетки", Category: "Обследования", IsRequired: true, ExpiresInDays: 365, SortOrder: 10},
+		{Name: "Заключение терапевта", Description: "Заключение терапевта об отсутствии противопоказаний", Category: "Заключения", IsRequired: true, ExpiresInDays: 14, SortOrder: 11},
+		{Name: "Заключение эндокринолога", Description: "При наличии сахарного диабета", Category: "Заключения", ExpiresInDays: 30, SortOrder: 12, RequiredWhen: diabetes},
+	}
+
+	switch opType {
+	case OperationPhacoemulsification:
+		items = append(items,
+			ChecklistTemplateItem{Name: "Биометрия глаза (IOL Master / A-scan)", Description: "Биометрические данные для расчёта ИОЛ", Category: "Офтальмология", IsRequired: true, ExpiresInDays: 90, SortOrder: 13},
+			ChecklistTemplateItem{Name: "Кератометрия", Description: "Данные кератометрии", Category: "Офтальмология", IsRequired: true, ExpiresInDays: 90, SortOrder: 14},
+			ChecklistTemplateItem{Name: "OCT макулярной зоны", Description: "Оптическая когерентная томография", Category: "Офтальмология", IsRequired: false, ExpiresInDays: 90, SortOrder: 15},
+		)
+	case OperationAntiglaucoma:
+		items = append(items,
+			ChecklistTemplateItem{Name: "Периметрия", Description: "Поля зрения", Category: "Офтальмология", IsRequired: true, ExpiresInDays: 30, SortOrder: 13},
+			ChecklistTemplateItem{Name: "Тонометрия", Description: "Измерение ВГД в динамике", Category: "Офтальмология", IsRequired: true, ExpiresInDays: 14, SortOrder: 14},
+			ChecklistTemplateItem{Name: "OCT диска зрительного нерва", Description: "OCT ДЗН", Category: "Офтальмология", IsRequired: true, ExpiresInDays: 90, SortOrder: 15},
+			ChecklistTemplateItem{Name: "Гониоскопия", Description: "Исследование угла передней камеры", Category: "Офтальмология", IsRequired: true, ExpiresInDays: 90, SortOrder: 16},
+		)
+	case OperationVitrectomy:
+		items = append(items,
+			ChecklistTemplateItem{Name: "B-скан", Description: "УЗИ глазного яблока", Category: "Офтальмология", IsRequired: true, ExpiresInDays: 30, SortOrder: 13},
+			ChecklistTemplateItem{Name: "OCT макулярной зоны", Description: "Оптическая когерентная томография", Category: "Офтальмология", IsRequired: true, ExpiresInDays: 30, SortOrder: 14},
+			ChecklistTemplateItem{Name: "ЭФИ", Description: "Электрофизиологическое исследование", Category: "Офтальмология", IsRequired: false, ExpiresInDays: 90, SortOrder: 15},
+		)
+	}
+
+	return &ChecklistTemplate{
+		OperationType: opType,
+		Version:       1,
+		Name:          "Базовый шаблон",
+		Description:   "Стандартный перечень обследований перед операцией",
+		Items:         items,
+	}
+}
+
+// --- Requests ---
+
+type ChecklistTemplateItemInput struct {
+	Name          string              `json:"name" binding:"required"`
+	Description   string              `json:"description"`
+	Category      string              `json:"category"`
+	IsRequired    bool                `json:"is_required"`
+	ExpiresInDays int                 `json:"expires_in_days" binding:"min=0"`
+	SortOrder     int                 `json:"sort_order"`
+	IncludeWhen   ChecklistConditions `json:"include_when"`
+	RequiredWhen  ChecklistConditions `json:"required_when"`
+}
+
+type CreateChecklistTemplateRequest struct {
+	OperationType OperationType                `json:"operation_type" binding:"required"`
+	Name          string                       `json:"name" binding:"required"`
+	Description   string                       `json:"description"`
+	Items         []ChecklistTemplateItemInput `json:"items" binding:"required,min=1,dive"`
+	Activate      bool                         `json:"activate"`
+}
+
+type UpdateChecklistTemplateRequest struct {
+	Name        *string                       `json:"name"`
+	Description *string                       `json:"description"`
+	Items       *[]ChecklistTemplateItemInput `json:"items"`
+}
+
+type UpgradeChecklistRequest struct {
+	// Версия шаблона; не указана — активная версия для типа операции пациента
+	TemplateID *uint `json:"template_id"`
+}
+
+type ChecklistUpgradeResult struct {
+	TemplateID uint            `json:"template_id"`
+	Version    int             `json:"version"`
+	Added      int             `json:"added"`
+	Updated    int             `json:"updated"`
+	Removed    int             `json:"removed"`
+	Items      []ChecklistItem `json:"items"`
+}
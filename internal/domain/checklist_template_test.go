@@ -0,0 +1,186 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestChecklistConditionsMatch(t *testing.T) {
+	now := time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC)
+	diabetic := &Patient{Diagnosis: "Катаракта, сахарный диабет 2 типа", DateOfBirth: time.Date(1950, 3, 11, 0, 0, 0, 0, time.UTC), Gender: "female", Eye: "OD"}
+	coded := &Patient{Diagnosis: "Катаракта", MedicalMetadata: &MedicalStandardsMetadata{DiagnosisCodes: []ICD10Code{{Code: "E11.9", Display: "Инсулиннезависимый сахарный диабет"}}}}
+	plain := &Patient{Diagnosis: "Катаракта", DateOfBirth: time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC)}
+
+	tests := []struct {
+		name    string
+		patient *Patient
+		cond    ChecklistCondition
+		want    bool
+	}{
+		{"diagnosis text", diabetic, ChecklistCondition{ConditionFieldDiagnosis, ConditionOpContains, []string{"диабет"}}, true},
+		{"diagnosis case insensitive", diabetic, ChecklistCondition{ConditionFieldDiagnosis, ConditionOpContains, []string{"ДИАБЕТ"}}, true},
+		{"diagnosis from icd code", coded, ChecklistCondition{ConditionFieldDiagnosis, ConditionOpContains, []string{"E11"}}, true},
+		{"diagnosis absent", plain, ChecklistCondition{ConditionFieldDiagnosis, ConditionOpContains, []string{"диабет", "E11"}}, false},
+		{"not contains", plain, ChecklistCondition{ConditionFieldDiagnosis, ConditionOpNotContains, []string{"диабет"}}, true},
+		{"code prefix", coded, ChecklistCondition{ConditionFieldDiagnosisCode, ConditionOpPrefix, []string{"E10", "E11"}}, true},
+		{"code prefix no codes", plain, ChecklistCondition{ConditionFieldDiagnosisCode, ConditionOpPrefix, []string{"E11"}}, false},
+		{"age before birthday", diabetic, ChecklistCondition{ConditionFieldAge, ConditionOpGTE, []string{"76"}}, false},
+		{"age gte", diabetic, ChecklistCondition{ConditionFieldAge, ConditionOpGTE, []string{"75"}}, true},
+		{"age lt", plain, ChecklistCondition{ConditionFieldAge, ConditionOpLT, []string{"60"}}, true},
+		{"age unknown", coded, ChecklistCondition{ConditionFieldAge, ConditionOpLT, []string{"60"}}, false},
+		{"gender", diabetic, ChecklistCondition{ConditionFieldGender, ConditionOpEquals, []string{"Female"}}, true},
+		{"eye", diabetic, ChecklistCondition{ConditionFieldEye, ConditionOpEquals, []string{"OS", "OU"}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ChecklistConditions{tt.cond}.Matches(ChecklistFactsFor(tt.patient, now))
+			if got != tt.want {
+				t.Errorf("Matches = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChecklistConditionsValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		conds   ChecklistConditions
+		wantErr bool
+	}{
+		{"empty", nil, false},
+		{"valid", ChecklistConditions{{ConditionFieldAge, ConditionOpGTE, []string{"60"}}, {ConditionFieldDiagnosis, ConditionOpContains, []string{"диабет"}}}, false},
+		{"unknown field", ChecklistConditions{{"weight", ConditionOpGTE, []string{"80"}}}, true},
+		{"text op on age", ChecklistConditions{{ConditionFieldAge, ConditionOpContains, []string{"6"}}}, true},
+		{"numeric op on text", ChecklistConditions{{ConditionFieldDiagnosis, ConditionOpGT, []string{"1"}}}, true},
+		{"age not a number", ChecklistConditions{{ConditionFieldAge, ConditionOpGTE, []string{"шестьдесят"}}}, true},
+		{"no values", ChecklistConditions{{ConditionFieldEye, ConditionOpEquals, nil}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.conds.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDefaultChecklistTemplateEndocrinologist(t *testing.T) {
+	now := time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC)
+	tmpl := DefaultChecklistTemplate(OperationPhacoemulsification)
+
+	tests := []struct {
+		name      string
+		diagnosis string
+		want      bool
+	}{
+		{"diabetes", "Катаракта. Сахарный диабет 2 типа", true},
+		{"icd code", "H25.1, E11.9", true},
+		{"no diabetes", "Возрастная катаракта", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			items := tmpl.BuildItems(&Patient{ID: 1, Diagnosis: tt.diagnosis}, now)
+			if len(items) != 15 {
+				t.Fatalf("items = %d, want 15", len(items))
+			}
+			for _, item := range items {
+				if item.Name == "Заключение эндокринолога" {
+					if item.IsRequired != tt.want {
+						t.Errorf("IsRequired = %v, want %v", item.IsRequired, tt.want)
+					}
+					return
+				}
+			}
+			t.Error("endocrinologist item missing")
+		})
+	}
+}
+
+func TestChecklistTemplateBuildItems(t *testing.T) {
+	now := time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC)
+	tmpl := &ChecklistTemplate{
+		ID:      4,
+		Version: 2,
+		Items: []ChecklistTemplateItem{
+			{ID: 12, Name: "Консультация кардиолога", IsRequired: true, SortOrder: 2,
+				IncludeWhen: ChecklistConditions{{ConditionFieldAge, ConditionOpGTE, []string{"65"}}}},
+			{ID: 11, Name: "ЭКГ", IsRequired: true, ExpiresInDays: 14, SortOrder: 1},
+		},
+	}
+
+	young := tmpl.BuildItems(&Patient{ID: 7, DateOfBirth: time.Date(1980, 5, 1, 0, 0, 0, 0, time.UTC)}, now)
+	if len(young) != 1 || young[0].Name != "ЭКГ" {
+		t.Fatalf("young patient items = %+v, want only ЭКГ", young)
+	}
+	item := young[0]
+	if item.PatientID != 7 || item.Status != ChecklistStatusPending {
+		t.Errorf("item = %+v", item)
+	}
+	if item.TemplateID == nil || *item.TemplateID != 4 || item.TemplateItemID == nil || *item.TemplateItemID != 11 {
+		t.Errorf("template refs = %v/%v, want 4/11", item.TemplateID, item.TemplateItemID)
+	}
+	if item.ExpiresAt == nil || !item.ExpiresAt.Equal(now.AddDate(0, 0, 14)) {
+		t.Errorf("ExpiresAt = %v", item.ExpiresAt)
+	}
+
+	old := tmpl.BuildItems(&Patient{ID: 8, DateOfBirth: time.Date(1950, 5, 1, 0, 0, 0, 0, time.UTC)}, now)
+	if len(old) != 2 || old[0].Name != "ЭКГ" || old[1].Name != "Консультация кардиолога" {
+		t.Fatalf("elderly patient items = %+v", old)
+	}
+
+	// Встроенный шаблон без ID не ссылается на записи БД
+	for _, item := range DefaultChecklistTemplate(OperationVitrectomy).BuildItems(&Patient{ID: 1}, now) {
+		if item.TemplateID != nil || item.TemplateItemID != nil {
+			t.Fatalf("default template item has refs: %+v", item)
+		}
+	}
+}
+
+func TestPlanChecklistUpgrade(t *testing.T) {
+	oldTemplate, newTemplate := uint(1), uint(2)
+	mediaID := uint(9)
+	ref := func(v uint) *uint { return &v }
+
+	existing := []ChecklistItem{
+		{ID: 1, Name: "ЭКГ", TemplateID: &oldTemplate, TemplateItemID: ref(10), IsRequired: true, Status: ChecklistStatusCompleted, Result: "норма"},
+		{ID: 2, Name: "Флюорография", TemplateID: &oldTemplate, TemplateItemID: ref(11), IsRequired: true, Status: ChecklistStatusPending},
+		{ID: 3, Name: "Анализ на сифилис", TemplateID: &oldTemplate, TemplateItemID: ref(12), IsRequired: true, Status: ChecklistStatusPending, MediaID: &mediaID},
+		{ID: 4, Name: "Справка от стоматолога", IsRequired: true, Status: ChecklistStatusPending},
+		{ID: 5, Name: "Гониоскопия", TemplateID: &newTemplate, TemplateItemID: ref(22), IsRequired: false, Status: ChecklistStatusPending},
+	}
+	target := []ChecklistItem{
+		{Name: " экг ", TemplateID: &newTemplate, TemplateItemID: ref(20), IsRequired: true, Status: ChecklistStatusPending},
+		{Name: "Консультация кардиолога", TemplateID: &newTemplate, TemplateItemID: ref(21), IsRequired: true, Status: ChecklistStatusPending},
+		{Name: "Гониоскопия", TemplateID: &newTemplate, TemplateItemID: ref(22), IsRequired: false, Status: ChecklistStatusPending},
+	}
+
+	plan := PlanChecklistUpgrade(existing, target)
+
+	if len(plan.Create) != 1 || plan.Create[0].Name != "Консультация кардиолога" {
+		t.Errorf("Create = %+v", plan.Create)
+	}
+	if len(plan.Delete) != 1 || plan.Delete[0].ID != 2 {
+		t.Errorf("Delete = %+v, want item 2", plan.Delete)
+	}
+
+	updated := map[uint]ChecklistItem{}
+	for _, item := range plan.Update {
+		updated[item.ID] = item
+	}
+	if len(updated) != 2 {
+		t.Fatalf("Update = %+v, want items 1 and 3", plan.Update)
+	}
+	ecg := updated[1]
+	if ecg.Status != ChecklistStatusCompleted || ecg.Result != "норма" || *ecg.TemplateID != newTemplate || *ecg.TemplateItemID != 20 {
+		t.Errorf("matched item lost progress or refs: %+v", ecg)
+	}
+	if syphilis := updated[3]; syphilis.IsRequired {
+		t.Errorf("item with attached file should become optional: %+v", syphilis)
+	}
+	if _, ok := updated[4]; ok {
+		t.Error("manual item must not be touched")
+	}
+}
@@ -3,16 +3,17 @@ package domain
 import "time"
 
 type Comment struct {
-	ID        uint      `gorm:"primaryKey" json:"id"`
-	PatientID uint      `gorm:"index;not null" json:"patient_id"`
-	AuthorID  uint      `gorm:"not null" json:"author_id"`
-	Author    *User     `gorm:"foreignKey:AuthorID" json:"author,omitempty"`
-	ParentID  *uint     `gorm:"index" json:"parent_id"`
-	Body      string    `gorm:"type:text;not null" json:"body"`
-	IsUrgent  bool      `gorm:"default:false" json:"is_urgent"`
-	IsRead    bool      `gorm:"default:false" json:"is_read"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	PatientID   uint      `gorm:"index;not null" json:"patient_id"`
+	AuthorID    uint      `gorm:"not null" json:"author_id"`
+	Author      *User     `gorm:"foreignKey:AuthorID" json:"author,omitempty"`
+	ParentID    *uint     `gorm:"index" json:"parent_id"`
+	Body        string    `gorm:"type:text;not null" json:"body"`
+	IsUrgent    bool      `gorm:"default:false" json:"is_urgent"`
+	IsRead      bool      `gorm:"default:false" json:"is_read"`
+	SyncVersion int64     `gorm:"index;not null;default:0" json:"sync_version"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
 }
 
 type CreateCommentRequest struct {
@@ -21,3 +22,8 @@ type CreateCommentRequest struct {
 	Body      string `json:"body" binding:"required"`
 	IsUrgent  bool   `json:"is_urgent"`
 }
+
+type UpdateCommentRequest struct {
+	Body     *string `json:"body"`
+	IsUrgent *bool   `json:"is_urgent"`
+}
@@ -0,0 +1,162 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+// ConsentType — на что пациент даёт согласие
+type ConsentType string
+
+const (
+	ConsentPersonalData ConsentType = "PERSONAL_DATA"          // обработка ПДн (152-ФЗ, ст. 9)
+	ConsentTelegram     ConsentType = "TELEGRAM_NOTIFICATIONS" // уведомления в Telegram
+	ConsentEMIAS        ConsentType = "EMIAS_TRANSFER"         // передача данных в ЕМИАС
+	ConsentRIAMS        ConsentType = "RIAMS_TRANSFER"         // передача данных в РИАМС
+)
+
+// ConsentChannel — как получено согласие или отзыв
+type ConsentChannel string
+
+const (
+	ConsentChannelPaper    ConsentChannel = "PAPER_SCAN" // скан подписанного бланка, вносит сотрудник
+	ConsentChannelPortal   ConsentChannel = "PORTAL"     // отметка в личном кабинете пациента
+	ConsentChannelTelegram ConsentChannel = "TELEGRAM"   // команда в Telegram-боте
+)
+
+// ConsentText — действующая редакция текста согласия
+type ConsentText struct {
+	Type    ConsentType `json:"type"`
+	Version string      `json:"version"`
+	Title   string      `json:"title"`
+	Text    string      `json:"text"`
+}
+
+// ConsentTexts — действующие редакции; при изменении текста повышается версия,
+// и ранее данные согласия продолжают действовать в своей редакции
+var ConsentTexts = []ConsentText{
+	{
+		Type:    ConsentPersonalData,
+		Version: "1.0",
+		Title:   "Согласие на обработку персональных данных",
+		Text: "Даю согласие клинике на обработку моих персональных данных, включая сведения о состоянии здоровья, " +
+			"в целях подготовки к операции и лечения. Согласие действует до его отзыва.",
+	},
+	{
+		Type:    ConsentTelegram,
+		Version: "1.0",
+		Title:   "Согласие на уведомления в Telegram",
+		Text: "Согласен получать в Telegram уведомления о статусе подготовки, чек-листе и дате операции. " +
+			"Уведомления содержат ФИО и сведения о лечении.",
+	},
+	{
+		Type:    ConsentEMIAS,
+		Version: "1.0",
+		Title:   "Согласие на передачу данных в ЕМИАС",
+		Text:    "Согласен на передачу моих персональных и медицинских данных в Единую медицинскую информационно-аналитическую систему.",
+	},
+	{
+		Type:    ConsentRIAMS,
+		Version: "1.0",
+		Title:   "Согласие на передачу данных в РИАМС",
+		Text:    "Согласен на передачу моих персональных и медицинских данных в региональную медицинскую информационную систему.",
+	},
+}
+
+// CurrentConsentText — действующая редакция для типа согласия
+func CurrentConsentText(t ConsentType) (ConsentText, bool) {
+	for _, text := range ConsentTexts {
+		if text.Type == t {
+			return text, true
+		}
+	}
+	return ConsentText{}, false
+}
+
+// Согласия, без которых действие запрещено
+var (
+	ConsentsForTelegram = []ConsentType{ConsentPersonalData, ConsentTelegram}
+	ConsentsForEMIAS    = []ConsentType{ConsentPersonalData, ConsentEMIAS}
+	ConsentsForRIAMS    = []ConsentType{ConsentPersonalData, ConsentRIAMS}
+)
+
+// Consent — согласие пациента. Не удаляется: отзыв фиксируется в RevokedAt.
+type Consent struct {
+	ID          uint           `gorm:"primaryKey" json:"id"`
+	PatientID   uint           `gorm:"index;not null" json:"patient_id"`
+	Type        ConsentType    `gorm:"type:varchar(30);not null;index" json:"type"`
+	TextVersion string         `gorm:"type:varchar(20);not null" json:"text_version"`
+	Channel     ConsentChannel `gorm:"type:varchar(20);not null" json:"channel"`
+	SignedAt    time.Time      `gorm:"not null" json:"signed_at"`
+	// Скан бланка для PAPER_SCAN
+	MediaID *uint `json:"media_id,omitempty"`
+	// Сотрудник, внёсший согласие; пусто, если пациент дал его сам
+	RecordedBy *uint `json:"recorded_by,omitempty"`
+
+	RevokedAt     *time.Time     `gorm:"index" json:"revoked_at,omitempty"`
+	RevokeChannel ConsentChannel `gorm:"type:varchar(20)" json:"revoke_channel,omitempty"`
+	RevokedBy     *uint          `json:"revoked_by,omitempty"`
+	RevokeReason  string         `gorm:"type:text" json:"revoke_reason,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (c *Consent) Active() bool {
+	return c.RevokedAt == nil
+}
+
+// Revoke отзывает согласие; повторный отзыв — ошибка
+func (c *Consent) Revoke(channel ConsentChannel, by *uint, reason string, at time.Time) error {
+	if !c.Active() {
+		return errors.New("согласие уже отозвано")
+	}
+	c.RevokedAt = &at
+	c.RevokeChannel = channel
+	c.RevokedBy = by
+	c.RevokeReason = reason
+	return nil
+}
+
+// MissingConsents — какие из требуемых согласий не действуют
+func MissingConsents(active []Consent, required []ConsentType) []ConsentType {
+	var missing []ConsentType
+	for _, t := range required {
+		found := false
+		for i := range active {
+			if active[i].Type == t && active[i].Active() {
+				found = true
+				break
+			}
+		}
+		if !found {
+			missing = append(missing, t)
+		}
+	}
+	return missing
+}
+
+func ValidConsentType(t ConsentType) bool {
+	_, ok := CurrentConsentText(t)
+	return ok
+}
+
+// CaptureConsentRequest — согласие из личного кабинета или скан бланка от сотрудника.
+// Канал определяется ролью: пациент — PORTAL, сотрудник — PAPER_SCAN.
+type CaptureConsentRequest struct {
+	Type ConsentType `json:"type" binding:"required"`
+	// Версия текста, который видел пациент; для бланка — версия на бланке
+	TextVersion string     `json:"text_version"`
+	MediaID     *uint      `json:"media_id"`
+	SignedAt    *time.Time `json:"signed_at"` // дата подписи бланка; по умолчанию — сейчас
+}
+
+type RevokeConsentRequest struct {
+	Reason string `json:"reason"`
+}
+
+// PatientConsents — история согласий пациента (новые первыми) и типы без действующего согласия
+type PatientConsents struct {
+	Consents []Consent     `json:"consents"`
+	Missing  []ConsentType `json:"missing"`
+}
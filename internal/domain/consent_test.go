@@ -0,0 +1,52 @@
+package domain
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMissingConsents(t *testing.T) {
+	revokedAt := time.Now()
+	tests := []struct {
+		name     string
+		active   []Consent
+		required []ConsentType
+		want     []ConsentType
+	}{
+		{"none", nil, ConsentsForTelegram, []ConsentType{ConsentPersonalData, ConsentTelegram}},
+		{"all", []Consent{{Type: ConsentPersonalData}, {Type: ConsentTelegram}}, ConsentsForTelegram, nil},
+		{"partial", []Consent{{Type: ConsentPersonalData}}, ConsentsForEMIAS, []ConsentType{ConsentEMIAS}},
+		{"revoked", []Consent{{Type: ConsentPersonalData, RevokedAt: &revokedAt}, {Type: ConsentRIAMS}}, ConsentsForRIAMS, []ConsentType{ConsentPersonalData}},
+	}
+	for _, tt := range tests {
+		if got := MissingConsents(tt.active, tt.required); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: MissingConsents = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestConsentRevoke(t *testing.T) {
+	c := Consent{Type: ConsentTelegram}
+	by := uint(7)
+	if err := c.Revoke(ConsentChannelPaper, &by, "заявление", time.Now()); err != nil {
+		t.Fatalf("first revoke: %v", err)
+	}
+	if c.Active() || c.RevokeChannel != ConsentChannelPaper || c.RevokedBy == nil || *c.RevokedBy != by {
+		t.Errorf("unexpected consent after revoke: %+v", c)
+	}
+	if err := c.Revoke(ConsentChannelPortal, nil, "", time.Now()); err == nil {
+		t.Error("second revoke: expected error")
+	}
+}
+
+func TestCurrentConsentText(t *testing.T) {
+	for _, ct := range []ConsentType{ConsentPersonalData, ConsentTelegram, ConsentEMIAS, ConsentRIAMS} {
+		if text, ok := CurrentConsentText(ct); !ok || text.Version == "" || text.Title == "" {
+			t.Errorf("CurrentConsentText(%s) = %+v, %v", ct, text, ok)
+		}
+	}
+	if _, ok := CurrentConsentText("UNKNOWN"); ok {
+		t.Error("CurrentConsentText(UNKNOWN): expected false")
+	}
+}
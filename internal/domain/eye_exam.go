@@ -0,0 +1,198 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// IOPMethod — метод тонометрии
+type IOPMethod string
+
+const (
+	IOPMethodNonContact IOPMethod = "NON_CONTACT" // пневмотонометрия
+	IOPMethodGoldmann   IOPMethod = "GOLDMANN"    // аппланационная по Гольдману
+	IOPMethodMaklakov   IOPMethod = "MAKLAKOV"    // по Маклакову, грузом 10 г
+	IOPMethodRebound    IOPMethod = "REBOUND"     // iCare
+	IOPMethodPalpation  IOPMethod = "PALPATION"
+)
+
+func ValidIOPMethod(m IOPMethod) bool {
+	switch m {
+	case IOPMethodNonContact, IOPMethodGoldmann, IOPMethodMaklakov, IOPMethodRebound, IOPMethodPalpation:
+		return true
+	}
+	return false
+}
+
+// LOCSGrade — помутнение хрусталика по LOCS III: ядерная опалесценция (NO)
+// и окраска ядра (NC) 0.1–6.9, кортикальная (C) и задняя субкапсулярная (P) 0.1–5.9
+type LOCSGrade struct {
+	NO *float64 `json:"no,omitempty"`
+	NC *float64 `json:"nc,omitempty"`
+	C  *float64 `json:"c,omitempty"`
+	P  *float64 `json:"p,omitempty"`
+}
+
+func (g LOCSGrade) IsZero() bool {
+	return g.NO == nil && g.NC == nil && g.C == nil && g.P == nil
+}
+
+func (g LOCSGrade) String() string {
+	s := ""
+	for _, v := range []struct {
+		name  string
+		value *float64
+	}{{"NO", g.NO}, {"NC", g.NC}, {"C", g.C}, {"P", g.P}} {
+		if v.value == nil {
+			continue
+		}
+		if s != "" {
+			s += " "
+		}
+		s += fmt.Sprintf("%s%.1f", v.name, *v.value)
+	}
+	return s
+}
+
+// EyeExam — офтальмологический осмотр одного глаза за визит
+type EyeExam struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	PatientID uint      `gorm:"not null;uniqueIndex:idx_eye_exams_visit" json:"patient_id"`
+	Eye       string    `gorm:"type:varchar(5);not null;uniqueIndex:idx_eye_exams_visit" json:"eye"`
+	ExamDate  time.Time `gorm:"type:date;not null;uniqueIndex:idx_eye_exams_visit" json:"exam_date"`
+	// Острота зрения без коррекции и с коррекцией, десятичная
+	UCVA *float64 `json:"ucva,omitempty"`
+	BCVA *float64 `json:"bcva,omitempty"`
+	// Рефракция: сфера и цилиндр в D, ось в градусах
+	Sphere   *float64 `json:"sphere,omitempty"`
+	Cylinder *float64 `json:"cylinder,omitempty"`
+	Axis     *float64 `json:"axis,omitempty"`
+	// IOP — ВГД, мм рт. ст.
+	IOP       *float64  `json:"iop,omitempty"`
+	IOPMethod IOPMethod `gorm:"type:varchar(20)" json:"iop_method,omitempty"`
+	// Биомикроскопия переднего отрезка и осмотр глазного дна
+	Biomicroscopy string    `gorm:"type:text" json:"biomicroscopy"`
+	Fundus        string    `gorm:"type:text" json:"fundus"`
+	LOCS          LOCSGrade `gorm:"embedded;embeddedPrefix:locs_" json:"locs"`
+	Notes         string    `gorm:"type:text" json:"notes"`
+	ExaminedBy    uint      `json:"examined_by"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// SphericalEquivalent — сферический эквивалент рефракции; false — рефракция не записана
+func (e *EyeExam) SphericalEquivalent() (float64, bool) {
+	if e.Sphere == nil {
+		return 0, false
+	}
+	cyl := 0.0
+	if e.Cylinder != nil {
+		cyl = *e.Cylinder
+	}
+	return SphericalEquivalentOf(*e.Sphere, cyl), true
+}
+
+// Validate проверяет диапазоны значений осмотра
+func (e *EyeExam) Validate() error {
+	if e.Eye != "OD" && e.Eye != "OS" {
+		return errors.New("глаз должен быть OD или OS")
+	}
+	checks := []struct {
+		value    *float64
+		min, max float64
+		msg      string
+	}{
+		{e.UCVA, 0, 2, "острота зрения должна быть в диапазоне 0-2.0"},
+		{e.BCVA, 0, 2, "острота зрения должна быть в диапазоне 0-2.0"},
+		{e.Sphere, -30, 30, "сфера должна быть в диапазоне ±30 D"},
+		{e.Cylinder, -10, 10, "цилиндр должен быть в диапазоне ±10 D"},
+		{e.Axis, 0, 180, "ось должна быть в диапазоне 0-180°"},
+		{e.IOP, 0, 80, "ВГД должно быть в диапазоне 0-80 мм рт. ст."},
+		{e.LOCS.NO, 0.1, 6.9, "LOCS III NO должно быть в диапазоне 0.1-6.9"},
+		{e.LOCS.NC, 0.1, 6.9, "LOCS III NC должно быть в диапазоне 0.1-6.9"},
+		{e.LOCS.C, 0.1, 5.9, "LOCS III C должно быть в диапазоне 0.1-5.9"},
+		{e.LOCS.P, 0.1, 5.9, "LOCS III P должно быть в диапазоне 0.1-5.9"},
+	}
+	for _, c := range checks {
+		if c.value != nil && (*c.value < c.min || *c.value > c.max) {
+			return errors.New(c.msg)
+		}
+	}
+	if (e.Cylinder != nil || e.Axis != nil) && e.Sphere == nil {
+		return errors.New("цилиндр и ось указываются вместе со сферой")
+	}
+	if e.IOPMethod != "" && !ValidIOPMethod(e.IOPMethod) {
+		return fmt.Errorf("неизвестный метод тонометрии %s", e.IOPMethod)
+	}
+	if e.IOP != nil && e.IOPMethod == "" {
+		return errors.New("укажите метод тонометрии")
+	}
+	return nil
+}
+
+// Показатели осмотра, кодируемые LOINC
+const (
+	ExamVisualAcuity = "VA"
+	ExamRefraction   = "REFRACTION"
+	ExamIOP          = "IOP"
+)
+
+var examLOINC = map[string]map[string]LOINCCode{
+	"OD": {
+		ExamVisualAcuity: {Code: "79905-6", Display: "Острота зрения правого глаза"},
+		ExamRefraction:   {Code: "79907-2", Display: "Рефракция правого глаза", Unit: "D"},
+		ExamIOP:          {Code: "79909-8", Display: "Внутриглазное давление правого глаза", Unit: "mm[Hg]"},
+	},
+	"OS": {
+		ExamVisualAcuity: {Code: "79906-4", Display: "Острота зрения левого глаза"},
+		ExamRefraction:   {Code: "79908-0", Display: "Рефракция левого глаза", Unit: "D"},
+		ExamIOP:          {Code: "79910-6", Display: "Внутриглазное давление левого глаза", Unit: "mm[Hg]"},
+	},
+}
+
+// ExamLOINC возвращает LOINC-код показателя осмотра для глаза
+func ExamLOINC(eye, measurement string) (LOINCCode, bool) {
+	code, ok := examLOINC[NormalizeEye(eye)][measurement]
+	if !ok {
+		return LOINCCode{}, false
+	}
+	code.System = CodeSystemLOINC
+	return code, true
+}
+
+// --- Requests ---
+
+type CreateEyeExamRequest struct {
+	PatientID uint   `json:"patient_id" binding:"required"`
+	Eye       string `json:"eye" binding:"required"`
+	// ГГГГ-ММ-ДД; по умолчанию сегодня
+	ExamDate      string    `json:"exam_date"`
+	UCVA          *float64  `json:"ucva"`
+	BCVA          *float64  `json:"bcva"`
+	Sphere        *float64  `json:"sphere"`
+	Cylinder      *float64  `json:"cylinder"`
+	Axis          *float64  `json:"axis"`
+	IOP           *float64  `json:"iop"`
+	IOPMethod     IOPMethod `json:"iop_method"`
+	Biomicroscopy string    `json:"biomicroscopy"`
+	Fundus        string    `json:"fundus"`
+	LOCS          LOCSGrade `json:"locs"`
+	Notes         string    `json:"notes"`
+}
+
+// UpdateEyeExamRequest — частичное обновление; LOCS заменяется целиком
+type UpdateEyeExamRequest struct {
+	ExamDate      *string    `json:"exam_date"`
+	UCVA          *float64   `json:"ucva"`
+	BCVA          *float64   `json:"bcva"`
+	Sphere        *float64   `json:"sphere"`
+	Cylinder      *float64   `json:"cylinder"`
+	Axis          *float64   `json:"axis"`
+	IOP           *float64   `json:"iop"`
+	IOPMethod     *IOPMethod `json:"iop_method"`
+	Biomicroscopy *string    `json:"biomicroscopy"`
+	Fundus        *string    `json:"fundus"`
+	LOCS          *LOCSGrade `json:"locs"`
+	Notes         *string    `json:"notes"`
+}
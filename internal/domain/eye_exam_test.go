@@ -0,0 +1,85 @@
+package domain
+
+import "testing"
+
+func floatPtr(v float64) *float64 { return &v }
+
+func TestEyeExamValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		exam    EyeExam
+		wantErr bool
+	}{
+		{"empty exam", EyeExam{Eye: "OD"}, false},
+		{"full exam", EyeExam{
+			Eye: "OS", UCVA: floatPtr(0.3), BCVA: floatPtr(0.8),
+			Sphere: floatPtr(-1.5), Cylinder: floatPtr(-0.75), Axis: floatPtr(90),
+			IOP: floatPtr(18), IOPMethod: IOPMethodMaklakov,
+			LOCS: LOCSGrade{NO: floatPtr(3.2), NC: floatPtr(3.0), C: floatPtr(1.5), P: floatPtr(0.1)},
+		}, false},
+		{"both eyes", EyeExam{Eye: "OU"}, true},
+		{"acuity above 2.0", EyeExam{Eye: "OD", BCVA: floatPtr(2.5)}, true},
+		{"cylinder without sphere", EyeExam{Eye: "OD", Cylinder: floatPtr(-1)}, true},
+		{"axis out of range", EyeExam{Eye: "OD", Sphere: floatPtr(0), Axis: floatPtr(190)}, true},
+		{"iop without method", EyeExam{Eye: "OD", IOP: floatPtr(21)}, true},
+		{"unknown iop method", EyeExam{Eye: "OD", IOP: floatPtr(21), IOPMethod: "FINGER"}, true},
+		{"locs nuclear above scale", EyeExam{Eye: "OD", LOCS: LOCSGrade{NO: floatPtr(7)}}, true},
+		{"locs cortical above scale", EyeExam{Eye: "OD", LOCS: LOCSGrade{C: floatPtr(6.0)}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.exam.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestEyeExamSphericalEquivalent(t *testing.T) {
+	if _, ok := (&EyeExam{}).SphericalEquivalent(); ok {
+		t.Error("expected no spherical equivalent without refraction")
+	}
+	if se, ok := (&EyeExam{Sphere: floatPtr(-1.0)}).SphericalEquivalent(); !ok || se != -1.0 {
+		t.Errorf("sphere only: %v, %v", se, ok)
+	}
+	if se, _ := (&EyeExam{Sphere: floatPtr(1.0), Cylinder: floatPtr(-1.5)}).SphericalEquivalent(); se != 0.25 {
+		t.Errorf("sphere and cylinder: %v, want 0.25", se)
+	}
+}
+
+func TestLOCSGradeString(t *testing.T) {
+	tests := []struct {
+		grade LOCSGrade
+		want  string
+	}{
+		{LOCSGrade{}, ""},
+		{LOCSGrade{NO: floatPtr(3.2), NC: floatPtr(3)}, "NO3.2 NC3.0"},
+		{LOCSGrade{C: floatPtr(1.5), P: floatPtr(0.5)}, "C1.5 P0.5"},
+	}
+	for _, tt := range tests {
+		if got := tt.grade.String(); got != tt.want {
+			t.Errorf("String() = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestExamLOINC(t *testing.T) {
+	tests := []struct {
+		eye, measurement, want string
+	}{
+		{"OD", ExamVisualAcuity, "79905-6"},
+		{"LEFT", ExamIOP, "79910-6"},
+		{"OS", ExamRefraction, "79908-0"},
+	}
+	for _, tt := range tests {
+		code, ok := ExamLOINC(tt.eye, tt.measurement)
+		if !ok || code.Code != tt.want || code.System != CodeSystemLOINC {
+			t.Errorf("ExamLOINC(%s, %s) = %+v, want %s", tt.eye, tt.measurement, code, tt.want)
+		}
+	}
+	if _, ok := ExamLOINC("OU", ExamIOP); ok {
+		t.Error("expected no code for both eyes")
+	}
+}
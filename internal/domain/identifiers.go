@@ -0,0 +1,164 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// FieldErrors — ошибки проверки по полям запроса: имя поля в JSON → сообщение
+type FieldErrors map[string]string
+
+func (e FieldErrors) Error() string {
+	fields := make([]string, 0, len(e))
+	for field := range e {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	parts := make([]string, 0, len(fields))
+	for _, field := range fields {
+		parts = append(parts, field+": "+e[field])
+	}
+	return strings.Join(parts, "; ")
+}
+
+// Номера СНИЛС до 001-001-998 выдавались без контрольной суммы
+const snilsChecksumFrom = "001001998"
+
+// NormalizeSNILS проверяет СНИЛС (11 цифр и контрольное число) и приводит
+// к виду XXX-XXX-XXX YY. Пробелы, дефисы и другие разделители игнорируются.
+func NormalizeSNILS(s string) (string, error) {
+	digits, err := onlyDigits(s, 11)
+	if err != nil {
+		return "", err
+	}
+
+	if digits[:9] > snilsChecksumFrom {
+		sum := 0
+		for i := 0; i < 9; i++ {
+			sum += int(digits[i]-'0') * (9 - i)
+		}
+		check := sum % 101
+		if check == 100 {
+			check = 0
+		}
+		if fmt.Sprintf("%02d", check) != digits[9:] {
+			return "", errors.New("неверное контрольное число СНИЛС")
+		}
+	}
+
+	return digits[0:3] + "-" + digits[3:6] + "-" + digits[6:9] + " " + digits[9:], nil
+}
+
+// NormalizeOMSPolicy проверяет единый номер полиса ОМС: 16 цифр, последняя —
+// контрольная по алгоритму ФФОМС (совпадает с алгоритмом Луна)
+func NormalizeOMSPolicy(s string) (string, error) {
+	digits, err := onlyDigits(s, 16)
+	if err != nil {
+		return "", err
+	}
+
+	sum := 0
+	for i := 0; i < 15; i++ {
+		d := int(digits[14-i] - '0')
+		// Нечётные позиции справа (без контрольной цифры) удваиваются
+		if i%2 == 0 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+	}
+	if check := (10 - sum%10) % 10; int(digits[15]-'0') != check {
+		return "", errors.New("неверная контрольная цифра полиса ОМС")
+	}
+	return digits, nil
+}
+
+// NormalizePassportSeries — серия паспорта РФ: 4 цифры (две — код региона, две — год бланка)
+func NormalizePassportSeries(s string) (string, error) {
+	digits, err := onlyDigits(s, 4)
+	if err != nil {
+		return "", err
+	}
+	if digits[:2] == "00" {
+		return "", errors.New("неверный код региона в серии паспорта")
+	}
+	return digits, nil
+}
+
+// NormalizePassportNumber — номер паспорта РФ: 6 цифр
+func NormalizePassportNumber(s string) (string, error) {
+	digits, err := onlyDigits(s, 6)
+	if err != nil {
+		return "", err
+	}
+	if digits == "000000" {
+		return "", errors.New("неверный номер паспорта")
+	}
+	return digits, nil
+}
+
+// onlyDigits убирает пробелы и дефисы и проверяет, что осталось ровно n цифр
+func onlyDigits(s string, n int) (string, error) {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r >= '0' && r <= '9':
+			b.WriteRune(r)
+		case r == ' ' || r == '-' || r == '\t':
+		default:
+			return "", errors.New("допустимы только цифры, пробелы и дефисы")
+		}
+	}
+	if b.Len() != n {
+		return "", fmt.Errorf("должно быть %d цифр", n)
+	}
+	return b.String(), nil
+}
+
+// normalizeField приводит значение поля к каноническому виду; пустое значение не проверяется
+func normalizeField(errs FieldErrors, field string, value *string, normalize func(string) (string, error)) {
+	if value == nil || strings.TrimSpace(*value) == "" {
+		if value != nil {
+			*value = ""
+		}
+		return
+	}
+	normalized, err := normalize(*value)
+	if err != nil {
+		errs[field] = err.Error()
+		return
+	}
+	*value = normalized
+}
+
+// NormalizeIdentifiers проверяет и нормализует СНИЛС, полис и паспорт в запросе на создание
+func (r *CreatePatientRequest) NormalizeIdentifiers() error {
+	errs := FieldErrors{}
+	normalizeField(errs, "snils", &r.SNILs, NormalizeSNILS)
+	normalizeField(errs, "oms_policy", &r.OMSPolicy, NormalizeOMSPolicy)
+	normalizeField(errs, "policy_number", &r.PolicyNumber, NormalizeOMSPolicy)
+	normalizeField(errs, "passport_series", &r.PassportSeries, NormalizePassportSeries)
+	normalizeField(errs, "passport_number", &r.PassportNumber, NormalizePassportNumber)
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// NormalizeIdentifiers проверяет и нормализует переданные в запросе на изменение документы
+func (r *UpdatePatientRequest) NormalizeIdentifiers() error {
+	errs := FieldErrors{}
+	normalizeField(errs, "snils", r.SNILs, NormalizeSNILS)
+	normalizeField(errs, "policy_number", r.PolicyNumber, NormalizeOMSPolicy)
+	normalizeField(errs, "passport_series", r.PassportSeries, NormalizePassportSeries)
+	normalizeField(errs, "passport_number", r.PassportNumber, NormalizePassportNumber)
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package domain
+
+import "testing"
+
+func TestNormalizeSNILS(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{"112-233-445 95", "112-233-445 95", false},
+		{"11223344595", "112-233-445 95", false},
+		{"123 456 789 64", "123-456-789 64", false},
+		{"087-654-303 00", "087-654-303 00", false}, // сумма 101 — контрольное число 00
+		{"001-001-998 45", "001-001-998 45", false}, // старые номера без контрольной суммы
+		{"112-233-445 96", "", true},
+		{"112-233-445", "", true},
+		{"112-233-445 95 1", "", true},
+		{"112.233.445 95", "", true},
+		{"СНИЛС 11223344595", "", true},
+	}
+	for _, tt := range tests {
+		got, err := NormalizeSNILS(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("NormalizeSNILS(%q) = %q, %v; want %q, error %v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestNormalizeOMSPolicy(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{"1234567890123452", "1234567890123452", false},
+		{"1234 5678 9012 3452", "1234567890123452", false},
+		{"7700000000000008", "7700000000000008", false},
+		{"1234567890123456", "", true},
+		{"123456789012345", "", true},
+		{"ЕП 1234567890123452", "", true},
+	}
+	for _, tt := range tests {
+		got, err := NormalizeOMSPolicy(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("NormalizeOMSPolicy(%q) = %q, %v; want %q, error %v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestNormalizePassport(t *testing.T) {
+	series := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{"45 10", "4510", false},
+		{"4510", "4510", false},
+		{"0010", "", true},
+		{"451", "", true},
+		{"AB10", "", true},
+	}
+	for _, tt := range series {
+		got, err := NormalizePassportSeries(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("NormalizePassportSeries(%q) = %q, %v; want %q, error %v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+
+	numbers := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{"123456", "123456", false},
+		{"123 456", "123456", false},
+		{"000000", "", true},
+		{"12345", "", true},
+	}
+	for _, tt := range numbers {
+		got, err := NormalizePassportNumber(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("NormalizePassportNumber(%q) = %q, %v; want %q, error %v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestCreatePatientRequestNormalizeIdentifiers(t *testing.T) {
+	req := CreatePatientRequest{SNILs: "11223344595", OMSPolicy: " ", PassportSeries: "45 10", PassportNumber: "12345"}
+	err := req.NormalizeIdentifiers()
+
+	fields, ok := err.(FieldErrors)
+	if !ok {
+		t.Fatalf("expected FieldErrors, got %v", err)
+	}
+	if len(fields) != 1 || fields["passport_number"] == "" {
+		t.Errorf("field errors = %v, want only passport_number", fields)
+	}
+	if req.SNILs != "112-233-445 95" || req.OMSPolicy != "" || req.PassportSeries != "4510" {
+		t.Errorf("not normalized: %+v", req)
+	}
+}
+
+func TestUpdatePatientRequestNormalizeIdentifiers(t *testing.T) {
+	snils, policy := "112 233 445 95", ""
+	req := UpdatePatientRequest{SNILs: &snils, PolicyNumber: &policy}
+	if err := req.NormalizeIdentifiers(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if *req.SNILs != "112-233-445 95" || *req.PolicyNumber != "" {
+		t.Errorf("not normalized: snils %q, policy %q", *req.SNILs, *req.PolicyNumber)
+	}
+
+	bad := "1234567890123456"
+	req = UpdatePatientRequest{PolicyNumber: &bad}
+	if err := req.NormalizeIdentifiers(); err == nil {
+		t.Error("expected error for policy with wrong check digit")
+	}
+}
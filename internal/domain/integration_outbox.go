@@ -0,0 +1,48 @@
+package domain
+
+import "time"
+
+type OutboxStatus string
+
+const (
+	OutboxStatusPending    OutboxStatus = "pending"
+	OutboxStatusProcessing OutboxStatus = "processing"
+	OutboxStatusDone       OutboxStatus = "done"
+	OutboxStatusError      OutboxStatus = "error"
+)
+
+const (
+	OutboxOpExportPatient = "export_patient"
+	OutboxOpCreateCase    = "create_case"
+)
+
+// Статусы синхронизации в EMIASMetadata/RIAMSMetadata
+const (
+	SyncStatusPending = "pending"
+	SyncStatusSynced  = "synced"
+	SyncStatusError   = "error"
+)
+
+// IntegrationOutbox — исходящее сообщение во внешнюю систему (ЕМИАС/РИАМС).
+// Обрабатывается воркером с экспоненциальной задержкой между попытками.
+type IntegrationOutbox struct {
+	ID          uint         `gorm:"primaryKey" json:"id"`
+	System      string       `gorm:"type:varchar(10);not null;index" json:"system"` // emias, riams
+	Operation   string       `gorm:"type:varchar(30);not null" json:"operation"`
+	PatientID   uint         `gorm:"index;not null" json:"patient_id"`
+	Payload     string       `gorm:"type:text" json:"payload"`
+	Status      OutboxStatus `gorm:"type:varchar(20);default:'pending';not null;index" json:"status"`
+	Attempts    int          `gorm:"default:0;not null" json:"attempts"`
+	MaxAttempts int          `gorm:"default:10;not null" json:"max_attempts"`
+	NextRetryAt time.Time    `gorm:"index;not null" json:"next_retry_at"`
+	LastError   string       `gorm:"type:text" json:"last_error,omitempty"`
+	ExternalID  string       `json:"external_id,omitempty"`
+	CreatedBy   uint         `json:"created_by"`
+	CompletedAt *time.Time   `json:"completed_at,omitempty"`
+	CreatedAt   time.Time    `json:"created_at"`
+	UpdatedAt   time.Time    `json:"updated_at"`
+}
+
+func (IntegrationOutbox) TableName() string {
+	return "integration_outbox"
+}
@@ -12,6 +12,8 @@ type EMIASExportRequest struct {
 
 type EMIASExportResponse struct {
 	Success    bool   `json:"success"`
+	JobID      uint   `json:"job_id,omitempty"`
+	Status     string `json:"status,omitempty"` // pending, synced, error
 	ExternalID string `json:"external_id,omitempty"`
 	Message    string `json:"message,omitempty"`
 	Error      string `json:"error,omitempty"`
@@ -23,6 +25,7 @@ type EMIASStatusResponse struct {
 	CaseID     string    `json:"case_id,omitempty"`
 	Status     string    `json:"status,omitempty"` // synced, pending, error
 	LastSyncAt time.Time `json:"last_sync_at,omitempty"`
+	LastError  string    `json:"last_error,omitempty"`
 }
 
 // RIAMS integration types
@@ -33,6 +36,8 @@ type RIAMSExportRequest struct {
 
 type RIAMSExportResponse struct {
 	Success    bool   `json:"success"`
+	JobID      uint   `json:"job_id,omitempty"`
+	Status     string `json:"status,omitempty"` // pending, synced, error
 	ExternalID string `json:"external_id,omitempty"`
 	Message    string `json:"message,omitempty"`
 	Error      string `json:"error,omitempty"`
@@ -44,6 +49,7 @@ type RIAMSStatusResponse struct {
 	RegionCode string    `json:"region_code,omitempty"`
 	Status     string    `json:"status,omitempty"` // synced, pending, error
 	LastSyncAt time.Time `json:"last_sync_at,omitempty"`
+	LastError  string    `json:"last_error,omitempty"`
 }
 
 type RIAMSRegion struct {
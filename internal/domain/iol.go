@@ -17,6 +17,10 @@ type IOLCalculation struct {
 	AConstant     float64   `json:"a_constant"`
 	CalculatedBy  uint      `json:"calculated_by"`
 	Warnings      string    `gorm:"type:text" json:"warnings"`
+	// MediaID — файл биометра, из которого взяты измерения
+	MediaID       *uint     `gorm:"index" json:"media_id,omitempty"`
+	// LensID — линза из каталога, константы которой использованы в расчёте
+	LensID        *uint     `gorm:"index" json:"lens_id,omitempty"`
 	CreatedAt     time.Time `json:"created_at"`
 }
 
@@ -30,4 +34,6 @@ type IOLCalculationRequest struct {
 	TargetRefraction float64 `json:"target_refraction"`
 	Formula        string  `json:"formula" binding:"required"`
 	AConstant      float64 `json:"a_constant"`
+	MediaID        *uint   `json:"media_id,omitempty"`
+	LensID         *uint   `json:"lens_id,omitempty"`
 }
@@ -0,0 +1,44 @@
+package domain
+
+import "time"
+
+// DefaultImportFormulas — формулы, которые считаются при импорте биометрии по умолчанию
+var DefaultImportFormulas = []string{"SRKT", "HAIGIS", "HOFFERQ"}
+
+// BiometryImportRequest — параметры импорта выгрузки биометра (multipart-форма)
+type BiometryImportRequest struct {
+	PatientID uint `form:"patient_id" binding:"required"`
+	// Eye ограничивает импорт одним глазом; пусто — все глаза из файла
+	Eye string `form:"eye"`
+	// Formulas — список формул; допускается перечисление через запятую
+	Formulas         []string `form:"formulas"`
+	Calculate        bool     `form:"calculate"`
+	TargetRefraction float64  `form:"target_refraction"`
+	AConstant        float64  `form:"a_constant"`
+	// LensID — линза из каталога для расчётов
+	LensID *uint `form:"lens_id"`
+}
+
+// BiometryMeasurement — биометрия одного глаза из выгрузки
+type BiometryMeasurement struct {
+	Eye          string  `json:"eye"`
+	AxialLength  float64 `json:"axial_length"`
+	Keratometry1 float64 `json:"keratometry1"`
+	Keratometry2 float64 `json:"keratometry2"`
+	ACD          float64 `json:"acd,omitempty"`
+}
+
+// BiometryImportResult — результат импорта: исходный файл, измерения,
+// LOINC-наблюдения и готовые (или уже выполненные) расчёты ИОЛ
+type BiometryImportResult struct {
+	Media        *Media                  `json:"media"`
+	Format       string                  `json:"format"`
+	Device       string                  `json:"device,omitempty"`
+	PatientName  string                  `json:"patient_name,omitempty"`
+	MeasuredAt   *time.Time              `json:"measured_at,omitempty"`
+	Measurements []BiometryMeasurement   `json:"measurements"`
+	Observations []LOINCCode             `json:"observations"`
+	Prefill      []IOLCalculationRequest `json:"prefill"`
+	Calculations []IOLCalculation        `json:"calculations,omitempty"`
+	Warnings     []string                `json:"warnings,omitempty"`
+}
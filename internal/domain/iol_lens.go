@@ -0,0 +1,118 @@
+package domain
+
+import (
+	"math"
+	"time"
+)
+
+// IOLLens — модель интраокулярной линзы с оптическими константами для формул
+type IOLLens struct {
+	ID           uint   `gorm:"primaryKey" json:"id"`
+	Manufacturer string `gorm:"not null;uniqueIndex:idx_iol_lenses_model" json:"manufacturer"`
+	Model        string `gorm:"not null;uniqueIndex:idx_iol_lenses_model" json:"model"`
+	// A-константа для SRK/T
+	AConstant float64 `gorm:"not null" json:"a_constant"`
+	// Константы Haigis; все нули — используются значения по умолчанию
+	HaigisA0 float64 `json:"haigis_a0"`
+	HaigisA1 float64 `json:"haigis_a1"`
+	HaigisA2 float64 `json:"haigis_a2"`
+	// pACD для Hoffer Q и хирургический фактор (SF) для Holladay 1
+	HofferPACD    float64 `json:"hoffer_pacd"`
+	SurgeonFactor float64 `json:"surgeon_factor"`
+	// Диапазон выпускаемых мощностей; 0 — без ограничения
+	MinPower  float64   `json:"min_power"`
+	MaxPower  float64   `json:"max_power"`
+	IsActive  bool      `gorm:"default:true;not null" json:"is_active"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// FillDerivedConstants подставляет pACD и SF, пересчитанные из A-константы,
+// если производитель их не указал
+func (l *IOLLens) FillDerivedConstants() {
+	if l.HofferPACD == 0 {
+		l.HofferPACD = math.Round((0.58357*l.AConstant-63.896)*100) / 100
+	}
+	if l.SurgeonFactor == 0 {
+		l.SurgeonFactor = math.Round((0.5663*l.AConstant-65.6)*100) / 100
+	}
+}
+
+// HasPower — мощность выпускается для этой модели
+func (l *IOLLens) HasPower(power float64) bool {
+	if l.MinPower != 0 && power < l.MinPower {
+		return false
+	}
+	if l.MaxPower != 0 && power > l.MaxPower {
+		return false
+	}
+	return true
+}
+
+// --- Requests ---
+
+type CreateIOLLensRequest struct {
+	Manufacturer  string  `json:"manufacturer" binding:"required"`
+	Model         string  `json:"model" binding:"required"`
+	AConstant     float64 `json:"a_constant" binding:"required"`
+	HaigisA0      float64 `json:"haigis_a0"`
+	HaigisA1      float64 `json:"haigis_a1"`
+	HaigisA2      float64 `json:"haigis_a2"`
+	HofferPACD    float64 `json:"hoffer_pacd"`
+	SurgeonFactor float64 `json:"surgeon_factor"`
+	MinPower      float64 `json:"min_power"`
+	MaxPower      float64 `json:"max_power"`
+}
+
+type UpdateIOLLensRequest struct {
+	Manufacturer  *string  `json:"manufacturer"`
+	Model         *string  `json:"model"`
+	AConstant     *float64 `json:"a_constant"`
+	HaigisA0      *float64 `json:"haigis_a0"`
+	HaigisA1      *float64 `json:"haigis_a1"`
+	HaigisA2      *float64 `json:"haigis_a2"`
+	HofferPACD    *float64 `json:"hoffer_pacd"`
+	SurgeonFactor *float64 `json:"surgeon_factor"`
+	MinPower      *float64 `json:"min_power"`
+	MaxPower      *float64 `json:"max_power"`
+	IsActive      *bool    `json:"is_active"`
+}
+
+// IOLCompareRequest — сравнение формул для одной линзы
+type IOLCompareRequest struct {
+	PatientID        uint    `json:"patient_id" binding:"required"`
+	Eye              string  `json:"eye" binding:"required"`
+	AxialLength      float64 `json:"axial_length" binding:"required"`
+	Keratometry1     float64 `json:"keratometry1" binding:"required"`
+	Keratometry2     float64 `json:"keratometry2" binding:"required"`
+	ACD              float64 `json:"acd"`
+	TargetRefraction float64 `json:"target_refraction"`
+	// LensID — линза из каталога; без неё используется a_constant и константы по умолчанию
+	LensID    *uint   `json:"lens_id"`
+	AConstant float64 `json:"a_constant"`
+	// Formulas — по умолчанию SRKT, HAIGIS, HOFFERQ, HOLLADAY1, BARRETT_STYLE
+	Formulas []string `json:"formulas"`
+}
+
+// IOLPowerRow — строка таблицы мощностей: мощность линзы и ожидаемая рефракция
+type IOLPowerRow struct {
+	Power      float64 `json:"power"`
+	Refraction float64 `json:"refraction"`
+}
+
+// IOLFormulaResult — результат одной формулы; Error — причина, по которой формула не посчитана
+type IOLFormulaResult struct {
+	Formula             string        `json:"formula"`
+	IOLPower            float64       `json:"iol_power,omitempty"`
+	PredictedRefraction float64       `json:"predicted_refraction"`
+	PowerTable          []IOLPowerRow `json:"power_table,omitempty"`
+	Error               string        `json:"error,omitempty"`
+}
+
+type IOLCompareResult struct {
+	Lens             *IOLLens           `json:"lens,omitempty"`
+	AConstant        float64            `json:"a_constant"`
+	TargetRefraction float64            `json:"target_refraction"`
+	Results          []IOLFormulaResult `json:"results"`
+	Warnings         []string           `json:"warnings,omitempty"`
+}
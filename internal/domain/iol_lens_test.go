@@ -0,0 +1,45 @@
+package domain
+
+import "testing"
+
+func TestIOLLensFillDerivedConstants(t *testing.T) {
+	tests := []struct {
+		name     string
+		lens     IOLLens
+		wantPACD float64
+		wantSF   float64
+	}{
+		{"derived from A-constant", IOLLens{AConstant: 118.7}, 5.37, 1.62},
+		{"manufacturer values kept", IOLLens{AConstant: 118.7, HofferPACD: 5.5, SurgeonFactor: 1.7}, 5.5, 1.7},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.lens.FillDerivedConstants()
+			if tt.lens.HofferPACD != tt.wantPACD || tt.lens.SurgeonFactor != tt.wantSF {
+				t.Errorf("pACD %.2f SF %.2f, want %.2f %.2f", tt.lens.HofferPACD, tt.lens.SurgeonFactor, tt.wantPACD, tt.wantSF)
+			}
+		})
+	}
+}
+
+func TestIOLLensHasPower(t *testing.T) {
+	lens := IOLLens{MinPower: 6, MaxPower: 30}
+	tests := []struct {
+		power float64
+		want  bool
+	}{
+		{5.5, false},
+		{6, true},
+		{21.5, true},
+		{30, true},
+		{30.5, false},
+	}
+	for _, tt := range tests {
+		if got := lens.HasPower(tt.power); got != tt.want {
+			t.Errorf("HasPower(%.1f) = %v, want %v", tt.power, got, tt.want)
+		}
+	}
+	if !(&IOLLens{}).HasPower(-5) {
+		t.Error("lens without range should accept any power")
+	}
+}
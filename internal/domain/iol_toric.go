@@ -0,0 +1,45 @@
+package domain
+
+// IOLToricRequest — планирование торической ИОЛ
+type IOLToricRequest struct {
+	PatientID    uint    `json:"patient_id" binding:"required"`
+	Eye          string  `json:"eye" binding:"required"`
+	AxialLength  float64 `json:"axial_length" binding:"required"`
+	Keratometry1 float64 `json:"keratometry1" binding:"required"`
+	Keratometry2 float64 `json:"keratometry2" binding:"required"`
+	// SteepAxis — ось сильного меридиана роговицы, 0–180°
+	SteepAxis *float64 `json:"steep_axis" binding:"required"`
+	// SIA — индуцированный разрезом астигматизм, D; IncisionAxis — меридиан разреза
+	SIA              float64 `json:"sia"`
+	IncisionAxis     float64 `json:"incision_axis"`
+	ACD              float64 `json:"acd"`
+	TargetRefraction float64 `json:"target_refraction"`
+	LensID           *uint   `json:"lens_id"`
+	AConstant        float64 `json:"a_constant"`
+	// Formula — формула сферического эквивалента; по умолчанию HOLLADAY1
+	Formula string `json:"formula"`
+}
+
+// IOLToricResult — сферический эквивалент линзы и торический план.
+// Цилиндры положительные, оси 1–180°
+type IOLToricResult struct {
+	Lens                *IOLLens `json:"lens,omitempty"`
+	Formula             string   `json:"formula"`
+	SphericalEquivalent float64  `json:"spherical_equivalent"`
+	PredictedRefraction float64  `json:"predicted_refraction"`
+	CornealCylinder     float64  `json:"corneal_cylinder"`
+	CornealAxis         float64  `json:"corneal_axis"`
+	// TotalCylinder — астигматизм роговицы с учётом разреза
+	TotalCylinder float64 `json:"total_cylinder"`
+	TotalAxis     float64 `json:"total_axis"`
+	// CylinderRatio — пересчёт цилиндра из плоскости роговицы в плоскость ИОЛ
+	CylinderRatio float64 `json:"cylinder_ratio"`
+	// IOLCylinder — требуемый цилиндр в плоскости ИОЛ
+	IOLCylinder float64 `json:"iol_cylinder"`
+	// RecommendedCylinder — цилиндр модели; 0 — торическая линза не нужна
+	RecommendedCylinder float64  `json:"recommended_cylinder"`
+	Axis                float64  `json:"axis"`
+	ResidualCylinder    float64  `json:"residual_cylinder"`
+	ResidualAxis        float64  `json:"residual_axis"`
+	Warnings            []string `json:"warnings,omitempty"`
+}
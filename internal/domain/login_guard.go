@@ -0,0 +1,40 @@
+package domain
+
+import "strings"
+
+// LoginChallenge — задача proof-of-work, которую клиент решает после нескольких
+// неудачных попыток: найти challenge_solution, при котором
+// SHA-256("nonce:challenge_solution") начинается с difficulty нулевых бит
+type LoginChallenge struct {
+	ID         string `json:"id"`
+	Nonce      string `json:"nonce"`
+	Difficulty int    `json:"difficulty"`
+	ExpiresIn  int    `json:"expires_in"`
+}
+
+// ChallengeProof — решение задачи; для GET — заголовки X-Challenge-Id и X-Challenge-Solution
+type ChallengeProof struct {
+	ChallengeID       string `json:"challenge_id"`
+	ChallengeSolution string `json:"challenge_solution"`
+}
+
+func (p ChallengeProof) Empty() bool {
+	return p.ChallengeID == "" || p.ChallengeSolution == ""
+}
+
+// Длина начала кода, по которому считаются попытки перебора с разных адресов
+const accessCodePrefixLen = 4
+
+// AccessCodePrefix — начало кода в нижнем регистре
+func AccessCodePrefix(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	if len(code) > accessCodePrefixLen {
+		return code[:accessCodePrefixLen]
+	}
+	return code
+}
+
+// MaskAccessCode — код для журналов: полный код не пишется
+func MaskAccessCode(code string) string {
+	return AccessCodePrefix(code) + "****"
+}
@@ -0,0 +1,31 @@
+package domain
+
+import "testing"
+
+func TestAccessCodePrefix(t *testing.T) {
+	tests := []struct {
+		in, prefix, masked string
+	}{
+		{"A1B2C3D4", "a1b2", "a1b2****"},
+		{" a1b2c3d4 ", "a1b2", "a1b2****"},
+		{"ab", "ab", "ab****"},
+		{"", "", "****"},
+	}
+	for _, tt := range tests {
+		if got := AccessCodePrefix(tt.in); got != tt.prefix {
+			t.Errorf("AccessCodePrefix(%q) = %q, want %q", tt.in, got, tt.prefix)
+		}
+		if got := MaskAccessCode(tt.in); got != tt.masked {
+			t.Errorf("MaskAccessCode(%q) = %q, want %q", tt.in, got, tt.masked)
+		}
+	}
+}
+
+func TestChallengeProofEmpty(t *testing.T) {
+	if !(ChallengeProof{ChallengeID: "x"}).Empty() {
+		t.Error("proof without solution must be empty")
+	}
+	if (ChallengeProof{ChallengeID: "x", ChallengeSolution: "1"}).Empty() {
+		t.Error("complete proof reported empty")
+	}
+}
@@ -1,17 +1,50 @@
 package domain
 
-import "time"
+import (
+	"path"
+	"strings"
+	"time"
+)
+
+type MediaStatus string
+
+const (
+	MediaStatusPending    MediaStatus = "PENDING"
+	MediaStatusProcessing MediaStatus = "PROCESSING"
+	MediaStatusReady      MediaStatus = "READY"
+	MediaStatusFailed     MediaStatus = "FAILED"
+)
 
 type Media struct {
-	ID           uint      `gorm:"primaryKey" json:"id"`
-	PatientID    uint      `gorm:"index;not null" json:"patient_id"`
-	UploadedBy   uint      `json:"uploaded_by"`
-	FileName     string    `gorm:"not null" json:"file_name"`
-	OriginalName string    `gorm:"not null" json:"original_name"`
-	ContentType  string    `gorm:"not null" json:"content_type"`
-	Size         int64     `json:"size"`
-	StoragePath  string    `gorm:"not null" json:"storage_path"`
-	ThumbnailPath string   `json:"thumbnail_path"`
-	Category     string    `gorm:"type:varchar(50);index" json:"category"`
-	CreatedAt    time.Time `json:"created_at"`
+	ID            uint      `gorm:"primaryKey" json:"id"`
+	PatientID     uint      `gorm:"index;not null" json:"patient_id"`
+	UploadedBy    uint      `json:"uploaded_by"`
+	FileName      string    `gorm:"not null" json:"file_name"`
+	OriginalName  string    `gorm:"not null" json:"original_name"`
+	ContentType   string    `gorm:"not null" json:"content_type"`
+	Size          int64     `json:"size"`
+	StoragePath   string    `gorm:"not null" json:"storage_path"`
+	ThumbnailPath string    `json:"thumbnail_path"`
+	Category      string    `gorm:"type:varchar(50);index" json:"category"`
+	Width         int       `json:"width,omitempty"`
+	Height        int       `json:"height,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+
+	// Снимки DICOM: глаз и пункт чек-листа определяются по заголовку
+	Eye             string        `gorm:"type:varchar(5)" json:"eye,omitempty"`
+	ChecklistItemID *uint         `gorm:"index" json:"checklist_item_id,omitempty"`
+	DICOM           *DICOMStudy   `gorm:"column:dicom;type:jsonb" json:"dicom,omitempty"`
+	Warnings        MediaWarnings `gorm:"type:jsonb" json:"warnings,omitempty"`
+
+	// Обработка изображений: очистка метаданных и миниатюра
+	ProcessingStatus   MediaStatus `gorm:"type:varchar(20);index;default:'READY'" json:"processing_status"`
+	ProcessingError    string      `json:"processing_error,omitempty"`
+	ProcessingAttempts int         `gorm:"default:0" json:"-"`
+	NextAttemptAt      *time.Time  `json:"-"`
+	ProcessedAt        *time.Time  `json:"processed_at,omitempty"`
+}
+
+// ThumbnailPathFor — путь миниатюры рядом с оригиналом; ext — расширение миниатюры
+func ThumbnailPathFor(storagePath, ext string) string {
+	return strings.TrimSuffix(storagePath, path.Ext(storagePath)) + "_thumb" + ext
 }
@@ -0,0 +1,205 @@
+package domain
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"strings"
+	"unicode"
+)
+
+// Модальности DICOM, которые встречаются в офтальмологии
+const (
+	DICOMModalityOCT        = "OPT" // оптическая когерентная томография
+	DICOMModalityPhoto      = "OP"  // фото глазного дна
+	DICOMModalityUltrasound = "US"  // B-скан
+	DICOMModalityMapping    = "OPM" // кератотопография
+	DICOMModalityBiometry   = "IOL" // оптическая биометрия
+)
+
+// DICOMStudy — сведения из заголовка DICOM, хранятся в jsonb
+type DICOMStudy struct {
+	PatientName  string `json:"patient_name,omitempty"`
+	PatientID    string `json:"patient_id,omitempty"`
+	BirthDate    string `json:"birth_date,omitempty"`
+	StudyDate    string `json:"study_date,omitempty"`
+	Modality     string `json:"modality,omitempty"`
+	Laterality   string `json:"laterality,omitempty"`
+	Manufacturer string `json:"manufacturer,omitempty"`
+	Model        string `json:"model,omitempty"`
+	Description  string `json:"description,omitempty"`
+}
+
+func (d *DICOMStudy) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+
+	bytes, ok := value.([]byte)
+	if !ok {
+		return errors.New("failed to unmarshal JSONB value")
+	}
+
+	return json.Unmarshal(bytes, d)
+}
+
+func (d DICOMStudy) Value() (driver.Value, error) {
+	return json.Marshal(d)
+}
+
+// MediaWarnings — предупреждения обработки, которые стоит показать врачу
+type MediaWarnings []string
+
+func (w *MediaWarnings) Scan(value interface{}) error {
+	if value == nil {
+		*w = nil
+		return nil
+	}
+
+	bytes, ok := value.([]byte)
+	if !ok {
+		return errors.New("failed to unmarshal JSONB value")
+	}
+
+	return json.Unmarshal(bytes, w)
+}
+
+func (w MediaWarnings) Value() (driver.Value, error) {
+	if len(w) == 0 {
+		return nil, nil
+	}
+	return json.Marshal(w)
+}
+
+// Ключевые слова пунктов чек-листа для каждой модальности
+var modalityChecklistKeywords = map[string][]string{
+	DICOMModalityOCT:        {"oct", "окт", "когерентн"},
+	DICOMModalityUltrasound: {"b-скан", "в-скан", "b-scan", "узи"},
+	DICOMModalityPhoto:      {"глазного дна", "фундус", "fundus"},
+	DICOMModalityMapping:    {"топограф", "кератометр"},
+	DICOMModalityBiometry:   {"биометр", "iol master"},
+}
+
+// Подсказки из описания серии: уточняют, какой из нескольких OCT-пунктов подходит
+var studyDescriptionHints = []struct {
+	description []string
+	item        []string
+}{
+	{[]string{"macula", "macular", "retina", "макул", "сетчат"}, []string{"макул"}},
+	{[]string{"disc", "onh", "rnfl", "optic", "glaucoma", "диск", "нерв", "дзн"}, []string{"дзн", "диска", "нерв"}},
+}
+
+// ChecklistItemForStudy подбирает пункт чек-листа, к которому относится снимок.
+// Рассматриваются только пункты без прикреплённого файла, ещё не выполненные
+// или отклонённые. Возвращает nil, если подходящего пункта нет.
+func ChecklistItemForStudy(items []ChecklistItem, modality, description string) *ChecklistItem {
+	keywords := modalityChecklistKeywords[strings.ToUpper(modality)]
+	if len(keywords) == 0 {
+		return nil
+	}
+	description = strings.ToLower(description)
+
+	var best *ChecklistItem
+	bestScore := -1
+	for i := range items {
+		item := &items[i]
+		if item.Status == ChecklistStatusCompleted || item.Status == ChecklistStatusExpired {
+			continue
+		}
+		if item.MediaID != nil && item.Status != ChecklistStatusRejected {
+			continue
+		}
+		name := strings.ToLower(item.Name)
+		if !containsAny(name, keywords) {
+			continue
+		}
+
+		score := 0
+		for _, hint := range studyDescriptionHints {
+			if containsAny(description, hint.description) && containsAny(name, hint.item) {
+				score++
+			}
+		}
+		if score > bestScore {
+			best, bestScore = item, score
+		}
+	}
+	return best
+}
+
+func containsAny(s string, substrings []string) bool {
+	for _, sub := range substrings {
+		if strings.Contains(s, sub) {
+			return true
+		}
+	}
+	return false
+}
+
+// DICOMNameMatches сравнивает имя из DICOM (Фамилия^Имя^Отчество) с пациентом.
+// Аппараты часто пишут имя латиницей в произвольной транслитерации,
+// поэтому обе стороны приводятся к упрощённому латинскому ключу.
+// Совпадать должны фамилия и имя (или инициал, если аппарат хранит только его).
+func DICOMNameMatches(dicomName string, p *Patient) bool {
+	lastName, firstName := nameKey(p.LastName), nameKey(p.FirstName)
+	if lastName == "" {
+		return true
+	}
+
+	// Варианты записи имени разделяются «=»: буквенный, идеографический, фонетический
+	for _, group := range strings.Split(dicomName, "=") {
+		parts := strings.Split(group, "^")
+		if nameKey(parts[0]) != lastName {
+			continue
+		}
+		if len(parts) < 2 || firstName == "" {
+			return true
+		}
+		given := nameKey(parts[1])
+		if given == "" || given == firstName {
+			return true
+		}
+		// Инициал сверяем и с полной транслитерацией: «Юлия» — и «U», и «I», и «Y»
+		if len(given) == 1 && (strings.HasPrefix(firstName, given) || strings.HasPrefix(transliterate(p.FirstName), given)) {
+			return true
+		}
+	}
+	return false
+}
+
+var cyrillicToLatin = map[rune]string{
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "e", 'ж': "zh",
+	'з': "z", 'и': "i", 'й': "i", 'к': "k", 'л': "l", 'м': "m", 'н': "n", 'о': "o",
+	'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u", 'ф': "f", 'х': "kh", 'ц': "ts",
+	'ч': "ch", 'ш': "sh", 'щ': "shch", 'ъ': "", 'ы': "y", 'ь': "", 'э': "e", 'ю': "iu", 'я': "ia",
+}
+
+// Сведение распространённых схем транслитерации к одной (порядок важен)
+var latinNameKey = strings.NewReplacer(
+	"shch", "sc", "sch", "sc", "kh", "h", "ts", "c", "tz", "c",
+	"yu", "u", "iu", "u", "ju", "u", "ya", "a", "ia", "a", "ja", "a",
+	"ye", "e", "ie", "e", "je", "e", "yo", "e", "jo", "e",
+	"y", "i", "j", "i", "w", "v",
+)
+
+// nameKey транслитерирует имя и приводит его к упрощённой латинской записи
+func nameKey(name string) string {
+	return latinKey(transliterate(name))
+}
+
+func latinKey(s string) string {
+	return latinNameKey.Replace(s)
+}
+
+// transliterate переводит кириллицу в латиницу по ИКАО и оставляет только буквы
+func transliterate(name string) string {
+	var sb strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if lat, ok := cyrillicToLatin[r]; ok {
+			sb.WriteString(lat)
+		} else if unicode.IsLetter(r) {
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,69 @@
+package domain
+
+import "testing"
+
+func TestDICOMNameMatches(t *testing.T) {
+	patient := &Patient{LastName: "Хабибуллина", FirstName: "Юлия", MiddleName: "Сергеевна"}
+
+	tests := []struct {
+		name      string
+		dicomName string
+		want      bool
+	}{
+		{"cyrillic", "Хабибуллина^Юлия^Сергеевна", true},
+		{"icao transliteration", "KHABIBULLINA^IULIIA", true},
+		{"common transliteration", "HABIBULLINA^YULIYA^SERGEEVNA", true},
+		{"initial only", "KHABIBULLINA^Y", true},
+		{"last name only", "Khabibullina", true},
+		{"alternative group", "XX^YY=Хабибуллина^Юлия", true},
+		{"different first name", "KHABIBULLINA^ANNA", false},
+		{"different patient", "IVANOV^IVAN", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DICOMNameMatches(tt.dicomName, patient); got != tt.want {
+				t.Errorf("DICOMNameMatches(%q) = %v, want %v", tt.dicomName, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChecklistItemForStudy(t *testing.T) {
+	mediaID := uint(7)
+	items := []ChecklistItem{
+		{ID: 1, Name: "ЭКГ", Status: ChecklistStatusPending},
+		{ID: 2, Name: "OCT макулярной зоны", Status: ChecklistStatusPending},
+		{ID: 3, Name: "OCT диска зрительного нерва", Status: ChecklistStatusInProgress},
+		{ID: 4, Name: "B-скан", Status: ChecklistStatusCompleted},
+		{ID: 5, Name: "УЗИ глазного яблока", Status: ChecklistStatusPending, MediaID: &mediaID},
+		{ID: 6, Name: "Фото глазного дна", Status: ChecklistStatusRejected, MediaID: &mediaID},
+	}
+
+	tests := []struct {
+		name        string
+		modality    string
+		description string
+		wantID      uint
+	}{
+		{"oct without hint takes first", DICOMModalityOCT, "", 2},
+		{"oct macula", DICOMModalityOCT, "Macular Cube 512x128", 2},
+		{"oct optic disc", DICOMModalityOCT, "Optic Disc Cube 200x200", 3},
+		{"ultrasound items completed or attached", DICOMModalityUltrasound, "", 0},
+		{"rejected item accepts new file", DICOMModalityPhoto, "", 6},
+		{"unknown modality", "CT", "", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ChecklistItemForStudy(items, tt.modality, tt.description)
+			var gotID uint
+			if got != nil {
+				gotID = got.ID
+			}
+			if gotID != tt.wantID {
+				t.Errorf("ChecklistItemForStudy() = %d, want %d", gotID, tt.wantID)
+			}
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"database/sql/driver"
 	"encoding/json"
 	"errors"
+	"strings"
 	"time"
 )
 
@@ -40,6 +41,7 @@ type EMIASMetadata struct {
 	CaseID     string    `json:"case_id,omitempty"`
 	LastSyncAt time.Time `json:"last_sync_at,omitempty"`
 	SyncStatus string    `json:"sync_status,omitempty"` // synced, pending, error
+	LastError  string    `json:"last_error,omitempty"`
 }
 
 type RIAMSMetadata struct {
@@ -47,6 +49,7 @@ type RIAMSMetadata struct {
 	RegionCode string    `json:"region_code,omitempty"`
 	LastSyncAt time.Time `json:"last_sync_at,omitempty"`
 	SyncStatus string    `json:"sync_status,omitempty"` // synced, pending, error
+	LastError  string    `json:"last_error,omitempty"`
 }
 
 // Main metadata structure
@@ -98,8 +101,99 @@ func (m *MedicalStandardsMetadata) Scan(value interface{}) error {
 
 // Value implements driver.Valuer for JSONB support
 func (m MedicalStandardsMetadata) Value() (driver.Value, error) {
-	if m.DiagnosisCodes == nil && m.ProcedureCodes == nil && m.Observations == nil {
+	if m.DiagnosisCodes == nil && m.ProcedureCodes == nil && m.Observations == nil &&
+		m.FHIRResourceID == "" && m.Extensions == nil && m.Integrations == nil {
 		return nil, nil
 	}
 	return json.Marshal(m)
 }
+
+// Code system URIs (FHIR canonical)
+const (
+	CodeSystemICD10  = "http://hl7.org/fhir/sid/icd-10"
+	CodeSystemSNOMED = "http://snomed.info/sct"
+	CodeSystemLOINC  = "http://loinc.org"
+)
+
+// Biometry measurement kinds used for LOINC mapping
+const (
+	BiometryAxialLength = "AL"
+	BiometryK1          = "K1"
+	BiometryK2          = "K2"
+	BiometryACD         = "ACD"
+)
+
+var biometryLOINC = map[string]map[string]LOINCCode{
+	"OD": {
+		BiometryAxialLength: {Code: "79894-2", Display: "Длина оси правого глаза", Unit: "mm"},
+		BiometryK1:          {Code: "79897-5", Display: "Кератометрия K1 правого глаза", Unit: "D"},
+		BiometryK2:          {Code: "79898-3", Display: "Кератометрия K2 правого глаза", Unit: "D"},
+		BiometryACD:         {Code: "79901-5", Display: "Глубина передней камеры правого глаза", Unit: "mm"},
+	},
+	"OS": {
+		BiometryAxialLength: {Code: "79895-9", Display: "Длина оси левого глаза", Unit: "mm"},
+		BiometryK1:          {Code: "79899-1", Display: "Кератометрия K1 левого глаза", Unit: "D"},
+		BiometryK2:          {Code: "79900-7", Display: "Кератометрия K2 левого глаза", Unit: "D"},
+		BiometryACD:         {Code: "79902-3", Display: "Глубина передней камеры левого глаза", Unit: "mm"},
+	},
+}
+
+// NormalizeEye приводит обозначение глаза к OD/OS/OU
+func NormalizeEye(eye string) string {
+	switch strings.ToUpper(strings.TrimSpace(eye)) {
+	case "OD", "RIGHT", "R":
+		return "OD"
+	case "OS", "LEFT", "L":
+		return "OS"
+	case "OU", "BOTH":
+		return "OU"
+	}
+	return strings.ToUpper(strings.TrimSpace(eye))
+}
+
+// BiometryLOINC возвращает LOINC-код биометрического показателя для глаза
+func BiometryLOINC(eye, measurement string) (LOINCCode, bool) {
+	codes, ok := biometryLOINC[NormalizeEye(eye)]
+	if !ok {
+		return LOINCCode{}, false
+	}
+	code, ok := codes[measurement]
+	if !ok {
+		return LOINCCode{}, false
+	}
+	code.System = CodeSystemLOINC
+	return code, true
+}
+
+// DefaultProcedureCode возвращает SNOMED-код операции по умолчанию для типа операции
+func DefaultProcedureCode(opType OperationType) SNOMEDCode {
+	if t, ok := LookupOperationType(opType); ok {
+		return t.ProcedureCode()
+	}
+	return builtinProcedureCode(opType)
+}
+
+func builtinProcedureCode(opType OperationType) SNOMEDCode {
+	switch opType {
+	case OperationPhacoemulsification:
+		return SNOMEDCode{Code: "231744001", Display: "Факоэмульсификация катаракты", System: CodeSystemSNOMED}
+	case OperationAntiglaucoma:
+		return SNOMEDCode{Code: "46309007", Display: "Трабекулэктомия", System: CodeSystemSNOMED}
+	case OperationVitrectomy:
+		return SNOMEDCode{Code: "397193006", Display: "Витрэктомия", System: CodeSystemSNOMED}
+	}
+	return SNOMEDCode{Display: GetOperationTypeDisplayName(opType), System: CodeSystemSNOMED}
+}
+
+// EyeBodySite возвращает SNOMED-код анатомической локализации для глаза
+func EyeBodySite(eye string) (SNOMEDCode, bool) {
+	switch NormalizeEye(eye) {
+	case "OD":
+		return SNOMEDCode{Code: "18944008", Display: "Right eye structure", System: CodeSystemSNOMED}, true
+	case "OS":
+		return SNOMEDCode{Code: "8966001", Display: "Left eye structure", System: CodeSystemSNOMED}, true
+	case "OU":
+		return SNOMEDCode{Code: "40638003", Display: "Structure of both eyes", System: CodeSystemSNOMED}, true
+	}
+	return SNOMEDCode{}, false
+}
@@ -1,33 +1,164 @@
 package domain
 
-import "time"
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sync"
+	"time"
+)
 
 // OperationTypeModel - модель для управления типами операций в БД
 type OperationTypeModel struct {
-	ID               uint      `gorm:"primaryKey" json:"id"`
-	Code             string    `gorm:"uniqueIndex;not null" json:"code"` // PHACOEMULSIFICATION, ANTIGLAUCOMA, VITRECTOMY
-	Name             string    `gorm:"not null" json:"name"`
-	Description      string    `gorm:"type:text" json:"description"`
-	ChecklistTemplate string   `gorm:"type:text" json:"checklist_template"` // JSON array of checklist items
-	IsActive         bool      `gorm:"default:true;not null" json:"is_active"`
-	CreatedAt        time.Time `json:"created_at"`
-	UpdatedAt        time.Time `json:"updated_at"`
+	ID          uint   `gorm:"primaryKey" json:"id"`
+	Code        string `gorm:"type:varchar(50);uniqueIndex;not null" json:"code"` // PHACOEMULSIFICATION, ANTIGLAUCOMA, VITRECTOMY
+	Name        string `gorm:"not null" json:"name"`
+	Description string `gorm:"type:text" json:"description"`
+	// Глаз по умолчанию для новых пациентов (OD, OS, OU); пусто — указывается вручную
+	DefaultEye string `gorm:"type:varchar(5)" json:"default_eye"`
+	// Ожидаемая длительность операции; 0 — норматив по умолчанию
+	DurationMinutes int `gorm:"not null;default:0" json:"duration_minutes"`
+	// SNOMED-коды процедуры для экспорта в ЕМИАС и FHIR; первый — основной
+	ProcedureCodes SNOMEDCodes `gorm:"type:jsonb" json:"procedure_codes"`
+	IsActive       bool        `gorm:"default:true;not null" json:"is_active"`
+	CreatedAt      time.Time   `json:"created_at"`
+	UpdatedAt      time.Time   `json:"updated_at"`
 }
 
 func (OperationTypeModel) TableName() string {
 	return "operation_types"
 }
 
+// Duration возвращает ожидаемую длительность операции этого типа
+func (t *OperationTypeModel) Duration() time.Duration {
+	if t.DurationMinutes > 0 {
+		return time.Duration(t.DurationMinutes) * time.Minute
+	}
+	return builtinOperationDuration(OperationType(t.Code))
+}
+
+// ProcedureCode возвращает основной SNOMED-код процедуры
+func (t *OperationTypeModel) ProcedureCode() SNOMEDCode {
+	if len(t.ProcedureCodes) > 0 && t.ProcedureCodes[0].Code != "" {
+		code := t.ProcedureCodes[0]
+		if code.System == "" {
+			code.System = CodeSystemSNOMED
+		}
+		if code.Display == "" {
+			code.Display = t.Name
+		}
+		return code
+	}
+	code := builtinProcedureCode(OperationType(t.Code))
+	if code.Code == "" && t.Name != "" {
+		code.Display = t.Name
+	}
+	return code
+}
+
+// SNOMEDCodes — список кодов, хранится в jsonb
+type SNOMEDCodes []SNOMEDCode
+
+func (c *SNOMEDCodes) Scan(value interface{}) error {
+	if value == nil {
+		*c = nil
+		return nil
+	}
+
+	bytes, ok := value.([]byte)
+	if !ok {
+		return errors.New("failed to unmarshal JSONB value")
+	}
+
+	return json.Unmarshal(bytes, c)
+}
+
+func (c SNOMEDCodes) Value() (driver.Value, error) {
+	if len(c) == 0 {
+		return nil, nil
+	}
+	return json.Marshal(c)
+}
+
+var operationTypeCodePattern = regexp.MustCompile(`^[A-Z][A-Z0-9_]{1,29}$`)
+
+// ValidateOperationTypeCode проверяет код типа операции: латиница в верхнем регистре, цифры и «_»
+func ValidateOperationTypeCode(code string) error {
+	if !operationTypeCodePattern.MatchString(code) {
+		return fmt.Errorf("неверный код типа операции %q: используйте латиницу в верхнем регистре, цифры и _, до 30 символов", code)
+	}
+	return nil
+}
+
+// --- Registry ---
+
+// Справочник типов операций из БД. Заполняется сервисом типов операций при старте
+// и после каждого изменения; до загрузки действуют встроенные значения.
+var operationTypes = struct {
+	sync.RWMutex
+	byCode map[OperationType]OperationTypeModel
+}{}
+
+// RegisterOperationTypes заменяет справочник типов операций
+func RegisterOperationTypes(types []OperationTypeModel) {
+	byCode := make(map[OperationType]OperationTypeModel, len(types))
+	for _, t := range types {
+		byCode[OperationType(t.Code)] = t
+	}
+
+	operationTypes.Lock()
+	operationTypes.byCode = byCode
+	operationTypes.Unlock()
+}
+
+// LookupOperationType возвращает тип операции из справочника
+func LookupOperationType(code OperationType) (OperationTypeModel, bool) {
+	operationTypes.RLock()
+	defer operationTypes.RUnlock()
+	t, ok := operationTypes.byCode[code]
+	return t, ok
+}
+
+// DefaultOperationTypes — встроенные типы операций для начального заполнения справочника
+func DefaultOperationTypes() []OperationTypeModel {
+	descriptions := map[OperationType]string{
+		OperationPhacoemulsification: "Хирургическое удаление катаракты с имплантацией интраокулярной линзы",
+		OperationAntiglaucoma:        "Хирургическое лечение глаукомы для снижения внутриглазного давления",
+		OperationVitrectomy:          "Хирургическое удаление стекловидного тела глаза",
+	}
+
+	types := make([]OperationTypeModel, 0, len(BuiltinOperationTypes))
+	for _, code := range BuiltinOperationTypes {
+		types = append(types, OperationTypeModel{
+			Code:            string(code),
+			Name:            builtinOperationTypeNames[code],
+			Description:     descriptions[code],
+			DurationMinutes: int(builtinOperationDuration(code) / time.Minute),
+			ProcedureCodes:  SNOMEDCodes{builtinProcedureCode(code)},
+			IsActive:        true,
+		})
+	}
+	return types
+}
+
+// --- Requests ---
+
 type CreateOperationTypeRequest struct {
-	Code              string `json:"code" binding:"required"`
-	Name              string `json:"name" binding:"required"`
-	Description       string `json:"description"`
-	ChecklistTemplate string `json:"checklist_template"`
+	Code            string       `json:"code" binding:"required"`
+	Name            string       `json:"name" binding:"required"`
+	Description     string       `json:"description"`
+	DefaultEye      string       `json:"default_eye"`
+	DurationMinutes int          `json:"duration_minutes"`
+	ProcedureCodes  []SNOMEDCode `json:"procedure_codes"`
 }
 
 type UpdateOperationTypeRequest struct {
-	Name              *string `json:"name"`
-	Description       *string `json:"description"`
-	ChecklistTemplate *string `json:"checklist_template"`
-	IsActive          *bool   `json:"is_active"`
+	Name            *string       `json:"name"`
+	Description     *string       `json:"description"`
+	DefaultEye      *string       `json:"default_eye"`
+	DurationMinutes *int          `json:"duration_minutes"`
+	ProcedureCodes  *[]SNOMEDCode `json:"procedure_codes"`
+	IsActive        *bool         `json:"is_active"`
 }
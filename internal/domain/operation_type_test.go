@@ -0,0 +1,91 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestOperationTypeModelDefaults(t *testing.T) {
+	tests := []struct {
+		name         string
+		model        OperationTypeModel
+		wantDuration time.Duration
+		wantCode     string
+	}{
+		{"own values", OperationTypeModel{Code: "KERATOPLASTY", Name: "Кератопластика", DurationMinutes: 120, ProcedureCodes: SNOMEDCodes{{Code: "75732000"}}}, 120 * time.Minute, "75732000"},
+		{"builtin fallback", OperationTypeModel{Code: string(OperationVitrectomy), Name: "Витрэктомия"}, 90 * time.Minute, "397193006"},
+		{"unknown type", OperationTypeModel{Code: "STRABISMUS", Name: "Коррекция косоглазия"}, defaultOperationDurationM * time.Minute, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.model.Duration(); got != tt.wantDuration {
+				t.Errorf("Duration() = %v, want %v", got, tt.wantDuration)
+			}
+			code := tt.model.ProcedureCode()
+			if code.Code != tt.wantCode || code.System != CodeSystemSNOMED {
+				t.Errorf("ProcedureCode() = %+v, want code %q", code, tt.wantCode)
+			}
+			if code.Display == "" {
+				t.Error("ProcedureCode() without display")
+			}
+		})
+	}
+}
+
+func TestOperationTypeRegistry(t *testing.T) {
+	t.Cleanup(func() { RegisterOperationTypes(nil) })
+
+	keratoplasty := OperationType("KERATOPLASTY")
+	if got := OperationDuration(OperationPhacoemulsification); got != 30*time.Minute {
+		t.Fatalf("builtin duration = %v", got)
+	}
+
+	RegisterOperationTypes([]OperationTypeModel{
+		{Code: string(keratoplasty), Name: "Кератопластика", DurationMinutes: 120, ProcedureCodes: SNOMEDCodes{{Code: "75732000", Display: "Keratoplasty"}}},
+		{Code: string(OperationPhacoemulsification), Name: "ФЭК", DurationMinutes: 25},
+	})
+
+	if got := GetOperationTypeDisplayName(keratoplasty); got != "Кератопластика" {
+		t.Errorf("display name = %q", got)
+	}
+	if got := GetOperationTypeDisplayName(OperationPhacoemulsification); got != "ФЭК" {
+		t.Errorf("renamed builtin = %q", got)
+	}
+	if got := OperationDuration(keratoplasty); got != 120*time.Minute {
+		t.Errorf("duration = %v", got)
+	}
+	if got := OperationDuration(OperationPhacoemulsification); got != 25*time.Minute {
+		t.Errorf("overridden duration = %v", got)
+	}
+	if got := DefaultProcedureCode(keratoplasty); got.Code != "75732000" {
+		t.Errorf("procedure code = %+v", got)
+	}
+	// Тип, которого нет в справочнике, получает встроенные значения
+	if got := OperationDuration(OperationAntiglaucoma); got != 45*time.Minute {
+		t.Errorf("unregistered builtin duration = %v", got)
+	}
+}
+
+func TestValidateOperationTypeCode(t *testing.T) {
+	tests := []struct {
+		code    string
+		wantErr bool
+	}{
+		{"KERATOPLASTY", false},
+		{"STRABISMUS_2", false},
+		{"keratoplasty", true},
+		{"КЕРАТОПЛАСТИКА", true},
+		{"2STEP", true},
+		{"A", true},
+		{"ABCDEFGHIJKLMNOPQRSTUVWXYZ_12345", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			if err := ValidateOperationTypeCode(tt.code); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateOperationTypeCode(%q) error = %v, wantErr %v", tt.code, err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -27,6 +27,10 @@ const (
 	OperationVitrectomy           OperationType = "VITRECTOMY"
 )
 
+// BuiltinOperationTypes — типы операций, для которых есть встроенные шаблоны и нормативы.
+// Остальные типы заводятся в справочнике operation_types.
+var BuiltinOperationTypes = []OperationType{OperationPhacoemulsification, OperationAntiglaucoma, OperationVitrectomy}
+
 type Patient struct {
 	ID             uint          `gorm:"primaryKey" json:"id"`
 	AccessCode     string        `gorm:"uniqueIndex;not null" json:"access_code"`
@@ -34,13 +38,13 @@ type Patient struct {
 	LastName       string        `gorm:"not null" json:"last_name"`
 	MiddleName     string        `json:"middle_name"`
 	DateOfBirth    time.Time     `json:"date_of_birth"`
-	Phone          string        `json:"phone"`
+	Phone          string        `gorm:"serializer:pii" json:"phone"`
 	Email          string        `json:"email"`
-	Address        string        `json:"address"`
-	SNILs          string        `json:"snils"`
-	PassportSeries string        `json:"passport_series"`
-	PassportNumber string        `json:"passport_number"`
-	PolicyNumber   string        `json:"policy_number"`
+	Address        string        `gorm:"serializer:pii" json:"address"`
+	SNILs          string        `gorm:"serializer:pii" json:"snils"`
+	PassportSeries string        `gorm:"serializer:pii" json:"passport_series"`
+	PassportNumber string        `gorm:"serializer:pii" json:"passport_number"`
+	PolicyNumber   string        `gorm:"serializer:pii" json:"policy_number"`
 	Diagnosis      string        `gorm:"type:text" json:"diagnosis"`
 	OperationType  OperationType `gorm:"type:varchar(30);not null" json:"operation_type"`
 	Eye            string        `gorm:"type:varchar(5)" json:"eye"` // OD, OS, OU
@@ -56,7 +60,7 @@ type Patient struct {
 
 	// Medical standards and integrations
 	MedicalMetadata *MedicalStandardsMetadata `gorm:"type:jsonb" json:"medical_metadata,omitempty"`
-	OMSPolicy       string                    `gorm:"type:varchar(16)" json:"oms_policy,omitempty"`
+	OMSPolicy       string                    `gorm:"serializer:pii" json:"oms_policy,omitempty"`
 	Gender          string                    `gorm:"type:varchar(10)" json:"gender,omitempty"` // male, female
 
 	// Human-readable display names (computed fields, not stored in DB)
@@ -64,6 +68,16 @@ type Patient struct {
 	OperationTypeDisplay string `gorm:"-" json:"operation_type_display"`
 	EyeDisplay           string `gorm:"-" json:"eye_display"`
 
+	// Слепые индексы зашифрованных документов и телефона (см. SetBlindIndexes)
+	SNILSHash        string `gorm:"type:varchar(64);index" json:"-"`
+	OMSPolicyHash    string `gorm:"type:varchar(64);index" json:"-"`
+	PolicyNumberHash string `gorm:"type:varchar(64);index" json:"-"`
+	PassportHash     string `gorm:"type:varchar(64);index" json:"-"`
+	PhoneHash        string `gorm:"type:varchar(64);index" json:"-"`
+
+	// Версия для офлайн-синхронизации, выдаётся при каждом сохранении
+	SyncVersion int64 `gorm:"index;not null;default:0" json:"sync_version"`
+
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
@@ -135,13 +149,18 @@ func GetStatusDisplayName(status PatientStatus) string {
 	return string(status)
 }
 
+var builtinOperationTypeNames = map[OperationType]string{
+	OperationPhacoemulsification: "Факоэмульсификация катаракты",
+	OperationAntiglaucoma:        "Антиглаукомная операция",
+	OperationVitrectomy:          "Витрэктомия",
+}
+
+// GetOperationTypeDisplayName возвращает название типа операции из справочника
 func GetOperationTypeDisplayName(opType OperationType) string {
-	names := map[OperationType]string{
-		OperationPhacoemulsification: "Факоэмульсификация катаракты",
-		OperationAntiglaucoma:        "Антиглаукомная операция",
-		OperationVitrectomy:          "Витрэктомия",
+	if t, ok := LookupOperationType(opType); ok && t.Name != "" {
+		return t.Name
 	}
-	if name, ok := names[opType]; ok {
+	if name, ok := builtinOperationTypeNames[opType]; ok {
 		return name
 	}
 	return string(opType)
@@ -180,11 +199,15 @@ type CreatePatientRequest struct {
 	PassportSeries string        `json:"passport_series"`
 	PassportNumber string        `json:"passport_number"`
 	PolicyNumber   string        `json:"policy_number"`
+	OMSPolicy      string        `json:"oms_policy"`
+	Gender         string        `json:"gender"`
 	Diagnosis      string        `json:"diagnosis"`
 	OperationType  OperationType `json:"operation_type" binding:"required"`
-	Eye            string        `json:"eye" binding:"required"`
+	Eye            string        `json:"eye"` // по умолчанию — глаз из типа операции
 	DistrictID     uint          `json:"district_id" binding:"required"`
 	Notes          string        `json:"notes"`
+	// Создать, несмотря на похожего пациента с той же датой рождения (совпадение документов не обходится)
+	IgnoreDuplicates bool `json:"ignore_duplicates"`
 }
 
 type UpdatePatientRequest struct {
@@ -200,6 +223,8 @@ type UpdatePatientRequest struct {
 	PassportSeries *string `json:"passport_series"`
 	PassportNumber *string `json:"passport_number"`
 	PolicyNumber   *string `json:"policy_number"`
+	// Сохранить, несмотря на похожего пациента с той же датой рождения
+	IgnoreDuplicates bool `json:"ignore_duplicates"`
 }
 
 type PatientStatusRequest struct {
@@ -0,0 +1,232 @@
+package domain
+
+import (
+	"strings"
+	"time"
+	"unicode"
+)
+
+// DuplicateReason — по какому признаку записи пациентов считаются одним человеком
+type DuplicateReason string
+
+const (
+	DuplicateSNILS     DuplicateReason = "SNILS"
+	DuplicateOMSPolicy DuplicateReason = "OMS_POLICY"
+	DuplicatePassport  DuplicateReason = "PASSPORT"
+	// DuplicateNameDOB — похожее ФИО (до двух опечаток) и та же дата рождения
+	DuplicateNameDOB DuplicateReason = "NAME_DOB"
+)
+
+// Strong сообщает, что совпал документ: такой дубль нельзя создать даже с подтверждением
+func (r DuplicateReason) Strong() bool {
+	return r != DuplicateNameDOB
+}
+
+// DuplicateMatch — найденная при создании или изменении запись того же пациента.
+// ФИО не возвращается: врач может не иметь доступа к чужому пациенту.
+type DuplicateMatch struct {
+	PatientID uint              `json:"patient_id"`
+	DoctorID  uint              `json:"doctor_id"`
+	Reasons   []DuplicateReason `json:"reasons"`
+}
+
+// PatientBrief — краткие данные пациента для отчёта о дублях
+type PatientBrief struct {
+	ID          uint          `json:"id"`
+	LastName    string        `json:"last_name"`
+	FirstName   string        `json:"first_name"`
+	MiddleName  string        `json:"middle_name"`
+	DateOfBirth time.Time     `json:"date_of_birth"`
+	SNILs       string        `json:"snils"`
+	OMSPolicy   string        `json:"oms_policy"`
+	DoctorID    uint          `json:"doctor_id"`
+	Status      PatientStatus `json:"status"`
+	CreatedAt   time.Time     `json:"created_at"`
+}
+
+func NewPatientBrief(p *Patient) PatientBrief {
+	policy := p.OMSPolicy
+	if policy == "" {
+		policy = p.PolicyNumber
+	}
+	return PatientBrief{
+		ID:          p.ID,
+		LastName:    p.LastName,
+		FirstName:   p.FirstName,
+		MiddleName:  p.MiddleName,
+		DateOfBirth: p.DateOfBirth,
+		SNILs:       p.SNILs,
+		OMSPolicy:   policy,
+		DoctorID:    p.DoctorID,
+		Status:      p.Status,
+		CreatedAt:   p.CreatedAt,
+	}
+}
+
+// DuplicatePair — пара записей в отчёте; Patient создан раньше Duplicate
+type DuplicatePair struct {
+	Patient   PatientBrief      `json:"patient"`
+	Duplicate PatientBrief      `json:"duplicate"`
+	Reasons   []DuplicateReason `json:"reasons"`
+}
+
+type MergePatientsRequest struct {
+	SurvivorID  uint `json:"survivor_id" binding:"required"`
+	DuplicateID uint `json:"duplicate_id" binding:"required"`
+}
+
+// MergePatientsResult — оставшаяся запись, заполненные из дубля поля и число перенесённых записей по таблицам
+type MergePatientsResult struct {
+	Survivor     *Patient         `json:"survivor"`
+	FilledFields []string         `json:"filled_fields"`
+	Moved        map[string]int64 `json:"moved"`
+}
+
+// ChangesIdentity — запрос меняет ФИО или документы, и дубли нужно проверить заново
+func (r *UpdatePatientRequest) ChangesIdentity() bool {
+	return r.FirstName != nil || r.LastName != nil || r.MiddleName != nil || r.SNILs != nil ||
+		r.PassportSeries != nil || r.PassportNumber != nil || r.PolicyNumber != nil
+}
+
+// DuplicateReasons сравнивает две записи пациентов. Документы сравниваются по цифрам,
+// полис — в обоих полях (oms_policy и policy_number).
+func DuplicateReasons(a, b *Patient) []DuplicateReason {
+	var reasons []DuplicateReason
+
+	if s := DocumentDigits(a.SNILs); s != "" && s == DocumentDigits(b.SNILs) {
+		reasons = append(reasons, DuplicateSNILS)
+	}
+
+	if policiesIntersect(a, b) {
+		reasons = append(reasons, DuplicateOMSPolicy)
+	}
+
+	if p := DocumentDigits(a.PassportSeries + a.PassportNumber); p != "" && p == DocumentDigits(b.PassportSeries+b.PassportNumber) {
+		reasons = append(reasons, DuplicatePassport)
+	}
+
+	if !a.DateOfBirth.IsZero() && a.DateOfBirth.Format("2006-01-02") == b.DateOfBirth.Format("2006-01-02") && SimilarNames(a, b) {
+		reasons = append(reasons, DuplicateNameDOB)
+	}
+
+	return reasons
+}
+
+func policiesIntersect(a, b *Patient) bool {
+	for _, x := range []string{a.OMSPolicy, a.PolicyNumber} {
+		x = DocumentDigits(x)
+		if x == "" {
+			continue
+		}
+		for _, y := range []string{b.OMSPolicy, b.PolicyNumber} {
+			if x == DocumentDigits(y) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// SimilarNames — фамилия и имя совпадают с точностью до опечаток («ё» = «е»);
+// отчество сравнивается, только если указано в обеих записях
+func SimilarNames(a, b *Patient) bool {
+	if !similarWord(a.LastName, b.LastName) || !similarWord(a.FirstName, b.FirstName) {
+		return false
+	}
+	if a.MiddleName != "" && b.MiddleName != "" {
+		return similarWord(a.MiddleName, b.MiddleName)
+	}
+	return true
+}
+
+func similarWord(a, b string) bool {
+	ra, rb := []rune(NormalizeSearchName(a)), []rune(NormalizeSearchName(b))
+	if len(ra) == 0 || len(rb) == 0 {
+		return false
+	}
+	// В коротких словах допускается одна опечатка, в длинных — две
+	maxTypos := 1
+	if len(ra) > 6 && len(rb) > 6 {
+		maxTypos = 2
+	}
+	return editDistance(ra, rb) <= maxTypos
+}
+
+// editDistance — расстояние Дамерау–Левенштейна (перестановка соседних букв — одна опечатка)
+func editDistance(a, b []rune) int {
+	d := make([][]int, len(a)+1)
+	for i := range d {
+		d[i] = make([]int, len(b)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(a)][len(b)]
+}
+
+// DocumentDigits оставляет в номере документа только цифры
+func DocumentDigits(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsDigit(r) {
+			return r
+		}
+		return -1
+	}, s)
+}
+
+// MergePatientFields заполняет пустые поля оставшейся записи значениями из дубля
+// и возвращает имена заполненных полей
+func MergePatientFields(survivor, duplicate *Patient) []string {
+	var filled []string
+	fill := func(name string, dst *string, src string) {
+		if *dst == "" && src != "" {
+			*dst = src
+			filled = append(filled, name)
+		}
+	}
+	fill("middle_name", &survivor.MiddleName, duplicate.MiddleName)
+	fill("phone", &survivor.Phone, duplicate.Phone)
+	fill("email", &survivor.Email, duplicate.Email)
+	fill("address", &survivor.Address, duplicate.Address)
+	fill("snils", &survivor.SNILs, duplicate.SNILs)
+	fill("passport_series", &survivor.PassportSeries, duplicate.PassportSeries)
+	fill("passport_number", &survivor.PassportNumber, duplicate.PassportNumber)
+	fill("policy_number", &survivor.PolicyNumber, duplicate.PolicyNumber)
+	fill("oms_policy", &survivor.OMSPolicy, duplicate.OMSPolicy)
+	fill("gender", &survivor.Gender, duplicate.Gender)
+	fill("diagnosis", &survivor.Diagnosis, duplicate.Diagnosis)
+
+	if survivor.DateOfBirth.IsZero() && !duplicate.DateOfBirth.IsZero() {
+		survivor.DateOfBirth = duplicate.DateOfBirth
+		filled = append(filled, "date_of_birth")
+	}
+	if survivor.SurgeonID == nil && duplicate.SurgeonID != nil {
+		survivor.SurgeonID = duplicate.SurgeonID
+		filled = append(filled, "surgeon_id")
+	}
+	if survivor.SurgeryDate == nil && duplicate.SurgeryDate != nil {
+		survivor.SurgeryDate = duplicate.SurgeryDate
+		filled = append(filled, "surgery_date")
+	}
+	if duplicate.Notes != "" && duplicate.Notes != survivor.Notes {
+		if survivor.Notes != "" {
+			survivor.Notes += "\n"
+		}
+		survivor.Notes += duplicate.Notes
+		filled = append(filled, "notes")
+	}
+	return filled
+}
@@ -0,0 +1,88 @@
+package domain
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDuplicateReasons(t *testing.T) {
+	dob := time.Date(1955, 3, 14, 0, 0, 0, 0, time.UTC)
+	base := Patient{
+		LastName: "Фёдоров", FirstName: "Пётр", MiddleName: "Иванович", DateOfBirth: dob,
+		SNILs: "112-233-445 95", OMSPolicy: "1234567890123456", PassportSeries: "45 10", PassportNumber: "123456",
+	}
+
+	tests := []struct {
+		name  string
+		other Patient
+		want  []DuplicateReason
+	}{
+		{"same SNILS in other format", Patient{LastName: "Сидоров", FirstName: "Иван", SNILs: "11223344595"}, []DuplicateReason{DuplicateSNILS}},
+		{"policy in policy_number", Patient{LastName: "Сидоров", FirstName: "Иван", PolicyNumber: "1234 5678 9012 3456"}, []DuplicateReason{DuplicateOMSPolicy}},
+		{"passport", Patient{LastName: "Сидоров", FirstName: "Иван", PassportSeries: "4510", PassportNumber: "123456"}, []DuplicateReason{DuplicatePassport}},
+		{"typo in name, same DOB", Patient{LastName: "Федоров", FirstName: "Петр", MiddleName: "Ивнаович", DateOfBirth: dob}, []DuplicateReason{DuplicateNameDOB}},
+		{"no middle name, same DOB", Patient{LastName: "Федоровв", FirstName: "Петр", DateOfBirth: dob}, []DuplicateReason{DuplicateNameDOB}},
+		{"similar name, other DOB", Patient{LastName: "Федоров", FirstName: "Петр", DateOfBirth: dob.AddDate(0, 0, 1)}, nil},
+		{"other person, same DOB", Patient{LastName: "Смирнов", FirstName: "Петр", DateOfBirth: dob}, nil},
+		{"other middle name", Patient{LastName: "Федоров", FirstName: "Петр", MiddleName: "Сергеевич", DateOfBirth: dob}, nil},
+		{"empty documents do not match", Patient{LastName: "Сидоров", FirstName: "Иван"}, nil},
+		{"everything", base, []DuplicateReason{DuplicateSNILS, DuplicateOMSPolicy, DuplicatePassport, DuplicateNameDOB}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DuplicateReasons(&base, &tt.other); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DuplicateReasons() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	empty := Patient{LastName: "Иванов", FirstName: "Иван"}
+	if got := DuplicateReasons(&empty, &empty); got != nil {
+		t.Errorf("records without DOB and documents matched: %v", got)
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"иванов", "иванов", 0},
+		{"иванов", "иваноф", 1},
+		{"иванов", "ивнаов", 1},
+		{"иванов", "иванова", 1},
+		{"иванов", "петров", 4},
+		{"", "abc", 3},
+	}
+	for _, tt := range tests {
+		if got := editDistance([]rune(tt.a), []rune(tt.b)); got != tt.want {
+			t.Errorf("editDistance(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestMergePatientFields(t *testing.T) {
+	surgeonID := uint(7)
+	survivor := Patient{LastName: "Иванов", FirstName: "Иван", Phone: "+79001234567", Notes: "аллергия на йод"}
+	duplicate := Patient{
+		LastName: "Иванов", FirstName: "Иван", MiddleName: "Петрович", Phone: "+79990000000",
+		SNILs: "11223344595", SurgeonID: &surgeonID, Notes: "глаукома в анамнезе",
+	}
+
+	filled := MergePatientFields(&survivor, &duplicate)
+
+	want := []string{"middle_name", "snils", "surgeon_id", "notes"}
+	if !reflect.DeepEqual(filled, want) {
+		t.Errorf("filled = %v, want %v", filled, want)
+	}
+	if survivor.Phone != "+79001234567" {
+		t.Errorf("existing phone overwritten: %q", survivor.Phone)
+	}
+	if survivor.SurgeonID == nil || *survivor.SurgeonID != surgeonID {
+		t.Errorf("surgeon not taken from duplicate")
+	}
+	if survivor.Notes != "аллергия на йод\nглаукома в анамнезе" {
+		t.Errorf("notes = %q", survivor.Notes)
+	}
+}
@@ -0,0 +1,79 @@
+package domain
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Сортировка списка пациентов
+const (
+	PatientSortUpdated     = "updated_at"
+	PatientSortCreated     = "created_at"
+	PatientSortName        = "name"
+	PatientSortBirthDate   = "date_of_birth"
+	PatientSortSurgeryDate = "surgery_date"
+	// PatientSortRelevance — по близости к строке поиска; без поиска — как updated_at
+	PatientSortRelevance = "relevance"
+)
+
+func ValidPatientSort(sort string) bool {
+	switch sort {
+	case PatientSortUpdated, PatientSortCreated, PatientSortName, PatientSortBirthDate, PatientSortSurgeryDate, PatientSortRelevance:
+		return true
+	}
+	return false
+}
+
+// Фильтр по готовности чек-листа
+const (
+	ChecklistComplete   = "complete"
+	ChecklistIncomplete = "incomplete"
+)
+
+// FacetCount — значение фильтра и число пациентов с ним
+type FacetCount struct {
+	Value string `json:"value"`
+	Label string `json:"label"`
+	Count int64  `json:"count"`
+}
+
+// PatientFacets — счётчики по значениям фильтров. Каждый фасет считается
+// со всеми фильтрами, кроме своего, чтобы были видны альтернативы.
+type PatientFacets struct {
+	Statuses       []FacetCount `json:"statuses"`
+	Districts      []FacetCount `json:"districts"`
+	Surgeons       []FacetCount `json:"surgeons"`
+	OperationTypes []FacetCount `json:"operation_types"`
+	Checklist      []FacetCount `json:"checklist"`
+}
+
+type PatientSearchResult struct {
+	Patients []Patient     `json:"patients"`
+	Facets   PatientFacets `json:"facets"`
+}
+
+// NormalizeSearchName готовит строку для поиска по ФИО: нижний регистр,
+// «ё» как «е», одиночные пробелы
+func NormalizeSearchName(s string) string {
+	s = strings.ToLower(strings.ReplaceAll(strings.ReplaceAll(s, "ё", "е"), "Ё", "Е"))
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// SearchDigits возвращает цифры строки поиска, если она похожа на номер
+// (СНИЛС, полис, телефон): только цифры и разделители, не меньше четырёх цифр
+func SearchDigits(s string) (string, bool) {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case unicode.IsDigit(r):
+			b.WriteRune(r)
+		case unicode.IsSpace(r) || strings.ContainsRune("-+()", r):
+		default:
+			return "", false
+		}
+	}
+	if b.Len() < 4 {
+		return "", false
+	}
+	return b.String(), true
+}
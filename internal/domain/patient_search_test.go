@@ -0,0 +1,40 @@
+package domain
+
+import "testing"
+
+func TestNormalizeSearchName(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"Петров", "петров"},
+		{"  Фёдоров   Пётр ", "федоров петр"},
+		{"ЁЛКИН", "елкин"},
+		{"Smith John", "smith john"},
+	}
+	for _, tt := range tests {
+		if got := NormalizeSearchName(tt.in); got != tt.want {
+			t.Errorf("NormalizeSearchName(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestSearchDigits(t *testing.T) {
+	tests := []struct {
+		in     string
+		want   string
+		wantOK bool
+	}{
+		{"112-233-445 95", "11223344595", true},
+		{"+7 (900) 123-45-67", "79001234567", true},
+		{"1234567890123456", "1234567890123456", true},
+		{"123", "", false},
+		{"Петров 1955", "", false},
+		{"abcd1234", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := SearchDigits(tt.in)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("SearchDigits(%q) = %q, %v, want %q, %v", tt.in, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
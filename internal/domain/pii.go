@@ -0,0 +1,110 @@
+package domain
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/beercut-team/backend-boilerplate/pkg/pii"
+	"gorm.io/gorm/schema"
+)
+
+// PIIFields — ключи JSON с персональными данными пациента (152-ФЗ). В базе эти поля
+// хранятся зашифрованными, а в журналах (аудит, очередь синхронизации) значения скрываются.
+var PIIFields = map[string]bool{
+	"snils":           true,
+	"passport_series": true,
+	"passport_number": true,
+	"policy_number":   true,
+	"oms_policy":      true,
+	"phone":           true,
+	"address":         true,
+}
+
+// PatientColumns — колонки patients по ключам JSON из PIIFields и по именам полей
+// слепых индексов. Имена берутся из схемы GORM: они не всегда совпадают
+// с ключами JSON (поле SNILs хранится в колонке sni_ls)
+func PatientColumns() (columns, hashes map[string]string, err error) {
+	s, err := schema.Parse(&Patient{}, &sync.Map{}, schema.NamingStrategy{})
+	if err != nil {
+		return nil, nil, err
+	}
+	columns = make(map[string]string, len(PIIFields))
+	hashes = make(map[string]string)
+	for _, field := range s.Fields {
+		if field.DBName == "" {
+			continue
+		}
+		key, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if PIIFields[key] {
+			columns[key] = field.DBName
+		}
+		if strings.HasSuffix(field.Name, "Hash") {
+			hashes[field.Name] = field.DBName
+		}
+	}
+	for key := range PIIFields {
+		if _, ok := columns[key]; !ok {
+			return nil, nil, fmt.Errorf("нет колонки для поля ПДн %s", key)
+		}
+	}
+	return columns, hashes, nil
+}
+
+// RedactedValue подставляется в журналы вместо значения поля с ПДн
+const RedactedValue = "***"
+
+// PhoneDigits — последние 10 цифр телефона: +7 и 8 в начале номера не влияют на совпадение
+func PhoneDigits(s string) string {
+	digits := DocumentDigits(s)
+	if len(digits) > 10 {
+		digits = digits[len(digits)-10:]
+	}
+	return digits
+}
+
+// SetBlindIndexes пересчитывает слепые индексы по открытым значениям документов и телефона
+func (p *Patient) SetBlindIndexes() {
+	p.SNILSHash = pii.BlindIndex(DocumentDigits(p.SNILs))
+	p.OMSPolicyHash = pii.BlindIndex(DocumentDigits(p.OMSPolicy))
+	p.PolicyNumberHash = pii.BlindIndex(DocumentDigits(p.PolicyNumber))
+	p.PassportHash = pii.BlindIndex(DocumentDigits(p.PassportSeries + p.PassportNumber))
+	p.PhoneHash = pii.BlindIndex(PhoneDigits(p.Phone))
+}
+
+// RedactPII возвращает JSON, в котором непустые значения полей ПДн и секретов
+// (auditSecretFields) на любой глубине заменены на RedactedValue. Если JSON не разбирается, возвращает пустую строку —
+// записать его как есть нельзя.
+func RedactPII(raw []byte) string {
+	if len(raw) == 0 {
+		return ""
+	}
+	var v interface{}
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return ""
+	}
+	b, err := json.Marshal(redactValue(v))
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
+
+func redactValue(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for key, value := range t {
+			if (PIIFields[key] || auditSecretFields[key]) && value != nil && value != "" {
+				t[key] = RedactedValue
+				continue
+			}
+			t[key] = redactValue(value)
+		}
+	case []interface{}:
+		for i := range t {
+			t[i] = redactValue(t[i])
+		}
+	}
+	return v
+}
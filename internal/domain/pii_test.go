@@ -0,0 +1,56 @@
+package domain
+
+import "testing"
+
+func TestRedactPII(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"flat", `{"first_name":"Иван","snils":"112-233-445 95","phone":""}`, `{"first_name":"Иван","phone":"","snils":"***"}`},
+		{"nested", `{"mutations":[{"payload":{"passport_number":"123456","oms_policy":null}}]}`, `{"mutations":[{"payload":{"oms_policy":null,"passport_number":"***"}}]}`},
+		{"secret", `{"access_code":"abcd1234","id":3}`, `{"access_code":"***","id":3}`},
+		{"not pii", `{"status":"DRAFT"}`, `{"status":"DRAFT"}`},
+		{"empty", ``, ``},
+		{"invalid", `{"snils":`, ``},
+	}
+	for _, tt := range tests {
+		if got := RedactPII([]byte(tt.in)); got != tt.want {
+			t.Errorf("%s: RedactPII = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestPhoneDigits(t *testing.T) {
+	for in, want := range map[string]string{
+		"+7 (900) 123-45-67": "9001234567",
+		"8 900 123 45 67":    "9001234567",
+		"123-45":             "12345",
+	} {
+		if got := PhoneDigits(in); got != want {
+			t.Errorf("PhoneDigits(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestPatientColumns(t *testing.T) {
+	columns, hashes, err := PatientColumns()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for key := range PIIFields {
+		if columns[key] == "" {
+			t.Errorf("поле ПДн %s без колонки", key)
+		}
+	}
+	// Имя колонки СНИЛС задаёт схема GORM, а не ключ JSON
+	if columns["snils"] != "sni_ls" {
+		t.Errorf("snils column = %q, want sni_ls", columns["snils"])
+	}
+	for _, field := range []string{"SNILSHash", "OMSPolicyHash", "PolicyNumberHash", "PassportHash", "PhoneHash"} {
+		if hashes[field] == "" {
+			t.Errorf("нет колонки слепого индекса %s", field)
+		}
+	}
+}
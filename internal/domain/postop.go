@@ -0,0 +1,206 @@
+package domain
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// StableRefractionDays — через сколько дней после операции рефракция
+// считается стабильной и идёт в статистику ошибки прогноза
+const StableRefractionDays = 21
+
+// MinOptimizationCases — число исходов, с которого персональная A-константа надёжна
+const MinOptimizationCases = 20
+
+// PostOpVisit — послеоперационный осмотр: фактическая рефракция, острота зрения и ВГД.
+// Связан с операцией и расчётом ИОЛ, по которому выбиралась линза.
+type PostOpVisit struct {
+	ID               uint      `gorm:"primaryKey" json:"id"`
+	SurgeryID        uint      `gorm:"index;not null" json:"surgery_id"`
+	PatientID        uint      `gorm:"index;not null" json:"patient_id"`
+	IOLCalculationID *uint     `gorm:"index" json:"iol_calculation_id,omitempty"`
+	Eye              string    `gorm:"type:varchar(5)" json:"eye"`
+	VisitDate        time.Time `gorm:"not null" json:"visit_date"`
+	// Манифестная рефракция в плоскости очков
+	Sphere   float64 `gorm:"not null" json:"sphere"`
+	Cylinder float64 `json:"cylinder"`
+	Axis     float64 `json:"axis"`
+	// SphericalEquivalent — sphere + cylinder/2
+	SphericalEquivalent float64 `gorm:"not null" json:"spherical_equivalent"`
+	// Острота зрения без коррекции и с коррекцией, десятичная
+	UCVA *float64 `json:"ucva,omitempty"`
+	BCVA *float64 `json:"bcva,omitempty"`
+	// IOP — внутриглазное давление, мм рт. ст.
+	IOP *float64 `json:"iop,omitempty"`
+	// ImplantedPower — мощность имплантированной линзы; по умолчанию из расчёта
+	ImplantedPower *float64 `json:"implanted_power,omitempty"`
+	// PredictionError — фактический сферический эквивалент минус прогноз расчёта, D
+	PredictionError *float64  `json:"prediction_error,omitempty"`
+	Notes           string    `gorm:"type:text" json:"notes"`
+	RecordedBy      uint      `json:"recorded_by"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// SphericalEquivalentOf — сферический эквивалент рефракции
+func SphericalEquivalentOf(sphere, cylinder float64) float64 {
+	return math.Round((sphere+cylinder/2)*100) / 100
+}
+
+// --- Requests ---
+
+type CreatePostOpVisitRequest struct {
+	IOLCalculationID *uint `json:"iol_calculation_id"`
+	// ГГГГ-ММ-ДД; по умолчанию сегодня
+	VisitDate      string   `json:"visit_date"`
+	Sphere         *float64 `json:"sphere" binding:"required"`
+	Cylinder       float64  `json:"cylinder"`
+	Axis           float64  `json:"axis"`
+	UCVA           *float64 `json:"ucva"`
+	BCVA           *float64 `json:"bcva"`
+	IOP            *float64 `json:"iop"`
+	ImplantedPower *float64 `json:"implanted_power"`
+	Notes          string   `json:"notes"`
+}
+
+type UpdatePostOpVisitRequest struct {
+	IOLCalculationID *uint    `json:"iol_calculation_id"`
+	VisitDate        *string  `json:"visit_date"`
+	Sphere           *float64 `json:"sphere"`
+	Cylinder         *float64 `json:"cylinder"`
+	Axis             *float64 `json:"axis"`
+	UCVA             *float64 `json:"ucva"`
+	BCVA             *float64 `json:"bcva"`
+	IOP              *float64 `json:"iop"`
+	ImplantedPower   *float64 `json:"implanted_power"`
+	Notes            *string  `json:"notes"`
+}
+
+// PostOpOutcome — исход для статистики: последний стабильный осмотр после операции
+// вместе с биометрией и прогнозом расчёта
+type PostOpOutcome struct {
+	VisitID             uint
+	SurgeryID           uint
+	SurgeonID           uint
+	LensID              *uint
+	Formula             string
+	AxialLength         float64
+	AverageK            float64
+	AConstant           float64
+	ImplantedPower      float64
+	PredictedRefraction float64
+	ActualRefraction    float64
+	SurgeryDate         time.Time
+	VisitDate           time.Time
+}
+
+// PredictionError — ошибка прогноза: фактическая рефракция минус ожидаемая
+func (o PostOpOutcome) PredictionError() float64 {
+	return o.ActualRefraction - o.PredictedRefraction
+}
+
+// Stable — осмотр проведён не раньше StableRefractionDays после операции
+func (o PostOpOutcome) Stable() bool {
+	return o.VisitDate.Sub(o.SurgeryDate) >= StableRefractionDays*24*time.Hour
+}
+
+// --- Responses ---
+
+// PredictionErrorStats — статистика ошибки прогноза рефракции, D.
+// Доли — проценты исходов в пределах ±0.25, ±0.5 и ±1.0 D.
+type PredictionErrorStats struct {
+	Cases               int     `json:"cases"`
+	MeanError           float64 `json:"mean_error"`
+	MeanAbsoluteError   float64 `json:"mean_absolute_error"`
+	MedianAbsoluteError float64 `json:"median_absolute_error"`
+	StdDev              float64 `json:"std_dev"`
+	WithinQuarter       float64 `json:"within_025"`
+	WithinHalf          float64 `json:"within_050"`
+	WithinOne           float64 `json:"within_100"`
+}
+
+// NewPredictionErrorStats считает статистику по ошибкам прогноза
+func NewPredictionErrorStats(errs []float64) PredictionErrorStats {
+	stats := PredictionErrorStats{Cases: len(errs)}
+	if len(errs) == 0 {
+		return stats
+	}
+
+	abs := make([]float64, len(errs))
+	var sum, sumAbs float64
+	var quarter, half, one int
+	for i, e := range errs {
+		abs[i] = math.Abs(e)
+		sum += e
+		sumAbs += abs[i]
+		// Допуск на погрешность float: 0.25 должно попасть в ±0.25
+		switch {
+		case abs[i] <= 0.25+1e-9:
+			quarter++
+			fallthrough
+		case abs[i] <= 0.5+1e-9:
+			half++
+			fallthrough
+		case abs[i] <= 1.0+1e-9:
+			one++
+		}
+	}
+	n := float64(len(errs))
+	mean := sum / n
+
+	var variance float64
+	for _, e := range errs {
+		variance += (e - mean) * (e - mean)
+	}
+	if len(errs) > 1 {
+		variance /= n - 1
+	}
+
+	sort.Float64s(abs)
+	median := abs[len(abs)/2]
+	if len(abs)%2 == 0 {
+		median = (abs[len(abs)/2-1] + abs[len(abs)/2]) / 2
+	}
+
+	stats.MeanError = round2(mean)
+	stats.MeanAbsoluteError = round2(sumAbs / n)
+	stats.MedianAbsoluteError = round2(median)
+	stats.StdDev = round2(math.Sqrt(variance))
+	stats.WithinQuarter = round2(float64(quarter) / n * 100)
+	stats.WithinHalf = round2(float64(half) / n * 100)
+	stats.WithinOne = round2(float64(one) / n * 100)
+	return stats
+}
+
+// OutcomeGroupStats — статистика по хирургу или модели линзы
+type OutcomeGroupStats struct {
+	SurgeonID   *uint  `json:"surgeon_id,omitempty"`
+	SurgeonName string `json:"surgeon_name,omitempty"`
+	LensID      *uint  `json:"lens_id,omitempty"`
+	LensName    string `json:"lens_name,omitempty"`
+	PredictionErrorStats
+}
+
+type OutcomeStats struct {
+	Overall   PredictionErrorStats `json:"overall"`
+	BySurgeon []OutcomeGroupStats  `json:"by_surgeon"`
+	ByLens    []OutcomeGroupStats  `json:"by_lens"`
+}
+
+// AConstantOptimization — персональная A-константа SRK/T по исходам хирурга.
+// Ошибки «до» и «после» пересчитаны SRK/T для имплантированных линз.
+type AConstantOptimization struct {
+	SurgeonID          uint                 `json:"surgeon_id"`
+	LensID             *uint                `json:"lens_id,omitempty"`
+	Cases              int                  `json:"cases"`
+	CurrentAConstant   float64              `json:"current_a_constant"`
+	OptimizedAConstant float64              `json:"optimized_a_constant"`
+	Before             PredictionErrorStats `json:"before"`
+	After              PredictionErrorStats `json:"after"`
+	Warnings           []string             `json:"warnings,omitempty"`
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,69 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewPredictionErrorStats(t *testing.T) {
+	tests := []struct {
+		name string
+		errs []float64
+		want PredictionErrorStats
+	}{
+		{"no cases", nil, PredictionErrorStats{}},
+		{
+			"single case",
+			[]float64{-0.25},
+			PredictionErrorStats{Cases: 1, MeanError: -0.25, MeanAbsoluteError: 0.25, MedianAbsoluteError: 0.25, WithinQuarter: 100, WithinHalf: 100, WithinOne: 100},
+		},
+		{
+			// Миопический сдвиг: средняя ошибка отрицательная, MAE больше |ME|
+			"myopic shift",
+			[]float64{-0.5, -0.25, 0.25, -1.0},
+			PredictionErrorStats{Cases: 4, MeanError: -0.38, MeanAbsoluteError: 0.5, MedianAbsoluteError: 0.38, StdDev: 0.52, WithinQuarter: 50, WithinHalf: 75, WithinOne: 100},
+		},
+		{
+			"outlier",
+			[]float64{0.1, 0.2, 1.5},
+			PredictionErrorStats{Cases: 3, MeanError: 0.6, MeanAbsoluteError: 0.6, MedianAbsoluteError: 0.2, StdDev: 0.78, WithinQuarter: 66.67, WithinHalf: 66.67, WithinOne: 66.67},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NewPredictionErrorStats(tt.errs); got != tt.want {
+				t.Errorf("NewPredictionErrorStats() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPostOpOutcome(t *testing.T) {
+	surgery := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name       string
+		visit      time.Time
+		wantStable bool
+	}{
+		{"next day", surgery.AddDate(0, 0, 1), false},
+		{"two weeks", surgery.AddDate(0, 0, 14), false},
+		{"three weeks", surgery.AddDate(0, 0, StableRefractionDays), true},
+		{"three months", surgery.AddDate(0, 3, 0), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := PostOpOutcome{SurgeryDate: surgery, VisitDate: tt.visit, PredictedRefraction: -0.3, ActualRefraction: -0.75}
+			if got := o.Stable(); got != tt.wantStable {
+				t.Errorf("Stable() = %v, want %v", got, tt.wantStable)
+			}
+			if got := round2(o.PredictionError()); got != -0.45 {
+				t.Errorf("PredictionError() = %v, want -0.45", got)
+			}
+		})
+	}
+
+	if got := SphericalEquivalentOf(-0.5, -1.25); got != -1.13 {
+		t.Errorf("SphericalEquivalentOf() = %v, want -1.13", got)
+	}
+}
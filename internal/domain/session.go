@@ -0,0 +1,148 @@
+package domain
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"math/big"
+	"strings"
+	"time"
+)
+
+// SessionSubject — чья сессия: ID сотрудника и ID пациента пересекаются
+type SessionSubject string
+
+const (
+	SessionSubjectUser    SessionSubject = "USER"
+	SessionSubjectPatient SessionSubject = "PATIENT"
+)
+
+// Причины завершения сессии
+const (
+	SessionRevokedLogout = "LOGOUT"
+	SessionRevokedByUser = "REVOKED"
+	// Предъявлен уже заменённый токен обновления: токен мог быть украден,
+	// поэтому завершается вся цепочка
+	SessionRevokedReuse = "REFRESH_REUSE"
+	SessionRevoked2FA   = "2FA_CHANGED"
+	// Изменения учётной записи сотрудника: все его токены отзываются сразу
+	SessionRevokedDeactivated = "DEACTIVATED"
+	SessionRevokedRoleChanged = "ROLE_CHANGED"
+	SessionRevokedPassword    = "PASSWORD_CHANGED"
+)
+
+// Session — вход с устройства. Токен обновления меняется при каждом обновлении,
+// хранится только хеш последнего выданного.
+type Session struct {
+	ID           uint           `gorm:"primaryKey" json:"id"`
+	UserID       uint           `gorm:"not null;index:idx_sessions_subject" json:"user_id"`
+	Subject      SessionSubject `gorm:"type:varchar(10);not null;index:idx_sessions_subject" json:"subject"`
+	RefreshHash  string         `gorm:"type:varchar(64);not null" json:"-"`
+	UserAgent    string         `gorm:"type:varchar(255)" json:"user_agent"`
+	IP           string         `gorm:"type:varchar(45)" json:"ip"`
+	LastUsedAt   time.Time      `gorm:"not null" json:"last_used_at"`
+	ExpiresAt    time.Time      `gorm:"not null;index" json:"expires_at"`
+	RevokedAt    *time.Time     `json:"revoked_at,omitempty"`
+	RevokeReason string         `gorm:"type:varchar(20)" json:"revoke_reason,omitempty"`
+	CreatedAt    time.Time      `json:"created_at"`
+
+	// Текущая сессия запроса; не хранится
+	Current bool `gorm:"-" json:"current"`
+}
+
+func (s *Session) Active(now time.Time) bool {
+	return s.RevokedAt == nil && now.Before(s.ExpiresAt)
+}
+
+// HashToken — SHA-256 токена: в базе не хранятся предъявляемые секреты
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// ClientInfo — устройство и адрес, с которых выполняется вход
+type ClientInfo struct {
+	UserAgent string
+	IP        string
+}
+
+// RecoveryCode — одноразовый резервный код входа при потере телефона
+type RecoveryCode struct {
+	ID        uint   `gorm:"primaryKey"`
+	UserID    uint   `gorm:"not null;index"`
+	CodeHash  string `gorm:"type:varchar(64);not null"`
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+const RecoveryCodeCount = 10
+
+// Без похожих символов (0/o, 1/l/i): коды переписывают с бумаги
+const recoveryAlphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+
+// NewRecoveryCodes — n кодов вида xxxxx-xxxxx (около 49 бит каждый)
+func NewRecoveryCodes(n int) ([]string, error) {
+	codes := make([]string, 0, n)
+	max := big.NewInt(int64(len(recoveryAlphabet)))
+	for i := 0; i < n; i++ {
+		var b strings.Builder
+		for j := 0; j < 10; j++ {
+			if j == 5 {
+				b.WriteByte('-')
+			}
+			k, err := rand.Int(rand.Reader, max)
+			if err != nil {
+				return nil, err
+			}
+			b.WriteByte(recoveryAlphabet[k.Int64()])
+		}
+		codes = append(codes, b.String())
+	}
+	return codes, nil
+}
+
+// NormalizeRecoveryCode приводит введённый код к виду, от которого считается хеш
+func NormalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
+
+// --- Requests ---
+
+type TwoFactorSetupRequest struct {
+	Password string `json:"password" binding:"required"`
+}
+
+type TwoFactorCodeRequest struct {
+	// Код из приложения или резервный код
+	Code string `json:"code" binding:"required"`
+}
+
+type TwoFactorDisableRequest struct {
+	Password string `json:"password" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+}
+
+type TwoFactorVerifyRequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+	Code           string `json:"code" binding:"required"`
+}
+
+// --- Responses ---
+
+type TwoFactorSetupResponse struct {
+	Secret     string `json:"secret"`
+	OTPAuthURL string `json:"otpauth_url"`
+}
+
+// RecoveryCodesResponse — резервные коды показываются один раз
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// TwoFactorChallengeResponse — пароль верный, нужен второй фактор
+type TwoFactorChallengeResponse struct {
+	TwoFactorRequired bool   `json:"two_factor_required"`
+	ChallengeToken    string `json:"challenge_token"`
+	ExpiresIn         int    `json:"expires_in"`
+}
@@ -0,0 +1,61 @@
+package domain
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewRecoveryCodes(t *testing.T) {
+	codes, err := NewRecoveryCodes(RecoveryCodeCount)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(codes) != RecoveryCodeCount {
+		t.Fatalf("got %d codes", len(codes))
+	}
+	seen := map[string]bool{}
+	for _, c := range codes {
+		if len(c) != 11 || c[5] != '-' {
+			t.Errorf("unexpected format %q", c)
+		}
+		if strings.ContainsAny(NormalizeRecoveryCode(c), "01ilo") {
+			t.Errorf("ambiguous symbol in %q", c)
+		}
+		if seen[c] {
+			t.Errorf("duplicate code %q", c)
+		}
+		seen[c] = true
+	}
+}
+
+func TestNormalizeRecoveryCode(t *testing.T) {
+	for in, want := range map[string]string{
+		"abcde-fghjk":   "abcdefghjk",
+		" ABCDE FGHJK ": "abcdefghjk",
+		"abcdefghjk":    "abcdefghjk",
+	} {
+		if got := NormalizeRecoveryCode(in); got != want {
+			t.Errorf("NormalizeRecoveryCode(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestSessionActive(t *testing.T) {
+	now := time.Now()
+	revoked := now.Add(-time.Minute)
+	tests := []struct {
+		name    string
+		session Session
+		want    bool
+	}{
+		{"active", Session{ExpiresAt: now.Add(time.Hour)}, true},
+		{"expired", Session{ExpiresAt: now.Add(-time.Second)}, false},
+		{"revoked", Session{ExpiresAt: now.Add(time.Hour), RevokedAt: &revoked}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.session.Active(now); got != tt.want {
+			t.Errorf("%s: Active = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -16,23 +16,31 @@ type Surgery struct {
 	Patient       *Patient      `gorm:"foreignKey:PatientID" json:"patient,omitempty"`
 	SurgeonID     uint          `gorm:"index;not null" json:"surgeon_id"`
 	Surgeon       *User         `gorm:"foreignKey:SurgeonID" json:"surgeon,omitempty"`
-	ScheduledDate time.Time     `gorm:"not null" json:"scheduled_date"`
+	ScheduledDate time.Time     `gorm:"not null;index" json:"scheduled_date"` // дата и время начала
 	OperationType OperationType `gorm:"type:varchar(30);not null" json:"operation_type"`
-	Eye           string        `gorm:"type:varchar(5)" json:"eye"`
-	Status        SurgeryStatus `gorm:"type:varchar(20);default:'SCHEDULED';not null" json:"status"`
-	Notes         string        `gorm:"type:text" json:"notes"`
-	CreatedAt     time.Time     `json:"created_at"`
-	UpdatedAt     time.Time     `json:"updated_at"`
+	// Длительность в минутах; 0 у операций, назначенных до появления календаря
+	DurationMinutes int            `gorm:"default:0;not null" json:"duration_minutes"`
+	RoomID          *uint          `gorm:"index" json:"room_id"`
+	Room            *OperatingRoom `gorm:"foreignKey:RoomID" json:"room,omitempty"`
+	Eye             string         `gorm:"type:varchar(5)" json:"eye"`
+	Status          SurgeryStatus  `gorm:"type:varchar(20);default:'SCHEDULED';not null" json:"status"`
+	Notes           string         `gorm:"type:text" json:"notes"`
+	CreatedAt       time.Time      `json:"created_at"`
+	UpdatedAt       time.Time      `json:"updated_at"`
 }
 
 type CreateSurgeryRequest struct {
-	PatientID     uint   `json:"patient_id" binding:"required"`
+	PatientID uint `json:"patient_id" binding:"required"`
+	// ГГГГ-ММ-ДДTЧЧ:ММ или ISO 8601; при одной дате выбирается первый свободный слот
 	ScheduledDate string `json:"scheduled_date" binding:"required"`
+	RoomID        *uint  `json:"room_id"`
+	SurgeonID     *uint  `json:"surgeon_id"` // для администратора; хирург записывает себя
 	Notes         string `json:"notes"`
 }
 
 type UpdateSurgeryRequest struct {
 	ScheduledDate *string `json:"scheduled_date"`
+	RoomID        *uint   `json:"room_id"`
 	Status        *string `json:"status"`
 	Notes         *string `json:"notes"`
 }
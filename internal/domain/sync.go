@@ -1,32 +1,218 @@
 package domain
 
-import "time"
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"time"
 
+	"gorm.io/gorm"
+)
+
+// Сущности, изменяемые через офлайн-синхронизацию
+const (
+	SyncEntityPatient       = "patient"
+	SyncEntityChecklistItem = "checklist_item"
+	SyncEntityComment       = "comment"
+)
+
+const (
+	SyncActionCreate = "CREATE"
+	SyncActionUpdate = "UPDATE"
+	SyncActionDelete = "DELETE"
+	SyncActionUpsert = "UPSERT"
+)
+
+// Результаты применения мутации
+const (
+	SyncResultApplied  = "applied"
+	SyncResultConflict = "conflict"
+	SyncResultRejected = "rejected"
+)
+
+// SyncVersionSequence — последовательность Postgres, из которой выдаются версии синхронизации
+const SyncVersionSequence = "sync_version_seq"
+
+// SyncVersionFunction выдаёт версию из SyncVersionSequence. При первом вызове в транзакции
+// она берёт advisory-блокировку (пространство SyncVersionLockSpace) с нижней границей
+// версий транзакции, чтобы pull не отдавал версии выше незафиксированных.
+const (
+	SyncVersionFunction  = "next_sync_version"
+	SyncVersionLockSpace = 21337
+)
+
+// SyncQueue — журнал мутаций, полученных от клиентов
 type SyncQueue struct {
 	ID         uint      `gorm:"primaryKey" json:"id"`
 	UserID     uint      `gorm:"index;not null" json:"user_id"`
+	ClientID   string    `gorm:"type:varchar(64);index" json:"client_id,omitempty"`
 	Entity     string    `gorm:"type:varchar(50);not null;index" json:"entity"`
 	EntityID   uint      `gorm:"not null" json:"entity_id"`
 	Action     string    `gorm:"type:varchar(20);not null" json:"action"` // CREATE, UPDATE, DELETE
 	Payload    string    `gorm:"type:text" json:"payload"`
+	Status     string    `gorm:"type:varchar(20)" json:"status"` // applied, conflict, rejected
+	Error      string    `gorm:"type:text" json:"error,omitempty"`
 	ClientTime time.Time `json:"client_time"`
 	ServerTime time.Time `gorm:"autoCreateTime" json:"server_time"`
 	Synced     bool      `gorm:"default:false;index" json:"synced"`
 }
 
+// SyncTombstone фиксирует удаление записи, чтобы клиенты узнали о нём при pull.
+// Хранит владельца записи на момент удаления для проверки видимости.
+type SyncTombstone struct {
+	ID          uint          `gorm:"primaryKey" json:"id"`
+	Entity      string        `gorm:"type:varchar(50);not null" json:"entity"`
+	EntityID    uint          `gorm:"not null" json:"entity_id"`
+	PatientID   uint          `gorm:"index;not null" json:"patient_id"`
+	DoctorID    uint          `gorm:"index" json:"doctor_id"`
+	DistrictID  uint          `gorm:"index;not null;default:0" json:"district_id"`
+	SurgeonID   *uint         `gorm:"index" json:"surgeon_id"`
+	Status      PatientStatus `gorm:"type:varchar(30)" json:"status"`
+	SyncVersion int64         `gorm:"index;not null;default:0" json:"sync_version"`
+	CreatedAt   time.Time     `json:"created_at"`
+}
+
+// NewSyncTombstone копирует из пациента поля, по которым pull применяет ReadScope
+func NewSyncTombstone(entity string, entityID uint, p *Patient) *SyncTombstone {
+	return &SyncTombstone{
+		Entity:     entity,
+		EntityID:   entityID,
+		PatientID:  p.ID,
+		DoctorID:   p.DoctorID,
+		DistrictID: p.DistrictID,
+		SurgeonID:  p.SurgeonID,
+		Status:     p.Status,
+	}
+}
+
 type SyncPushRequest struct {
 	Mutations []SyncMutation `json:"mutations" binding:"required"`
 }
 
 type SyncMutation struct {
-	Entity     string      `json:"entity" binding:"required"`
-	EntityID   uint        `json:"entity_id"`
-	Action     string      `json:"action" binding:"required"`
-	Payload    interface{} `json:"payload"`
-	ClientTime string      `json:"client_time" binding:"required"`
+	ClientID string `json:"client_id"` // идентификатор мутации на клиенте, повторная отправка не применяется дважды
+	Entity   string `json:"entity" binding:"required"`
+	EntityID uint   `json:"entity_id"`
+	Action   string `json:"action" binding:"required"`
+	// Версия записи, от которой клиент вносил изменения (sync_version или updated_at)
+	BaseVersion   *int64          `json:"base_version"`
+	BaseUpdatedAt string          `json:"base_updated_at"`
+	Payload       json.RawMessage `json:"payload"`
+	ClientTime    string          `json:"client_time" binding:"required"`
+}
+
+// Conflicts сообщает, изменилась ли запись на сервере после версии, известной клиенту.
+// Приоритет у base_version; base_updated_at используется клиентами без версий.
+func (m SyncMutation) Conflicts(serverVersion int64, serverUpdatedAt time.Time) (bool, error) {
+	if m.BaseVersion != nil {
+		return *m.BaseVersion != serverVersion, nil
+	}
+	if m.BaseUpdatedAt == "" {
+		return false, errors.New("не указана базовая версия записи (base_version или base_updated_at)")
+	}
+	base, err := time.Parse(time.RFC3339Nano, m.BaseUpdatedAt)
+	if err != nil {
+		return false, errors.New("неверный формат base_updated_at, используйте ISO 8601")
+	}
+	return serverUpdatedAt.After(base), nil
+}
+
+type SyncMutationResult struct {
+	Index    int    `json:"index"`
+	ClientID string `json:"client_id,omitempty"`
+	Entity   string `json:"entity"`
+	EntityID uint   `json:"entity_id,omitempty"`
+	Action   string `json:"action"`
+	Status   string `json:"status"` // applied, conflict, rejected
+	Version  int64  `json:"version,omitempty"`
+	Error    string `json:"error,omitempty"`
+	// Сохранённая запись при applied или серверная копия при conflict
+	Data interface{} `json:"data,omitempty"`
+}
+
+type SyncPushResponse struct {
+	Results   []SyncMutationResult `json:"results"`
+	Applied   int                  `json:"applied"`
+	Conflicts int                  `json:"conflicts"`
+	Rejected  int                  `json:"rejected"`
+}
+
+// SyncChange — изменение на сервере, отдаваемое клиенту при pull
+type SyncChange struct {
+	Entity   string      `json:"entity"`
+	EntityID uint        `json:"entity_id"`
+	Action   string      `json:"action"` // UPSERT, DELETE
+	Version  int64       `json:"version"`
+	Data     interface{} `json:"data,omitempty"`
 }
 
 type SyncPullResponse struct {
-	Changes []SyncQueue `json:"changes"`
-	Since   string      `json:"since"`
+	Changes []SyncChange `json:"changes"`
+	Cursor  int64        `json:"cursor"`
+	HasMore bool         `json:"has_more"`
+}
+
+// SyncWatermark — граница стабильных версий для pull. Версии выдаются до фиксации
+// транзакций, и транзакция с меньшей версией может зафиксироваться позже большей:
+// курсор, ушедший за такую версию, пропустил бы запись. Граница — минимум из следующего
+// значения последовательности и нижних границ версий незафиксированных транзакций;
+// pull отдаёт только версии ниже неё.
+func SyncWatermark(next int64, inFlight []int64) int64 {
+	watermark := next
+	for _, v := range inFlight {
+		if v < watermark {
+			watermark = v
+		}
+	}
+	return watermark
+}
+
+// PageSyncChanges упорядочивает изменения по версии и отрезает страницу из limit записей.
+// Записи с одинаковой версией (массовые обновления) не разрываются между страницами,
+// иначе часть из них была бы пропущена при следующем запросе с курсором.
+func PageSyncChanges(changes []SyncChange, limit int) ([]SyncChange, bool) {
+	sort.SliceStable(changes, func(i, j int) bool {
+		return changes[i].Version < changes[j].Version
+	})
+	if len(changes) <= limit {
+		return changes, false
+	}
+
+	n := limit
+	for n > 0 && changes[n-1].Version == changes[n].Version {
+		n--
+	}
+	if n == 0 {
+		n = limit
+	}
+	return changes[:n], true
+}
+
+// nextSyncVersion присваивает сохраняемой записи новую версию синхронизации.
+// Версии монотонны по всем сущностям и служат курсором для pull.
+func nextSyncVersion(tx *gorm.DB) error {
+	var version int64
+	if err := tx.Session(&gorm.Session{NewDB: true}).
+		Raw("SELECT " + SyncVersionFunction + "()").Scan(&version).Error; err != nil {
+		return err
+	}
+	tx.Statement.SetColumn("SyncVersion", version)
+	return nil
+}
+
+func (p *Patient) BeforeSave(tx *gorm.DB) error {
+	p.SetBlindIndexes()
+	return nextSyncVersion(tx)
+}
+
+func (i *ChecklistItem) BeforeSave(tx *gorm.DB) error {
+	return nextSyncVersion(tx)
+}
+
+func (c *Comment) BeforeSave(tx *gorm.DB) error {
+	return nextSyncVersion(tx)
+}
+
+func (t *SyncTombstone) BeforeSave(tx *gorm.DB) error {
+	return nextSyncVersion(tx)
 }
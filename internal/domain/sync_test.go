@@ -0,0 +1,136 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSyncMutationConflicts(t *testing.T) {
+	updatedAt := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	v := func(n int64) *int64 { return &n }
+
+	tests := []struct {
+		name     string
+		mutation SyncMutation
+		conflict bool
+		wantErr  bool
+	}{
+		{"same version", SyncMutation{BaseVersion: v(42)}, false, false},
+		{"stale version", SyncMutation{BaseVersion: v(41)}, true, false},
+		{"version wins over updated_at", SyncMutation{BaseVersion: v(42), BaseUpdatedAt: "2020-01-01T00:00:00Z"}, false, false},
+		{"same updated_at", SyncMutation{BaseUpdatedAt: "2026-03-01T10:00:00Z"}, false, false},
+		{"stale updated_at", SyncMutation{BaseUpdatedAt: "2026-03-01T09:59:59Z"}, true, false},
+		{"fractional updated_at", SyncMutation{BaseUpdatedAt: "2026-03-01T10:00:00.000000Z"}, false, false},
+		{"no base", SyncMutation{}, false, true},
+		{"bad updated_at", SyncMutation{BaseUpdatedAt: "01.03.2026"}, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conflict, err := tt.mutation.Conflicts(42, updatedAt)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Conflicts() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if conflict != tt.conflict {
+				t.Errorf("Conflicts() = %v, want %v", conflict, tt.conflict)
+			}
+		})
+	}
+}
+
+func TestSyncWatermarkOutOfOrderCommit(t *testing.T) {
+	// Транзакция A получила версию 10, B — 11; B зафиксирована раньше A
+	committed := []int64{11}
+	inFlight := []int64{10}
+
+	pull := func(cursor, next int64) ([]SyncChange, int64) {
+		watermark := SyncWatermark(next, inFlight)
+		var changes []SyncChange
+		for _, v := range committed {
+			if v > cursor && v < watermark {
+				changes = append(changes, SyncChange{Version: v})
+			}
+		}
+		page, _ := PageSyncChanges(changes, 100)
+		if len(page) > 0 {
+			cursor = page[len(page)-1].Version
+		}
+		return page, cursor
+	}
+
+	page, cursor := pull(0, 12)
+	if len(page) != 0 || cursor != 0 {
+		t.Fatalf("pull before A commits = %+v, cursor %d; want nothing above in-flight version 10", page, cursor)
+	}
+
+	committed, inFlight = []int64{10, 11}, nil
+	page, cursor = pull(cursor, 12)
+	if len(page) != 2 || page[0].Version != 10 || cursor != 11 {
+		t.Errorf("pull after A commits = %+v, cursor %d; want versions 10 and 11", page, cursor)
+	}
+}
+
+func TestSyncWatermark(t *testing.T) {
+	tests := []struct {
+		next     int64
+		inFlight []int64
+		want     int64
+	}{
+		{42, nil, 42},
+		{42, []int64{40, 37}, 37},
+		{42, []int64{45}, 42},
+	}
+	for _, tt := range tests {
+		if got := SyncWatermark(tt.next, tt.inFlight); got != tt.want {
+			t.Errorf("SyncWatermark(%d, %v) = %d, want %d", tt.next, tt.inFlight, got, tt.want)
+		}
+	}
+}
+
+func TestPageSyncChanges(t *testing.T) {
+	changes := func(versions ...int64) []SyncChange {
+		out := make([]SyncChange, len(versions))
+		for i, v := range versions {
+			out[i] = SyncChange{Version: v}
+		}
+		return out
+	}
+	versions := func(cs []SyncChange) []int64 {
+		out := make([]int64, len(cs))
+		for i, c := range cs {
+			out[i] = c.Version
+		}
+		return out
+	}
+
+	tests := []struct {
+		name    string
+		in      []SyncChange
+		limit   int
+		want    []int64
+		hasMore bool
+	}{
+		{"fits", changes(3, 1, 2), 5, []int64{1, 2, 3}, false},
+		{"truncated", changes(5, 1, 4, 2, 3), 3, []int64{1, 2, 3}, true},
+		{"ties are not split", changes(1, 2, 3, 3, 4), 3, []int64{1, 2}, true},
+		{"all ties", changes(7, 7, 7, 7), 2, []int64{7, 7}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, hasMore := PageSyncChanges(tt.in, tt.limit)
+			got := versions(page)
+			if len(got) != len(tt.want) {
+				t.Fatalf("PageSyncChanges() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("PageSyncChanges() = %v, want %v", got, tt.want)
+				}
+			}
+			if hasMore != tt.hasMore {
+				t.Errorf("hasMore = %v, want %v", hasMore, tt.hasMore)
+			}
+		})
+	}
+}
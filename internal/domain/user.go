@@ -28,23 +28,42 @@ type User struct {
 	LicenseNumber  string    `json:"license_number"`
 	TelegramChatID *int64    `gorm:"index" json:"telegram_chat_id,omitempty"`
 	IsActive       bool      `gorm:"default:true;not null" json:"is_active"`
-	RefreshToken   string    `gorm:"index" json:"-"`
-	CreatedAt      time.Time `json:"created_at"`
-	UpdatedAt      time.Time `json:"updated_at"`
+	// Двухфакторная аутентификация: секрет TOTP шифруется как ПДн
+	TOTPSecret   string `gorm:"serializer:pii" json:"-"`
+	TOTPEnabled  bool   `gorm:"default:false;not null" json:"two_factor_enabled"`
+	TOTPLastStep int64  `json:"-"` // интервал последнего принятого кода, защита от повтора
+	// Адрес подтверждён ссылкой из письма (приглашение, сброс пароля или подтверждение)
+	EmailVerifiedAt *time.Time `json:"email_verified_at,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
 }
 
 // --- Requests ---
 
+// RegisterRequest — регистрация по приглашению: email, роль и район берутся
+// из приглашения, имя по умолчанию — тоже
 type RegisterRequest struct {
-	Email      string `json:"email" binding:"required,email"`
-	Password   string `json:"password" binding:"required,min=6"`
-	Name       string `json:"name" binding:"required,min=2"`
-	FirstName  string `json:"first_name"`
-	LastName   string `json:"last_name"`
-	MiddleName string `json:"middle_name"`
-	Phone      string `json:"phone"`
-	Role       Role   `json:"role"`
-	DistrictID uint   `json:"district_id" binding:"required"`
+	InviteToken string `json:"invite_token" binding:"required"`
+	Password    string `json:"password" binding:"required"`
+	Name        string `json:"name"`
+	FirstName   string `json:"first_name"`
+	LastName    string `json:"last_name"`
+	MiddleName  string `json:"middle_name"`
+	Phone       string `json:"phone"`
+}
+
+// UpdateUserRequest — правка сотрудника администратором; nil — поле не меняется
+type UpdateUserRequest struct {
+	Name           *string `json:"name"`
+	FirstName      *string `json:"first_name"`
+	LastName       *string `json:"last_name"`
+	MiddleName     *string `json:"middle_name"`
+	Phone          *string `json:"phone"`
+	Role           *Role   `json:"role"`
+	DistrictID     *uint   `json:"district_id"`
+	Specialization *string `json:"specialization"`
+	LicenseNumber  *string `json:"license_number"`
 }
 
 type LoginRequest struct {
@@ -54,6 +73,10 @@ type LoginRequest struct {
 
 type PatientLoginRequest struct {
 	AccessCode string `json:"access_code" binding:"required"`
+	// Дата рождения ГГГГ-ММ-ДД — второй фактор, если он включён (PATIENT_LOGIN_BIRTH_DATE)
+	BirthDate string `json:"birth_date"`
+	// Решение задачи после нескольких неудачных попыток
+	ChallengeProof
 }
 
 type TelegramTokenRequest struct {
@@ -85,6 +108,9 @@ type UserResponse struct {
 	Specialization string `json:"specialization"`
 	LicenseNumber  string `json:"license_number"`
 	IsActive       bool   `json:"is_active"`
+	// Включена двухфакторная аутентификация
+	TwoFactorEnabled bool `json:"two_factor_enabled"`
+	EmailVerified    bool `json:"email_verified"`
 }
 
 type ErrorResponse struct {
@@ -109,6 +135,9 @@ func (u *User) ToResponse() UserResponse {
 		Specialization: u.Specialization,
 		LicenseNumber:  u.LicenseNumber,
 		IsActive:       u.IsActive,
+
+		TwoFactorEnabled: u.TOTPEnabled,
+		EmailVerified:    u.EmailVerifiedAt != nil,
 	}
 }
 
@@ -0,0 +1,121 @@
+package domain
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// UserImportMaxRows — строк в одном файле импорта; каждая строка отправляет письмо
+const UserImportMaxRows = 500
+
+// UserImportRow — сотрудник из CSV; приглашение создаётся как через POST /admin/invitations
+type UserImportRow struct {
+	Line int
+	CreateInvitationRequest
+}
+
+// UserImportError — строка, по которой приглашение не создано
+type UserImportError struct {
+	Line  int    `json:"line"`
+	Email string `json:"email,omitempty"`
+	Error string `json:"error"`
+}
+
+type UserImportResult struct {
+	Total   int               `json:"total"`
+	Invited int               `json:"invited"`
+	Errors  []UserImportError `json:"errors"`
+}
+
+// ParseUserImportCSV читает файл с заголовком email, name, role, district_id
+// (порядок любой, name необязателен). Разделитель — запятая или точка с запятой,
+// как сохраняет Excel. Ошибки отдельных строк возвращаются вместе с остальными строками.
+func ParseUserImportCSV(r io.Reader) ([]UserImportRow, []UserImportError, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	text := strings.TrimPrefix(string(data), "\ufeff")
+	header, _, _ := strings.Cut(text, "\n")
+
+	reader := csv.NewReader(strings.NewReader(text))
+	if strings.Count(header, ";") > strings.Count(header, ",") {
+		reader.Comma = ';'
+	}
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	columns, err := reader.Read()
+	if err != nil {
+		return nil, nil, errors.New("файл пуст или не является CSV")
+	}
+	index := map[string]int{}
+	for i, name := range columns {
+		index[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, required := range []string{"email", "role", "district_id"} {
+		if _, ok := index[required]; !ok {
+			return nil, nil, fmt.Errorf("нет колонки %s", required)
+		}
+	}
+	field := func(record []string, name string) string {
+		i, ok := index[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	var rows []UserImportRow
+	var rowErrors []UserImportError
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		line, _ := reader.FieldPos(0)
+		if err != nil {
+			return nil, nil, fmt.Errorf("строка %d: %w", line, err)
+		}
+		if strings.TrimSpace(strings.Join(record, "")) == "" {
+			continue
+		}
+		if len(rows)+len(rowErrors) >= UserImportMaxRows {
+			return nil, nil, fmt.Errorf("в файле больше %d строк", UserImportMaxRows)
+		}
+
+		email := NormalizeEmail(field(record, "email"))
+		rowError := func(msg string) {
+			rowErrors = append(rowErrors, UserImportError{Line: line, Email: email, Error: msg})
+		}
+		if !strings.Contains(email, "@") {
+			rowError("некорректный email")
+			continue
+		}
+		role := Role(strings.ToUpper(field(record, "role")))
+		if !ValidRole(role) || role == RolePatient {
+			rowError("недопустимая роль")
+			continue
+		}
+		districtID, err := strconv.ParseUint(field(record, "district_id"), 10, 32)
+		if err != nil || districtID == 0 {
+			rowError("некорректный district_id")
+			continue
+		}
+
+		rows = append(rows, UserImportRow{
+			Line: line,
+			CreateInvitationRequest: CreateInvitationRequest{
+				Email:      email,
+				Name:       field(record, "name"),
+				Role:       role,
+				DistrictID: uint(districtID),
+			},
+		})
+	}
+	return rows, rowErrors, nil
+}
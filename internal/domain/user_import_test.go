@@ -0,0 +1,58 @@
+package domain
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseUserImportCSV(t *testing.T) {
+	input := "\ufeffName;Email;Role;District_ID\n" +
+		"Иванов И.И.;Ivanov@Clinic.ru;district_doctor;3\n" +
+		"\n" +
+		"Петров П.П.;petrov@clinic.ru;PATIENT;3\n" +
+		"Сидоров;sidorov;SURGEON;1\n" +
+		"Смирнова;smirnova@clinic.ru;SURGEON;x\n" +
+		"Кузнецов;kuznetsov@clinic.ru;ADMIN;2\n"
+
+	rows, rowErrors, err := ParseUserImportCSV(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("rows = %+v", rows)
+	}
+	first := rows[0]
+	if first.Line != 2 || first.Email != "ivanov@clinic.ru" || first.Role != RoleDistrictDoctor || first.DistrictID != 3 || first.Name != "Иванов И.И." {
+		t.Errorf("first row = %+v", first)
+	}
+	if rows[1].Line != 7 || rows[1].Role != RoleAdmin {
+		t.Errorf("second row = %+v", rows[1])
+	}
+
+	wantLines := []int{4, 5, 6}
+	if len(rowErrors) != len(wantLines) {
+		t.Fatalf("errors = %+v", rowErrors)
+	}
+	for i, line := range wantLines {
+		if rowErrors[i].Line != line {
+			t.Errorf("error #%d line = %d, want %d", i, rowErrors[i].Line, line)
+		}
+	}
+}
+
+func TestParseUserImportCSVHeader(t *testing.T) {
+	tests := []struct {
+		input string
+		ok    bool
+	}{
+		{"email,role,district_id\na@b.ru,SURGEON,1\n", true},
+		{"email,role\na@b.ru,SURGEON\n", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		_, _, err := ParseUserImportCSV(strings.NewReader(tt.input))
+		if (err == nil) != tt.ok {
+			t.Errorf("ParseUserImportCSV(%q) error = %v, want ok=%v", tt.input, err, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"errors"
+
+	"github.com/beercut-team/backend-boilerplate/internal/domain"
+	"github.com/beercut-team/backend-boilerplate/internal/middleware"
+	"github.com/beercut-team/backend-boilerplate/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// requireAccess отвечает 403/404/500 по результату проверки доступа и возвращает false
+func requireAccess(c *gin.Context, err error) bool {
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, service.ErrAccessDenied):
+		Forbidden(c, err.Error())
+	case service.IsNotFound(err):
+		NotFound(c, err.Error())
+	default:
+		InternalError(c, "не удалось проверить доступ")
+	}
+	return false
+}
+
+func canAccessPatient(c *gin.Context, policy service.AccessPolicy, patientID uint, action domain.PatientAction) bool {
+	return requireAccess(c, policy.CanAccessPatient(c.Request.Context(), middleware.GetUserID(c), middleware.GetUserRole(c), patientID, action))
+}
+
+func canAccessChecklistItem(c *gin.Context, policy service.AccessPolicy, itemID uint, action domain.PatientAction) bool {
+	return requireAccess(c, policy.CanAccessChecklistItem(c.Request.Context(), middleware.GetUserID(c), middleware.GetUserRole(c), itemID, action))
+}
+
+func canAccessMedia(c *gin.Context, policy service.AccessPolicy, mediaID uint, action domain.PatientAction) bool {
+	return requireAccess(c, policy.CanAccessMedia(c.Request.Context(), middleware.GetUserID(c), middleware.GetUserRole(c), mediaID, action))
+}
+
+func canAccessSurgery(c *gin.Context, policy service.AccessPolicy, surgeryID uint, action domain.PatientAction) bool {
+	return requireAccess(c, policy.CanAccessSurgery(c.Request.Context(), middleware.GetUserID(c), middleware.GetUserRole(c), surgeryID, action))
+}
+
+func canAccessEyeExam(c *gin.Context, policy service.AccessPolicy, examID uint, action domain.PatientAction) bool {
+	return requireAccess(c, policy.CanAccessEyeExam(c.Request.Context(), middleware.GetUserID(c), middleware.GetUserRole(c), examID, action))
+}
+
+func canAccessPostOpVisit(c *gin.Context, policy service.AccessPolicy, visitID uint, action domain.PatientAction) bool {
+	return requireAccess(c, policy.CanAccessPostOpVisit(c.Request.Context(), middleware.GetUserID(c), middleware.GetUserRole(c), visitID, action))
+}
+
+func canAccessConsent(c *gin.Context, policy service.AccessPolicy, consentID uint, action domain.PatientAction) bool {
+	return requireAccess(c, policy.CanAccessConsent(c.Request.Context(), middleware.GetUserID(c), middleware.GetUserRole(c), consentID, action))
+}
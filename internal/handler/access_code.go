@@ -0,0 +1,38 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/beercut-team/backend-boilerplate/internal/domain"
+	"github.com/beercut-team/backend-boilerplate/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// accessCodeErrorResponse — блокировка после неудачных попыток входа по коду
+// или задача, которую нужно решить перед следующей попыткой
+type accessCodeErrorResponse struct {
+	Success    bool                   `json:"success"`
+	Error      string                 `json:"error"`
+	RetryAfter int                    `json:"retry_after,omitempty"`
+	Challenge  *domain.LoginChallenge `json:"challenge,omitempty"`
+}
+
+// writeAccessCodeError отвечает 429 при блокировке и 428 с задачей;
+// false — ошибка другого рода
+func writeAccessCodeError(c *gin.Context, err error) bool {
+	var locked *service.AccessCodeLockedError
+	if errors.As(err, &locked) {
+		seconds := int(locked.RetryAfter.Seconds()) + 1
+		c.Header("Retry-After", strconv.Itoa(seconds))
+		c.JSON(http.StatusTooManyRequests, accessCodeErrorResponse{Error: err.Error(), RetryAfter: seconds})
+		return true
+	}
+	var challenge *service.ChallengeRequiredError
+	if errors.As(err, &challenge) {
+		c.JSON(http.StatusPreconditionRequired, accessCodeErrorResponse{Error: err.Error(), Challenge: &challenge.Challenge})
+		return true
+	}
+	return false
+}
@@ -0,0 +1,246 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/beercut-team/backend-boilerplate/internal/domain"
+	"github.com/beercut-team/backend-boilerplate/internal/middleware"
+	"github.com/beercut-team/backend-boilerplate/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+type accessCall struct {
+	kind   string
+	id     uint
+	action domain.PatientAction
+}
+
+// denyPolicy запоминает проверку и возвращает заданную ошибку
+type denyPolicy struct {
+	err   error
+	calls []accessCall
+}
+
+func (p *denyPolicy) record(kind string, id uint, action domain.PatientAction) error {
+	p.calls = append(p.calls, accessCall{kind, id, action})
+	return p.err
+}
+
+func (p *denyPolicy) CanAccessPatient(_ context.Context, _ uint, _ domain.Role, id uint, action domain.PatientAction) error {
+	return p.record("patient", id, action)
+}
+
+func (p *denyPolicy) CanAccessChecklistItem(_ context.Context, _ uint, _ domain.Role, id uint, action domain.PatientAction) error {
+	return p.record("checklist_item", id, action)
+}
+
+func (p *denyPolicy) CanAccessMedia(_ context.Context, _ uint, _ domain.Role, id uint, action domain.PatientAction) error {
+	return p.record("media", id, action)
+}
+
+func (p *denyPolicy) CanAccessSurgery(_ context.Context, _ uint, _ domain.Role, id uint, action domain.PatientAction) error {
+	return p.record("surgery", id, action)
+}
+
+func (p *denyPolicy) CanAccessEyeExam(_ context.Context, _ uint, _ domain.Role, id uint, action domain.PatientAction) error {
+	return p.record("eye_exam", id, action)
+}
+
+func (p *denyPolicy) CanAccessPostOpVisit(_ context.Context, _ uint, _ domain.Role, id uint, action domain.PatientAction) error {
+	return p.record("postop_visit", id, action)
+}
+
+func (p *denyPolicy) CanAccessConsent(_ context.Context, _ uint, _ domain.Role, id uint, action domain.PatientAction) error {
+	return p.record("consent", id, action)
+}
+
+func (p *denyPolicy) Actor(_ context.Context, userID uint, role domain.Role) (domain.Actor, error) {
+	return domain.Actor{ID: userID, Role: role}, p.record("actor", userID, "")
+}
+
+type accessRoute struct {
+	method string
+	route  string
+	path   string
+	body   string
+	handle func(*gin.Context)
+	want   accessCall
+}
+
+// patientScopedRoutes повторяет маршруты сервера, работающие с данными пациента.
+// Сервисы не заданы: при отказе в доступе обработчик не должен к ним обращаться.
+func patientScopedRoutes(policy service.AccessPolicy) []accessRoute {
+	patients := NewPatientHandler(nil, policy)
+	checklists := NewChecklistHandler(nil, policy)
+	templates := NewChecklistTemplateHandler(nil, policy)
+	media := NewMediaHandler(nil, policy)
+	iol := NewIOLHandler(nil, nil, policy)
+	surgeries := NewSurgeryHandler(nil, policy)
+	comments := NewCommentHandler(nil, policy)
+	print := NewPrintHandler(nil, policy)
+	medical := NewMedicalStandardsHandler(nil, policy)
+	integrations := NewIntegrationsHandler(nil, policy)
+	fhirAPI := NewFHIRHandler(nil, policy)
+	eyeExams := NewEyeExamHandler(nil, policy)
+	postOp := NewPostOpHandler(nil, policy)
+	consents := NewConsentHandler(nil, policy)
+	sync := NewSyncHandler(nil, policy)
+
+	read, write, review := domain.PatientActionRead, domain.PatientActionWrite, domain.PatientActionReview
+	return []accessRoute{
+		{"GET", "/patients/:id", "/patients/3", "", patients.GetByID, accessCall{"patient", 3, read}},
+		{"PATCH", "/patients/:id", "/patients/3", `{}`, patients.Update, accessCall{"patient", 3, write}},
+		{"DELETE", "/patients/:id", "/patients/3", "", patients.Delete, accessCall{"patient", 3, write}},
+		{"POST", "/patients/:id/status", "/patients/3/status", `{"status":"APPROVED"}`, patients.ChangeStatus, accessCall{"patient", 3, review}},
+		{"POST", "/patients/:id/batch-update", "/patients/3/batch-update", `{}`, patients.BatchUpdate, accessCall{"patient", 3, write}},
+		{"POST", "/patients/:id/regenerate-code", "/patients/3/regenerate-code", "", patients.RegenerateAccessCode, accessCall{"patient", 3, write}},
+		{"POST", "/patients/:id/medical-metadata", "/patients/3/medical-metadata", `{}`, medical.UpdateMedicalMetadata, accessCall{"patient", 3, write}},
+
+		{"GET", "/checklists/patient/:patientId", "/checklists/patient/3", "", checklists.GetByPatient, accessCall{"patient", 3, read}},
+		{"GET", "/checklists/patient/:patientId/progress", "/checklists/patient/3/progress", "", checklists.GetProgress, accessCall{"patient", 3, read}},
+		{"POST", "/checklists/patient/:patientId/upgrade", "/checklists/patient/3/upgrade", "", templates.UpgradePatient, accessCall{"patient", 3, write}},
+		{"POST", "/checklists", "/checklists", `{"patient_id":3,"name":"ЭКГ"}`, checklists.CreateItem, accessCall{"patient", 3, write}},
+		{"PATCH", "/checklists/:id", "/checklists/4", `{}`, checklists.UpdateItem, accessCall{"checklist_item", 4, write}},
+		{"POST", "/checklists/:id/review", "/checklists/4/review", `{"status":"COMPLETED"}`, checklists.ReviewItem, accessCall{"checklist_item", 4, review}},
+
+		{"POST", "/media/upload", "/media/upload", "", media.Upload, accessCall{"patient", 3, write}},
+		{"GET", "/media/patient/:patientId", "/media/patient/3", "", media.GetByPatient, accessCall{"patient", 3, read}},
+		{"GET", "/media/:id/download", "/media/5/download", "", media.Download, accessCall{"media", 5, read}},
+		{"GET", "/media/:id/download-url", "/media/5/download-url", "", media.DownloadURL, accessCall{"media", 5, read}},
+		{"GET", "/media/:id/thumbnail", "/media/5/thumbnail", "", media.Thumbnail, accessCall{"media", 5, read}},
+		{"DELETE", "/media/:id", "/media/5", "", media.Delete, accessCall{"media", 5, write}},
+
+		{"POST", "/iol/calculate", "/iol/calculate", `{"patient_id":3,"eye":"OD","axial_length":23.5,"keratometry1":43,"keratometry2":44,"formula":"SRKT"}`, iol.Calculate, accessCall{"patient", 3, write}},
+		{"GET", "/iol/patient/:patientId/history", "/iol/patient/3/history", "", iol.History, accessCall{"patient", 3, read}},
+		{"POST", "/iol/import", "/iol/import", "", iol.Import, accessCall{"patient", 3, write}},
+		{"POST", "/iol/compare", "/iol/compare", `{"patient_id":3,"eye":"OD","axial_length":23.5,"keratometry1":43,"keratometry2":44}`, iol.Compare, accessCall{"patient", 3, read}},
+		{"POST", "/iol/toric", "/iol/toric", `{"patient_id":3,"eye":"OD","axial_length":23.5,"keratometry1":43,"keratometry2":44,"steep_axis":90}`, iol.Toric, accessCall{"patient", 3, read}},
+
+		{"GET", "/eye-exams/patient/:patientId", "/eye-exams/patient/3", "", eyeExams.History, accessCall{"patient", 3, read}},
+		{"POST", "/eye-exams", "/eye-exams", `{"patient_id":3,"eye":"OD"}`, eyeExams.Create, accessCall{"patient", 3, write}},
+		{"GET", "/eye-exams/:id", "/eye-exams/8", "", eyeExams.GetByID, accessCall{"eye_exam", 8, read}},
+		{"PATCH", "/eye-exams/:id", "/eye-exams/8", `{}`, eyeExams.Update, accessCall{"eye_exam", 8, write}},
+		{"DELETE", "/eye-exams/:id", "/eye-exams/8", "", eyeExams.Delete, accessCall{"eye_exam", 8, write}},
+
+		{"POST", "/surgeries", "/surgeries", `{"patient_id":3,"scheduled_date":"2026-03-16"}`, surgeries.Schedule, accessCall{"patient", 3, review}},
+		{"GET", "/surgeries/:id", "/surgeries/6", "", surgeries.GetByID, accessCall{"surgery", 6, read}},
+		{"PATCH", "/surgeries/:id", "/surgeries/6", `{}`, surgeries.Update, accessCall{"surgery", 6, write}},
+		{"DELETE", "/surgeries/:id", "/surgeries/6", "", surgeries.Delete, accessCall{"surgery", 6, write}},
+		{"GET", "/surgeries/:id/postop-visits", "/surgeries/6/postop-visits", "", postOp.List, accessCall{"surgery", 6, read}},
+		{"POST", "/surgeries/:id/postop-visits", "/surgeries/6/postop-visits", `{}`, postOp.Create, accessCall{"surgery", 6, write}},
+		{"PATCH", "/postop-visits/:id", "/postop-visits/9", `{}`, postOp.Update, accessCall{"postop_visit", 9, write}},
+		{"DELETE", "/postop-visits/:id", "/postop-visits/9", "", postOp.Delete, accessCall{"postop_visit", 9, write}},
+
+		// Пациент управляет только своими согласиями: для него проверяется право на чтение
+		{"GET", "/consents/patient/:patientId", "/consents/patient/3", "", consents.ListByPatient, accessCall{"patient", 3, read}},
+		{"POST", "/consents/patient/:patientId", "/consents/patient/3", `{"type":"PERSONAL_DATA"}`, consents.Capture, accessCall{"patient", 3, read}},
+		{"POST", "/consents/:id/revoke", "/consents/10/revoke", "", consents.Revoke, accessCall{"consent", 10, read}},
+
+		{"POST", "/comments", "/comments", `{"patient_id":3,"body":"Проверьте анализы"}`, comments.Create, accessCall{"patient", 3, review}},
+		{"GET", "/comments/patient/:patientId", "/comments/patient/3", "", comments.GetByPatient, accessCall{"patient", 3, read}},
+		{"POST", "/comments/patient/:patientId/read", "/comments/patient/3/read", "", comments.MarkAsRead, accessCall{"patient", 3, read}},
+
+		{"GET", "/print/patient/:patientId/routing-sheet", "/print/patient/3/routing-sheet", "", print.RoutingSheet, accessCall{"patient", 3, read}},
+		{"GET", "/print/patient/:patientId/checklist-report", "/print/patient/3/checklist-report", "", print.ChecklistReport, accessCall{"patient", 3, read}},
+
+		{"POST", "/integrations/emias/patients/:id/export", "/integrations/emias/patients/3/export", "", integrations.ExportToEMIAS, accessCall{"patient", 3, write}},
+		{"POST", "/integrations/emias/patients/:id/case", "/integrations/emias/patients/3/case", `{}`, integrations.CreateEMIASCase, accessCall{"patient", 3, write}},
+		{"GET", "/integrations/emias/patients/:id/status", "/integrations/emias/patients/3/status", "", integrations.GetEMIASStatus, accessCall{"patient", 3, read}},
+		{"POST", "/integrations/riams/patients/:id/export", "/integrations/riams/patients/3/export", `{"region_code":"77"}`, integrations.ExportToRIAMS, accessCall{"patient", 3, write}},
+		{"GET", "/integrations/riams/patients/:id/status", "/integrations/riams/patients/3/status", "", integrations.GetRIAMSStatus, accessCall{"patient", 3, read}},
+
+		{"GET", "/fhir/Patient/:id", "/fhir/Patient/3", "", fhirAPI.GetPatient, accessCall{"patient", 3, read}},
+		{"GET", "/fhir/Patient/:id/$everything", "/fhir/Patient/3/$everything", "", fhirAPI.Everything, accessCall{"patient", 3, read}},
+		{"GET", "/fhir/Condition", "/fhir/Condition?patient=3", "", fhirAPI.SearchConditions, accessCall{"patient", 3, read}},
+		{"GET", "/fhir/Procedure", "/fhir/Procedure?patient=3", "", fhirAPI.SearchProcedures, accessCall{"patient", 3, read}},
+		{"GET", "/fhir/Procedure/:id", "/fhir/Procedure/6", "", fhirAPI.GetProcedure, accessCall{"surgery", 6, read}},
+		{"GET", "/fhir/Observation", "/fhir/Observation?patient=3", "", fhirAPI.SearchObservations, accessCall{"patient", 3, read}},
+		{"POST", "/sync/push", "/sync/push", `{"mutations":[{"entity":"patient","entity_id":3,"action":"update","client_time":"2026-03-16T10:00:00Z"}]}`, sync.Push, accessCall{"actor", 7, ""}},
+		{"GET", "/sync/pull", "/sync/pull", "", sync.Pull, accessCall{"actor", 7, ""}},
+
+		{"POST", "/fhir/Bundle", "/fhir/Bundle", `{"resourceType":"Bundle","type":"transaction","entry":[{"resource":{"resourceType":"Patient"},"request":{"method":"PUT","url":"Patient/3"}}]}`, fhirAPI.ImportBundle, accessCall{"patient", 3, write}},
+	}
+}
+
+// multipartRoutes — маршруты загрузки файла и имя файла в форме
+var multipartRoutes = map[string]string{
+	"/media/upload": "scan.jpg",
+	"/iol/import":   "iolmaster.csv",
+}
+
+func newAccessRequest(t *testing.T, rt accessRoute) *http.Request {
+	t.Helper()
+	if upload, ok := multipartRoutes[rt.route]; ok {
+		var buf bytes.Buffer
+		w := multipart.NewWriter(&buf)
+		_ = w.WriteField("patient_id", "3")
+		part, err := w.CreateFormFile("file", upload)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _ = part.Write([]byte("jpeg"))
+		_ = w.Close()
+		req := httptest.NewRequest(rt.method, rt.path, &buf)
+		req.Header.Set("Content-Type", w.FormDataContentType())
+		return req
+	}
+
+	req := httptest.NewRequest(rt.method, rt.path, strings.NewReader(rt.body))
+	if rt.body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return req
+}
+
+func serveAccessRoute(t *testing.T, rt accessRoute) *httptest.ResponseRecorder {
+	t.Helper()
+	r := gin.New()
+	r.Handle(rt.method, rt.route, func(c *gin.Context) {
+		c.Set("user_id", uint(7))
+		middleware.SetUserRole(c, domain.RolePatient)
+	}, rt.handle)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, newAccessRequest(t, rt))
+	return w
+}
+
+func TestPatientScopedRoutesCheckAccess(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	policy := &denyPolicy{err: service.ErrAccessDenied}
+	for _, rt := range patientScopedRoutes(policy) {
+		t.Run(rt.method+" "+rt.route, func(t *testing.T) {
+			policy.calls = nil
+			w := serveAccessRoute(t, rt)
+
+			if w.Code != http.StatusForbidden {
+				t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusForbidden, w.Body.String())
+			}
+			if len(policy.calls) != 1 || policy.calls[0] != rt.want {
+				t.Errorf("access checks = %+v, want [%+v]", policy.calls, rt.want)
+			}
+		})
+	}
+}
+
+func TestPatientScopedRoutesPolicyError(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	policy := &denyPolicy{err: errors.New("connection refused")}
+	for _, rt := range patientScopedRoutes(policy) {
+		t.Run(rt.method+" "+rt.route, func(t *testing.T) {
+			w := serveAccessRoute(t, rt)
+			if w.Code != http.StatusInternalServerError {
+				t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusInternalServerError, w.Body.String())
+			}
+		})
+	}
+}
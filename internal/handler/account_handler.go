@@ -0,0 +1,151 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/beercut-team/backend-boilerplate/internal/domain"
+	"github.com/beercut-team/backend-boilerplate/internal/middleware"
+	"github.com/beercut-team/backend-boilerplate/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+type AccountHandler struct {
+	svc service.AccountService
+}
+
+func NewAccountHandler(svc service.AccountService) *AccountHandler {
+	return &AccountHandler{svc: svc}
+}
+
+// GetInvitation — данные приглашения для формы регистрации
+func (h *AccountHandler) GetInvitation(c *gin.Context) {
+	info, err := h.svc.GetInvitation(c.Request.Context(), c.Param("token"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, domain.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, info)
+}
+
+// ForgotPassword всегда отвечает одинаково, чтобы не раскрывать зарегистрированные адреса
+func (h *AccountHandler) ForgotPassword(c *gin.Context) {
+	var req domain.ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	ctx := service.WithAuditActor(c.Request.Context(), 0, "", c.ClientIP())
+	if err := h.svc.ForgotPassword(ctx, req); err != nil {
+		c.JSON(http.StatusInternalServerError, domain.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, domain.MessageResponse{Message: "если адрес зарегистрирован, на него отправлена ссылка для сброса пароля"})
+}
+
+// ResetPassword задаёт пароль по ссылке из письма и завершает все сессии
+func (h *AccountHandler) ResetPassword(c *gin.Context) {
+	var req domain.ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	ctx := service.WithAuditActor(c.Request.Context(), 0, "", c.ClientIP())
+	if err := h.svc.ResetPassword(ctx, req); err != nil {
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, domain.MessageResponse{Message: "пароль изменён, войдите с новым паролем"})
+}
+
+// ChangePassword — смена пароля с проверкой текущего; остальные сессии завершаются
+func (h *AccountHandler) ChangePassword(c *gin.Context) {
+	var req domain.ChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	if err := h.svc.ChangePassword(auditContext(c), middleware.GetUserID(c), middleware.GetSessionID(c), req); err != nil {
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, domain.MessageResponse{Message: "пароль изменён"})
+}
+
+func (h *AccountHandler) SendVerification(c *gin.Context) {
+	if err := h.svc.SendVerification(c.Request.Context(), middleware.GetUserID(c)); err != nil {
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, domain.MessageResponse{Message: "письмо со ссылкой отправлено"})
+}
+
+func (h *AccountHandler) VerifyEmail(c *gin.Context) {
+	var req domain.VerifyEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	ctx := service.WithAuditActor(c.Request.Context(), 0, "", c.ClientIP())
+	if err := h.svc.VerifyEmail(ctx, req); err != nil {
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, domain.MessageResponse{Message: "адрес подтверждён"})
+}
+
+// --- Приглашения (ADMIN) ---
+
+func (h *AccountHandler) CreateInvitation(c *gin.Context) {
+	var req domain.CreateInvitationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, err.Error())
+		return
+	}
+
+	inv, err := h.svc.Invite(c.Request.Context(), req, middleware.GetUserID(c))
+	if err != nil {
+		BadRequest(c, err.Error())
+		return
+	}
+
+	Success(c, http.StatusCreated, inv)
+}
+
+// ListInvitations — GET /api/v1/admin/invitations?status=PENDING|ACCEPTED|REVOKED|EXPIRED
+func (h *AccountHandler) ListInvitations(c *gin.Context) {
+	p := GetPagination(c)
+	invitations, total, err := h.svc.ListInvitations(c.Request.Context(), strings.ToUpper(c.Query("status")), p.Offset(), p.Limit)
+	if err != nil {
+		InternalError(c, err.Error())
+		return
+	}
+
+	SuccessWithMeta(c, http.StatusOK, invitations, NewMeta(p.Page, p.Limit, total))
+}
+
+func (h *AccountHandler) RevokeInvitation(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		BadRequest(c, "неверный ID приглашения")
+		return
+	}
+
+	if err := h.svc.RevokeInvitation(c.Request.Context(), uint(id)); err != nil {
+		BadRequest(c, err.Error())
+		return
+	}
+
+	Success(c, http.StatusOK, gin.H{"message": "приглашение отозвано"})
+}
@@ -2,29 +2,160 @@ package handler
 
 import (
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/beercut-team/backend-boilerplate/internal/domain"
+	"github.com/beercut-team/backend-boilerplate/internal/middleware"
+	"github.com/beercut-team/backend-boilerplate/internal/repository"
 	"github.com/beercut-team/backend-boilerplate/internal/service"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
+// Размер CSV для импорта сотрудников
+const maxUserImportSize = 1 << 20
+
 type AdminHandler struct {
-	authService service.AuthService
-	db          *gorm.DB
+	userService    service.UserService
+	accountService service.AccountService
+	db             *gorm.DB
 }
 
-func NewAdminHandler(authService service.AuthService, db *gorm.DB) *AdminHandler {
-	return &AdminHandler{authService: authService, db: db}
+func NewAdminHandler(userService service.UserService, accountService service.AccountService, db *gorm.DB) *AdminHandler {
+	return &AdminHandler{userService: userService, accountService: accountService, db: db}
 }
 
+// ListUsers — GET /api/v1/admin/users?role=&district_id=&is_active=&search=&page=&limit=
 func (h *AdminHandler) ListUsers(c *gin.Context) {
-	users, err := h.authService.ListUsers(c.Request.Context())
+	filters := repository.UserFilters{
+		Role:   domain.Role(strings.ToUpper(c.Query("role"))),
+		Search: c.Query("search"),
+	}
+	var ok bool
+	if filters.DistrictID, ok = optionalID(c, "district_id"); !ok {
+		return
+	}
+	if v := c.Query("is_active"); v != "" {
+		active, err := strconv.ParseBool(v)
+		if err != nil {
+			BadRequest(c, "неверный is_active")
+			return
+		}
+		filters.IsActive = &active
+	}
+
+	p := GetPagination(c)
+	users, total, err := h.userService.List(c.Request.Context(), filters, p.Offset(), p.Limit)
+	if err != nil {
+		InternalError(c, err.Error())
+		return
+	}
+
+	SuccessWithMeta(c, http.StatusOK, users, NewMeta(p.Page, p.Limit, total))
+}
+
+func (h *AdminHandler) GetUser(c *gin.Context) {
+	id, ok := userIDParam(c)
+	if !ok {
+		return
+	}
+
+	user, err := h.userService.GetByID(c.Request.Context(), id)
+	if err != nil {
+		NotFound(c, err.Error())
+		return
+	}
+
+	Success(c, http.StatusOK, user)
+}
+
+// UpdateUser — профиль, роль и район сотрудника; смена роли завершает его сессии
+func (h *AdminHandler) UpdateUser(c *gin.Context) {
+	id, ok := userIDParam(c)
+	if !ok {
+		return
+	}
+	var req domain.UpdateUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, err.Error())
+		return
+	}
+
+	user, err := h.userService.Update(c.Request.Context(), id, middleware.GetUserID(c), req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, domain.ErrorResponse{Error: err.Error()})
+		BadRequest(c, err.Error())
 		return
 	}
-	c.JSON(http.StatusOK, users)
+
+	Success(c, http.StatusOK, user)
+}
+
+// DeactivateUser — мягкое удаление: учётная запись остаётся в журналах и связях с пациентами
+func (h *AdminHandler) DeactivateUser(c *gin.Context) {
+	id, ok := userIDParam(c)
+	if !ok {
+		return
+	}
+
+	if err := h.userService.Deactivate(c.Request.Context(), id, middleware.GetUserID(c)); err != nil {
+		BadRequest(c, err.Error())
+		return
+	}
+
+	Success(c, http.StatusOK, gin.H{"message": "пользователь деактивирован"})
+}
+
+func (h *AdminHandler) ActivateUser(c *gin.Context) {
+	id, ok := userIDParam(c)
+	if !ok {
+		return
+	}
+
+	if err := h.userService.Activate(c.Request.Context(), id); err != nil {
+		BadRequest(c, err.Error())
+		return
+	}
+
+	Success(c, http.StatusOK, gin.H{"message": "пользователь активирован"})
+}
+
+// ResetUserPassword отправляет сотруднику ссылку для сброса пароля
+func (h *AdminHandler) ResetUserPassword(c *gin.Context) {
+	id, ok := userIDParam(c)
+	if !ok {
+		return
+	}
+
+	if err := h.accountService.SendPasswordReset(c.Request.Context(), id); err != nil {
+		BadRequest(c, err.Error())
+		return
+	}
+
+	Success(c, http.StatusOK, gin.H{"message": "ссылка для сброса пароля отправлена"})
+}
+
+// ImportUsers — multipart с полем file: CSV email, name, role, district_id;
+// по каждой строке отправляется приглашение
+func (h *AdminHandler) ImportUsers(c *gin.Context) {
+	file, header, err := c.Request.FormFile("file")
+	if err != nil {
+		BadRequest(c, "файл обязателен")
+		return
+	}
+	defer file.Close()
+	if header.Size > maxUserImportSize {
+		BadRequest(c, "файл больше 1 МБ")
+		return
+	}
+
+	result, err := h.userService.Import(c.Request.Context(), file, middleware.GetUserID(c))
+	if err != nil {
+		BadRequest(c, err.Error())
+		return
+	}
+
+	Success(c, http.StatusOK, result)
 }
 
 func (h *AdminHandler) Stats(c *gin.Context) {
@@ -41,3 +172,12 @@ func (h *AdminHandler) Stats(c *gin.Context) {
 		"surgeries": surgeriesCount,
 	})
 }
+
+func userIDParam(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		BadRequest(c, "неверный ID пользователя")
+		return 0, false
+	}
+	return uint(id), true
+}
@@ -0,0 +1,104 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/beercut-team/backend-boilerplate/internal/repository"
+	"github.com/beercut-team/backend-boilerplate/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+type AuditHandler struct {
+	svc service.AuditService
+}
+
+func NewAuditHandler(svc service.AuditService) *AuditHandler {
+	return &AuditHandler{svc: svc}
+}
+
+// List — журнал аудита с фильтрами; format=csv выгружает все записи по фильтрам
+// GET /api/v1/admin/audit?user_id=&entity=&entity_id=&action=&from=&to=&format=csv
+func (h *AuditHandler) List(c *gin.Context) {
+	filters, ok := auditFilters(c)
+	if !ok {
+		return
+	}
+
+	if c.Query("format") == "csv" {
+		h.exportCSV(c, filters)
+		return
+	}
+
+	p := GetPagination(c)
+	logs, total, err := h.svc.List(c.Request.Context(), filters, p.Offset(), p.Limit)
+	if err != nil {
+		InternalError(c, "не удалось получить журнал аудита")
+		return
+	}
+
+	SuccessWithMeta(c, http.StatusOK, logs, NewMeta(p.Page, p.Limit, total))
+}
+
+func (h *AuditHandler) exportCSV(c *gin.Context, filters repository.AuditFilters) {
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=audit_%s.csv", time.Now().Format("20060102_150405")))
+	c.Status(http.StatusOK)
+
+	// Журнал выгружается потоком: после начала ответа статус уже не изменить
+	if err := h.svc.ExportCSV(c.Request.Context(), filters, c.Writer); err != nil {
+		log.Error().Err(err).Msg("не удалось выгрузить журнал аудита")
+	}
+}
+
+// auditFilters разбирает фильтры журнала; from и to — дата (ГГГГ-ММ-ДД, to включительно) или RFC 3339
+func auditFilters(c *gin.Context) (repository.AuditFilters, bool) {
+	filters := repository.AuditFilters{
+		Entity: strings.TrimSpace(c.Query("entity")),
+		Action: strings.ToUpper(strings.TrimSpace(c.Query("action"))),
+	}
+
+	var ok bool
+	if filters.UserID, ok = optionalID(c, "user_id"); !ok {
+		return filters, false
+	}
+	if filters.EntityID, ok = optionalID(c, "entity_id"); !ok {
+		return filters, false
+	}
+	if filters.From, ok = optionalTime(c, "from", false); !ok {
+		return filters, false
+	}
+	if filters.To, ok = optionalTime(c, "to", true); !ok {
+		return filters, false
+	}
+	if filters.From != nil && filters.To != nil && !filters.From.Before(*filters.To) {
+		BadRequest(c, "from должен быть раньше to")
+		return filters, false
+	}
+
+	return filters, true
+}
+
+// optionalTime разбирает момент времени; дата без времени для конца диапазона
+// означает конец этого дня
+func optionalTime(c *gin.Context, name string, endOfDay bool) (*time.Time, bool) {
+	v := c.Query(name)
+	if v == "" {
+		return nil, true
+	}
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return &t, true
+	}
+	t, err := time.Parse("2006-01-02", v)
+	if err != nil {
+		BadRequest(c, "неверный формат "+name+", используйте ГГГГ-ММ-ДД или RFC 3339")
+		return nil, false
+	}
+	if endOfDay {
+		t = t.AddDate(0, 0, 1)
+	}
+	return &t, true
+}
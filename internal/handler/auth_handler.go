@@ -1,7 +1,10 @@
 package handler
 
 import (
+	"context"
+	"errors"
 	"net/http"
+	"strconv"
 
 	"github.com/beercut-team/backend-boilerplate/internal/domain"
 	"github.com/beercut-team/backend-boilerplate/internal/middleware"
@@ -10,11 +13,12 @@ import (
 )
 
 type AuthHandler struct {
-	authService service.AuthService
+	authService  service.AuthService
+	tokenService service.TokenService
 }
 
-func NewAuthHandler(authService service.AuthService) *AuthHandler {
-	return &AuthHandler{authService: authService}
+func NewAuthHandler(authService service.AuthService, tokenService service.TokenService) *AuthHandler {
+	return &AuthHandler{authService: authService, tokenService: tokenService}
 }
 
 func (h *AuthHandler) Register(c *gin.Context) {
@@ -24,7 +28,9 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		return
 	}
 
-	resp, err := h.authService.Register(c.Request.Context(), req)
+	// Регистрация без токена: в аудите автор 0 и IP запроса
+	ctx := service.WithAuditActor(c.Request.Context(), 0, "", c.ClientIP())
+	resp, err := h.authService.Register(ctx, req, clientInfo(c))
 	if err != nil {
 		c.JSON(http.StatusConflict, domain.ErrorResponse{Error: err.Error()})
 		return
@@ -40,8 +46,34 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
-	resp, err := h.authService.Login(c.Request.Context(), req)
+	resp, err := h.authService.Login(c.Request.Context(), req, clientInfo(c))
 	if err != nil {
+		// Пароль верный, клиент запрашивает код и вызывает /auth/2fa/verify
+		var challenge *service.TwoFactorRequiredError
+		if errors.As(err, &challenge) {
+			c.JSON(http.StatusOK, challenge.Response())
+			return
+		}
+		c.JSON(http.StatusUnauthorized, domain.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// VerifyTwoFactor — второй шаг входа: код из приложения или резервный код
+func (h *AuthHandler) VerifyTwoFactor(c *gin.Context) {
+	var req domain.TwoFactorVerifyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	resp, err := h.authService.VerifyTwoFactor(c.Request.Context(), req, clientInfo(c))
+	if err != nil {
+		if writeTwoFactorLocked(c, err) {
+			return
+		}
 		c.JSON(http.StatusUnauthorized, domain.ErrorResponse{Error: err.Error()})
 		return
 	}
@@ -56,8 +88,11 @@ func (h *AuthHandler) PatientLogin(c *gin.Context) {
 		return
 	}
 
-	resp, err := h.authService.PatientLogin(c.Request.Context(), req)
+	resp, err := h.authService.PatientLogin(c.Request.Context(), req, clientInfo(c))
 	if err != nil {
+		if writeAccessCodeError(c, err) {
+			return
+		}
 		c.JSON(http.StatusUnauthorized, domain.ErrorResponse{Error: err.Error()})
 		return
 	}
@@ -72,7 +107,7 @@ func (h *AuthHandler) TelegramTokenLogin(c *gin.Context) {
 		return
 	}
 
-	resp, err := h.authService.TelegramTokenLogin(c.Request.Context(), req)
+	resp, err := h.authService.TelegramTokenLogin(c.Request.Context(), req, clientInfo(c))
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, domain.ErrorResponse{Error: err.Error()})
 		return
@@ -88,7 +123,7 @@ func (h *AuthHandler) Refresh(c *gin.Context) {
 		return
 	}
 
-	resp, err := h.authService.Refresh(c.Request.Context(), req)
+	resp, err := h.authService.Refresh(c.Request.Context(), req, clientInfo(c))
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, domain.ErrorResponse{Error: err.Error()})
 		return
@@ -97,10 +132,9 @@ func (h *AuthHandler) Refresh(c *gin.Context) {
 	c.JSON(http.StatusOK, resp)
 }
 
+// Logout завершает текущую сессию: её токен обновления больше не принимается
 func (h *AuthHandler) Logout(c *gin.Context) {
-	userID := middleware.GetUserID(c)
-
-	if err := h.authService.Logout(c.Request.Context(), userID); err != nil {
+	if err := h.authService.Logout(c.Request.Context(), middleware.GetAccessClaims(c)); err != nil {
 		c.JSON(http.StatusInternalServerError, domain.ErrorResponse{Error: "не удалось выйти из системы"})
 		return
 	}
@@ -108,6 +142,12 @@ func (h *AuthHandler) Logout(c *gin.Context) {
 	c.JSON(http.StatusOK, domain.MessageResponse{Message: "вы вышли из системы"})
 }
 
+// JWKS — открытые ключи подписи токенов (RS256/EdDSA) для проверки в других сервисах
+func (h *AuthHandler) JWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, h.tokenService.JWKS())
+}
+
 func (h *AuthHandler) Me(c *gin.Context) {
 	userID := middleware.GetUserID(c)
 
@@ -119,3 +159,140 @@ func (h *AuthHandler) Me(c *gin.Context) {
 
 	c.JSON(http.StatusOK, resp)
 }
+
+// ListSessions — активные сессии текущего пользователя; current — сессия запроса
+func (h *AuthHandler) ListSessions(c *gin.Context) {
+	sessions, err := h.authService.ListSessions(c.Request.Context(), middleware.GetSessionID(c))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, domain.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, sessions)
+}
+
+func (h *AuthHandler) RevokeSession(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse{Error: "неверный ID сессии"})
+		return
+	}
+
+	if err := h.authService.RevokeSession(c.Request.Context(), middleware.GetSessionID(c), uint(id)); err != nil {
+		c.JSON(http.StatusNotFound, domain.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, domain.MessageResponse{Message: "сессия завершена"})
+}
+
+// RevokeOtherSessions — выход на всех устройствах, кроме текущего
+func (h *AuthHandler) RevokeOtherSessions(c *gin.Context) {
+	if err := h.authService.RevokeOtherSessions(c.Request.Context(), middleware.GetSessionID(c)); err != nil {
+		c.JSON(http.StatusUnauthorized, domain.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, domain.MessageResponse{Message: "остальные сессии завершены"})
+}
+
+// SetupTwoFactor выдаёт секрет для приложения-аутентификатора; 2FA включается после EnableTwoFactor
+func (h *AuthHandler) SetupTwoFactor(c *gin.Context) {
+	var req domain.TwoFactorSetupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	resp, err := h.authService.SetupTwoFactor(c.Request.Context(), middleware.GetSessionID(c), req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// EnableTwoFactor подтверждает секрет первым кодом и возвращает резервные коды
+func (h *AuthHandler) EnableTwoFactor(c *gin.Context) {
+	var req domain.TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	resp, err := h.authService.EnableTwoFactor(auditContext(c), middleware.GetSessionID(c), req)
+	if err != nil {
+		if writeTwoFactorLocked(c, err) {
+			return
+		}
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+func (h *AuthHandler) DisableTwoFactor(c *gin.Context) {
+	var req domain.TwoFactorDisableRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	if err := h.authService.DisableTwoFactor(auditContext(c), middleware.GetSessionID(c), req); err != nil {
+		if writeTwoFactorLocked(c, err) {
+			return
+		}
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, domain.MessageResponse{Message: "двухфакторная аутентификация отключена"})
+}
+
+// RegenerateRecoveryCodes заменяет резервные коды новыми; старые перестают действовать
+func (h *AuthHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	var req domain.TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	resp, err := h.authService.RegenerateRecoveryCodes(auditContext(c), middleware.GetSessionID(c), req)
+	if err != nil {
+		if writeTwoFactorLocked(c, err) {
+			return
+		}
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// writeTwoFactorLocked отвечает 429, если ввод кодов 2FA заблокирован
+func writeTwoFactorLocked(c *gin.Context, err error) bool {
+	var locked *service.TwoFactorLockedError
+	if !errors.As(err, &locked) {
+		return false
+	}
+	seconds := int(locked.RetryAfter.Seconds()) + 1
+	c.Header("Retry-After", strconv.Itoa(seconds))
+	c.JSON(http.StatusTooManyRequests, domain.ErrorResponse{Error: err.Error()})
+	return true
+}
+
+// clientInfo — устройство и IP для списка сессий
+func clientInfo(c *gin.Context) domain.ClientInfo {
+	ua := []rune(c.Request.UserAgent())
+	if len(ua) > 255 {
+		ua = ua[:255]
+	}
+	return domain.ClientInfo{UserAgent: string(ua), IP: c.ClientIP()}
+}
+
+// auditContext — AuditMiddleware пропускает /auth, автор изменений передаётся явно
+func auditContext(c *gin.Context) context.Context {
+	return service.WithAuditActor(c.Request.Context(), middleware.GetUserID(c), middleware.GetUserRole(c), c.ClientIP())
+}
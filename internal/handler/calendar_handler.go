@@ -0,0 +1,171 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/beercut-team/backend-boilerplate/internal/domain"
+	"github.com/beercut-team/backend-boilerplate/internal/middleware"
+	"github.com/beercut-team/backend-boilerplate/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+type CalendarHandler struct {
+	svc service.CalendarService
+}
+
+func NewCalendarHandler(svc service.CalendarService) *CalendarHandler {
+	return &CalendarHandler{svc: svc}
+}
+
+func (h *CalendarHandler) ListRooms(c *gin.Context) {
+	activeOnly := c.Query("active") == "true"
+	rooms, err := h.svc.ListRooms(c.Request.Context(), activeOnly)
+	if err != nil {
+		InternalError(c, "не удалось получить список операционных")
+		return
+	}
+	Success(c, http.StatusOK, rooms)
+}
+
+func (h *CalendarHandler) CreateRoom(c *gin.Context) {
+	var req domain.CreateOperatingRoomRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, err.Error())
+		return
+	}
+
+	room, err := h.svc.CreateRoom(c.Request.Context(), req)
+	if err != nil {
+		BadRequest(c, err.Error())
+		return
+	}
+	Success(c, http.StatusCreated, room)
+}
+
+func (h *CalendarHandler) UpdateRoom(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		BadRequest(c, "неверный id")
+		return
+	}
+
+	var req domain.UpdateOperatingRoomRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, err.Error())
+		return
+	}
+
+	room, err := h.svc.UpdateRoom(c.Request.Context(), uint(id), req)
+	if err != nil {
+		BadRequest(c, err.Error())
+		return
+	}
+	Success(c, http.StatusOK, room)
+}
+
+func (h *CalendarHandler) GetSurgeonCalendar(c *gin.Context) {
+	surgeonID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		BadRequest(c, "неверный id")
+		return
+	}
+
+	calendar, err := h.svc.GetSurgeonCalendar(c.Request.Context(), uint(surgeonID))
+	if err != nil {
+		NotFound(c, err.Error())
+		return
+	}
+	Success(c, http.StatusOK, calendar)
+}
+
+func (h *CalendarHandler) SetWorkingHours(c *gin.Context) {
+	surgeonID, ok := h.editableSurgeon(c)
+	if !ok {
+		return
+	}
+
+	var req domain.SetWorkingHoursRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, err.Error())
+		return
+	}
+
+	hours, err := h.svc.SetWorkingHours(c.Request.Context(), surgeonID, req)
+	if err != nil {
+		BadRequest(c, err.Error())
+		return
+	}
+	Success(c, http.StatusOK, hours)
+}
+
+func (h *CalendarHandler) AddTimeOff(c *gin.Context) {
+	surgeonID, ok := h.editableSurgeon(c)
+	if !ok {
+		return
+	}
+
+	var req domain.CreateTimeOffRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, err.Error())
+		return
+	}
+
+	timeOff, err := h.svc.AddTimeOff(c.Request.Context(), surgeonID, req, middleware.GetUserID(c))
+	if err != nil {
+		BadRequest(c, err.Error())
+		return
+	}
+	Success(c, http.StatusCreated, timeOff)
+}
+
+func (h *CalendarHandler) DeleteTimeOff(c *gin.Context) {
+	surgeonID, ok := h.editableSurgeon(c)
+	if !ok {
+		return
+	}
+	id, err := strconv.ParseUint(c.Param("timeOffId"), 10, 32)
+	if err != nil {
+		BadRequest(c, "неверный id")
+		return
+	}
+
+	if err := h.svc.DeleteTimeOff(c.Request.Context(), surgeonID, uint(id)); err != nil {
+		NotFound(c, err.Error())
+		return
+	}
+	Success(c, http.StatusOK, gin.H{"message": "запись об отсутствии удалена"})
+}
+
+func (h *CalendarHandler) AvailableSlots(c *gin.Context) {
+	surgeonID := middleware.GetUserID(c)
+	if v := c.Query("surgeon_id"); v != "" {
+		id, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			BadRequest(c, "неверный surgeon_id")
+			return
+		}
+		surgeonID = uint(id)
+	}
+
+	resp, err := h.svc.AvailableSlots(c.Request.Context(), surgeonID, domain.OperationType(c.Query("operation_type")), c.Query("from"), c.Query("to"))
+	if err != nil {
+		BadRequest(c, err.Error())
+		return
+	}
+	Success(c, http.StatusOK, resp)
+}
+
+// editableSurgeon возвращает id хирурга из пути, если текущий пользователь может менять его календарь
+func (h *CalendarHandler) editableSurgeon(c *gin.Context) (uint, bool) {
+	surgeonID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		BadRequest(c, "неверный id")
+		return 0, false
+	}
+	if middleware.GetUserRole(c) != domain.RoleAdmin && middleware.GetUserID(c) != uint(surgeonID) {
+		Forbidden(c, "можно изменять только собственный календарь")
+		return 0, false
+	}
+	return uint(surgeonID), true
+}
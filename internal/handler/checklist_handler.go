@@ -11,11 +11,12 @@ import (
 )
 
 type ChecklistHandler struct {
-	svc service.ChecklistService
+	svc    service.ChecklistService
+	policy service.AccessPolicy
 }
 
-func NewChecklistHandler(svc service.ChecklistService) *ChecklistHandler {
-	return &ChecklistHandler{svc: svc}
+func NewChecklistHandler(svc service.ChecklistService, policy service.AccessPolicy) *ChecklistHandler {
+	return &ChecklistHandler{svc: svc, policy: policy}
 }
 
 func (h *ChecklistHandler) GetByPatient(c *gin.Context) {
@@ -24,6 +25,9 @@ func (h *ChecklistHandler) GetByPatient(c *gin.Context) {
 		BadRequest(c, "неверный patient_id")
 		return
 	}
+	if !canAccessPatient(c, h.policy, uint(patientID), domain.PatientActionRead) {
+		return
+	}
 
 	items, err := h.svc.GetByPatient(c.Request.Context(), uint(patientID))
 	if err != nil {
@@ -40,6 +44,9 @@ func (h *ChecklistHandler) CreateItem(c *gin.Context) {
 		BadRequest(c, err.Error())
 		return
 	}
+	if !canAccessPatient(c, h.policy, req.PatientID, domain.PatientActionWrite) {
+		return
+	}
 
 	userID := middleware.GetUserID(c)
 	item, err := h.svc.CreateItem(c.Request.Context(), req, userID)
@@ -57,6 +64,9 @@ func (h *ChecklistHandler) UpdateItem(c *gin.Context) {
 		BadRequest(c, "неверный id")
 		return
 	}
+	if !canAccessChecklistItem(c, h.policy, uint(id), domain.PatientActionWrite) {
+		return
+	}
 
 	var req domain.UpdateChecklistItemRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -80,6 +90,9 @@ func (h *ChecklistHandler) ReviewItem(c *gin.Context) {
 		BadRequest(c, "неверный id")
 		return
 	}
+	if !canAccessChecklistItem(c, h.policy, uint(id), domain.PatientActionReview) {
+		return
+	}
 
 	var req domain.ReviewChecklistItemRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -103,6 +116,9 @@ func (h *ChecklistHandler) GetProgress(c *gin.Context) {
 		BadRequest(c, "неверный patient_id")
 		return
 	}
+	if !canAccessPatient(c, h.policy, uint(patientID), domain.PatientActionRead) {
+		return
+	}
 
 	progress, err := h.svc.GetProgress(c.Request.Context(), uint(patientID))
 	if err != nil {
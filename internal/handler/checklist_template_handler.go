@@ -0,0 +1,143 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/beercut-team/backend-boilerplate/internal/domain"
+	"github.com/beercut-team/backend-boilerplate/internal/middleware"
+	"github.com/beercut-team/backend-boilerplate/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+type ChecklistTemplateHandler struct {
+	svc    service.ChecklistTemplateService
+	policy service.AccessPolicy
+}
+
+func NewChecklistTemplateHandler(svc service.ChecklistTemplateService, policy service.AccessPolicy) *ChecklistTemplateHandler {
+	return &ChecklistTemplateHandler{svc: svc, policy: policy}
+}
+
+func (h *ChecklistTemplateHandler) List(c *gin.Context) {
+	var opType *domain.OperationType
+	if v := strings.TrimSpace(c.Query("operation_type")); v != "" {
+		t := domain.OperationType(strings.ToUpper(v))
+		opType = &t
+	}
+
+	templates, err := h.svc.List(c.Request.Context(), opType)
+	if err != nil {
+		InternalError(c, "не удалось получить шаблоны чек-листов")
+		return
+	}
+	Success(c, http.StatusOK, templates)
+}
+
+func (h *ChecklistTemplateHandler) GetByID(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		BadRequest(c, "неверный id")
+		return
+	}
+
+	t, err := h.svc.GetByID(c.Request.Context(), uint(id))
+	if err != nil {
+		NotFound(c, err.Error())
+		return
+	}
+	Success(c, http.StatusOK, t)
+}
+
+func (h *ChecklistTemplateHandler) Create(c *gin.Context) {
+	var req domain.CreateChecklistTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, err.Error())
+		return
+	}
+
+	t, err := h.svc.Create(c.Request.Context(), req, middleware.GetUserID(c))
+	if err != nil {
+		BadRequest(c, err.Error())
+		return
+	}
+	Success(c, http.StatusCreated, t)
+}
+
+func (h *ChecklistTemplateHandler) Update(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		BadRequest(c, "неверный id")
+		return
+	}
+
+	var req domain.UpdateChecklistTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, err.Error())
+		return
+	}
+
+	t, err := h.svc.Update(c.Request.Context(), uint(id), req)
+	if err != nil {
+		BadRequest(c, err.Error())
+		return
+	}
+	Success(c, http.StatusOK, t)
+}
+
+func (h *ChecklistTemplateHandler) Delete(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		BadRequest(c, "неверный id")
+		return
+	}
+
+	if err := h.svc.Delete(c.Request.Context(), uint(id)); err != nil {
+		BadRequest(c, err.Error())
+		return
+	}
+	Success(c, http.StatusOK, gin.H{"message": "шаблон удалён"})
+}
+
+func (h *ChecklistTemplateHandler) Activate(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		BadRequest(c, "неверный id")
+		return
+	}
+
+	t, err := h.svc.Activate(c.Request.Context(), uint(id))
+	if err != nil {
+		BadRequest(c, err.Error())
+		return
+	}
+	Success(c, http.StatusOK, t)
+}
+
+// UpgradePatient переводит чек-лист пациента на новую версию шаблона
+func (h *ChecklistTemplateHandler) UpgradePatient(c *gin.Context) {
+	patientID, err := strconv.ParseUint(c.Param("patientId"), 10, 32)
+	if err != nil {
+		BadRequest(c, "неверный patient_id")
+		return
+	}
+	if !canAccessPatient(c, h.policy, uint(patientID), domain.PatientActionWrite) {
+		return
+	}
+
+	var req domain.UpgradeChecklistRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			BadRequest(c, err.Error())
+			return
+		}
+	}
+
+	result, err := h.svc.UpgradePatientChecklist(c.Request.Context(), uint(patientID), req.TemplateID)
+	if err != nil {
+		BadRequest(c, err.Error())
+		return
+	}
+	Success(c, http.StatusOK, result)
+}
@@ -11,11 +11,12 @@ import (
 )
 
 type CommentHandler struct {
-	svc service.CommentService
+	svc    service.CommentService
+	policy service.AccessPolicy
 }
 
-func NewCommentHandler(svc service.CommentService) *CommentHandler {
-	return &CommentHandler{svc: svc}
+func NewCommentHandler(svc service.CommentService, policy service.AccessPolicy) *CommentHandler {
+	return &CommentHandler{svc: svc, policy: policy}
 }
 
 func (h *CommentHandler) Create(c *gin.Context) {
@@ -24,6 +25,9 @@ func (h *CommentHandler) Create(c *gin.Context) {
 		BadRequest(c, err.Error())
 		return
 	}
+	if !canAccessPatient(c, h.policy, req.PatientID, domain.PatientActionReview) {
+		return
+	}
 
 	authorID := middleware.GetUserID(c)
 	comment, err := h.svc.Create(c.Request.Context(), req, authorID)
@@ -41,6 +45,9 @@ func (h *CommentHandler) GetByPatient(c *gin.Context) {
 		BadRequest(c, "неверный patient_id")
 		return
 	}
+	if !canAccessPatient(c, h.policy, uint(patientID), domain.PatientActionRead) {
+		return
+	}
 
 	comments, err := h.svc.GetByPatient(c.Request.Context(), uint(patientID))
 	if err != nil {
@@ -57,6 +64,9 @@ func (h *CommentHandler) MarkAsRead(c *gin.Context) {
 		BadRequest(c, "неверный patient_id")
 		return
 	}
+	if !canAccessPatient(c, h.policy, uint(patientID), domain.PatientActionRead) {
+		return
+	}
 
 	userID := middleware.GetUserID(c)
 	if err := h.svc.MarkAsRead(c.Request.Context(), uint(patientID), userID); err != nil {
@@ -0,0 +1,134 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/beercut-team/backend-boilerplate/internal/domain"
+	"github.com/beercut-team/backend-boilerplate/internal/middleware"
+	"github.com/beercut-team/backend-boilerplate/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+type ConsentHandler struct {
+	svc    service.ConsentService
+	policy service.AccessPolicy
+}
+
+func NewConsentHandler(svc service.ConsentService, policy service.AccessPolicy) *ConsentHandler {
+	return &ConsentHandler{svc: svc, policy: policy}
+}
+
+// Texts — действующие редакции текстов согласий
+// GET /api/v1/consents/texts
+func (h *ConsentHandler) Texts(c *gin.Context) {
+	Success(c, http.StatusOK, domain.ConsentTexts)
+}
+
+// ListByPatient — история согласий пациента и недостающие согласия
+// GET /api/v1/consents/patient/:patientId
+func (h *ConsentHandler) ListByPatient(c *gin.Context) {
+	patientID, ok := parsePatientID(c)
+	if !ok {
+		return
+	}
+	if !canAccessPatient(c, h.policy, patientID, domain.PatientActionRead) {
+		return
+	}
+
+	consents, err := h.svc.ListByPatient(c.Request.Context(), patientID)
+	if err != nil {
+		InternalError(c, "не удалось получить согласия")
+		return
+	}
+
+	Success(c, http.StatusOK, consents)
+}
+
+// Capture — согласие из личного кабинета (пациент) или скан бланка (сотрудник)
+// POST /api/v1/consents/patient/:patientId
+func (h *ConsentHandler) Capture(c *gin.Context) {
+	patientID, ok := parsePatientID(c)
+	if !ok {
+		return
+	}
+	if !h.canManage(c, patientID) {
+		return
+	}
+
+	var req domain.CaptureConsentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, err.Error())
+		return
+	}
+
+	consent, created, err := h.svc.Capture(c.Request.Context(), patientID, req, requestActor(c))
+	if err != nil {
+		Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+	Success(c, status, consent)
+}
+
+// Revoke — отзыв согласия
+// POST /api/v1/consents/:id/revoke
+func (h *ConsentHandler) Revoke(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		BadRequest(c, "неверный id")
+		return
+	}
+	if !canAccessConsent(c, h.policy, uint(id), manageAction(c)) {
+		return
+	}
+
+	var req domain.RevokeConsentRequest
+	// Причина необязательна, пустое тело допустимо
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			BadRequest(c, err.Error())
+			return
+		}
+	}
+
+	revoked, err := h.svc.Revoke(c.Request.Context(), uint(id), req, requestActor(c))
+	if err != nil {
+		Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	Success(c, http.StatusOK, revoked)
+}
+
+// canManage — пациент даёт и отзывает только свои согласия,
+// сотрудник — согласия пациентов, которых может редактировать
+func (h *ConsentHandler) canManage(c *gin.Context, patientID uint) bool {
+	return canAccessPatient(c, h.policy, patientID, manageAction(c))
+}
+
+// manageAction — пациенту чтение разрешено только к своей карте, поэтому
+// для него достаточно права на чтение; сотруднику нужно право на изменение
+func manageAction(c *gin.Context) domain.PatientAction {
+	if middleware.GetUserRole(c) == domain.RolePatient {
+		return domain.PatientActionRead
+	}
+	return domain.PatientActionWrite
+}
+
+func parsePatientID(c *gin.Context) (uint, bool) {
+	patientID, err := strconv.ParseUint(c.Param("patientId"), 10, 32)
+	if err != nil {
+		BadRequest(c, "неверный patient_id")
+		return 0, false
+	}
+	return uint(patientID), true
+}
+
+func requestActor(c *gin.Context) domain.Actor {
+	return domain.Actor{ID: middleware.GetUserID(c), Role: middleware.GetUserRole(c)}
+}
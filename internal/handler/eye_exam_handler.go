@@ -0,0 +1,122 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/beercut-team/backend-boilerplate/internal/domain"
+	"github.com/beercut-team/backend-boilerplate/internal/middleware"
+	"github.com/beercut-team/backend-boilerplate/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+type EyeExamHandler struct {
+	svc    service.EyeExamService
+	policy service.AccessPolicy
+}
+
+func NewEyeExamHandler(svc service.EyeExamService, policy service.AccessPolicy) *EyeExamHandler {
+	return &EyeExamHandler{svc: svc, policy: policy}
+}
+
+func (h *EyeExamHandler) Create(c *gin.Context) {
+	var req domain.CreateEyeExamRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, err.Error())
+		return
+	}
+	if !canAccessPatient(c, h.policy, req.PatientID, domain.PatientActionWrite) {
+		return
+	}
+
+	exam, err := h.svc.Create(c.Request.Context(), req, middleware.GetUserID(c))
+	if err != nil {
+		Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	Success(c, http.StatusCreated, exam)
+}
+
+func (h *EyeExamHandler) GetByID(c *gin.Context) {
+	id, ok := h.examID(c, domain.PatientActionRead)
+	if !ok {
+		return
+	}
+
+	exam, err := h.svc.GetByID(c.Request.Context(), id)
+	if err != nil {
+		NotFound(c, err.Error())
+		return
+	}
+	Success(c, http.StatusOK, exam)
+}
+
+// History — осмотры пациента от последнего к первому
+// GET /api/v1/eye-exams/patient/:patientId?eye=OD
+func (h *EyeExamHandler) History(c *gin.Context) {
+	patientID, err := strconv.ParseUint(c.Param("patientId"), 10, 32)
+	if err != nil {
+		BadRequest(c, "неверный patient_id")
+		return
+	}
+	if !canAccessPatient(c, h.policy, uint(patientID), domain.PatientActionRead) {
+		return
+	}
+
+	exams, err := h.svc.History(c.Request.Context(), uint(patientID), c.Query("eye"))
+	if err != nil {
+		InternalError(c, "не удалось получить осмотры")
+		return
+	}
+
+	Success(c, http.StatusOK, exams)
+}
+
+func (h *EyeExamHandler) Update(c *gin.Context) {
+	id, ok := h.examID(c, domain.PatientActionWrite)
+	if !ok {
+		return
+	}
+
+	var req domain.UpdateEyeExamRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, err.Error())
+		return
+	}
+
+	updated, err := h.svc.Update(c.Request.Context(), id, req)
+	if err != nil {
+		Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	Success(c, http.StatusOK, updated)
+}
+
+func (h *EyeExamHandler) Delete(c *gin.Context) {
+	id, ok := h.examID(c, domain.PatientActionWrite)
+	if !ok {
+		return
+	}
+
+	if err := h.svc.Delete(c.Request.Context(), id); err != nil {
+		Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	Success(c, http.StatusOK, gin.H{"message": "осмотр удалён"})
+}
+
+// examID разбирает id осмотра из пути и проверяет доступ к пациенту
+func (h *EyeExamHandler) examID(c *gin.Context, action domain.PatientAction) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		BadRequest(c, "неверный id")
+		return 0, false
+	}
+	if !canAccessEyeExam(c, h.policy, uint(id), action) {
+		return 0, false
+	}
+	return uint(id), true
+}
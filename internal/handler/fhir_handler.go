@@ -0,0 +1,247 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/beercut-team/backend-boilerplate/internal/domain"
+	"github.com/beercut-team/backend-boilerplate/internal/middleware"
+	"github.com/beercut-team/backend-boilerplate/internal/service"
+	"github.com/beercut-team/backend-boilerplate/internal/service/fhir"
+	"github.com/gin-gonic/gin"
+)
+
+// FHIRHandler отдаёт ресурсы в формате FHIR R4 (application/fhir+json),
+// ошибки возвращаются как OperationOutcome.
+type FHIRHandler struct {
+	svc    service.FHIRService
+	policy service.AccessPolicy
+}
+
+func NewFHIRHandler(svc service.FHIRService, policy service.AccessPolicy) *FHIRHandler {
+	return &FHIRHandler{svc: svc, policy: policy}
+}
+
+func fhirJSON(c *gin.Context, status int, body interface{}) {
+	c.Header("Content-Type", fhir.ContentType)
+	c.JSON(status, body)
+}
+
+func fhirError(c *gin.Context, status int, code, message string) {
+	fhirJSON(c, status, fhir.NewOperationOutcome(code, message))
+}
+
+// patientParam читает id пациента из пути или из параметра поиска ?patient=
+func patientParam(c *gin.Context) (uint, bool) {
+	raw := c.Param("id")
+	if raw == "" {
+		raw = c.Query("patient")
+	}
+	id, err := strconv.ParseUint(raw, 10, 32)
+	if err != nil {
+		fhirError(c, http.StatusBadRequest, "invalid", "неверный id пациента")
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// fhirAccess отвечает OperationOutcome, если проверка доступа не пройдена
+func fhirAccess(c *gin.Context, err error) bool {
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, service.ErrAccessDenied):
+		fhirError(c, http.StatusForbidden, "forbidden", err.Error())
+	case service.IsNotFound(err):
+		fhirError(c, http.StatusNotFound, "not-found", err.Error())
+	default:
+		fhirError(c, http.StatusInternalServerError, "exception", "не удалось проверить доступ")
+	}
+	return false
+}
+
+// fhirServiceError отвечает на ошибку чтения: 404, если объект не найден, иначе 500 без подробностей
+func fhirServiceError(c *gin.Context, err error) {
+	if service.IsNotFound(err) {
+		fhirError(c, http.StatusNotFound, "not-found", err.Error())
+		return
+	}
+	fhirError(c, http.StatusInternalServerError, "exception", "внутренняя ошибка сервера")
+}
+
+// fhirImportError отвечает на ошибку импорта Bundle
+func fhirImportError(c *gin.Context, err error) {
+	var dup *service.DuplicatePatientError
+	var fields domain.FieldErrors
+	switch {
+	case errors.Is(err, service.ErrAccessDenied):
+		fhirError(c, http.StatusForbidden, "forbidden", err.Error())
+	case service.IsNotFound(err):
+		fhirError(c, http.StatusNotFound, "not-found", err.Error())
+	case errors.As(err, &dup):
+		fhirError(c, http.StatusConflict, "duplicate", err.Error())
+	case errors.As(err, &fields):
+		fhirError(c, http.StatusUnprocessableEntity, "invalid", err.Error())
+	case service.IsInvalidBundle(err):
+		fhirError(c, http.StatusBadRequest, "invalid", err.Error())
+	default:
+		fhirError(c, http.StatusInternalServerError, "exception", "не удалось импортировать Bundle")
+	}
+}
+
+// patientAccess читает id пациента и проверяет право просмотра
+func (h *FHIRHandler) patientAccess(c *gin.Context) (uint, bool) {
+	id, ok := patientParam(c)
+	if !ok {
+		return 0, false
+	}
+	err := h.policy.CanAccessPatient(c.Request.Context(), middleware.GetUserID(c), middleware.GetUserRole(c), id, domain.PatientActionRead)
+	return id, fhirAccess(c, err)
+}
+
+// GetPatient returns FHIR Patient
+// GET /api/v1/fhir/Patient/:id
+func (h *FHIRHandler) GetPatient(c *gin.Context) {
+	id, ok := h.patientAccess(c)
+	if !ok {
+		return
+	}
+	res, err := h.svc.GetPatient(c.Request.Context(), id)
+	if err != nil {
+		fhirServiceError(c, err)
+		return
+	}
+	fhirJSON(c, http.StatusOK, res)
+}
+
+// Everything returns all patient resources as a searchset Bundle
+// GET /api/v1/fhir/Patient/:id/$everything
+func (h *FHIRHandler) Everything(c *gin.Context) {
+	id, ok := h.patientAccess(c)
+	if !ok {
+		return
+	}
+	bundle, err := h.svc.Everything(c.Request.Context(), id)
+	if err != nil {
+		fhirServiceError(c, err)
+		return
+	}
+	fhirJSON(c, http.StatusOK, bundle)
+}
+
+// SearchConditions returns patient conditions
+// GET /api/v1/fhir/Condition?patient=:id
+func (h *FHIRHandler) SearchConditions(c *gin.Context) {
+	id, ok := h.patientAccess(c)
+	if !ok {
+		return
+	}
+	conditions, err := h.svc.GetConditions(c.Request.Context(), id)
+	if err != nil {
+		fhirServiceError(c, err)
+		return
+	}
+	h.searchset(c, conditions)
+}
+
+// SearchProcedures returns patient procedures (surgeries)
+// GET /api/v1/fhir/Procedure?patient=:id
+func (h *FHIRHandler) SearchProcedures(c *gin.Context) {
+	id, ok := h.patientAccess(c)
+	if !ok {
+		return
+	}
+	procedures, err := h.svc.GetProcedures(c.Request.Context(), id)
+	if err != nil {
+		fhirServiceError(c, err)
+		return
+	}
+	h.searchset(c, procedures)
+}
+
+// GetProcedure returns a single procedure by surgery id
+// GET /api/v1/fhir/Procedure/:id
+func (h *FHIRHandler) GetProcedure(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		fhirError(c, http.StatusBadRequest, "invalid", "неверный id")
+		return
+	}
+	if !fhirAccess(c, h.policy.CanAccessSurgery(c.Request.Context(), middleware.GetUserID(c), middleware.GetUserRole(c), uint(id), domain.PatientActionRead)) {
+		return
+	}
+	res, err := h.svc.GetProcedure(c.Request.Context(), uint(id))
+	if err != nil {
+		fhirServiceError(c, err)
+		return
+	}
+	fhirJSON(c, http.StatusOK, res)
+}
+
+// SearchObservations returns patient observations (biometry, checklist results)
+// GET /api/v1/fhir/Observation?patient=:id
+func (h *FHIRHandler) SearchObservations(c *gin.Context) {
+	id, ok := h.patientAccess(c)
+	if !ok {
+		return
+	}
+	observations, err := h.svc.GetObservations(c.Request.Context(), id)
+	if err != nil {
+		fhirServiceError(c, err)
+		return
+	}
+	h.searchset(c, observations)
+}
+
+// ImportBundle imports a transaction/batch Bundle with Patient and Condition resources
+// POST /api/v1/fhir/Bundle
+func (h *FHIRHandler) ImportBundle(c *gin.Context) {
+	var bundle fhir.Bundle
+	if err := c.ShouldBindJSON(&bundle); err != nil {
+		fhirError(c, http.StatusBadRequest, "invalid", "некорректный Bundle: "+err.Error())
+		return
+	}
+
+	// Явные ссылки на пациентов проверяются до разбора Bundle;
+	// найденных по СНИЛС и полису проверяет сервис
+	ctx := c.Request.Context()
+	userID, role := middleware.GetUserID(c), middleware.GetUserRole(c)
+	for _, id := range fhir.ReferencedPatientIDs(bundle) {
+		if !fhirAccess(c, h.policy.CanAccessPatient(ctx, userID, role, id, domain.PatientActionWrite)) {
+			return
+		}
+	}
+
+	resp, err := h.svc.ImportBundle(ctx, bundle, userID, role)
+	if err != nil {
+		fhirImportError(c, err)
+		return
+	}
+	fhirJSON(c, http.StatusOK, resp)
+}
+
+func (h *FHIRHandler) searchset(c *gin.Context, resources interface{}) {
+	var items []interface{}
+	switch v := resources.(type) {
+	case []fhir.Condition:
+		for _, r := range v {
+			items = append(items, r)
+		}
+	case []fhir.Procedure:
+		for _, r := range v {
+			items = append(items, r)
+		}
+	case []fhir.Observation:
+		for _, r := range v {
+			items = append(items, r)
+		}
+	}
+
+	bundle, err := fhir.NewSearchsetBundle(items...)
+	if err != nil {
+		fhirError(c, http.StatusInternalServerError, "exception", "не удалось сформировать Bundle")
+		return
+	}
+	fhirJSON(c, http.StatusOK, bundle)
+}
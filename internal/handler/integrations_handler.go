@@ -1,20 +1,23 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
 	"github.com/beercut-team/backend-boilerplate/internal/domain"
+	"github.com/beercut-team/backend-boilerplate/internal/middleware"
 	"github.com/beercut-team/backend-boilerplate/internal/service"
 	"github.com/gin-gonic/gin"
 )
 
 type IntegrationsHandler struct {
 	integrationsSvc service.IntegrationsService
+	policy          service.AccessPolicy
 }
 
-func NewIntegrationsHandler(integrationsSvc service.IntegrationsService) *IntegrationsHandler {
-	return &IntegrationsHandler{integrationsSvc: integrationsSvc}
+func NewIntegrationsHandler(integrationsSvc service.IntegrationsService, policy service.AccessPolicy) *IntegrationsHandler {
+	return &IntegrationsHandler{integrationsSvc: integrationsSvc, policy: policy}
 }
 
 // EMIAS endpoints
@@ -27,6 +30,9 @@ func (h *IntegrationsHandler) ExportToEMIAS(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "неверный ID пациента"})
 		return
 	}
+	if !canAccessPatient(c, h.policy, uint(patientID), domain.PatientActionWrite) {
+		return
+	}
 
 	// Validate first
 	validation, err := h.integrationsSvc.ValidateForEMIAS(c.Request.Context(), uint(patientID))
@@ -45,13 +51,13 @@ func (h *IntegrationsHandler) ExportToEMIAS(c *gin.Context) {
 		return
 	}
 
-	result, err := h.integrationsSvc.ExportToEMIAS(c.Request.Context(), uint(patientID))
+	result, err := h.integrationsSvc.ExportToEMIAS(c.Request.Context(), uint(patientID), middleware.GetUserID(c))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, result)
+		writeExportError(c, result, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, result)
+	c.JSON(http.StatusAccepted, result)
 }
 
 // CreateEMIASCase creates a case in EMIAS
@@ -62,6 +68,9 @@ func (h *IntegrationsHandler) CreateEMIASCase(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "неверный ID пациента"})
 		return
 	}
+	if !canAccessPatient(c, h.policy, uint(patientID), domain.PatientActionWrite) {
+		return
+	}
 
 	var req domain.EMIASExportRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -71,13 +80,13 @@ func (h *IntegrationsHandler) CreateEMIASCase(c *gin.Context) {
 
 	req.PatientID = uint(patientID)
 
-	result, err := h.integrationsSvc.CreateEMIASCase(c.Request.Context(), req)
+	result, err := h.integrationsSvc.CreateEMIASCase(c.Request.Context(), req, middleware.GetUserID(c))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, result)
+		writeExportError(c, result, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, result)
+	c.JSON(http.StatusAccepted, result)
 }
 
 // GetEMIASStatus gets EMIAS sync status
@@ -88,6 +97,9 @@ func (h *IntegrationsHandler) GetEMIASStatus(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "неверный ID пациента"})
 		return
 	}
+	if !canAccessPatient(c, h.policy, uint(patientID), domain.PatientActionRead) {
+		return
+	}
 
 	result, err := h.integrationsSvc.GetEMIASStatus(c.Request.Context(), uint(patientID))
 	if err != nil {
@@ -108,6 +120,9 @@ func (h *IntegrationsHandler) ExportToRIAMS(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "неверный ID пациента"})
 		return
 	}
+	if !canAccessPatient(c, h.policy, uint(patientID), domain.PatientActionWrite) {
+		return
+	}
 
 	var req domain.RIAMSExportRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -137,13 +152,13 @@ func (h *IntegrationsHandler) ExportToRIAMS(c *gin.Context) {
 		return
 	}
 
-	result, err := h.integrationsSvc.ExportToRIAMS(c.Request.Context(), uint(patientID), req.RegionCode)
+	result, err := h.integrationsSvc.ExportToRIAMS(c.Request.Context(), uint(patientID), req.RegionCode, middleware.GetUserID(c))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, result)
+		writeExportError(c, result, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, result)
+	c.JSON(http.StatusAccepted, result)
 }
 
 // GetRIAMSStatus gets RIAMS sync status
@@ -154,6 +169,9 @@ func (h *IntegrationsHandler) GetRIAMSStatus(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "неверный ID пациента"})
 		return
 	}
+	if !canAccessPatient(c, h.policy, uint(patientID), domain.PatientActionRead) {
+		return
+	}
 
 	result, err := h.integrationsSvc.GetRIAMSStatus(c.Request.Context(), uint(patientID))
 	if err != nil {
@@ -174,3 +192,18 @@ func (h *IntegrationsHandler) GetRIAMSRegions(c *gin.Context) {
 		"count":   len(regions),
 	})
 }
+
+// writeExportError — без согласия пациента передача запрещена (403),
+// остальные ошибки постановки в очередь — 500
+func writeExportError(c *gin.Context, result interface{}, err error) {
+	var consentErr *service.ConsentRequiredError
+	if errors.As(err, &consentErr) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"success":          false,
+			"error":            consentErr.Error(),
+			"missing_consents": consentErr.Missing,
+		})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, result)
+}
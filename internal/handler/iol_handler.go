@@ -11,11 +11,13 @@ import (
 )
 
 type IOLHandler struct {
-	svc service.IOLService
+	svc      service.IOLService
+	biometry service.BiometryService
+	policy   service.AccessPolicy
 }
 
-func NewIOLHandler(svc service.IOLService) *IOLHandler {
-	return &IOLHandler{svc: svc}
+func NewIOLHandler(svc service.IOLService, biometry service.BiometryService, policy service.AccessPolicy) *IOLHandler {
+	return &IOLHandler{svc: svc, biometry: biometry, policy: policy}
 }
 
 func (h *IOLHandler) Calculate(c *gin.Context) {
@@ -24,6 +26,9 @@ func (h *IOLHandler) Calculate(c *gin.Context) {
 		BadRequest(c, err.Error())
 		return
 	}
+	if !canAccessPatient(c, h.policy, req.PatientID, domain.PatientActionWrite) {
+		return
+	}
 
 	userID := middleware.GetUserID(c)
 	calc, err := h.svc.Calculate(c.Request.Context(), req, userID)
@@ -35,12 +40,86 @@ func (h *IOLHandler) Calculate(c *gin.Context) {
 	Success(c, http.StatusOK, calc)
 }
 
+// Compare сравнивает формулы для выбранной линзы и возвращает таблицы мощностей
+// POST /api/v1/iol/compare
+func (h *IOLHandler) Compare(c *gin.Context) {
+	var req domain.IOLCompareRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, err.Error())
+		return
+	}
+	if !canAccessPatient(c, h.policy, req.PatientID, domain.PatientActionRead) {
+		return
+	}
+
+	result, err := h.svc.Compare(c.Request.Context(), req)
+	if err != nil {
+		Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	Success(c, http.StatusOK, result)
+}
+
+// Toric планирует торическую ИОЛ: цилиндр и ось с учётом разреза
+// POST /api/v1/iol/toric
+func (h *IOLHandler) Toric(c *gin.Context) {
+	var req domain.IOLToricRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, err.Error())
+		return
+	}
+	if !canAccessPatient(c, h.policy, req.PatientID, domain.PatientActionRead) {
+		return
+	}
+
+	result, err := h.svc.Toric(c.Request.Context(), req)
+	if err != nil {
+		Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	Success(c, http.StatusOK, result)
+}
+
+// Import принимает выгрузку биометра (CSV/XML IOLMaster, текстовый протокол А-скана)
+// POST /api/v1/iol/import
+func (h *IOLHandler) Import(c *gin.Context) {
+	file, header, err := c.Request.FormFile("file")
+	if err != nil {
+		BadRequest(c, "файл обязателен")
+		return
+	}
+	defer file.Close()
+
+	var req domain.BiometryImportRequest
+	if err := c.ShouldBind(&req); err != nil {
+		BadRequest(c, err.Error())
+		return
+	}
+	if !canAccessPatient(c, h.policy, req.PatientID, domain.PatientActionWrite) {
+		return
+	}
+
+	userID := middleware.GetUserID(c)
+	result, err := h.biometry.Import(c.Request.Context(), req, userID, header.Filename, header.Size, file)
+	if err != nil {
+		Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	Success(c, http.StatusCreated, result)
+}
+
 func (h *IOLHandler) History(c *gin.Context) {
 	patientID, err := strconv.ParseUint(c.Param("patientId"), 10, 32)
 	if err != nil {
 		BadRequest(c, "неверный patient_id")
 		return
 	}
+	if !canAccessPatient(c, h.policy, uint(patientID), domain.PatientActionRead) {
+		return
+	}
 
 	calcs, err := h.svc.GetHistory(c.Request.Context(), uint(patientID))
 	if err != nil {
@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/beercut-team/backend-boilerplate/internal/domain"
+	"github.com/beercut-team/backend-boilerplate/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+type IOLLensHandler struct {
+	svc service.IOLLensService
+}
+
+func NewIOLLensHandler(svc service.IOLLensService) *IOLLensHandler {
+	return &IOLLensHandler{svc: svc}
+}
+
+// ListActive — линзы, доступные для расчётов
+func (h *IOLLensHandler) ListActive(c *gin.Context) {
+	h.list(c, true)
+}
+
+// ListAll — весь каталог, включая отключённые линзы (для администратора)
+func (h *IOLLensHandler) ListAll(c *gin.Context) {
+	h.list(c, c.Query("active") == "true")
+}
+
+func (h *IOLLensHandler) list(c *gin.Context, activeOnly bool) {
+	lenses, err := h.svc.List(c.Request.Context(), activeOnly)
+	if err != nil {
+		InternalError(c, "не удалось получить каталог линз")
+		return
+	}
+	Success(c, http.StatusOK, lenses)
+}
+
+func (h *IOLLensHandler) Create(c *gin.Context) {
+	var req domain.CreateIOLLensRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, err.Error())
+		return
+	}
+
+	lens, err := h.svc.Create(c.Request.Context(), req)
+	if err != nil {
+		BadRequest(c, err.Error())
+		return
+	}
+	Success(c, http.StatusCreated, lens)
+}
+
+func (h *IOLLensHandler) Update(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		BadRequest(c, "неверный id")
+		return
+	}
+
+	var req domain.UpdateIOLLensRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, err.Error())
+		return
+	}
+
+	lens, err := h.svc.Update(c.Request.Context(), uint(id), req)
+	if err != nil {
+		BadRequest(c, err.Error())
+		return
+	}
+	Success(c, http.StatusOK, lens)
+}
+
+// Deactivate отключает линзу; расчёты с ней сохраняются
+func (h *IOLLensHandler) Deactivate(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		BadRequest(c, "неверный id")
+		return
+	}
+
+	lens, err := h.svc.Deactivate(c.Request.Context(), uint(id))
+	if err != nil {
+		BadRequest(c, err.Error())
+		return
+	}
+	Success(c, http.StatusOK, lens)
+}
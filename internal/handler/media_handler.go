@@ -1,22 +1,25 @@
 package handler
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"strconv"
 
+	"github.com/beercut-team/backend-boilerplate/internal/domain"
 	"github.com/beercut-team/backend-boilerplate/internal/middleware"
 	"github.com/beercut-team/backend-boilerplate/internal/service"
 	"github.com/gin-gonic/gin"
 )
 
 type MediaHandler struct {
-	svc service.MediaService
+	svc    service.MediaService
+	policy service.AccessPolicy
 }
 
-func NewMediaHandler(svc service.MediaService) *MediaHandler {
-	return &MediaHandler{svc: svc}
+func NewMediaHandler(svc service.MediaService, policy service.AccessPolicy) *MediaHandler {
+	return &MediaHandler{svc: svc, policy: policy}
 }
 
 func (h *MediaHandler) Upload(c *gin.Context) {
@@ -33,6 +36,9 @@ func (h *MediaHandler) Upload(c *gin.Context) {
 		BadRequest(c, "неверный patient_id")
 		return
 	}
+	if !canAccessPatient(c, h.policy, uint(patientID), domain.PatientActionWrite) {
+		return
+	}
 
 	category := c.PostForm("category")
 	if category == "" {
@@ -64,6 +70,9 @@ func (h *MediaHandler) GetByPatient(c *gin.Context) {
 		BadRequest(c, "неверный patient_id")
 		return
 	}
+	if !canAccessPatient(c, h.policy, uint(patientID), domain.PatientActionRead) {
+		return
+	}
 
 	media, err := h.svc.GetByPatient(c.Request.Context(), uint(patientID))
 	if err != nil {
@@ -80,6 +89,9 @@ func (h *MediaHandler) Download(c *gin.Context) {
 		BadRequest(c, "неверный id")
 		return
 	}
+	if !canAccessMedia(c, h.policy, uint(id), domain.PatientActionRead) {
+		return
+	}
 
 	media, err := h.svc.GetByID(c.Request.Context(), uint(id))
 	if err != nil {
@@ -113,6 +125,9 @@ func (h *MediaHandler) DownloadURL(c *gin.Context) {
 		BadRequest(c, "неверный id")
 		return
 	}
+	if !canAccessMedia(c, h.policy, uint(id), domain.PatientActionRead) {
+		return
+	}
 
 	url, err := h.svc.GetDownloadURL(c.Request.Context(), uint(id))
 	if err != nil {
@@ -129,8 +144,15 @@ func (h *MediaHandler) Thumbnail(c *gin.Context) {
 		BadRequest(c, "неверный id")
 		return
 	}
+	if !canAccessMedia(c, h.policy, uint(id), domain.PatientActionRead) {
+		return
+	}
 
 	url, err := h.svc.GetThumbnailURL(c.Request.Context(), uint(id))
+	if errors.Is(err, service.ErrMediaProcessing) {
+		Error(c, http.StatusConflict, err.Error())
+		return
+	}
 	if err != nil {
 		NotFound(c, err.Error())
 		return
@@ -145,6 +167,9 @@ func (h *MediaHandler) Delete(c *gin.Context) {
 		BadRequest(c, "неверный id")
 		return
 	}
+	if !canAccessMedia(c, h.policy, uint(id), domain.PatientActionWrite) {
+		return
+	}
 
 	if err := h.svc.Delete(c.Request.Context(), uint(id)); err != nil {
 		NotFound(c, err.Error())
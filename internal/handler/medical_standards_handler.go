@@ -11,10 +11,11 @@ import (
 
 type MedicalStandardsHandler struct {
 	medicalSvc service.MedicalStandardsService
+	policy     service.AccessPolicy
 }
 
-func NewMedicalStandardsHandler(medicalSvc service.MedicalStandardsService) *MedicalStandardsHandler {
-	return &MedicalStandardsHandler{medicalSvc: medicalSvc}
+func NewMedicalStandardsHandler(medicalSvc service.MedicalStandardsService, policy service.AccessPolicy) *MedicalStandardsHandler {
+	return &MedicalStandardsHandler{medicalSvc: medicalSvc, policy: policy}
 }
 
 // UpdateMedicalMetadata updates medical metadata for a patient
@@ -25,6 +26,9 @@ func (h *MedicalStandardsHandler) UpdateMedicalMetadata(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "неверный ID пациента"})
 		return
 	}
+	if !canAccessPatient(c, h.policy, uint(patientID), domain.PatientActionWrite) {
+		return
+	}
 
 	var req domain.UpdateMedicalMetadataRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/beercut-team/backend-boilerplate/internal/domain"
+	"github.com/beercut-team/backend-boilerplate/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+type OperationTypeHandler struct {
+	svc service.OperationTypeService
+}
+
+func NewOperationTypeHandler(svc service.OperationTypeService) *OperationTypeHandler {
+	return &OperationTypeHandler{svc: svc}
+}
+
+// ListActive — типы операций, доступные для новых пациентов
+func (h *OperationTypeHandler) ListActive(c *gin.Context) {
+	h.list(c, true)
+}
+
+// ListAll — все типы операций, включая отключённые (для администратора)
+func (h *OperationTypeHandler) ListAll(c *gin.Context) {
+	h.list(c, c.Query("active") == "true")
+}
+
+func (h *OperationTypeHandler) list(c *gin.Context, activeOnly bool) {
+	types, err := h.svc.List(c.Request.Context(), activeOnly)
+	if err != nil {
+		InternalError(c, "не удалось получить типы операций")
+		return
+	}
+	Success(c, http.StatusOK, types)
+}
+
+func (h *OperationTypeHandler) Create(c *gin.Context) {
+	var req domain.CreateOperationTypeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, err.Error())
+		return
+	}
+
+	t, err := h.svc.Create(c.Request.Context(), req)
+	if err != nil {
+		BadRequest(c, err.Error())
+		return
+	}
+	Success(c, http.StatusCreated, t)
+}
+
+func (h *OperationTypeHandler) Update(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		BadRequest(c, "неверный id")
+		return
+	}
+
+	var req domain.UpdateOperationTypeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, err.Error())
+		return
+	}
+
+	t, err := h.svc.Update(c.Request.Context(), uint(id), req)
+	if err != nil {
+		BadRequest(c, err.Error())
+		return
+	}
+	Success(c, http.StatusOK, t)
+}
+
+// Deactivate отключает тип операции; пациенты и операции этого типа сохраняются
+func (h *OperationTypeHandler) Deactivate(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		BadRequest(c, "неверный id")
+		return
+	}
+
+	t, err := h.svc.Deactivate(c.Request.Context(), uint(id))
+	if err != nil {
+		BadRequest(c, err.Error())
+		return
+	}
+	Success(c, http.StatusOK, t)
+}
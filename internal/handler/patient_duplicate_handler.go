@@ -0,0 +1,58 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/beercut-team/backend-boilerplate/internal/domain"
+	"github.com/beercut-team/backend-boilerplate/internal/middleware"
+	"github.com/beercut-team/backend-boilerplate/internal/repository"
+	"github.com/beercut-team/backend-boilerplate/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+type PatientDuplicateHandler struct {
+	svc service.PatientDuplicateService
+}
+
+func NewPatientDuplicateHandler(svc service.PatientDuplicateService) *PatientDuplicateHandler {
+	return &PatientDuplicateHandler{svc: svc}
+}
+
+// Report — пары записей, похожих на одного пациента
+// GET /api/v1/admin/patients/duplicates?page=&limit=
+func (h *PatientDuplicateHandler) Report(c *gin.Context) {
+	p := GetPagination(c)
+
+	pairs, err := h.svc.Report(c.Request.Context())
+	if err != nil {
+		InternalError(c, "не удалось найти дубли пациентов")
+		return
+	}
+
+	total := int64(len(pairs))
+	start, end := min(p.Offset(), len(pairs)), min(p.Offset()+p.Limit, len(pairs))
+	SuccessWithMeta(c, http.StatusOK, pairs[start:end], NewMeta(p.Page, p.Limit, total))
+}
+
+// Merge — перенос данных дубля на оставшуюся запись
+// POST /api/v1/admin/patients/merge
+func (h *PatientDuplicateHandler) Merge(c *gin.Context) {
+	var req domain.MergePatientsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, err.Error())
+		return
+	}
+
+	result, err := h.svc.Merge(c.Request.Context(), req, middleware.GetUserID(c), c.ClientIP())
+	if err != nil {
+		if errors.Is(err, repository.ErrEyeExamConflict) {
+			Error(c, http.StatusConflict, err.Error())
+			return
+		}
+		Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	Success(c, http.StatusOK, result)
+}
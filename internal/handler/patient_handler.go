@@ -1,8 +1,10 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/beercut-team/backend-boilerplate/internal/domain"
 	"github.com/beercut-team/backend-boilerplate/internal/middleware"
@@ -12,11 +14,12 @@ import (
 )
 
 type PatientHandler struct {
-	svc service.PatientService
+	svc    service.PatientService
+	policy service.AccessPolicy
 }
 
-func NewPatientHandler(svc service.PatientService) *PatientHandler {
-	return &PatientHandler{svc: svc}
+func NewPatientHandler(svc service.PatientService, policy service.AccessPolicy) *PatientHandler {
+	return &PatientHandler{svc: svc, policy: policy}
 }
 
 func (h *PatientHandler) Create(c *gin.Context) {
@@ -28,6 +31,9 @@ func (h *PatientHandler) Create(c *gin.Context) {
 
 	doctorID := middleware.GetUserID(c)
 	patient, err := h.svc.Create(c.Request.Context(), req, doctorID)
+	if invalidFields(c, err) || duplicateConflict(c, err) {
+		return
+	}
 	if err != nil {
 		Error(c, http.StatusBadRequest, err.Error())
 		return
@@ -42,6 +48,9 @@ func (h *PatientHandler) GetByID(c *gin.Context) {
 		BadRequest(c, "неверный id")
 		return
 	}
+	if !canAccessPatient(c, h.policy, uint(id), domain.PatientActionRead) {
+		return
+	}
 
 	patient, err := h.svc.GetByID(c.Request.Context(), uint(id))
 	if err != nil {
@@ -52,10 +61,19 @@ func (h *PatientHandler) GetByID(c *gin.Context) {
 	Success(c, http.StatusOK, patient)
 }
 
+// GetPublic — статус по коду доступа без входа; решение задачи — в заголовках
+// X-Challenge-Id и X-Challenge-Solution
 func (h *PatientHandler) GetPublic(c *gin.Context) {
 	code := c.Param("code")
-	resp, err := h.svc.GetByAccessCode(c.Request.Context(), code)
+	proof := domain.ChallengeProof{
+		ChallengeID:       c.GetHeader("X-Challenge-Id"),
+		ChallengeSolution: c.GetHeader("X-Challenge-Solution"),
+	}
+	resp, err := h.svc.GetByAccessCode(c.Request.Context(), code, clientInfo(c), proof)
 	if err != nil {
+		if writeAccessCodeError(c, err) {
+			return
+		}
 		NotFound(c, err.Error())
 		return
 	}
@@ -64,39 +82,122 @@ func (h *PatientHandler) GetPublic(c *gin.Context) {
 
 func (h *PatientHandler) List(c *gin.Context) {
 	p := GetPagination(c)
-	role := middleware.GetUserRole(c)
-	userID := middleware.GetUserID(c)
+	filters, ok := patientFilters(c)
+	if !ok {
+		return
+	}
+
+	patients, total, err := h.svc.List(c.Request.Context(), filters, p.Offset(), p.Limit)
+	if err != nil {
+		InternalError(c, "не удалось получить список пациентов")
+		return
+	}
+
+	SuccessWithMeta(c, http.StatusOK, patients, NewMeta(p.Page, p.Limit, total))
+}
 
+// Search — список с фасетами: счётчики по статусам, районам, хирургам, типам операций и чек-листу
+// GET /api/v1/patients/search?search=&status=&district_id=&surgeon_id=&snils=&oms_policy=&phone=...
+func (h *PatientHandler) Search(c *gin.Context) {
+	p := GetPagination(c)
+	filters, ok := patientFilters(c)
+	if !ok {
+		return
+	}
+
+	result, total, err := h.svc.Search(c.Request.Context(), filters, p.Offset(), p.Limit)
+	if err != nil {
+		InternalError(c, "не удалось выполнить поиск пациентов")
+		return
+	}
+
+	SuccessWithMeta(c, http.StatusOK, result, NewMeta(p.Page, p.Limit, total))
+}
+
+// patientFilters разбирает фильтры списка пациентов и ограничивает выборку по роли
+func patientFilters(c *gin.Context) (repository.PatientFilters, bool) {
 	filters := repository.PatientFilters{
-		Search: c.Query("search"),
+		Search:        strings.TrimSpace(c.Query("search")),
+		OMSPolicy:     strings.TrimSpace(c.Query("oms_policy")),
+		DiagnosisCode: strings.TrimSpace(c.Query("diagnosis_code")),
+		OperationType: domain.OperationType(c.Query("operation_type")),
+		Sort:          c.DefaultQuery("sort", domain.PatientSortUpdated),
 	}
 
-	if s := c.Query("status"); s != "" {
-		status := domain.PatientStatus(s)
-		filters.Status = &status
+	// status=A&status=B или status=A,B
+	for _, v := range c.QueryArray("status") {
+		for _, s := range strings.Split(v, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				filters.Statuses = append(filters.Statuses, domain.PatientStatus(s))
+			}
+		}
+	}
+
+	if !domain.ValidPatientSort(filters.Sort) {
+		BadRequest(c, "неверный sort")
+		return filters, false
+	}
+	// По умолчанию по алфавиту — от А до Я, остальное — от новых к старым
+	switch order := c.Query("order"); order {
+	case "":
+		filters.Descending = filters.Sort != domain.PatientSortName
+	case "asc", "desc":
+		filters.Descending = order == "desc"
+	default:
+		BadRequest(c, "order должен быть asc или desc")
+		return filters, false
+	}
+	if v := c.Query("checklist"); v != "" {
+		if v != domain.ChecklistComplete && v != domain.ChecklistIncomplete {
+			BadRequest(c, "checklist должен быть complete или incomplete")
+			return filters, false
+		}
+		filters.Checklist = v
+	}
+
+	for _, f := range []struct {
+		name string
+		dst  *string
+	}{{"snils", &filters.SNILS}, {"phone", &filters.Phone}} {
+		v := c.Query(f.name)
+		if v == "" {
+			continue
+		}
+		digits, ok := domain.SearchDigits(v)
+		if !ok {
+			BadRequest(c, "неверный "+f.name)
+			return filters, false
+		}
+		*f.dst = digits
+	}
+
+	var ok bool
+	if filters.DistrictID, ok = optionalID(c, "district_id"); !ok {
+		return filters, false
+	}
+	if filters.SurgeonID, ok = optionalID(c, "surgeon_id"); !ok {
+		return filters, false
+	}
+	if filters.DateOfBirth, ok = optionalDate(c, "date_of_birth"); !ok {
+		return filters, false
+	}
+	if filters.SurgeryFrom, ok = optionalDate(c, "surgery_from"); !ok {
+		return filters, false
+	}
+	if filters.SurgeryTo, ok = optionalDate(c, "surgery_to"); !ok {
+		return filters, false
 	}
 
 	// RBAC scoping
-	switch role {
+	switch middleware.GetUserRole(c) {
 	case domain.RoleDistrictDoctor:
+		userID := middleware.GetUserID(c)
 		filters.DoctorID = &userID
 	case domain.RoleSurgeon:
-		filters.MinStatus = []domain.PatientStatus{
-			domain.PatientStatusPendingReview,
-			domain.PatientStatusApproved,
-			domain.PatientStatusScheduled,
-			domain.PatientStatusCompleted,
-			domain.PatientStatusNeedsCorrection,
-		}
+		filters.MinStatus = domain.SurgeonVisibleStatuses
 	}
 
-	patients, total, err := h.svc.List(c.Request.Context(), filters, p.Offset(), p.Limit)
-	if err != nil {
-		InternalError(c, "не удалось получить список пациентов")
-		return
-	}
-
-	SuccessWithMeta(c, http.StatusOK, patients, NewMeta(p.Page, p.Limit, total))
+	return filters, true
 }
 
 func (h *PatientHandler) Update(c *gin.Context) {
@@ -105,6 +206,9 @@ func (h *PatientHandler) Update(c *gin.Context) {
 		BadRequest(c, "неверный id")
 		return
 	}
+	if !canAccessPatient(c, h.policy, uint(id), domain.PatientActionWrite) {
+		return
+	}
 
 	var req domain.UpdatePatientRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -113,6 +217,9 @@ func (h *PatientHandler) Update(c *gin.Context) {
 	}
 
 	patient, err := h.svc.Update(c.Request.Context(), uint(id), req)
+	if invalidFields(c, err) || duplicateConflict(c, err) {
+		return
+	}
 	if err != nil {
 		Error(c, http.StatusBadRequest, err.Error())
 		return
@@ -127,6 +234,9 @@ func (h *PatientHandler) ChangeStatus(c *gin.Context) {
 		BadRequest(c, "неверный id")
 		return
 	}
+	if !canAccessPatient(c, h.policy, uint(id), domain.PatientActionReview) {
+		return
+	}
 
 	var req domain.PatientStatusRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -167,6 +277,9 @@ func (h *PatientHandler) Delete(c *gin.Context) {
 		BadRequest(c, "неверный id")
 		return
 	}
+	if !canAccessPatient(c, h.policy, uint(id), domain.PatientActionWrite) {
+		return
+	}
 
 	if err := h.svc.Delete(c.Request.Context(), uint(id)); err != nil {
 		Error(c, http.StatusBadRequest, err.Error())
@@ -182,6 +295,9 @@ func (h *PatientHandler) RegenerateAccessCode(c *gin.Context) {
 		BadRequest(c, "неверный id")
 		return
 	}
+	if !canAccessPatient(c, h.policy, uint(id), domain.PatientActionWrite) {
+		return
+	}
 
 	patient, err := h.svc.RegenerateAccessCode(c.Request.Context(), uint(id))
 	if err != nil {
@@ -198,6 +314,9 @@ func (h *PatientHandler) BatchUpdate(c *gin.Context) {
 		BadRequest(c, "неверный id")
 		return
 	}
+	if !canAccessPatient(c, h.policy, uint(id), domain.PatientActionWrite) {
+		return
+	}
 
 	var req domain.BatchUpdateRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -207,6 +326,9 @@ func (h *PatientHandler) BatchUpdate(c *gin.Context) {
 
 	userID := middleware.GetUserID(c)
 	response, err := h.svc.BatchUpdate(c.Request.Context(), uint(id), req, userID)
+	if invalidFields(c, err) || duplicateConflict(c, err) {
+		return
+	}
 	if err != nil {
 		Error(c, http.StatusBadRequest, err.Error())
 		return
@@ -218,3 +340,23 @@ func (h *PatientHandler) BatchUpdate(c *gin.Context) {
 		Success(c, http.StatusPartialContent, response)
 	}
 }
+
+// duplicateConflict отвечает 409 со списком найденных записей того же пациента
+func duplicateConflict(c *gin.Context, err error) bool {
+	var dup *service.DuplicatePatientError
+	if !errors.As(err, &dup) {
+		return false
+	}
+	c.JSON(http.StatusConflict, APIResponse{Success: false, Error: dup.Error(), Data: gin.H{"duplicates": dup.Matches}})
+	return true
+}
+
+// invalidFields отвечает 400 с ошибками по полям запроса
+func invalidFields(c *gin.Context, err error) bool {
+	var fields domain.FieldErrors
+	if !errors.As(err, &fields) {
+		return false
+	}
+	c.JSON(http.StatusBadRequest, APIResponse{Success: false, Error: "неверные данные: " + fields.Error(), Data: gin.H{"fields": fields}})
+	return true
+}
@@ -0,0 +1,207 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/beercut-team/backend-boilerplate/internal/domain"
+	"github.com/beercut-team/backend-boilerplate/internal/middleware"
+	"github.com/beercut-team/backend-boilerplate/internal/repository"
+	"github.com/beercut-team/backend-boilerplate/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+type PostOpHandler struct {
+	svc    service.PostOpService
+	policy service.AccessPolicy
+}
+
+func NewPostOpHandler(svc service.PostOpService, policy service.AccessPolicy) *PostOpHandler {
+	return &PostOpHandler{svc: svc, policy: policy}
+}
+
+// Create записывает послеоперационный осмотр
+// POST /api/v1/surgeries/:id/postop-visits
+func (h *PostOpHandler) Create(c *gin.Context) {
+	surgeryID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		BadRequest(c, "неверный id")
+		return
+	}
+	if !canAccessSurgery(c, h.policy, uint(surgeryID), domain.PatientActionWrite) {
+		return
+	}
+
+	var req domain.CreatePostOpVisitRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, err.Error())
+		return
+	}
+
+	visit, err := h.svc.Create(c.Request.Context(), uint(surgeryID), req, middleware.GetUserID(c))
+	if err != nil {
+		Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	Success(c, http.StatusCreated, visit)
+}
+
+// List — осмотры после операции
+// GET /api/v1/surgeries/:id/postop-visits
+func (h *PostOpHandler) List(c *gin.Context) {
+	surgeryID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		BadRequest(c, "неверный id")
+		return
+	}
+	if !canAccessSurgery(c, h.policy, uint(surgeryID), domain.PatientActionRead) {
+		return
+	}
+
+	visits, err := h.svc.ListBySurgery(c.Request.Context(), uint(surgeryID))
+	if err != nil {
+		InternalError(c, "не удалось получить осмотры")
+		return
+	}
+
+	Success(c, http.StatusOK, visits)
+}
+
+func (h *PostOpHandler) Update(c *gin.Context) {
+	id, ok := h.writableVisitID(c)
+	if !ok {
+		return
+	}
+
+	var req domain.UpdatePostOpVisitRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, err.Error())
+		return
+	}
+
+	updated, err := h.svc.Update(c.Request.Context(), id, req)
+	if err != nil {
+		Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	Success(c, http.StatusOK, updated)
+}
+
+func (h *PostOpHandler) Delete(c *gin.Context) {
+	id, ok := h.writableVisitID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.svc.Delete(c.Request.Context(), id); err != nil {
+		Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	Success(c, http.StatusOK, gin.H{"message": "осмотр удалён"})
+}
+
+// Stats — ошибка прогноза рефракции по хирургам и линзам; хирург видит только свои исходы
+// GET /api/v1/iol/outcomes?surgeon_id=&lens_id=&formula=&from=&to=
+func (h *PostOpHandler) Stats(c *gin.Context) {
+	var filters repository.OutcomeFilters
+	var ok bool
+	if filters.SurgeonID, ok = h.surgeonScope(c); !ok {
+		return
+	}
+	if filters.LensID, ok = optionalID(c, "lens_id"); !ok {
+		return
+	}
+	filters.Formula = c.Query("formula")
+	if filters.From, ok = optionalDate(c, "from"); !ok {
+		return
+	}
+	if filters.To, ok = optionalDate(c, "to"); !ok {
+		return
+	}
+
+	stats, err := h.svc.Stats(c.Request.Context(), filters)
+	if err != nil {
+		InternalError(c, "не удалось посчитать статистику исходов")
+		return
+	}
+
+	Success(c, http.StatusOK, stats)
+}
+
+// OptimizeAConstant — персональная A-константа хирурга по исходам
+// GET /api/v1/iol/outcomes/a-constant?surgeon_id=&lens_id=
+func (h *PostOpHandler) OptimizeAConstant(c *gin.Context) {
+	surgeonID, ok := h.surgeonScope(c)
+	if !ok {
+		return
+	}
+	if surgeonID == nil {
+		BadRequest(c, "укажите surgeon_id")
+		return
+	}
+	lensID, ok := optionalID(c, "lens_id")
+	if !ok {
+		return
+	}
+
+	result, err := h.svc.OptimizeAConstant(c.Request.Context(), *surgeonID, lensID)
+	if err != nil {
+		Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	Success(c, http.StatusOK, result)
+}
+
+// writableVisitID разбирает id осмотра из пути и проверяет право менять операцию
+func (h *PostOpHandler) writableVisitID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		BadRequest(c, "неверный id")
+		return 0, false
+	}
+	if !canAccessPostOpVisit(c, h.policy, uint(id), domain.PatientActionWrite) {
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// surgeonScope — хирург из запроса; хирург, кроме администратора, видит только себя
+func (h *PostOpHandler) surgeonScope(c *gin.Context) (*uint, bool) {
+	if middleware.GetUserRole(c) != domain.RoleAdmin {
+		userID := middleware.GetUserID(c)
+		return &userID, true
+	}
+	return optionalID(c, "surgeon_id")
+}
+
+func optionalID(c *gin.Context, name string) (*uint, bool) {
+	v := c.Query(name)
+	if v == "" {
+		return nil, true
+	}
+	id, err := strconv.ParseUint(v, 10, 32)
+	if err != nil {
+		BadRequest(c, "неверный "+name)
+		return nil, false
+	}
+	result := uint(id)
+	return &result, true
+}
+
+func optionalDate(c *gin.Context, name string) (*time.Time, bool) {
+	v := c.Query(name)
+	if v == "" {
+		return nil, true
+	}
+	t, err := time.Parse("2006-01-02", v)
+	if err != nil {
+		BadRequest(c, "неверный формат "+name+", используйте ГГГГ-ММ-ДД")
+		return nil, false
+	}
+	return &t, true
+}
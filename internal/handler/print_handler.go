@@ -5,16 +5,18 @@ import (
 	"net/http"
 	"strconv"
 
+	"github.com/beercut-team/backend-boilerplate/internal/domain"
 	"github.com/beercut-team/backend-boilerplate/internal/service"
 	"github.com/gin-gonic/gin"
 )
 
 type PrintHandler struct {
 	pdfSvc service.PDFService
+	policy service.AccessPolicy
 }
 
-func NewPrintHandler(pdfSvc service.PDFService) *PrintHandler {
-	return &PrintHandler{pdfSvc: pdfSvc}
+func NewPrintHandler(pdfSvc service.PDFService, policy service.AccessPolicy) *PrintHandler {
+	return &PrintHandler{pdfSvc: pdfSvc, policy: policy}
 }
 
 func (h *PrintHandler) RoutingSheet(c *gin.Context) {
@@ -23,6 +25,9 @@ func (h *PrintHandler) RoutingSheet(c *gin.Context) {
 		BadRequest(c, "неверный patient_id")
 		return
 	}
+	if !canAccessPatient(c, h.policy, uint(patientID), domain.PatientActionRead) {
+		return
+	}
 
 	buf, err := h.pdfSvc.GenerateRoutingSheet(c.Request.Context(), uint(patientID))
 	if err != nil {
@@ -41,6 +46,9 @@ func (h *PrintHandler) ChecklistReport(c *gin.Context) {
 		BadRequest(c, "неверный patient_id")
 		return
 	}
+	if !canAccessPatient(c, h.policy, uint(patientID), domain.PatientActionRead) {
+		return
+	}
 
 	buf, err := h.pdfSvc.GenerateChecklistReport(c.Request.Context(), uint(patientID))
 	if err != nil {
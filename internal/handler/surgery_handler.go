@@ -11,11 +11,12 @@ import (
 )
 
 type SurgeryHandler struct {
-	svc service.SurgeryService
+	svc    service.SurgeryService
+	policy service.AccessPolicy
 }
 
-func NewSurgeryHandler(svc service.SurgeryService) *SurgeryHandler {
-	return &SurgeryHandler{svc: svc}
+func NewSurgeryHandler(svc service.SurgeryService, policy service.AccessPolicy) *SurgeryHandler {
+	return &SurgeryHandler{svc: svc, policy: policy}
 }
 
 func (h *SurgeryHandler) Schedule(c *gin.Context) {
@@ -24,9 +25,14 @@ func (h *SurgeryHandler) Schedule(c *gin.Context) {
 		BadRequest(c, err.Error())
 		return
 	}
+	// Назначение операции закрепляет пациента за хирургом
+	if !canAccessPatient(c, h.policy, req.PatientID, domain.PatientActionReview) {
+		return
+	}
 
-	surgeonID := middleware.GetUserID(c)
-	surgery, err := h.svc.Schedule(c.Request.Context(), req, surgeonID)
+	userID := middleware.GetUserID(c)
+	role := middleware.GetUserRole(c)
+	surgery, err := h.svc.Schedule(c.Request.Context(), req, userID, role)
 	if err != nil {
 		Error(c, http.StatusBadRequest, err.Error())
 		return
@@ -41,6 +47,9 @@ func (h *SurgeryHandler) GetByID(c *gin.Context) {
 		BadRequest(c, "неверный id")
 		return
 	}
+	if !canAccessSurgery(c, h.policy, uint(id), domain.PatientActionRead) {
+		return
+	}
 
 	surgery, err := h.svc.GetByID(c.Request.Context(), uint(id))
 	if err != nil {
@@ -70,6 +79,9 @@ func (h *SurgeryHandler) Update(c *gin.Context) {
 		BadRequest(c, "неверный id")
 		return
 	}
+	if !canAccessSurgery(c, h.policy, uint(id), domain.PatientActionWrite) {
+		return
+	}
 
 	var req domain.UpdateSurgeryRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -92,6 +104,9 @@ func (h *SurgeryHandler) Delete(c *gin.Context) {
 		BadRequest(c, "неверный id")
 		return
 	}
+	if !canAccessSurgery(c, h.policy, uint(id), domain.PatientActionWrite) {
+		return
+	}
 
 	userID := middleware.GetUserID(c)
 	if err := h.svc.Delete(c.Request.Context(), uint(id), userID); err != nil {
@@ -2,6 +2,7 @@ package handler
 
 import (
 	"net/http"
+	"strconv"
 
 	"github.com/beercut-team/backend-boilerplate/internal/domain"
 	"github.com/beercut-team/backend-boilerplate/internal/middleware"
@@ -10,11 +11,18 @@ import (
 )
 
 type SyncHandler struct {
-	svc service.SyncService
+	svc    service.SyncService
+	policy service.AccessPolicy
 }
 
-func NewSyncHandler(svc service.SyncService) *SyncHandler {
-	return &SyncHandler{svc: svc}
+func NewSyncHandler(svc service.SyncService, policy service.AccessPolicy) *SyncHandler {
+	return &SyncHandler{svc: svc, policy: policy}
+}
+
+// actor загружает пользователя для проверок доступа в Push и Pull
+func (h *SyncHandler) actor(c *gin.Context) (domain.Actor, bool) {
+	actor, err := h.policy.Actor(c.Request.Context(), middleware.GetUserID(c), middleware.GetUserRole(c))
+	return actor, requireAccess(c, err)
 }
 
 func (h *SyncHandler) Push(c *gin.Context) {
@@ -24,24 +32,36 @@ func (h *SyncHandler) Push(c *gin.Context) {
 		return
 	}
 
-	userID := middleware.GetUserID(c)
-	if err := h.svc.Push(c.Request.Context(), userID, req); err != nil {
+	actor, ok := h.actor(c)
+	if !ok {
+		return
+	}
+	resp, err := h.svc.Push(c.Request.Context(), actor, req)
+	if err != nil {
 		Error(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	Success(c, http.StatusOK, domain.MessageResponse{Message: "синхронизация завершена"})
+	Success(c, http.StatusOK, resp)
 }
 
 func (h *SyncHandler) Pull(c *gin.Context) {
-	since := c.Query("since")
-	if since == "" {
-		BadRequest(c, "параметр since обязателен (ISO 8601)")
-		return
+	var cursor int64
+	if v := c.Query("cursor"); v != "" {
+		parsed, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || parsed < 0 {
+			BadRequest(c, "неверный cursor")
+			return
+		}
+		cursor = parsed
 	}
+	limit, _ := strconv.Atoi(c.Query("limit"))
 
-	userID := middleware.GetUserID(c)
-	resp, err := h.svc.Pull(c.Request.Context(), userID, since)
+	actor, ok := h.actor(c)
+	if !ok {
+		return
+	}
+	resp, err := h.svc.Pull(c.Request.Context(), actor, cursor, limit)
 	if err != nil {
 		Error(c, http.StatusBadRequest, err.Error())
 		return
@@ -2,6 +2,7 @@ package middleware
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -11,16 +12,10 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-// AuditMiddleware логирует все мутации (POST, PUT, PATCH, DELETE)
+// AuditMiddleware передаёт сервисам автора запроса для аудита изменений с diff.
+// Мутации, которые сервисы не записали сами, логируются по телу запроса.
 func AuditMiddleware(auditService service.AuditService) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// Логируем только мутации
-		method := c.Request.Method
-		if method != "POST" && method != "PUT" && method != "PATCH" && method != "DELETE" {
-			c.Next()
-			return
-		}
-
 		// Пропускаем auth endpoints
 		if strings.HasPrefix(c.Request.URL.Path, "/api/v1/auth") ||
 			strings.HasPrefix(c.Request.URL.Path, "/api/public") {
@@ -35,6 +30,16 @@ func AuditMiddleware(auditService service.AuditService) gin.HandlerFunc {
 			return
 		}
 
+		ip := c.ClientIP()
+		c.Request = c.Request.WithContext(service.WithAuditActor(c.Request.Context(), userID, GetUserRole(c), ip))
+
+		// Логируем только мутации
+		method := c.Request.Method
+		if method != "POST" && method != "PUT" && method != "PATCH" && method != "DELETE" {
+			c.Next()
+			return
+		}
+
 		// Читаем body для логирования
 		var bodyBytes []byte
 		if c.Request.Body != nil {
@@ -52,14 +57,12 @@ func AuditMiddleware(auditService service.AuditService) gin.HandlerFunc {
 		entity := extractEntity(path)
 		action := mapMethodToAction(method)
 
-		// Получаем IP
-		ip := c.ClientIP()
-
 		// Выполняем запрос
 		c.Next()
 
-		// Логируем только успешные операции (2xx)
-		if c.Writer.Status() >= 200 && c.Writer.Status() < 300 {
+		// Логируем только успешные операции (2xx), не записанные сервисами
+		ctx := c.Request.Context()
+		if c.Writer.Status() >= 200 && c.Writer.Status() < 300 && !service.AuditRecorded(ctx) {
 			// Пытаемся извлечь entityID из параметров
 			entityID := uint(0)
 			if idParam := c.Param("id"); idParam != "" {
@@ -70,15 +73,15 @@ func AuditMiddleware(auditService service.AuditService) gin.HandlerFunc {
 				}
 			}
 
-			// Асинхронно логируем (не блокируем ответ)
+			// Асинхронно логируем (не блокируем ответ); контекст запроса к этому моменту отменён
 			go func() {
 				auditService.LogAction(
-					c.Request.Context(),
+					context.WithoutCancel(ctx),
 					userID,
 					action,
 					entity,
 					entityID,
-					nil, // oldValue - можно расширить позже
+					nil,
 					requestData,
 					ip,
 				)
@@ -9,9 +9,13 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-const userIDKey = "user_id"
+const (
+	userIDKey    = "user_id"
+	sessionIDKey = "session_id"
+	claimsKey    = "access_claims"
+)
 
-func Auth(tokenService service.TokenService) gin.HandlerFunc {
+func Auth(tokenService service.TokenService, revocation service.TokenRevocation) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var token string
 
@@ -35,14 +39,20 @@ func Auth(tokenService service.TokenService) gin.HandlerFunc {
 			return
 		}
 
-		userID, role, err := tokenService.ValidateAccessToken(token)
+		claims, err := tokenService.ValidateAccessToken(token)
 		if err != nil {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, domain.ErrorResponse{Error: "недействительный или просроченный токен"})
 			return
 		}
+		if err := revocation.Check(c.Request.Context(), claims); err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, domain.ErrorResponse{Error: err.Error()})
+			return
+		}
 
-		c.Set(userIDKey, userID)
-		SetUserRole(c, role)
+		c.Set(claimsKey, claims)
+		c.Set(userIDKey, claims.UserID)
+		c.Set(sessionIDKey, claims.SessionID)
+		SetUserRole(c, claims.Role)
 		c.Next()
 	}
 }
@@ -52,3 +62,17 @@ func GetUserID(c *gin.Context) uint {
 	userID, _ := id.(uint)
 	return userID
 }
+
+// GetSessionID — сессия, в которой выдан токен запроса; 0 для токенов без сессии
+func GetSessionID(c *gin.Context) uint {
+	id, _ := c.Get(sessionIDKey)
+	sessionID, _ := id.(uint)
+	return sessionID
+}
+
+// GetAccessClaims — данные токена запроса; nil вне защищённых маршрутов
+func GetAccessClaims(c *gin.Context) *service.AccessClaims {
+	v, _ := c.Get(claimsKey)
+	claims, _ := v.(*service.AccessClaims)
+	return claims
+}
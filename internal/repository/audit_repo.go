@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/beercut-team/backend-boilerplate/internal/domain"
 	"gorm.io/gorm"
@@ -10,6 +11,19 @@ import (
 type AuditRepository interface {
 	Create(ctx context.Context, log *domain.AuditLog) error
 	FindByEntity(ctx context.Context, entity string, entityID uint) ([]domain.AuditLog, error)
+	// FindAll — записи журнала по фильтрам, новые первыми
+	FindAll(ctx context.Context, filters AuditFilters, offset, limit int) ([]domain.AuditLog, int64, error)
+	// FindInBatches обходит все записи по фильтрам пачками по batchSize, новые первыми
+	FindInBatches(ctx context.Context, filters AuditFilters, batchSize int, fn func([]domain.AuditLog) error) error
+}
+
+type AuditFilters struct {
+	UserID   *uint
+	Entity   string
+	EntityID *uint
+	Action   string
+	From     *time.Time
+	To       *time.Time // не включительно
 }
 
 type auditRepository struct {
@@ -30,3 +44,63 @@ func (r *auditRepository) FindByEntity(ctx context.Context, entity string, entit
 		Order("created_at DESC").Find(&logs).Error
 	return logs, err
 }
+
+func (r *auditRepository) FindAll(ctx context.Context, filters AuditFilters, offset, limit int) ([]domain.AuditLog, int64, error) {
+	var logs []domain.AuditLog
+	var total int64
+
+	query := r.filtered(ctx, filters)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err := query.Order("id DESC").Offset(offset).Limit(limit).Find(&logs).Error
+	return logs, total, err
+}
+
+func (r *auditRepository) FindInBatches(ctx context.Context, filters AuditFilters, batchSize int, fn func([]domain.AuditLog) error) error {
+	// Курсор по id: журнал пополняется во время выгрузки, смещение бы съезжало
+	var lastID uint
+	for {
+		var logs []domain.AuditLog
+		query := r.filtered(ctx, filters)
+		if lastID > 0 {
+			query = query.Where("id < ?", lastID)
+		}
+		if err := query.Order("id DESC").Limit(batchSize).Find(&logs).Error; err != nil {
+			return err
+		}
+		if len(logs) == 0 {
+			return nil
+		}
+		if err := fn(logs); err != nil {
+			return err
+		}
+		if len(logs) < batchSize {
+			return nil
+		}
+		lastID = logs[len(logs)-1].ID
+	}
+}
+
+func (r *auditRepository) filtered(ctx context.Context, f AuditFilters) *gorm.DB {
+	query := r.db.WithContext(ctx).Model(&domain.AuditLog{})
+	if f.UserID != nil {
+		query = query.Where("user_id = ?", *f.UserID)
+	}
+	if f.Entity != "" {
+		query = query.Where("entity = ?", f.Entity)
+	}
+	if f.EntityID != nil {
+		query = query.Where("entity_id = ?", *f.EntityID)
+	}
+	if f.Action != "" {
+		query = query.Where("action = ?", f.Action)
+	}
+	if f.From != nil {
+		query = query.Where("created_at >= ?", *f.From)
+	}
+	if f.To != nil {
+		query = query.Where("created_at < ?", *f.To)
+	}
+	return query
+}
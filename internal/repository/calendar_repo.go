@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/beercut-team/backend-boilerplate/internal/domain"
+	"gorm.io/gorm"
+)
+
+type CalendarRepository interface {
+	CreateRoom(ctx context.Context, room *domain.OperatingRoom) error
+	UpdateRoom(ctx context.Context, room *domain.OperatingRoom) error
+	FindRoomByID(ctx context.Context, id uint) (*domain.OperatingRoom, error)
+	FindRooms(ctx context.Context, activeOnly bool) ([]domain.OperatingRoom, error)
+
+	FindWorkingHours(ctx context.Context, surgeonID uint) ([]domain.SurgeonWorkingHours, error)
+	ReplaceWorkingHours(ctx context.Context, surgeonID uint, hours []domain.SurgeonWorkingHours) error
+
+	CreateTimeOff(ctx context.Context, t *domain.SurgeonTimeOff) error
+	FindTimeOffByID(ctx context.Context, id uint) (*domain.SurgeonTimeOff, error)
+	FindTimeOff(ctx context.Context, surgeonID uint, from, to time.Time) ([]domain.SurgeonTimeOff, error)
+	DeleteTimeOff(ctx context.Context, id uint) error
+
+	FindSurgeonSurgeries(ctx context.Context, surgeonID uint, from, to time.Time) ([]domain.Surgery, error)
+	FindRoomSurgeries(ctx context.Context, roomID uint, from, to time.Time) ([]domain.Surgery, error)
+	// LockBookings блокирует запись на операции до конца транзакции
+	LockBookings(ctx context.Context) error
+}
+
+type calendarRepository struct {
+	db *gorm.DB
+}
+
+func NewCalendarRepository(db *gorm.DB) CalendarRepository {
+	return &calendarRepository{db: db}
+}
+
+// Ключ advisory-блокировки записи на операции
+const surgeryBookingLockKey = 7701
+
+func (r *calendarRepository) CreateRoom(ctx context.Context, room *domain.OperatingRoom) error {
+	return r.db.WithContext(ctx).Create(room).Error
+}
+
+func (r *calendarRepository) UpdateRoom(ctx context.Context, room *domain.OperatingRoom) error {
+	return r.db.WithContext(ctx).Save(room).Error
+}
+
+func (r *calendarRepository) FindRoomByID(ctx context.Context, id uint) (*domain.OperatingRoom, error) {
+	var room domain.OperatingRoom
+	if err := r.db.WithContext(ctx).First(&room, id).Error; err != nil {
+		return nil, err
+	}
+	return &room, nil
+}
+
+func (r *calendarRepository) FindRooms(ctx context.Context, activeOnly bool) ([]domain.OperatingRoom, error) {
+	var rooms []domain.OperatingRoom
+	query := r.db.WithContext(ctx)
+	if activeOnly {
+		query = query.Where("is_active = ?", true)
+	}
+	err := query.Order("name ASC").Find(&rooms).Error
+	return rooms, err
+}
+
+func (r *calendarRepository) FindWorkingHours(ctx context.Context, surgeonID uint) ([]domain.SurgeonWorkingHours, error) {
+	var hours []domain.SurgeonWorkingHours
+	err := r.db.WithContext(ctx).Where("surgeon_id = ?", surgeonID).Order("weekday ASC").Find(&hours).Error
+	return hours, err
+}
+
+func (r *calendarRepository) ReplaceWorkingHours(ctx context.Context, surgeonID uint, hours []domain.SurgeonWorkingHours) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("surgeon_id = ?", surgeonID).Delete(&domain.SurgeonWorkingHours{}).Error; err != nil {
+			return err
+		}
+		if len(hours) == 0 {
+			return nil
+		}
+		return tx.Create(&hours).Error
+	})
+}
+
+func (r *calendarRepository) CreateTimeOff(ctx context.Context, t *domain.SurgeonTimeOff) error {
+	return r.db.WithContext(ctx).Create(t).Error
+}
+
+func (r *calendarRepository) FindTimeOffByID(ctx context.Context, id uint) (*domain.SurgeonTimeOff, error) {
+	var t domain.SurgeonTimeOff
+	if err := r.db.WithContext(ctx).First(&t, id).Error; err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (r *calendarRepository) FindTimeOff(ctx context.Context, surgeonID uint, from, to time.Time) ([]domain.SurgeonTimeOff, error) {
+	var items []domain.SurgeonTimeOff
+	err := r.db.WithContext(ctx).
+		Where("surgeon_id = ? AND date_to >= ? AND date_from <= ?", surgeonID, from.Format("2006-01-02"), to.Format("2006-01-02")).
+		Order("date_from ASC").Find(&items).Error
+	return items, err
+}
+
+func (r *calendarRepository) DeleteTimeOff(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Delete(&domain.SurgeonTimeOff{}, id).Error
+}
+
+func (r *calendarRepository) FindSurgeonSurgeries(ctx context.Context, surgeonID uint, from, to time.Time) ([]domain.Surgery, error) {
+	var surgeries []domain.Surgery
+	err := r.db.WithContext(ctx).
+		Where("surgeon_id = ? AND scheduled_date >= ? AND scheduled_date < ? AND status <> ?", surgeonID, from, to, domain.SurgeryStatusCancelled).
+		Order("scheduled_date ASC").Find(&surgeries).Error
+	return surgeries, err
+}
+
+func (r *calendarRepository) FindRoomSurgeries(ctx context.Context, roomID uint, from, to time.Time) ([]domain.Surgery, error) {
+	var surgeries []domain.Surgery
+	err := r.db.WithContext(ctx).
+		Where("room_id = ? AND scheduled_date >= ? AND scheduled_date < ? AND status <> ?", roomID, from, to, domain.SurgeryStatusCancelled).
+		Order("scheduled_date ASC").Find(&surgeries).Error
+	return surgeries, err
+}
+
+func (r *calendarRepository) LockBookings(ctx context.Context) error {
+	return r.db.WithContext(ctx).Exec("SELECT pg_advisory_xact_lock(?)", surgeryBookingLockKey).Error
+}
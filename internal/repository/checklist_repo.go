@@ -8,8 +8,6 @@ import (
 )
 
 type ChecklistRepository interface {
-	CreateTemplate(ctx context.Context, t *domain.ChecklistTemplate) error
-	FindTemplatesByOperation(ctx context.Context, opType domain.OperationType) ([]domain.ChecklistTemplate, error)
 	CreateItem(ctx context.Context, item *domain.ChecklistItem) error
 	CreateItems(ctx context.Context, items []domain.ChecklistItem) error
 	FindItemByID(ctx context.Context, id uint) (*domain.ChecklistItem, error)
@@ -28,16 +26,6 @@ func NewChecklistRepository(db *gorm.DB) ChecklistRepository {
 	return &checklistRepository{db: db}
 }
 
-func (r *checklistRepository) CreateTemplate(ctx context.Context, t *domain.ChecklistTemplate) error {
-	return r.db.WithContext(ctx).Create(t).Error
-}
-
-func (r *checklistRepository) FindTemplatesByOperation(ctx context.Context, opType domain.OperationType) ([]domain.ChecklistTemplate, error) {
-	var templates []domain.ChecklistTemplate
-	err := r.db.WithContext(ctx).Where("operation_type = ?", opType).Order("sort_order ASC").Find(&templates).Error
-	return templates, err
-}
-
 func (r *checklistRepository) CreateItem(ctx context.Context, item *domain.ChecklistItem) error {
 	return r.db.WithContext(ctx).Create(item).Error
 }
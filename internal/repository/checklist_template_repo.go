@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/beercut-team/backend-boilerplate/internal/domain"
+	"gorm.io/gorm"
+)
+
+type ChecklistTemplateRepository interface {
+	Create(ctx context.Context, t *domain.ChecklistTemplate) error
+	// Update сохраняет шаблон и заменяет его пункты на t.Items
+	Update(ctx context.Context, t *domain.ChecklistTemplate) error
+	Delete(ctx context.Context, id uint) error
+	FindByID(ctx context.Context, id uint) (*domain.ChecklistTemplate, error)
+	FindAll(ctx context.Context, opType *domain.OperationType) ([]domain.ChecklistTemplate, error)
+	FindActive(ctx context.Context, opType domain.OperationType) (*domain.ChecklistTemplate, error)
+	NextVersion(ctx context.Context, opType domain.OperationType) (int, error)
+	// Activate делает версию активной и снимает активность с остальных версий типа операции
+	Activate(ctx context.Context, t *domain.ChecklistTemplate) error
+	IsInUse(ctx context.Context, id uint) (bool, error)
+}
+
+type checklistTemplateRepository struct {
+	db *gorm.DB
+}
+
+func NewChecklistTemplateRepository(db *gorm.DB) ChecklistTemplateRepository {
+	return &checklistTemplateRepository{db: db}
+}
+
+func (r *checklistTemplateRepository) Create(ctx context.Context, t *domain.ChecklistTemplate) error {
+	return r.db.WithContext(ctx).Create(t).Error
+}
+
+func (r *checklistTemplateRepository) Update(ctx context.Context, t *domain.ChecklistTemplate) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Items").Save(t).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("template_id = ?", t.ID).Delete(&domain.ChecklistTemplateItem{}).Error; err != nil {
+			return err
+		}
+		for i := range t.Items {
+			t.Items[i].ID = 0
+			t.Items[i].TemplateID = t.ID
+		}
+		if len(t.Items) == 0 {
+			return nil
+		}
+		return tx.Create(&t.Items).Error
+	})
+}
+
+func (r *checklistTemplateRepository) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("template_id = ?", id).Delete(&domain.ChecklistTemplateItem{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&domain.ChecklistTemplate{}, id).Error
+	})
+}
+
+func (r *checklistTemplateRepository) FindByID(ctx context.Context, id uint) (*domain.ChecklistTemplate, error) {
+	var t domain.ChecklistTemplate
+	if err := r.db.WithContext(ctx).Preload("Items", itemsOrder).First(&t, id).Error; err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (r *checklistTemplateRepository) FindAll(ctx context.Context, opType *domain.OperationType) ([]domain.ChecklistTemplate, error) {
+	var templates []domain.ChecklistTemplate
+	query := r.db.WithContext(ctx).Preload("Items", itemsOrder)
+	if opType != nil {
+		query = query.Where("operation_type = ?", *opType)
+	}
+	err := query.Order("operation_type ASC, version DESC").Find(&templates).Error
+	return templates, err
+}
+
+func (r *checklistTemplateRepository) FindActive(ctx context.Context, opType domain.OperationType) (*domain.ChecklistTemplate, error) {
+	var t domain.ChecklistTemplate
+	if err := r.db.WithContext(ctx).Preload("Items", itemsOrder).
+		Where("operation_type = ? AND is_active = ?", opType, true).
+		Order("version DESC").First(&t).Error; err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (r *checklistTemplateRepository) NextVersion(ctx context.Context, opType domain.OperationType) (int, error) {
+	var version int
+	err := r.db.WithContext(ctx).Model(&domain.ChecklistTemplate{}).
+		Where("operation_type = ?", opType).
+		Select("COALESCE(MAX(version), 0) + 1").Scan(&version).Error
+	return version, err
+}
+
+func (r *checklistTemplateRepository) Activate(ctx context.Context, t *domain.ChecklistTemplate) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&domain.ChecklistTemplate{}).
+			Where("operation_type = ? AND id <> ?", t.OperationType, t.ID).
+			Update("is_active", false).Error; err != nil {
+			return err
+		}
+		t.IsActive = true
+		return tx.Model(t).Update("is_active", true).Error
+	})
+}
+
+func (r *checklistTemplateRepository) IsInUse(ctx context.Context, id uint) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&domain.ChecklistItem{}).Where("template_id = ?", id).Count(&count).Error
+	return count > 0, err
+}
+
+func itemsOrder(db *gorm.DB) *gorm.DB {
+	return db.Order("sort_order ASC, id ASC")
+}
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/beercut-team/backend-boilerplate/internal/domain"
+	"gorm.io/gorm"
+)
+
+type ConsentRepository interface {
+	// Capture сохраняет согласие и возвращает действующее. Согласие того же типа
+	// в той же редакции уже действует — возвращается оно (created = false);
+	// действующее в другой редакции отзывается как заменённое.
+	Capture(ctx context.Context, consent *domain.Consent) (current *domain.Consent, created bool, err error)
+	Update(ctx context.Context, consent *domain.Consent) error
+	FindByID(ctx context.Context, id uint) (*domain.Consent, error)
+	// FindByPatient — вся история согласий, новые первыми
+	FindByPatient(ctx context.Context, patientID uint) ([]domain.Consent, error)
+	FindActive(ctx context.Context, patientID uint) ([]domain.Consent, error)
+}
+
+type consentRepository struct {
+	db *gorm.DB
+}
+
+func NewConsentRepository(db *gorm.DB) ConsentRepository {
+	return &consentRepository{db: db}
+}
+
+func (r *consentRepository) Capture(ctx context.Context, consent *domain.Consent) (*domain.Consent, bool, error) {
+	current, created := consent, true
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var active domain.Consent
+		err := tx.Where("patient_id = ? AND type = ? AND revoked_at IS NULL", consent.PatientID, consent.Type).
+			Order("signed_at DESC").First(&active).Error
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+		case err != nil:
+			return err
+		case active.TextVersion == consent.TextVersion:
+			current, created = &active, false
+			return nil
+		default:
+			if err := active.Revoke(consent.Channel, consent.RecordedBy, "заменено согласием в редакции "+consent.TextVersion, time.Now()); err != nil {
+				return err
+			}
+			if err := tx.Save(&active).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Create(consent).Error
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	return current, created, nil
+}
+
+func (r *consentRepository) Update(ctx context.Context, consent *domain.Consent) error {
+	return r.db.WithContext(ctx).Save(consent).Error
+}
+
+func (r *consentRepository) FindByID(ctx context.Context, id uint) (*domain.Consent, error) {
+	var consent domain.Consent
+	if err := r.db.WithContext(ctx).First(&consent, id).Error; err != nil {
+		return nil, err
+	}
+	return &consent, nil
+}
+
+func (r *consentRepository) FindByPatient(ctx context.Context, patientID uint) ([]domain.Consent, error) {
+	var consents []domain.Consent
+	err := r.db.WithContext(ctx).Where("patient_id = ?", patientID).Order("signed_at DESC, id DESC").Find(&consents).Error
+	return consents, err
+}
+
+func (r *consentRepository) FindActive(ctx context.Context, patientID uint) ([]domain.Consent, error) {
+	var consents []domain.Consent
+	err := r.db.WithContext(ctx).Where("patient_id = ? AND revoked_at IS NULL", patientID).Find(&consents).Error
+	return consents, err
+}
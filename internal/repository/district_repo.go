@@ -10,6 +10,7 @@ import (
 type DistrictRepository interface {
 	Create(ctx context.Context, district *domain.District) error
 	FindByID(ctx context.Context, id uint) (*domain.District, error)
+	FindByCode(ctx context.Context, code string) (*domain.District, error)
 	FindAll(ctx context.Context, search string, offset, limit int) ([]domain.District, int64, error)
 	Update(ctx context.Context, district *domain.District) error
 	Delete(ctx context.Context, id uint) error
@@ -35,6 +36,14 @@ func (r *districtRepository) FindByID(ctx context.Context, id uint) (*domain.Dis
 	return &district, nil
 }
 
+func (r *districtRepository) FindByCode(ctx context.Context, code string) (*domain.District, error) {
+	var district domain.District
+	if err := r.db.WithContext(ctx).Where("code = ?", code).First(&district).Error; err != nil {
+		return nil, err
+	}
+	return &district, nil
+}
+
 func (r *districtRepository) FindAll(ctx context.Context, search string, offset, limit int) ([]domain.District, int64, error) {
 	var districts []domain.District
 	var total int64
@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/beercut-team/backend-boilerplate/internal/domain"
+	"gorm.io/gorm"
+)
+
+type EyeExamRepository interface {
+	Create(ctx context.Context, exam *domain.EyeExam) error
+	Update(ctx context.Context, exam *domain.EyeExam) error
+	Delete(ctx context.Context, id uint) error
+	FindByID(ctx context.Context, id uint) (*domain.EyeExam, error)
+	// FindByPatient — осмотры от последнего к первому; eye ограничивает глазом
+	FindByPatient(ctx context.Context, patientID uint, eye string) ([]domain.EyeExam, error)
+}
+
+type eyeExamRepository struct {
+	db *gorm.DB
+}
+
+func NewEyeExamRepository(db *gorm.DB) EyeExamRepository {
+	return &eyeExamRepository{db: db}
+}
+
+func (r *eyeExamRepository) Create(ctx context.Context, exam *domain.EyeExam) error {
+	return r.db.WithContext(ctx).Create(exam).Error
+}
+
+func (r *eyeExamRepository) Update(ctx context.Context, exam *domain.EyeExam) error {
+	return r.db.WithContext(ctx).Save(exam).Error
+}
+
+func (r *eyeExamRepository) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Delete(&domain.EyeExam{}, id).Error
+}
+
+func (r *eyeExamRepository) FindByID(ctx context.Context, id uint) (*domain.EyeExam, error) {
+	var exam domain.EyeExam
+	if err := r.db.WithContext(ctx).First(&exam, id).Error; err != nil {
+		return nil, err
+	}
+	return &exam, nil
+}
+
+func (r *eyeExamRepository) FindByPatient(ctx context.Context, patientID uint, eye string) ([]domain.EyeExam, error) {
+	var exams []domain.EyeExam
+	query := r.db.WithContext(ctx).Where("patient_id = ?", patientID)
+	if eye != "" {
+		query = query.Where("eye = ?", eye)
+	}
+	err := query.Order("exam_date DESC, eye ASC").Find(&exams).Error
+	return exams, err
+}
@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/beercut-team/backend-boilerplate/internal/domain"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type IntegrationOutboxRepository interface {
+	Create(ctx context.Context, job *domain.IntegrationOutbox) error
+	Update(ctx context.Context, job *domain.IntegrationOutbox) error
+	ClaimDue(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]domain.IntegrationOutbox, error)
+	FindByPatient(ctx context.Context, patientID uint, system string) ([]domain.IntegrationOutbox, error)
+	FindActive(ctx context.Context, patientID uint, system, operation string) (*domain.IntegrationOutbox, error)
+}
+
+type integrationOutboxRepository struct {
+	db *gorm.DB
+}
+
+func NewIntegrationOutboxRepository(db *gorm.DB) IntegrationOutboxRepository {
+	return &integrationOutboxRepository{db: db}
+}
+
+func (r *integrationOutboxRepository) Create(ctx context.Context, job *domain.IntegrationOutbox) error {
+	return r.db.WithContext(ctx).Create(job).Error
+}
+
+func (r *integrationOutboxRepository) Update(ctx context.Context, job *domain.IntegrationOutbox) error {
+	return r.db.WithContext(ctx).Save(job).Error
+}
+
+// ClaimDue забирает готовые к отправке сообщения и продлевает их аренду на lease,
+// чтобы параллельные воркеры не взяли их повторно. Сообщения, зависшие в processing
+// после падения воркера, снова становятся доступны по истечении аренды.
+func (r *integrationOutboxRepository) ClaimDue(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]domain.IntegrationOutbox, error) {
+	var jobs []domain.IntegrationOutbox
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status IN ? AND next_retry_at <= ?", []domain.OutboxStatus{domain.OutboxStatusPending, domain.OutboxStatusProcessing}, now).
+			Order("next_retry_at ASC").Limit(limit).Find(&jobs).Error; err != nil {
+			return err
+		}
+		if len(jobs) == 0 {
+			return nil
+		}
+
+		ids := make([]uint, len(jobs))
+		for i := range jobs {
+			ids[i] = jobs[i].ID
+			jobs[i].Status = domain.OutboxStatusProcessing
+			jobs[i].NextRetryAt = now.Add(lease)
+		}
+		return tx.Model(&domain.IntegrationOutbox{}).Where("id IN ?", ids).
+			Updates(map[string]interface{}{"status": domain.OutboxStatusProcessing, "next_retry_at": now.Add(lease)}).Error
+	})
+	return jobs, err
+}
+
+func (r *integrationOutboxRepository) FindByPatient(ctx context.Context, patientID uint, system string) ([]domain.IntegrationOutbox, error) {
+	var jobs []domain.IntegrationOutbox
+	query := r.db.WithContext(ctx).Where("patient_id = ?", patientID)
+	if system != "" {
+		query = query.Where("system = ?", system)
+	}
+	err := query.Order("created_at DESC").Find(&jobs).Error
+	return jobs, err
+}
+
+func (r *integrationOutboxRepository) FindActive(ctx context.Context, patientID uint, system, operation string) (*domain.IntegrationOutbox, error) {
+	var job domain.IntegrationOutbox
+	if err := r.db.WithContext(ctx).
+		Where("patient_id = ? AND system = ? AND operation = ? AND status IN ?", patientID, system, operation,
+			[]domain.OutboxStatus{domain.OutboxStatusPending, domain.OutboxStatusProcessing}).
+		Order("created_at DESC").First(&job).Error; err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/beercut-team/backend-boilerplate/internal/domain"
+	"gorm.io/gorm"
+)
+
+type InvitationRepository interface {
+	// Create отзывает неотвеченные приглашения на тот же адрес и сохраняет новое
+	Create(ctx context.Context, inv *domain.Invitation) error
+	FindByID(ctx context.Context, id uint) (*domain.Invitation, error)
+	FindByTokenHash(ctx context.Context, hash string) (*domain.Invitation, error)
+	// List — приглашения по статусу (пусто — все), новые первыми
+	List(ctx context.Context, status string, offset, limit int) ([]domain.Invitation, int64, error)
+	// Accept создаёт пользователя и отмечает приглашение принятым в одной
+	// транзакции; false — приглашение уже принято, отозвано или истекло
+	Accept(ctx context.Context, id uint, user *domain.User) (bool, error)
+	// Revoke отзывает неотвеченное приглашение; false — оно уже принято или отозвано
+	Revoke(ctx context.Context, id uint) (bool, error)
+}
+
+type invitationRepository struct {
+	db *gorm.DB
+}
+
+func NewInvitationRepository(db *gorm.DB) InvitationRepository {
+	return &invitationRepository{db: db}
+}
+
+func (r *invitationRepository) Create(ctx context.Context, inv *domain.Invitation) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&domain.Invitation{}).
+			Where("email = ? AND accepted_at IS NULL AND revoked_at IS NULL", inv.Email).
+			Update("revoked_at", time.Now()).Error; err != nil {
+			return err
+		}
+		return tx.Create(inv).Error
+	})
+}
+
+func (r *invitationRepository) FindByID(ctx context.Context, id uint) (*domain.Invitation, error) {
+	var inv domain.Invitation
+	if err := r.db.WithContext(ctx).First(&inv, id).Error; err != nil {
+		return nil, err
+	}
+	return &inv, nil
+}
+
+func (r *invitationRepository) FindByTokenHash(ctx context.Context, hash string) (*domain.Invitation, error) {
+	var inv domain.Invitation
+	if err := r.db.WithContext(ctx).Where("token_hash = ?", hash).First(&inv).Error; err != nil {
+		return nil, err
+	}
+	return &inv, nil
+}
+
+func (r *invitationRepository) List(ctx context.Context, status string, offset, limit int) ([]domain.Invitation, int64, error) {
+	query := r.db.WithContext(ctx).Model(&domain.Invitation{})
+	now := time.Now()
+	switch status {
+	case domain.InvitationPending:
+		query = query.Where("accepted_at IS NULL AND revoked_at IS NULL AND expires_at > ?", now)
+	case domain.InvitationAccepted:
+		query = query.Where("accepted_at IS NOT NULL")
+	case domain.InvitationRevoked:
+		query = query.Where("accepted_at IS NULL AND revoked_at IS NOT NULL")
+	case domain.InvitationExpired:
+		query = query.Where("accepted_at IS NULL AND revoked_at IS NULL AND expires_at <= ?", now)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var invitations []domain.Invitation
+	err := query.Order("id DESC").Offset(offset).Limit(limit).Find(&invitations).Error
+	return invitations, total, err
+}
+
+func (r *invitationRepository) Accept(ctx context.Context, id uint, user *domain.User) (bool, error) {
+	accepted := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		res := tx.Model(&domain.Invitation{}).
+			Where("id = ? AND accepted_at IS NULL AND revoked_at IS NULL AND expires_at > ?", id, now).
+			Update("accepted_at", now)
+		if res.Error != nil || res.RowsAffected == 0 {
+			return res.Error
+		}
+		if err := tx.Create(user).Error; err != nil {
+			return err
+		}
+		accepted = true
+		return tx.Model(&domain.Invitation{}).Where("id = ?", id).Update("user_id", user.ID).Error
+	})
+	return accepted, err
+}
+
+func (r *invitationRepository) Revoke(ctx context.Context, id uint) (bool, error) {
+	res := r.db.WithContext(ctx).Model(&domain.Invitation{}).
+		Where("id = ? AND accepted_at IS NULL AND revoked_at IS NULL", id).
+		Update("revoked_at", time.Now())
+	return res.RowsAffected == 1, res.Error
+}
@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/beercut-team/backend-boilerplate/internal/domain"
+	"gorm.io/gorm"
+)
+
+type IOLLensRepository interface {
+	Create(ctx context.Context, lens *domain.IOLLens) error
+	Update(ctx context.Context, lens *domain.IOLLens) error
+	FindByID(ctx context.Context, id uint) (*domain.IOLLens, error)
+	FindByModel(ctx context.Context, manufacturer, model string) (*domain.IOLLens, error)
+	FindAll(ctx context.Context, activeOnly bool) ([]domain.IOLLens, error)
+}
+
+type iolLensRepository struct {
+	db *gorm.DB
+}
+
+func NewIOLLensRepository(db *gorm.DB) IOLLensRepository {
+	return &iolLensRepository{db: db}
+}
+
+func (r *iolLensRepository) Create(ctx context.Context, lens *domain.IOLLens) error {
+	return r.db.WithContext(ctx).Create(lens).Error
+}
+
+func (r *iolLensRepository) Update(ctx context.Context, lens *domain.IOLLens) error {
+	return r.db.WithContext(ctx).Save(lens).Error
+}
+
+func (r *iolLensRepository) FindByID(ctx context.Context, id uint) (*domain.IOLLens, error) {
+	var lens domain.IOLLens
+	if err := r.db.WithContext(ctx).First(&lens, id).Error; err != nil {
+		return nil, err
+	}
+	return &lens, nil
+}
+
+func (r *iolLensRepository) FindByModel(ctx context.Context, manufacturer, model string) (*domain.IOLLens, error) {
+	var lens domain.IOLLens
+	err := r.db.WithContext(ctx).
+		Where("LOWER(manufacturer) = LOWER(?) AND LOWER(model) = LOWER(?)", manufacturer, model).
+		First(&lens).Error
+	if err != nil {
+		return nil, err
+	}
+	return &lens, nil
+}
+
+func (r *iolLensRepository) FindAll(ctx context.Context, activeOnly bool) ([]domain.IOLLens, error) {
+	var lenses []domain.IOLLens
+	query := r.db.WithContext(ctx)
+	if activeOnly {
+		query = query.Where("is_active = ?", true)
+	}
+	err := query.Order("manufacturer ASC, model ASC").Find(&lenses).Error
+	return lenses, err
+}
@@ -2,17 +2,22 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/beercut-team/backend-boilerplate/internal/domain"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type MediaRepository interface {
 	Create(ctx context.Context, media *domain.Media) error
+	SaveProcessingResult(ctx context.Context, media *domain.Media) (bool, error)
+	SetChecklistItem(ctx context.Context, id, itemID uint) error
 	FindByID(ctx context.Context, id uint) (*domain.Media, error)
 	FindByPatient(ctx context.Context, patientID uint) ([]domain.Media, error)
 	Delete(ctx context.Context, id uint) error
 	FindOrphaned(ctx context.Context) ([]domain.Media, error)
+	ClaimPending(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]domain.Media, error)
 }
 
 type mediaRepository struct {
@@ -27,6 +32,22 @@ func (r *mediaRepository) Create(ctx context.Context, media *domain.Media) error
 	return r.db.WithContext(ctx).Create(media).Error
 }
 
+// SaveProcessingResult сохраняет результат обработки, только если файл ещё числится
+// в обработке. false означает, что запись успели удалить.
+func (r *mediaRepository) SaveProcessingResult(ctx context.Context, media *domain.Media) (bool, error) {
+	res := r.db.WithContext(ctx).Model(&domain.Media{}).
+		Where("id = ? AND processing_status = ?", media.ID, domain.MediaStatusProcessing).
+		Select("content_type", "size", "width", "height", "thumbnail_path",
+			"eye", "checklist_item_id", "dicom", "warnings",
+			"processing_status", "processing_error", "processing_attempts", "next_attempt_at", "processed_at").
+		Updates(media)
+	return res.RowsAffected > 0, res.Error
+}
+
+func (r *mediaRepository) SetChecklistItem(ctx context.Context, id, itemID uint) error {
+	return r.db.WithContext(ctx).Model(&domain.Media{}).Where("id = ?", id).Update("checklist_item_id", itemID).Error
+}
+
 func (r *mediaRepository) FindByID(ctx context.Context, id uint) (*domain.Media, error) {
 	var media domain.Media
 	if err := r.db.WithContext(ctx).First(&media, id).Error; err != nil {
@@ -52,3 +73,31 @@ func (r *mediaRepository) FindOrphaned(ctx context.Context) ([]domain.Media, err
 		Find(&media).Error
 	return media, err
 }
+
+// ClaimPending забирает файлы, ожидающие обработки, и выставляет аренду на lease.
+// Файлы, зависшие в PROCESSING после падения воркера, снова доступны по истечении аренды.
+func (r *mediaRepository) ClaimPending(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]domain.Media, error) {
+	var media []domain.Media
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("processing_status IN ?", []domain.MediaStatus{domain.MediaStatusPending, domain.MediaStatusProcessing}).
+			Where("next_attempt_at IS NULL OR next_attempt_at <= ?", now).
+			Order("created_at ASC").Limit(limit).Find(&media).Error; err != nil {
+			return err
+		}
+		if len(media) == 0 {
+			return nil
+		}
+
+		until := now.Add(lease)
+		ids := make([]uint, len(media))
+		for i := range media {
+			ids[i] = media[i].ID
+			media[i].ProcessingStatus = domain.MediaStatusProcessing
+			media[i].NextAttemptAt = &until
+		}
+		return tx.Model(&domain.Media{}).Where("id IN ?", ids).
+			Updates(map[string]interface{}{"processing_status": domain.MediaStatusProcessing, "next_attempt_at": until}).Error
+	})
+	return media, err
+}
@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/beercut-team/backend-boilerplate/internal/domain"
+	"gorm.io/gorm"
+)
+
+type OperationTypeRepository interface {
+	Create(ctx context.Context, t *domain.OperationTypeModel) error
+	Update(ctx context.Context, t *domain.OperationTypeModel) error
+	FindByID(ctx context.Context, id uint) (*domain.OperationTypeModel, error)
+	FindByCode(ctx context.Context, code domain.OperationType) (*domain.OperationTypeModel, error)
+	FindAll(ctx context.Context, activeOnly bool) ([]domain.OperationTypeModel, error)
+}
+
+type operationTypeRepository struct {
+	db *gorm.DB
+}
+
+func NewOperationTypeRepository(db *gorm.DB) OperationTypeRepository {
+	return &operationTypeRepository{db: db}
+}
+
+func (r *operationTypeRepository) Create(ctx context.Context, t *domain.OperationTypeModel) error {
+	return r.db.WithContext(ctx).Create(t).Error
+}
+
+func (r *operationTypeRepository) Update(ctx context.Context, t *domain.OperationTypeModel) error {
+	return r.db.WithContext(ctx).Save(t).Error
+}
+
+func (r *operationTypeRepository) FindByID(ctx context.Context, id uint) (*domain.OperationTypeModel, error) {
+	var t domain.OperationTypeModel
+	if err := r.db.WithContext(ctx).First(&t, id).Error; err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (r *operationTypeRepository) FindByCode(ctx context.Context, code domain.OperationType) (*domain.OperationTypeModel, error) {
+	var t domain.OperationTypeModel
+	if err := r.db.WithContext(ctx).Where("code = ?", string(code)).First(&t).Error; err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (r *operationTypeRepository) FindAll(ctx context.Context, activeOnly bool) ([]domain.OperationTypeModel, error) {
+	var types []domain.OperationTypeModel
+	query := r.db.WithContext(ctx)
+	if activeOnly {
+		query = query.Where("is_active = ?", true)
+	}
+	err := query.Order("name ASC").Find(&types).Error
+	return types, err
+}
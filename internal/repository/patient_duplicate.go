@@ -0,0 +1,189 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/beercut-team/backend-boilerplate/internal/domain"
+	"github.com/beercut-team/backend-boilerplate/pkg/pii"
+	"gorm.io/gorm"
+)
+
+// Минимальная триграммная близость ФИО, при которой пара с одной датой рождения
+// попадает в кандидаты; окончательно сравнивает domain.DuplicateReasons
+const duplicateNameSimilarity = 0.3
+
+// blindIndex — слепой индекс для условия WHERE. Пустое значение даёт NULL:
+// поиск без номера не должен совпадать с записями без документа.
+func blindIndex(value string) interface{} {
+	if value == "" {
+		return nil
+	}
+	return pii.BlindIndex(value)
+}
+
+// hashSQL — слепой индекс записи; у записи без документа он пустой и превращается в NULL
+func hashSQL(column string) string {
+	return "nullif(" + column + ", '')"
+}
+
+// FindDuplicateCandidates — записи, совпадающие с пациентом по документам или по дате рождения.
+// Отсев по ФИО — в domain.DuplicateReasons.
+func (r *patientRepository) FindDuplicateCandidates(ctx context.Context, p *domain.Patient) ([]domain.Patient, error) {
+	var conds []string
+	var args []interface{}
+
+	if snils := domain.DocumentDigits(p.SNILs); snils != "" {
+		conds = append(conds, "snils_hash = ?")
+		args = append(args, blindIndex(snils))
+	}
+	for _, policy := range []string{p.OMSPolicy, p.PolicyNumber} {
+		if policy = domain.DocumentDigits(policy); policy != "" {
+			conds = append(conds, "? IN (oms_policy_hash, policy_number_hash)")
+			args = append(args, blindIndex(policy))
+		}
+	}
+	if passport := domain.DocumentDigits(p.PassportSeries + p.PassportNumber); passport != "" {
+		conds = append(conds, "passport_hash = ?")
+		args = append(args, blindIndex(passport))
+	}
+	if !p.DateOfBirth.IsZero() {
+		conds = append(conds, "date_of_birth::date = ?")
+		args = append(args, p.DateOfBirth.Format("2006-01-02"))
+	}
+	if len(conds) == 0 {
+		return nil, nil
+	}
+
+	var candidates []domain.Patient
+	err := r.db.WithContext(ctx).
+		Where("id <> ?", p.ID).
+		Where(strings.Join(conds, " OR "), args...).
+		Order("id").Find(&candidates).Error
+	return candidates, err
+}
+
+// FindDuplicatePairs — пары записей (старшая первой) с общим документом
+// или одной датой рождения и похожим ФИО
+func (r *patientRepository) FindDuplicatePairs(ctx context.Context) ([][2]domain.Patient, error) {
+	type idPair struct {
+		AID uint
+		BID uint
+	}
+	var ids []idPair
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT a.id AS a_id, b.id AS b_id
+		FROM patients a JOIN patients b ON a.id < b.id
+		WHERE `+hashSQL("a.snils_hash")+` = b.snils_hash
+			OR `+hashSQL("a.oms_policy_hash")+` IN (b.oms_policy_hash, b.policy_number_hash)
+			OR `+hashSQL("a.policy_number_hash")+` IN (b.oms_policy_hash, b.policy_number_hash)
+			OR `+hashSQL("a.passport_hash")+` = b.passport_hash
+			OR (a.date_of_birth::date = b.date_of_birth::date AND a.date_of_birth > '1900-01-01'
+				AND similarity(`+patientNameExpr("a")+`, `+patientNameExpr("b")+`) >= ?)
+		ORDER BY a.id, b.id`, duplicateNameSimilarity).Scan(&ids).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	idSet := make([]uint, 0, len(ids)*2)
+	for _, p := range ids {
+		idSet = append(idSet, p.AID, p.BID)
+	}
+	var patients []domain.Patient
+	if err := r.db.WithContext(ctx).Where("id IN ?", idSet).Find(&patients).Error; err != nil {
+		return nil, err
+	}
+	byID := make(map[uint]domain.Patient, len(patients))
+	for _, p := range patients {
+		byID[p.ID] = p
+	}
+
+	pairs := make([][2]domain.Patient, 0, len(ids))
+	for _, p := range ids {
+		pairs = append(pairs, [2]domain.Patient{byID[p.AID], byID[p.BID]})
+	}
+	return pairs, nil
+}
+
+// ErrEyeExamConflict — у обеих записей есть осмотр одного глаза за одну дату
+var ErrEyeExamConflict = errors.New("у обоих пациентов есть осмотр одного глаза за одну дату")
+
+// Merge переносит все данные дубля на оставшуюся запись, сохраняет её и удаляет дубль.
+// Возвращает число перенесённых записей по таблицам.
+func (r *patientRepository) Merge(ctx context.Context, survivor *domain.Patient, duplicateID uint) (map[string]int64, error) {
+	moved := make(map[string]int64)
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var duplicate domain.Patient
+		if err := tx.First(&duplicate, duplicateID).Error; err != nil {
+			return err
+		}
+
+		// Осмотры уникальны по (пациент, глаз, дата) — конфликт решает врач, а не слияние
+		var conflicts int64
+		if err := tx.Model(&domain.EyeExam{}).
+			Where("patient_id = ?", duplicateID).
+			Where("EXISTS (SELECT 1 FROM eye_exams s WHERE s.patient_id = ? AND s.eye = eye_exams.eye AND s.exam_date = eye_exams.exam_date)", survivor.ID).
+			Count(&conflicts).Error; err != nil {
+			return err
+		}
+		if conflicts > 0 {
+			return ErrEyeExamConflict
+		}
+
+		for _, m := range []struct {
+			name  string
+			model interface{}
+		}{
+			{"checklist_items", &domain.ChecklistItem{}},
+			{"media", &domain.Media{}},
+			{"comments", &domain.Comment{}},
+			{"iol_calculations", &domain.IOLCalculation{}},
+			{"surgeries", &domain.Surgery{}},
+			{"post_op_visits", &domain.PostOpVisit{}},
+			{"eye_exams", &domain.EyeExam{}},
+			{"status_history", &domain.PatientStatusHistory{}},
+			{"telegram_login_tokens", &domain.TelegramLoginToken{}},
+			{"integration_outbox", &domain.IntegrationOutbox{}},
+			{"consents", &domain.Consent{}},
+		} {
+			res := tx.Model(m.model).Where("patient_id = ?", duplicateID).Update("patient_id", survivor.ID)
+			if res.Error != nil {
+				return res.Error
+			}
+			moved[m.name] = res.RowsAffected
+		}
+
+		// Привязки Telegram хранят и код доступа — переводим на код оставшейся записи
+		res := tx.Model(&domain.TelegramBinding{}).Where("patient_id = ?", duplicateID).
+			Updates(map[string]interface{}{"patient_id": survivor.ID, "access_code": survivor.AccessCode})
+		if res.Error != nil {
+			return res.Error
+		}
+		moved["telegram_bindings"] = res.RowsAffected
+
+		res = tx.Model(&domain.Notification{}).Where("entity_type = ? AND entity_id = ?", "patient", duplicateID).
+			Update("entity_id", survivor.ID)
+		if res.Error != nil {
+			return res.Error
+		}
+		moved["notifications"] = res.RowsAffected
+
+		if err := tx.Omit("Doctor", "Surgeon", "District").Save(survivor).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Delete(&duplicate).Error; err != nil {
+			return err
+		}
+		return tx.Create(domain.NewSyncTombstone(domain.SyncEntityPatient, duplicate.ID, &duplicate)).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return moved, nil
+}
@@ -2,32 +2,56 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/beercut-team/backend-boilerplate/internal/domain"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type PatientRepository interface {
 	Create(ctx context.Context, patient *domain.Patient) error
 	FindByID(ctx context.Context, id uint) (*domain.Patient, error)
 	FindByAccessCode(ctx context.Context, code string) (*domain.Patient, error)
+	FindBySNILS(ctx context.Context, snils string) (*domain.Patient, error)
+	FindByOMSPolicy(ctx context.Context, policy string) (*domain.Patient, error)
+	FindByFHIRResourceID(ctx context.Context, resourceID string) (*domain.Patient, error)
 	FindAll(ctx context.Context, filters PatientFilters, offset, limit int) ([]domain.Patient, int64, error)
 	Update(ctx context.Context, patient *domain.Patient) error
+	UpdateMedicalMetadata(ctx context.Context, id uint, metadata *domain.MedicalStandardsMetadata) error
+	ModifyMedicalMetadata(ctx context.Context, id uint, modify func(p *domain.Patient)) error
 	Delete(ctx context.Context, id uint) error
 	UpdateStatus(ctx context.Context, id uint, status domain.PatientStatus) error
 	CreateStatusHistory(ctx context.Context, h *domain.PatientStatusHistory) error
 	FindStatusHistory(ctx context.Context, patientID uint) ([]domain.PatientStatusHistory, error)
 	CountByStatus(ctx context.Context, doctorID *uint, role domain.Role) (map[domain.PatientStatus]int64, error)
 	CountByAccessCode(ctx context.Context, code string, count *int64) error
+	Facets(ctx context.Context, filters PatientFilters) (*domain.PatientFacets, error)
+	FindDuplicateCandidates(ctx context.Context, p *domain.Patient) ([]domain.Patient, error)
+	FindDuplicatePairs(ctx context.Context) ([][2]domain.Patient, error)
+	Merge(ctx context.Context, survivor *domain.Patient, duplicateID uint) (map[string]int64, error)
 }
 
 type PatientFilters struct {
 	DoctorID   *uint
 	SurgeonID  *uint
 	DistrictID *uint
-	Status     *domain.PatientStatus
+	Statuses   []domain.PatientStatus
 	Search     string
 	MinStatus  []domain.PatientStatus
+
+	SNILS         string // только цифры
+	OMSPolicy     string
+	Phone         string // только цифры
+	DateOfBirth   *time.Time
+	DiagnosisCode string // префикс кода МКБ-10, например H25
+	OperationType domain.OperationType
+	SurgeryFrom   *time.Time
+	SurgeryTo     *time.Time // включительно
+	Checklist     string     // domain.ChecklistComplete / domain.ChecklistIncomplete
+
+	Sort       string
+	Descending bool
 }
 
 type patientRepository struct {
@@ -60,38 +84,42 @@ func (r *patientRepository) FindByAccessCode(ctx context.Context, code string) (
 	return &patient, nil
 }
 
+func (r *patientRepository) FindBySNILS(ctx context.Context, snils string) (*domain.Patient, error) {
+	var patient domain.Patient
+	if err := r.db.WithContext(ctx).Where("snils_hash = ?", blindIndex(domain.DocumentDigits(snils))).First(&patient).Error; err != nil {
+		return nil, err
+	}
+	return &patient, nil
+}
+
+func (r *patientRepository) FindByOMSPolicy(ctx context.Context, policy string) (*domain.Patient, error) {
+	var patient domain.Patient
+	if err := r.db.WithContext(ctx).Where("oms_policy_hash = ?", blindIndex(domain.DocumentDigits(policy))).First(&patient).Error; err != nil {
+		return nil, err
+	}
+	return &patient, nil
+}
+
+func (r *patientRepository) FindByFHIRResourceID(ctx context.Context, resourceID string) (*domain.Patient, error) {
+	var patient domain.Patient
+	if err := r.db.WithContext(ctx).Where("medical_metadata->>'fhir_resource_id' = ?", resourceID).First(&patient).Error; err != nil {
+		return nil, err
+	}
+	return &patient, nil
+}
+
 func (r *patientRepository) FindAll(ctx context.Context, filters PatientFilters, offset, limit int) ([]domain.Patient, int64, error) {
 	var patients []domain.Patient
 	var total int64
 
-	query := r.db.WithContext(ctx).Model(&domain.Patient{})
-
-	if filters.DoctorID != nil {
-		query = query.Where("doctor_id = ?", *filters.DoctorID)
-	}
-	if filters.SurgeonID != nil {
-		query = query.Where("surgeon_id = ?", *filters.SurgeonID)
-	}
-	if filters.DistrictID != nil {
-		query = query.Where("district_id = ?", *filters.DistrictID)
-	}
-	if filters.Status != nil {
-		query = query.Where("status = ?", *filters.Status)
-	}
-	if len(filters.MinStatus) > 0 {
-		query = query.Where("status IN ?", filters.MinStatus)
-	}
-	if filters.Search != "" {
-		query = query.Where("first_name ILIKE ? OR last_name ILIKE ? OR access_code ILIKE ?",
-			"%"+filters.Search+"%", "%"+filters.Search+"%", "%"+filters.Search+"%")
-	}
+	query := r.filtered(ctx, filters)
 
 	if err := query.Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
 
 	if err := query.Preload("Doctor").Preload("District").
-		Offset(offset).Limit(limit).Order("updated_at DESC").Find(&patients).Error; err != nil {
+		Offset(offset).Limit(limit).Order(patientOrder(filters)).Find(&patients).Error; err != nil {
 		return nil, 0, err
 	}
 
@@ -102,8 +130,35 @@ func (r *patientRepository) Update(ctx context.Context, patient *domain.Patient)
 	return r.db.WithContext(ctx).Save(patient).Error
 }
 
+func (r *patientRepository) UpdateMedicalMetadata(ctx context.Context, id uint, metadata *domain.MedicalStandardsMetadata) error {
+	return r.db.WithContext(ctx).Model(&domain.Patient{}).Where("id = ?", id).Update("medical_metadata", metadata).Error
+}
+
+// ModifyMedicalMetadata перечитывает метаданные под блокировкой строки и сохраняет их после modify,
+// чтобы не затереть поля, изменённые с момента чтения пациента
+func (r *patientRepository) ModifyMedicalMetadata(ctx context.Context, id uint, modify func(p *domain.Patient)) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var patient domain.Patient
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id", "medical_metadata").First(&patient, id).Error; err != nil {
+			return err
+		}
+		modify(&patient)
+		return tx.Model(&domain.Patient{}).Where("id = ?", id).Update("medical_metadata", patient.MedicalMetadata).Error
+	})
+}
+
 func (r *patientRepository) Delete(ctx context.Context, id uint) error {
-	return r.db.WithContext(ctx).Delete(&domain.Patient{}, id).Error
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var patient domain.Patient
+		if err := tx.First(&patient, id).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&patient).Error; err != nil {
+			return err
+		}
+		// Надгробие для офлайн-клиентов: удаление придёт им при следующем pull
+		return tx.Create(domain.NewSyncTombstone(domain.SyncEntityPatient, patient.ID, &patient)).Error
+	})
 }
 
 func (r *patientRepository) UpdateStatus(ctx context.Context, id uint, status domain.PatientStatus) error {
@@ -132,13 +187,7 @@ func (r *patientRepository) CountByStatus(ctx context.Context, doctorID *uint, r
 		query = query.Where("doctor_id = ?", *doctorID)
 	}
 	if role == domain.RoleSurgeon {
-		query = query.Where("status IN ?", []domain.PatientStatus{
-			domain.PatientStatusPendingReview,
-			domain.PatientStatusApproved,
-			domain.PatientStatusScheduled,
-			domain.PatientStatusCompleted,
-			domain.PatientStatusNeedsCorrection,
-		})
+		query = query.Where("status IN ?", domain.SurgeonVisibleStatuses)
 	}
 
 	if err := query.Find(&results).Error; err != nil {
@@ -0,0 +1,226 @@
+package repository
+
+import (
+	"context"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/beercut-team/backend-boilerplate/internal/domain"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ФИО в нижнем регистре с «ё» как «е»; по этому выражению построен триграммный индекс
+var patientNameSQL = patientNameExpr("patients")
+
+func patientNameExpr(table string) string {
+	return "translate(lower(" + table + ".last_name || ' ' || " + table + ".first_name || ' ' || coalesce(" + table + ".middle_name, '')), 'ё', 'е')"
+}
+
+// Полнотекстовый поиск по диагнозу; выражение совпадает с индексом idx_patients_diagnosis_fts
+const patientDiagnosisSQL = "to_tsvector('russian', coalesce(patients.diagnosis, ''))"
+
+// Все обязательные пункты чек-листа выполнены (и хотя бы один обязательный есть)
+const checklistCompleteSQL = `(EXISTS (SELECT 1 FROM checklist_items ci WHERE ci.patient_id = patients.id AND ci.is_required)
+	AND NOT EXISTS (SELECT 1 FROM checklist_items ci WHERE ci.patient_id = patients.id AND ci.is_required AND ci.status <> 'COMPLETED'))`
+
+// Порог word_similarity для слова ФИО с опечаткой: одна ошибка в фамилии из шести букв даёт ~0.55
+const nameSimilarityThreshold = 0.45
+
+// Слова короче не сравниваются по триграммам — слишком много ложных совпадений
+const minFuzzyTokenLength = 4
+
+// filtered строит запрос по всем фильтрам, кроме сортировки и пагинации
+func (r *patientRepository) filtered(ctx context.Context, filters PatientFilters) *gorm.DB {
+	query := r.db.WithContext(ctx).Model(&domain.Patient{})
+
+	if filters.DoctorID != nil {
+		query = query.Where("patients.doctor_id = ?", *filters.DoctorID)
+	}
+	if filters.SurgeonID != nil {
+		query = query.Where("patients.surgeon_id = ?", *filters.SurgeonID)
+	}
+	if filters.DistrictID != nil {
+		query = query.Where("patients.district_id = ?", *filters.DistrictID)
+	}
+	if len(filters.Statuses) > 0 {
+		query = query.Where("patients.status IN ?", filters.Statuses)
+	}
+	if len(filters.MinStatus) > 0 {
+		query = query.Where("patients.status IN ?", filters.MinStatus)
+	}
+	if filters.OperationType != "" {
+		query = query.Where("patients.operation_type = ?", filters.OperationType)
+	}
+	// Документы и телефон зашифрованы — сравниваются слепые индексы, только точное совпадение
+	if filters.SNILS != "" {
+		query = query.Where("patients.snils_hash = ?", blindIndex(domain.DocumentDigits(filters.SNILS)))
+	}
+	if filters.OMSPolicy != "" {
+		hash := blindIndex(domain.DocumentDigits(filters.OMSPolicy))
+		query = query.Where("patients.oms_policy_hash = ? OR patients.policy_number_hash = ?", hash, hash)
+	}
+	if filters.Phone != "" {
+		query = query.Where("patients.phone_hash = ?", blindIndex(domain.PhoneDigits(filters.Phone)))
+	}
+	if filters.DateOfBirth != nil {
+		query = query.Where("patients.date_of_birth::date = ?", filters.DateOfBirth.Format("2006-01-02"))
+	}
+	if filters.DiagnosisCode != "" {
+		query = query.Where(`EXISTS (SELECT 1 FROM jsonb_array_elements(coalesce(patients.medical_metadata->'diagnosis_codes', '[]'::jsonb)) d
+			WHERE upper(d->>'code') LIKE ?)`, strings.ToUpper(filters.DiagnosisCode)+"%")
+	}
+	if filters.SurgeryFrom != nil {
+		query = query.Where("patients.surgery_date >= ?", *filters.SurgeryFrom)
+	}
+	if filters.SurgeryTo != nil {
+		query = query.Where("patients.surgery_date < ?", filters.SurgeryTo.Add(24*time.Hour))
+	}
+	switch filters.Checklist {
+	case domain.ChecklistComplete:
+		query = query.Where(checklistCompleteSQL)
+	case domain.ChecklistIncomplete:
+		query = query.Where("NOT " + checklistCompleteSQL)
+	}
+
+	if filters.Search != "" {
+		query = applySearch(query, filters.Search)
+	}
+	return query
+}
+
+// applySearch ищет по строке: номер — по СНИЛС, полису и телефону (точно) и коду доступа;
+// текст — по словам ФИО с допуском опечаток, коду доступа и диагнозу (полнотекстово)
+func applySearch(query *gorm.DB, search string) *gorm.DB {
+	like := "%" + search + "%"
+	if digits, ok := domain.SearchDigits(search); ok {
+		hash := blindIndex(digits)
+		return query.Where(`patients.snils_hash = ? OR patients.oms_policy_hash = ? OR patients.policy_number_hash = ?
+			OR patients.phone_hash = ? OR patients.access_code ILIKE ?`,
+			hash, hash, hash, blindIndex(domain.PhoneDigits(digits)), like)
+	}
+
+	var nameConds []string
+	var args []interface{}
+	for _, token := range strings.Fields(domain.NormalizeSearchName(search)) {
+		if utf8.RuneCountInString(token) < minFuzzyTokenLength {
+			nameConds = append(nameConds, patientNameSQL+" LIKE ?")
+			args = append(args, "%"+token+"%")
+			continue
+		}
+		nameConds = append(nameConds, "("+patientNameSQL+" LIKE ? OR word_similarity(?, "+patientNameSQL+") >= ?)")
+		args = append(args, "%"+token+"%", token, nameSimilarityThreshold)
+	}
+	if len(nameConds) == 0 {
+		return query
+	}
+
+	sql := "(" + strings.Join(nameConds, " AND ") + ") OR patients.access_code ILIKE ? OR " +
+		patientDiagnosisSQL + " @@ plainto_tsquery('russian', ?)"
+	args = append(args, like, search)
+	return query.Where(sql, args...)
+}
+
+// patientOrder — сортировка списка; name и relevance с учётом направления
+func patientOrder(filters PatientFilters) clause.OrderBy {
+	desc := " ASC"
+	if filters.Descending {
+		desc = " DESC"
+	}
+	column := func(sql string) clause.OrderByColumn {
+		return clause.OrderByColumn{Column: clause.Column{Name: sql, Raw: true}}
+	}
+	tieBreak := column("patients.id" + desc)
+
+	switch filters.Sort {
+	case domain.PatientSortCreated:
+		return clause.OrderBy{Columns: []clause.OrderByColumn{column("patients.created_at" + desc), tieBreak}}
+	case domain.PatientSortName:
+		return clause.OrderBy{Columns: []clause.OrderByColumn{
+			column("patients.last_name" + desc), column("patients.first_name" + desc), column("patients.middle_name" + desc), tieBreak,
+		}}
+	case domain.PatientSortBirthDate:
+		return clause.OrderBy{Columns: []clause.OrderByColumn{column("patients.date_of_birth" + desc), tieBreak}}
+	case domain.PatientSortSurgeryDate:
+		return clause.OrderBy{Columns: []clause.OrderByColumn{column("patients.surgery_date" + desc + " NULLS LAST"), tieBreak}}
+	case domain.PatientSortRelevance:
+		if filters.Search != "" {
+			name := domain.NormalizeSearchName(filters.Search)
+			return clause.OrderBy{Expression: clause.Expr{
+				SQL:  "word_similarity(?, " + patientNameSQL + ") + ts_rank(" + patientDiagnosisSQL + ", plainto_tsquery('russian', ?)) DESC, patients.updated_at DESC",
+				Vars: []interface{}{name, filters.Search},
+			}}
+		}
+	}
+	// По умолчанию — недавно изменённые сверху
+	if filters.Sort == "" || filters.Sort == domain.PatientSortRelevance {
+		desc = " DESC"
+	}
+	return clause.OrderBy{Columns: []clause.OrderByColumn{column("patients.updated_at" + desc), column("patients.id" + desc)}}
+}
+
+func (r *patientRepository) Facets(ctx context.Context, filters PatientFilters) (*domain.PatientFacets, error) {
+	facets := &domain.PatientFacets{}
+
+	noStatus := filters
+	noStatus.Statuses = nil
+	if err := r.filtered(ctx, noStatus).
+		Select("patients.status AS value, count(*) AS count").
+		Group("patients.status").Order("count DESC").
+		Scan(&facets.Statuses).Error; err != nil {
+		return nil, err
+	}
+	for i := range facets.Statuses {
+		facets.Statuses[i].Label = domain.GetStatusDisplayName(domain.PatientStatus(facets.Statuses[i].Value))
+	}
+
+	noDistrict := filters
+	noDistrict.DistrictID = nil
+	if err := r.filtered(ctx, noDistrict).
+		Select("patients.district_id::text AS value, coalesce(districts.name, '') AS label, count(*) AS count").
+		Joins("LEFT JOIN districts ON districts.id = patients.district_id").
+		Group("patients.district_id, districts.name").Order("count DESC").
+		Scan(&facets.Districts).Error; err != nil {
+		return nil, err
+	}
+
+	noSurgeon := filters
+	noSurgeon.SurgeonID = nil
+	if err := r.filtered(ctx, noSurgeon).
+		Select("patients.surgeon_id::text AS value, coalesce(users.name, '') AS label, count(*) AS count").
+		Joins("JOIN users ON users.id = patients.surgeon_id").
+		Group("patients.surgeon_id, users.name").Order("count DESC").
+		Scan(&facets.Surgeons).Error; err != nil {
+		return nil, err
+	}
+
+	noOperation := filters
+	noOperation.OperationType = ""
+	if err := r.filtered(ctx, noOperation).
+		Select("patients.operation_type AS value, count(*) AS count").
+		Group("patients.operation_type").Order("count DESC").
+		Scan(&facets.OperationTypes).Error; err != nil {
+		return nil, err
+	}
+	for i := range facets.OperationTypes {
+		facets.OperationTypes[i].Label = domain.GetOperationTypeDisplayName(domain.OperationType(facets.OperationTypes[i].Value))
+	}
+
+	noChecklist := filters
+	noChecklist.Checklist = ""
+	if err := r.filtered(ctx, noChecklist).
+		Select("CASE WHEN " + checklistCompleteSQL + " THEN '" + domain.ChecklistComplete + "' ELSE '" + domain.ChecklistIncomplete + "' END AS value, count(*) AS count").
+		Group("1").Order("1").
+		Scan(&facets.Checklist).Error; err != nil {
+		return nil, err
+	}
+	for i := range facets.Checklist {
+		facets.Checklist[i].Label = "Чек-лист не готов"
+		if facets.Checklist[i].Value == domain.ChecklistComplete {
+			facets.Checklist[i].Label = "Чек-лист готов"
+		}
+	}
+
+	return facets, nil
+}
@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/beercut-team/backend-boilerplate/internal/domain"
+	"gorm.io/gorm"
+)
+
+// OutcomeFilters — отбор исходов для статистики; nil — без ограничения
+type OutcomeFilters struct {
+	SurgeonID *uint
+	LensID    *uint
+	Formula   string
+	From      *time.Time
+	To        *time.Time
+}
+
+type PostOpRepository interface {
+	Create(ctx context.Context, visit *domain.PostOpVisit) error
+	Update(ctx context.Context, visit *domain.PostOpVisit) error
+	Delete(ctx context.Context, id uint) error
+	FindByID(ctx context.Context, id uint) (*domain.PostOpVisit, error)
+	FindBySurgery(ctx context.Context, surgeryID uint) ([]domain.PostOpVisit, error)
+	// FindOutcomes — осмотры с расчётом ИОЛ вместе с биометрией и хирургом,
+	// от последнего осмотра к первому
+	FindOutcomes(ctx context.Context, filters OutcomeFilters) ([]domain.PostOpOutcome, error)
+}
+
+type postOpRepository struct {
+	db *gorm.DB
+}
+
+func NewPostOpRepository(db *gorm.DB) PostOpRepository {
+	return &postOpRepository{db: db}
+}
+
+func (r *postOpRepository) Create(ctx context.Context, visit *domain.PostOpVisit) error {
+	return r.db.WithContext(ctx).Create(visit).Error
+}
+
+func (r *postOpRepository) Update(ctx context.Context, visit *domain.PostOpVisit) error {
+	return r.db.WithContext(ctx).Save(visit).Error
+}
+
+func (r *postOpRepository) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Delete(&domain.PostOpVisit{}, id).Error
+}
+
+func (r *postOpRepository) FindByID(ctx context.Context, id uint) (*domain.PostOpVisit, error) {
+	var visit domain.PostOpVisit
+	if err := r.db.WithContext(ctx).First(&visit, id).Error; err != nil {
+		return nil, err
+	}
+	return &visit, nil
+}
+
+func (r *postOpRepository) FindBySurgery(ctx context.Context, surgeryID uint) ([]domain.PostOpVisit, error) {
+	var visits []domain.PostOpVisit
+	err := r.db.WithContext(ctx).Where("surgery_id = ?", surgeryID).Order("visit_date ASC").Find(&visits).Error
+	return visits, err
+}
+
+func (r *postOpRepository) FindOutcomes(ctx context.Context, filters OutcomeFilters) ([]domain.PostOpOutcome, error) {
+	query := r.db.WithContext(ctx).Table("post_op_visits AS v").
+		Select(`v.id AS visit_id, v.surgery_id, s.surgeon_id, c.lens_id, c.formula,
+			c.axial_length, (c.keratometry1 + c.keratometry2) / 2 AS average_k, c.a_constant,
+			COALESCE(v.implanted_power, c.iol_power) AS implanted_power,
+			c.predicted_refraction, v.spherical_equivalent AS actual_refraction,
+			s.scheduled_date AS surgery_date, v.visit_date`).
+		Joins("JOIN surgeries s ON s.id = v.surgery_id").
+		Joins("JOIN iol_calculations c ON c.id = v.iol_calculation_id")
+
+	if filters.SurgeonID != nil {
+		query = query.Where("s.surgeon_id = ?", *filters.SurgeonID)
+	}
+	if filters.LensID != nil {
+		query = query.Where("c.lens_id = ?", *filters.LensID)
+	}
+	if filters.Formula != "" {
+		query = query.Where("c.formula = ?", filters.Formula)
+	}
+	if filters.From != nil {
+		query = query.Where("s.scheduled_date >= ?", *filters.From)
+	}
+	if filters.To != nil {
+		query = query.Where("s.scheduled_date < ?", *filters.To)
+	}
+
+	var outcomes []domain.PostOpOutcome
+	err := query.Order("v.visit_date DESC").Scan(&outcomes).Error
+	return outcomes, err
+}
@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/beercut-team/backend-boilerplate/internal/domain"
+	"gorm.io/gorm"
+)
+
+type SessionRepository interface {
+	Create(ctx context.Context, session *domain.Session) error
+	FindByID(ctx context.Context, id uint) (*domain.Session, error)
+	// FindActive — незавершённые и неистёкшие сессии, последние использованные первыми
+	FindActive(ctx context.Context, userID uint, subject domain.SessionSubject) ([]domain.Session, error)
+	// Rotate заменяет хеш токена обновления, только если сессия активна и хеш
+	// совпадает с oldHash; false — токен уже заменён или сессия завершена
+	Rotate(ctx context.Context, id uint, oldHash, newHash string, client domain.ClientInfo) (bool, error)
+	Revoke(ctx context.Context, id uint, reason string) error
+	// RevokeAll завершает сессии пользователя, кроме exceptID
+	RevokeAll(ctx context.Context, userID uint, subject domain.SessionSubject, exceptID uint, reason string) error
+	// DeleteStale удаляет сессии, истёкшие или завершённые раньше before
+	DeleteStale(ctx context.Context, before time.Time) (int64, error)
+}
+
+type sessionRepository struct {
+	db *gorm.DB
+}
+
+func NewSessionRepository(db *gorm.DB) SessionRepository {
+	return &sessionRepository{db: db}
+}
+
+func (r *sessionRepository) Create(ctx context.Context, session *domain.Session) error {
+	return r.db.WithContext(ctx).Create(session).Error
+}
+
+func (r *sessionRepository) FindByID(ctx context.Context, id uint) (*domain.Session, error) {
+	var session domain.Session
+	if err := r.db.WithContext(ctx).First(&session, id).Error; err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+func (r *sessionRepository) FindActive(ctx context.Context, userID uint, subject domain.SessionSubject) ([]domain.Session, error) {
+	var sessions []domain.Session
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND subject = ? AND revoked_at IS NULL AND expires_at > ?", userID, subject, time.Now()).
+		Order("last_used_at DESC").
+		Find(&sessions).Error
+	return sessions, err
+}
+
+func (r *sessionRepository) Rotate(ctx context.Context, id uint, oldHash, newHash string, client domain.ClientInfo) (bool, error) {
+	res := r.db.WithContext(ctx).Model(&domain.Session{}).
+		Where("id = ? AND refresh_hash = ? AND revoked_at IS NULL", id, oldHash).
+		Updates(map[string]interface{}{
+			"refresh_hash": newHash,
+			"last_used_at": time.Now(),
+			"ip":           client.IP,
+			"user_agent":   client.UserAgent,
+		})
+	return res.RowsAffected == 1, res.Error
+}
+
+func (r *sessionRepository) Revoke(ctx context.Context, id uint, reason string) error {
+	return r.db.WithContext(ctx).Model(&domain.Session{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Updates(map[string]interface{}{"revoked_at": time.Now(), "revoke_reason": reason}).Error
+}
+
+func (r *sessionRepository) RevokeAll(ctx context.Context, userID uint, subject domain.SessionSubject, exceptID uint, reason string) error {
+	return r.db.WithContext(ctx).Model(&domain.Session{}).
+		Where("user_id = ? AND subject = ? AND id <> ? AND revoked_at IS NULL", userID, subject, exceptID).
+		Updates(map[string]interface{}{"revoked_at": time.Now(), "revoke_reason": reason}).Error
+}
+
+func (r *sessionRepository) DeleteStale(ctx context.Context, before time.Time) (int64, error) {
+	res := r.db.WithContext(ctx).
+		Where("expires_at < ? OR revoked_at < ?", before, before).
+		Delete(&domain.Session{})
+	return res.RowsAffected, res.Error
+}
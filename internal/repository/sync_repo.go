@@ -2,7 +2,7 @@ package repository
 
 import (
 	"context"
-	"time"
+	"strings"
 
 	"github.com/beercut-team/backend-boilerplate/internal/domain"
 	"gorm.io/gorm"
@@ -10,7 +10,14 @@ import (
 
 type SyncRepository interface {
 	Create(ctx context.Context, entry *domain.SyncQueue) error
-	FindChangesSince(ctx context.Context, userID uint, since time.Time) ([]domain.SyncQueue, error)
+	// VersionBounds возвращает следующее значение последовательности версий и нижние
+	// границы версий незафиксированных транзакций (см. domain.SyncWatermark)
+	VersionBounds(ctx context.Context) (int64, []int64, error)
+	// Find*After возвращают записи с версией в интервале (cursor, before)
+	FindPatientsAfter(ctx context.Context, scope domain.PatientScope, cursor, before int64, limit int) ([]domain.Patient, error)
+	FindChecklistItemsAfter(ctx context.Context, scope domain.PatientScope, cursor, before int64, limit int) ([]domain.ChecklistItem, error)
+	FindCommentsAfter(ctx context.Context, scope domain.PatientScope, cursor, before int64, limit int) ([]domain.Comment, error)
+	FindTombstonesAfter(ctx context.Context, scope domain.PatientScope, cursor, before int64, limit int) ([]domain.SyncTombstone, error)
 }
 
 type syncRepository struct {
@@ -25,10 +32,97 @@ func (r *syncRepository) Create(ctx context.Context, entry *domain.SyncQueue) er
 	return r.db.WithContext(ctx).Create(entry).Error
 }
 
-func (r *syncRepository) FindChangesSince(ctx context.Context, userID uint, since time.Time) ([]domain.SyncQueue, error) {
-	var entries []domain.SyncQueue
+func (r *syncRepository) VersionBounds(ctx context.Context) (int64, []int64, error) {
+	db := r.db.WithContext(ctx)
+
+	// Порядок важен: транзакция, не успевшая взять блокировку к моменту чтения pg_locks,
+	// получит версию не ниже прочитанного до этого значения последовательности
+	var next int64
+	if err := db.Raw("SELECT CASE WHEN is_called THEN last_value + 1 ELSE last_value END FROM " + domain.SyncVersionSequence).
+		Scan(&next).Error; err != nil {
+		return 0, nil, err
+	}
+
+	var inFlight []int64
+	err := db.Raw(`SELECT ((classid::bigint << 32) | objid::bigint) & ?
+		FROM pg_locks
+		WHERE locktype = 'advisory' AND objsubid = 1 AND (classid::bigint >> 16) = ?
+		  AND database = (SELECT oid FROM pg_database WHERE datname = current_database())`,
+		int64(1)<<48-1, domain.SyncVersionLockSpace).
+		Scan(&inFlight).Error
+	return next, inFlight, err
+}
+
+func (r *syncRepository) FindPatientsAfter(ctx context.Context, scope domain.PatientScope, cursor, before int64, limit int) ([]domain.Patient, error) {
+	var patients []domain.Patient
+	err := applyPatientScope(r.db.WithContext(ctx), scope, "id").
+		Where("sync_version > ? AND sync_version < ?", cursor, before).
+		Order("sync_version ASC").Limit(limit).Find(&patients).Error
+	return patients, err
+}
+
+func (r *syncRepository) FindChecklistItemsAfter(ctx context.Context, scope domain.PatientScope, cursor, before int64, limit int) ([]domain.ChecklistItem, error) {
+	var items []domain.ChecklistItem
+	err := r.db.WithContext(ctx).
+		Where("sync_version > ? AND sync_version < ?", cursor, before).
+		Where("patient_id IN (?)", r.visiblePatientIDs(ctx, scope)).
+		Order("sync_version ASC").Limit(limit).Find(&items).Error
+	return items, err
+}
+
+func (r *syncRepository) FindCommentsAfter(ctx context.Context, scope domain.PatientScope, cursor, before int64, limit int) ([]domain.Comment, error) {
+	var comments []domain.Comment
 	err := r.db.WithContext(ctx).
-		Where("user_id = ? AND server_time > ?", userID, since).
-		Order("server_time ASC").Find(&entries).Error
-	return entries, err
+		Where("sync_version > ? AND sync_version < ?", cursor, before).
+		Where("patient_id IN (?)", r.visiblePatientIDs(ctx, scope)).
+		Preload("Author").
+		Order("sync_version ASC").Limit(limit).Find(&comments).Error
+	return comments, err
+}
+
+func (r *syncRepository) FindTombstonesAfter(ctx context.Context, scope domain.PatientScope, cursor, before int64, limit int) ([]domain.SyncTombstone, error) {
+	var tombstones []domain.SyncTombstone
+	// Надгробия хранят врача, район, хирурга и статус пациента, поэтому scope применяется напрямую
+	err := applyPatientScope(r.db.WithContext(ctx), scope, "patient_id").
+		Where("sync_version > ? AND sync_version < ?", cursor, before).
+		Order("sync_version ASC").Limit(limit).Find(&tombstones).Error
+	return tombstones, err
+}
+
+func (r *syncRepository) visiblePatientIDs(ctx context.Context, scope domain.PatientScope) *gorm.DB {
+	return applyPatientScope(r.db.WithContext(ctx).Model(&domain.Patient{}).Select("id"), scope, "id")
+}
+
+// applyPatientScope переводит domain.PatientScope в условие запроса; idColumn — колонка с ID пациента
+func applyPatientScope(query *gorm.DB, scope domain.PatientScope, idColumn string) *gorm.DB {
+	if scope.All {
+		return query
+	}
+
+	var conds []string
+	var args []interface{}
+	if scope.PatientID != nil {
+		conds = append(conds, idColumn+" = ?")
+		args = append(args, *scope.PatientID)
+	}
+	if scope.DoctorID != nil {
+		conds = append(conds, "doctor_id = ?")
+		args = append(args, *scope.DoctorID)
+	}
+	if scope.DistrictID != nil {
+		conds = append(conds, "district_id = ?")
+		args = append(args, *scope.DistrictID)
+	}
+	if scope.SurgeonID != nil {
+		conds = append(conds, "surgeon_id = ?")
+		args = append(args, *scope.SurgeonID)
+	}
+	if len(scope.Statuses) > 0 {
+		conds = append(conds, "status IN ?")
+		args = append(args, scope.Statuses)
+	}
+	if len(conds) == 0 {
+		return query.Where("1 = 0")
+	}
+	return query.Where("("+strings.Join(conds, " OR ")+")", args...)
 }
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"strings"
 
 	"github.com/beercut-team/backend-boilerplate/internal/domain"
 	"gorm.io/gorm"
@@ -9,12 +10,23 @@ import (
 
 type UserRepository interface {
 	Create(ctx context.Context, user *domain.User) error
-	FindAll(ctx context.Context) ([]domain.User, error)
+	FindAll(ctx context.Context, filters UserFilters, offset, limit int) ([]domain.User, int64, error)
 	FindByEmail(ctx context.Context, email string) (*domain.User, error)
 	FindByID(ctx context.Context, id uint) (*domain.User, error)
 	FindByChatID(ctx context.Context, chatID int64) (*domain.User, error)
 	Update(ctx context.Context, user *domain.User) error
-	UpdateRefreshToken(ctx context.Context, userID uint, token string) error
+	// UpdateTOTPStep запоминает интервал принятого кода TOTP; false — код этого
+	// или более позднего интервала уже был принят
+	UpdateTOTPStep(ctx context.Context, userID uint, step int64) (bool, error)
+	// CountActiveAdmins — активные администраторы, кроме exceptID
+	CountActiveAdmins(ctx context.Context, exceptID uint) (int64, error)
+}
+
+type UserFilters struct {
+	Role       domain.Role
+	DistrictID *uint
+	IsActive   *bool
+	Search     string // email, имя, фамилия или телефон
 }
 
 type userRepository struct {
@@ -29,17 +41,45 @@ func (r *userRepository) Create(ctx context.Context, user *domain.User) error {
 	return r.db.WithContext(ctx).Create(user).Error
 }
 
-func (r *userRepository) FindAll(ctx context.Context) ([]domain.User, error) {
+func (r *userRepository) FindAll(ctx context.Context, filters UserFilters, offset, limit int) ([]domain.User, int64, error) {
+	query := r.db.WithContext(ctx).Model(&domain.User{})
+	if filters.Role != "" {
+		query = query.Where("role = ?", filters.Role)
+	}
+	if filters.DistrictID != nil {
+		query = query.Where("district_id = ?", *filters.DistrictID)
+	}
+	if filters.IsActive != nil {
+		query = query.Where("is_active = ?", *filters.IsActive)
+	}
+	if s := strings.TrimSpace(filters.Search); s != "" {
+		like := "%" + strings.ToLower(s) + "%"
+		query = query.Where("LOWER(email) LIKE ? OR LOWER(name) LIKE ? OR LOWER(last_name) LIKE ? OR phone LIKE ?", like, like, like, like)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
 	var users []domain.User
-	if err := r.db.WithContext(ctx).Preload("District").Find(&users).Error; err != nil {
-		return nil, err
+	if err := query.Preload("District").Order("id").Offset(offset).Limit(limit).Find(&users).Error; err != nil {
+		return nil, 0, err
 	}
-	return users, nil
+	return users, total, nil
+}
+
+func (r *userRepository) CountActiveAdmins(ctx context.Context, exceptID uint) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&domain.User{}).
+		Where("role = ? AND is_active = ? AND id <> ?", domain.RoleAdmin, true, exceptID).
+		Count(&count).Error
+	return count, err
 }
 
 func (r *userRepository) FindByEmail(ctx context.Context, email string) (*domain.User, error) {
 	var user domain.User
-	if err := r.db.WithContext(ctx).Where("email = ?", email).First(&user).Error; err != nil {
+	// Адрес сравнивается без учёта регистра: приглашения хранят его в нижнем регистре
+	if err := r.db.WithContext(ctx).Where("LOWER(email) = LOWER(?)", strings.TrimSpace(email)).First(&user).Error; err != nil {
 		return nil, err
 	}
 	return &user, nil
@@ -53,8 +93,11 @@ func (r *userRepository) FindByID(ctx context.Context, id uint) (*domain.User, e
 	return &user, nil
 }
 
-func (r *userRepository) UpdateRefreshToken(ctx context.Context, userID uint, token string) error {
-	return r.db.WithContext(ctx).Model(&domain.User{}).Where("id = ?", userID).Update("refresh_token", token).Error
+func (r *userRepository) UpdateTOTPStep(ctx context.Context, userID uint, step int64) (bool, error) {
+	res := r.db.WithContext(ctx).Model(&domain.User{}).
+		Where("id = ? AND coalesce(totp_last_step, 0) < ?", userID, step).
+		UpdateColumn("totp_last_step", step)
+	return res.RowsAffected == 1, res.Error
 }
 
 func (r *userRepository) FindByChatID(ctx context.Context, chatID int64) (*domain.User, error) {
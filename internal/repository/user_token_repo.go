@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/beercut-team/backend-boilerplate/internal/domain"
+	"gorm.io/gorm"
+)
+
+type UserTokenRepository interface {
+	// Create заменяет неиспользованные токены пользователя с тем же назначением:
+	// действует только ссылка из последнего письма
+	Create(ctx context.Context, token *domain.UserToken) error
+	// FindActive — неиспользованный и неистёкший токен
+	FindActive(ctx context.Context, hash, purpose string) (*domain.UserToken, error)
+	// MarkUsed отмечает токен использованным; false — его уже использовали
+	MarkUsed(ctx context.Context, id uint) (bool, error)
+	// DeleteStale удаляет токены, истёкшие раньше before
+	DeleteStale(ctx context.Context, before time.Time) (int64, error)
+}
+
+type userTokenRepository struct {
+	db *gorm.DB
+}
+
+func NewUserTokenRepository(db *gorm.DB) UserTokenRepository {
+	return &userTokenRepository{db: db}
+}
+
+func (r *userTokenRepository) Create(ctx context.Context, token *domain.UserToken) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ? AND purpose = ? AND used_at IS NULL", token.UserID, token.Purpose).
+			Delete(&domain.UserToken{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(token).Error
+	})
+}
+
+func (r *userTokenRepository) FindActive(ctx context.Context, hash, purpose string) (*domain.UserToken, error) {
+	var token domain.UserToken
+	err := r.db.WithContext(ctx).
+		Where("token_hash = ? AND purpose = ? AND used_at IS NULL AND expires_at > ?", hash, purpose, time.Now()).
+		First(&token).Error
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (r *userTokenRepository) MarkUsed(ctx context.Context, id uint) (bool, error) {
+	res := r.db.WithContext(ctx).Model(&domain.UserToken{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", time.Now())
+	return res.RowsAffected == 1, res.Error
+}
+
+func (r *userTokenRepository) DeleteStale(ctx context.Context, before time.Time) (int64, error) {
+	res := r.db.WithContext(ctx).Where("expires_at < ?", before).Delete(&domain.UserToken{})
+	return res.RowsAffected, res.Error
+}
@@ -0,0 +1,178 @@
+package server
+
+// accountHTML — страницы по ссылкам из писем: /account/invite,
+// /account/reset-password и /account/verify-email
+const accountHTML = `<!DOCTYPE html>
+<html lang="ru">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>Oculus-Feldsher — учётная запись</title>
+    <script src="https://cdn.tailwindcss.com"></script>
+</head>
+<body class="bg-gradient-to-br from-blue-50 to-indigo-100 min-h-screen">
+
+<div class="container mx-auto px-4 py-8 max-w-md">
+    <div class="bg-white rounded-2xl shadow-xl p-8">
+        <h1 id="title" class="text-2xl font-bold text-gray-800 mb-2 text-center">Oculus-Feldsher</h1>
+        <p id="subtitle" class="text-gray-600 text-center mb-6"></p>
+
+        <div id="error" class="hidden bg-red-50 text-red-600 rounded-lg p-3 mb-4 text-sm"></div>
+        <div id="done" class="hidden bg-green-50 text-green-700 rounded-lg p-3 mb-4 text-sm"></div>
+
+        <form id="invite-form" class="hidden space-y-4">
+            <div>
+                <label class="block text-sm font-medium text-gray-700 mb-1">Email</label>
+                <input id="inv-email" type="email" disabled class="w-full border-2 border-gray-200 bg-gray-50 rounded-lg px-4 py-2">
+            </div>
+            <div>
+                <label class="block text-sm font-medium text-gray-700 mb-1">Отображаемое имя</label>
+                <input id="inv-name" type="text" required class="w-full border-2 border-gray-300 rounded-lg px-4 py-2 focus:outline-none focus:border-blue-500">
+            </div>
+            <div class="grid grid-cols-2 gap-3">
+                <div>
+                    <label class="block text-sm font-medium text-gray-700 mb-1">Фамилия</label>
+                    <input id="inv-lname" type="text" class="w-full border-2 border-gray-300 rounded-lg px-4 py-2 focus:outline-none focus:border-blue-500">
+                </div>
+                <div>
+                    <label class="block text-sm font-medium text-gray-700 mb-1">Имя</label>
+                    <input id="inv-fname" type="text" class="w-full border-2 border-gray-300 rounded-lg px-4 py-2 focus:outline-none focus:border-blue-500">
+                </div>
+            </div>
+            <div>
+                <label class="block text-sm font-medium text-gray-700 mb-1">Отчество</label>
+                <input id="inv-mname" type="text" class="w-full border-2 border-gray-300 rounded-lg px-4 py-2 focus:outline-none focus:border-blue-500">
+            </div>
+            <div>
+                <label class="block text-sm font-medium text-gray-700 mb-1">Телефон</label>
+                <input id="inv-phone" type="tel" class="w-full border-2 border-gray-300 rounded-lg px-4 py-2 focus:outline-none focus:border-blue-500">
+            </div>
+            <div>
+                <label class="block text-sm font-medium text-gray-700 mb-1">Пароль</label>
+                <input id="inv-password" type="password" required autocomplete="new-password" class="w-full border-2 border-gray-300 rounded-lg px-4 py-2 focus:outline-none focus:border-blue-500">
+                <p class="text-xs text-gray-500 mt-1">Не короче 10 символов, буквы и цифры, без email и имени</p>
+            </div>
+            <button type="submit" class="w-full bg-blue-600 hover:bg-blue-700 text-white font-semibold py-3 rounded-lg">Завершить регистрацию</button>
+        </form>
+
+        <form id="reset-form" class="hidden space-y-4">
+            <div>
+                <label class="block text-sm font-medium text-gray-700 mb-1">Новый пароль</label>
+                <input id="reset-password" type="password" required autocomplete="new-password" class="w-full border-2 border-gray-300 rounded-lg px-4 py-2 focus:outline-none focus:border-blue-500">
+                <p class="text-xs text-gray-500 mt-1">Не короче 10 символов, буквы и цифры, без email и имени</p>
+            </div>
+            <div>
+                <label class="block text-sm font-medium text-gray-700 mb-1">Повторите пароль</label>
+                <input id="reset-confirm" type="password" required autocomplete="new-password" class="w-full border-2 border-gray-300 rounded-lg px-4 py-2 focus:outline-none focus:border-blue-500">
+            </div>
+            <button type="submit" class="w-full bg-blue-600 hover:bg-blue-700 text-white font-semibold py-3 rounded-lg">Сохранить пароль</button>
+        </form>
+    </div>
+</div>
+
+<script>
+const API = '/api/v1';
+const token = new URLSearchParams(window.location.search).get('token') || '';
+const page = window.location.pathname.replace(/\/+$/, '').split('/').pop();
+
+function showError(msg) {
+    const el = document.getElementById('error');
+    el.textContent = msg;
+    el.classList.remove('hidden');
+}
+
+function showDone(msg) {
+    document.getElementById('error').classList.add('hidden');
+    const el = document.getElementById('done');
+    el.textContent = msg;
+    el.classList.remove('hidden');
+}
+
+async function post(path, body) {
+    const response = await fetch(API + path, {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify(body)
+    });
+    const data = await response.json().catch(() => ({}));
+    if (!response.ok) throw new Error(data.error || 'Ошибка запроса');
+    return data;
+}
+
+async function initInvite() {
+    document.getElementById('subtitle').textContent = 'Регистрация по приглашению';
+    const response = await fetch(API + '/auth/invitations/' + encodeURIComponent(token));
+    const data = await response.json().catch(() => ({}));
+    if (!response.ok) {
+        showError(data.error || 'Приглашение недействительно');
+        return;
+    }
+    document.getElementById('inv-email').value = data.email;
+    document.getElementById('inv-name').value = data.name || '';
+    const form = document.getElementById('invite-form');
+    form.classList.remove('hidden');
+    form.addEventListener('submit', async (e) => {
+        e.preventDefault();
+        try {
+            await post('/auth/register', {
+                invite_token: token,
+                password: document.getElementById('inv-password').value,
+                name: document.getElementById('inv-name').value.trim(),
+                first_name: document.getElementById('inv-fname').value.trim(),
+                last_name: document.getElementById('inv-lname').value.trim(),
+                middle_name: document.getElementById('inv-mname').value.trim(),
+                phone: document.getElementById('inv-phone').value.trim()
+            });
+            form.classList.add('hidden');
+            showDone('Регистрация завершена. Войдите в приложение с email ' + data.email + ' и новым паролем.');
+        } catch (err) {
+            showError(err.message);
+        }
+    });
+}
+
+function initReset() {
+    document.getElementById('subtitle').textContent = 'Новый пароль';
+    const form = document.getElementById('reset-form');
+    form.classList.remove('hidden');
+    form.addEventListener('submit', async (e) => {
+        e.preventDefault();
+        const password = document.getElementById('reset-password').value;
+        if (password !== document.getElementById('reset-confirm').value) {
+            showError('Пароли не совпадают');
+            return;
+        }
+        try {
+            const data = await post('/auth/password/reset', { token: token, password: password });
+            form.classList.add('hidden');
+            showDone(data.message);
+        } catch (err) {
+            showError(err.message);
+        }
+    });
+}
+
+async function initVerify() {
+    document.getElementById('subtitle').textContent = 'Подтверждение адреса';
+    try {
+        const data = await post('/auth/email/verify', { token: token });
+        showDone(data.message);
+    } catch (err) {
+        showError(err.message);
+    }
+}
+
+if (!token) {
+    showError('В ссылке нет токена. Откройте ссылку из письма целиком.');
+} else if (page === 'invite') {
+    initInvite();
+} else if (page === 'reset-password') {
+    initReset();
+} else if (page === 'verify-email') {
+    initVerify();
+}
+</script>
+
+</body>
+</html>
+`
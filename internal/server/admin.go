@@ -511,7 +511,7 @@ async function renderUsers(page = 1) {
     const districts = districtsResponse.data;
     let html = ` + "`" + `
     <div class="mb-4">
-        <button onclick="showCreateUser()" class="bg-blue-600 text-white px-4 py-2 rounded-lg text-sm hover:bg-blue-700">+ Добавить пользователя</button>
+        <button onclick="showCreateUser()" class="bg-blue-600 text-white px-4 py-2 rounded-lg text-sm hover:bg-blue-700">+ Пригласить сотрудника</button>
     </div>
     <div id="user-form-area"></div>
     <div class="bg-white rounded-xl shadow overflow-hidden">
@@ -543,7 +543,10 @@ async function renderUsers(page = 1) {
                 <td class="px-4 py-3">${dist ? dist.name : '—'}</td>
                 <td class="px-4 py-3">${u.is_active ? '<span class="text-green-600">✓</span>' : '<span class="text-red-600">✗</span>'}</td>
                 <td class="px-4 py-3 space-x-2">
-                    <span class="text-gray-400 text-xs">—</span>
+                    ${u.is_active
+                        ? ` + "`" + `<button onclick="resetUserPassword(${safe(u.id)})" class="text-blue-600 hover:underline text-xs">Сброс пароля</button>
+                           <button onclick="setUserActive(${safe(u.id)}, false)" class="text-red-600 hover:underline text-xs">Деактивировать</button>` + "`" + `
+                        : ` + "`" + `<button onclick="setUserActive(${safe(u.id)}, true)" class="text-green-600 hover:underline text-xs">Активировать</button>` + "`" + `}
                 </td>
             </tr>` + "`" + `;
         });
@@ -569,76 +572,83 @@ function showCreateUser() {
         <div class="grid grid-cols-2 md:grid-cols-3 gap-3">
             <input id="uf-email" placeholder="Email" class="border rounded px-3 py-2 text-sm">
             <input id="uf-name" placeholder="Полное имя" class="border rounded px-3 py-2 text-sm">
-            <input id="uf-fname" placeholder="Имя" class="border rounded px-3 py-2 text-sm">
-            <input id="uf-lname" placeholder="Фамилия" class="border rounded px-3 py-2 text-sm">
-            <input id="uf-mname" placeholder="Отчество" class="border rounded px-3 py-2 text-sm">
-            <input id="uf-phone" placeholder="Телефон" class="border rounded px-3 py-2 text-sm">
             <select id="uf-role" class="border rounded px-3 py-2 text-sm">
-                <option value="PATIENT">Пациент</option>
                 <option value="DISTRICT_DOCTOR">Районный врач</option>
                 <option value="SURGEON">Хирург</option>
                 <option value="CALL_CENTER">Колл-центр</option>
                 <option value="ADMIN">Администратор</option>
             </select>
             <select id="uf-district" class="border rounded px-3 py-2 text-sm">
-                <option value="">Без района</option>
+                <option value="">Район</option>
                 ${districts.map(d => ` + "`" + `<option value="${safe(d.id)}">${safe(d.name)}</option>` + "`" + `).join('')}
             </select>
-            <input id="uf-password" type="password" placeholder="Пароль" class="border rounded px-3 py-2 text-sm">
         </div>
+        <p class="mt-2 text-xs text-gray-500">Сотрудник получит письмо со ссылкой и сам задаст пароль. Ссылка действует 72 часа.</p>
         <div class="mt-3 flex gap-2">
-            <button onclick="createUser()" class="bg-blue-600 text-white px-4 py-1.5 rounded text-sm hover:bg-blue-700">Создать</button>
+            <button onclick="createUser()" class="bg-blue-600 text-white px-4 py-1.5 rounded text-sm hover:bg-blue-700">Пригласить</button>
             <button onclick="document.getElementById('user-form-area').innerHTML=''" class="bg-gray-200 px-4 py-1.5 rounded text-sm hover:bg-gray-300">Отмена</button>
         </div>
     </div>
     ` + "`" + `;
-    const phoneInput = document.getElementById('uf-phone');
-    if (phoneInput) maskPhoneInput(phoneInput);
 }
 
 async function createUser() {
     const email = document.getElementById('uf-email').value.trim();
-    const password = document.getElementById('uf-password').value;
     const name = document.getElementById('uf-name').value.trim();
     const role = document.getElementById('uf-role').value;
     const districtId = document.getElementById('uf-district').value;
 
-    if (!email || !password || !name || !role || !districtId) {
+    if (!email || !role || !districtId) {
         alert('Заполните все обязательные поля');
         return;
     }
-    if (password.length < 6) {
-        alert('Пароль должен содержать минимум 6 символов');
-        return;
-    }
-
-    const phoneInput = document.getElementById('uf-phone').value;
-    const phone = cleanPhone(phoneInput);
-    if (phoneInput && !phone) {
-        alert('Неверный формат телефона. Используйте 10 или 11 цифр.');
-        return;
-    }
 
     try {
         showLoading();
-        await api('/auth/register', {
+        await api('/admin/invitations', {
             method: 'POST',
             body: JSON.stringify({
-                email: document.getElementById('uf-email').value,
-                password: document.getElementById('uf-password').value,
-                name: document.getElementById('uf-name').value,
-                first_name: document.getElementById('uf-fname').value,
-                last_name: document.getElementById('uf-lname').value,
-                middle_name: document.getElementById('uf-mname').value,
-                phone: phone,
-                role: document.getElementById('uf-role').value,
-                district_id: districtId ? parseInt(districtId) : null
+                email: email,
+                name: name,
+                role: role,
+                district_id: parseInt(districtId)
             })
         });
-        showSuccess('Пользователь успешно создан');
+        showSuccess('Приглашение отправлено на ' + email);
         await renderUsers();
     } catch (err) {
-        showError('Ошибка создания пользователя: ' + err.message);
+        showError('Ошибка отправки приглашения: ' + err.message);
+    } finally {
+        hideLoading();
+    }
+}
+
+async function setUserActive(id, active) {
+    if (!active && !confirm('Деактивировать пользователя? Все его сессии будут завершены.')) return;
+    try {
+        showLoading();
+        if (active) {
+            await api('/admin/users/' + id + '/activate', { method: 'POST' });
+        } else {
+            await api('/admin/users/' + id, { method: 'DELETE' });
+        }
+        showSuccess(active ? 'Пользователь активирован' : 'Пользователь деактивирован');
+        await renderUsers();
+    } catch (err) {
+        showError(err.message);
+    } finally {
+        hideLoading();
+    }
+}
+
+async function resetUserPassword(id) {
+    if (!confirm('Отправить пользователю ссылку для сброса пароля?')) return;
+    try {
+        showLoading();
+        await api('/admin/users/' + id + '/reset-password', { method: 'POST' });
+        showSuccess('Ссылка для сброса пароля отправлена');
+    } catch (err) {
+        showError(err.message);
     } finally {
         hideLoading();
     }
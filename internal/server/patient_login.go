@@ -36,6 +36,15 @@ const patientLoginHTML = `<!DOCTYPE html>
                     autocomplete="off">
             </div>
 
+            <div>
+                <label class="block text-sm font-medium text-gray-700 mb-2">Дата рождения</label>
+                <input
+                    id="birth-date"
+                    type="date"
+                    class="w-full border-2 border-gray-300 rounded-lg px-4 py-3 text-center focus:outline-none focus:border-blue-500">
+                <p class="text-xs text-gray-500 mt-1">Если клиника требует её при входе</p>
+            </div>
+
             <button
                 type="submit"
                 id="submit-btn"
@@ -54,6 +63,22 @@ const patientLoginHTML = `<!DOCTYPE html>
 <script>
 const API = '/api/v1';
 
+// Задача после нескольких неудачных попыток: найти число, при котором
+// SHA-256("nonce:число") начинается с difficulty нулевых бит
+async function solveChallenge(challenge) {
+    const encoder = new TextEncoder();
+    for (let i = 0; ; i++) {
+        const hash = new Uint8Array(await crypto.subtle.digest('SHA-256', encoder.encode(challenge.nonce + ':' + i)));
+        let bits = 0;
+        for (const b of hash) {
+            if (b === 0) { bits += 8; continue; }
+            bits += Math.clz32(b) - 24;
+            break;
+        }
+        if (bits >= challenge.difficulty) return String(i);
+    }
+}
+
 document.getElementById('login-form').addEventListener('submit', async (e) => {
     e.preventDefault();
 
@@ -69,12 +94,25 @@ document.getElementById('login-form').addEventListener('submit', async (e) => {
     errorDiv.classList.add('hidden');
 
     try {
-        const response = await fetch(API + '/auth/patient-login', {
+        const body = { access_code: accessCode };
+        const birthDate = document.getElementById('birth-date').value;
+        if (birthDate) body.birth_date = birthDate;
+
+        const login = () => fetch(API + '/auth/patient-login', {
             method: 'POST',
             headers: { 'Content-Type': 'application/json' },
-            body: JSON.stringify({ access_code: accessCode })
+            body: JSON.stringify(body)
         });
 
+        let response = await login();
+        if (response.status === 428) {
+            const { challenge } = await response.json();
+            submitBtn.textContent = 'Проверка...';
+            body.challenge_id = challenge.id;
+            body.challenge_solution = await solveChallenge(challenge);
+            response = await login();
+        }
+
         if (!response.ok) {
             const error = await response.json();
             throw new Error(error.error || 'Неверный код доступа');
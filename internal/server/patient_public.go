@@ -19,7 +19,7 @@ const patientPublicHTML = `<!DOCTYPE html>
 <div id="app" class="container mx-auto px-4 py-8 max-w-2xl"></div>
 
 <script>
-const API = '/api/v1';
+const API = '/api/public';
 let accessCode = '';
 
 function render() {
@@ -57,9 +57,35 @@ function renderCodeInput() {
     };
 }
 
+// Задача после нескольких неудачных попыток: найти число, при котором
+// SHA-256("nonce:число") начинается с difficulty нулевых бит
+async function solveChallenge(challenge) {
+    const encoder = new TextEncoder();
+    for (let i = 0; ; i++) {
+        const hash = new Uint8Array(await crypto.subtle.digest('SHA-256', encoder.encode(challenge.nonce + ':' + i)));
+        let bits = 0;
+        for (const b of hash) {
+            if (b === 0) { bits += 8; continue; }
+            bits += Math.clz32(b) - 24;
+            break;
+        }
+        if (bits >= challenge.difficulty) return String(i);
+    }
+}
+
 async function loadPatientStatus() {
     try {
-        const response = await fetch(API + '/patients/public/' + accessCode);
+        const url = API + '/status/' + encodeURIComponent(accessCode);
+        let response = await fetch(url);
+        if (response.status === 428) {
+            const { challenge } = await response.json();
+            const solution = await solveChallenge(challenge);
+            response = await fetch(url, { headers: { 'X-Challenge-Id': challenge.id, 'X-Challenge-Solution': solution } });
+        }
+        if (response.status === 429) {
+            const error = await response.json();
+            throw new Error(error.error);
+        }
         if (!response.ok) throw new Error('Неверный код доступа');
         const data = await response.json();
         renderPatientStatus(data.data);
@@ -1,12 +1,20 @@
 package server
 
 import (
+	"context"
+	"time"
+
 	"github.com/beercut-team/backend-boilerplate/internal/config"
 	"github.com/beercut-team/backend-boilerplate/internal/domain"
 	"github.com/beercut-team/backend-boilerplate/internal/handler"
 	"github.com/beercut-team/backend-boilerplate/internal/middleware"
 	"github.com/beercut-team/backend-boilerplate/internal/repository"
 	"github.com/beercut-team/backend-boilerplate/internal/service"
+	"github.com/beercut-team/backend-boilerplate/pkg/database"
+	"github.com/beercut-team/backend-boilerplate/pkg/integrations"
+	"github.com/beercut-team/backend-boilerplate/pkg/jwtkeys"
+	"github.com/beercut-team/backend-boilerplate/pkg/mail"
+	"github.com/beercut-team/backend-boilerplate/pkg/ratelimit"
 	"github.com/beercut-team/backend-boilerplate/pkg/storage"
 	"github.com/beercut-team/backend-boilerplate/pkg/telegram"
 	"github.com/gin-contrib/cors"
@@ -15,6 +23,9 @@ import (
 	"gorm.io/gorm"
 )
 
+// Роли сотрудников клиники: списки пациентов и операций недоступны пациентам
+var staffRoles = []domain.Role{domain.RoleDistrictDoctor, domain.RoleSurgeon, domain.RoleAdmin, domain.RoleCallCenter}
+
 func NewRouter(cfg *config.Config, db *gorm.DB) *gin.Engine {
 	r := gin.Default()
 
@@ -31,14 +42,26 @@ func NewRouter(cfg *config.Config, db *gorm.DB) *gin.Engine {
 	auditRepo := repository.NewAuditRepository(db)
 	patientRepo := repository.NewPatientRepository(db)
 	checklistRepo := repository.NewChecklistRepository(db)
+	checklistTemplateRepo := repository.NewChecklistTemplateRepository(db)
+	operationTypeRepo := repository.NewOperationTypeRepository(db)
 	mediaRepo := repository.NewMediaRepository(db)
 	iolRepo := repository.NewIOLRepository(db)
+	iolLensRepo := repository.NewIOLLensRepository(db)
 	surgeryRepo := repository.NewSurgeryRepository(db)
+	postOpRepo := repository.NewPostOpRepository(db)
+	eyeExamRepo := repository.NewEyeExamRepository(db)
+	calendarRepo := repository.NewCalendarRepository(db)
 	commentRepo := repository.NewCommentRepository(db)
 	notifRepo := repository.NewNotificationRepository(db)
 	telegramRepo := repository.NewTelegramRepository(db)
 	telegramTokenRepo := repository.NewTelegramTokenRepository(db)
 	syncRepo := repository.NewSyncRepository(db)
+	outboxRepo := repository.NewIntegrationOutboxRepository(db)
+	consentRepo := repository.NewConsentRepository(db)
+	sessionRepo := repository.NewSessionRepository(db)
+	recoveryCodeRepo := repository.NewRecoveryCodeRepository(db)
+	invitationRepo := repository.NewInvitationRepository(db)
+	userTokenRepo := repository.NewUserTokenRepository(db)
 
 	// --- Storage ---
 	var store storage.Storage
@@ -53,8 +76,52 @@ func NewRouter(cfg *config.Config, db *gorm.DB) *gin.Engine {
 		store = storage.NewLocalStorage(cfg.LocalUploadPath)
 	}
 
+	// --- Redis: счётчики попыток входа по коду доступа и отозванные токены ---
+	var limitStore, denyStore ratelimit.Store
+	if rdb, err := database.NewRedis(cfg); err != nil {
+		log.Warn().Err(err).Msg("Redis недоступен, счётчики попыток входа и отозванные токены хранятся в памяти процесса")
+		limitStore = ratelimit.NewMemoryStore()
+		denyStore = limitStore
+	} else {
+		limitStore = ratelimit.NewRedisStore(rdb, "ratelimit:")
+		denyStore = ratelimit.NewRedisStore(rdb, "jwt:")
+	}
+
+	// --- Ключи подписи JWT ---
+	var jwtKeys *jwtkeys.Keyring
+	if cfg.JWTKeysFile != "" {
+		keys, err := jwtkeys.LoadFile(cfg.JWTKeysFile)
+		if err != nil {
+			log.Fatal().Err(err).Msg("не удалось загрузить ключи подписи JWT")
+		}
+		jwtKeys = keys
+		log.Info().Str("active_key", jwtKeys.ActiveKeyID()).Msg("ключи подписи JWT загружены")
+	}
+	// Токены без kid после перехода на набор ключей принимаются только до JWT_LEGACY_ACCEPT_UNTIL
+	var jwtLegacyUntil time.Time
+	if v := cfg.JWTLegacyAcceptUntil; v != "" {
+		var err error
+		if jwtLegacyUntil, err = time.Parse(time.RFC3339, v); err != nil {
+			if jwtLegacyUntil, err = time.Parse("2006-01-02", v); err != nil {
+				log.Fatal().Str("value", v).Msg("неверный JWT_LEGACY_ACCEPT_UNTIL, используйте ГГГГ-ММ-ДД или RFC 3339")
+			}
+		}
+		if jwtKeys != nil {
+			log.Info().Time("until", jwtLegacyUntil).Msg("токены без kid принимаются до указанного момента")
+		}
+	}
+
+	// --- Почта: приглашения, сброс пароля, подтверждение адреса ---
+	var mailer mail.Sender
+	if cfg.SMTPHost != "" {
+		mailer = mail.NewSMTPSender(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.SMTPFrom, 15*time.Second)
+	} else {
+		log.Warn().Msg("SMTP_HOST не задан, письма не отправляются и только пишутся в лог")
+		mailer = mail.NewMemorySender()
+	}
+
 	// --- Telegram Bot (создаём рано, чтобы передать в сервисы) ---
-	bot, err := telegram.NewBot(cfg.TelegramBotToken, cfg.BaseURL, patientRepo, telegramRepo, telegramTokenRepo, userRepo)
+	bot, err := telegram.NewBot(cfg.TelegramBotToken, cfg.BaseURL, patientRepo, telegramRepo, telegramTokenRepo, userRepo, consentRepo, limitStore)
 	if err != nil {
 		log.Warn().Err(err).Msg("Telegram bot failed to start")
 	}
@@ -66,40 +133,98 @@ func NewRouter(cfg *config.Config, db *gorm.DB) *gin.Engine {
 
 	// --- Services ---
 	auditService := service.NewAuditService(auditRepo)
-	tokenService := service.NewTokenService(cfg)
-	authService := service.NewAuthServiceWithPatient(userRepo, patientRepo, telegramTokenRepo, tokenService)
+	tokenService := service.NewTokenService(cfg, jwtKeys, jwtLegacyUntil)
+	tokenRevocation := service.NewTokenRevocation(denyStore, userRepo, sessionRepo, tokenService.AccessTTL())
+	accessCodeGuard := service.NewAccessCodeGuard(limitStore, auditService)
+	twoFactorGuard := service.NewTwoFactorGuard(limitStore, auditService)
+	authService := service.NewAuthServiceWithPatient(userRepo, patientRepo, telegramTokenRepo, sessionRepo, recoveryCodeRepo, invitationRepo, tokenService, tokenRevocation, accessCodeGuard, twoFactorGuard, auditService, cfg.TOTPIssuer, cfg.PatientLoginBirthDate)
+	accountService := service.NewAccountService(userRepo, invitationRepo, userTokenRepo, districtRepo, tokenRevocation, mailer, limitStore, auditService, cfg.BaseURL)
+	userService := service.NewUserService(userRepo, districtRepo, accountService, tokenRevocation, auditService)
 	districtService := service.NewDistrictService(districtRepo)
-	patientService := service.NewPatientService(db, patientRepo, checklistRepo, notifRepo, bot)
-	checklistService := service.NewChecklistService(checklistRepo, patientRepo, notifRepo, bot)
+	operationTypeService := service.NewOperationTypeService(operationTypeRepo)
+	if err := operationTypeService.Load(context.Background()); err != nil {
+		log.Warn().Err(err).Msg("не удалось загрузить справочник типов операций, используются встроенные значения")
+	}
+	patientService := service.NewPatientService(db, patientRepo, checklistRepo, checklistTemplateRepo, operationTypeRepo, notifRepo, auditService, accessCodeGuard, bot)
+	patientDuplicateService := service.NewPatientDuplicateService(patientRepo, auditService)
+	checklistService := service.NewChecklistService(checklistRepo, patientRepo, notifRepo, auditService, bot)
+	checklistTemplateService := service.NewChecklistTemplateService(db, checklistTemplateRepo, checklistService)
 	mediaService := service.NewMediaService(mediaRepo, store)
-	iolService := service.NewIOLService(iolRepo)
-	surgeryService := service.NewSurgeryService(surgeryRepo, patientRepo, checklistRepo, notifRepo)
+	iolService := service.NewIOLService(iolRepo, iolLensRepo)
+	iolLensService := service.NewIOLLensService(iolLensRepo)
+	biometryService := service.NewBiometryService(patientRepo, checklistRepo, mediaRepo, mediaService, iolService)
+	clinicLoc, err := time.LoadLocation(cfg.ClinicTimezone)
+	if err != nil {
+		log.Warn().Err(err).Str("timezone", cfg.ClinicTimezone).Msg("неизвестный часовой пояс клиники, используется системный")
+		clinicLoc = time.Local
+	}
+	surgeryService := service.NewSurgeryService(db, surgeryRepo, patientRepo, checklistRepo, notifRepo, userRepo, operationTypeRepo, auditService, clinicLoc)
+	calendarService := service.NewCalendarService(calendarRepo, userRepo, clinicLoc)
+	postOpService := service.NewPostOpService(postOpRepo, surgeryRepo, iolRepo, iolLensRepo, userRepo)
+	eyeExamService := service.NewEyeExamService(eyeExamRepo, patientRepo)
 	commentService := service.NewCommentService(commentRepo, patientRepo, userRepo, notifRepo)
 	notifService := service.NewNotificationService(notifRepo)
-	pdfService := service.NewPDFService(patientRepo, checklistRepo)
-	syncService := service.NewSyncService(syncRepo)
+	pdfService := service.NewPDFService(patientRepo, checklistRepo, eyeExamRepo, auditService)
+	syncService := service.NewSyncService(db, syncRepo, checklistService, auditService)
 	medicalStandardsService := service.NewMedicalStandardsService(patientRepo)
-	integrationsService := service.NewIntegrationsService(patientRepo)
+	integrationsService := service.NewIntegrationsService(patientRepo, outboxRepo, consentRepo)
+	consentService := service.NewConsentService(consentRepo, patientRepo, mediaRepo)
+	accessPolicy := service.NewAccessPolicy(userRepo, patientRepo, checklistRepo, mediaRepo, surgeryRepo, eyeExamRepo, postOpRepo, consentRepo)
+	fhirService := service.NewFHIRService(db, patientRepo, checklistRepo, iolRepo, eyeExamRepo, surgeryRepo, districtRepo, userRepo, auditService, accessPolicy)
 
 	// --- Scheduler ---
-	scheduler := service.NewSchedulerService(checklistRepo, surgeryRepo, notifRepo, mediaRepo)
+	scheduler := service.NewSchedulerService(checklistRepo, surgeryRepo, notifRepo, mediaRepo, sessionRepo, userTokenRepo)
 	scheduler.Start()
 
+	// --- Integrations worker ---
+	emiasURL, riamsURL := cfg.EMIASBaseURL, cfg.RIAMSBaseURL
+	if emiasURL == "" || riamsURL == "" {
+		fake := integrations.NewFakeServer()
+		log.Warn().Str("url", fake.URL()).Msg("адреса ЕМИАС/РИАМС не заданы, используется локальный фейковый сервер")
+		if emiasURL == "" {
+			emiasURL = fake.URL()
+		}
+		if riamsURL == "" {
+			riamsURL = fake.URL()
+		}
+	}
+	integrationTimeout := time.Duration(cfg.IntegrationTimeoutSeconds) * time.Second
+	integrationWorker := service.NewIntegrationWorker(outboxRepo, patientRepo, consentRepo,
+		integrations.NewEMIASClient(emiasURL, cfg.EMIASToken, integrationTimeout),
+		integrations.NewRIAMSClient(riamsURL, cfg.RIAMSToken, integrationTimeout),
+	)
+	integrationWorker.Start()
+
+	// --- Media processing ---
+	mediaProcessor := service.NewMediaProcessor(mediaRepo, patientRepo, checklistRepo, store)
+	mediaProcessor.Start()
+
 	// --- Handlers ---
-	authHandler := handler.NewAuthHandler(authService)
+	authHandler := handler.NewAuthHandler(authService, tokenService)
+	accountHandler := handler.NewAccountHandler(accountService)
 	districtHandler := handler.NewDistrictHandler(districtService)
-	patientHandler := handler.NewPatientHandler(patientService)
-	checklistHandler := handler.NewChecklistHandler(checklistService)
-	mediaHandler := handler.NewMediaHandler(mediaService)
-	iolHandler := handler.NewIOLHandler(iolService)
-	surgeryHandler := handler.NewSurgeryHandler(surgeryService)
-	commentHandler := handler.NewCommentHandler(commentService)
+	operationTypeHandler := handler.NewOperationTypeHandler(operationTypeService)
+	patientHandler := handler.NewPatientHandler(patientService, accessPolicy)
+	patientDuplicateHandler := handler.NewPatientDuplicateHandler(patientDuplicateService)
+	checklistHandler := handler.NewChecklistHandler(checklistService, accessPolicy)
+	checklistTemplateHandler := handler.NewChecklistTemplateHandler(checklistTemplateService, accessPolicy)
+	mediaHandler := handler.NewMediaHandler(mediaService, accessPolicy)
+	iolHandler := handler.NewIOLHandler(iolService, biometryService, accessPolicy)
+	iolLensHandler := handler.NewIOLLensHandler(iolLensService)
+	surgeryHandler := handler.NewSurgeryHandler(surgeryService, accessPolicy)
+	postOpHandler := handler.NewPostOpHandler(postOpService, accessPolicy)
+	eyeExamHandler := handler.NewEyeExamHandler(eyeExamService, accessPolicy)
+	calendarHandler := handler.NewCalendarHandler(calendarService)
+	commentHandler := handler.NewCommentHandler(commentService, accessPolicy)
 	notifHandler := handler.NewNotificationHandler(notifService)
-	printHandler := handler.NewPrintHandler(pdfService)
-	syncHandler := handler.NewSyncHandler(syncService)
-	adminHandler := handler.NewAdminHandler(authService, db)
-	medicalStandardsHandler := handler.NewMedicalStandardsHandler(medicalStandardsService)
-	integrationsHandler := handler.NewIntegrationsHandler(integrationsService)
+	printHandler := handler.NewPrintHandler(pdfService, accessPolicy)
+	syncHandler := handler.NewSyncHandler(syncService, accessPolicy)
+	adminHandler := handler.NewAdminHandler(userService, accountService, db)
+	medicalStandardsHandler := handler.NewMedicalStandardsHandler(medicalStandardsService, accessPolicy)
+	integrationsHandler := handler.NewIntegrationsHandler(integrationsService, accessPolicy)
+	fhirHandler := handler.NewFHIRHandler(fhirService, accessPolicy)
+	consentHandler := handler.NewConsentHandler(consentService, accessPolicy)
+	auditHandler := handler.NewAuditHandler(auditService)
 
 	// --- Serve OpenAPI docs ---
 	r.StaticFile("/openapi.json", "./openapi.json")
@@ -128,6 +253,16 @@ func NewRouter(cfg *config.Config, db *gorm.DB) *gin.Engine {
 		c.String(200, patientPortalHTML)
 	})
 
+	// --- Account pages (ссылки из писем) ---
+	for _, path := range []string{"/account/invite", "/account/reset-password", "/account/verify-email"} {
+		r.GET(path, func(c *gin.Context) {
+			c.Header("Content-Type", "text/html")
+			c.String(200, accountHTML)
+		})
+	}
+
+	r.GET("/.well-known/jwks.json", authHandler.JWKS)
+
 	// Public endpoints (no auth required)
 	publicAPI := r.Group("/api/public")
 	{
@@ -144,6 +279,11 @@ func NewRouter(cfg *config.Config, db *gorm.DB) *gin.Engine {
 			auth.POST("/patient-login", authHandler.PatientLogin)
 			auth.POST("/telegram-token-login", authHandler.TelegramTokenLogin)
 			auth.POST("/refresh", authHandler.Refresh)
+			auth.POST("/2fa/verify", authHandler.VerifyTwoFactor)
+			auth.GET("/invitations/:token", accountHandler.GetInvitation)
+			auth.POST("/password/forgot", accountHandler.ForgotPassword)
+			auth.POST("/password/reset", accountHandler.ResetPassword)
+			auth.POST("/email/verify", accountHandler.VerifyEmail)
 		}
 
 		// Public districts (needed for registration)
@@ -152,12 +292,27 @@ func NewRouter(cfg *config.Config, db *gorm.DB) *gin.Engine {
 
 		// Protected routes
 		protected := api.Group("")
-		protected.Use(middleware.Auth(tokenService))
+		protected.Use(middleware.Auth(tokenService, tokenRevocation))
 		protected.Use(middleware.AuditMiddleware(auditService))
 		{
 			// Auth
 			protected.GET("/auth/me", authHandler.Me)
 			protected.POST("/auth/logout", authHandler.Logout)
+
+			// Sessions
+			protected.GET("/auth/sessions", authHandler.ListSessions)
+			protected.DELETE("/auth/sessions", authHandler.RevokeOtherSessions)
+			protected.DELETE("/auth/sessions/:id", authHandler.RevokeSession)
+
+			// Two-factor authentication (staff)
+			protected.POST("/auth/2fa/setup", authHandler.SetupTwoFactor)
+			protected.POST("/auth/2fa/enable", authHandler.EnableTwoFactor)
+			protected.POST("/auth/2fa/disable", authHandler.DisableTwoFactor)
+			protected.POST("/auth/2fa/recovery-codes", authHandler.RegenerateRecoveryCodes)
+
+			// Password and email (staff)
+			protected.POST("/auth/password/change", middleware.RequireRole(staffRoles...), accountHandler.ChangePassword)
+			protected.POST("/auth/email/send-verification", middleware.RequireRole(staffRoles...), accountHandler.SendVerification)
 			protected.GET("/ping", func(c *gin.Context) {
 				userID := middleware.GetUserID(c)
 				c.JSON(200, gin.H{"message": "pong", "user_id": userID})
@@ -172,11 +327,15 @@ func NewRouter(cfg *config.Config, db *gorm.DB) *gin.Engine {
 				districts.DELETE("/:id", districtHandler.Delete)
 			}
 
+			// Operation types (active, for patient forms)
+			protected.GET("/operation-types", operationTypeHandler.ListActive)
+
 			// Patients
 			patients := protected.Group("/patients")
 			{
-				patients.GET("", patientHandler.List)
-				patients.GET("/dashboard", patientHandler.Dashboard)
+				patients.GET("", middleware.RequireRole(staffRoles...), patientHandler.List)
+				patients.GET("/dashboard", middleware.RequireRole(staffRoles...), patientHandler.Dashboard)
+				patients.GET("/search", middleware.RequireRole(staffRoles...), patientHandler.Search)
 				patients.GET("/:id", patientHandler.GetByID)
 				patients.POST("", middleware.RequireRole(domain.RoleDistrictDoctor, domain.RoleAdmin), patientHandler.Create)
 				patients.PATCH("/:id", patientHandler.Update)
@@ -200,11 +359,22 @@ func NewRouter(cfg *config.Config, db *gorm.DB) *gin.Engine {
 			{
 				checklists.GET("/patient/:patientId", checklistHandler.GetByPatient)
 				checklists.GET("/patient/:patientId/progress", checklistHandler.GetProgress)
+				checklists.POST("/patient/:patientId/upgrade", middleware.RequireRole(domain.RoleDistrictDoctor, domain.RoleSurgeon, domain.RoleAdmin), checklistTemplateHandler.UpgradePatient)
 				checklists.POST("", middleware.RequireRole(domain.RoleDistrictDoctor, domain.RoleSurgeon, domain.RoleAdmin), checklistHandler.CreateItem)
 				checklists.PATCH("/:id", checklistHandler.UpdateItem)
 				checklists.POST("/:id/review", middleware.RequireRole(domain.RoleSurgeon, domain.RoleAdmin), checklistHandler.ReviewItem)
 			}
 
+			// Eye examinations
+			eyeExams := protected.Group("/eye-exams")
+			{
+				eyeExams.GET("/patient/:patientId", eyeExamHandler.History)
+				eyeExams.GET("/:id", eyeExamHandler.GetByID)
+				eyeExams.POST("", middleware.RequireRole(domain.RoleDistrictDoctor, domain.RoleSurgeon, domain.RoleAdmin), eyeExamHandler.Create)
+				eyeExams.PATCH("/:id", middleware.RequireRole(domain.RoleDistrictDoctor, domain.RoleSurgeon, domain.RoleAdmin), eyeExamHandler.Update)
+				eyeExams.DELETE("/:id", middleware.RequireRole(domain.RoleDistrictDoctor, domain.RoleSurgeon, domain.RoleAdmin), eyeExamHandler.Delete)
+			}
+
 			// Media
 			media := protected.Group("/media")
 			{
@@ -220,17 +390,51 @@ func NewRouter(cfg *config.Config, db *gorm.DB) *gin.Engine {
 			iol := protected.Group("/iol")
 			{
 				iol.POST("/calculate", iolHandler.Calculate)
+				iol.POST("/compare", iolHandler.Compare)
+				iol.POST("/toric", iolHandler.Toric)
+				iol.GET("/lenses", iolLensHandler.ListActive)
+				iol.POST("/import", iolHandler.Import)
 				iol.GET("/patient/:patientId/history", iolHandler.History)
+				iol.GET("/outcomes", middleware.RequireRole(domain.RoleSurgeon, domain.RoleAdmin), postOpHandler.Stats)
+				iol.GET("/outcomes/a-constant", middleware.RequireRole(domain.RoleSurgeon, domain.RoleAdmin), postOpHandler.OptimizeAConstant)
 			}
 
 			// Surgeries (SURGEON only for creation)
 			surgeries := protected.Group("/surgeries")
 			{
-				surgeries.GET("", surgeryHandler.List)
+				surgeries.GET("", middleware.RequireRole(staffRoles...), surgeryHandler.List)
+				surgeries.GET("/available-slots", calendarHandler.AvailableSlots)
 				surgeries.GET("/:id", surgeryHandler.GetByID)
 				surgeries.POST("", middleware.RequireRole(domain.RoleSurgeon, domain.RoleAdmin), surgeryHandler.Schedule)
 				surgeries.PATCH("/:id", middleware.RequireRole(domain.RoleSurgeon, domain.RoleAdmin), surgeryHandler.Update)
 				surgeries.DELETE("/:id", middleware.RequireRole(domain.RoleSurgeon, domain.RoleAdmin), surgeryHandler.Delete)
+				surgeries.GET("/:id/postop-visits", postOpHandler.List)
+				surgeries.POST("/:id/postop-visits", middleware.RequireRole(domain.RoleDistrictDoctor, domain.RoleSurgeon, domain.RoleAdmin), postOpHandler.Create)
+			}
+
+			// Post-op visits
+			postOpVisits := protected.Group("/postop-visits")
+			postOpVisits.Use(middleware.RequireRole(domain.RoleDistrictDoctor, domain.RoleSurgeon, domain.RoleAdmin))
+			{
+				postOpVisits.PATCH("/:id", postOpHandler.Update)
+				postOpVisits.DELETE("/:id", postOpHandler.Delete)
+			}
+
+			// Operating rooms
+			rooms := protected.Group("/operating-rooms")
+			{
+				rooms.GET("", calendarHandler.ListRooms)
+				rooms.POST("", middleware.RequireRole(domain.RoleAdmin), calendarHandler.CreateRoom)
+				rooms.PATCH("/:id", middleware.RequireRole(domain.RoleAdmin), calendarHandler.UpdateRoom)
+			}
+
+			// Surgeon calendars (admin or the surgeon themself for edits)
+			surgeons := protected.Group("/surgeons")
+			{
+				surgeons.GET("/:id/calendar", calendarHandler.GetSurgeonCalendar)
+				surgeons.PUT("/:id/working-hours", middleware.RequireRole(domain.RoleSurgeon, domain.RoleAdmin), calendarHandler.SetWorkingHours)
+				surgeons.POST("/:id/time-off", middleware.RequireRole(domain.RoleSurgeon, domain.RoleAdmin), calendarHandler.AddTimeOff)
+				surgeons.DELETE("/:id/time-off/:timeOffId", middleware.RequireRole(domain.RoleSurgeon, domain.RoleAdmin), calendarHandler.DeleteTimeOff)
 			}
 
 			// Comments
@@ -261,8 +465,17 @@ func NewRouter(cfg *config.Config, db *gorm.DB) *gin.Engine {
 			// Sync
 			sync := protected.Group("/sync")
 			{
-				sync.POST("/push", syncHandler.Push)
-				sync.GET("/pull", syncHandler.Pull)
+				sync.POST("/push", middleware.RequireRole(domain.RoleDistrictDoctor, domain.RoleSurgeon, domain.RoleAdmin), syncHandler.Push)
+				sync.GET("/pull", middleware.RequireRole(domain.RoleDistrictDoctor, domain.RoleSurgeon, domain.RoleAdmin, domain.RoleCallCenter), syncHandler.Pull)
+			}
+
+			// Consents (пациент — свои из кабинета, сотрудник — сканы бланков)
+			consents := protected.Group("/consents")
+			{
+				consents.GET("/texts", consentHandler.Texts)
+				consents.GET("/patient/:patientId", consentHandler.ListByPatient)
+				consents.POST("/patient/:patientId", consentHandler.Capture)
+				consents.POST("/:id/revoke", consentHandler.Revoke)
 			}
 
 			// Integrations
@@ -285,12 +498,52 @@ func NewRouter(cfg *config.Config, db *gorm.DB) *gin.Engine {
 				}
 			}
 
+			// FHIR R4
+			fhirAPI := protected.Group("/fhir")
+			{
+				fhirAPI.GET("/Patient/:id", fhirHandler.GetPatient)
+				fhirAPI.GET("/Patient/:id/$everything", fhirHandler.Everything)
+				fhirAPI.GET("/Condition", fhirHandler.SearchConditions)
+				fhirAPI.GET("/Procedure", fhirHandler.SearchProcedures)
+				fhirAPI.GET("/Procedure/:id", fhirHandler.GetProcedure)
+				fhirAPI.GET("/Observation", fhirHandler.SearchObservations)
+				fhirAPI.POST("/Bundle", middleware.RequireRole(domain.RoleDistrictDoctor, domain.RoleSurgeon, domain.RoleAdmin), fhirHandler.ImportBundle)
+			}
+
 			// Admin
 			admin := protected.Group("/admin")
 			admin.Use(middleware.RequireRole(domain.RoleAdmin))
 			{
 				admin.GET("/users", adminHandler.ListUsers)
+				admin.POST("/users/import", adminHandler.ImportUsers)
+				admin.GET("/users/:id", adminHandler.GetUser)
+				admin.PATCH("/users/:id", adminHandler.UpdateUser)
+				admin.DELETE("/users/:id", adminHandler.DeactivateUser)
+				admin.POST("/users/:id/activate", adminHandler.ActivateUser)
+				admin.POST("/users/:id/reset-password", adminHandler.ResetUserPassword)
+				admin.GET("/invitations", accountHandler.ListInvitations)
+				admin.POST("/invitations", accountHandler.CreateInvitation)
+				admin.DELETE("/invitations/:id", accountHandler.RevokeInvitation)
 				admin.GET("/stats", adminHandler.Stats)
+				admin.GET("/audit", auditHandler.List)
+				admin.GET("/patients/duplicates", patientDuplicateHandler.Report)
+				admin.POST("/patients/merge", patientDuplicateHandler.Merge)
+
+				admin.GET("/operation-types", operationTypeHandler.ListAll)
+				admin.POST("/operation-types", operationTypeHandler.Create)
+				admin.PATCH("/operation-types/:id", operationTypeHandler.Update)
+				admin.DELETE("/operation-types/:id", operationTypeHandler.Deactivate)
+				admin.GET("/iol-lenses", iolLensHandler.ListAll)
+				admin.POST("/iol-lenses", iolLensHandler.Create)
+				admin.PATCH("/iol-lenses/:id", iolLensHandler.Update)
+				admin.DELETE("/iol-lenses/:id", iolLensHandler.Deactivate)
+
+				admin.GET("/checklist-templates", checklistTemplateHandler.List)
+				admin.GET("/checklist-templates/:id", checklistTemplateHandler.GetByID)
+				admin.POST("/checklist-templates", checklistTemplateHandler.Create)
+				admin.PATCH("/checklist-templates/:id", checklistTemplateHandler.Update)
+				admin.DELETE("/checklist-templates/:id", checklistTemplateHandler.Delete)
+				admin.POST("/checklist-templates/:id/activate", checklistTemplateHandler.Activate)
 			}
 		}
 	}
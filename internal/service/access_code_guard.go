@@ -0,0 +1,161 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/beercut-team/backend-boilerplate/internal/domain"
+	"github.com/beercut-team/backend-boilerplate/pkg/ratelimit"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+// Откуда пришла попытка входа по коду доступа
+const (
+	AccessCodeScopeLogin  = "patient_login"
+	AccessCodeScopeStatus = "public_status"
+)
+
+// Пороги защиты от перебора. Код — 8 hex-символов: без ограничений его
+// подбирают за часы. Попытки считаются по IP и по началу кода — второе
+// ловит перебор одного диапазона кодов с множества адресов.
+const (
+	ipFailWindow         = 15 * time.Minute
+	ipChallengeAfter     = 3
+	ipLockAfter          = 10
+	ipLockFor            = 15 * time.Minute
+	prefixFailWindow     = time.Hour
+	prefixChallengeAfter = 5
+	prefixLockAfter      = 30
+	prefixLockFor        = 30 * time.Minute
+
+	workDifficulty = 16 // ~65 тыс. хешей, около секунды в браузере
+	workTTL        = 2 * time.Minute
+)
+
+// AccessCodeLockedError — слишком много неудачных попыток, повторить через RetryAfter
+type AccessCodeLockedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *AccessCodeLockedError) Error() string {
+	return fmt.Sprintf("слишком много неудачных попыток, повторите через %d мин", int(e.RetryAfter.Minutes())+1)
+}
+
+// ChallengeRequiredError — перед следующей попыткой нужно решить задачу
+type ChallengeRequiredError struct {
+	Challenge domain.LoginChallenge
+}
+
+func (e *ChallengeRequiredError) Error() string {
+	return "слишком много неудачных попыток, подтвердите, что вы не робот"
+}
+
+type AccessCodeGuard interface {
+	// Check вызывается до поиска по коду: *AccessCodeLockedError при блокировке,
+	// *ChallengeRequiredError, если нужна задача, а решения нет или оно неверно
+	Check(ctx context.Context, ip, code string, proof domain.ChallengeProof) error
+	// Fail учитывает неудачную попытку и пишет её в журнал аудита
+	Fail(ctx context.Context, scope, ip, code, reason string)
+}
+
+type accessCodeGuard struct {
+	store ratelimit.Store
+	audit AuditService
+}
+
+func NewAccessCodeGuard(store ratelimit.Store, audit AuditService) AccessCodeGuard {
+	return &accessCodeGuard{store: store, audit: audit}
+}
+
+// Ошибки хранилища не блокируют вход: защита ослабевает, но пациенты не теряют доступ
+func (g *accessCodeGuard) Check(ctx context.Context, ip, code string, proof domain.ChallengeProof) error {
+	prefix := domain.AccessCodePrefix(code)
+
+	for _, key := range []string{"ac:lock:ip:" + ip, "ac:lock:prefix:" + prefix} {
+		ttl, err := g.store.TTL(ctx, key)
+		if err != nil {
+			log.Error().Err(err).Msg("защита входа: не удалось проверить блокировку")
+			continue
+		}
+		if ttl > 0 {
+			return &AccessCodeLockedError{RetryAfter: ttl}
+		}
+	}
+
+	ipFails, err := g.store.Count(ctx, "ac:fail:ip:"+ip)
+	if err != nil {
+		log.Error().Err(err).Msg("защита входа: не удалось прочитать счётчик")
+	}
+	prefixFails, err := g.store.Count(ctx, "ac:fail:prefix:"+prefix)
+	if err != nil {
+		log.Error().Err(err).Msg("защита входа: не удалось прочитать счётчик")
+	}
+	if ipFails < ipChallengeAfter && prefixFails < prefixChallengeAfter {
+		return nil
+	}
+
+	if !proof.Empty() {
+		nonce, ok, err := g.store.Take(ctx, "ac:challenge:"+proof.ChallengeID)
+		if err != nil {
+			log.Error().Err(err).Msg("защита входа: не удалось прочитать задачу")
+			return nil
+		}
+		if ok && ratelimit.VerifyWork(nonce, proof.ChallengeSolution, workDifficulty) {
+			return nil
+		}
+	}
+	return g.newChallenge(ctx)
+}
+
+func (g *accessCodeGuard) newChallenge(ctx context.Context) error {
+	nonce, err := ratelimit.NewNonce()
+	if err != nil {
+		return err
+	}
+	challenge := domain.LoginChallenge{
+		ID:         uuid.NewString(),
+		Nonce:      nonce,
+		Difficulty: workDifficulty,
+		ExpiresIn:  int(workTTL / time.Second),
+	}
+	if err := g.store.Put(ctx, "ac:challenge:"+challenge.ID, nonce, workTTL); err != nil {
+		log.Error().Err(err).Msg("защита входа: не удалось сохранить задачу")
+		return nil
+	}
+	return &ChallengeRequiredError{Challenge: challenge}
+}
+
+func (g *accessCodeGuard) Fail(ctx context.Context, scope, ip, code, reason string) {
+	prefix := domain.AccessCodePrefix(code)
+	masked := domain.MaskAccessCode(code)
+
+	g.audit.LogAction(ctx, 0, domain.AuditActionLoginFailed, domain.AuditEntityAccessCode, 0, nil,
+		map[string]string{"scope": scope, "code_prefix": masked, "reason": reason}, ip)
+
+	ipFails, err := g.store.Incr(ctx, "ac:fail:ip:"+ip, ipFailWindow)
+	if err != nil {
+		log.Error().Err(err).Msg("защита входа: не удалось увеличить счётчик")
+	} else if ipFails >= ipLockAfter {
+		g.lock(ctx, scope, ip, "ac:lock:ip:"+ip, ipLockFor, map[string]string{"target": "ip"})
+	}
+
+	prefixFails, err := g.store.Incr(ctx, "ac:fail:prefix:"+prefix, prefixFailWindow)
+	if err != nil {
+		log.Error().Err(err).Msg("защита входа: не удалось увеличить счётчик")
+	} else if prefixFails >= prefixLockAfter {
+		g.lock(ctx, scope, ip, "ac:lock:prefix:"+prefix, prefixLockFor, map[string]string{"target": "code_prefix", "code_prefix": masked})
+	}
+}
+
+func (g *accessCodeGuard) lock(ctx context.Context, scope, ip, key string, ttl time.Duration, details map[string]string) {
+	if err := g.store.Lock(ctx, key, ttl); err != nil {
+		log.Error().Err(err).Msg("защита входа: не удалось установить блокировку")
+		return
+	}
+	details["scope"] = scope
+	details["duration"] = ttl.String()
+	log.Warn().Str("ip", ip).Str("target", details["target"]).Dur("duration", ttl).Msg("вход по коду доступа заблокирован")
+	g.audit.LogAction(ctx, 0, domain.AuditActionLockout, domain.AuditEntityAccessCode, 0, nil, details, ip)
+}
@@ -0,0 +1,167 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/beercut-team/backend-boilerplate/internal/domain"
+	"github.com/beercut-team/backend-boilerplate/internal/repository"
+	"gorm.io/gorm"
+)
+
+// ErrAccessDenied — пользователь не имеет доступа к пациенту
+var ErrAccessDenied = errors.New("нет доступа к данным пациента")
+
+// notFoundError — объект, к которому проверяется доступ или который запрошен, не найден
+type notFoundError string
+
+func (e notFoundError) Error() string { return string(e) }
+
+// IsNotFound сообщает, что проверка доступа или сервис не нашли пациента или связанный объект
+func IsNotFound(err error) bool {
+	var nf notFoundError
+	return errors.As(err, &nf)
+}
+
+// AccessPolicy — проверка доступа к пациенту и связанным с ним объектам.
+// Возвращает ErrAccessDenied, ошибку IsNotFound или ошибку БД.
+type AccessPolicy interface {
+	CanAccessPatient(ctx context.Context, userID uint, role domain.Role, patientID uint, action domain.PatientAction) error
+	CanAccessChecklistItem(ctx context.Context, userID uint, role domain.Role, itemID uint, action domain.PatientAction) error
+	CanAccessMedia(ctx context.Context, userID uint, role domain.Role, mediaID uint, action domain.PatientAction) error
+	CanAccessSurgery(ctx context.Context, userID uint, role domain.Role, surgeryID uint, action domain.PatientAction) error
+	CanAccessEyeExam(ctx context.Context, userID uint, role domain.Role, examID uint, action domain.PatientAction) error
+	CanAccessPostOpVisit(ctx context.Context, userID uint, role domain.Role, visitID uint, action domain.PatientAction) error
+	CanAccessConsent(ctx context.Context, userID uint, role domain.Role, consentID uint, action domain.PatientAction) error
+	// Actor загружает данные пользователя, нужные domain.CanAccessPatient и domain.ReadScope;
+	// для массовых проверок и запросов, где загрузка пациента по одному не подходит
+	Actor(ctx context.Context, userID uint, role domain.Role) (domain.Actor, error)
+}
+
+type accessPolicy struct {
+	userRepo      repository.UserRepository
+	patientRepo   repository.PatientRepository
+	checklistRepo repository.ChecklistRepository
+	mediaRepo     repository.MediaRepository
+	surgeryRepo   repository.SurgeryRepository
+	eyeExamRepo   repository.EyeExamRepository
+	postOpRepo    repository.PostOpRepository
+	consentRepo   repository.ConsentRepository
+}
+
+func NewAccessPolicy(userRepo repository.UserRepository, patientRepo repository.PatientRepository, checklistRepo repository.ChecklistRepository, mediaRepo repository.MediaRepository, surgeryRepo repository.SurgeryRepository, eyeExamRepo repository.EyeExamRepository, postOpRepo repository.PostOpRepository, consentRepo repository.ConsentRepository) AccessPolicy {
+	return &accessPolicy{
+		userRepo:      userRepo,
+		patientRepo:   patientRepo,
+		checklistRepo: checklistRepo,
+		mediaRepo:     mediaRepo,
+		surgeryRepo:   surgeryRepo,
+		eyeExamRepo:   eyeExamRepo,
+		postOpRepo:    postOpRepo,
+		consentRepo:   consentRepo,
+	}
+}
+
+func (p *accessPolicy) CanAccessPatient(ctx context.Context, userID uint, role domain.Role, patientID uint, action domain.PatientAction) error {
+	patient, err := p.patientRepo.FindByID(ctx, patientID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return notFoundError("пациент не найден")
+		}
+		return err
+	}
+
+	actor, err := p.Actor(ctx, userID, role)
+	if err != nil {
+		return err
+	}
+	if !domain.CanAccessPatient(actor, patient, action) {
+		return ErrAccessDenied
+	}
+	return nil
+}
+
+func (p *accessPolicy) CanAccessChecklistItem(ctx context.Context, userID uint, role domain.Role, itemID uint, action domain.PatientAction) error {
+	item, err := p.checklistRepo.FindItemByID(ctx, itemID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return notFoundError("пункт чек-листа не найден")
+		}
+		return err
+	}
+	return p.CanAccessPatient(ctx, userID, role, item.PatientID, action)
+}
+
+func (p *accessPolicy) CanAccessMedia(ctx context.Context, userID uint, role domain.Role, mediaID uint, action domain.PatientAction) error {
+	media, err := p.mediaRepo.FindByID(ctx, mediaID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return notFoundError("медиафайл не найден")
+		}
+		return err
+	}
+	return p.CanAccessPatient(ctx, userID, role, media.PatientID, action)
+}
+
+func (p *accessPolicy) CanAccessSurgery(ctx context.Context, userID uint, role domain.Role, surgeryID uint, action domain.PatientAction) error {
+	surgery, err := p.surgeryRepo.FindByID(ctx, surgeryID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return notFoundError("операция не найдена")
+		}
+		return err
+	}
+	return p.CanAccessPatient(ctx, userID, role, surgery.PatientID, action)
+}
+
+func (p *accessPolicy) CanAccessEyeExam(ctx context.Context, userID uint, role domain.Role, examID uint, action domain.PatientAction) error {
+	exam, err := p.eyeExamRepo.FindByID(ctx, examID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return notFoundError("осмотр не найден")
+		}
+		return err
+	}
+	return p.CanAccessPatient(ctx, userID, role, exam.PatientID, action)
+}
+
+// CanAccessPostOpVisit проверяет доступ к операции, по которой записан осмотр
+func (p *accessPolicy) CanAccessPostOpVisit(ctx context.Context, userID uint, role domain.Role, visitID uint, action domain.PatientAction) error {
+	visit, err := p.postOpRepo.FindByID(ctx, visitID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return notFoundError("осмотр не найден")
+		}
+		return err
+	}
+	return p.CanAccessSurgery(ctx, userID, role, visit.SurgeryID, action)
+}
+
+func (p *accessPolicy) CanAccessConsent(ctx context.Context, userID uint, role domain.Role, consentID uint, action domain.PatientAction) error {
+	consent, err := p.consentRepo.FindByID(ctx, consentID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return notFoundError("согласие не найдено")
+		}
+		return err
+	}
+	return p.CanAccessPatient(ctx, userID, role, consent.PatientID, action)
+}
+
+// Actor загружает район сотрудника; для пациента ID токена — это ID пациента
+func (p *accessPolicy) Actor(ctx context.Context, userID uint, role domain.Role) (domain.Actor, error) {
+	actor := domain.Actor{ID: userID, Role: role}
+	if role != domain.RoleDistrictDoctor {
+		return actor, nil
+	}
+
+	user, err := p.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return actor, ErrAccessDenied
+		}
+		return actor, err
+	}
+	actor.DistrictID = user.DistrictID
+	return actor, nil
+}
@@ -0,0 +1,362 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/beercut-team/backend-boilerplate/internal/domain"
+	"github.com/beercut-team/backend-boilerplate/internal/repository"
+	"github.com/beercut-team/backend-boilerplate/pkg/mail"
+	"github.com/beercut-team/backend-boilerplate/pkg/ratelimit"
+	"github.com/rs/zerolog/log"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// Писем со ссылками на один адрес за час: защита от рассылки чужим людям
+const (
+	mailsPerHour = 3
+	mailWindow   = time.Hour
+)
+
+// AccountService — приглашения сотрудников, сброс и смена пароля, подтверждение адреса
+type AccountService interface {
+	// Invite создаёт приглашение и отправляет ссылку; прежние неотвеченные
+	// приглашения на тот же адрес отзываются
+	Invite(ctx context.Context, req domain.CreateInvitationRequest, adminID uint) (*domain.Invitation, error)
+	ListInvitations(ctx context.Context, status string, offset, limit int) ([]domain.Invitation, int64, error)
+	RevokeInvitation(ctx context.Context, id uint) error
+	// GetInvitation — данные приглашения для формы регистрации
+	GetInvitation(ctx context.Context, token string) (*domain.InvitationInfo, error)
+
+	// ForgotPassword не сообщает, зарегистрирован ли адрес
+	ForgotPassword(ctx context.Context, req domain.ForgotPasswordRequest) error
+	ResetPassword(ctx context.Context, req domain.ResetPasswordRequest) error
+	// SendPasswordReset — ссылка сброса по запросу администратора; пароль администратор не видит
+	SendPasswordReset(ctx context.Context, userID uint) error
+	// ChangePassword завершает остальные сессии сотрудника
+	ChangePassword(ctx context.Context, userID, sessionID uint, req domain.ChangePasswordRequest) error
+
+	SendVerification(ctx context.Context, userID uint) error
+	VerifyEmail(ctx context.Context, req domain.VerifyEmailRequest) error
+}
+
+type accountService struct {
+	userRepo       repository.UserRepository
+	invitationRepo repository.InvitationRepository
+	tokenRepo      repository.UserTokenRepository
+	districtRepo   repository.DistrictRepository
+	revocation     TokenRevocation
+	mailer         mail.Sender
+	limits         ratelimit.Store
+	audit          AuditService
+	baseURL        string
+}
+
+func NewAccountService(userRepo repository.UserRepository, invitationRepo repository.InvitationRepository, tokenRepo repository.UserTokenRepository, districtRepo repository.DistrictRepository, revocation TokenRevocation, mailer mail.Sender, limits ratelimit.Store, audit AuditService, baseURL string) AccountService {
+	return &accountService{
+		userRepo:       userRepo,
+		invitationRepo: invitationRepo,
+		tokenRepo:      tokenRepo,
+		districtRepo:   districtRepo,
+		revocation:     revocation,
+		mailer:         mailer,
+		limits:         limits,
+		audit:          audit,
+		baseURL:        strings.TrimRight(baseURL, "/"),
+	}
+}
+
+func (s *accountService) Invite(ctx context.Context, req domain.CreateInvitationRequest, adminID uint) (*domain.Invitation, error) {
+	email := domain.NormalizeEmail(req.Email)
+	// Пациенты входят по коду доступа, учётные записи им не нужны
+	if !domain.ValidRole(req.Role) || req.Role == domain.RolePatient {
+		return nil, errors.New("недопустимая роль")
+	}
+	if _, err := s.districtRepo.FindByID(ctx, req.DistrictID); err != nil {
+		return nil, errors.New("район не найден")
+	}
+	if existing, _ := s.userRepo.FindByEmail(ctx, email); existing != nil {
+		return nil, errors.New("этот email уже зарегистрирован")
+	}
+
+	token, hash, err := domain.NewLinkToken()
+	if err != nil {
+		return nil, errors.New("не удалось создать приглашение")
+	}
+	inv := &domain.Invitation{
+		Email:      email,
+		Name:       strings.TrimSpace(req.Name),
+		Role:       req.Role,
+		DistrictID: req.DistrictID,
+		TokenHash:  hash,
+		InvitedBy:  adminID,
+		ExpiresAt:  time.Now().Add(domain.InvitationTTL),
+	}
+	if err := s.invitationRepo.Create(ctx, inv); err != nil {
+		return nil, errors.New("не удалось создать приглашение")
+	}
+	inv.Status = domain.InvitationPending
+	s.audit.Record(ctx, domain.AuditActionCreate, domain.AuditEntityInvitation, inv.ID, nil, inv)
+
+	err = s.mailer.Send(ctx, mail.Message{
+		To:      email,
+		Subject: "Приглашение в Oculus-Feldsher",
+		Body: fmt.Sprintf("Здравствуйте!\n\nВас пригласили в Oculus-Feldsher. Чтобы завершить регистрацию и задать пароль, перейдите по ссылке:\n\n%s\n\nСсылка действует до %s. Если вы не ждали приглашения, просто проигнорируйте это письмо.\n",
+			s.link("/account/invite", token), inv.ExpiresAt.Format("02.01.2006 15:04 MST")),
+	})
+	if err != nil {
+		log.Error().Err(err).Uint("invitation_id", inv.ID).Msg("не удалось отправить приглашение")
+		return nil, errors.New("приглашение создано, но письмо не отправлено; повторите приглашение")
+	}
+	return inv, nil
+}
+
+func (s *accountService) ListInvitations(ctx context.Context, status string, offset, limit int) ([]domain.Invitation, int64, error) {
+	invitations, total, err := s.invitationRepo.List(ctx, status, offset, limit)
+	if err != nil {
+		return nil, 0, errors.New("не удалось получить приглашения")
+	}
+	now := time.Now()
+	for i := range invitations {
+		invitations[i].Status = invitations[i].CurrentStatus(now)
+	}
+	return invitations, total, nil
+}
+
+func (s *accountService) RevokeInvitation(ctx context.Context, id uint) error {
+	inv, err := s.invitationRepo.FindByID(ctx, id)
+	if err != nil {
+		return errors.New("приглашение не найдено")
+	}
+	revoked, err := s.invitationRepo.Revoke(ctx, id)
+	if err != nil {
+		return errors.New("не удалось отозвать приглашение")
+	}
+	if !revoked {
+		return errors.New("приглашение уже принято или отозвано")
+	}
+	before := *inv
+	now := time.Now()
+	inv.RevokedAt = &now
+	s.audit.Record(ctx, domain.AuditActionUpdate, domain.AuditEntityInvitation, inv.ID, &before, inv)
+	return nil
+}
+
+func (s *accountService) GetInvitation(ctx context.Context, token string) (*domain.InvitationInfo, error) {
+	inv, err := s.invitationRepo.FindByTokenHash(ctx, domain.HashToken(token))
+	if err != nil || inv.CurrentStatus(time.Now()) != domain.InvitationPending {
+		return nil, errors.New("приглашение недействительно или истекло")
+	}
+	return &domain.InvitationInfo{
+		Email:      inv.Email,
+		Name:       inv.Name,
+		Role:       inv.Role,
+		DistrictID: inv.DistrictID,
+		ExpiresAt:  inv.ExpiresAt,
+	}, nil
+}
+
+func (s *accountService) ForgotPassword(ctx context.Context, req domain.ForgotPasswordRequest) error {
+	email := domain.NormalizeEmail(req.Email)
+	if !s.allowMail(ctx, email) {
+		return nil
+	}
+	user, err := s.userRepo.FindByEmail(ctx, email)
+	if err != nil || !user.IsActive {
+		return nil
+	}
+
+	if err := s.sendPasswordReset(ctx, user); err != nil {
+		log.Error().Err(err).Uint("user_id", user.ID).Msg("не удалось отправить письмо сброса пароля")
+	}
+	return nil
+}
+
+func (s *accountService) SendPasswordReset(ctx context.Context, userID uint) error {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return errors.New("пользователь не найден")
+	}
+	if !user.IsActive {
+		return errors.New("пользователь деактивирован")
+	}
+	if err := s.sendPasswordReset(ctx, user); err != nil {
+		log.Error().Err(err).Uint("user_id", user.ID).Msg("не удалось отправить письмо сброса пароля")
+		return errors.New("не удалось отправить письмо")
+	}
+	return nil
+}
+
+func (s *accountService) sendPasswordReset(ctx context.Context, user *domain.User) error {
+	token, err := s.issueToken(ctx, user.ID, domain.UserTokenPasswordReset, domain.PasswordResetTTL)
+	if err != nil {
+		return err
+	}
+	return s.mailer.Send(ctx, mail.Message{
+		To:      user.Email,
+		Subject: "Сброс пароля Oculus-Feldsher",
+		Body: fmt.Sprintf("Здравствуйте, %s!\n\nЧтобы задать новый пароль, перейдите по ссылке:\n\n%s\n\nСсылка действует один час и только один раз. Если вы не запрашивали сброс, проигнорируйте письмо — пароль останется прежним.\n",
+			user.Name, s.link("/account/reset-password", token)),
+	})
+}
+
+func (s *accountService) ResetPassword(ctx context.Context, req domain.ResetPasswordRequest) error {
+	token, err := s.tokenRepo.FindActive(ctx, domain.HashToken(req.Token), domain.UserTokenPasswordReset)
+	if err != nil {
+		return errors.New("ссылка недействительна или истекла")
+	}
+	user, err := s.userRepo.FindByID(ctx, token.UserID)
+	if err != nil || !user.IsActive {
+		return errors.New("ссылка недействительна или истекла")
+	}
+	// Пароль проверяется до использования ссылки, чтобы при ошибке не запрашивать новую
+	if err := domain.ValidatePassword(req.Password, user.Email, user.FirstName, user.LastName); err != nil {
+		return err
+	}
+	used, err := s.tokenRepo.MarkUsed(ctx, token.ID)
+	if err != nil || !used {
+		return errors.New("ссылка недействительна или истекла")
+	}
+
+	// Письмо дошло до владельца адреса — адрес подтверждён
+	if err := s.setPassword(ctx, user, req.Password, true); err != nil {
+		return err
+	}
+	if err := s.revocation.RevokeAll(ctx, user.ID, domain.SessionSubjectUser, 0, domain.SessionRevokedPassword); err != nil {
+		log.Error().Err(err).Uint("user_id", user.ID).Msg("не удалось завершить сессии после сброса пароля")
+	}
+	return nil
+}
+
+func (s *accountService) ChangePassword(ctx context.Context, userID, sessionID uint, req domain.ChangePasswordRequest) error {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return errors.New("пользователь не найден")
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.CurrentPassword)); err != nil {
+		return errors.New("неверный текущий пароль")
+	}
+	if req.NewPassword == req.CurrentPassword {
+		return errors.New("новый пароль совпадает с текущим")
+	}
+	if err := domain.ValidatePassword(req.NewPassword, user.Email, user.FirstName, user.LastName); err != nil {
+		return err
+	}
+
+	if err := s.setPassword(ctx, user, req.NewPassword, false); err != nil {
+		return err
+	}
+	if err := s.revocation.RevokeAll(ctx, user.ID, domain.SessionSubjectUser, sessionID, domain.SessionRevokedPassword); err != nil {
+		log.Error().Err(err).Uint("user_id", user.ID).Msg("не удалось завершить сессии после смены пароля")
+	}
+	return nil
+}
+
+func (s *accountService) SendVerification(ctx context.Context, userID uint) error {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return errors.New("пользователь не найден")
+	}
+	if user.EmailVerifiedAt != nil {
+		return errors.New("адрес уже подтверждён")
+	}
+	if !s.allowMail(ctx, domain.NormalizeEmail(user.Email)) {
+		return errors.New("слишком много писем, попробуйте через час")
+	}
+
+	token, err := s.issueToken(ctx, user.ID, domain.UserTokenEmailVerify, domain.EmailVerifyTTL)
+	if err != nil {
+		return errors.New("не удалось создать ссылку подтверждения")
+	}
+	err = s.mailer.Send(ctx, mail.Message{
+		To:      user.Email,
+		Subject: "Подтверждение адреса Oculus-Feldsher",
+		Body: fmt.Sprintf("Здравствуйте, %s!\n\nПодтвердите адрес электронной почты, перейдя по ссылке:\n\n%s\n\nСсылка действует 48 часов.\n",
+			user.Name, s.link("/account/verify-email", token)),
+	})
+	if err != nil {
+		log.Error().Err(err).Uint("user_id", user.ID).Msg("не удалось отправить письмо подтверждения")
+		return errors.New("не удалось отправить письмо")
+	}
+	return nil
+}
+
+func (s *accountService) VerifyEmail(ctx context.Context, req domain.VerifyEmailRequest) error {
+	token, err := s.tokenRepo.FindActive(ctx, domain.HashToken(req.Token), domain.UserTokenEmailVerify)
+	if err != nil {
+		return errors.New("ссылка недействительна или истекла")
+	}
+	used, err := s.tokenRepo.MarkUsed(ctx, token.ID)
+	if err != nil || !used {
+		return errors.New("ссылка недействительна или истекла")
+	}
+
+	user, err := s.userRepo.FindByID(ctx, token.UserID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("пользователь не найден")
+		}
+		return errors.New("не удалось найти пользователя")
+	}
+	if user.EmailVerifiedAt != nil {
+		return nil
+	}
+	before := *user
+	now := time.Now()
+	user.EmailVerifiedAt = &now
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return errors.New("не удалось подтвердить адрес")
+	}
+	s.audit.Record(ctx, domain.AuditActionUpdate, domain.AuditEntityUser, user.ID, &before, user)
+	return nil
+}
+
+func (s *accountService) setPassword(ctx context.Context, user *domain.User, password string, verifyEmail bool) error {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return errors.New("ошибка хеширования пароля")
+	}
+	before := *user
+	user.PasswordHash = string(hash)
+	if verifyEmail && user.EmailVerifiedAt == nil {
+		now := time.Now()
+		user.EmailVerifiedAt = &now
+	}
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return errors.New("не удалось сохранить пароль")
+	}
+	s.audit.Record(ctx, domain.AuditActionPasswordChange, domain.AuditEntityUser, user.ID, &before, user)
+	return nil
+}
+
+func (s *accountService) issueToken(ctx context.Context, userID uint, purpose string, ttl time.Duration) (string, error) {
+	token, hash, err := domain.NewLinkToken()
+	if err != nil {
+		return "", err
+	}
+	err = s.tokenRepo.Create(ctx, &domain.UserToken{
+		UserID:    userID,
+		Purpose:   purpose,
+		TokenHash: hash,
+		ExpiresAt: time.Now().Add(ttl),
+	})
+	return token, err
+}
+
+// allowMail учитывает письмо на адрес; ошибка хранилища не блокирует отправку
+func (s *accountService) allowMail(ctx context.Context, email string) bool {
+	n, err := s.limits.Incr(ctx, "mail:"+email, mailWindow)
+	if err != nil {
+		log.Error().Err(err).Msg("не удалось учесть письмо")
+		return true
+	}
+	return n <= mailsPerHour
+}
+
+func (s *accountService) link(path, token string) string {
+	return s.baseURL + path + "?token=" + token
+}
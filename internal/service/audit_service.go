@@ -2,14 +2,64 @@ package service
 
 import (
 	"context"
+	"encoding/csv"
 	"encoding/json"
+	"io"
+	"strconv"
+	"sync/atomic"
+	"time"
 
 	"github.com/beercut-team/backend-boilerplate/internal/domain"
 	"github.com/beercut-team/backend-boilerplate/internal/repository"
+	"github.com/rs/zerolog/log"
 )
 
 type AuditService interface {
 	LogAction(ctx context.Context, userID uint, action, entity string, entityID uint, oldValue, newValue interface{}, ip string) error
+	// Record пишет изменение сущности с diff полей. Автор и IP берутся из контекста
+	// запроса (WithAuditActor); ошибка записи не прерывает операцию, а логируется.
+	Record(ctx context.Context, action, entity string, entityID uint, before, after interface{})
+	// LogRead — просмотр карточки с персональными данными
+	LogRead(ctx context.Context, entity string, entityID uint)
+	List(ctx context.Context, filters repository.AuditFilters, offset, limit int) ([]domain.AuditLog, int64, error)
+	// ExportCSV выгружает журнал по фильтрам для проверок
+	ExportCSV(ctx context.Context, filters repository.AuditFilters, w io.Writer) error
+}
+
+// auditActor — кто выполняет запрос; recorded отмечает, что сервисы уже записали аудит
+type auditActor struct {
+	userID   uint
+	role     domain.Role
+	ip       string
+	recorded atomic.Bool
+}
+
+type auditActorKey struct{}
+
+// WithAuditActor добавляет в контекст автора изменений для сервисного аудита
+func WithAuditActor(ctx context.Context, userID uint, role domain.Role, ip string) context.Context {
+	return context.WithValue(ctx, auditActorKey{}, &auditActor{userID: userID, role: role, ip: ip})
+}
+
+// AuditRecorded — сервисы записали аудит в рамках запроса
+func AuditRecorded(ctx context.Context) bool {
+	actor, ok := ctx.Value(auditActorKey{}).(*auditActor)
+	return ok && actor.recorded.Load()
+}
+
+// auditEntry — изменение, которое пишется в журнал после фиксации транзакции
+type auditEntry struct {
+	action   string
+	entity   string
+	entityID uint
+	before   interface{}
+	after    interface{}
+}
+
+func recordAll(ctx context.Context, audit AuditService, entries []auditEntry) {
+	for _, e := range entries {
+		audit.Record(ctx, e.action, e.entity, e.entityID, e.before, e.after)
+	}
 }
 
 type auditService struct {
@@ -21,21 +71,22 @@ func NewAuditService(repo repository.AuditRepository) AuditService {
 }
 
 func (s *auditService) LogAction(ctx context.Context, userID uint, action, entity string, entityID uint, oldValue, newValue interface{}, ip string) error {
+	// Значения ПДн в журнал не попадают: он не шифруется и доступен администраторам
 	var oldJSON, newJSON string
 
 	if oldValue != nil {
 		if b, err := json.Marshal(oldValue); err == nil {
-			oldJSON = string(b)
+			oldJSON = domain.RedactPII(b)
 		}
 	}
 
 	if newValue != nil {
 		if b, err := json.Marshal(newValue); err == nil {
-			newJSON = string(b)
+			newJSON = domain.RedactPII(b)
 		}
 	}
 
-	log := &domain.AuditLog{
+	entry := &domain.AuditLog{
 		UserID:   userID,
 		Action:   action,
 		Entity:   entity,
@@ -44,6 +95,96 @@ func (s *auditService) LogAction(ctx context.Context, userID uint, action, entit
 		NewValue: newJSON,
 		IP:       ip,
 	}
+	if actor, ok := ctx.Value(auditActorKey{}).(*auditActor); ok && actor.userID == userID {
+		entry.UserRole = actor.role
+	}
+
+	if err := s.repo.Create(ctx, entry); err != nil {
+		return err
+	}
+	s.markRecorded(ctx)
+	return nil
+}
+
+func (s *auditService) Record(ctx context.Context, action, entity string, entityID uint, before, after interface{}) {
+	changes, err := domain.AuditDiff(before, after)
+	if err != nil {
+		log.Error().Err(err).Str("entity", entity).Uint("entity_id", entityID).Msg("не удалось построить diff для аудита")
+		return
+	}
+	// Сохранение без изменений не засоряет журнал
+	if action == domain.AuditActionUpdate && len(changes) == 0 {
+		s.markRecorded(ctx)
+		return
+	}
+	s.create(ctx, &domain.AuditLog{Action: action, Entity: entity, EntityID: entityID, Changes: changes})
+}
+
+func (s *auditService) LogRead(ctx context.Context, entity string, entityID uint) {
+	s.create(ctx, &domain.AuditLog{Action: domain.AuditActionRead, Entity: entity, EntityID: entityID})
+}
+
+func (s *auditService) create(ctx context.Context, entry *domain.AuditLog) {
+	if actor, ok := ctx.Value(auditActorKey{}).(*auditActor); ok {
+		entry.UserID, entry.UserRole, entry.IP = actor.userID, actor.role, actor.ip
+	}
+	// Запись аудита не должна пропасть из-за отмены запроса клиентом
+	if err := s.repo.Create(context.WithoutCancel(ctx), entry); err != nil {
+		log.Error().Err(err).Str("action", entry.Action).Str("entity", entry.Entity).Uint("entity_id", entry.EntityID).Msg("не удалось записать аудит")
+		return
+	}
+	s.markRecorded(ctx)
+}
+
+func (s *auditService) markRecorded(ctx context.Context) {
+	if actor, ok := ctx.Value(auditActorKey{}).(*auditActor); ok {
+		actor.recorded.Store(true)
+	}
+}
+
+func (s *auditService) List(ctx context.Context, filters repository.AuditFilters, offset, limit int) ([]domain.AuditLog, int64, error) {
+	return s.repo.FindAll(ctx, filters, offset, limit)
+}
+
+func (s *auditService) ExportCSV(ctx context.Context, filters repository.AuditFilters, w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"id", "created_at", "user_id", "user_role", "ip", "action", "entity", "entity_id", "changes", "old_value", "new_value"}); err != nil {
+		return err
+	}
+
+	err := s.repo.FindInBatches(ctx, filters, 500, func(logs []domain.AuditLog) error {
+		for _, l := range logs {
+			changes := ""
+			if len(l.Changes) > 0 {
+				b, err := json.Marshal(l.Changes)
+				if err != nil {
+					return err
+				}
+				changes = string(b)
+			}
+			if err := cw.Write([]string{
+				strconv.FormatUint(uint64(l.ID), 10),
+				l.CreatedAt.UTC().Format(time.RFC3339),
+				strconv.FormatUint(uint64(l.UserID), 10),
+				string(l.UserRole),
+				l.IP,
+				l.Action,
+				l.Entity,
+				strconv.FormatUint(uint64(l.EntityID), 10),
+				changes,
+				l.OldValue,
+				l.NewValue,
+			}); err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+	})
+	if err != nil {
+		return err
+	}
 
-	return s.repo.Create(ctx, log)
+	cw.Flush()
+	return cw.Error()
 }
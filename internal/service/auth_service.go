@@ -3,87 +3,163 @@ package service
 import (
 	"context"
 	"errors"
+	"strings"
+	"time"
 
 	"github.com/beercut-team/backend-boilerplate/internal/domain"
 	"github.com/beercut-team/backend-boilerplate/internal/repository"
+	"github.com/beercut-team/backend-boilerplate/pkg/totp"
+	"github.com/rs/zerolog/log"
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 )
 
+// TwoFactorRequiredError — пароль верный, для входа нужен код 2FA с токеном ChallengeToken
+type TwoFactorRequiredError struct {
+	ChallengeToken string
+}
+
+func (e *TwoFactorRequiredError) Error() string {
+	return "требуется код двухфакторной аутентификации"
+}
+
+func (e *TwoFactorRequiredError) Response() domain.TwoFactorChallengeResponse {
+	return domain.TwoFactorChallengeResponse{
+		TwoFactorRequired: true,
+		ChallengeToken:    e.ChallengeToken,
+		ExpiresIn:         int(challengeTTL / time.Second),
+	}
+}
+
 type AuthService interface {
-	Register(ctx context.Context, req domain.RegisterRequest) (*domain.AuthResponse, error)
-	Login(ctx context.Context, req domain.LoginRequest) (*domain.AuthResponse, error)
-	PatientLogin(ctx context.Context, req domain.PatientLoginRequest) (*domain.AuthResponse, error)
-	TelegramTokenLogin(ctx context.Context, req domain.TelegramTokenRequest) (*domain.AuthResponse, error)
-	Refresh(ctx context.Context, req domain.RefreshRequest) (*domain.AuthResponse, error)
-	Logout(ctx context.Context, userID uint) error
+	Register(ctx context.Context, req domain.RegisterRequest, client domain.ClientInfo) (*domain.AuthResponse, error)
+	// Login возвращает *TwoFactorRequiredError, если у пользователя включена 2FA
+	Login(ctx context.Context, req domain.LoginRequest, client domain.ClientInfo) (*domain.AuthResponse, error)
+	VerifyTwoFactor(ctx context.Context, req domain.TwoFactorVerifyRequest, client domain.ClientInfo) (*domain.AuthResponse, error)
+	PatientLogin(ctx context.Context, req domain.PatientLoginRequest, client domain.ClientInfo) (*domain.AuthResponse, error)
+	TelegramTokenLogin(ctx context.Context, req domain.TelegramTokenRequest, client domain.ClientInfo) (*domain.AuthResponse, error)
+	Refresh(ctx context.Context, req domain.RefreshRequest, client domain.ClientInfo) (*domain.AuthResponse, error)
+	// Logout завершает сессию токена и отзывает сам токен доступа
+	Logout(ctx context.Context, claims *AccessClaims) error
 	Me(ctx context.Context, userID uint) (*domain.UserResponse, error)
-	ListUsers(ctx context.Context) ([]domain.UserResponse, error)
+
+	// Сессии владельца текущей сессии
+	ListSessions(ctx context.Context, sessionID uint) ([]domain.Session, error)
+	RevokeSession(ctx context.Context, sessionID, id uint) error
+	RevokeOtherSessions(ctx context.Context, sessionID uint) error
+
+	// Двухфакторная аутентификация сотрудника текущей сессии
+	SetupTwoFactor(ctx context.Context, sessionID uint, req domain.TwoFactorSetupRequest) (*domain.TwoFactorSetupResponse, error)
+	EnableTwoFactor(ctx context.Context, sessionID uint, req domain.TwoFactorCodeRequest) (*domain.RecoveryCodesResponse, error)
+	DisableTwoFactor(ctx context.Context, sessionID uint, req domain.TwoFactorDisableRequest) error
+	RegenerateRecoveryCodes(ctx context.Context, sessionID uint, req domain.TwoFactorCodeRequest) (*domain.RecoveryCodesResponse, error)
 }
 
 type authService struct {
 	userRepo     repository.UserRepository
 	patientRepo  repository.PatientRepository
 	tokenRepo    repository.TelegramTokenRepository
-	tokenService TokenService
+	sessionRepo  repository.SessionRepository
+	recoveryRepo repository.RecoveryCodeRepository
+	// Сотрудники регистрируются только по приглашению администратора
+	invitationRepo repository.InvitationRepository
+	tokenService   TokenService
+	revocation     TokenRevocation
+	codeGuard      AccessCodeGuard
+	twoFactorGuard TwoFactorGuard
+	audit          AuditService
+	totpIssuer     string
+	// Вход по коду доступа требует дату рождения пациента
+	requireBirthDate bool
 }
 
-func NewAuthService(userRepo repository.UserRepository, tokenService TokenService) AuthService {
+func NewAuthService(userRepo repository.UserRepository, sessionRepo repository.SessionRepository, recoveryRepo repository.RecoveryCodeRepository, invitationRepo repository.InvitationRepository, tokenService TokenService, revocation TokenRevocation, twoFactorGuard TwoFactorGuard, audit AuditService, totpIssuer string) AuthService {
 	return &authService{
-		userRepo:     userRepo,
-		tokenService: tokenService,
+		userRepo:       userRepo,
+		sessionRepo:    sessionRepo,
+		recoveryRepo:   recoveryRepo,
+		invitationRepo: invitationRepo,
+		tokenService:   tokenService,
+		revocation:     revocation,
+		twoFactorGuard: twoFactorGuard,
+		audit:          audit,
+		totpIssuer:     totpIssuer,
 	}
 }
 
-func NewAuthServiceWithPatient(userRepo repository.UserRepository, patientRepo repository.PatientRepository, tokenRepo repository.TelegramTokenRepository, tokenService TokenService) AuthService {
+func NewAuthServiceWithPatient(userRepo repository.UserRepository, patientRepo repository.PatientRepository, tokenRepo repository.TelegramTokenRepository, sessionRepo repository.SessionRepository, recoveryRepo repository.RecoveryCodeRepository, invitationRepo repository.InvitationRepository, tokenService TokenService, revocation TokenRevocation, codeGuard AccessCodeGuard, twoFactorGuard TwoFactorGuard, audit AuditService, totpIssuer string, requireBirthDate bool) AuthService {
 	return &authService{
-		userRepo:     userRepo,
-		patientRepo:  patientRepo,
-		tokenRepo:    tokenRepo,
-		tokenService: tokenService,
+		userRepo:         userRepo,
+		patientRepo:      patientRepo,
+		tokenRepo:        tokenRepo,
+		sessionRepo:      sessionRepo,
+		recoveryRepo:     recoveryRepo,
+		invitationRepo:   invitationRepo,
+		tokenService:     tokenService,
+		revocation:       revocation,
+		codeGuard:        codeGuard,
+		twoFactorGuard:   twoFactorGuard,
+		audit:            audit,
+		totpIssuer:       totpIssuer,
+		requireBirthDate: requireBirthDate,
 	}
 }
 
-func (s *authService) Register(ctx context.Context, req domain.RegisterRequest) (*domain.AuthResponse, error) {
-	existing, _ := s.userRepo.FindByEmail(ctx, req.Email)
+func (s *authService) Register(ctx context.Context, req domain.RegisterRequest, client domain.ClientInfo) (*domain.AuthResponse, error) {
+	inv, err := s.invitationRepo.FindByTokenHash(ctx, domain.HashToken(req.InviteToken))
+	if err != nil || inv.CurrentStatus(time.Now()) != domain.InvitationPending {
+		return nil, errors.New("приглашение недействительно или истекло")
+	}
+	existing, _ := s.userRepo.FindByEmail(ctx, inv.Email)
 	if existing != nil {
 		return nil, errors.New("этот email уже зарегистрирован")
 	}
 
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		name = inv.Name
+	}
+	if name == "" {
+		return nil, errors.New("укажите имя")
+	}
+	if err := domain.ValidatePassword(req.Password, inv.Email, req.FirstName, req.LastName); err != nil {
+		return nil, err
+	}
 	hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
 	if err != nil {
 		return nil, errors.New("ошибка хеширования пароля")
 	}
 
-	role := req.Role
-	if role == "" {
-		role = domain.RolePatient
-	}
-	if !domain.ValidRole(role) {
-		return nil, errors.New("недопустимая роль")
-	}
-
+	// Письмо с приглашением дошло до адресата — адрес подтверждён
+	now := time.Now()
+	districtID := inv.DistrictID
 	user := &domain.User{
-		Email:        req.Email,
-		PasswordHash: string(hash),
-		Name:         req.Name,
-		FirstName:    req.FirstName,
-		LastName:     req.LastName,
-		MiddleName:   req.MiddleName,
-		Phone:        req.Phone,
-		Role:         role,
-		DistrictID:   &req.DistrictID,
-		IsActive:     true,
-	}
-
-	if err := s.userRepo.Create(ctx, user); err != nil {
+		Email:           inv.Email,
+		PasswordHash:    string(hash),
+		Name:            name,
+		FirstName:       req.FirstName,
+		LastName:        req.LastName,
+		MiddleName:      req.MiddleName,
+		Phone:           req.Phone,
+		Role:            inv.Role,
+		DistrictID:      &districtID,
+		IsActive:        true,
+		EmailVerifiedAt: &now,
+	}
+
+	accepted, err := s.invitationRepo.Accept(ctx, inv.ID, user)
+	if err != nil {
 		return nil, errors.New("не удалось создать пользователя")
 	}
+	if !accepted {
+		return nil, errors.New("приглашение недействительно или истекло")
+	}
+	s.audit.Record(ctx, domain.AuditActionCreate, domain.AuditEntityUser, user.ID, nil, user)
 
-	return s.generateTokens(ctx, user)
+	return s.generateTokens(ctx, user, client)
 }
 
-func (s *authService) Login(ctx context.Context, req domain.LoginRequest) (*domain.AuthResponse, error) {
+func (s *authService) Login(ctx context.Context, req domain.LoginRequest, client domain.ClientInfo) (*domain.AuthResponse, error) {
 	user, err := s.userRepo.FindByEmail(ctx, req.Email)
 	if err != nil {
 		return nil, errors.New("неверный email или пароль")
@@ -97,52 +173,77 @@ func (s *authService) Login(ctx context.Context, req domain.LoginRequest) (*doma
 		return nil, errors.New("неверный email или пароль")
 	}
 
-	return s.generateTokens(ctx, user)
+	if user.TOTPEnabled {
+		challenge, err := s.tokenService.GenerateChallengeToken(user.ID)
+		if err != nil {
+			return nil, errors.New("не удалось сгенерировать токен доступа")
+		}
+		return nil, &TwoFactorRequiredError{ChallengeToken: challenge}
+	}
+
+	return s.generateTokens(ctx, user, client)
 }
 
-func (s *authService) PatientLogin(ctx context.Context, req domain.PatientLoginRequest) (*domain.AuthResponse, error) {
+func (s *authService) VerifyTwoFactor(ctx context.Context, req domain.TwoFactorVerifyRequest, client domain.ClientInfo) (*domain.AuthResponse, error) {
+	userID, err := s.tokenService.ValidateChallengeToken(req.ChallengeToken)
+	if err != nil {
+		return nil, errors.New("время на ввод кода истекло, войдите заново")
+	}
+
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, errors.New("пользователь не найден")
+	}
+	if !user.IsActive {
+		return nil, errors.New("аккаунт деактивирован")
+	}
+	if !user.TOTPEnabled {
+		return nil, errors.New("двухфакторная аутентификация не включена")
+	}
+
+	if err := s.checkSecondFactor(ctx, user, req.Code, true, client.IP); err != nil {
+		return nil, err
+	}
+
+	return s.generateTokens(ctx, user, client)
+}
+
+func (s *authService) PatientLogin(ctx context.Context, req domain.PatientLoginRequest, client domain.ClientInfo) (*domain.AuthResponse, error) {
 	if s.patientRepo == nil {
 		return nil, errors.New("вход по коду доступа недоступен")
 	}
 
-	// Find patient by access code (case-insensitive)
-	patient, err := s.patientRepo.FindByAccessCode(ctx, req.AccessCode)
-	if err != nil {
-		return nil, errors.New("неверный код доступа")
+	// Дата рождения проверяется до поиска: ответ не должен выдавать, существует ли код
+	birthDate := strings.TrimSpace(req.BirthDate)
+	if s.requireBirthDate && birthDate == "" {
+		return nil, errors.New("укажите дату рождения")
+	}
+	failMsg := "неверный код доступа"
+	if s.requireBirthDate {
+		failMsg = "неверный код доступа или дата рождения"
 	}
 
-	// Generate tokens with patient ID and PATIENT role
-	accessToken, err := s.tokenService.GenerateAccessToken(patient.ID, domain.RolePatient)
-	if err != nil {
-		return nil, errors.New("не удалось сгенерировать токен доступа")
+	if err := s.codeGuard.Check(ctx, client.IP, req.AccessCode, req.ChallengeProof); err != nil {
+		return nil, err
 	}
 
-	refreshToken, err := s.tokenService.GenerateRefreshToken(patient.ID)
+	// Find patient by access code (case-insensitive)
+	patient, err := s.patientRepo.FindByAccessCode(ctx, req.AccessCode)
 	if err != nil {
-		return nil, errors.New("не удалось сгенерировать токен обновления")
+		s.codeGuard.Fail(ctx, AccessCodeScopeLogin, client.IP, req.AccessCode, "not_found")
+		return nil, errors.New(failMsg)
 	}
 
-	// Create virtual user response from patient data
-	userResp := domain.UserResponse{
-		ID:         patient.ID,
-		Email:      patient.Email,
-		Name:       patient.FirstName + " " + patient.LastName,
-		FirstName:  patient.FirstName,
-		LastName:   patient.LastName,
-		MiddleName: patient.MiddleName,
-		Phone:      patient.Phone,
-		Role:       domain.RolePatient,
-		IsActive:   true,
+	// Без даты рождения в карте второй фактор не проверить — вход только по коду
+	if s.requireBirthDate && !patient.DateOfBirth.IsZero() && patient.DateOfBirth.Format("2006-01-02") != birthDate {
+		s.codeGuard.Fail(ctx, AccessCodeScopeLogin, client.IP, req.AccessCode, "birth_date_mismatch")
+		return nil, errors.New(failMsg)
 	}
 
-	return &domain.AuthResponse{
-		AccessToken:  accessToken,
-		RefreshToken: refreshToken,
-		User:         userResp,
-	}, nil
+	return s.generatePatientTokens(ctx, patient, client)
 }
 
-func (s *authService) TelegramTokenLogin(ctx context.Context, req domain.TelegramTokenRequest) (*domain.AuthResponse, error) {
+func (s *authService) TelegramTokenLogin(ctx context.Context, req domain.TelegramTokenRequest, client domain.ClientInfo) (*domain.AuthResponse, error) {
 	if s.tokenRepo == nil || s.patientRepo == nil {
 		return nil, errors.New("вход через Telegram недоступен")
 	}
@@ -164,60 +265,104 @@ func (s *authService) TelegramTokenLogin(ctx context.Context, req domain.Telegra
 		return nil, errors.New("не удалось использовать токен")
 	}
 
-	// Generate JWT tokens
-	accessToken, err := s.tokenService.GenerateAccessToken(patient.ID, domain.RolePatient)
+	return s.generatePatientTokens(ctx, patient, client)
+}
+
+// Refresh выдаёт новую пару токенов и заменяет токен обновления сессии.
+// Повторное предъявление заменённого токена означает его утечку: сессия
+// завершается целиком, и токены вора, и токены владельца перестают обновляться.
+func (s *authService) Refresh(ctx context.Context, req domain.RefreshRequest, client domain.ClientInfo) (*domain.AuthResponse, error) {
+	userID, sessionID, err := s.tokenService.ValidateRefreshToken(req.RefreshToken)
 	if err != nil {
-		return nil, errors.New("не удалось сгенерировать токен доступа")
+		return nil, errors.New("недействительный токен обновления")
+	}
+	if sessionID == 0 {
+		return nil, errors.New("токен обновления отозван")
 	}
 
-	refreshToken, err := s.tokenService.GenerateRefreshToken(patient.ID)
-	if err != nil {
-		return nil, errors.New("не удалось сгенерировать токен обновления")
+	session, err := s.sessionRepo.FindByID(ctx, sessionID)
+	if err != nil || session.UserID != userID {
+		return nil, errors.New("токен обновления отозван")
+	}
+	if !session.Active(time.Now()) {
+		return nil, errors.New("сессия завершена, войдите заново")
 	}
 
-	// Create virtual user response from patient data
-	userResp := domain.UserResponse{
-		ID:         patient.ID,
-		Email:      patient.Email,
-		Name:       patient.FirstName + " " + patient.LastName,
-		FirstName:  patient.FirstName,
-		LastName:   patient.LastName,
-		MiddleName: patient.MiddleName,
-		Phone:      patient.Phone,
-		Role:       domain.RolePatient,
-		IsActive:   true,
+	hash := domain.HashToken(req.RefreshToken)
+	if session.RefreshHash != hash {
+		s.revokeReused(ctx, session, client)
+		return nil, errors.New("токен обновления уже использован, сессия завершена")
 	}
 
-	return &domain.AuthResponse{
-		AccessToken:  accessToken,
-		RefreshToken: refreshToken,
-		User:         userResp,
-	}, nil
-}
+	var resp *domain.AuthResponse
+	var role domain.Role
+	switch session.Subject {
+	case domain.SessionSubjectPatient:
+		if s.patientRepo == nil {
+			return nil, errors.New("вход по коду доступа недоступен")
+		}
+		patient, err := s.patientRepo.FindByID(ctx, userID)
+		if err != nil {
+			return nil, errors.New("пациент не найден")
+		}
+		resp, role = &domain.AuthResponse{User: patientUserResponse(patient)}, domain.RolePatient
+	default:
+		user, err := s.userRepo.FindByID(ctx, userID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, errors.New("пользователь не найден")
+			}
+			return nil, errors.New("не удалось найти пользователя")
+		}
+		if !user.IsActive {
+			return nil, errors.New("аккаунт деактивирован")
+		}
+		resp, role = &domain.AuthResponse{User: user.ToResponse()}, user.Role
+	}
 
-func (s *authService) Refresh(ctx context.Context, req domain.RefreshRequest) (*domain.AuthResponse, error) {
-	userID, err := s.tokenService.ValidateRefreshToken(req.RefreshToken)
+	refreshToken, err := s.tokenService.GenerateRefreshToken(userID, session.ID)
 	if err != nil {
-		return nil, errors.New("недействительный токен обновления")
+		return nil, errors.New("не удалось сгенерировать токен обновления")
 	}
-
-	user, err := s.userRepo.FindByID(ctx, userID)
+	rotated, err := s.sessionRepo.Rotate(ctx, session.ID, hash, domain.HashToken(refreshToken), client)
 	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("пользователь не найден")
-		}
-		return nil, errors.New("не удалось найти пользователя")
+		return nil, errors.New("не удалось сохранить токен обновления")
+	}
+	if !rotated {
+		// Этот же токен только что обменяли в другом запросе
+		s.revokeReused(ctx, session, client)
+		return nil, errors.New("токен обновления уже использован, сессия завершена")
 	}
 
-	if user.RefreshToken != req.RefreshToken {
-		return nil, errors.New("токен обновления отозван")
+	accessToken, err := s.tokenService.GenerateAccessToken(userID, role, session.ID)
+	if err != nil {
+		return nil, errors.New("не удалось сгенерировать токен доступа")
 	}
 
-	return s.generateTokens(ctx, user)
+	resp.AccessToken, resp.RefreshToken = accessToken, refreshToken
+	return resp, nil
 }
 
-func (s *authService) Logout(ctx context.Context, userID uint) error {
-	return s.userRepo.UpdateRefreshToken(ctx, userID, "")
+func (s *authService) revokeReused(ctx context.Context, session *domain.Session, client domain.ClientInfo) {
+	log.Warn().Uint("session_id", session.ID).Uint("user_id", session.UserID).Str("subject", string(session.Subject)).
+		Str("ip", client.IP).Msg("повторное использование токена обновления, сессия завершена")
+	if err := s.sessionRepo.Revoke(ctx, session.ID, domain.SessionRevokedReuse); err != nil {
+		log.Error().Err(err).Uint("session_id", session.ID).Msg("не удалось завершить сессию")
+	}
+	s.revocation.RevokeSessions(ctx, session.ID)
+}
+
+func (s *authService) Logout(ctx context.Context, claims *AccessClaims) error {
+	s.revocation.RevokeToken(ctx, claims)
+	// Токен выдан до появления сессий — завершать нечего
+	if claims.SessionID == 0 {
+		return nil
+	}
+	if err := s.sessionRepo.Revoke(ctx, claims.SessionID, domain.SessionRevokedLogout); err != nil {
+		return err
+	}
+	s.revocation.RevokeSessions(ctx, claims.SessionID)
+	return nil
 }
 
 func (s *authService) Me(ctx context.Context, userID uint) (*domain.UserResponse, error) {
@@ -233,32 +378,239 @@ func (s *authService) Me(ctx context.Context, userID uint) (*domain.UserResponse
 	return &resp, nil
 }
 
-func (s *authService) ListUsers(ctx context.Context) ([]domain.UserResponse, error) {
-	users, err := s.userRepo.FindAll(ctx)
+func (s *authService) ListSessions(ctx context.Context, sessionID uint) ([]domain.Session, error) {
+	current, err := s.currentSession(ctx, sessionID)
 	if err != nil {
-		return nil, errors.New("не удалось получить список пользователей")
+		return nil, err
 	}
 
-	var resp []domain.UserResponse
-	for _, u := range users {
-		resp = append(resp, u.ToResponse())
+	sessions, err := s.sessionRepo.FindActive(ctx, current.UserID, current.Subject)
+	if err != nil {
+		return nil, errors.New("не удалось получить список сессий")
 	}
-	return resp, nil
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == current.ID
+	}
+	return sessions, nil
 }
 
-func (s *authService) generateTokens(ctx context.Context, user *domain.User) (*domain.AuthResponse, error) {
-	accessToken, err := s.tokenService.GenerateAccessToken(user.ID, user.Role)
+func (s *authService) RevokeSession(ctx context.Context, sessionID, id uint) error {
+	current, err := s.currentSession(ctx, sessionID)
 	if err != nil {
-		return nil, errors.New("не удалось сгенерировать токен доступа")
+		return err
 	}
 
-	refreshToken, err := s.tokenService.GenerateRefreshToken(user.ID)
+	target, err := s.sessionRepo.FindByID(ctx, id)
+	if err != nil || target.UserID != current.UserID || target.Subject != current.Subject {
+		return errors.New("сессия не найдена")
+	}
+	reason := domain.SessionRevokedByUser
+	if target.ID == current.ID {
+		reason = domain.SessionRevokedLogout
+	}
+	if err := s.sessionRepo.Revoke(ctx, target.ID, reason); err != nil {
+		return err
+	}
+	s.revocation.RevokeSessions(ctx, target.ID)
+	return nil
+}
+
+func (s *authService) RevokeOtherSessions(ctx context.Context, sessionID uint) error {
+	current, err := s.currentSession(ctx, sessionID)
 	if err != nil {
-		return nil, errors.New("не удалось сгенерировать токен обновления")
+		return err
 	}
+	return s.revocation.RevokeAll(ctx, current.UserID, current.Subject, current.ID, domain.SessionRevokedByUser)
+}
 
-	if err := s.userRepo.UpdateRefreshToken(ctx, user.ID, refreshToken); err != nil {
-		return nil, errors.New("не удалось сохранить токен обновления")
+func (s *authService) SetupTwoFactor(ctx context.Context, sessionID uint, req domain.TwoFactorSetupRequest) (*domain.TwoFactorSetupResponse, error) {
+	user, err := s.sessionUser(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TOTPEnabled {
+		return nil, errors.New("двухфакторная аутентификация уже включена")
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)); err != nil {
+		return nil, errors.New("неверный пароль")
+	}
+
+	// Секрет сохраняется неподтверждённым: 2FA включится после ввода первого кода
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, errors.New("не удалось сгенерировать секрет")
+	}
+	user.TOTPSecret = secret
+	user.TOTPLastStep = 0
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return nil, errors.New("не удалось сохранить секрет")
+	}
+
+	return &domain.TwoFactorSetupResponse{
+		Secret:     secret,
+		OTPAuthURL: totp.URL(s.totpIssuer, user.Email, secret),
+	}, nil
+}
+
+func (s *authService) EnableTwoFactor(ctx context.Context, sessionID uint, req domain.TwoFactorCodeRequest) (*domain.RecoveryCodesResponse, error) {
+	user, err := s.sessionUser(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TOTPEnabled {
+		return nil, errors.New("двухфакторная аутентификация уже включена")
+	}
+	if user.TOTPSecret == "" {
+		return nil, errors.New("сначала получите секрет: POST /auth/2fa/setup")
+	}
+	if err := s.checkSecondFactor(ctx, user, req.Code, false, ""); err != nil {
+		return nil, err
+	}
+
+	before := *user
+	user.TOTPEnabled = true
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return nil, errors.New("не удалось включить двухфакторную аутентификацию")
+	}
+	s.audit.Record(ctx, domain.AuditActionUpdate, domain.AuditEntityUser, user.ID, &before, user)
+
+	// Сессии, открытые без второго фактора, завершаются
+	if err := s.revocation.RevokeAll(ctx, user.ID, domain.SessionSubjectUser, sessionID, domain.SessionRevoked2FA); err != nil {
+		log.Error().Err(err).Uint("user_id", user.ID).Msg("не удалось завершить сессии после включения 2FA")
+	}
+
+	return s.issueRecoveryCodes(ctx, user.ID)
+}
+
+func (s *authService) DisableTwoFactor(ctx context.Context, sessionID uint, req domain.TwoFactorDisableRequest) error {
+	user, err := s.sessionUser(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	if !user.TOTPEnabled {
+		return errors.New("двухфакторная аутентификация не включена")
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)); err != nil {
+		return errors.New("неверный пароль")
+	}
+	if err := s.checkSecondFactor(ctx, user, req.Code, true, ""); err != nil {
+		return err
+	}
+
+	before := *user
+	user.TOTPEnabled = false
+	user.TOTPSecret = ""
+	user.TOTPLastStep = 0
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return errors.New("не удалось отключить двухфакторную аутентификацию")
+	}
+	s.audit.Record(ctx, domain.AuditActionUpdate, domain.AuditEntityUser, user.ID, &before, user)
+
+	if err := s.recoveryRepo.DeleteByUser(ctx, user.ID); err != nil {
+		log.Error().Err(err).Uint("user_id", user.ID).Msg("не удалось удалить резервные коды")
+	}
+	return nil
+}
+
+func (s *authService) RegenerateRecoveryCodes(ctx context.Context, sessionID uint, req domain.TwoFactorCodeRequest) (*domain.RecoveryCodesResponse, error) {
+	user, err := s.sessionUser(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.TOTPEnabled {
+		return nil, errors.New("двухфакторная аутентификация не включена")
+	}
+	// Только код из приложения: резервным кодом нельзя выпустить новые
+	if err := s.checkSecondFactor(ctx, user, req.Code, false, ""); err != nil {
+		return nil, err
+	}
+	return s.issueRecoveryCodes(ctx, user.ID)
+}
+
+func (s *authService) issueRecoveryCodes(ctx context.Context, userID uint) (*domain.RecoveryCodesResponse, error) {
+	codes, err := domain.NewRecoveryCodes(domain.RecoveryCodeCount)
+	if err != nil {
+		return nil, errors.New("не удалось сгенерировать резервные коды")
+	}
+	hashes := make([]string, len(codes))
+	for i, code := range codes {
+		hashes[i] = domain.HashToken(domain.NormalizeRecoveryCode(code))
+	}
+	if err := s.recoveryRepo.Replace(ctx, userID, hashes); err != nil {
+		return nil, errors.New("не удалось сохранить резервные коды")
+	}
+	return &domain.RecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+// checkSecondFactor проверяет код из приложения, а при allowRecovery — и резервный код.
+// Каждый код принимается один раз; неверные коды учитывает TwoFactorGuard.
+// ip пустой для запросов в открытой сессии — он берётся из контекста аудита.
+func (s *authService) checkSecondFactor(ctx context.Context, user *domain.User, code string, allowRecovery bool, ip string) error {
+	if err := s.twoFactorGuard.Check(ctx, user.ID); err != nil {
+		return err
+	}
+
+	if step, ok := totp.Validate(user.TOTPSecret, code, time.Now(), 1); ok {
+		fresh, err := s.userRepo.UpdateTOTPStep(ctx, user.ID, step)
+		if err != nil {
+			return errors.New("не удалось проверить код")
+		}
+		if !fresh {
+			return errors.New("код уже использован, дождитесь следующего")
+		}
+		user.TOTPLastStep = step
+		return nil
+	}
+
+	if allowRecovery {
+		used, err := s.recoveryRepo.Use(ctx, user.ID, domain.HashToken(domain.NormalizeRecoveryCode(code)))
+		if err != nil {
+			return errors.New("не удалось проверить код")
+		}
+		if used {
+			log.Info().Uint("user_id", user.ID).Msg("вход по резервному коду 2FA")
+			return nil
+		}
+	}
+
+	if err := s.twoFactorGuard.Fail(ctx, user.ID, ip); err != nil {
+		return err
+	}
+	return errors.New("неверный код")
+}
+
+// currentSession — активная сессия, в которой выдан токен запроса
+func (s *authService) currentSession(ctx context.Context, sessionID uint) (*domain.Session, error) {
+	if sessionID == 0 {
+		return nil, errors.New("токен выдан до появления сессий, войдите заново")
+	}
+	session, err := s.sessionRepo.FindByID(ctx, sessionID)
+	if err != nil || !session.Active(time.Now()) {
+		return nil, errors.New("сессия завершена, войдите заново")
+	}
+	return session, nil
+}
+
+// sessionUser — сотрудник текущей сессии; у пациентов 2FA нет
+func (s *authService) sessionUser(ctx context.Context, sessionID uint) (*domain.User, error) {
+	session, err := s.currentSession(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if session.Subject != domain.SessionSubjectUser {
+		return nil, errors.New("двухфакторная аутентификация доступна только сотрудникам")
+	}
+	user, err := s.userRepo.FindByID(ctx, session.UserID)
+	if err != nil {
+		return nil, errors.New("пользователь не найден")
+	}
+	return user, nil
+}
+
+func (s *authService) generateTokens(ctx context.Context, user *domain.User, client domain.ClientInfo) (*domain.AuthResponse, error) {
+	accessToken, refreshToken, err := s.startSession(ctx, user.ID, user.Role, domain.SessionSubjectUser, client)
+	if err != nil {
+		return nil, err
 	}
 
 	return &domain.AuthResponse{
@@ -267,3 +619,63 @@ func (s *authService) generateTokens(ctx context.Context, user *domain.User) (*d
 		User:         user.ToResponse(),
 	}, nil
 }
+
+func (s *authService) generatePatientTokens(ctx context.Context, patient *domain.Patient, client domain.ClientInfo) (*domain.AuthResponse, error) {
+	// Generate tokens with patient ID and PATIENT role
+	accessToken, refreshToken, err := s.startSession(ctx, patient.ID, domain.RolePatient, domain.SessionSubjectPatient, client)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.AuthResponse{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		User:         patientUserResponse(patient),
+	}, nil
+}
+
+// startSession открывает сессию устройства и выдаёт первую пару токенов
+func (s *authService) startSession(ctx context.Context, userID uint, role domain.Role, subject domain.SessionSubject, client domain.ClientInfo) (string, string, error) {
+	now := time.Now()
+	session := &domain.Session{
+		UserID:     userID,
+		Subject:    subject,
+		UserAgent:  client.UserAgent,
+		IP:         client.IP,
+		LastUsedAt: now,
+		ExpiresAt:  now.Add(s.tokenService.RefreshTTL()),
+	}
+	if err := s.sessionRepo.Create(ctx, session); err != nil {
+		return "", "", errors.New("не удалось создать сессию")
+	}
+
+	refreshToken, err := s.tokenService.GenerateRefreshToken(userID, session.ID)
+	if err != nil {
+		return "", "", errors.New("не удалось сгенерировать токен обновления")
+	}
+	if _, err := s.sessionRepo.Rotate(ctx, session.ID, "", domain.HashToken(refreshToken), client); err != nil {
+		return "", "", errors.New("не удалось сохранить токен обновления")
+	}
+
+	accessToken, err := s.tokenService.GenerateAccessToken(userID, role, session.ID)
+	if err != nil {
+		return "", "", errors.New("не удалось сгенерировать токен доступа")
+	}
+
+	return accessToken, refreshToken, nil
+}
+
+// Create virtual user response from patient data
+func patientUserResponse(patient *domain.Patient) domain.UserResponse {
+	return domain.UserResponse{
+		ID:         patient.ID,
+		Email:      patient.Email,
+		Name:       patient.FirstName + " " + patient.LastName,
+		FirstName:  patient.FirstName,
+		LastName:   patient.LastName,
+		MiddleName: patient.MiddleName,
+		Phone:      patient.Phone,
+		Role:       domain.RolePatient,
+		IsActive:   true,
+	}
+}
@@ -0,0 +1,269 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/beercut-team/backend-boilerplate/internal/domain"
+	"github.com/beercut-team/backend-boilerplate/internal/repository"
+	"github.com/beercut-team/backend-boilerplate/pkg/biometry"
+	"github.com/rs/zerolog/log"
+)
+
+// Категория медиафайлов для исходных выгрузок биометра
+const biometryMediaCategory = "biometry"
+
+// Написания формул, которые принимает Calculate
+var importFormulaAliases = map[string]string{
+	"SRKT":          "SRKT",
+	"SRK/T":         "SRKT",
+	"HAIGIS":        "HAIGIS",
+	"HOFFERQ":       "HOFFERQ",
+	"HOFFER_Q":      "HOFFERQ",
+	"HOLLADAY1":     "HOLLADAY1",
+	"HOLLADAY":      "HOLLADAY1",
+	"BARRETT_STYLE": "BARRETT_STYLE",
+	"BARRETT":       "BARRETT_STYLE",
+}
+
+// BiometryService импортирует выгрузки биометров: сохраняет файл, LOINC-наблюдения
+// и готовит расчёты ИОЛ по измерениям
+type BiometryService interface {
+	Import(ctx context.Context, req domain.BiometryImportRequest, userID uint, fileName string, size int64, reader io.Reader) (*domain.BiometryImportResult, error)
+}
+
+type biometryService struct {
+	patientRepo   repository.PatientRepository
+	checklistRepo repository.ChecklistRepository
+	mediaRepo     repository.MediaRepository
+	mediaService  MediaService
+	iolService    IOLService
+}
+
+func NewBiometryService(patientRepo repository.PatientRepository, checklistRepo repository.ChecklistRepository, mediaRepo repository.MediaRepository, mediaService MediaService, iolService IOLService) BiometryService {
+	return &biometryService{
+		patientRepo:   patientRepo,
+		checklistRepo: checklistRepo,
+		mediaRepo:     mediaRepo,
+		mediaService:  mediaService,
+		iolService:    iolService,
+	}
+}
+
+func (s *biometryService) Import(ctx context.Context, req domain.BiometryImportRequest, userID uint, fileName string, size int64, reader io.Reader) (*domain.BiometryImportResult, error) {
+	if size > maxFileSize {
+		return nil, errors.New("файл слишком большой, максимум 20МБ")
+	}
+	eye := ""
+	if req.Eye != "" {
+		eye = domain.NormalizeEye(req.Eye)
+		if eye != biometry.EyeRight && eye != biometry.EyeLeft {
+			return nil, errors.New("глаз должен быть OD или OS")
+		}
+	}
+	formulaList, err := importFormulas(req.Formulas)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := io.ReadAll(io.LimitReader(reader, maxFileSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("не удалось прочитать файл: %w", err)
+	}
+	if len(data) > maxFileSize {
+		return nil, errors.New("файл слишком большой, максимум 20МБ")
+	}
+
+	// Разбираем до загрузки, чтобы не хранить файлы без измерений
+	report, err := biometry.Parse(fileName, data)
+	if err != nil {
+		if errors.Is(err, biometry.ErrNoMeasurements) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("не удалось разобрать файл биометра: %w", err)
+	}
+	measurements := report.Eyes
+	if eye != "" {
+		m, ok := report.Eye(eye)
+		if !ok {
+			return nil, fmt.Errorf("в файле нет измерений для глаза %s", eye)
+		}
+		measurements = []biometry.Measurement{m}
+	}
+
+	patient, err := s.patientRepo.FindByID(ctx, req.PatientID)
+	if err != nil {
+		return nil, errors.New("пациент не найден")
+	}
+
+	result := &domain.BiometryImportResult{
+		Format:      report.Format,
+		Device:      report.Device,
+		PatientName: report.PatientName,
+		MeasuredAt:  report.MeasuredAt,
+	}
+	// Имя в выгрузке сверяем так же, как имя в DICOM: Фамилия^Имя
+	if report.PatientName != "" && !domain.DICOMNameMatches(strings.Join(strings.Fields(report.PatientName), "^"), patient) {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("Имя в файле «%s» не совпадает с пациентом", report.PatientName))
+	}
+
+	media, err := s.mediaService.Upload(ctx, patient.ID, userID, fileName, "", biometryMediaCategory, int64(len(data)), bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	s.linkChecklistItem(ctx, media)
+	result.Media = media
+
+	observedAt := time.Now()
+	if report.MeasuredAt != nil {
+		observedAt = *report.MeasuredAt
+	}
+	for _, m := range measurements {
+		result.Measurements = append(result.Measurements, domain.BiometryMeasurement{
+			Eye:          m.Eye,
+			AxialLength:  m.AxialLength,
+			Keratometry1: m.K1,
+			Keratometry2: m.K2,
+			ACD:          m.ACD,
+		})
+		result.Observations = append(result.Observations, biometryObservations(m, observedAt)...)
+	}
+	if err := s.saveObservations(ctx, patient, result.Observations); err != nil {
+		return nil, errors.New("не удалось сохранить наблюдения")
+	}
+
+	for _, m := range measurements {
+		if m.K1 == 0 || m.K2 == 0 {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("%s: нет кератометрии, расчёт ИОЛ невозможен", m.Eye))
+			continue
+		}
+		for _, formula := range formulaList {
+			if formula == "HAIGIS" && m.ACD == 0 {
+				result.Warnings = append(result.Warnings, fmt.Sprintf("%s: формула Haigis пропущена, в файле нет ACD", m.Eye))
+				continue
+			}
+			calcReq := domain.IOLCalculationRequest{
+				PatientID:        patient.ID,
+				Eye:              m.Eye,
+				AxialLength:      m.AxialLength,
+				Keratometry1:     m.K1,
+				Keratometry2:     m.K2,
+				ACD:              m.ACD,
+				TargetRefraction: req.TargetRefraction,
+				Formula:          formula,
+				AConstant:        req.AConstant,
+				MediaID:          &media.ID,
+				LensID:           req.LensID,
+			}
+			result.Prefill = append(result.Prefill, calcReq)
+			if !req.Calculate {
+				continue
+			}
+			calc, err := s.iolService.Calculate(ctx, calcReq, userID)
+			if err != nil {
+				result.Warnings = append(result.Warnings, fmt.Sprintf("%s, %s: %s", m.Eye, formula, err.Error()))
+				continue
+			}
+			result.Calculations = append(result.Calculations, *calc)
+		}
+	}
+
+	return result, nil
+}
+
+// linkChecklistItem прикрепляет выгрузку к пункту «Биометрия» чек-листа
+func (s *biometryService) linkChecklistItem(ctx context.Context, media *domain.Media) {
+	item, err := attachToChecklist(ctx, s.checklistRepo, media, domain.DICOMModalityBiometry, "")
+	if err == nil && item != nil {
+		err = s.mediaRepo.SetChecklistItem(ctx, media.ID, item.ID)
+		media.ChecklistItemID = &item.ID
+	}
+	if err != nil {
+		log.Error().Err(err).Uint("media_id", media.ID).Msg("импорт биометрии: не удалось привязать файл к чек-листу")
+	}
+}
+
+// saveObservations добавляет наблюдения в метаданные пациента; повторный импорт
+// того же исследования заменяет значения, а не дублирует их
+func (s *biometryService) saveObservations(ctx context.Context, patient *domain.Patient, observations []domain.LOINCCode) error {
+	metadata := patient.MedicalMetadata
+	if metadata == nil {
+		metadata = &domain.MedicalStandardsMetadata{}
+	}
+	metadata.Observations = mergeObservations(metadata.Observations, observations)
+	return s.patientRepo.UpdateMedicalMetadata(ctx, patient.ID, metadata)
+}
+
+func mergeObservations(existing, added []domain.LOINCCode) []domain.LOINCCode {
+	for _, obs := range added {
+		replaced := false
+		for i := range existing {
+			if existing[i].Code == obs.Code && existing[i].ObservedAt.Equal(obs.ObservedAt) {
+				existing[i] = obs
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			existing = append(existing, obs)
+		}
+	}
+	return existing
+}
+
+func biometryObservations(m biometry.Measurement, observedAt time.Time) []domain.LOINCCode {
+	values := []struct {
+		kind  string
+		value float64
+	}{
+		{domain.BiometryAxialLength, m.AxialLength},
+		{domain.BiometryK1, m.K1},
+		{domain.BiometryK2, m.K2},
+		{domain.BiometryACD, m.ACD},
+	}
+	var obs []domain.LOINCCode
+	for _, v := range values {
+		if v.value == 0 {
+			continue
+		}
+		code, ok := domain.BiometryLOINC(m.Eye, v.kind)
+		if !ok {
+			continue
+		}
+		code.Value = fmt.Sprintf("%.2f", v.value)
+		code.ObservedAt = observedAt
+		obs = append(obs, code)
+	}
+	return obs
+}
+
+// importFormulas нормализует список формул; пустой список — формулы по умолчанию
+func importFormulas(requested []string) ([]string, error) {
+	var formulaList []string
+	seen := map[string]bool{}
+	for _, item := range requested {
+		for _, name := range strings.Split(item, ",") {
+			name = strings.ToUpper(strings.TrimSpace(name))
+			if name == "" {
+				continue
+			}
+			formula, ok := importFormulaAliases[name]
+			if !ok {
+				return nil, fmt.Errorf("неподдерживаемая формула %s, используйте: SRKT, HAIGIS, HOFFERQ, HOLLADAY1 или BARRETT_STYLE", name)
+			}
+			if !seen[formula] {
+				seen[formula] = true
+				formulaList = append(formulaList, formula)
+			}
+		}
+	}
+	if len(formulaList) == 0 {
+		return domain.DefaultImportFormulas, nil
+	}
+	return formulaList, nil
+}
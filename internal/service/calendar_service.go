@@ -0,0 +1,306 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/beercut-team/backend-boilerplate/internal/domain"
+	"github.com/beercut-team/backend-boilerplate/internal/repository"
+	"gorm.io/gorm"
+)
+
+const defaultSlotSearchDays = 14
+
+type CalendarService interface {
+	ListRooms(ctx context.Context, activeOnly bool) ([]domain.OperatingRoom, error)
+	CreateRoom(ctx context.Context, req domain.CreateOperatingRoomRequest) (*domain.OperatingRoom, error)
+	UpdateRoom(ctx context.Context, id uint, req domain.UpdateOperatingRoomRequest) (*domain.OperatingRoom, error)
+	GetSurgeonCalendar(ctx context.Context, surgeonID uint) (*domain.SurgeonCalendarResponse, error)
+	SetWorkingHours(ctx context.Context, surgeonID uint, req domain.SetWorkingHoursRequest) ([]domain.SurgeonWorkingHours, error)
+	AddTimeOff(ctx context.Context, surgeonID uint, req domain.CreateTimeOffRequest, createdBy uint) (*domain.SurgeonTimeOff, error)
+	DeleteTimeOff(ctx context.Context, surgeonID, id uint) error
+	AvailableSlots(ctx context.Context, surgeonID uint, opType domain.OperationType, from, to string) (*domain.AvailableSlotsResponse, error)
+}
+
+type calendarService struct {
+	repo     repository.CalendarRepository
+	userRepo repository.UserRepository
+	loc      *time.Location
+}
+
+func NewCalendarService(repo repository.CalendarRepository, userRepo repository.UserRepository, loc *time.Location) CalendarService {
+	if loc == nil {
+		loc = time.Local
+	}
+	return &calendarService{repo: repo, userRepo: userRepo, loc: loc}
+}
+
+func (s *calendarService) ListRooms(ctx context.Context, activeOnly bool) ([]domain.OperatingRoom, error) {
+	return s.repo.FindRooms(ctx, activeOnly)
+}
+
+func (s *calendarService) CreateRoom(ctx context.Context, req domain.CreateOperatingRoomRequest) (*domain.OperatingRoom, error) {
+	room := &domain.OperatingRoom{
+		Name:          strings.TrimSpace(req.Name),
+		Description:   req.Description,
+		OpensAt:       req.OpensAt,
+		ClosesAt:      req.ClosesAt,
+		DailyCapacity: req.DailyCapacity,
+		IsActive:      true,
+	}
+	if room.OpensAt == "" {
+		room.OpensAt = domain.DefaultWorkdayStart
+	}
+	if room.ClosesAt == "" {
+		room.ClosesAt = domain.DefaultWorkdayEnd
+	}
+	if err := validateRoom(room); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.CreateRoom(ctx, room); err != nil {
+		return nil, errors.New("не удалось создать операционную")
+	}
+	return room, nil
+}
+
+func (s *calendarService) UpdateRoom(ctx context.Context, id uint, req domain.UpdateOperatingRoomRequest) (*domain.OperatingRoom, error) {
+	room, err := s.repo.FindRoomByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("операционная не найдена")
+		}
+		return nil, err
+	}
+
+	if req.Name != nil {
+		room.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.Description != nil {
+		room.Description = *req.Description
+	}
+	if req.OpensAt != nil {
+		room.OpensAt = *req.OpensAt
+	}
+	if req.ClosesAt != nil {
+		room.ClosesAt = *req.ClosesAt
+	}
+	if req.DailyCapacity != nil {
+		room.DailyCapacity = *req.DailyCapacity
+	}
+	if req.IsActive != nil {
+		room.IsActive = *req.IsActive
+	}
+	if err := validateRoom(room); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.UpdateRoom(ctx, room); err != nil {
+		return nil, errors.New("не удалось обновить операционную")
+	}
+	return room, nil
+}
+
+func validateRoom(room *domain.OperatingRoom) error {
+	if room.Name == "" {
+		return errors.New("название операционной обязательно")
+	}
+	if room.DailyCapacity < 0 {
+		return errors.New("вместимость операционной не может быть отрицательной")
+	}
+	return domain.ValidateClockRange(room.OpensAt, room.ClosesAt)
+}
+
+func (s *calendarService) GetSurgeonCalendar(ctx context.Context, surgeonID uint) (*domain.SurgeonCalendarResponse, error) {
+	if err := s.checkSurgeon(ctx, surgeonID); err != nil {
+		return nil, err
+	}
+
+	hours, err := s.repo.FindWorkingHours(ctx, surgeonID)
+	if err != nil {
+		return nil, err
+	}
+	today := dateIn(time.Now(), s.loc)
+	timeOff, err := s.repo.FindTimeOff(ctx, surgeonID, today, today.AddDate(1, 0, 0))
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.SurgeonCalendarResponse{
+		SurgeonID:    surgeonID,
+		Hours:        hours,
+		TimeOff:      timeOff,
+		DefaultHours: len(hours) == 0,
+	}, nil
+}
+
+func (s *calendarService) SetWorkingHours(ctx context.Context, surgeonID uint, req domain.SetWorkingHoursRequest) ([]domain.SurgeonWorkingHours, error) {
+	if err := s.checkSurgeon(ctx, surgeonID); err != nil {
+		return nil, err
+	}
+
+	seen := map[int]bool{}
+	hours := make([]domain.SurgeonWorkingHours, 0, len(req.Hours))
+	for _, h := range req.Hours {
+		if h.Weekday < 1 || h.Weekday > 7 {
+			return nil, errors.New("день недели должен быть от 1 (понедельник) до 7 (воскресенье)")
+		}
+		if seen[h.Weekday] {
+			return nil, fmt.Errorf("день недели %d указан несколько раз", h.Weekday)
+		}
+		seen[h.Weekday] = true
+		if err := domain.ValidateClockRange(h.StartTime, h.EndTime); err != nil {
+			return nil, err
+		}
+		if h.MaxOperations < 0 {
+			return nil, errors.New("лимит операций не может быть отрицательным")
+		}
+		hours = append(hours, domain.SurgeonWorkingHours{
+			SurgeonID:     surgeonID,
+			Weekday:       h.Weekday,
+			StartTime:     h.StartTime,
+			EndTime:       h.EndTime,
+			MaxOperations: h.MaxOperations,
+		})
+	}
+
+	if err := s.repo.ReplaceWorkingHours(ctx, surgeonID, hours); err != nil {
+		return nil, errors.New("не удалось сохранить рабочие часы")
+	}
+	return hours, nil
+}
+
+func (s *calendarService) AddTimeOff(ctx context.Context, surgeonID uint, req domain.CreateTimeOffRequest, createdBy uint) (*domain.SurgeonTimeOff, error) {
+	if err := s.checkSurgeon(ctx, surgeonID); err != nil {
+		return nil, err
+	}
+	if !domain.ValidTimeOffType(req.Type) {
+		return nil, errors.New("неверный тип отсутствия, допустимо: DAY_OFF, VACATION, SICK_LEAVE")
+	}
+
+	from, err := time.Parse("2006-01-02", req.DateFrom)
+	if err != nil {
+		return nil, errors.New("неверный формат date_from, используйте ГГГГ-ММ-ДД")
+	}
+	to, err := time.Parse("2006-01-02", req.DateTo)
+	if err != nil {
+		return nil, errors.New("неверный формат date_to, используйте ГГГГ-ММ-ДД")
+	}
+	if to.Before(from) {
+		return nil, errors.New("date_to не может быть раньше date_from")
+	}
+
+	timeOff := &domain.SurgeonTimeOff{
+		SurgeonID: surgeonID,
+		Type:      req.Type,
+		DateFrom:  from,
+		DateTo:    to,
+		Reason:    req.Reason,
+		CreatedBy: createdBy,
+	}
+	if err := s.repo.CreateTimeOff(ctx, timeOff); err != nil {
+		return nil, errors.New("не удалось сохранить отсутствие")
+	}
+	return timeOff, nil
+}
+
+func (s *calendarService) DeleteTimeOff(ctx context.Context, surgeonID, id uint) error {
+	timeOff, err := s.repo.FindTimeOffByID(ctx, id)
+	if err != nil || timeOff.SurgeonID != surgeonID {
+		return errors.New("запись об отсутствии не найдена")
+	}
+	return s.repo.DeleteTimeOff(ctx, id)
+}
+
+func (s *calendarService) AvailableSlots(ctx context.Context, surgeonID uint, opType domain.OperationType, from, to string) (*domain.AvailableSlotsResponse, error) {
+	if err := s.checkSurgeon(ctx, surgeonID); err != nil {
+		return nil, err
+	}
+	if opType == "" {
+		return nil, errors.New("параметр operation_type обязателен")
+	}
+
+	fromDate := dateIn(time.Now(), s.loc)
+	if from != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", from, s.loc)
+		if err != nil {
+			return nil, errors.New("неверный формат from, используйте ГГГГ-ММ-ДД")
+		}
+		fromDate = parsed
+	}
+	toDate := fromDate.AddDate(0, 0, defaultSlotSearchDays-1)
+	if to != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", to, s.loc)
+		if err != nil {
+			return nil, errors.New("неверный формат to, используйте ГГГГ-ММ-ДД")
+		}
+		toDate = parsed
+	}
+	if toDate.Before(fromDate) {
+		return nil, errors.New("to не может быть раньше from")
+	}
+	if toDate.Sub(fromDate) >= domain.MaxSlotSearchDays*24*time.Hour {
+		return nil, fmt.Errorf("период поиска не может превышать %d дней", domain.MaxSlotSearchDays)
+	}
+
+	calendar, rooms, err := loadBookingContext(ctx, s.repo, surgeonID, fromDate, toDate.AddDate(0, 0, 1))
+	if err != nil {
+		return nil, err
+	}
+
+	duration := domain.OperationDuration(opType)
+	slots := domain.FindSlots(calendar, rooms, fromDate, toDate, duration, domain.DefaultSlotStepMinutes*time.Minute, 0, time.Now())
+
+	return &domain.AvailableSlotsResponse{
+		SurgeonID:       surgeonID,
+		OperationType:   opType,
+		DurationMinutes: int(duration / time.Minute),
+		Slots:           slots,
+	}, nil
+}
+
+func (s *calendarService) checkSurgeon(ctx context.Context, surgeonID uint) error {
+	user, err := s.userRepo.FindByID(ctx, surgeonID)
+	if err != nil || user.Role != domain.RoleSurgeon {
+		return errors.New("хирург не найден")
+	}
+	return nil
+}
+
+// loadBookingContext загружает календарь хирурга и активные операционные с операциями за [from, to)
+func loadBookingContext(ctx context.Context, repo repository.CalendarRepository, surgeonID uint, from, to time.Time) (domain.SurgeonCalendar, []domain.RoomBookings, error) {
+	var calendar domain.SurgeonCalendar
+	var err error
+
+	if calendar.Hours, err = repo.FindWorkingHours(ctx, surgeonID); err != nil {
+		return calendar, nil, err
+	}
+	if calendar.TimeOff, err = repo.FindTimeOff(ctx, surgeonID, from, to); err != nil {
+		return calendar, nil, err
+	}
+	if calendar.Surgeries, err = repo.FindSurgeonSurgeries(ctx, surgeonID, from, to); err != nil {
+		return calendar, nil, err
+	}
+
+	rooms, err := repo.FindRooms(ctx, true)
+	if err != nil {
+		return calendar, nil, err
+	}
+	bookings := make([]domain.RoomBookings, 0, len(rooms))
+	for _, room := range rooms {
+		surgeries, err := repo.FindRoomSurgeries(ctx, room.ID, from, to)
+		if err != nil {
+			return calendar, nil, err
+		}
+		bookings = append(bookings, domain.RoomBookings{Room: room, Surgeries: surgeries})
+	}
+	return calendar, bookings, nil
+}
+
+func dateIn(t time.Time, loc *time.Location) time.Time {
+	y, m, d := t.In(loc).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, loc)
+}
@@ -34,14 +34,16 @@ type checklistService struct {
 	repo        repository.ChecklistRepository
 	patientRepo repository.PatientRepository
 	notifRepo   repository.NotificationRepository
+	audit       AuditService
 	bot         *telegram.Bot
 }
 
-func NewChecklistService(repo repository.ChecklistRepository, patientRepo repository.PatientRepository, notifRepo repository.NotificationRepository, bot *telegram.Bot) ChecklistService {
+func NewChecklistService(repo repository.ChecklistRepository, patientRepo repository.PatientRepository, notifRepo repository.NotificationRepository, audit AuditService, bot *telegram.Bot) ChecklistService {
 	return &checklistService{
 		repo:        repo,
 		patientRepo: patientRepo,
 		notifRepo:   notifRepo,
+		audit:       audit,
 		bot:         bot,
 	}
 }
@@ -78,6 +80,7 @@ func (s *checklistService) CreateItem(ctx context.Context, req domain.CreateChec
 	if err := s.repo.CreateItem(ctx, item); err != nil {
 		return nil, errors.New("не удалось создать пункт чек-листа")
 	}
+	s.audit.Record(ctx, domain.AuditActionCreate, domain.AuditEntityChecklistItem, item.ID, nil, item)
 
 	// Создать уведомление в БД для врача
 	if s.notifRepo != nil {
@@ -136,29 +139,13 @@ func (s *checklistService) UpdateItem(ctx context.Context, id uint, req domain.U
 		return nil, err
 	}
 
-	oldStatus := item.Status
-	statusChanged := false
-
-	if req.Status != "" {
-		status := domain.ChecklistItemStatus(req.Status)
-		item.Status = status
-		statusChanged = (oldStatus != status)
-		if status == domain.ChecklistStatusCompleted {
-			now := time.Now()
-			item.CompletedAt = &now
-			item.CompletedBy = &userID
-		}
-	}
-	if req.Result != nil {
-		item.Result = *req.Result
-	}
-	if req.Notes != nil {
-		item.Notes = *req.Notes
-	}
+	before := *item
+	statusChanged := applyChecklistItemUpdate(item, req, userID)
 
 	if err := s.repo.UpdateItem(ctx, item); err != nil {
 		return nil, errors.New("не удалось обновить элемент чек-листа")
 	}
+	s.audit.Record(ctx, domain.AuditActionUpdate, domain.AuditEntityChecklistItem, id, &before, item)
 
 	// Создать уведомление в БД для врача при изменении статуса
 	if statusChanged && s.notifRepo != nil {
@@ -227,6 +214,30 @@ func (s *checklistService) UpdateItem(ctx context.Context, id uint, req domain.U
 	return item, nil
 }
 
+// applyChecklistItemUpdate переносит изменения в пункт чек-листа и сообщает, сменился ли статус
+func applyChecklistItemUpdate(item *domain.ChecklistItem, req domain.UpdateChecklistItemRequest, userID uint) bool {
+	oldStatus := item.Status
+	statusChanged := false
+
+	if req.Status != "" {
+		status := domain.ChecklistItemStatus(req.Status)
+		item.Status = status
+		statusChanged = (oldStatus != status)
+		if status == domain.ChecklistStatusCompleted {
+			now := time.Now()
+			item.CompletedAt = &now
+			item.CompletedBy = &userID
+		}
+	}
+	if req.Result != nil {
+		item.Result = *req.Result
+	}
+	if req.Notes != nil {
+		item.Notes = *req.Notes
+	}
+	return statusChanged
+}
+
 func (s *checklistService) ReviewItem(ctx context.Context, id uint, req domain.ReviewChecklistItemRequest, reviewerID uint) (*domain.ChecklistItem, error) {
 	item, err := s.repo.FindItemByID(ctx, id)
 	if err != nil {
@@ -241,6 +252,7 @@ func (s *checklistService) ReviewItem(ctx context.Context, id uint, req domain.R
 		return nil, errors.New("статус проверки должен быть COMPLETED или REJECTED")
 	}
 
+	before := *item
 	item.Status = status
 	item.ReviewedBy = &reviewerID
 	item.ReviewNote = req.ReviewNote
@@ -253,6 +265,7 @@ func (s *checklistService) ReviewItem(ctx context.Context, id uint, req domain.R
 	if err := s.repo.UpdateItem(ctx, item); err != nil {
 		return nil, errors.New("не удалось проверить элемент чек-листа")
 	}
+	s.audit.Record(ctx, domain.AuditActionUpdate, domain.AuditEntityChecklistItem, id, &before, item)
 
 	// Создать уведомление в БД для врача о результате проверки
 	if s.notifRepo != nil {
@@ -355,6 +368,9 @@ func (s *checklistService) CheckAndTransition(ctx context.Context, patientID uin
 				log.Error().Err(err).Uint("patient_id", patientID).Msg("не удалось обновить статус пациента")
 				return err
 			}
+			after := *p
+			after.Status = domain.PatientStatusPendingReview
+			s.audit.Record(ctx, domain.AuditActionUpdate, domain.AuditEntityPatient, patientID, p, &after)
 
 			if err := s.patientRepo.CreateStatusHistory(ctx, &domain.PatientStatusHistory{
 				PatientID:  patientID,
@@ -0,0 +1,316 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/beercut-team/backend-boilerplate/internal/domain"
+	"github.com/beercut-team/backend-boilerplate/internal/repository"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+type ChecklistTemplateService interface {
+	List(ctx context.Context, opType *domain.OperationType) ([]domain.ChecklistTemplate, error)
+	GetByID(ctx context.Context, id uint) (*domain.ChecklistTemplate, error)
+	Create(ctx context.Context, req domain.CreateChecklistTemplateRequest, userID uint) (*domain.ChecklistTemplate, error)
+	Update(ctx context.Context, id uint, req domain.UpdateChecklistTemplateRequest) (*domain.ChecklistTemplate, error)
+	Delete(ctx context.Context, id uint) error
+	Activate(ctx context.Context, id uint) (*domain.ChecklistTemplate, error)
+	UpgradePatientChecklist(ctx context.Context, patientID uint, templateID *uint) (*domain.ChecklistUpgradeResult, error)
+}
+
+type checklistTemplateService struct {
+	db           *gorm.DB
+	repo         repository.ChecklistTemplateRepository
+	checklistSvc ChecklistService
+}
+
+func NewChecklistTemplateService(db *gorm.DB, repo repository.ChecklistTemplateRepository, checklistSvc ChecklistService) ChecklistTemplateService {
+	return &checklistTemplateService{db: db, repo: repo, checklistSvc: checklistSvc}
+}
+
+func (s *checklistTemplateService) List(ctx context.Context, opType *domain.OperationType) ([]domain.ChecklistTemplate, error) {
+	return s.repo.FindAll(ctx, opType)
+}
+
+func (s *checklistTemplateService) GetByID(ctx context.Context, id uint) (*domain.ChecklistTemplate, error) {
+	t, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("шаблон чек-листа не найден")
+		}
+		return nil, err
+	}
+	return t, nil
+}
+
+// Create добавляет новую версию шаблона для типа операции
+func (s *checklistTemplateService) Create(ctx context.Context, req domain.CreateChecklistTemplateRequest, userID uint) (*domain.ChecklistTemplate, error) {
+	items, err := templateItemsFromInput(req.Items)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &domain.ChecklistTemplate{
+		OperationType: domain.OperationType(strings.ToUpper(strings.TrimSpace(string(req.OperationType)))),
+		Name:          strings.TrimSpace(req.Name),
+		Description:   req.Description,
+		CreatedBy:     &userID,
+		Items:         items,
+	}
+	if t.OperationType == "" || t.Name == "" {
+		return nil, errors.New("тип операции и название шаблона обязательны")
+	}
+	if _, err := repository.NewOperationTypeRepository(s.db).FindByCode(ctx, t.OperationType); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("неизвестный тип операции: %s", t.OperationType)
+		}
+		return nil, err
+	}
+
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		repo := repository.NewChecklistTemplateRepository(tx)
+		version, err := repo.NextVersion(ctx, t.OperationType)
+		if err != nil {
+			return err
+		}
+		t.Version = version
+		if err := repo.Create(ctx, t); err != nil {
+			return err
+		}
+		if req.Activate {
+			return repo.Activate(ctx, t)
+		}
+		return nil
+	})
+	if err != nil {
+		log.Error().Err(err).Str("operation_type", string(t.OperationType)).Msg("не удалось создать шаблон чек-листа")
+		return nil, errors.New("не удалось создать шаблон чек-листа")
+	}
+	return t, nil
+}
+
+// Update меняет версию, по которой ещё не создано ни одного чек-листа;
+// иначе изменения оформляются новой версией
+func (s *checklistTemplateService) Update(ctx context.Context, id uint, req domain.UpdateChecklistTemplateRequest) (*domain.ChecklistTemplate, error) {
+	t, err := s.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.ensureUnused(ctx, id); err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		t.Name = strings.TrimSpace(*req.Name)
+		if t.Name == "" {
+			return nil, errors.New("название шаблона обязательно")
+		}
+	}
+	if req.Description != nil {
+		t.Description = *req.Description
+	}
+	if req.Items != nil {
+		items, err := templateItemsFromInput(*req.Items)
+		if err != nil {
+			return nil, err
+		}
+		t.Items = items
+	}
+
+	if err := s.repo.Update(ctx, t); err != nil {
+		return nil, errors.New("не удалось обновить шаблон чек-листа")
+	}
+	return t, nil
+}
+
+func (s *checklistTemplateService) Delete(ctx context.Context, id uint) error {
+	t, err := s.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if t.IsActive {
+		return errors.New("нельзя удалить активную версию шаблона")
+	}
+	if err := s.ensureUnused(ctx, id); err != nil {
+		return err
+	}
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return errors.New("не удалось удалить шаблон чек-листа")
+	}
+	return nil
+}
+
+func (s *checklistTemplateService) Activate(ctx context.Context, id uint) (*domain.ChecklistTemplate, error) {
+	t, err := s.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.Activate(ctx, t); err != nil {
+		return nil, errors.New("не удалось активировать шаблон чек-листа")
+	}
+	return t, nil
+}
+
+func (s *checklistTemplateService) ensureUnused(ctx context.Context, id uint) error {
+	inUse, err := s.repo.IsInUse(ctx, id)
+	if err != nil {
+		return err
+	}
+	if inUse {
+		return errors.New("по шаблону уже созданы чек-листы, создайте новую версию")
+	}
+	return nil
+}
+
+// UpgradePatientChecklist переводит чек-лист пациента на указанную или активную версию шаблона
+func (s *checklistTemplateService) UpgradePatientChecklist(ctx context.Context, patientID uint, templateID *uint) (*domain.ChecklistUpgradeResult, error) {
+	var result *domain.ChecklistUpgradeResult
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		patient, err := repository.NewPatientRepository(tx).FindByID(ctx, patientID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("пациент не найден")
+			}
+			return err
+		}
+
+		templateRepo := repository.NewChecklistTemplateRepository(tx)
+		var t *domain.ChecklistTemplate
+		if templateID != nil {
+			t, err = templateRepo.FindByID(ctx, *templateID)
+		} else {
+			t, err = templateRepo.FindActive(ctx, patient.OperationType)
+		}
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("шаблон чек-листа не найден")
+			}
+			return err
+		}
+		if t.OperationType != patient.OperationType {
+			return errors.New("шаблон относится к другому типу операции")
+		}
+
+		checklistRepo := repository.NewChecklistRepository(tx)
+		existing, err := checklistRepo.FindItemsByPatient(ctx, patientID)
+		if err != nil {
+			return err
+		}
+
+		plan := domain.PlanChecklistUpgrade(existing, t.BuildItems(patient, time.Now()))
+		if len(plan.Create) > 0 {
+			if err := checklistRepo.CreateItems(ctx, plan.Create); err != nil {
+				return err
+			}
+		}
+		for i := range plan.Update {
+			if err := checklistRepo.UpdateItem(ctx, &plan.Update[i]); err != nil {
+				return err
+			}
+		}
+		for _, item := range plan.Delete {
+			if err := tx.Delete(&domain.ChecklistItem{}, item.ID).Error; err != nil {
+				return err
+			}
+			// Надгробие, чтобы офлайн-клиенты тоже удалили пункт
+			if err := tx.Create(domain.NewSyncTombstone(domain.SyncEntityChecklistItem, item.ID, patient)).Error; err != nil {
+				return err
+			}
+		}
+
+		items, err := checklistRepo.FindItemsByPatient(ctx, patientID)
+		if err != nil {
+			return err
+		}
+		result = &domain.ChecklistUpgradeResult{
+			TemplateID: t.ID,
+			Version:    t.Version,
+			Added:      len(plan.Create),
+			Updated:    len(plan.Update),
+			Removed:    len(plan.Delete),
+			Items:      items,
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	log.Info().Uint("patient_id", patientID).Uint("template_id", result.TemplateID).Int("version", result.Version).
+		Int("added", result.Added).Int("updated", result.Updated).Int("removed", result.Removed).
+		Msg("чек-лист пациента переведён на новую версию шаблона")
+
+	// Новые обязательные пункты или снятые требования могут изменить статус пациента
+	if s.checklistSvc != nil {
+		s.checklistSvc.CheckAndTransition(ctx, patientID)
+	}
+	return result, nil
+}
+
+// templateItemsFromInput проверяет пункты шаблона и условия в них
+func templateItemsFromInput(input []domain.ChecklistTemplateItemInput) ([]domain.ChecklistTemplateItem, error) {
+	if len(input) == 0 {
+		return nil, errors.New("шаблон должен содержать хотя бы один пункт")
+	}
+
+	items := make([]domain.ChecklistTemplateItem, 0, len(input))
+	seen := make(map[string]bool, len(input))
+	for i, in := range input {
+		name := strings.TrimSpace(in.Name)
+		if name == "" {
+			return nil, fmt.Errorf("пункт %d: название обязательно", i+1)
+		}
+		key := strings.ToLower(name)
+		if seen[key] {
+			return nil, fmt.Errorf("пункт %q повторяется в шаблоне", name)
+		}
+		seen[key] = true
+		if in.ExpiresInDays < 0 {
+			return nil, fmt.Errorf("пункт %q: срок действия не может быть отрицательным", name)
+		}
+		if err := in.IncludeWhen.Validate(); err != nil {
+			return nil, fmt.Errorf("пункт %q: %w", name, err)
+		}
+		if err := in.RequiredWhen.Validate(); err != nil {
+			return nil, fmt.Errorf("пункт %q: %w", name, err)
+		}
+
+		sortOrder := in.SortOrder
+		if sortOrder == 0 {
+			sortOrder = i + 1
+		}
+		items = append(items, domain.ChecklistTemplateItem{
+			Name:          name,
+			Description:   in.Description,
+			Category:      in.Category,
+			IsRequired:    in.IsRequired,
+			ExpiresInDays: in.ExpiresInDays,
+			SortOrder:     sortOrder,
+			IncludeWhen:   in.IncludeWhen,
+			RequiredWhen:  in.RequiredWhen,
+		})
+	}
+	return items, nil
+}
+
+// buildPatientChecklist формирует чек-лист нового пациента по активной версии шаблона.
+// Если активной версии нет, используется встроенный шаблон по умолчанию.
+func buildPatientChecklist(ctx context.Context, repo repository.ChecklistTemplateRepository, patient *domain.Patient, now time.Time) ([]domain.ChecklistItem, error) {
+	t, err := repo.FindActive(ctx, patient.OperationType)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+		log.Warn().Str("operation_type", string(patient.OperationType)).Msg("активный шаблон чек-листа не найден, используется шаблон по умолчанию")
+		t = domain.DefaultChecklistTemplate(patient.OperationType)
+	}
+
+	items := t.BuildItems(patient, now)
+	log.Info().Uint("patient_id", patient.ID).Uint("template_id", t.ID).Int("version", t.Version).Int("items_count", len(items)).Msg("чек-лист сформирован по шаблону")
+	return items, nil
+}
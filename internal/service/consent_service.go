@@ -0,0 +1,150 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/beercut-team/backend-boilerplate/internal/domain"
+	"github.com/beercut-team/backend-boilerplate/internal/repository"
+)
+
+// ConsentRequiredError — у пациента нет действующих согласий, нужных для действия
+type ConsentRequiredError struct {
+	Missing []domain.ConsentType
+}
+
+func (e *ConsentRequiredError) Error() string {
+	names := make([]string, 0, len(e.Missing))
+	for _, t := range e.Missing {
+		if text, ok := domain.CurrentConsentText(t); ok {
+			names = append(names, text.Title)
+		} else {
+			names = append(names, string(t))
+		}
+	}
+	return "нет действующих согласий пациента: " + strings.Join(names, "; ")
+}
+
+// requireConsents возвращает *ConsentRequiredError, если хотя бы одно согласие не действует
+func requireConsents(ctx context.Context, repo repository.ConsentRepository, patientID uint, required []domain.ConsentType) error {
+	active, err := repo.FindActive(ctx, patientID)
+	if err != nil {
+		return err
+	}
+	if missing := domain.MissingConsents(active, required); len(missing) > 0 {
+		return &ConsentRequiredError{Missing: missing}
+	}
+	return nil
+}
+
+type ConsentService interface {
+	ListByPatient(ctx context.Context, patientID uint) (*domain.PatientConsents, error)
+	GetByID(ctx context.Context, id uint) (*domain.Consent, error)
+	// Capture — согласие от пациента из личного кабинета или скан бланка от сотрудника.
+	// created = false, если такое согласие уже действует.
+	Capture(ctx context.Context, patientID uint, req domain.CaptureConsentRequest, actor domain.Actor) (consent *domain.Consent, created bool, err error)
+	Revoke(ctx context.Context, id uint, req domain.RevokeConsentRequest, actor domain.Actor) (*domain.Consent, error)
+}
+
+type consentService struct {
+	repo        repository.ConsentRepository
+	patientRepo repository.PatientRepository
+	mediaRepo   repository.MediaRepository
+}
+
+func NewConsentService(repo repository.ConsentRepository, patientRepo repository.PatientRepository, mediaRepo repository.MediaRepository) ConsentService {
+	return &consentService{repo: repo, patientRepo: patientRepo, mediaRepo: mediaRepo}
+}
+
+func (s *consentService) ListByPatient(ctx context.Context, patientID uint) (*domain.PatientConsents, error) {
+	consents, err := s.repo.FindByPatient(ctx, patientID)
+	if err != nil {
+		return nil, err
+	}
+	all := make([]domain.ConsentType, 0, len(domain.ConsentTexts))
+	for _, text := range domain.ConsentTexts {
+		all = append(all, text.Type)
+	}
+	return &domain.PatientConsents{Consents: consents, Missing: domain.MissingConsents(consents, all)}, nil
+}
+
+func (s *consentService) GetByID(ctx context.Context, id uint) (*domain.Consent, error) {
+	consent, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, errors.New("согласие не найдено")
+	}
+	return consent, nil
+}
+
+func (s *consentService) Capture(ctx context.Context, patientID uint, req domain.CaptureConsentRequest, actor domain.Actor) (*domain.Consent, bool, error) {
+	text, ok := domain.CurrentConsentText(req.Type)
+	if !ok {
+		return nil, false, errors.New("неизвестный тип согласия")
+	}
+	if _, err := s.patientRepo.FindByID(ctx, patientID); err != nil {
+		return nil, false, errors.New("пациент не найден")
+	}
+
+	consent := &domain.Consent{
+		PatientID:   patientID,
+		Type:        req.Type,
+		TextVersion: strings.TrimSpace(req.TextVersion),
+		SignedAt:    time.Now(),
+	}
+
+	if actor.Role == domain.RolePatient {
+		// В кабинете пациент видит только действующую редакцию
+		if consent.TextVersion == "" {
+			consent.TextVersion = text.Version
+		}
+		if consent.TextVersion != text.Version {
+			return nil, false, errors.New("редакция текста согласия устарела, обновите страницу")
+		}
+		consent.Channel = domain.ConsentChannelPortal
+	} else {
+		// Сотрудник вносит подписанный бланк: скан обязателен, редакция — с бланка
+		if req.MediaID == nil {
+			return nil, false, errors.New("приложите скан подписанного бланка (media_id)")
+		}
+		media, err := s.mediaRepo.FindByID(ctx, *req.MediaID)
+		if err != nil || media.PatientID != patientID {
+			return nil, false, errors.New("скан бланка не найден среди файлов пациента")
+		}
+		if consent.TextVersion == "" {
+			return nil, false, errors.New("укажите редакцию текста на бланке (text_version)")
+		}
+		if req.SignedAt != nil {
+			if req.SignedAt.After(time.Now()) {
+				return nil, false, errors.New("дата подписи не может быть в будущем")
+			}
+			consent.SignedAt = *req.SignedAt
+		}
+		consent.Channel = domain.ConsentChannelPaper
+		consent.MediaID = req.MediaID
+		consent.RecordedBy = &actor.ID
+	}
+
+	return s.repo.Capture(ctx, consent)
+}
+
+func (s *consentService) Revoke(ctx context.Context, id uint, req domain.RevokeConsentRequest, actor domain.Actor) (*domain.Consent, error) {
+	consent, err := s.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// Пациент отзывает согласие сам из кабинета; сотрудник — по письменному заявлению
+	channel, by := domain.ConsentChannelPortal, (*uint)(nil)
+	if actor.Role != domain.RolePatient {
+		channel, by = domain.ConsentChannelPaper, &actor.ID
+	}
+	if err := consent.Revoke(channel, by, strings.TrimSpace(req.Reason), time.Now()); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Update(ctx, consent); err != nil {
+		return nil, err
+	}
+	return consent, nil
+}
@@ -0,0 +1,171 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/beercut-team/backend-boilerplate/internal/domain"
+	"github.com/beercut-team/backend-boilerplate/internal/repository"
+)
+
+type EyeExamService interface {
+	Create(ctx context.Context, req domain.CreateEyeExamRequest, userID uint) (*domain.EyeExam, error)
+	GetByID(ctx context.Context, id uint) (*domain.EyeExam, error)
+	History(ctx context.Context, patientID uint, eye string) ([]domain.EyeExam, error)
+	Update(ctx context.Context, id uint, req domain.UpdateEyeExamRequest) (*domain.EyeExam, error)
+	Delete(ctx context.Context, id uint) error
+}
+
+type eyeExamService struct {
+	repo        repository.EyeExamRepository
+	patientRepo repository.PatientRepository
+}
+
+func NewEyeExamService(repo repository.EyeExamRepository, patientRepo repository.PatientRepository) EyeExamService {
+	return &eyeExamService{repo: repo, patientRepo: patientRepo}
+}
+
+func (s *eyeExamService) Create(ctx context.Context, req domain.CreateEyeExamRequest, userID uint) (*domain.EyeExam, error) {
+	if _, err := s.patientRepo.FindByID(ctx, req.PatientID); err != nil {
+		return nil, errors.New("пациент не найден")
+	}
+
+	exam := &domain.EyeExam{
+		PatientID:     req.PatientID,
+		Eye:           domain.NormalizeEye(req.Eye),
+		ExamDate:      truncateDay(time.Now()),
+		UCVA:          req.UCVA,
+		BCVA:          req.BCVA,
+		Sphere:        req.Sphere,
+		Cylinder:      req.Cylinder,
+		Axis:          req.Axis,
+		IOP:           req.IOP,
+		IOPMethod:     req.IOPMethod,
+		Biomicroscopy: req.Biomicroscopy,
+		Fundus:        req.Fundus,
+		LOCS:          req.LOCS,
+		Notes:         req.Notes,
+		ExaminedBy:    userID,
+	}
+	if req.ExamDate != "" {
+		date, err := parseExamDate(req.ExamDate)
+		if err != nil {
+			return nil, err
+		}
+		exam.ExamDate = date
+	}
+
+	if err := s.check(ctx, exam); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Create(ctx, exam); err != nil {
+		return nil, errors.New("не удалось сохранить осмотр")
+	}
+	return exam, nil
+}
+
+func (s *eyeExamService) GetByID(ctx context.Context, id uint) (*domain.EyeExam, error) {
+	exam, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, errors.New("осмотр не найден")
+	}
+	return exam, nil
+}
+
+func (s *eyeExamService) History(ctx context.Context, patientID uint, eye string) ([]domain.EyeExam, error) {
+	if eye != "" {
+		eye = domain.NormalizeEye(eye)
+	}
+	return s.repo.FindByPatient(ctx, patientID, eye)
+}
+
+func (s *eyeExamService) Update(ctx context.Context, id uint, req domain.UpdateEyeExamRequest) (*domain.EyeExam, error) {
+	exam, err := s.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.ExamDate != nil {
+		if exam.ExamDate, err = parseExamDate(*req.ExamDate); err != nil {
+			return nil, err
+		}
+	}
+	if req.UCVA != nil {
+		exam.UCVA = req.UCVA
+	}
+	if req.BCVA != nil {
+		exam.BCVA = req.BCVA
+	}
+	if req.Sphere != nil {
+		exam.Sphere = req.Sphere
+	}
+	if req.Cylinder != nil {
+		exam.Cylinder = req.Cylinder
+	}
+	if req.Axis != nil {
+		exam.Axis = req.Axis
+	}
+	if req.IOP != nil {
+		exam.IOP = req.IOP
+	}
+	if req.IOPMethod != nil {
+		exam.IOPMethod = *req.IOPMethod
+	}
+	if req.Biomicroscopy != nil {
+		exam.Biomicroscopy = *req.Biomicroscopy
+	}
+	if req.Fundus != nil {
+		exam.Fundus = *req.Fundus
+	}
+	if req.LOCS != nil {
+		exam.LOCS = *req.LOCS
+	}
+	if req.Notes != nil {
+		exam.Notes = *req.Notes
+	}
+
+	if err := s.check(ctx, exam); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Update(ctx, exam); err != nil {
+		return nil, errors.New("не удалось обновить осмотр")
+	}
+	return exam, nil
+}
+
+func (s *eyeExamService) Delete(ctx context.Context, id uint) error {
+	if _, err := s.GetByID(ctx, id); err != nil {
+		return err
+	}
+	return s.repo.Delete(ctx, id)
+}
+
+// check проверяет значения и то, что за визит у глаза один осмотр
+func (s *eyeExamService) check(ctx context.Context, exam *domain.EyeExam) error {
+	if err := exam.Validate(); err != nil {
+		return err
+	}
+	if exam.ExamDate.After(time.Now()) {
+		return errors.New("дата осмотра в будущем")
+	}
+
+	existing, err := s.repo.FindByPatient(ctx, exam.PatientID, exam.Eye)
+	if err != nil {
+		return err
+	}
+	for _, e := range existing {
+		if e.ID != exam.ID && e.ExamDate.Format("2006-01-02") == exam.ExamDate.Format("2006-01-02") {
+			return errors.New("осмотр этого глаза за эту дату уже есть, измените его")
+		}
+	}
+	return nil
+}
+
+func parseExamDate(value string) (time.Time, error) {
+	date, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, errors.New("неверный формат даты осмотра, используйте ГГГГ-ММ-ДД")
+	}
+	return date, nil
+}
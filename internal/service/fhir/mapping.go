@@ -0,0 +1,687 @@
+package fhir
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/beercut-team/backend-boilerplate/internal/domain"
+)
+
+// Identifier systems
+const (
+	SystemSNILS        = "urn:oid:1.2.643.100.3"
+	SystemOMS          = "https://oculus-feldsher.ru/fhir/sid/oms"
+	SystemPolicy       = "https://oculus-feldsher.ru/fhir/sid/policy"
+	SystemAccessCode   = "https://oculus-feldsher.ru/fhir/sid/access-code"
+	SystemExternalID   = "https://oculus-feldsher.ru/fhir/sid/external-id"
+	SystemDistrictCode = "https://oculus-feldsher.ru/fhir/sid/district"
+
+	ExtOperationType = "https://oculus-feldsher.ru/fhir/StructureDefinition/operation-type"
+	ExtEye           = "https://oculus-feldsher.ru/fhir/StructureDefinition/eye"
+	ExtStatus        = "https://oculus-feldsher.ru/fhir/StructureDefinition/patient-status"
+
+	systemConditionClinical   = "http://terminology.hl7.org/CodeSystem/condition-clinical"
+	systemObservationCategory = "http://terminology.hl7.org/CodeSystem/observation-category"
+	systemUCUM                = "http://unitsofmeasure.org"
+)
+
+const dateLayout = "2006-01-02"
+
+func patientRef(p *domain.Patient) Reference {
+	return Reference{
+		Reference: "Patient/" + strconv.FormatUint(uint64(p.ID), 10),
+		Display:   strings.TrimSpace(p.LastName + " " + p.FirstName + " " + p.MiddleName),
+	}
+}
+
+func bodySite(eye string) *CodeableConcept {
+	site, ok := domain.EyeBodySite(eye)
+	if !ok {
+		return nil
+	}
+	return &CodeableConcept{Coding: []Coding{{System: site.System, Code: site.Code, Display: site.Display}}}
+}
+
+func bodySites(eye string) []CodeableConcept {
+	if site := bodySite(eye); site != nil {
+		return []CodeableConcept{*site}
+	}
+	return nil
+}
+
+func formatDateTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+// PatientResource преобразует пациента в FHIR Patient
+func PatientResource(p *domain.Patient) Patient {
+	res := Patient{
+		ResourceType: "Patient",
+		ID:           strconv.FormatUint(uint64(p.ID), 10),
+		Active:       p.Status != domain.PatientStatusCancelled,
+		Gender:       p.Gender,
+	}
+	if !p.UpdatedAt.IsZero() {
+		res.Meta = &Meta{LastUpdated: formatDateTime(p.UpdatedAt)}
+	}
+
+	if p.SNILs != "" {
+		res.Identifier = append(res.Identifier, Identifier{Use: "official", System: SystemSNILS, Value: p.SNILs})
+	}
+	if p.OMSPolicy != "" {
+		res.Identifier = append(res.Identifier, Identifier{Use: "official", System: SystemOMS, Value: p.OMSPolicy})
+	}
+	if p.PolicyNumber != "" {
+		res.Identifier = append(res.Identifier, Identifier{Use: "secondary", System: SystemPolicy, Value: p.PolicyNumber})
+	}
+	if p.AccessCode != "" {
+		res.Identifier = append(res.Identifier, Identifier{Use: "usual", System: SystemAccessCode, Value: p.AccessCode})
+	}
+	if p.MedicalMetadata != nil && p.MedicalMetadata.FHIRResourceID != "" {
+		res.Identifier = append(res.Identifier, Identifier{Use: "secondary", System: SystemExternalID, Value: p.MedicalMetadata.FHIRResourceID})
+	}
+
+	given := []string{p.FirstName}
+	if p.MiddleName != "" {
+		given = append(given, p.MiddleName)
+	}
+	res.Name = []HumanName{{
+		Use:    "official",
+		Text:   strings.TrimSpace(p.LastName + " " + p.FirstName + " " + p.MiddleName),
+		Family: p.LastName,
+		Given:  given,
+	}}
+
+	if p.Phone != "" {
+		res.Telecom = append(res.Telecom, ContactPoint{System: "phone", Value: p.Phone})
+	}
+	if p.Email != "" {
+		res.Telecom = append(res.Telecom, ContactPoint{System: "email", Value: p.Email})
+	}
+	if !p.DateOfBirth.IsZero() {
+		res.BirthDate = p.DateOfBirth.Format(dateLayout)
+	}
+	if p.Address != "" {
+		res.Address = []Address{{Text: p.Address}}
+	}
+
+	if p.District != nil {
+		res.ManagingOrganization = &Reference{
+			Identifier: &Identifier{System: SystemDistrictCode, Value: p.District.Code},
+			Display:    p.District.Name,
+		}
+	}
+
+	res.Extension = []Extension{
+		{URL: ExtOperationType, ValueCode: string(p.OperationType)},
+		{URL: ExtStatus, ValueCode: string(p.Status)},
+	}
+	if p.Eye != "" {
+		res.Extension = append(res.Extension, Extension{URL: ExtEye, ValueCode: p.Eye})
+	}
+
+	return res
+}
+
+// ConditionResources строит FHIR Condition по кодам МКБ-10 пациента.
+// Если кодов нет, используется текстовый диагноз.
+func ConditionResources(p *domain.Patient) []Condition {
+	clinical := &CodeableConcept{Coding: []Coding{{System: systemConditionClinical, Code: "active"}}}
+	recorded := ""
+	if !p.CreatedAt.IsZero() {
+		recorded = p.CreatedAt.Format(dateLayout)
+	}
+
+	var conditions []Condition
+	if p.MedicalMetadata != nil {
+		for i, dx := range p.MedicalMetadata.DiagnosisCodes {
+			conditions = append(conditions, Condition{
+				ResourceType:   "Condition",
+				ID:             fmt.Sprintf("patient-%d-dx-%d", p.ID, i+1),
+				ClinicalStatus: clinical,
+				Code: CodeableConcept{
+					Coding: []Coding{{System: domain.CodeSystemICD10, Code: dx.Code, Display: dx.Display}},
+					Text:   dx.Display,
+				},
+				BodySite:     bodySites(p.Eye),
+				Subject:      patientRef(p),
+				RecordedDate: recorded,
+			})
+		}
+	}
+
+	if len(conditions) == 0 && strings.TrimSpace(p.Diagnosis) != "" {
+		conditions = append(conditions, Condition{
+			ResourceType:   "Condition",
+			ID:             fmt.Sprintf("patient-%d-dx-1", p.ID),
+			ClinicalStatus: clinical,
+			Code:           CodeableConcept{Text: p.Diagnosis},
+			BodySite:       bodySites(p.Eye),
+			Subject:        patientRef(p),
+			RecordedDate:   recorded,
+		})
+	}
+
+	return conditions
+}
+
+func procedureStatus(s domain.SurgeryStatus) string {
+	switch s {
+	case domain.SurgeryStatusCompleted:
+		return "completed"
+	case domain.SurgeryStatusCancelled:
+		return "not-done"
+	}
+	return "preparation"
+}
+
+// ProcedureResource преобразует операцию в FHIR Procedure
+func ProcedureResource(p *domain.Patient, s *domain.Surgery) Procedure {
+	code := domain.DefaultProcedureCode(s.OperationType)
+	if p.MedicalMetadata != nil && len(p.MedicalMetadata.ProcedureCodes) > 0 {
+		code = p.MedicalMetadata.ProcedureCodes[0]
+	}
+
+	proc := Procedure{
+		ResourceType: "Procedure",
+		ID:           strconv.FormatUint(uint64(s.ID), 10),
+		Status:       procedureStatus(s.Status),
+		Code: CodeableConcept{
+			Coding: []Coding{{System: domain.CodeSystemSNOMED, Code: code.Code, Display: code.Display}},
+			Text:   domain.GetOperationTypeDisplayName(s.OperationType),
+		},
+		Subject:           patientRef(p),
+		PerformedDateTime: formatDateTime(s.ScheduledDate),
+		BodySite:          bodySites(s.Eye),
+	}
+
+	performer := Reference{Reference: "Practitioner/" + strconv.FormatUint(uint64(s.SurgeonID), 10)}
+	if s.Surgeon != nil {
+		performer.Display = s.Surgeon.LastName + " " + s.Surgeon.FirstName
+	}
+	proc.Performer = []ProcedurePerformer{{Actor: performer}}
+
+	if s.Notes != "" {
+		proc.Note = []Annotation{{Text: s.Notes}}
+	}
+	return proc
+}
+
+// ProcedureResources преобразует список операций пациента в FHIR Procedure
+func ProcedureResources(p *domain.Patient, surgeries []domain.Surgery) []Procedure {
+	procedures := make([]Procedure, 0, len(surgeries))
+	for i := range surgeries {
+		procedures = append(procedures, ProcedureResource(p, &surgeries[i]))
+	}
+	return procedures
+}
+
+func biometryObservation(p *domain.Patient, calc *domain.IOLCalculation, measurement, suffix string, value float64) (Observation, bool) {
+	code, ok := domain.BiometryLOINC(calc.Eye, measurement)
+	if !ok || value == 0 {
+		return Observation{}, false
+	}
+	return Observation{
+		ResourceType: "Observation",
+		ID:           fmt.Sprintf("iol-%d-%s", calc.ID, suffix),
+		Status:       "final",
+		Category:     []CodeableConcept{{Coding: []Coding{{System: systemObservationCategory, Code: "exam"}}}},
+		Code: CodeableConcept{
+			Coding: []Coding{{System: domain.CodeSystemLOINC, Code: code.Code, Display: code.Display}},
+			Text:   code.Display,
+		},
+		Subject:           patientRef(p),
+		EffectiveDateTime: formatDateTime(calc.CreatedAt),
+		ValueQuantity:     &Quantity{Value: value, Unit: code.Unit, System: systemUCUM, Code: ucumCode(code.Unit)},
+		BodySite:          bodySite(calc.Eye),
+	}, true
+}
+
+func ucumCode(unit string) string {
+	if unit == "D" {
+		return "[diop]"
+	}
+	return unit
+}
+
+// IOLObservations строит биометрические Observation из расчётов ИОЛ
+func IOLObservations(p *domain.Patient, calcs []domain.IOLCalculation) []Observation {
+	var obs []Observation
+	for i := range calcs {
+		calc := &calcs[i]
+		measurements := []struct {
+			kind, suffix string
+			value        float64
+		}{
+			{domain.BiometryAxialLength, "al", calc.AxialLength},
+			{domain.BiometryK1, "k1", calc.Keratometry1},
+			{domain.BiometryK2, "k2", calc.Keratometry2},
+			{domain.BiometryACD, "acd", calc.ACD},
+		}
+		for _, m := range measurements {
+			if o, ok := biometryObservation(p, calc, m.kind, m.suffix, m.value); ok {
+				obs = append(obs, o)
+			}
+		}
+	}
+	return obs
+}
+
+var iopMethodDisplay = map[domain.IOPMethod]string{
+	domain.IOPMethodNonContact: "Пневмотонометрия",
+	domain.IOPMethodGoldmann:   "Тонометрия по Гольдману",
+	domain.IOPMethodMaklakov:   "Тонометрия по Маклакову",
+	domain.IOPMethodRebound:    "Рикошетная тонометрия",
+	domain.IOPMethodPalpation:  "Пальпаторно",
+}
+
+func examObservation(p *domain.Patient, exam *domain.EyeExam, suffix string, code CodeableConcept) Observation {
+	return Observation{
+		ResourceType:      "Observation",
+		ID:                fmt.Sprintf("exam-%d-%s", exam.ID, suffix),
+		Status:            "final",
+		Category:          []CodeableConcept{{Coding: []Coding{{System: systemObservationCategory, Code: "exam"}}}},
+		Code:              code,
+		Subject:           patientRef(p),
+		EffectiveDateTime: exam.ExamDate.Format(dateLayout),
+		BodySite:          bodySite(exam.Eye),
+	}
+}
+
+// examCode — LOINC-код показателя; text уточняет показатель, если код общий
+func examCode(eye, measurement, text string) CodeableConcept {
+	code, ok := domain.ExamLOINC(eye, measurement)
+	if !ok {
+		return CodeableConcept{Text: text}
+	}
+	if text == "" {
+		text = code.Display
+	}
+	return CodeableConcept{Coding: []Coding{{System: domain.CodeSystemLOINC, Code: code.Code, Display: code.Display}}, Text: text}
+}
+
+func diopters(v float64) *Quantity {
+	return &Quantity{Value: v, Unit: "D", System: systemUCUM, Code: ucumCode("D")}
+}
+
+// EyeExamObservations строит Observation из структурированных осмотров:
+// острота зрения, рефракция (компоненты сфера/цилиндр/ось), ВГД с методом,
+// LOCS III, биомикроскопия и глазное дно
+func EyeExamObservations(p *domain.Patient, exams []domain.EyeExam) []Observation {
+	var obs []Observation
+	for i := range exams {
+		exam := &exams[i]
+
+		for _, va := range []struct {
+			suffix, text string
+			value        *float64
+		}{
+			{"ucva", "Острота зрения без коррекции", exam.UCVA},
+			{"bcva", "Острота зрения с коррекцией", exam.BCVA},
+		} {
+			if va.value == nil {
+				continue
+			}
+			o := examObservation(p, exam, va.suffix, examCode(exam.Eye, domain.ExamVisualAcuity, va.text))
+			o.ValueQuantity = &Quantity{Value: *va.value, System: systemUCUM, Code: "1"}
+			obs = append(obs, o)
+		}
+
+		if se, ok := exam.SphericalEquivalent(); ok {
+			o := examObservation(p, exam, "refraction", examCode(exam.Eye, domain.ExamRefraction, ""))
+			o.ValueQuantity = diopters(se)
+			o.Component = []ObservationComponent{{Code: CodeableConcept{Text: "Сфера"}, ValueQuantity: diopters(*exam.Sphere)}}
+			if exam.Cylinder != nil {
+				o.Component = append(o.Component, ObservationComponent{Code: CodeableConcept{Text: "Цилиндр"}, ValueQuantity: diopters(*exam.Cylinder)})
+			}
+			if exam.Axis != nil {
+				o.Component = append(o.Component, ObservationComponent{Code: CodeableConcept{Text: "Ось"}, ValueQuantity: &Quantity{Value: *exam.Axis, Unit: "deg", System: systemUCUM, Code: "deg"}})
+			}
+			obs = append(obs, o)
+		}
+
+		if exam.IOP != nil {
+			o := examObservation(p, exam, "iop", examCode(exam.Eye, domain.ExamIOP, ""))
+			o.ValueQuantity = &Quantity{Value: *exam.IOP, Unit: "mm[Hg]", System: systemUCUM, Code: "mm[Hg]"}
+			if display, ok := iopMethodDisplay[exam.IOPMethod]; ok {
+				o.Method = &CodeableConcept{Text: display}
+			}
+			obs = append(obs, o)
+		}
+
+		if !exam.LOCS.IsZero() {
+			o := examObservation(p, exam, "locs", CodeableConcept{Text: "Помутнение хрусталика LOCS III"})
+			o.ValueString = exam.LOCS.String()
+			for _, g := range []struct {
+				text  string
+				value *float64
+			}{
+				{"Ядерная опалесценция (NO)", exam.LOCS.NO},
+				{"Окраска ядра (NC)", exam.LOCS.NC},
+				{"Кортикальное помутнение (C)", exam.LOCS.C},
+				{"Задняя субкапсулярная катаракта (P)", exam.LOCS.P},
+			} {
+				if g.value != nil {
+					o.Component = append(o.Component, ObservationComponent{Code: CodeableConcept{Text: g.text}, ValueQuantity: &Quantity{Value: *g.value}})
+				}
+			}
+			obs = append(obs, o)
+		}
+
+		for _, f := range []struct {
+			suffix, text, value string
+		}{
+			{"biomicroscopy", "Биомикроскопия", exam.Biomicroscopy},
+			{"fundus", "Глазное дно", exam.Fundus},
+		} {
+			if f.value == "" {
+				continue
+			}
+			o := examObservation(p, exam, f.suffix, CodeableConcept{Text: f.text})
+			o.ValueString = f.value
+			obs = append(obs, o)
+		}
+	}
+	return obs
+}
+
+// ChecklistObservations строит Observation по выполненным пунктам чек-листа
+func ChecklistObservations(p *domain.Patient, items []domain.ChecklistItem) []Observation {
+	var obs []Observation
+	for _, item := range items {
+		if item.Result == "" && item.Status != domain.ChecklistStatusCompleted {
+			continue
+		}
+
+		category := "exam"
+		if item.Category == "Анализы" {
+			category = "laboratory"
+		}
+		status := "preliminary"
+		if item.Status == domain.ChecklistStatusCompleted {
+			status = "final"
+		}
+		effective := item.UpdatedAt
+		if item.CompletedAt != nil {
+			effective = *item.CompletedAt
+		}
+
+		o := Observation{
+			ResourceType:      "Observation",
+			ID:                fmt.Sprintf("checklist-%d", item.ID),
+			Status:            status,
+			Category:          []CodeableConcept{{Coding: []Coding{{System: systemObservationCategory, Code: category}}}},
+			Code:              CodeableConcept{Text: item.Name},
+			Subject:           patientRef(p),
+			EffectiveDateTime: formatDateTime(effective),
+			ValueString:       item.Result,
+		}
+		if item.Notes != "" {
+			o.Note = []Annotation{{Text: item.Notes}}
+		}
+		obs = append(obs, o)
+	}
+	return obs
+}
+
+// MetadataObservations строит Observation из LOINC-наблюдений в медицинских метаданных
+func MetadataObservations(p *domain.Patient) []Observation {
+	if p.MedicalMetadata == nil {
+		return nil
+	}
+	var obs []Observation
+	for i, l := range p.MedicalMetadata.Observations {
+		o := Observation{
+			ResourceType: "Observation",
+			ID:           fmt.Sprintf("patient-%d-obs-%d", p.ID, i+1),
+			Status:       "final",
+			Code: CodeableConcept{
+				Coding: []Coding{{System: domain.CodeSystemLOINC, Code: l.Code, Display: l.Display}},
+				Text:   l.Display,
+			},
+			Subject:           patientRef(p),
+			EffectiveDateTime: formatDateTime(l.ObservedAt),
+		}
+		if v, err := strconv.ParseFloat(strings.Replace(l.Value, ",", ".", 1), 64); err == nil {
+			o.ValueQuantity = &Quantity{Value: v, Unit: l.Unit, System: systemUCUM, Code: ucumCode(l.Unit)}
+		} else {
+			o.ValueString = l.Value
+		}
+		obs = append(obs, o)
+	}
+	return obs
+}
+
+// NewSearchsetBundle собирает Bundle типа searchset из набора ресурсов
+func NewSearchsetBundle(resources ...interface{}) (*Bundle, error) {
+	bundle := &Bundle{
+		ResourceType: "Bundle",
+		Type:         "searchset",
+		Timestamp:    time.Now().Format(time.RFC3339),
+	}
+	for _, r := range resources {
+		raw, err := json.Marshal(r)
+		if err != nil {
+			return nil, err
+		}
+		entry := BundleEntry{Resource: raw}
+		if ref := resourceURL(r); ref != "" {
+			entry.FullURL = ref
+		}
+		bundle.Entry = append(bundle.Entry, entry)
+	}
+	total := len(bundle.Entry)
+	bundle.Total = &total
+	return bundle, nil
+}
+
+func resourceURL(r interface{}) string {
+	switch v := r.(type) {
+	case Patient:
+		return "Patient/" + v.ID
+	case Condition:
+		return "Condition/" + v.ID
+	case Procedure:
+		return "Procedure/" + v.ID
+	case Observation:
+		return "Observation/" + v.ID
+	}
+	return ""
+}
+
+// --- Import ---
+
+// ImportedPatient — данные пациента, извлечённые из FHIR Patient
+type ImportedPatient struct {
+	ResourceID    string
+	FirstName     string
+	LastName      string
+	MiddleName    string
+	DateOfBirth   time.Time
+	Gender        string
+	Phone         string
+	Email         string
+	Address       string
+	SNILS         string
+	OMSPolicy     string
+	PolicyNumber  string
+	DistrictCode  string
+	OperationType domain.OperationType
+	Eye           string
+}
+
+// ParsePatient разбирает FHIR Patient для импорта
+func ParsePatient(raw json.RawMessage) (*ImportedPatient, error) {
+	var res Patient
+	if err := json.Unmarshal(raw, &res); err != nil {
+		return nil, fmt.Errorf("некорректный ресурс Patient: %w", err)
+	}
+	if res.ResourceType != "Patient" {
+		return nil, errors.New("ожидался ресурс Patient")
+	}
+
+	imp := &ImportedPatient{ResourceID: res.ID}
+
+	if len(res.Name) > 0 {
+		name := res.Name[0]
+		for _, n := range res.Name {
+			if n.Use == "official" {
+				name = n
+				break
+			}
+		}
+		imp.LastName = name.Family
+		if len(name.Given) > 0 {
+			imp.FirstName = name.Given[0]
+		}
+		if len(name.Given) > 1 {
+			imp.MiddleName = strings.Join(name.Given[1:], " ")
+		}
+	}
+	if imp.FirstName == "" || imp.LastName == "" {
+		return nil, errors.New("у пациента должны быть указаны фамилия и имя")
+	}
+
+	if res.BirthDate != "" {
+		dob, err := time.Parse(dateLayout, res.BirthDate)
+		if err != nil {
+			return nil, errors.New("неверный формат birthDate, используйте ГГГГ-ММ-ДД")
+		}
+		imp.DateOfBirth = dob
+	}
+
+	switch res.Gender {
+	case "", "male", "female":
+		imp.Gender = res.Gender
+	case "other", "unknown":
+	default:
+		return nil, fmt.Errorf("неизвестное значение gender: %s", res.Gender)
+	}
+
+	for _, id := range res.Identifier {
+		switch id.System {
+		case SystemSNILS:
+			imp.SNILS = id.Value
+		case SystemOMS:
+			imp.OMSPolicy = id.Value
+		case SystemPolicy:
+			imp.PolicyNumber = id.Value
+		case SystemExternalID:
+			if imp.ResourceID == "" {
+				imp.ResourceID = id.Value
+			}
+		}
+	}
+
+	for _, t := range res.Telecom {
+		switch t.System {
+		case "phone":
+			if imp.Phone == "" {
+				imp.Phone = t.Value
+			}
+		case "email":
+			if imp.Email == "" {
+				imp.Email = t.Value
+			}
+		}
+	}
+	if len(res.Address) > 0 {
+		imp.Address = res.Address[0].Text
+	}
+	if res.ManagingOrganization != nil && res.ManagingOrganization.Identifier != nil &&
+		res.ManagingOrganization.Identifier.System == SystemDistrictCode {
+		imp.DistrictCode = res.ManagingOrganization.Identifier.Value
+	}
+
+	for _, ext := range res.Extension {
+		switch ext.URL {
+		case ExtOperationType:
+			imp.OperationType = domain.OperationType(ext.ValueCode)
+		case ExtEye:
+			imp.Eye = domain.NormalizeEye(ext.ValueCode)
+		}
+	}
+
+	return imp, nil
+}
+
+// ImportedCondition — диагноз, извлечённый из FHIR Condition
+type ImportedCondition struct {
+	SubjectReference string
+	Code             domain.ICD10Code
+	Text             string
+}
+
+// ParseCondition разбирает FHIR Condition для импорта
+func ParseCondition(raw json.RawMessage) (*ImportedCondition, error) {
+	var res Condition
+	if err := json.Unmarshal(raw, &res); err != nil {
+		return nil, fmt.Errorf("некорректный ресурс Condition: %w", err)
+	}
+	if res.ResourceType != "Condition" {
+		return nil, errors.New("ожидался ресурс Condition")
+	}
+	if res.Subject.Reference == "" {
+		return nil, errors.New("у Condition не указан subject")
+	}
+
+	imp := &ImportedCondition{SubjectReference: res.Subject.Reference, Text: res.Code.Text}
+	for _, c := range res.Code.Coding {
+		if c.System == domain.CodeSystemICD10 {
+			imp.Code = domain.ICD10Code{Code: c.Code, Display: c.Display, System: "ICD-10"}
+			break
+		}
+	}
+	if imp.Code.Code == "" && imp.Text == "" {
+		return nil, errors.New("у Condition не указан код МКБ-10 или текст диагноза")
+	}
+	if imp.Text == "" {
+		imp.Text = imp.Code.Display
+	}
+	return imp, nil
+}
+
+// PatientReferenceID разбирает ссылку вида Patient/{id} на пациента системы
+func PatientReferenceID(ref string) (uint, bool) {
+	idStr, ok := strings.CutPrefix(ref, "Patient/")
+	if !ok {
+		return 0, false
+	}
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// ReferencedPatientIDs возвращает id существующих пациентов, на которых Bundle
+// ссылается явно: PUT Patient/{id} и Condition.subject. Пациенты, найденные
+// по СНИЛС или полису, определяются только при импорте.
+func ReferencedPatientIDs(bundle Bundle) []uint {
+	var ids []uint
+	seen := map[uint]bool{}
+	add := func(ref string) {
+		if id, ok := PatientReferenceID(ref); ok && !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	for _, e := range bundle.Entry {
+		if e.Request != nil && strings.EqualFold(e.Request.Method, "PUT") {
+			add(e.Request.URL)
+		}
+		if ResourceType(e.Resource) == "Condition" {
+			if c, err := ParseCondition(e.Resource); err == nil {
+				add(c.SubjectReference)
+			}
+		}
+	}
+	return ids
+}
@@ -0,0 +1,250 @@
+package fhir
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/beercut-team/backend-boilerplate/internal/domain"
+)
+
+func testPatient() *domain.Patient {
+	return &domain.Patient{
+		ID:            42,
+		AccessCode:    "abcd1234",
+		FirstName:     "Иван",
+		LastName:      "Петров",
+		MiddleName:    "Сергеевич",
+		DateOfBirth:   time.Date(1955, 3, 14, 0, 0, 0, 0, time.UTC),
+		Phone:         "+79001234567",
+		SNILs:         "112-233-445 95",
+		OMSPolicy:     "1234567890123456",
+		Gender:        "male",
+		Diagnosis:     "Старческая ядерная катаракта",
+		OperationType: domain.OperationPhacoemulsification,
+		Eye:           "OD",
+		Status:        domain.PatientStatusInProgress,
+		District:      &domain.District{Name: "Центральный", Code: "CEN"},
+	}
+}
+
+func TestPatientRoundTrip(t *testing.T) {
+	p := testPatient()
+	raw, err := json.Marshal(PatientResource(p))
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+
+	imp, err := ParsePatient(raw)
+	if err != nil {
+		t.Fatalf("ParsePatient: %v", err)
+	}
+
+	checks := []struct {
+		name, got, want string
+	}{
+		{"first name", imp.FirstName, p.FirstName},
+		{"last name", imp.LastName, p.LastName},
+		{"middle name", imp.MiddleName, p.MiddleName},
+		{"snils", imp.SNILS, p.SNILs},
+		{"oms", imp.OMSPolicy, p.OMSPolicy},
+		{"gender", imp.Gender, p.Gender},
+		{"phone", imp.Phone, p.Phone},
+		{"district", imp.DistrictCode, "CEN"},
+		{"operation type", string(imp.OperationType), string(p.OperationType)},
+		{"eye", imp.Eye, p.Eye},
+		{"birth date", imp.DateOfBirth.Format(dateLayout), "1955-03-14"},
+	}
+	for _, c := range checks {
+		t.Run(c.name, func(t *testing.T) {
+			if c.got != c.want {
+				t.Errorf("got %q, want %q", c.got, c.want)
+			}
+		})
+	}
+}
+
+func TestParsePatientValidation(t *testing.T) {
+	tests := []struct {
+		name string
+		json string
+	}{
+		{"wrong type", `{"resourceType":"Observation"}`},
+		{"no name", `{"resourceType":"Patient"}`},
+		{"bad birth date", `{"resourceType":"Patient","name":[{"family":"Петров","given":["Иван"]}],"birthDate":"14.03.1955"}`},
+		{"bad gender", `{"resourceType":"Patient","name":[{"family":"Петров","given":["Иван"]}],"gender":"x"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParsePatient(json.RawMessage(tt.json)); err == nil {
+				t.Error("expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestConditionResources(t *testing.T) {
+	p := testPatient()
+
+	conds := ConditionResources(p)
+	if len(conds) != 1 || conds[0].Code.Text != p.Diagnosis || len(conds[0].Code.Coding) != 0 {
+		t.Fatalf("expected free-text condition, got %+v", conds)
+	}
+
+	p.MedicalMetadata = &domain.MedicalStandardsMetadata{
+		DiagnosisCodes: []domain.ICD10Code{{Code: "H25.1", Display: "Старческая ядерная катаракта"}},
+	}
+	conds = ConditionResources(p)
+	if len(conds) != 1 || conds[0].Code.Coding[0].Code != "H25.1" || conds[0].Code.Coding[0].System != domain.CodeSystemICD10 {
+		t.Fatalf("expected ICD-10 condition, got %+v", conds)
+	}
+	if conds[0].Subject.Reference != "Patient/42" {
+		t.Errorf("subject = %q, want Patient/42", conds[0].Subject.Reference)
+	}
+}
+
+func TestParseCondition(t *testing.T) {
+	raw, _ := json.Marshal(Condition{
+		ResourceType: "Condition",
+		Code:         CodeableConcept{Coding: []Coding{{System: domain.CodeSystemICD10, Code: "H40.1", Display: "ПОУГ"}}},
+		Subject:      Reference{Reference: "urn:uuid:1"},
+	})
+	c, err := ParseCondition(raw)
+	if err != nil {
+		t.Fatalf("ParseCondition: %v", err)
+	}
+	if c.Code.Code != "H40.1" || c.Text != "ПОУГ" || c.SubjectReference != "urn:uuid:1" {
+		t.Errorf("unexpected condition: %+v", c)
+	}
+
+	if _, err := ParseCondition(json.RawMessage(`{"resourceType":"Condition","code":{"text":"x"}}`)); err == nil {
+		t.Error("expected error for condition without subject")
+	}
+}
+
+func TestReferencedPatientIDs(t *testing.T) {
+	bundle := Bundle{ResourceType: "Bundle", Type: "transaction", Entry: []BundleEntry{
+		{Resource: json.RawMessage(`{"resourceType":"Patient"}`), Request: &BundleEntryRequest{Method: "PUT", URL: "Patient/3"}},
+		{Resource: json.RawMessage(`{"resourceType":"Patient"}`), Request: &BundleEntryRequest{Method: "POST", URL: "Patient/4"}},
+		{Resource: json.RawMessage(`{"resourceType":"Condition","subject":{"reference":"Patient/5"},"code":{"text":"ПОУГ"}}`)},
+		{Resource: json.RawMessage(`{"resourceType":"Condition","subject":{"reference":"Patient/3"},"code":{"text":"ПОУГ"}}`)},
+		{Resource: json.RawMessage(`{"resourceType":"Condition","subject":{"reference":"urn:uuid:1"},"code":{"text":"ПОУГ"}}`)},
+	}}
+
+	ids := ReferencedPatientIDs(bundle)
+	if len(ids) != 2 || ids[0] != 3 || ids[1] != 5 {
+		t.Errorf("ReferencedPatientIDs = %v, want [3 5]", ids)
+	}
+}
+
+func TestProcedureStatus(t *testing.T) {
+	tests := []struct {
+		status domain.SurgeryStatus
+		want   string
+	}{
+		{domain.SurgeryStatusScheduled, "preparation"},
+		{domain.SurgeryStatusCompleted, "completed"},
+		{domain.SurgeryStatusCancelled, "not-done"},
+	}
+	p := testPatient()
+	for _, tt := range tests {
+		t.Run(string(tt.status), func(t *testing.T) {
+			proc := ProcedureResource(p, &domain.Surgery{ID: 1, SurgeonID: 7, OperationType: p.OperationType, Eye: "OD", Status: tt.status})
+			if proc.Status != tt.want {
+				t.Errorf("status = %q, want %q", proc.Status, tt.want)
+			}
+			if proc.Code.Coding[0].Code != "231744001" {
+				t.Errorf("code = %q, want 231744001", proc.Code.Coding[0].Code)
+			}
+		})
+	}
+}
+
+func TestIOLObservations(t *testing.T) {
+	p := testPatient()
+	calcs := []domain.IOLCalculation{
+		{ID: 1, Eye: "OD", AxialLength: 23.5, Keratometry1: 43.5, Keratometry2: 44.5, ACD: 3.1},
+		{ID: 2, Eye: "OS", AxialLength: 23.7, Keratometry1: 43.0, Keratometry2: 44.0},
+	}
+
+	obs := IOLObservations(p, calcs)
+	if len(obs) != 7 {
+		t.Fatalf("expected 7 observations (ACD omitted for OS), got %d", len(obs))
+	}
+
+	want := map[string]string{
+		"iol-1-al":  "79894-2",
+		"iol-1-k1":  "79897-5",
+		"iol-1-acd": "79901-5",
+		"iol-2-al":  "79895-9",
+		"iol-2-k2":  "79900-7",
+	}
+	for _, o := range obs {
+		if code, ok := want[o.ID]; ok && o.Code.Coding[0].Code != code {
+			t.Errorf("%s: code = %s, want %s", o.ID, o.Code.Coding[0].Code, code)
+		}
+	}
+}
+
+func TestEyeExamObservations(t *testing.T) {
+	p := testPatient()
+	v := func(f float64) *float64 { return &f }
+	exams := []domain.EyeExam{
+		{
+			ID: 5, Eye: "OD", ExamDate: time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC),
+			UCVA: v(0.2), BCVA: v(0.5),
+			Sphere: v(-1.0), Cylinder: v(-1.5), Axis: v(180),
+			IOP: v(19), IOPMethod: domain.IOPMethodMaklakov,
+			LOCS:   domain.LOCSGrade{NO: v(3.5), NC: v(3.0)},
+			Fundus: "ДЗН бледно-розовый, границы чёткие",
+		},
+		{ID: 6, Eye: "OS", ExamDate: time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC), IOP: v(16), IOPMethod: domain.IOPMethodNonContact},
+	}
+
+	obs := EyeExamObservations(p, exams)
+	byID := map[string]Observation{}
+	for _, o := range obs {
+		byID[o.ID] = o
+	}
+	if len(byID) != 7 {
+		t.Fatalf("expected 7 observations, got %d: %+v", len(byID), obs)
+	}
+
+	tests := []struct {
+		id, code string
+		value    float64
+	}{
+		{"exam-5-ucva", "79905-6", 0.2},
+		{"exam-5-bcva", "79905-6", 0.5},
+		{"exam-5-refraction", "79907-2", -1.75},
+		{"exam-5-iop", "79909-8", 19},
+		{"exam-6-iop", "79910-6", 16},
+	}
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			o, ok := byID[tt.id]
+			if !ok {
+				t.Fatalf("missing observation %s", tt.id)
+			}
+			if o.Code.Coding[0].Code != tt.code || o.ValueQuantity == nil || o.ValueQuantity.Value != tt.value {
+				t.Errorf("code %s value %+v, want %s %v", o.Code.Coding[0].Code, o.ValueQuantity, tt.code, tt.value)
+			}
+			if o.EffectiveDateTime != "2026-03-02" || o.BodySite == nil {
+				t.Errorf("effective %q, body site %+v", o.EffectiveDateTime, o.BodySite)
+			}
+		})
+	}
+
+	if refraction := byID["exam-5-refraction"]; len(refraction.Component) != 3 {
+		t.Errorf("refraction components = %+v, want sphere, cylinder and axis", refraction.Component)
+	}
+	if iop := byID["exam-5-iop"]; iop.Method == nil || iop.Method.Text != "Тонометрия по Маклакову" {
+		t.Errorf("iop method = %+v", iop.Method)
+	}
+	if locs := byID["exam-5-locs"]; locs.ValueString != "NO3.5 NC3.0" || len(locs.Component) != 2 {
+		t.Errorf("locs = %q, %d components", locs.ValueString, len(locs.Component))
+	}
+	if fundus := byID["exam-5-fundus"]; fundus.ValueString != exams[0].Fundus {
+		t.Errorf("fundus = %q", fundus.ValueString)
+	}
+}
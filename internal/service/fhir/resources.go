@@ -0,0 +1,199 @@
+package fhir
+
+import "encoding/json"
+
+// Minimal subset of FHIR R4 resources used for export/import.
+// See https://hl7.org/fhir/R4/
+
+const ContentType = "application/fhir+json"
+
+type Coding struct {
+	System  string `json:"system,omitempty"`
+	Code    string `json:"code,omitempty"`
+	Display string `json:"display,omitempty"`
+}
+
+type CodeableConcept struct {
+	Coding []Coding `json:"coding,omitempty"`
+	Text   string   `json:"text,omitempty"`
+}
+
+type Identifier struct {
+	Use    string `json:"use,omitempty"`
+	System string `json:"system,omitempty"`
+	Value  string `json:"value,omitempty"`
+}
+
+type Reference struct {
+	Reference  string      `json:"reference,omitempty"`
+	Identifier *Identifier `json:"identifier,omitempty"`
+	Display    string      `json:"display,omitempty"`
+}
+
+type Extension struct {
+	URL         string `json:"url"`
+	ValueString string `json:"valueString,omitempty"`
+	ValueCode   string `json:"valueCode,omitempty"`
+}
+
+type HumanName struct {
+	Use    string   `json:"use,omitempty"`
+	Text   string   `json:"text,omitempty"`
+	Family string   `json:"family,omitempty"`
+	Given  []string `json:"given,omitempty"`
+}
+
+type ContactPoint struct {
+	System string `json:"system,omitempty"` // phone, email
+	Value  string `json:"value,omitempty"`
+}
+
+type Address struct {
+	Text string `json:"text,omitempty"`
+}
+
+type Meta struct {
+	LastUpdated string `json:"lastUpdated,omitempty"`
+}
+
+type Quantity struct {
+	Value  float64 `json:"value"`
+	Unit   string  `json:"unit,omitempty"`
+	System string  `json:"system,omitempty"`
+	Code   string  `json:"code,omitempty"`
+}
+
+type Period struct {
+	Start string `json:"start,omitempty"`
+	End   string `json:"end,omitempty"`
+}
+
+type Patient struct {
+	ResourceType         string         `json:"resourceType"`
+	ID                   string         `json:"id,omitempty"`
+	Meta                 *Meta          `json:"meta,omitempty"`
+	Extension            []Extension    `json:"extension,omitempty"`
+	Identifier           []Identifier   `json:"identifier,omitempty"`
+	Active               bool           `json:"active"`
+	Name                 []HumanName    `json:"name,omitempty"`
+	Telecom              []ContactPoint `json:"telecom,omitempty"`
+	Gender               string         `json:"gender,omitempty"`
+	BirthDate            string         `json:"birthDate,omitempty"`
+	Address              []Address      `json:"address,omitempty"`
+	ManagingOrganization *Reference     `json:"managingOrganization,omitempty"`
+}
+
+type Condition struct {
+	ResourceType       string            `json:"resourceType"`
+	ID                 string            `json:"id,omitempty"`
+	ClinicalStatus     *CodeableConcept  `json:"clinicalStatus,omitempty"`
+	VerificationStatus *CodeableConcept  `json:"verificationStatus,omitempty"`
+	Code               CodeableConcept   `json:"code"`
+	BodySite           []CodeableConcept `json:"bodySite,omitempty"`
+	Subject            Reference         `json:"subject"`
+	RecordedDate       string            `json:"recordedDate,omitempty"`
+}
+
+type Procedure struct {
+	ResourceType      string               `json:"resourceType"`
+	ID                string               `json:"id,omitempty"`
+	Status            string               `json:"status"`
+	Code              CodeableConcept      `json:"code"`
+	Subject           Reference            `json:"subject"`
+	PerformedDateTime string               `json:"performedDateTime,omitempty"`
+	Performer         []ProcedurePerformer `json:"performer,omitempty"`
+	BodySite          []CodeableConcept    `json:"bodySite,omitempty"`
+	Note              []Annotation         `json:"note,omitempty"`
+}
+
+type ProcedurePerformer struct {
+	Actor Reference `json:"actor"`
+}
+
+type Annotation struct {
+	Text string `json:"text"`
+}
+
+type Observation struct {
+	ResourceType      string                 `json:"resourceType"`
+	ID                string                 `json:"id,omitempty"`
+	Status            string                 `json:"status"`
+	Category          []CodeableConcept      `json:"category,omitempty"`
+	Code              CodeableConcept        `json:"code"`
+	Subject           Reference              `json:"subject"`
+	EffectiveDateTime string                 `json:"effectiveDateTime,omitempty"`
+	ValueQuantity     *Quantity              `json:"valueQuantity,omitempty"`
+	ValueString       string                 `json:"valueString,omitempty"`
+	BodySite          *CodeableConcept       `json:"bodySite,omitempty"`
+	Method            *CodeableConcept       `json:"method,omitempty"`
+	Note              []Annotation           `json:"note,omitempty"`
+	Component         []ObservationComponent `json:"component,omitempty"`
+}
+
+type ObservationComponent struct {
+	Code          CodeableConcept `json:"code"`
+	ValueQuantity *Quantity       `json:"valueQuantity,omitempty"`
+}
+
+type Bundle struct {
+	ResourceType string        `json:"resourceType"`
+	ID           string        `json:"id,omitempty"`
+	Type         string        `json:"type"` // searchset, transaction, transaction-response, batch, batch-response
+	Timestamp    string        `json:"timestamp,omitempty"`
+	Total        *int          `json:"total,omitempty"`
+	Entry        []BundleEntry `json:"entry,omitempty"`
+}
+
+type BundleEntry struct {
+	FullURL  string               `json:"fullUrl,omitempty"`
+	Resource json.RawMessage      `json:"resource,omitempty"`
+	Request  *BundleEntryRequest  `json:"request,omitempty"`
+	Response *BundleEntryResponse `json:"response,omitempty"`
+}
+
+type BundleEntryRequest struct {
+	Method string `json:"method"`
+	URL    string `json:"url"`
+}
+
+type BundleEntryResponse struct {
+	Status   string `json:"status"`
+	Location string `json:"location,omitempty"`
+}
+
+type OperationOutcome struct {
+	ResourceType string                  `json:"resourceType"`
+	Issue        []OperationOutcomeIssue `json:"issue"`
+}
+
+type OperationOutcomeIssue struct {
+	Severity    string `json:"severity"` // fatal, error, warning, information
+	Code        string `json:"code"`     // invalid, not-found, forbidden, exception...
+	Diagnostics string `json:"diagnostics,omitempty"`
+}
+
+// NewOperationOutcome создаёт OperationOutcome с одной ошибкой
+func NewOperationOutcome(code, diagnostics string) OperationOutcome {
+	return OperationOutcome{
+		ResourceType: "OperationOutcome",
+		Issue: []OperationOutcomeIssue{{
+			Severity:    "error",
+			Code:        code,
+			Diagnostics: diagnostics,
+		}},
+	}
+}
+
+// resourceHeader используется для определения типа ресурса при разборе Bundle
+type resourceHeader struct {
+	ResourceType string `json:"resourceType"`
+}
+
+// ResourceType возвращает resourceType из сырого JSON ресурса
+func ResourceType(raw json.RawMessage) string {
+	var h resourceHeader
+	if err := json.Unmarshal(raw, &h); err != nil {
+		return ""
+	}
+	return h.ResourceType
+}
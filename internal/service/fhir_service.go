@@ -0,0 +1,622 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/beercut-team/backend-boilerplate/internal/domain"
+	"github.com/beercut-team/backend-boilerplate/internal/repository"
+	"github.com/beercut-team/backend-boilerplate/internal/service/fhir"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+type FHIRService interface {
+	GetPatient(ctx context.Context, id uint) (*fhir.Patient, error)
+	GetConditions(ctx context.Context, patientID uint) ([]fhir.Condition, error)
+	GetProcedures(ctx context.Context, patientID uint) ([]fhir.Procedure, error)
+	GetProcedure(ctx context.Context, surgeryID uint) (*fhir.Procedure, error)
+	GetObservations(ctx context.Context, patientID uint) ([]fhir.Observation, error)
+	Everything(ctx context.Context, patientID uint) (*fhir.Bundle, error)
+	// ImportBundle проверяет право изменения каждого найденного существующего пациента
+	ImportBundle(ctx context.Context, bundle fhir.Bundle, userID uint, role domain.Role) (*fhir.Bundle, error)
+}
+
+type fhirService struct {
+	db            *gorm.DB
+	patientRepo   repository.PatientRepository
+	checklistRepo repository.ChecklistRepository
+	iolRepo       repository.IOLRepository
+	examRepo      repository.EyeExamRepository
+	surgeryRepo   repository.SurgeryRepository
+	districtRepo  repository.DistrictRepository
+	userRepo      repository.UserRepository
+	audit         AuditService
+	policy        AccessPolicy
+}
+
+func NewFHIRService(db *gorm.DB, patientRepo repository.PatientRepository, checklistRepo repository.ChecklistRepository, iolRepo repository.IOLRepository, examRepo repository.EyeExamRepository, surgeryRepo repository.SurgeryRepository, districtRepo repository.DistrictRepository, userRepo repository.UserRepository, audit AuditService, policy AccessPolicy) FHIRService {
+	return &fhirService{
+		db:            db,
+		patientRepo:   patientRepo,
+		checklistRepo: checklistRepo,
+		iolRepo:       iolRepo,
+		examRepo:      examRepo,
+		surgeryRepo:   surgeryRepo,
+		districtRepo:  districtRepo,
+		userRepo:      userRepo,
+		audit:         audit,
+		policy:        policy,
+	}
+}
+
+func (s *fhirService) findPatient(ctx context.Context, id uint) (*domain.Patient, error) {
+	p, err := s.patientRepo.FindByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, notFoundError("пациент не найден")
+		}
+		return nil, err
+	}
+	return p, nil
+}
+
+func (s *fhirService) GetPatient(ctx context.Context, id uint) (*fhir.Patient, error) {
+	p, err := s.findPatient(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	s.audit.LogRead(ctx, domain.AuditEntityPatient, p.ID)
+	res := fhir.PatientResource(p)
+	return &res, nil
+}
+
+func (s *fhirService) GetConditions(ctx context.Context, patientID uint) ([]fhir.Condition, error) {
+	p, err := s.findPatient(ctx, patientID)
+	if err != nil {
+		return nil, err
+	}
+	return fhir.ConditionResources(p), nil
+}
+
+func (s *fhirService) GetProcedures(ctx context.Context, patientID uint) ([]fhir.Procedure, error) {
+	p, err := s.findPatient(ctx, patientID)
+	if err != nil {
+		return nil, err
+	}
+	surgeries, err := s.surgeryRepo.FindByPatient(ctx, patientID)
+	if err != nil {
+		return nil, err
+	}
+	return fhir.ProcedureResources(p, surgeries), nil
+}
+
+func (s *fhirService) GetProcedure(ctx context.Context, surgeryID uint) (*fhir.Procedure, error) {
+	surgery, err := s.surgeryRepo.FindByID(ctx, surgeryID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, notFoundError("операция не найдена")
+		}
+		return nil, err
+	}
+	p, err := s.findPatient(ctx, surgery.PatientID)
+	if err != nil {
+		return nil, err
+	}
+	res := fhir.ProcedureResource(p, surgery)
+	return &res, nil
+}
+
+func (s *fhirService) GetObservations(ctx context.Context, patientID uint) ([]fhir.Observation, error) {
+	p, err := s.findPatient(ctx, patientID)
+	if err != nil {
+		return nil, err
+	}
+	return s.observations(ctx, p)
+}
+
+func (s *fhirService) observations(ctx context.Context, p *domain.Patient) ([]fhir.Observation, error) {
+	calcs, err := s.iolRepo.FindByPatient(ctx, p.ID)
+	if err != nil {
+		return nil, err
+	}
+	items, err := s.checklistRepo.FindItemsByPatient(ctx, p.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	exams, err := s.examRepo.FindByPatient(ctx, p.ID, "")
+	if err != nil {
+		return nil, err
+	}
+
+	obs := fhir.IOLObservations(p, calcs)
+	obs = append(obs, fhir.EyeExamObservations(p, exams)...)
+	obs = append(obs, fhir.MetadataObservations(p)...)
+	obs = append(obs, fhir.ChecklistObservations(p, items)...)
+	return obs, nil
+}
+
+func (s *fhirService) Everything(ctx context.Context, patientID uint) (*fhir.Bundle, error) {
+	p, err := s.findPatient(ctx, patientID)
+	if err != nil {
+		return nil, err
+	}
+	s.audit.LogRead(ctx, domain.AuditEntityPatient, p.ID)
+	surgeries, err := s.surgeryRepo.FindByPatient(ctx, patientID)
+	if err != nil {
+		return nil, err
+	}
+	obs, err := s.observations(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+
+	resources := []interface{}{fhir.PatientResource(p)}
+	for _, c := range fhir.ConditionResources(p) {
+		resources = append(resources, c)
+	}
+	for _, proc := range fhir.ProcedureResources(p, surgeries) {
+		resources = append(resources, proc)
+	}
+	for _, o := range obs {
+		resources = append(resources, o)
+	}
+
+	return fhir.NewSearchsetBundle(resources...)
+}
+
+// invalidBundleError — Bundle не прошёл проверку; отличает ошибки данных от ошибок БД
+type invalidBundleError struct{ err error }
+
+func (e invalidBundleError) Error() string { return e.err.Error() }
+func (e invalidBundleError) Unwrap() error { return e.err }
+
+func invalidBundle(format string, args ...interface{}) error {
+	return invalidBundleError{fmt.Errorf(format, args...)}
+}
+
+// IsInvalidBundle сообщает, что ImportBundle отклонил Bundle из-за его содержимого
+func IsInvalidBundle(err error) bool {
+	var e invalidBundleError
+	return errors.As(err, &e)
+}
+
+// importEntry — подготовленная к записи запись Bundle
+type importEntry struct {
+	index     int
+	fullURL   string
+	patient   *fhir.ImportedPatient
+	create    *domain.CreatePatientRequest
+	condition *fhir.ImportedCondition
+	existing  *domain.Patient
+	district  uint
+}
+
+// importedPatient — пациент, изменённый импортом; before == nil для созданных
+type importedPatient struct {
+	patient *domain.Patient
+	before  *domain.Patient
+}
+
+// ImportBundle импортирует пациентов и диагнозы из FHIR Bundle (transaction/batch).
+// Весь Bundle проверяется заранее и записывается в одной транзакции; документы
+// нормализуются и проверяются на дубликаты так же, как при создании пациента в API.
+func (s *fhirService) ImportBundle(ctx context.Context, bundle fhir.Bundle, userID uint, role domain.Role) (*fhir.Bundle, error) {
+	if bundle.ResourceType != "Bundle" {
+		return nil, invalidBundle("ожидался ресурс Bundle")
+	}
+	if bundle.Type != "transaction" && bundle.Type != "batch" {
+		return nil, invalidBundle("поддерживаются только Bundle типа transaction или batch")
+	}
+	if len(bundle.Entry) == 0 {
+		return nil, invalidBundle("Bundle не содержит записей")
+	}
+
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("пользователь не найден: %w", err)
+	}
+
+	entries := make([]*importEntry, 0, len(bundle.Entry))
+	patientsByURL := map[string]*importEntry{}
+
+	// 1. Разбор и проверка всех записей
+	for i, e := range bundle.Entry {
+		entry := &importEntry{index: i, fullURL: e.FullURL}
+
+		switch rt := fhir.ResourceType(e.Resource); rt {
+		case "Patient":
+			imp, err := fhir.ParsePatient(e.Resource)
+			if err != nil {
+				return nil, invalidBundle("запись %d: %w", i, err)
+			}
+			req := importedPatientRequest(imp)
+			if err := req.NormalizeIdentifiers(); err != nil {
+				return nil, invalidBundle("запись %d: %w", i, err)
+			}
+			// Поиск по СНИЛС и полису — по нормализованным значениям
+			imp.SNILS, imp.OMSPolicy, imp.PolicyNumber = req.SNILs, req.OMSPolicy, req.PolicyNumber
+			entry.patient = imp
+
+			existing, err := s.matchPatient(ctx, e.Request, imp)
+			if err != nil {
+				return nil, fmt.Errorf("запись %d: %w", i, err)
+			}
+			if existing != nil {
+				if err := s.policy.CanAccessPatient(ctx, userID, role, existing.ID, domain.PatientActionWrite); err != nil {
+					return nil, fmt.Errorf("запись %d: %w", i, err)
+				}
+			}
+			entry.existing = existing
+
+			if imp.DistrictCode != "" {
+				district, err := s.districtRepo.FindByCode(ctx, imp.DistrictCode)
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return nil, invalidBundle("запись %d: район с кодом %s не найден", i, imp.DistrictCode)
+				}
+				if err != nil {
+					return nil, err
+				}
+				entry.district = district.ID
+			} else if existing == nil {
+				if user.DistrictID == nil {
+					return nil, invalidBundle("запись %d: не указан район (managingOrganization)", i)
+				}
+				entry.district = *user.DistrictID
+			}
+
+			if existing == nil {
+				req.DistrictID = entry.district
+				if err := resolvePatientOperation(ctx, repository.NewOperationTypeRepository(s.db), &req); err != nil {
+					return nil, invalidBundle("запись %d: %v (extensions %s, %s)", i, err, fhir.ExtOperationType, fhir.ExtEye)
+				}
+				entry.create = &req
+			} else if err := checkImportedUpdate(ctx, repository.NewOperationTypeRepository(s.db), existing, imp, entry.district, role); err != nil {
+				return nil, fmt.Errorf("запись %d: %w", i, err)
+			}
+
+			if e.FullURL != "" {
+				patientsByURL[e.FullURL] = entry
+			}
+			if existing != nil {
+				patientsByURL["Patient/"+strconv.FormatUint(uint64(existing.ID), 10)] = entry
+			}
+			if imp.ResourceID != "" {
+				patientsByURL["Patient/"+imp.ResourceID] = entry
+			}
+
+		case "Condition":
+			imp, err := fhir.ParseCondition(e.Resource)
+			if err != nil {
+				return nil, invalidBundle("запись %d: %w", i, err)
+			}
+			entry.condition = imp
+
+		case "":
+			return nil, invalidBundle("запись %d: не указан resourceType", i)
+		default:
+			return nil, invalidBundle("запись %d: ресурс %s не поддерживается для импорта", i, rt)
+		}
+
+		entries = append(entries, entry)
+	}
+
+	// Диагнозы должны ссылаться на пациента из Bundle или на существующего пациента
+	for _, entry := range entries {
+		if entry.condition == nil {
+			continue
+		}
+		if _, ok := patientsByURL[entry.condition.SubjectReference]; ok {
+			continue
+		}
+		id, ok := fhir.PatientReferenceID(entry.condition.SubjectReference)
+		if !ok {
+			return nil, invalidBundle("запись %d: не удалось разрешить ссылку %s", entry.index, entry.condition.SubjectReference)
+		}
+		existing, err := s.patientRepo.FindByID(ctx, id)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, notFoundError(fmt.Sprintf("запись %d: пациент %s не найден", entry.index, entry.condition.SubjectReference))
+		}
+		if err != nil {
+			return nil, err
+		}
+		if err := s.policy.CanAccessPatient(ctx, userID, role, existing.ID, domain.PatientActionWrite); err != nil {
+			return nil, fmt.Errorf("запись %d: %w", entry.index, err)
+		}
+		patientsByURL[entry.condition.SubjectReference] = &importEntry{existing: existing}
+	}
+
+	// 2. Запись в одной транзакции
+	response := &fhir.Bundle{
+		ResourceType: "Bundle",
+		Type:         bundle.Type + "-response",
+		Timestamp:    time.Now().Format(time.RFC3339),
+		Entry:        make([]fhir.BundleEntry, len(bundle.Entry)),
+	}
+	saved := map[*importEntry]*importedPatient{}
+	var changed []*importedPatient
+
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		patients := repository.NewPatientRepository(tx)
+
+		for _, entry := range entries {
+			if entry.patient == nil {
+				continue
+			}
+
+			var imported *importedPatient
+			status := "200 OK"
+			if entry.existing != nil {
+				p := entry.existing
+				before := *p
+				upd := importedPatientUpdate(entry.patient)
+				applyPatientUpdate(p, upd)
+				applyImportedPatient(p, entry.patient, entry.district)
+				if upd.ChangesIdentity() {
+					if err := checkDuplicates(ctx, patients, p, false); err != nil {
+						return fmt.Errorf("запись %d: %w", entry.index, err)
+					}
+				}
+				if err := tx.Omit("Doctor", "Surgeon", "District").Save(p).Error; err != nil {
+					return fmt.Errorf("не удалось обновить пациента: %w", err)
+				}
+				imported = &importedPatient{patient: p, before: &before}
+			} else {
+				p, err := newPatientFromRequest(*entry.create, userID)
+				if err != nil {
+					return invalidBundle("запись %d: %w", entry.index, err)
+				}
+				p.Status = domain.PatientStatusInProgress
+				setFHIRResourceID(p, entry.patient.ResourceID)
+				if err := checkDuplicates(ctx, patients, p, false); err != nil {
+					return fmt.Errorf("запись %d: %w", entry.index, err)
+				}
+				if err := tx.Create(p).Error; err != nil {
+					return fmt.Errorf("не удалось создать пациента: %w", err)
+				}
+				items, err := buildPatientChecklist(ctx, repository.NewChecklistTemplateRepository(tx), p, time.Now())
+				if err != nil {
+					return fmt.Errorf("не удалось загрузить шаблон чек-листа: %w", err)
+				}
+				if len(items) > 0 {
+					if err := tx.Create(&items).Error; err != nil {
+						return fmt.Errorf("не удалось создать чек-лист: %w", err)
+					}
+				}
+				if err := tx.Create(&domain.PatientStatusHistory{
+					PatientID:  p.ID,
+					FromStatus: domain.PatientStatusDraft,
+					ToStatus:   domain.PatientStatusInProgress,
+					ChangedBy:  userID,
+					Comment:    "Пациент импортирован из FHIR, чек-лист сгенерирован",
+				}).Error; err != nil {
+					return fmt.Errorf("не удалось создать историю статуса: %w", err)
+				}
+				imported = &importedPatient{patient: p}
+				status = "201 Created"
+			}
+
+			saved[entry] = imported
+			changed = append(changed, imported)
+			response.Entry[entry.index] = fhir.BundleEntry{
+				FullURL:  entry.fullURL,
+				Response: &fhir.BundleEntryResponse{Status: status, Location: "Patient/" + strconv.FormatUint(uint64(imported.patient.ID), 10)},
+			}
+		}
+
+		for _, entry := range entries {
+			if entry.condition == nil {
+				continue
+			}
+			target := patientsByURL[entry.condition.SubjectReference]
+			imported, ok := saved[target]
+			if !ok {
+				before := *target.existing
+				imported = &importedPatient{patient: target.existing, before: &before}
+				saved[target] = imported
+				changed = append(changed, imported)
+			}
+			p := imported.patient
+
+			applyImportedCondition(p, entry.condition)
+			if err := tx.Omit("Doctor", "Surgeon", "District").Save(p).Error; err != nil {
+				return fmt.Errorf("не удалось сохранить диагноз: %w", err)
+			}
+
+			response.Entry[entry.index] = fhir.BundleEntry{
+				FullURL:  entry.fullURL,
+				Response: &fhir.BundleEntryResponse{Status: "200 OK", Location: "Patient/" + strconv.FormatUint(uint64(p.ID), 10)},
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.Error().Err(err).Uint("user_id", userID).Msg("ошибка импорта FHIR Bundle")
+		return nil, err
+	}
+
+	audits := make([]auditEntry, 0, len(changed))
+	for _, imported := range changed {
+		if imported.before == nil {
+			audits = append(audits, auditEntry{domain.AuditActionCreate, domain.AuditEntityPatient, imported.patient.ID, nil, imported.patient})
+		} else {
+			audits = append(audits, auditEntry{domain.AuditActionUpdate, domain.AuditEntityPatient, imported.patient.ID, imported.before, imported.patient})
+		}
+	}
+	recordAll(ctx, s.audit, audits)
+
+	log.Info().Uint("user_id", userID).Int("entries", len(entries)).Msg("FHIR Bundle импортирован")
+	return response, nil
+}
+
+// matchPatient ищет существующего пациента: по URL запроса PUT, затем по СНИЛС, полису ОМС и внешнему FHIR id
+func (s *fhirService) matchPatient(ctx context.Context, req *fhir.BundleEntryRequest, imp *fhir.ImportedPatient) (*domain.Patient, error) {
+	if req != nil && strings.EqualFold(req.Method, "PUT") {
+		if id, ok := fhir.PatientReferenceID(req.URL); ok {
+			p, err := s.patientRepo.FindByID(ctx, id)
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, notFoundError(fmt.Sprintf("пациент %s не найден", req.URL))
+			}
+			return p, err
+		}
+	}
+
+	lookups := []struct {
+		value string
+		find  func(context.Context, string) (*domain.Patient, error)
+	}{
+		{imp.SNILS, s.patientRepo.FindBySNILS},
+		{imp.OMSPolicy, s.patientRepo.FindByOMSPolicy},
+		{imp.ResourceID, s.patientRepo.FindByFHIRResourceID},
+	}
+	for _, l := range lookups {
+		if l.value == "" {
+			continue
+		}
+		p, err := l.find(ctx, l.value)
+		if err == nil {
+			return p, nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+	}
+	return nil, nil
+}
+
+// importedPatientRequest — импортируемый пациент как запрос на создание через API
+func importedPatientRequest(imp *fhir.ImportedPatient) domain.CreatePatientRequest {
+	req := domain.CreatePatientRequest{
+		FirstName:     imp.FirstName,
+		LastName:      imp.LastName,
+		MiddleName:    imp.MiddleName,
+		Phone:         imp.Phone,
+		Email:         imp.Email,
+		Address:       imp.Address,
+		SNILs:         imp.SNILS,
+		PolicyNumber:  imp.PolicyNumber,
+		OMSPolicy:     imp.OMSPolicy,
+		Gender:        imp.Gender,
+		OperationType: imp.OperationType,
+		Eye:           imp.Eye,
+	}
+	if !imp.DateOfBirth.IsZero() {
+		req.DateOfBirth = imp.DateOfBirth.Format("2006-01-02")
+	}
+	return req
+}
+
+// importedPatientUpdate — заполненные поля импортируемого пациента как запрос на изменение
+func importedPatientUpdate(imp *fhir.ImportedPatient) domain.UpdatePatientRequest {
+	optional := func(v string) *string {
+		if v == "" {
+			return nil
+		}
+		return &v
+	}
+	return domain.UpdatePatientRequest{
+		FirstName:    &imp.FirstName,
+		LastName:     &imp.LastName,
+		MiddleName:   optional(imp.MiddleName),
+		Phone:        optional(imp.Phone),
+		Email:        optional(imp.Email),
+		Address:      optional(imp.Address),
+		SNILs:        optional(imp.SNILS),
+		PolicyNumber: optional(imp.PolicyNumber),
+	}
+}
+
+// checkImportedUpdate проверяет изменения существующего пациента, которых нет в UpdatePatientRequest:
+// новые тип операции и глаз — как при создании, перевод в другой район — только администратором.
+// Тип операции и глаз в imp заменяются нормализованными значениями.
+func checkImportedUpdate(ctx context.Context, opTypes repository.OperationTypeRepository, p *domain.Patient, imp *fhir.ImportedPatient, districtID uint, role domain.Role) error {
+	if districtID != 0 && districtID != p.DistrictID && role != domain.RoleAdmin {
+		return fmt.Errorf("перевести пациента в другой район может только администратор: %w", ErrAccessDenied)
+	}
+
+	if imp.OperationType == "" && imp.Eye == "" {
+		return nil
+	}
+	req := domain.CreatePatientRequest{OperationType: p.OperationType, Eye: p.Eye}
+	if imp.OperationType != "" {
+		req.OperationType = imp.OperationType
+	}
+	if imp.Eye != "" {
+		req.Eye = imp.Eye
+	}
+	if strings.EqualFold(strings.TrimSpace(string(req.OperationType)), string(p.OperationType)) && domain.NormalizeEye(req.Eye) == p.Eye {
+		// Повторный импорт без изменений не зависит от того, отключён ли тип операции
+		imp.OperationType, imp.Eye = "", ""
+		return nil
+	}
+	if err := resolvePatientOperation(ctx, opTypes, &req); err != nil {
+		return invalidBundle("%v (extensions %s, %s)", err, fhir.ExtOperationType, fhir.ExtEye)
+	}
+	imp.OperationType, imp.Eye = req.OperationType, req.Eye
+	return nil
+}
+
+// applyImportedPatient переносит в существующего пациента поля, которых нет в UpdatePatientRequest
+func applyImportedPatient(p *domain.Patient, imp *fhir.ImportedPatient, districtID uint) {
+	if !imp.DateOfBirth.IsZero() {
+		p.DateOfBirth = imp.DateOfBirth
+	}
+	if imp.Gender != "" {
+		p.Gender = imp.Gender
+	}
+	if imp.OMSPolicy != "" {
+		p.OMSPolicy = imp.OMSPolicy
+	}
+	if imp.OperationType != "" {
+		p.OperationType = imp.OperationType
+	}
+	if imp.Eye != "" {
+		p.Eye = imp.Eye
+	}
+	if districtID != 0 {
+		p.DistrictID = districtID
+	}
+	setFHIRResourceID(p, imp.ResourceID)
+}
+
+// setFHIRResourceID запоминает внешний id ресурса, если это не наш собственный id
+func setFHIRResourceID(p *domain.Patient, resourceID string) {
+	if resourceID == "" || isLocalID(p, resourceID) {
+		return
+	}
+	if p.MedicalMetadata == nil {
+		p.MedicalMetadata = &domain.MedicalStandardsMetadata{}
+	}
+	p.MedicalMetadata.FHIRResourceID = resourceID
+}
+
+// isLocalID проверяет, что id ресурса совпадает с нашим собственным id пациента (повторный импорт экспорта)
+func isLocalID(p *domain.Patient, resourceID string) bool {
+	return p.ID != 0 && resourceID == strconv.FormatUint(uint64(p.ID), 10)
+}
+
+func applyImportedCondition(p *domain.Patient, c *fhir.ImportedCondition) {
+	if p.MedicalMetadata == nil {
+		p.MedicalMetadata = &domain.MedicalStandardsMetadata{}
+	}
+	if c.Code.Code != "" {
+		exists := false
+		for _, dx := range p.MedicalMetadata.DiagnosisCodes {
+			if dx.Code == c.Code.Code {
+				exists = true
+				break
+			}
+		}
+		if !exists {
+			p.MedicalMetadata.DiagnosisCodes = append(p.MedicalMetadata.DiagnosisCodes, c.Code)
+		}
+	}
+	if strings.TrimSpace(p.Diagnosis) == "" {
+		p.Diagnosis = c.Text
+	}
+}
@@ -0,0 +1,75 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/beercut-team/backend-boilerplate/internal/domain"
+	"github.com/beercut-team/backend-boilerplate/internal/repository"
+	"github.com/beercut-team/backend-boilerplate/internal/service/fhir"
+	"gorm.io/gorm"
+)
+
+// opTypeCatalog — справочник типов операций в памяти
+type opTypeCatalog struct {
+	repository.OperationTypeRepository
+	types map[domain.OperationType]*domain.OperationTypeModel
+}
+
+func (r *opTypeCatalog) FindByCode(_ context.Context, code domain.OperationType) (*domain.OperationTypeModel, error) {
+	t, ok := r.types[code]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return t, nil
+}
+
+func TestCheckImportedUpdate(t *testing.T) {
+	catalog := &opTypeCatalog{types: map[domain.OperationType]*domain.OperationTypeModel{
+		domain.OperationPhacoemulsification: {Code: "PHACOEMULSIFICATION", Name: "Факоэмульсификация", IsActive: true},
+		domain.OperationVitrectomy:          {Code: "VITRECTOMY", Name: "Витрэктомия", IsActive: false},
+	}}
+
+	tests := []struct {
+		name     string
+		imp      fhir.ImportedPatient
+		district uint
+		role     domain.Role
+		wantErr  func(error) bool
+		wantOp   domain.OperationType
+		wantEye  string
+	}{
+		{"no changes", fhir.ImportedPatient{}, 0, domain.RoleDistrictDoctor, nil, "", ""},
+		{"same operation and eye", fhir.ImportedPatient{OperationType: "antiglaucoma", Eye: "od"}, 0, domain.RoleDistrictDoctor, nil, "", ""},
+		{"active operation, eye normalized", fhir.ImportedPatient{OperationType: "phacoemulsification", Eye: "os"}, 0, domain.RoleDistrictDoctor, nil, domain.OperationPhacoemulsification, "OS"},
+		{"unknown operation", fhir.ImportedPatient{OperationType: "LASIK"}, 0, domain.RoleDistrictDoctor, IsInvalidBundle, "", ""},
+		{"disabled operation", fhir.ImportedPatient{OperationType: "VITRECTOMY"}, 0, domain.RoleAdmin, IsInvalidBundle, "", ""},
+		{"invalid eye", fhir.ImportedPatient{OperationType: "PHACOEMULSIFICATION", Eye: "OX"}, 0, domain.RoleDistrictDoctor, IsInvalidBundle, "", ""},
+		{"same district", fhir.ImportedPatient{}, 4, domain.RoleDistrictDoctor, nil, "", ""},
+		{"district moved by doctor", fhir.ImportedPatient{}, 5, domain.RoleDistrictDoctor, func(err error) bool { return errors.Is(err, ErrAccessDenied) }, "", ""},
+		{"district moved by surgeon", fhir.ImportedPatient{}, 5, domain.RoleSurgeon, func(err error) bool { return errors.Is(err, ErrAccessDenied) }, "", ""},
+		{"district moved by admin", fhir.ImportedPatient{}, 5, domain.RoleAdmin, nil, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Типа операции пациента больше нет в справочнике — повторный импорт без изменений проходит
+			p := &domain.Patient{ID: 3, OperationType: domain.OperationAntiglaucoma, Eye: "OD", DistrictID: 4}
+			imp := tt.imp
+
+			err := checkImportedUpdate(context.Background(), catalog, p, &imp, tt.district, tt.role)
+			if tt.wantErr != nil {
+				if err == nil || !tt.wantErr(err) {
+					t.Fatalf("err = %v, want rejection", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("err = %v", err)
+			}
+			if imp.OperationType != tt.wantOp || imp.Eye != tt.wantEye {
+				t.Errorf("imported operation %q eye %q, want %q %q", imp.OperationType, imp.Eye, tt.wantOp, tt.wantEye)
+			}
+		})
+	}
+}
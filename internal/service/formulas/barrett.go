@@ -0,0 +1,31 @@
+package formulas
+
+// Параметры формулы в стиле Barrett
+const (
+	// Длинные глаза: поправка длины оси Wang-Koch для Holladay 1 (2011)
+	longEyeLength = 25.0
+	// Средняя измеренная ACD (эпителий — передняя капсула) в катарактальной популяции
+	meanMeasuredACD = 3.1
+	// Доля отклонения измеренной ACD, переносимая в положение линзы
+	acdWeight = 0.3
+)
+
+// BarrettStyleResult — современная вергенционная формула в духе Barrett:
+// положение линзы зависит от измеренной ACD (мелкие камеры коротких глаз),
+// длина оси длинных глаз корректируется по Wang-Koch. Это не проприетарная
+// Barrett Universal II; константа линзы — тот же хирургический фактор, что у Holladay 1.
+// AL = axial length (mm), K = average keratometry (D), acd = anterior chamber depth (mm, 0 — не измерена)
+func BarrettStyleResult(al, k, acd, lensFactor, targetRef float64) Result {
+	if al > longEyeLength {
+		al = 0.8814*al + 2.8701
+	}
+
+	radius := cornealRadius(k)
+	elp := anatomicACD(al, radius) + lensFactor
+	if acd > 0 {
+		elp += acdWeight * (acd - meanMeasuredACD)
+	}
+
+	eye := vergenceEye{radius: radius, length: al + retinalOffset, elp: elp}
+	return newVergenceResult(eye.power, eye.refraction, targetRef)
+}
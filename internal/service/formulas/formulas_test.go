@@ -111,3 +111,255 @@ func TestLongEye(t *testing.T) {
 
 	t.Logf("Long eye: AL=%.2f, IOL=%.2f D", al, iolPower)
 }
+
+func TestPowerTable(t *testing.T) {
+	results := map[string]Result{
+		"SRKT":    SRKTResult(23.5, 44.0, 118.4, -0.5),
+		"Haigis":  HaigisResult(23.5, 44.0, 3.2, -0.5, DefaultHaigisConstants),
+		"HofferQ": HofferQResult(23.5, 44.0, DefaultHofferPACD, -0.5),
+	}
+
+	for name, r := range results {
+		t.Run(name, func(t *testing.T) {
+			table := r.PowerTable(5)
+			if len(table) != 11 {
+				t.Fatalf("table has %d rows, want 11", len(table))
+			}
+			if table[5].Power != r.Power || table[5].Refraction != r.PredictedRefraction {
+				t.Errorf("middle row %+v, want power %.2f refraction %.2f", table[5], r.Power, r.PredictedRefraction)
+			}
+			for i := 1; i < len(table); i++ {
+				if table[i-1].Power-table[i].Power != PowerStep {
+					t.Errorf("row %d: step %.2f, want %.2f", i, table[i-1].Power-table[i].Power, PowerStep)
+				}
+				// Меньшая мощность линзы — более гиперметропическая рефракция
+				if table[i].Refraction <= table[i-1].Refraction {
+					t.Errorf("row %d: refraction %.2f not above %.2f", i, table[i].Refraction, table[i-1].Refraction)
+				}
+			}
+		})
+	}
+}
+
+func TestHaigisConstants(t *testing.T) {
+	// Линза, сидящая глубже (больше a0), требует большей мощности
+	deep := HaigisConstants{A0: -0.5, A1: 0.40, A2: 0.10}
+
+	base := HaigisResult(23.5, 44.0, 3.2, 0, DefaultHaigisConstants)
+	shifted := HaigisResult(23.5, 44.0, 3.2, 0, deep)
+
+	if shifted.EmmetropicPower <= base.EmmetropicPower {
+		t.Errorf("deeper lens power %.2f D, want above %.2f D", shifted.EmmetropicPower, base.EmmetropicPower)
+	}
+
+	if p, _ := Haigis(23.5, 44.0, 3.2, 0); p != base.Power {
+		t.Errorf("Haigis() = %.2f, HaigisResult with defaults = %.2f", p, base.Power)
+	}
+}
+
+// holladay1Vergence — Holladay 1 по опубликованным уравнениям (Holladay et al.,
+// J Cataract Refract Surg 1988;14:17–24), посчитанный по шагам через вергенции
+// в плоскостях роговицы и линзы, а не по замкнутой форме из holladay1.go
+func holladay1Vergence(al, k, sf, ref float64) float64 {
+	r := math.Max(337.5/k, 7.0)
+	ag := math.Min(12.5*al/23.45, 13.5)
+	elp := 0.56 + r - math.Sqrt(r*r-ag*ag/4) + sf
+
+	atCornea := 1000*ref/(1000-12*ref) + 1000*(4.0/3.0-1)/r
+	atLens := atCornea / (1 - elp/1000/1.336*atCornea)
+	return 1000*1.336/(al+0.2-elp) - atLens
+}
+
+// Пример для среднего глаза AL 23.5 мм, K 44.0 D, SF 1.45 (A 118.4), посчитанный вручную:
+// r = 337.5/44 = 7.670 мм; AG = 12.5·23.5/23.45 = 12.527 мм;
+// ACD = 0.56 + r − √(r² − AG²/4) = 3.803 мм; ELP = ACD + SF = 5.252 мм;
+// роговица 1000·(4/3 − 1)/r = 43.457 D, вергенция у линзы 43.457/(1 − 5.252/1336·43.457) = 52.411 D;
+// нужная за линзой 1336/(23.5 + 0.2 − 5.252) = 72.422 D; мощность 72.422 − 52.411 = 20.01 D.
+func TestHolladay1WorkedExample(t *testing.T) {
+	if elp := HolladayELP(23.5, 44.0, 1.45); math.Abs(elp-5.252) > 0.001 {
+		t.Errorf("ELP %.4f mm, want 5.252 mm", elp)
+	}
+	r := Holladay1Result(23.5, 44.0, 1.45, 0)
+	if math.Abs(r.EmmetropicPower-20.01) > 0.01 {
+		t.Errorf("emmetropic power %.3f D, want 20.01 D", r.EmmetropicPower)
+	}
+	if r.Power != 20.0 {
+		t.Errorf("rounded power %.1f D, want 20.0 D", r.Power)
+	}
+}
+
+// A-константу определяет регрессия SRK (Sanders, Retzlaff, Kraff 1981): P = A − 2.5·AL − 0.9·K.
+// С SF = 0.5663·A − 65.6 Holladay 1 на среднем глазу (AL 23.5 мм, K 44 D) должен давать ту же мощность.
+func TestHolladay1MatchesSRKOnAverageEye(t *testing.T) {
+	for _, a := range []float64{118.0, 118.4, 118.7, 119.0} {
+		srk := a - 2.5*23.5 - 0.9*44.0
+		r := Holladay1Result(23.5, 44.0, SurgeonFactorFromA(a), 0)
+		if math.Abs(r.EmmetropicPower-srk) > 0.1 {
+			t.Errorf("A %.1f: Holladay 1 %.2f D, SRK %.2f D", a, r.EmmetropicPower, srk)
+		}
+	}
+}
+
+func TestHolladay1Reference(t *testing.T) {
+	tests := []struct {
+		name      string
+		al, k, sf float64
+		want      float64 // мощность для эмметропии по шагам, D
+	}{
+		{"short eye", 21.0, 46.0, 1.45, 26.63},
+		{"nanophthalmos", 20.0, 47.0, 1.62, 30.23},
+		{"long eye, corneal width capped", 26.0, 42.0, 1.45, 14.75},
+		{"high myope", 30.0, 42.0, 1.62, 4.18},
+		{"steep cornea, radius capped", 23.5, 50.0, 1.45, 14.73},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stepwise := holladay1Vergence(tt.al, tt.k, tt.sf, 0)
+			if math.Abs(stepwise-tt.want) > 0.01 {
+				t.Fatalf("step-by-step power %.3f D, table says %.2f D", stepwise, tt.want)
+			}
+			r := Holladay1Result(tt.al, tt.k, tt.sf, 0)
+			if math.Abs(r.EmmetropicPower-stepwise) > 0.005 {
+				t.Errorf("emmetropic power %.3f D, step-by-step %.3f D", r.EmmetropicPower, stepwise)
+			}
+			if math.Abs(r.PredictedRefraction) > 0.25 {
+				t.Errorf("predicted refraction %.2f D for rounded power %.1f D", r.PredictedRefraction, r.Power)
+			}
+		})
+	}
+}
+
+func TestHolladay1TargetAndLimits(t *testing.T) {
+	// Миопическая цель: 21.40 D по уравнениям, линза 21.5 D даёт −0.57 D
+	power, refraction := Holladay1(23.5, 44.0, SurgeonFactorFromA(119.0), -0.5)
+	if power != 21.5 || refraction != -0.57 {
+		t.Errorf("Holladay1 target -0.5 = %.2f D / %.2f D, want 21.5 D / -0.57 D", power, refraction)
+	}
+
+	// Радиус роговицы меньше 7 мм не учитывается: K 50 D считается как K 48.21 D
+	steep := Holladay1Result(23.5, 50.0, 1.45, 0)
+	capped := Holladay1Result(23.5, 337.5/7, 1.45, 0)
+	if math.Abs(steep.EmmetropicPower-capped.EmmetropicPower) > 1e-9 {
+		t.Errorf("K 50 D power %.3f, want capped %.3f", steep.EmmetropicPower, capped.EmmetropicPower)
+	}
+
+	// Рефракция для выбранной мощности — обратная функция мощности
+	r := Holladay1Result(24.0, 43.0, 1.62, 0)
+	for _, row := range r.PowerTable(5) {
+		back := Holladay1Result(24.0, 43.0, 1.62, row.Refraction)
+		if math.Abs(back.Power-row.Power) > 0.01 {
+			t.Errorf("power %.1f -> refraction %.2f -> power %.2f", row.Power, row.Refraction, back.Power)
+		}
+	}
+}
+
+// Абсолютного эталона нет: Barrett Universal II не опубликована, а BARRETT_STYLE —
+// Holladay 1 с поправками. Тест проверяет только знак и величину поправок
+// относительно Holladay 1, а не точность расчёта.
+func TestBarrettStyleRelativeToHolladay1(t *testing.T) {
+	tests := []struct {
+		name     string
+		al, k    float64
+		acd      float64
+		wantDiff float64 // ожидаемая разница с Holladay 1, D
+		tol      float64
+	}{
+		// Средний глаз и средняя камера — совпадает с Holladay 1
+		{"average eye", 23.5, 44.0, 3.1, 0, 0.01},
+		{"ACD not measured", 23.5, 44.0, 0, 0, 0.01},
+		// Мелкая камера: линза встанет кпереди, нужна меньшая мощность
+		{"short eye, shallow chamber", 21.0, 46.0, 2.5, -0.47, 0.05},
+		// Длинный глаз: поправка Wang-Koch устраняет гиперметропический сдвиг Holladay 1
+		{"long eye", 30.0, 42.0, 3.1, 1.6, 0.05},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sf := SurgeonFactorFromA(118.7)
+			b := BarrettStyleResult(tt.al, tt.k, tt.acd, sf, 0)
+			h := Holladay1Result(tt.al, tt.k, sf, 0)
+			if diff := b.EmmetropicPower - h.EmmetropicPower; math.Abs(diff-tt.wantDiff) > tt.tol {
+				t.Errorf("Barrett-style %.2f D, Holladay 1 %.2f D: diff %.2f, want %.2f", b.EmmetropicPower, h.EmmetropicPower, diff, tt.wantDiff)
+			}
+		})
+	}
+}
+
+func TestPlanToric(t *testing.T) {
+	tests := []struct {
+		name            string
+		in              ToricInput
+		wantTotal       float64
+		wantAxis        float64
+		wantRecommended float64
+		wantResidual    float64
+	}{
+		{
+			// Прямой астигматизм, височный разрез усиливает вертикальный меридиан
+			name:      "with-the-rule, temporal incision",
+			in:        ToricInput{K1: 43.0, K2: 45.0, SteepAxis: 90, SIA: 0.3, IncisionAxis: 180},
+			wantTotal: 2.3, wantAxis: 90, wantRecommended: 3.0, wantResidual: 0.2,
+		},
+		{
+			// Обратный астигматизм частично гасится разрезом
+			name:      "against-the-rule, temporal incision",
+			in:        ToricInput{K1: 43.5, K2: 44.0, SteepAxis: 180, SIA: 0.3, IncisionAxis: 180},
+			wantTotal: 0.2, wantAxis: 180, wantRecommended: 0, wantResidual: 0.2,
+		},
+		{
+			// Косой разрез поворачивает ось: 1.5 D @ 90 + 0.5 D @ 135 → 1.58 D @ 99
+			name:      "oblique incision",
+			in:        ToricInput{K1: 44.0, K2: 42.5, SteepAxis: 90, SIA: 0.5, IncisionAxis: 45},
+			wantTotal: 1.58, wantAxis: 99, wantRecommended: 2.25, wantResidual: 0.01,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := PlanToric(tt.in, ToricCylinderSteps)
+			if p.TotalCylinder != tt.wantTotal || p.TotalAxis != tt.wantAxis {
+				t.Errorf("total %.2f D @ %.0f, want %.2f D @ %.0f", p.TotalCylinder, p.TotalAxis, tt.wantTotal, tt.wantAxis)
+			}
+			if p.Recommended != tt.wantRecommended || p.Axis != tt.wantAxis {
+				t.Errorf("recommended %.2f D @ %.0f, want %.2f D @ %.0f", p.Recommended, p.Axis, tt.wantRecommended, tt.wantAxis)
+			}
+			if math.Abs(p.ResidualCylinder-tt.wantResidual) > 0.01 {
+				t.Errorf("residual %.2f D, want %.2f D", p.ResidualCylinder, tt.wantResidual)
+			}
+		})
+	}
+
+	// Отношение цилиндров растёт с ELP: ~1.43 при 5 мм и K 44 D
+	if r := toricRatio(44, 5); math.Abs(r-1.43) > 0.01 {
+		t.Errorf("toric ratio %.3f, want ~1.43", r)
+	}
+}
+
+func TestSRKTAConstantFor(t *testing.T) {
+	tests := []struct {
+		name      string
+		al, k     float64
+		power     float64
+		aConstant float64 // константа, из которой получена «фактическая» рефракция
+	}{
+		{"average eye", 23.5, 44.0, 21.0, 118.4},
+		{"short eye", 21.5, 45.5, 27.5, 118.9},
+		{"long eye", 26.5, 42.5, 14.0, 119.2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			refraction := SRKTResult(tt.al, tt.k, tt.aConstant, 0).exactRefraction(tt.power)
+			got, ok := SRKTAConstantFor(tt.al, tt.k, tt.power, refraction)
+			if !ok || math.Abs(got-tt.aConstant) > 0.001 {
+				t.Errorf("SRKTAConstantFor() = %.4f, %v, want %.1f", got, ok, tt.aConstant)
+			}
+
+			// Миопический исход требует меньшей константы
+			if myopic, _ := SRKTAConstantFor(tt.al, tt.k, tt.power, refraction-0.5); myopic >= got {
+				t.Errorf("myopic outcome A = %.2f, want < %.2f", myopic, got)
+			}
+		})
+	}
+
+	if _, ok := SRKTAConstantFor(23.5, 44.0, 21.0, -15); ok {
+		t.Error("expected no solution for implausible refraction")
+	}
+}
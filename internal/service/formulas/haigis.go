@@ -1,17 +1,31 @@
 package formulas
 
-import "math"
+// HaigisConstants — константы линзы для формулы Haigis
+type HaigisConstants struct {
+	A0 float64 // personalized constant
+	A1 float64 // ACD-related constant
+	A2 float64 // AL-related constant
+}
+
+// DefaultHaigisConstants — константы для линзы, которой нет в каталоге
+var DefaultHaigisConstants = HaigisConstants{A0: -1.0, A1: 0.40, A2: 0.10}
+
+// IsZero — константы не заданы
+func (c HaigisConstants) IsZero() bool {
+	return c == HaigisConstants{}
+}
 
 // Haigis implements the Haigis formula for IOL power calculation.
 // AL = axial length (mm), K = average keratometry (D), acd = anterior chamber depth (mm), targetRef = target refraction (D)
 func Haigis(al, k, acd, targetRef float64) (iolPower float64, predictedRefraction float64) {
-	// Haigis constants (typical values for a standard IOL)
-	a0 := -1.0  // personalized constant
-	a1 := 0.40  // ACD-related constant
-	a2 := 0.10  // AL-related constant
+	r := HaigisResult(al, k, acd, targetRef, DefaultHaigisConstants)
+	return r.Power, r.PredictedRefraction
+}
 
+// HaigisResult — Haigis с константами линзы и данными для таблицы мощностей
+func HaigisResult(al, k, acd, targetRef float64, c HaigisConstants) Result {
 	// Effective lens position (d) in mm
-	d := a0 + a1*acd + a2*al
+	d := c.A0 + c.A1*acd + c.A2*al
 
 	// Refractive index
 	n := 1.336
@@ -28,17 +42,5 @@ func Haigis(al, k, acd, targetRef float64) (iolPower float64, predictedRefractio
 	// Vergence correction factor (simplified)
 	vf := 1.5
 
-	// Apply target refraction
-	iolPower = pEmmetropia - targetRef*vf
-
-	// Round to nearest 0.5D
-	iolPower = math.Round(iolPower*2) / 2
-
-	// Predicted refraction
-	if vf != 0 {
-		predictedRefraction = (pEmmetropia - iolPower) / vf
-		predictedRefraction = math.Round(predictedRefraction*100) / 100
-	}
-
-	return iolPower, predictedRefraction
+	return newResult(pEmmetropia, vf, targetRef)
 }
@@ -2,16 +2,24 @@ package formulas
 
 import "math"
 
+// DefaultHofferPACD — Hoffer Q constant for a lens without catalog data
+const DefaultHofferPACD = 5.41
+
 // HofferQ implements the Hoffer Q formula for IOL power calculation.
 // AL = axial length (mm), K = average keratometry (D), acd = anterior chamber depth (mm), targetRef = target refraction (D)
 func HofferQ(al, k, acd, targetRef float64) (iolPower float64, predictedRefraction float64) {
 	// Personalized ACD (pACD)
-	pACD := 5.41 // Hoffer Q constant
+	pACD := DefaultHofferPACD
 	if acd > 0 {
 		// Use measured ACD if available
 		pACD = acd + 0.3
 	}
+	r := HofferQResult(al, k, pACD, targetRef)
+	return r.Power, r.PredictedRefraction
+}
 
+// HofferQResult — Hoffer Q с pACD линзы и данными для таблицы мощностей
+func HofferQResult(al, k, pACD, targetRef float64) Result {
 	// Tangent factor for corneal curvature
 	tanK := math.Tan(k * 0.01745329) // convert degrees to radians approximation
 
@@ -37,17 +45,5 @@ func HofferQ(al, k, acd, targetRef float64) (iolPower float64, predictedRefracti
 	// Vergence correction factor
 	vf := 1.5
 
-	// Apply target refraction
-	iolPower = pEmmetropia - targetRef*vf
-
-	// Round to nearest 0.5D
-	iolPower = math.Round(iolPower*2) / 2
-
-	// Predicted refraction
-	if vf != 0 {
-		predictedRefraction = (pEmmetropia - iolPower) / vf
-		predictedRefraction = math.Round(predictedRefraction*100) / 100
-	}
-
-	return iolPower, predictedRefraction
+	return newResult(pEmmetropia, vf, targetRef)
 }
@@ -0,0 +1,77 @@
+package formulas
+
+import "math"
+
+// Параметры тонколинзовой модели Holladay 1 (Holladay et al., 1988)
+const (
+	aqueousIndex     = 1.336
+	cornealIndex     = 4.0 / 3.0
+	vertexDistance   = 12.0 // мм, вершинное расстояние очков
+	retinalOffset    = 0.2  // мм, толщина сетчатки к оптической длине оси
+	minCornealRadius = 7.0
+	maxCornealWidth  = 13.5
+)
+
+// SurgeonFactorFromA — хирургический фактор Holladay из A-константы
+func SurgeonFactorFromA(aConst float64) float64 {
+	return 0.5663*aConst - 65.6
+}
+
+// Holladay1Result implements the Holladay 1 formula.
+// AL = axial length (mm), K = average keratometry (D), sf = surgeon factor, targetRef = target refraction (D)
+func Holladay1Result(al, k, sf, targetRef float64) Result {
+	rag := cornealRadius(k)
+	eye := vergenceEye{
+		radius: rag,
+		length: al + retinalOffset,
+		elp:    HolladayELP(al, k, sf),
+	}
+	return newVergenceResult(eye.power, eye.refraction, targetRef)
+}
+
+// Holladay1 возвращает мощность ИОЛ и ожидаемую рефракцию
+func Holladay1(al, k, sf, targetRef float64) (iolPower float64, predictedRefraction float64) {
+	r := Holladay1Result(al, k, sf, targetRef)
+	return r.Power, r.PredictedRefraction
+}
+
+// cornealRadius — радиус роговицы (мм); плоские роговицы ограничены 7 мм снизу, как у Holladay
+func cornealRadius(k float64) float64 {
+	return math.Max(337.5/k, minCornealRadius)
+}
+
+// anatomicACD — анатомическая глубина камеры по высоте роговичного купола (Fyodorov/Holladay):
+// ширина роговицы оценивается по длине оси и ограничена 13.5 мм
+func anatomicACD(al, radius float64) float64 {
+	ag := math.Min(12.5*al/23.45, maxCornealWidth)
+	return 0.56 + radius - math.Sqrt(radius*radius-ag*ag/4)
+}
+
+// vergenceEye — тонколинзовая модель глаза: роговица с индексом 4/3,
+// линза на расстоянии elp, сетчатка на расстоянии length (все в мм)
+type vergenceEye struct {
+	radius float64
+	length float64
+	elp    float64
+}
+
+// power — мощность ИОЛ для рефракции ref в плоскости очков
+func (e vergenceEye) power(ref float64) float64 {
+	na, ncm1, v := aqueousIndex, cornealIndex-1, vertexDistance
+	r, l, c := e.radius, e.length, e.elp
+	return 1000 * na * (na*r - ncm1*l - 0.001*ref*(v*(na*r-ncm1*l)+l*r)) /
+		((l - c) * (na*r - ncm1*c - 0.001*ref*(v*(na*r-ncm1*c)+c*r)))
+}
+
+// refraction — рефракция в плоскости очков при ИОЛ мощности p
+func (e vergenceEye) refraction(p float64) float64 {
+	na, ncm1, v := aqueousIndex, cornealIndex-1, vertexDistance
+	r, l, c := e.radius, e.length, e.elp
+	return (1000*na*(na*r-ncm1*l) - p*(l-c)*(na*r-ncm1*c)) /
+		(na*(v*(na*r-ncm1*l)+l*r) - 0.001*p*(l-c)*(v*(na*r-ncm1*c)+c*r))
+}
+
+// HolladayELP — эффективное положение линзы по Holladay 1, мм
+func HolladayELP(al, k, sf float64) float64 {
+	return anatomicACD(al, cornealRadius(k)) + sf
+}
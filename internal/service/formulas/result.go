@@ -0,0 +1,78 @@
+package formulas
+
+import "math"
+
+// PowerStep — шаг мощности ИОЛ, в котором выпускаются линзы
+const PowerStep = 0.5
+
+// Result — расчёт по одной формуле. Мощность округлена до PowerStep;
+// EmmetropicPower и RefractionFactor позволяют пересчитать рефракцию
+// для любой другой мощности из линейки.
+type Result struct {
+	Power               float64
+	PredictedRefraction float64
+	// EmmetropicPower — неокруглённая мощность для эмметропии
+	EmmetropicPower float64
+	// RefractionFactor — изменение мощности ИОЛ (D) на 1 D рефракции
+	RefractionFactor float64
+
+	// refraction — точный пересчёт рефракции для вергенционных формул
+	refraction func(power float64) float64
+}
+
+// PowerRow — строка таблицы мощностей
+type PowerRow struct {
+	Power      float64 `json:"power"`
+	Refraction float64 `json:"refraction"`
+}
+
+func newResult(pEmmetropia, factor, targetRef float64) Result {
+	r := Result{EmmetropicPower: pEmmetropia, RefractionFactor: factor}
+	r.Power = roundPower(pEmmetropia - targetRef*factor)
+	r.PredictedRefraction = r.RefractionFor(r.Power)
+	return r
+}
+
+// newVergenceResult — результат формулы, у которой есть точные уравнения
+// мощности для целевой рефракции и рефракции для заданной мощности
+func newVergenceResult(power, refraction func(float64) float64, targetRef float64) Result {
+	r := Result{
+		EmmetropicPower:  power(0),
+		RefractionFactor: power(-0.5) - power(0.5),
+		refraction:       refraction,
+	}
+	r.Power = roundPower(power(targetRef))
+	r.PredictedRefraction = r.RefractionFor(r.Power)
+	return r
+}
+
+// RefractionFor — ожидаемая рефракция при имплантации линзы указанной мощности
+func (r Result) RefractionFor(power float64) float64 {
+	return math.Round(r.exactRefraction(power)*100) / 100
+}
+
+// exactRefraction — рефракция без округления, для обратных расчётов
+func (r Result) exactRefraction(power float64) float64 {
+	if r.refraction != nil {
+		return r.refraction(power)
+	}
+	if r.RefractionFactor == 0 {
+		return 0
+	}
+	return (r.EmmetropicPower - power) / r.RefractionFactor
+}
+
+// PowerTable строит таблицу мощностей с шагом PowerStep: rows строк выше
+// и ниже расчётной мощности, от большей мощности к меньшей, как в распечатках биометров
+func (r Result) PowerTable(rows int) []PowerRow {
+	table := make([]PowerRow, 0, 2*rows+1)
+	for i := rows; i >= -rows; i-- {
+		power := r.Power + float64(i)*PowerStep
+		table = append(table, PowerRow{Power: power, Refraction: r.RefractionFor(power)})
+	}
+	return table
+}
+
+func roundPower(p float64) float64 {
+	return math.Round(p/PowerStep) * PowerStep
+}
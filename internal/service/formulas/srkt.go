@@ -1,10 +1,14 @@
 package formulas
 
-import "math"
-
 // SRKT implements the SRK/T formula for IOL power calculation.
 // AL = axial length (mm), K = average keratometry (D), aConst = A-constant, targetRef = target refraction (D)
 func SRKT(al, k, aConst, targetRef float64) (iolPower float64, predictedRefraction float64) {
+	r := SRKTResult(al, k, aConst, targetRef)
+	return r.Power, r.PredictedRefraction
+}
+
+// SRKTResult — SRK/T с данными для таблицы мощностей
+func SRKTResult(al, k, aConst, targetRef float64) Result {
 	// Corneal radius of curvature in mm
 	rC := 337.5 / k
 
@@ -38,17 +42,34 @@ func SRKT(al, k, aConst, targetRef float64) (iolPower float64, predictedRefracti
 	// P = n/(AL-ELP) - n/(n/K-ELP)
 	pEmmetropia := n/(lopt/1000.0-elp/1000.0) - n/(n/((n-1.0)/(rC/1000.0))-elp/1000.0)
 
-	// Apply target refraction correction
-	iolPower = pEmmetropia - targetRef*cw
+	// Target refraction correction and rounding to 0.5D
+	return newResult(pEmmetropia, cw, targetRef)
+}
 
-	// Round to nearest 0.5D
-	iolPower = math.Round(iolPower*2) / 2
+// Диапазон поиска A-константы при обратном расчёте
+const (
+	minSRKTAConstant = 100.0
+	maxSRKTAConstant = 130.0
+)
 
-	// Predicted refraction with chosen IOL power
-	if cw != 0 {
-		predictedRefraction = (pEmmetropia - iolPower) / cw
-		predictedRefraction = math.Round(predictedRefraction*100) / 100
+// SRKTAConstantFor — A-константа, при которой SRK/T предсказала бы фактическую
+// рефракцию для имплантированной линзы. false — решения нет в диапазоне 100–130.
+func SRKTAConstantFor(al, k, power, refraction float64) (float64, bool) {
+	// Рефракция растёт вместе с A-константой: больше ELP — слабее оптика глаза
+	diff := func(a float64) float64 {
+		return SRKTResult(al, k, a, 0).exactRefraction(power) - refraction
 	}
-
-	return iolPower, predictedRefraction
+	lo, hi := minSRKTAConstant, maxSRKTAConstant
+	if diff(lo) > 0 || diff(hi) < 0 {
+		return 0, false
+	}
+	for i := 0; i < 60; i++ {
+		mid := (lo + hi) / 2
+		if diff(mid) < 0 {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return (lo + hi) / 2, true
 }
@@ -0,0 +1,119 @@
+package formulas
+
+import "math"
+
+// ToricCylinderSteps — цилиндр торических ИОЛ в плоскости линзы (ряд T2–T9)
+var ToricCylinderSteps = []float64{1.0, 1.5, 2.25, 3.0, 3.75, 4.5, 5.25, 6.0}
+
+// ToricInput — данные для планирования торической ИОЛ
+type ToricInput struct {
+	K1, K2 float64 // кератометрия, D
+	// SteepAxis — ось сильного меридиана роговицы, градусы
+	SteepAxis float64
+	// SIA — индуцированный разрезом астигматизм, D; IncisionAxis — меридиан разреза
+	SIA          float64
+	IncisionAxis float64
+	// ELP — эффективное положение линзы, мм; 0 — типичные 5 мм
+	ELP float64
+}
+
+// ToricPlan — результат планирования. Цилиндры положительные, оси в градусах 1–180
+type ToricPlan struct {
+	CornealCylinder float64
+	CornealAxis     float64
+	// Total — астигматизм роговицы с учётом разреза
+	TotalCylinder float64
+	TotalAxis     float64
+	// Ratio — пересчёт цилиндра из плоскости роговицы в плоскость ИОЛ
+	Ratio float64
+	// IOLCylinder — требуемый цилиндр в плоскости ИОЛ
+	IOLCylinder float64
+	// Recommended — цилиндр модели из ряда; 0 — торическая линза не уменьшит астигматизм
+	Recommended float64
+	// Axis — ось установки ИОЛ (по сильному меридиану)
+	Axis float64
+	// Остаточный астигматизм в плоскости роговицы
+	ResidualCylinder float64
+	ResidualAxis     float64
+}
+
+// astigmatism — астигматизм в представлении двойного угла
+type astigmatism struct{ x, y float64 }
+
+func newAstigmatism(cyl, axis float64) astigmatism {
+	rad := 2 * axis * math.Pi / 180
+	return astigmatism{x: cyl * math.Cos(rad), y: cyl * math.Sin(rad)}
+}
+
+func (a astigmatism) add(b astigmatism) astigmatism {
+	return astigmatism{x: a.x + b.x, y: a.y + b.y}
+}
+
+func (a astigmatism) cylinder() float64 {
+	return math.Hypot(a.x, a.y)
+}
+
+func (a astigmatism) axis() float64 {
+	return normalizeAxis(math.Atan2(a.y, a.x) * 180 / math.Pi / 2)
+}
+
+// normalizeAxis приводит ось к записи 1–180, принятой в рецептах: 0° пишется как 180°
+func normalizeAxis(axis float64) float64 {
+	axis = math.Round(math.Mod(axis, 180))
+	if axis <= 0 {
+		axis += 180
+	}
+	return axis
+}
+
+// PlanToric рассчитывает торическую ИОЛ векторным сложением астигматизма роговицы
+// и индуцированного разреза. Разрез уплощает свой меридиан, что равносильно
+// усилению перпендикулярного. Цилиндр переносится в плоскость ИОЛ с учётом ELP.
+func PlanToric(in ToricInput, steps []float64) ToricPlan {
+	kFlat, kSteep := math.Min(in.K1, in.K2), math.Max(in.K1, in.K2)
+	elp := in.ELP
+	if elp == 0 {
+		elp = 5.0
+	}
+
+	cornea := newAstigmatism(kSteep-kFlat, in.SteepAxis)
+	total := cornea.add(newAstigmatism(in.SIA, in.IncisionAxis+90))
+
+	plan := ToricPlan{
+		CornealCylinder: round2(cornea.cylinder()),
+		CornealAxis:     normalizeAxis(in.SteepAxis),
+		TotalCylinder:   round2(total.cylinder()),
+		TotalAxis:       total.axis(),
+		Ratio:           round2(toricRatio((kFlat+kSteep)/2, elp)),
+	}
+	plan.Axis = plan.TotalAxis
+	plan.IOLCylinder = round2(total.cylinder() * plan.Ratio)
+
+	// Модель с наименьшим остаточным астигматизмом; без торики остаётся весь цилиндр
+	residual := total.cylinder()
+	for _, step := range steps {
+		r := total.cylinder() - step/plan.Ratio
+		if math.Abs(r) < math.Abs(residual) {
+			plan.Recommended, residual = step, r
+		}
+	}
+	plan.ResidualCylinder = round2(math.Abs(residual))
+	plan.ResidualAxis = plan.TotalAxis
+	if residual < 0 {
+		// Гиперкоррекция переносит ось на 90°
+		plan.ResidualAxis = normalizeAxis(plan.TotalAxis + 90)
+	}
+	return plan
+}
+
+// toricRatio — отношение цилиндра в плоскости ИОЛ к цилиндру роговицы:
+// производная вергенции в плоскости линзы по оптической силе роговицы
+func toricRatio(k, elp float64) float64 {
+	d := elp / 1000
+	q := aqueousIndex / (aqueousIndex - d*k)
+	return q * q
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
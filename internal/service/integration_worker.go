@@ -0,0 +1,293 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/beercut-team/backend-boilerplate/internal/domain"
+	"github.com/beercut-team/backend-boilerplate/internal/repository"
+	"github.com/beercut-team/backend-boilerplate/pkg/integrations"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	outboxMaxAttempts    = 10
+	outboxBatchSize      = 20
+	outboxLease          = 5 * time.Minute
+	outboxPollInterval   = 15 * time.Second
+	outboxRequestTimeout = 60 * time.Second
+)
+
+// IntegrationWorker разбирает outbox и отправляет сообщения во внешние системы
+// с экспоненциальной задержкой между неудачными попытками.
+type IntegrationWorker struct {
+	outboxRepo  repository.IntegrationOutboxRepository
+	patientRepo repository.PatientRepository
+	consentRepo repository.ConsentRepository
+	clients     map[string]integrations.IntegrationClient
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+func NewIntegrationWorker(
+	outboxRepo repository.IntegrationOutboxRepository,
+	patientRepo repository.PatientRepository,
+	consentRepo repository.ConsentRepository,
+	clients ...integrations.IntegrationClient,
+) *IntegrationWorker {
+	w := &IntegrationWorker{
+		outboxRepo:  outboxRepo,
+		patientRepo: patientRepo,
+		consentRepo: consentRepo,
+		clients:     map[string]integrations.IntegrationClient{},
+		stop:        make(chan struct{}),
+	}
+	for _, c := range clients {
+		if c != nil {
+			w.clients[c.System()] = c
+		}
+	}
+	return w
+}
+
+func (w *IntegrationWorker) Start() {
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+		ticker := time.NewTicker(outboxPollInterval)
+		defer ticker.Stop()
+
+		for {
+			w.ProcessDue(context.Background())
+			select {
+			case <-w.stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	log.Info().Msg("воркер интеграций запущен")
+}
+
+func (w *IntegrationWorker) Stop() {
+	close(w.stop)
+	w.wg.Wait()
+}
+
+// ProcessDue обрабатывает все готовые к отправке сообщения и возвращает их количество
+func (w *IntegrationWorker) ProcessDue(ctx context.Context) int {
+	processed := 0
+	for {
+		jobs, err := w.outboxRepo.ClaimDue(ctx, time.Now(), outboxLease, outboxBatchSize)
+		if err != nil {
+			log.Error().Err(err).Msg("воркер интеграций: не удалось получить сообщения из очереди")
+			return processed
+		}
+		for i := range jobs {
+			w.process(ctx, &jobs[i])
+		}
+		processed += len(jobs)
+		if len(jobs) < outboxBatchSize {
+			return processed
+		}
+	}
+}
+
+func (w *IntegrationWorker) process(ctx context.Context, job *domain.IntegrationOutbox) {
+	ctx, cancel := context.WithTimeout(ctx, outboxRequestTimeout)
+	defer cancel()
+
+	patient, err := w.patientRepo.FindByID(ctx, job.PatientID)
+	if err != nil {
+		w.fail(ctx, job, nil, fmt.Errorf("пациент не найден: %w", err), false)
+		return
+	}
+
+	// Согласие могло быть отозвано, пока сообщение ждало в очереди
+	if err := requireConsents(ctx, w.consentRepo, patient.ID, requiredConsents(job.System)); err != nil {
+		var missing *ConsentRequiredError
+		w.fail(ctx, job, patient, err, !errors.As(err, &missing))
+		return
+	}
+
+	externalID, err := w.send(ctx, job, patient)
+	if err != nil {
+		w.fail(ctx, job, patient, err, integrations.IsRetryable(err))
+		return
+	}
+
+	now := time.Now()
+	job.Status = domain.OutboxStatusDone
+	job.Attempts++
+	job.ExternalID = externalID
+	job.LastError = ""
+	job.CompletedAt = &now
+	if err := w.outboxRepo.Update(ctx, job); err != nil {
+		log.Error().Err(err).Uint("job_id", job.ID).Msg("воркер интеграций: не удалось сохранить результат")
+	}
+
+	w.saveMetadata(ctx, patient.ID, func(p *domain.Patient) {
+		switch job.System {
+		case integrations.SystemEMIAS:
+			emias := emiasMetadata(p)
+			if job.Operation == domain.OutboxOpCreateCase {
+				emias.CaseID = externalID
+			} else {
+				emias.PatientID = externalID
+			}
+			emias.SyncStatus = domain.SyncStatusSynced
+			emias.LastSyncAt = now
+			emias.LastError = ""
+		case integrations.SystemRIAMS:
+			riams := riamsMetadata(p)
+			riams.PatientID = externalID
+			riams.SyncStatus = domain.SyncStatusSynced
+			riams.LastSyncAt = now
+			riams.LastError = ""
+		}
+	})
+
+	log.Info().Uint("job_id", job.ID).Str("system", job.System).Str("external_id", externalID).Msg("воркер интеграций: сообщение доставлено")
+}
+
+// requiredConsents — согласия пациента, без которых нельзя передавать данные в систему
+func requiredConsents(system string) []domain.ConsentType {
+	if system == integrations.SystemRIAMS {
+		return domain.ConsentsForRIAMS
+	}
+	return domain.ConsentsForEMIAS
+}
+
+func (w *IntegrationWorker) send(ctx context.Context, job *domain.IntegrationOutbox, patient *domain.Patient) (string, error) {
+	client, ok := w.clients[job.System]
+	if !ok {
+		return "", fmt.Errorf("клиент %s не настроен", job.System)
+	}
+
+	key := fmt.Sprintf("outbox-%d", job.ID)
+	payload := integrations.PatientPayload{
+		IdempotencyKey: key,
+		LocalID:        patient.ID,
+		LastName:       patient.LastName,
+		FirstName:      patient.FirstName,
+		MiddleName:     patient.MiddleName,
+		Gender:         patient.Gender,
+		SNILS:          patient.SNILs,
+		OMSPolicy:      patient.OMSPolicy,
+	}
+	if !patient.DateOfBirth.IsZero() {
+		payload.BirthDate = patient.DateOfBirth.Format("2006-01-02")
+	}
+
+	switch {
+	case job.System == integrations.SystemEMIAS && job.Operation == domain.OutboxOpExportPatient:
+		payload.ExternalID = emiasMetadata(patient).PatientID
+		res, err := client.ExportPatient(ctx, payload)
+		if err != nil {
+			return "", err
+		}
+		return res.ExternalID, nil
+
+	case job.System == integrations.SystemEMIAS && job.Operation == domain.OutboxOpCreateCase:
+		var req domain.EMIASExportRequest
+		if err := json.Unmarshal([]byte(job.Payload), &req); err != nil {
+			return "", &integrations.Error{System: job.System, Message: "некорректные данные сообщения"}
+		}
+		patientExternalID := emiasMetadata(patient).PatientID
+		if patientExternalID == "" {
+			// Экспорт пациента ещё в очереди — повторим позже
+			return "", errors.New("пациент ещё не экспортирован в ЕМИАС")
+		}
+		res, err := client.CreateCase(ctx, caseFromRequest(key, patient, patientExternalID, req))
+		if err != nil {
+			return "", err
+		}
+		return res.ExternalID, nil
+
+	case job.System == integrations.SystemRIAMS && job.Operation == domain.OutboxOpExportPatient:
+		var req domain.RIAMSExportRequest
+		if err := json.Unmarshal([]byte(job.Payload), &req); err != nil {
+			return "", &integrations.Error{System: job.System, Message: "некорректные данные сообщения"}
+		}
+		payload.RegionCode = req.RegionCode
+		payload.ExternalID = riamsMetadata(patient).PatientID
+		res, err := client.ExportPatient(ctx, payload)
+		if err != nil {
+			return "", err
+		}
+		return res.ExternalID, nil
+	}
+
+	return "", integrations.ErrNotSupported
+}
+
+func caseFromRequest(key string, patient *domain.Patient, patientExternalID string, req domain.EMIASExportRequest) integrations.CasePayload {
+	c := integrations.CasePayload{
+		IdempotencyKey:    key,
+		LocalPatientID:    patient.ID,
+		PatientExternalID: patientExternalID,
+		DiagnosisCode:     req.DiagnosisCode,
+		ProcedureCode:     req.ProcedureCode,
+		SurgeryDate:       req.SurgeryDate,
+	}
+	if c.DiagnosisCode == "" && patient.MedicalMetadata != nil && len(patient.MedicalMetadata.DiagnosisCodes) > 0 {
+		c.DiagnosisCode = patient.MedicalMetadata.DiagnosisCodes[0].Code
+	}
+	if c.ProcedureCode == "" {
+		c.ProcedureCode = domain.DefaultProcedureCode(patient.OperationType).Code
+	}
+	if c.SurgeryDate == "" && patient.SurgeryDate != nil {
+		c.SurgeryDate = patient.SurgeryDate.Format("2006-01-02")
+	}
+	return c
+}
+
+// fail фиксирует неудачную попытку: либо планирует повтор, либо переводит сообщение в error
+func (w *IntegrationWorker) fail(ctx context.Context, job *domain.IntegrationOutbox, patient *domain.Patient, sendErr error, retryable bool) {
+	job.Attempts++
+	job.LastError = sendErr.Error()
+
+	syncStatus := domain.SyncStatusPending
+	if retryable && job.Attempts < job.MaxAttempts {
+		job.Status = domain.OutboxStatusPending
+		job.NextRetryAt = time.Now().Add(integrations.Backoff(job.Attempts))
+		log.Warn().Err(sendErr).Uint("job_id", job.ID).Int("attempt", job.Attempts).Time("next_retry_at", job.NextRetryAt).Msg("воркер интеграций: попытка не удалась, повтор запланирован")
+	} else {
+		job.Status = domain.OutboxStatusError
+		syncStatus = domain.SyncStatusError
+		log.Error().Err(sendErr).Uint("job_id", job.ID).Int("attempt", job.Attempts).Msg("воркер интеграций: сообщение не доставлено")
+	}
+
+	if err := w.outboxRepo.Update(ctx, job); err != nil {
+		log.Error().Err(err).Uint("job_id", job.ID).Msg("воркер интеграций: не удалось сохранить состояние сообщения")
+	}
+
+	if patient == nil {
+		return
+	}
+	w.saveMetadata(ctx, patient.ID, func(p *domain.Patient) {
+		switch job.System {
+		case integrations.SystemEMIAS:
+			emias := emiasMetadata(p)
+			emias.SyncStatus = syncStatus
+			emias.LastError = job.LastError
+		case integrations.SystemRIAMS:
+			riams := riamsMetadata(p)
+			riams.SyncStatus = syncStatus
+			riams.LastError = job.LastError
+		}
+	})
+}
+
+// saveMetadata меняет метаданные интеграции на свежей копии пациента: за время отправки
+// их могли изменить врач, биометрия или другой воркер
+func (w *IntegrationWorker) saveMetadata(ctx context.Context, patientID uint, modify func(p *domain.Patient)) {
+	if err := w.patientRepo.ModifyMedicalMetadata(ctx, patientID, modify); err != nil {
+		log.Error().Err(err).Uint("patient_id", patientID).Msg("воркер интеграций: не удалось обновить метаданные пациента")
+	}
+}
@@ -2,34 +2,38 @@ package service
 
 import (
 	"context"
-	"fmt"
+	"encoding/json"
+	"errors"
 	"time"
 
 	"github.com/beercut-team/backend-boilerplate/internal/domain"
 	"github.com/beercut-team/backend-boilerplate/internal/repository"
-	"github.com/google/uuid"
+	"github.com/beercut-team/backend-boilerplate/pkg/integrations"
+	"github.com/rs/zerolog/log"
 )
 
 type IntegrationsService interface {
 	// EMIAS
 	ValidateForEMIAS(ctx context.Context, patientID uint) (*domain.IntegrationValidationResult, error)
-	ExportToEMIAS(ctx context.Context, patientID uint) (*domain.EMIASExportResponse, error)
-	CreateEMIASCase(ctx context.Context, req domain.EMIASExportRequest) (*domain.EMIASExportResponse, error)
+	ExportToEMIAS(ctx context.Context, patientID uint, userID uint) (*domain.EMIASExportResponse, error)
+	CreateEMIASCase(ctx context.Context, req domain.EMIASExportRequest, userID uint) (*domain.EMIASExportResponse, error)
 	GetEMIASStatus(ctx context.Context, patientID uint) (*domain.EMIASStatusResponse, error)
 
 	// RIAMS
 	ValidateForRIAMS(ctx context.Context, patientID uint, regionCode string) (*domain.IntegrationValidationResult, error)
-	ExportToRIAMS(ctx context.Context, patientID uint, regionCode string) (*domain.RIAMSExportResponse, error)
+	ExportToRIAMS(ctx context.Context, patientID uint, regionCode string, userID uint) (*domain.RIAMSExportResponse, error)
 	GetRIAMSStatus(ctx context.Context, patientID uint) (*domain.RIAMSStatusResponse, error)
 	GetRIAMSRegions(ctx context.Context) []domain.RIAMSRegion
 }
 
 type integrationsService struct {
 	patientRepo repository.PatientRepository
+	outboxRepo  repository.IntegrationOutboxRepository
+	consentRepo repository.ConsentRepository
 }
 
-func NewIntegrationsService(patientRepo repository.PatientRepository) IntegrationsService {
-	return &integrationsService{patientRepo: patientRepo}
+func NewIntegrationsService(patientRepo repository.PatientRepository, outboxRepo repository.IntegrationOutboxRepository, consentRepo repository.ConsentRepository) IntegrationsService {
+	return &integrationsService{patientRepo: patientRepo, outboxRepo: outboxRepo, consentRepo: consentRepo}
 }
 
 // EMIAS methods
@@ -50,85 +54,130 @@ func (s *integrationsService) ValidateForEMIAS(ctx context.Context, patientID ui
 		result.Valid = false
 		result.Errors = append(result.Errors, "Дата рождения обязательна")
 	}
-	if patient.SNILs == "" {
-		result.Warnings = append(result.Warnings, "СНИЛС не указан")
-	}
-	if patient.OMSPolicy == "" {
-		result.Warnings = append(result.Warnings, "Полис ОМС не указан")
-	}
+	validateIdentifiers(patient, result)
 
 	return result, nil
 }
 
-func (s *integrationsService) ExportToEMIAS(ctx context.Context, patientID uint) (*domain.EMIASExportResponse, error) {
+func (s *integrationsService) ExportToEMIAS(ctx context.Context, patientID uint, userID uint) (*domain.EMIASExportResponse, error) {
 	patient, err := s.patientRepo.FindByID(ctx, patientID)
 	if err != nil {
 		return &domain.EMIASExportResponse{Success: false, Error: "пациент не найден"}, err
 	}
-
-	// Mock implementation - generate external ID
-	externalID := fmt.Sprintf("EMIAS-%s", uuid.New().String()[:8])
-
-	// Update patient metadata
-	if patient.MedicalMetadata == nil {
-		patient.MedicalMetadata = &domain.MedicalStandardsMetadata{}
-	}
-	if patient.MedicalMetadata.Integrations == nil {
-		patient.MedicalMetadata.Integrations = &domain.IntegrationMetadata{}
-	}
-	if patient.MedicalMetadata.Integrations.EMIAS == nil {
-		patient.MedicalMetadata.Integrations.EMIAS = &domain.EMIASMetadata{}
+	if err := requireConsents(ctx, s.consentRepo, patientID, domain.ConsentsForEMIAS); err != nil {
+		return &domain.EMIASExportResponse{Success: false, Error: err.Error()}, err
 	}
 
-	patient.MedicalMetadata.Integrations.EMIAS.PatientID = externalID
-	patient.MedicalMetadata.Integrations.EMIAS.LastSyncAt = time.Now()
-	patient.MedicalMetadata.Integrations.EMIAS.SyncStatus = "synced"
+	job, err := s.enqueue(ctx, integrations.SystemEMIAS, domain.OutboxOpExportPatient, patientID, domain.EMIASExportRequest{PatientID: patientID}, userID)
+	if err != nil {
+		return &domain.EMIASExportResponse{Success: false, Error: "не удалось поставить экспорт в очередь"}, err
+	}
 
-	if err := s.patientRepo.Update(ctx, patient); err != nil {
+	emias := emiasMetadata(patient)
+	emias.SyncStatus = domain.SyncStatusPending
+	if err := s.patientRepo.UpdateMedicalMetadata(ctx, patientID, patient.MedicalMetadata); err != nil {
 		return &domain.EMIASExportResponse{Success: false, Error: "не удалось обновить метаданные"}, err
 	}
 
 	return &domain.EMIASExportResponse{
 		Success:    true,
-		ExternalID: externalID,
-		Message:    "Пациент успешно экспортирован в ЕМИАС",
+		JobID:      job.ID,
+		Status:     domain.SyncStatusPending,
+		ExternalID: emias.PatientID,
+		Message:    "Экспорт пациента в ЕМИАС поставлен в очередь",
 	}, nil
 }
 
-func (s *integrationsService) CreateEMIASCase(ctx context.Context, req domain.EMIASExportRequest) (*domain.EMIASExportResponse, error) {
+func (s *integrationsService) CreateEMIASCase(ctx context.Context, req domain.EMIASExportRequest, userID uint) (*domain.EMIASExportResponse, error) {
 	patient, err := s.patientRepo.FindByID(ctx, req.PatientID)
 	if err != nil {
 		return &domain.EMIASExportResponse{Success: false, Error: "пациент не найден"}, err
 	}
-
-	// Mock implementation
-	caseID := fmt.Sprintf("CASE-%s", uuid.New().String()[:8])
-
-	if patient.MedicalMetadata == nil {
-		patient.MedicalMetadata = &domain.MedicalStandardsMetadata{}
-	}
-	if patient.MedicalMetadata.Integrations == nil {
-		patient.MedicalMetadata.Integrations = &domain.IntegrationMetadata{}
+	if err := requireConsents(ctx, s.consentRepo, req.PatientID, domain.ConsentsForEMIAS); err != nil {
+		return &domain.EMIASExportResponse{Success: false, Error: err.Error()}, err
 	}
-	if patient.MedicalMetadata.Integrations.EMIAS == nil {
-		patient.MedicalMetadata.Integrations.EMIAS = &domain.EMIASMetadata{}
+
+	// Случай создаётся только для пациента, уже выгруженного или выгружаемого в ЕМИАС
+	emias := emiasMetadata(patient)
+	if emias.PatientID == "" {
+		if _, err := s.outboxRepo.FindActive(ctx, req.PatientID, integrations.SystemEMIAS, domain.OutboxOpExportPatient); err != nil {
+			return &domain.EMIASExportResponse{Success: false, Error: "пациент ещё не экспортирован в ЕМИАС"}, errors.New("пациент ещё не экспортирован в ЕМИАС")
+		}
 	}
 
-	patient.MedicalMetadata.Integrations.EMIAS.CaseID = caseID
-	patient.MedicalMetadata.Integrations.EMIAS.LastSyncAt = time.Now()
-	patient.MedicalMetadata.Integrations.EMIAS.SyncStatus = "synced"
+	job, err := s.enqueue(ctx, integrations.SystemEMIAS, domain.OutboxOpCreateCase, req.PatientID, req, userID)
+	if err != nil {
+		return &domain.EMIASExportResponse{Success: false, Error: "не удалось поставить создание случая в очередь"}, err
+	}
 
-	if err := s.patientRepo.Update(ctx, patient); err != nil {
+	emias.SyncStatus = domain.SyncStatusPending
+	if err := s.patientRepo.UpdateMedicalMetadata(ctx, req.PatientID, patient.MedicalMetadata); err != nil {
 		return &domain.EMIASExportResponse{Success: false, Error: "не удалось обновить метаданные"}, err
 	}
 
 	return &domain.EMIASExportResponse{
 		Success:    true,
-		ExternalID: caseID,
-		Message:    "Случай успешно создан в ЕМИАС",
+		JobID:      job.ID,
+		Status:     domain.SyncStatusPending,
+		ExternalID: emias.CaseID,
+		Message:    "Создание случая в ЕМИАС поставлено в очередь",
 	}, nil
 }
 
+// enqueue ставит сообщение в outbox. Если такое же сообщение уже ожидает отправки, возвращает его
+func (s *integrationsService) enqueue(ctx context.Context, system, operation string, patientID uint, payload interface{}, userID uint) (*domain.IntegrationOutbox, error) {
+	if existing, err := s.outboxRepo.FindActive(ctx, patientID, system, operation); err == nil {
+		return existing, nil
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	job := &domain.IntegrationOutbox{
+		System:      system,
+		Operation:   operation,
+		PatientID:   patientID,
+		Payload:     string(data),
+		Status:      domain.OutboxStatusPending,
+		MaxAttempts: outboxMaxAttempts,
+		NextRetryAt: time.Now(),
+		CreatedBy:   userID,
+	}
+	if err := s.outboxRepo.Create(ctx, job); err != nil {
+		return nil, err
+	}
+	log.Info().Uint("job_id", job.ID).Str("system", system).Str("operation", operation).Uint("patient_id", patientID).Msg("сообщение интеграции поставлено в очередь")
+	return job, nil
+}
+
+func emiasMetadata(p *domain.Patient) *domain.EMIASMetadata {
+	if p.MedicalMetadata == nil {
+		p.MedicalMetadata = &domain.MedicalStandardsMetadata{}
+	}
+	if p.MedicalMetadata.Integrations == nil {
+		p.MedicalMetadata.Integrations = &domain.IntegrationMetadata{}
+	}
+	if p.MedicalMetadata.Integrations.EMIAS == nil {
+		p.MedicalMetadata.Integrations.EMIAS = &domain.EMIASMetadata{}
+	}
+	return p.MedicalMetadata.Integrations.EMIAS
+}
+
+func riamsMetadata(p *domain.Patient) *domain.RIAMSMetadata {
+	if p.MedicalMetadata == nil {
+		p.MedicalMetadata = &domain.MedicalStandardsMetadata{}
+	}
+	if p.MedicalMetadata.Integrations == nil {
+		p.MedicalMetadata.Integrations = &domain.IntegrationMetadata{}
+	}
+	if p.MedicalMetadata.Integrations.RIAMS == nil {
+		p.MedicalMetadata.Integrations.RIAMS = &domain.RIAMSMetadata{}
+	}
+	return p.MedicalMetadata.Integrations.RIAMS
+}
+
 func (s *integrationsService) GetEMIASStatus(ctx context.Context, patientID uint) (*domain.EMIASStatusResponse, error) {
 	patient, err := s.patientRepo.FindByID(ctx, patientID)
 	if err != nil {
@@ -151,6 +200,7 @@ func (s *integrationsService) GetEMIASStatus(ctx context.Context, patientID uint
 		CaseID:     emias.CaseID,
 		Status:     emias.SyncStatus,
 		LastSyncAt: emias.LastSyncAt,
+		LastError:  emias.LastError,
 	}, nil
 }
 
@@ -175,42 +225,38 @@ func (s *integrationsService) ValidateForRIAMS(ctx context.Context, patientID ui
 		result.Valid = false
 		result.Errors = append(result.Errors, "Код региона обязателен")
 	}
+	validateIdentifiers(patient, result)
 
 	return result, nil
 }
 
-func (s *integrationsService) ExportToRIAMS(ctx context.Context, patientID uint, regionCode string) (*domain.RIAMSExportResponse, error) {
+func (s *integrationsService) ExportToRIAMS(ctx context.Context, patientID uint, regionCode string, userID uint) (*domain.RIAMSExportResponse, error) {
 	patient, err := s.patientRepo.FindByID(ctx, patientID)
 	if err != nil {
 		return &domain.RIAMSExportResponse{Success: false, Error: "пациент не найден"}, err
 	}
-
-	// Mock implementation
-	externalID := fmt.Sprintf("RIAMS-%s-%s", regionCode, uuid.New().String()[:8])
-
-	if patient.MedicalMetadata == nil {
-		patient.MedicalMetadata = &domain.MedicalStandardsMetadata{}
-	}
-	if patient.MedicalMetadata.Integrations == nil {
-		patient.MedicalMetadata.Integrations = &domain.IntegrationMetadata{}
-	}
-	if patient.MedicalMetadata.Integrations.RIAMS == nil {
-		patient.MedicalMetadata.Integrations.RIAMS = &domain.RIAMSMetadata{}
+	if err := requireConsents(ctx, s.consentRepo, patientID, domain.ConsentsForRIAMS); err != nil {
+		return &domain.RIAMSExportResponse{Success: false, Error: err.Error()}, err
 	}
 
-	patient.MedicalMetadata.Integrations.RIAMS.PatientID = externalID
-	patient.MedicalMetadata.Integrations.RIAMS.RegionCode = regionCode
-	patient.MedicalMetadata.Integrations.RIAMS.LastSyncAt = time.Now()
-	patient.MedicalMetadata.Integrations.RIAMS.SyncStatus = "synced"
+	job, err := s.enqueue(ctx, integrations.SystemRIAMS, domain.OutboxOpExportPatient, patientID, domain.RIAMSExportRequest{PatientID: patientID, RegionCode: regionCode}, userID)
+	if err != nil {
+		return &domain.RIAMSExportResponse{Success: false, Error: "не удалось поставить экспорт в очередь"}, err
+	}
 
-	if err := s.patientRepo.Update(ctx, patient); err != nil {
+	riams := riamsMetadata(patient)
+	riams.RegionCode = regionCode
+	riams.SyncStatus = domain.SyncStatusPending
+	if err := s.patientRepo.UpdateMedicalMetadata(ctx, patientID, patient.MedicalMetadata); err != nil {
 		return &domain.RIAMSExportResponse{Success: false, Error: "не удалось обновить метаданные"}, err
 	}
 
 	return &domain.RIAMSExportResponse{
 		Success:    true,
-		ExternalID: externalID,
-		Message:    "Пациент успешно экспортирован в РИАМС",
+		JobID:      job.ID,
+		Status:     domain.SyncStatusPending,
+		ExternalID: riams.PatientID,
+		Message:    "Экспорт пациента в РИАМС поставлен в очередь",
 	}, nil
 }
 
@@ -236,6 +282,7 @@ func (s *integrationsService) GetRIAMSStatus(ctx context.Context, patientID uint
 		RegionCode: riams.RegionCode,
 		Status:     riams.SyncStatus,
 		LastSyncAt: riams.LastSyncAt,
+		LastError:  riams.LastError,
 	}, nil
 }
 
@@ -253,3 +300,30 @@ func (s *integrationsService) GetRIAMSRegions(ctx context.Context) []domain.RIAM
 		{Code: "16", Name: "Республика Татарстан"},
 	}
 }
+
+// validateIdentifiers проверяет СНИЛС, полис и паспорт: региональные системы
+// отклоняют выгрузку с неверными документами
+func validateIdentifiers(patient *domain.Patient, result *domain.IntegrationValidationResult) {
+	if patient.SNILs == "" {
+		result.Warnings = append(result.Warnings, "СНИЛС не указан")
+	} else if _, err := domain.NormalizeSNILS(patient.SNILs); err != nil {
+		result.Valid = false
+		result.Errors = append(result.Errors, "СНИЛС: "+err.Error())
+	}
+	if patient.OMSPolicy == "" {
+		result.Warnings = append(result.Warnings, "Полис ОМС не указан")
+	} else if _, err := domain.NormalizeOMSPolicy(patient.OMSPolicy); err != nil {
+		result.Valid = false
+		result.Errors = append(result.Errors, "Полис ОМС: "+err.Error())
+	}
+	if patient.PassportSeries != "" || patient.PassportNumber != "" {
+		if _, err := domain.NormalizePassportSeries(patient.PassportSeries); err != nil {
+			result.Valid = false
+			result.Errors = append(result.Errors, "Серия паспорта: "+err.Error())
+		}
+		if _, err := domain.NormalizePassportNumber(patient.PassportNumber); err != nil {
+			result.Valid = false
+			result.Errors = append(result.Errors, "Номер паспорта: "+err.Error())
+		}
+	}
+}
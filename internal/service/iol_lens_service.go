@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/beercut-team/backend-boilerplate/internal/domain"
+	"github.com/beercut-team/backend-boilerplate/internal/repository"
+	"gorm.io/gorm"
+)
+
+type IOLLensService interface {
+	List(ctx context.Context, activeOnly bool) ([]domain.IOLLens, error)
+	GetByID(ctx context.Context, id uint) (*domain.IOLLens, error)
+	Create(ctx context.Context, req domain.CreateIOLLensRequest) (*domain.IOLLens, error)
+	Update(ctx context.Context, id uint, req domain.UpdateIOLLensRequest) (*domain.IOLLens, error)
+	Deactivate(ctx context.Context, id uint) (*domain.IOLLens, error)
+}
+
+type iolLensService struct {
+	repo repository.IOLLensRepository
+}
+
+func NewIOLLensService(repo repository.IOLLensRepository) IOLLensService {
+	return &iolLensService{repo: repo}
+}
+
+func (s *iolLensService) List(ctx context.Context, activeOnly bool) ([]domain.IOLLens, error) {
+	return s.repo.FindAll(ctx, activeOnly)
+}
+
+func (s *iolLensService) GetByID(ctx context.Context, id uint) (*domain.IOLLens, error) {
+	lens, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("линза не найдена")
+		}
+		return nil, err
+	}
+	return lens, nil
+}
+
+func (s *iolLensService) Create(ctx context.Context, req domain.CreateIOLLensRequest) (*domain.IOLLens, error) {
+	lens := &domain.IOLLens{
+		Manufacturer:  strings.TrimSpace(req.Manufacturer),
+		Model:         strings.TrimSpace(req.Model),
+		AConstant:     req.AConstant,
+		HaigisA0:      req.HaigisA0,
+		HaigisA1:      req.HaigisA1,
+		HaigisA2:      req.HaigisA2,
+		HofferPACD:    req.HofferPACD,
+		SurgeonFactor: req.SurgeonFactor,
+		MinPower:      req.MinPower,
+		MaxPower:      req.MaxPower,
+		IsActive:      true,
+	}
+	if err := validateIOLLens(lens); err != nil {
+		return nil, err
+	}
+	if err := s.checkUnique(ctx, lens); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Create(ctx, lens); err != nil {
+		return nil, errors.New("не удалось добавить линзу")
+	}
+	return lens, nil
+}
+
+func (s *iolLensService) Update(ctx context.Context, id uint, req domain.UpdateIOLLensRequest) (*domain.IOLLens, error) {
+	lens, err := s.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Manufacturer != nil {
+		lens.Manufacturer = strings.TrimSpace(*req.Manufacturer)
+	}
+	if req.Model != nil {
+		lens.Model = strings.TrimSpace(*req.Model)
+	}
+	if req.AConstant != nil {
+		lens.AConstant = *req.AConstant
+	}
+	if req.HaigisA0 != nil {
+		lens.HaigisA0 = *req.HaigisA0
+	}
+	if req.HaigisA1 != nil {
+		lens.HaigisA1 = *req.HaigisA1
+	}
+	if req.HaigisA2 != nil {
+		lens.HaigisA2 = *req.HaigisA2
+	}
+	if req.HofferPACD != nil {
+		lens.HofferPACD = *req.HofferPACD
+	}
+	if req.SurgeonFactor != nil {
+		lens.SurgeonFactor = *req.SurgeonFactor
+	}
+	if req.MinPower != nil {
+		lens.MinPower = *req.MinPower
+	}
+	if req.MaxPower != nil {
+		lens.MaxPower = *req.MaxPower
+	}
+	if req.IsActive != nil {
+		lens.IsActive = *req.IsActive
+	}
+	if err := validateIOLLens(lens); err != nil {
+		return nil, err
+	}
+	if req.Manufacturer != nil || req.Model != nil {
+		if err := s.checkUnique(ctx, lens); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.repo.Update(ctx, lens); err != nil {
+		return nil, errors.New("не удалось обновить линзу")
+	}
+	return lens, nil
+}
+
+// Deactivate убирает линзу из выбора; сохранённые расчёты продолжают на неё ссылаться
+func (s *iolLensService) Deactivate(ctx context.Context, id uint) (*domain.IOLLens, error) {
+	inactive := false
+	return s.Update(ctx, id, domain.UpdateIOLLensRequest{IsActive: &inactive})
+}
+
+func (s *iolLensService) checkUnique(ctx context.Context, lens *domain.IOLLens) error {
+	existing, err := s.repo.FindByModel(ctx, lens.Manufacturer, lens.Model)
+	if err == nil && existing.ID != lens.ID {
+		return fmt.Errorf("линза %s %s уже есть в каталоге", lens.Manufacturer, lens.Model)
+	}
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	return nil
+}
+
+// validateIOLLens проверяет константы и дополняет pACD и SF из A-константы
+func validateIOLLens(lens *domain.IOLLens) error {
+	if lens.Manufacturer == "" || lens.Model == "" {
+		return errors.New("производитель и модель линзы обязательны")
+	}
+	if lens.AConstant < 110 || lens.AConstant > 125 {
+		return errors.New("A-константа должна быть в диапазоне 110-125")
+	}
+	if lens.HofferPACD < 0 || lens.HofferPACD > 10 {
+		return errors.New("pACD должен быть в диапазоне 0-10 мм")
+	}
+	if lens.MinPower != 0 && lens.MaxPower != 0 && lens.MinPower > lens.MaxPower {
+		return errors.New("минимальная мощность больше максимальной")
+	}
+	lens.FillDerivedConstants()
+	return nil
+}
@@ -11,52 +11,57 @@ import (
 	"github.com/beercut-team/backend-boilerplate/internal/service/formulas"
 )
 
+// A-константа SRK/T для линзы, которой нет в каталоге
+const defaultAConstant = 118.4
+
+// Строк таблицы мощностей выше и ниже расчётной мощности
+const powerTableRows = 5
+
+// Формулы, которые сравниваются по умолчанию
+var compareFormulas = []string{"SRKT", "HAIGIS", "HOFFERQ", "HOLLADAY1", "BARRETT_STYLE"}
+
 type IOLService interface {
 	Calculate(ctx context.Context, req domain.IOLCalculationRequest, userID uint) (*domain.IOLCalculation, error)
+	Compare(ctx context.Context, req domain.IOLCompareRequest) (*domain.IOLCompareResult, error)
+	Toric(ctx context.Context, req domain.IOLToricRequest) (*domain.IOLToricResult, error)
 	GetHistory(ctx context.Context, patientID uint) ([]domain.IOLCalculation, error)
 }
 
 type iolService struct {
-	repo repository.IOLRepository
+	repo     repository.IOLRepository
+	lensRepo repository.IOLLensRepository
+}
+
+func NewIOLService(repo repository.IOLRepository, lensRepo repository.IOLLensRepository) IOLService {
+	return &iolService{repo: repo, lensRepo: lensRepo}
 }
 
-func NewIOLService(repo repository.IOLRepository) IOLService {
-	return &iolService{repo: repo}
+// lensConstants — константы, с которыми считаются формулы
+type lensConstants struct {
+	aConstant float64
+	haigis    formulas.HaigisConstants
+	// pACD линзы; 0 — оценка по измеренной ACD, как без каталога
+	hofferPACD float64
+	// Хирургический фактор Holladay 1, он же фактор линзы для BARRETT_STYLE
+	surgeonFactor float64
 }
 
 func (s *iolService) Calculate(ctx context.Context, req domain.IOLCalculationRequest, userID uint) (*domain.IOLCalculation, error) {
 	avgK := (req.Keratometry1 + req.Keratometry2) / 2
-	aConst := req.AConstant
-	if aConst == 0 {
-		aConst = 118.4 // default SRK/T A-constant
+	lens, consts, err := s.constants(ctx, req.LensID, req.AConstant)
+	if err != nil {
+		return nil, err
 	}
 
-	var power, predictedRef float64
-	var warnings []string
-
 	// Input validation with warnings
-	if req.AxialLength < 20.0 || req.AxialLength > 30.0 {
-		warnings = append(warnings, fmt.Sprintf("Длина оси %.2f мм выходит за пределы нормы (20-30 мм)", req.AxialLength))
-	}
-	if avgK < 40.0 || avgK > 48.0 {
-		warnings = append(warnings, fmt.Sprintf("Средняя кератометрия %.2f D выходит за пределы нормы (40-48 D)", avgK))
-	}
-	if req.ACD > 0 && (req.ACD < 2.0 || req.ACD > 4.5) {
-		warnings = append(warnings, fmt.Sprintf("Глубина передней камеры %.2f мм выходит за пределы нормы (2.0-4.5 мм)", req.ACD))
-	}
+	warnings := biometryWarnings(req.AxialLength, avgK, req.ACD)
 
-	switch strings.ToUpper(req.Formula) {
-	case "SRKT", "SRK/T":
-		power, predictedRef = formulas.SRKT(req.AxialLength, avgK, aConst, req.TargetRefraction)
-	case "HAIGIS":
-		if req.ACD == 0 {
-			return nil, errors.New("ACD обязателен для формулы Haigis")
-		}
-		power, predictedRef = formulas.Haigis(req.AxialLength, avgK, req.ACD, req.TargetRefraction)
-	case "HOFFERQ", "HOFFER_Q":
-		power, predictedRef = formulas.HofferQ(req.AxialLength, avgK, req.ACD, req.TargetRefraction)
-	default:
-		return nil, errors.New("неподдерживаемая формула, используйте: SRKT, HAIGIS или HOFFERQ")
+	formula, result, err := runFormula(req.Formula, req.AxialLength, avgK, req.ACD, req.TargetRefraction, consts)
+	if err != nil {
+		return nil, err
+	}
+	if lens != nil && !lens.HasPower(result.Power) {
+		warnings = append(warnings, fmt.Sprintf("Мощность %.1f D не выпускается для %s %s", result.Power, lens.Manufacturer, lens.Model))
 	}
 
 	calc := &domain.IOLCalculation{
@@ -67,12 +72,14 @@ func (s *iolService) Calculate(ctx context.Context, req domain.IOLCalculationReq
 		Keratometry2:        req.Keratometry2,
 		ACD:                 req.ACD,
 		TargetRefraction:    req.TargetRefraction,
-		Formula:             strings.ToUpper(req.Formula),
-		IOLPower:            power,
-		PredictedRefraction: predictedRef,
-		AConstant:           aConst,
+		Formula:             formula,
+		IOLPower:            result.Power,
+		PredictedRefraction: result.PredictedRefraction,
+		AConstant:           consts.aConstant,
 		CalculatedBy:        userID,
 		Warnings:            strings.Join(warnings, "; "),
+		MediaID:             req.MediaID,
+		LensID:              req.LensID,
 	}
 
 	if err := s.repo.Create(ctx, calc); err != nil {
@@ -82,6 +89,191 @@ func (s *iolService) Calculate(ctx context.Context, req domain.IOLCalculationReq
 	return calc, nil
 }
 
+// Compare считает несколько формул для одной линзы и строит таблицы мощностей.
+// Ошибка одной формулы (например, Haigis без ACD) не прерывает остальные.
+func (s *iolService) Compare(ctx context.Context, req domain.IOLCompareRequest) (*domain.IOLCompareResult, error) {
+	avgK := (req.Keratometry1 + req.Keratometry2) / 2
+	lens, consts, err := s.constants(ctx, req.LensID, req.AConstant)
+	if err != nil {
+		return nil, err
+	}
+
+	names := req.Formulas
+	if len(names) == 0 {
+		names = compareFormulas
+	}
+
+	result := &domain.IOLCompareResult{
+		Lens:             lens,
+		AConstant:        consts.aConstant,
+		TargetRefraction: req.TargetRefraction,
+		Warnings:         biometryWarnings(req.AxialLength, avgK, req.ACD),
+	}
+	for _, name := range names {
+		formula, r, err := runFormula(name, req.AxialLength, avgK, req.ACD, req.TargetRefraction, consts)
+		if err != nil {
+			result.Results = append(result.Results, domain.IOLFormulaResult{Formula: formula, Error: err.Error()})
+			continue
+		}
+
+		fr := domain.IOLFormulaResult{
+			Formula:             formula,
+			IOLPower:            r.Power,
+			PredictedRefraction: r.PredictedRefraction,
+		}
+		for _, row := range r.PowerTable(powerTableRows) {
+			if lens != nil && !lens.HasPower(row.Power) {
+				continue
+			}
+			fr.PowerTable = append(fr.PowerTable, domain.IOLPowerRow{Power: row.Power, Refraction: row.Refraction})
+		}
+		result.Results = append(result.Results, fr)
+	}
+
+	return result, nil
+}
+
+// Toric рассчитывает сферический эквивалент и цилиндр торической ИОЛ
+// с учётом индуцированного разреза астигматизма
+func (s *iolService) Toric(ctx context.Context, req domain.IOLToricRequest) (*domain.IOLToricResult, error) {
+	steepAxis := *req.SteepAxis
+	if steepAxis < 0 || steepAxis > 180 || req.IncisionAxis < 0 || req.IncisionAxis > 180 {
+		return nil, errors.New("оси должны быть в диапазоне 0-180°")
+	}
+	if req.SIA < 0 || req.SIA > 2 {
+		return nil, errors.New("индуцированный астигматизм должен быть в диапазоне 0-2 D")
+	}
+	lens, consts, err := s.constants(ctx, req.LensID, req.AConstant)
+	if err != nil {
+		return nil, err
+	}
+
+	formula := req.Formula
+	if formula == "" {
+		formula = "HOLLADAY1"
+	}
+	avgK := (req.Keratometry1 + req.Keratometry2) / 2
+	formula, sphere, err := runFormula(formula, req.AxialLength, avgK, req.ACD, req.TargetRefraction, consts)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := formulas.PlanToric(formulas.ToricInput{
+		K1:           req.Keratometry1,
+		K2:           req.Keratometry2,
+		SteepAxis:    steepAxis,
+		SIA:          req.SIA,
+		IncisionAxis: req.IncisionAxis,
+		ELP:          formulas.HolladayELP(req.AxialLength, avgK, consts.surgeonFactor),
+	}, formulas.ToricCylinderSteps)
+
+	result := &domain.IOLToricResult{
+		Lens:                lens,
+		Formula:             formula,
+		SphericalEquivalent: sphere.Power,
+		PredictedRefraction: sphere.PredictedRefraction,
+		CornealCylinder:     plan.CornealCylinder,
+		CornealAxis:         plan.CornealAxis,
+		TotalCylinder:       plan.TotalCylinder,
+		TotalAxis:           plan.TotalAxis,
+		CylinderRatio:       plan.Ratio,
+		IOLCylinder:         plan.IOLCylinder,
+		RecommendedCylinder: plan.Recommended,
+		Axis:                plan.Axis,
+		ResidualCylinder:    plan.ResidualCylinder,
+		ResidualAxis:        plan.ResidualAxis,
+		Warnings:            biometryWarnings(req.AxialLength, avgK, req.ACD),
+	}
+	if plan.Recommended == 0 {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("Астигматизм %.2f D: торическая ИОЛ не уменьшит остаточный цилиндр", plan.TotalCylinder))
+	}
+	if lens != nil && !lens.HasPower(sphere.Power) {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("Мощность %.1f D не выпускается для %s %s", sphere.Power, lens.Manufacturer, lens.Model))
+	}
+	return result, nil
+}
+
 func (s *iolService) GetHistory(ctx context.Context, patientID uint) ([]domain.IOLCalculation, error) {
 	return s.repo.FindByPatient(ctx, patientID)
 }
+
+// constants берёт константы линзы из каталога; без линзы — A-константа
+// из запроса и значения по умолчанию
+func (s *iolService) constants(ctx context.Context, lensID *uint, aConstant float64) (*domain.IOLLens, lensConstants, error) {
+	if lensID == nil {
+		if aConstant == 0 {
+			aConstant = defaultAConstant
+		}
+		return nil, lensConstants{
+			aConstant:     aConstant,
+			haigis:        formulas.DefaultHaigisConstants,
+			surgeonFactor: formulas.SurgeonFactorFromA(aConstant),
+		}, nil
+	}
+
+	lens, err := s.lensRepo.FindByID(ctx, *lensID)
+	if err != nil {
+		return nil, lensConstants{}, errors.New("линза не найдена")
+	}
+	if !lens.IsActive {
+		return nil, lensConstants{}, fmt.Errorf("линза %s %s отключена", lens.Manufacturer, lens.Model)
+	}
+
+	consts := lensConstants{
+		aConstant: lens.AConstant,
+		haigis: formulas.HaigisConstants{
+			A0: lens.HaigisA0,
+			A1: lens.HaigisA1,
+			A2: lens.HaigisA2,
+		},
+		hofferPACD:    lens.HofferPACD,
+		surgeonFactor: lens.SurgeonFactor,
+	}
+	if consts.haigis.IsZero() {
+		consts.haigis = formulas.DefaultHaigisConstants
+	}
+	return lens, consts, nil
+}
+
+// runFormula считает одну формулу; возвращает нормализованное название формулы
+func runFormula(name string, al, avgK, acd, targetRef float64, c lensConstants) (string, formulas.Result, error) {
+	switch formula := strings.ToUpper(name); formula {
+	case "SRKT", "SRK/T":
+		return "SRKT", formulas.SRKTResult(al, avgK, c.aConstant, targetRef), nil
+	case "HAIGIS":
+		if acd == 0 {
+			return formula, formulas.Result{}, errors.New("ACD обязателен для формулы Haigis")
+		}
+		return formula, formulas.HaigisResult(al, avgK, acd, targetRef, c.haigis), nil
+	case "HOFFERQ", "HOFFER_Q":
+		pACD := c.hofferPACD
+		if pACD == 0 {
+			pACD = formulas.DefaultHofferPACD
+			if acd > 0 {
+				pACD = acd + 0.3
+			}
+		}
+		return "HOFFERQ", formulas.HofferQResult(al, avgK, pACD, targetRef), nil
+	case "HOLLADAY1", "HOLLADAY":
+		return "HOLLADAY1", formulas.Holladay1Result(al, avgK, c.surgeonFactor, targetRef), nil
+	case "BARRETT_STYLE", "BARRETT":
+		return "BARRETT_STYLE", formulas.BarrettStyleResult(al, avgK, acd, c.surgeonFactor, targetRef), nil
+	default:
+		return formula, formulas.Result{}, errors.New("неподдерживаемая формула, используйте: SRKT, HAIGIS, HOFFERQ, HOLLADAY1 или BARRETT_STYLE")
+	}
+}
+
+// biometryWarnings — предупреждения о значениях вне нормы
+func biometryWarnings(al, avgK, acd float64) []string {
+	var warnings []string
+	if al < 20.0 || al > 30.0 {
+		warnings = append(warnings, fmt.Sprintf("Длина оси %.2f мм выходит за пределы нормы (20-30 мм)", al))
+	}
+	if avgK < 40.0 || avgK > 48.0 {
+		warnings = append(warnings, fmt.Sprintf("Средняя кератометрия %.2f D выходит за пределы нормы (40-48 D)", avgK))
+	}
+	if acd > 0 && (acd < 2.0 || acd > 4.5) {
+		warnings = append(warnings, fmt.Sprintf("Глубина передней камеры %.2f мм выходит за пределы нормы (2.0-4.5 мм)", acd))
+	}
+	return warnings
+}
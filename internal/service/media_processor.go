@@ -0,0 +1,326 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image/png"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/beercut-team/backend-boilerplate/internal/domain"
+	"github.com/beercut-team/backend-boilerplate/internal/repository"
+	"github.com/beercut-team/backend-boilerplate/pkg/dicom"
+	"github.com/beercut-team/backend-boilerplate/pkg/imaging"
+	"github.com/beercut-team/backend-boilerplate/pkg/storage"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	mediaMaxAttempts    = 5
+	mediaBatchSize      = 10
+	mediaLease          = 5 * time.Minute
+	mediaPollInterval   = 10 * time.Second
+	mediaRetryDelay     = time.Minute
+	mediaProcessTimeout = 2 * time.Minute
+)
+
+// MediaProcessor обрабатывает загруженные изображения в фоне: определяет реальный тип,
+// удаляет GPS из EXIF, записывает размеры и строит миниатюру. DICOM-снимки
+// дополнительно привязываются к глазу и пункту чек-листа по заголовку.
+type MediaProcessor struct {
+	repo          repository.MediaRepository
+	patientRepo   repository.PatientRepository
+	checklistRepo repository.ChecklistRepository
+	storage       storage.Storage
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+func NewMediaProcessor(
+	repo repository.MediaRepository,
+	patientRepo repository.PatientRepository,
+	checklistRepo repository.ChecklistRepository,
+	store storage.Storage,
+) *MediaProcessor {
+	return &MediaProcessor{
+		repo:          repo,
+		patientRepo:   patientRepo,
+		checklistRepo: checklistRepo,
+		storage:       store,
+		stop:          make(chan struct{}),
+	}
+}
+
+func (p *MediaProcessor) Start() {
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		ticker := time.NewTicker(mediaPollInterval)
+		defer ticker.Stop()
+
+		for {
+			p.ProcessPending(context.Background())
+			select {
+			case <-p.stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	log.Info().Msg("обработчик медиафайлов запущен")
+}
+
+func (p *MediaProcessor) Stop() {
+	close(p.stop)
+	p.wg.Wait()
+}
+
+// ProcessPending обрабатывает все ожидающие файлы и возвращает их количество
+func (p *MediaProcessor) ProcessPending(ctx context.Context) int {
+	processed := 0
+	for {
+		batch, err := p.repo.ClaimPending(ctx, time.Now(), mediaLease, mediaBatchSize)
+		if err != nil {
+			log.Error().Err(err).Msg("обработчик медиафайлов: не удалось получить очередь")
+			return processed
+		}
+		for i := range batch {
+			p.process(ctx, &batch[i])
+		}
+		processed += len(batch)
+		if len(batch) < mediaBatchSize {
+			return processed
+		}
+	}
+}
+
+func (p *MediaProcessor) process(ctx context.Context, m *domain.Media) {
+	ctx, cancel := context.WithTimeout(ctx, mediaProcessTimeout)
+	defer cancel()
+
+	m.ProcessingAttempts++
+
+	data, err := p.download(ctx, m.StoragePath)
+	if err != nil {
+		p.fail(ctx, m, err, true)
+		return
+	}
+
+	if dicom.IsDICOM(data) {
+		p.processDICOM(ctx, m, data)
+		return
+	}
+
+	res, err := imaging.Process(data)
+	if errors.Is(err, imaging.ErrUnsupported) {
+		// Не изображение, хотя загружено как изображение: отдаём как есть, без миниатюры
+		m.ContentType = imaging.DetectContentType(data)
+		p.finish(ctx, m, "")
+		return
+	}
+	if err != nil {
+		p.fail(ctx, m, err, false)
+		return
+	}
+
+	if res.Modified {
+		if err := p.storage.Upload(ctx, m.StoragePath, bytes.NewReader(res.Data), int64(len(res.Data)), res.ContentType); err != nil {
+			p.fail(ctx, m, fmt.Errorf("не удалось сохранить очищенный файл: %w", err), true)
+			return
+		}
+	}
+
+	thumbPath := domain.ThumbnailPathFor(m.StoragePath, ".jpg")
+	if err := p.storage.Upload(ctx, thumbPath, bytes.NewReader(res.Thumbnail), int64(len(res.Thumbnail)), "image/jpeg"); err != nil {
+		p.fail(ctx, m, fmt.Errorf("не удалось сохранить миниатюру: %w", err), true)
+		return
+	}
+
+	m.ContentType = res.ContentType
+	m.Size = int64(len(res.Data))
+	m.Width, m.Height = res.Width, res.Height
+	p.finish(ctx, m, thumbPath)
+
+	log.Info().Uint("media_id", m.ID).Int("width", m.Width).Int("height", m.Height).
+		Bool("gps_removed", res.Modified).Msg("обработчик медиафайлов: изображение обработано")
+}
+
+// processDICOM разбирает заголовок, проверяет пациента и глаз, строит PNG-превью
+// первого кадра и привязывает снимок к пункту чек-листа
+func (p *MediaProcessor) processDICOM(ctx context.Context, m *domain.Media, data []byte) {
+	f, err := dicom.Parse(data)
+	if err != nil {
+		p.fail(ctx, m, err, false)
+		return
+	}
+	info := f.Info()
+
+	patient, err := p.patientRepo.FindByID(ctx, m.PatientID)
+	if err != nil {
+		p.fail(ctx, m, fmt.Errorf("пациент не найден: %w", err), true)
+		return
+	}
+
+	m.ContentType = dicom.ContentType
+	m.Width, m.Height = info.Columns, info.Rows
+	m.Eye = info.Eye()
+	m.DICOM = dicomStudy(info)
+	m.Warnings = nil
+
+	if info.PatientName != "" && !domain.DICOMNameMatches(info.PatientName, patient) {
+		m.Warnings = append(m.Warnings, fmt.Sprintf("имя пациента в DICOM «%s» не совпадает с карточкой «%s %s»",
+			strings.ReplaceAll(info.PatientName, "^", " "), patient.LastName, patient.FirstName))
+	}
+	if m.Eye != "" && m.Eye != "OU" && (patient.Eye == "OD" || patient.Eye == "OS") && m.Eye != patient.Eye {
+		m.Warnings = append(m.Warnings, fmt.Sprintf("снимок глаза %s, а операция планируется на %s", m.Eye, patient.Eye))
+	}
+
+	var thumbPath string
+	if img, err := f.Image(); err != nil {
+		m.Warnings = append(m.Warnings, "превью недоступно: "+err.Error())
+	} else {
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, imaging.Thumbnail(img, imaging.ThumbnailSize)); err != nil {
+			p.fail(ctx, m, fmt.Errorf("не удалось построить превью: %w", err), false)
+			return
+		}
+		thumbPath = domain.ThumbnailPathFor(m.StoragePath, ".png")
+		if err := p.storage.Upload(ctx, thumbPath, &buf, int64(buf.Len()), "image/png"); err != nil {
+			p.fail(ctx, m, fmt.Errorf("не удалось сохранить превью: %w", err), true)
+			return
+		}
+	}
+
+	p.linkChecklistItem(ctx, m, info)
+	p.finish(ctx, m, thumbPath)
+
+	if len(m.Warnings) > 0 {
+		log.Warn().Uint("media_id", m.ID).Uint("patient_id", m.PatientID).Strs("warnings", m.Warnings).
+			Msg("обработчик медиафайлов: DICOM обработан с предупреждениями")
+	}
+	log.Info().Uint("media_id", m.ID).Str("modality", info.Modality).Str("eye", m.Eye).
+		Msg("обработчик медиафайлов: DICOM обработан")
+}
+
+// linkChecklistItem прикрепляет снимок к подходящему пункту чек-листа (OCT, B-скан и т. п.)
+func (p *MediaProcessor) linkChecklistItem(ctx context.Context, m *domain.Media, info dicom.Info) {
+	if m.ChecklistItemID != nil {
+		return
+	}
+	item, err := attachToChecklist(ctx, p.checklistRepo, m, info.Modality, info.SeriesDescription+" "+info.StudyDescription)
+	if err != nil {
+		log.Error().Err(err).Uint("media_id", m.ID).Msg("обработчик медиафайлов: не удалось привязать снимок к чек-листу")
+		return
+	}
+	if item != nil {
+		m.ChecklistItemID = &item.ID
+	}
+}
+
+// attachToChecklist подбирает пункт чек-листа для исследования и прикрепляет к нему файл.
+// Ожидающий или отклонённый пункт переводится в работу. nil — подходящего пункта нет.
+func attachToChecklist(ctx context.Context, repo repository.ChecklistRepository, m *domain.Media, modality, description string) (*domain.ChecklistItem, error) {
+	items, err := repo.FindItemsByPatient(ctx, m.PatientID)
+	if err != nil {
+		return nil, err
+	}
+	item := domain.ChecklistItemForStudy(items, modality, description)
+	if item == nil {
+		return nil, nil
+	}
+
+	item.MediaID = &m.ID
+	item.Template = nil
+	if item.Status == domain.ChecklistStatusPending || item.Status == domain.ChecklistStatusRejected {
+		item.Status = domain.ChecklistStatusInProgress
+	}
+	if err := repo.UpdateItem(ctx, item); err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+func dicomStudy(info dicom.Info) *domain.DICOMStudy {
+	study := &domain.DICOMStudy{
+		PatientName:  info.PatientName,
+		PatientID:    info.PatientID,
+		Modality:     info.Modality,
+		Laterality:   info.Laterality,
+		Manufacturer: info.Manufacturer,
+		Model:        info.Model,
+		Description:  strings.TrimSpace(info.StudyDescription + " " + info.SeriesDescription),
+	}
+	if info.BirthDate != nil {
+		study.BirthDate = info.BirthDate.Format("2006-01-02")
+	}
+	if info.StudyDate != nil {
+		study.StudyDate = info.StudyDate.Format("2006-01-02")
+	}
+	return study
+}
+
+func (p *MediaProcessor) download(ctx context.Context, path string) ([]byte, error) {
+	reader, err := p.storage.Download(ctx, path)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось скачать файл: %w", err)
+	}
+	defer reader.Close()
+
+	data, err := io.ReadAll(io.LimitReader(reader, maxFileSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("не удалось прочитать файл: %w", err)
+	}
+	if len(data) > maxFileSize {
+		return nil, errors.New("файл превышает допустимый размер")
+	}
+	return data, nil
+}
+
+func (p *MediaProcessor) finish(ctx context.Context, m *domain.Media, thumbPath string) {
+	now := time.Now()
+	m.ThumbnailPath = thumbPath
+	m.ProcessingStatus = domain.MediaStatusReady
+	m.ProcessingError = ""
+	m.NextAttemptAt = nil
+	m.ProcessedAt = &now
+	p.save(ctx, m)
+}
+
+// fail возвращает файл в очередь с задержкой или, если повторять бессмысленно, помечает FAILED
+func (p *MediaProcessor) fail(ctx context.Context, m *domain.Media, cause error, retryable bool) {
+	m.ProcessingError = cause.Error()
+	if retryable && m.ProcessingAttempts < mediaMaxAttempts {
+		next := time.Now().Add(mediaRetryDelay * time.Duration(m.ProcessingAttempts))
+		m.ProcessingStatus = domain.MediaStatusPending
+		m.NextAttemptAt = &next
+	} else {
+		now := time.Now()
+		m.ProcessingStatus = domain.MediaStatusFailed
+		m.NextAttemptAt = nil
+		m.ProcessedAt = &now
+	}
+	p.save(ctx, m)
+
+	log.Warn().Err(cause).Uint("media_id", m.ID).Int("attempts", m.ProcessingAttempts).
+		Str("status", string(m.ProcessingStatus)).Msg("обработчик медиафайлов: ошибка обработки")
+}
+
+func (p *MediaProcessor) save(ctx context.Context, m *domain.Media) {
+	saved, err := p.repo.SaveProcessingResult(ctx, m)
+	if err != nil {
+		log.Error().Err(err).Uint("media_id", m.ID).Msg("обработчик медиафайлов: не удалось сохранить результат")
+		return
+	}
+	if !saved {
+		// Запись удалили во время обработки — убираем то, что успели записать
+		p.storage.Delete(ctx, m.StoragePath)
+		if m.ThumbnailPath != "" {
+			p.storage.Delete(ctx, m.ThumbnailPath)
+		}
+	}
+}
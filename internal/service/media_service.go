@@ -1,15 +1,17 @@
 package service
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
 	"io"
 	"path/filepath"
-	"strings"
 
 	"github.com/beercut-team/backend-boilerplate/internal/domain"
 	"github.com/beercut-team/backend-boilerplate/internal/repository"
+	"github.com/beercut-team/backend-boilerplate/pkg/dicom"
+	"github.com/beercut-team/backend-boilerplate/pkg/imaging"
 	"github.com/beercut-team/backend-boilerplate/pkg/storage"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
@@ -17,6 +19,9 @@ import (
 
 const maxFileSize = 20 * 1024 * 1024 // 20MB
 
+// ErrMediaProcessing — изображение ещё обрабатывается, миниатюры пока нет
+var ErrMediaProcessing = errors.New("изображение обрабатывается, миниатюра будет доступна позже")
+
 type MediaService interface {
 	Upload(ctx context.Context, patientID, uploadedBy uint, fileName, contentType, category string, size int64, reader io.Reader) (*domain.Media, error)
 	GetByID(ctx context.Context, id uint) (*domain.Media, error)
@@ -41,30 +46,38 @@ func (s *mediaService) Upload(ctx context.Context, patientID, uploadedBy uint, f
 		return nil, errors.New("файл слишком большой, максимум 20МБ")
 	}
 
+	head := make([]byte, imaging.SniffLen)
+	n, err := io.ReadFull(reader, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("не удалось прочитать файл: %w", err)
+	}
+	head = head[:n]
+	contentType = detectMediaType(head)
+
 	ext := filepath.Ext(fileName)
 	uid := uuid.New().String()
 	storagePath := fmt.Sprintf("%d/%s/%s%s", patientID, category, uid, ext)
 
-	if err := s.storage.Upload(ctx, storagePath, reader, size, contentType); err != nil {
+	if err := s.storage.Upload(ctx, storagePath, io.MultiReader(bytes.NewReader(head), reader), size, contentType); err != nil {
 		return nil, fmt.Errorf("не удалось загрузить файл: %w", err)
 	}
 
-	// Generate thumbnail for images
-	var thumbPath string
-	if strings.HasPrefix(contentType, "image/") {
-		thumbPath = fmt.Sprintf("%d/%s/%s_thumb%s", patientID, category, uid, ext)
+	// Изображения и DICOM обрабатывает MediaProcessor: GPS, размеры, миниатюра, привязка
+	status := domain.MediaStatusReady
+	if needsProcessing(contentType) {
+		status = domain.MediaStatusPending
 	}
 
 	media := &domain.Media{
-		PatientID:     patientID,
-		UploadedBy:    uploadedBy,
-		FileName:      uid + ext,
-		OriginalName:  fileName,
-		ContentType:   contentType,
-		Size:          size,
-		StoragePath:   storagePath,
-		ThumbnailPath: thumbPath,
-		Category:      category,
+		PatientID:        patientID,
+		UploadedBy:       uploadedBy,
+		FileName:         uid + ext,
+		OriginalName:     fileName,
+		ContentType:      contentType,
+		Size:             size,
+		StoragePath:      storagePath,
+		Category:         category,
+		ProcessingStatus: status,
 	}
 
 	if err := s.repo.Create(ctx, media); err != nil {
@@ -130,8 +143,26 @@ func (s *mediaService) GetThumbnailURL(ctx context.Context, id uint) (string, er
 	if err != nil {
 		return "", errors.New("медиафайл не найден")
 	}
+	switch m.ProcessingStatus {
+	case domain.MediaStatusPending, domain.MediaStatusProcessing:
+		return "", ErrMediaProcessing
+	case domain.MediaStatusFailed:
+		return "", errors.New("не удалось обработать изображение, миниатюра недоступна")
+	}
 	if m.ThumbnailPath == "" {
 		return "", errors.New("миниатюра недоступна")
 	}
 	return s.storage.PresignedURL(ctx, m.ThumbnailPath)
 }
+
+// detectMediaType определяет тип по содержимому: заголовку клиента доверять нельзя
+func detectMediaType(head []byte) string {
+	if dicom.IsDICOM(head) {
+		return dicom.ContentType
+	}
+	return imaging.DetectContentType(head)
+}
+
+func needsProcessing(contentType string) bool {
+	return contentType == dicom.ContentType || imaging.IsSupported(contentType)
+}
@@ -0,0 +1,210 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/beercut-team/backend-boilerplate/internal/domain"
+	"github.com/beercut-team/backend-boilerplate/internal/repository"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+// Верхняя граница длительности операции, минут
+const maxOperationDurationMinutes = 12 * 60
+
+type OperationTypeService interface {
+	List(ctx context.Context, activeOnly bool) ([]domain.OperationTypeModel, error)
+	GetByID(ctx context.Context, id uint) (*domain.OperationTypeModel, error)
+	Create(ctx context.Context, req domain.CreateOperationTypeRequest) (*domain.OperationTypeModel, error)
+	Update(ctx context.Context, id uint, req domain.UpdateOperationTypeRequest) (*domain.OperationTypeModel, error)
+	Deactivate(ctx context.Context, id uint) (*domain.OperationTypeModel, error)
+	// Load перечитывает справочник из БД в domain.RegisterOperationTypes
+	Load(ctx context.Context) error
+}
+
+type operationTypeService struct {
+	repo repository.OperationTypeRepository
+}
+
+func NewOperationTypeService(repo repository.OperationTypeRepository) OperationTypeService {
+	return &operationTypeService{repo: repo}
+}
+
+func (s *operationTypeService) List(ctx context.Context, activeOnly bool) ([]domain.OperationTypeModel, error) {
+	return s.repo.FindAll(ctx, activeOnly)
+}
+
+func (s *operationTypeService) GetByID(ctx context.Context, id uint) (*domain.OperationTypeModel, error) {
+	t, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("тип операции не найден")
+		}
+		return nil, err
+	}
+	return t, nil
+}
+
+func (s *operationTypeService) Create(ctx context.Context, req domain.CreateOperationTypeRequest) (*domain.OperationTypeModel, error) {
+	code := strings.ToUpper(strings.TrimSpace(req.Code))
+	if err := domain.ValidateOperationTypeCode(code); err != nil {
+		return nil, err
+	}
+	if _, err := s.repo.FindByCode(ctx, domain.OperationType(code)); err == nil {
+		return nil, fmt.Errorf("тип операции с кодом %s уже существует", code)
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	t := &domain.OperationTypeModel{
+		Code:            code,
+		Name:            strings.TrimSpace(req.Name),
+		Description:     req.Description,
+		DefaultEye:      req.DefaultEye,
+		DurationMinutes: req.DurationMinutes,
+		ProcedureCodes:  req.ProcedureCodes,
+		IsActive:        true,
+	}
+	if err := validateOperationType(t); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Create(ctx, t); err != nil {
+		return nil, errors.New("не удалось создать тип операции")
+	}
+	s.reload(ctx)
+	return t, nil
+}
+
+// Update меняет всё, кроме кода: код хранится у пациентов и операций
+func (s *operationTypeService) Update(ctx context.Context, id uint, req domain.UpdateOperationTypeRequest) (*domain.OperationTypeModel, error) {
+	t, err := s.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		t.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.Description != nil {
+		t.Description = *req.Description
+	}
+	if req.DefaultEye != nil {
+		t.DefaultEye = *req.DefaultEye
+	}
+	if req.DurationMinutes != nil {
+		t.DurationMinutes = *req.DurationMinutes
+	}
+	if req.ProcedureCodes != nil {
+		t.ProcedureCodes = *req.ProcedureCodes
+	}
+	if req.IsActive != nil {
+		t.IsActive = *req.IsActive
+	}
+	if err := validateOperationType(t); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Update(ctx, t); err != nil {
+		return nil, errors.New("не удалось обновить тип операции")
+	}
+	s.reload(ctx)
+	return t, nil
+}
+
+// Deactivate запрещает новых пациентов и операции этого типа; существующие сохраняются
+func (s *operationTypeService) Deactivate(ctx context.Context, id uint) (*domain.OperationTypeModel, error) {
+	inactive := false
+	return s.Update(ctx, id, domain.UpdateOperationTypeRequest{IsActive: &inactive})
+}
+
+func (s *operationTypeService) Load(ctx context.Context) error {
+	types, err := s.repo.FindAll(ctx, false)
+	if err != nil {
+		return err
+	}
+	domain.RegisterOperationTypes(types)
+	return nil
+}
+
+func (s *operationTypeService) reload(ctx context.Context) {
+	if err := s.Load(ctx); err != nil {
+		log.Error().Err(err).Msg("не удалось обновить справочник типов операций")
+	}
+}
+
+// validateOperationType нормализует глаз и коды процедур и проверяет значения
+func validateOperationType(t *domain.OperationTypeModel) error {
+	if t.Name == "" {
+		return errors.New("название типа операции обязательно")
+	}
+
+	if t.DefaultEye != "" {
+		t.DefaultEye = domain.NormalizeEye(t.DefaultEye)
+		if !validEye(t.DefaultEye) {
+			return errors.New("глаз по умолчанию должен быть OD, OS или OU")
+		}
+	}
+
+	if t.DurationMinutes < 0 || t.DurationMinutes > maxOperationDurationMinutes {
+		return fmt.Errorf("длительность операции должна быть от 0 до %d минут", maxOperationDurationMinutes)
+	}
+
+	for i := range t.ProcedureCodes {
+		code := &t.ProcedureCodes[i]
+		code.Code = strings.TrimSpace(code.Code)
+		if code.Code == "" {
+			return fmt.Errorf("код процедуры %d не указан", i+1)
+		}
+		if code.System == "" {
+			code.System = domain.CodeSystemSNOMED
+		}
+	}
+	return nil
+}
+
+func validEye(eye string) bool {
+	return eye == "OD" || eye == "OS" || eye == "OU"
+}
+
+// activeOperationType находит тип операции и проверяет, что он не отключён
+func activeOperationType(ctx context.Context, repo repository.OperationTypeRepository, code domain.OperationType) (*domain.OperationTypeModel, error) {
+	if code == "" {
+		return nil, errors.New("тип операции обязателен")
+	}
+	t, err := repo.FindByCode(ctx, code)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("неизвестный тип операции: %s", code)
+		}
+		return nil, err
+	}
+	if !t.IsActive {
+		return nil, fmt.Errorf("тип операции %s отключён", t.Name)
+	}
+	return t, nil
+}
+
+// resolvePatientOperation проверяет тип операции нового пациента и подставляет глаз по умолчанию
+func resolvePatientOperation(ctx context.Context, repo repository.OperationTypeRepository, req *domain.CreatePatientRequest) error {
+	req.OperationType = domain.OperationType(strings.ToUpper(strings.TrimSpace(string(req.OperationType))))
+	t, err := activeOperationType(ctx, repo, req.OperationType)
+	if err != nil {
+		return err
+	}
+
+	req.Eye = domain.NormalizeEye(req.Eye)
+	if req.Eye == "" {
+		req.Eye = t.DefaultEye
+	}
+	if req.Eye == "" {
+		return errors.New("не указан глаз")
+	}
+	if !validEye(req.Eye) {
+		return errors.New("глаз должен быть OD, OS или OU")
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/beercut-team/backend-boilerplate/internal/domain"
+	"github.com/beercut-team/backend-boilerplate/internal/repository"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+// DuplicatePatientError — при создании или изменении найдены записи того же пациента
+type DuplicatePatientError struct {
+	Matches []domain.DuplicateMatch
+	// Strong — совпал документ; иначе запрос можно повторить с ignore_duplicates
+	Strong bool
+}
+
+func (e *DuplicatePatientError) Error() string {
+	if e.Strong {
+		return "пациент с такими документами уже зарегистрирован"
+	}
+	return "пациент с похожим ФИО и той же датой рождения уже зарегистрирован; если это другой человек, повторите запрос с ignore_duplicates: true"
+}
+
+// checkDuplicates ищет записи того же пациента. Похожее ФИО с датой рождения
+// пропускается при ignoreWeak, совпадение документов — никогда.
+func checkDuplicates(ctx context.Context, repo repository.PatientRepository, p *domain.Patient, ignoreWeak bool) error {
+	candidates, err := repo.FindDuplicateCandidates(ctx, p)
+	if err != nil {
+		return err
+	}
+
+	dupErr := &DuplicatePatientError{}
+	for i := range candidates {
+		reasons := domain.DuplicateReasons(p, &candidates[i])
+		strong := false
+		for _, r := range reasons {
+			strong = strong || r.Strong()
+		}
+		if len(reasons) == 0 || (!strong && ignoreWeak) {
+			continue
+		}
+		dupErr.Strong = dupErr.Strong || strong
+		dupErr.Matches = append(dupErr.Matches, domain.DuplicateMatch{
+			PatientID: candidates[i].ID,
+			DoctorID:  candidates[i].DoctorID,
+			Reasons:   reasons,
+		})
+	}
+
+	if len(dupErr.Matches) > 0 {
+		return dupErr
+	}
+	return nil
+}
+
+type PatientDuplicateService interface {
+	Report(ctx context.Context) ([]domain.DuplicatePair, error)
+	Merge(ctx context.Context, req domain.MergePatientsRequest, userID uint, ip string) (*domain.MergePatientsResult, error)
+}
+
+type patientDuplicateService struct {
+	repo  repository.PatientRepository
+	audit AuditService
+}
+
+func NewPatientDuplicateService(repo repository.PatientRepository, audit AuditService) PatientDuplicateService {
+	return &patientDuplicateService{repo: repo, audit: audit}
+}
+
+func (s *patientDuplicateService) Report(ctx context.Context) ([]domain.DuplicatePair, error) {
+	candidates, err := s.repo.FindDuplicatePairs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	pairs := []domain.DuplicatePair{}
+	for i := range candidates {
+		a, b := &candidates[i][0], &candidates[i][1]
+		reasons := domain.DuplicateReasons(a, b)
+		if len(reasons) == 0 {
+			continue
+		}
+		pairs = append(pairs, domain.DuplicatePair{
+			Patient:   domain.NewPatientBrief(a),
+			Duplicate: domain.NewPatientBrief(b),
+			Reasons:   reasons,
+		})
+	}
+	return pairs, nil
+}
+
+// Merge переносит данные дубля на оставшуюся запись и удаляет дубль.
+// В аудит пишутся diff оставшейся записи и удалённый дубль (ПДн и код доступа скрыты).
+func (s *patientDuplicateService) Merge(ctx context.Context, req domain.MergePatientsRequest, userID uint, ip string) (*domain.MergePatientsResult, error) {
+	if req.SurvivorID == req.DuplicateID {
+		return nil, errors.New("нельзя объединить пациента с самим собой")
+	}
+
+	survivor, err := s.repo.FindByID(ctx, req.SurvivorID)
+	if err != nil {
+		return nil, errors.New("пациент не найден")
+	}
+	duplicate, err := s.repo.FindByID(ctx, req.DuplicateID)
+	if err != nil {
+		return nil, errors.New("дубль не найден")
+	}
+
+	before := *survivor
+	filled := domain.MergePatientFields(survivor, duplicate)
+
+	moved, err := s.repo.Merge(ctx, survivor, duplicate.ID)
+	if err != nil {
+		if errors.Is(err, repository.ErrEyeExamConflict) {
+			return nil, err
+		}
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("дубль не найден")
+		}
+		log.Error().Err(err).Uint("survivor_id", survivor.ID).Uint("duplicate_id", duplicate.ID).Msg("ошибка объединения пациентов")
+		return nil, errors.New("не удалось объединить пациентов")
+	}
+
+	log.Info().Uint("survivor_id", survivor.ID).Uint("duplicate_id", duplicate.ID).Uint("user_id", userID).Str("ip", ip).
+		Strs("filled_fields", filled).Interface("moved", moved).Msg("пациенты объединены")
+
+	if s.audit != nil {
+		s.audit.Record(ctx, domain.AuditActionMerge, domain.AuditEntityPatient, survivor.ID, &before, survivor)
+		s.audit.Record(ctx, domain.AuditActionMerge, domain.AuditEntityPatient, duplicate.ID, duplicate, nil)
+	}
+
+	survivor.PopulateDisplayNames()
+	return &domain.MergePatientsResult{Survivor: survivor, FilledFields: filled, Moved: moved}, nil
+}
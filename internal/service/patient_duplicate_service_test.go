@@ -0,0 +1,69 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/beercut-team/backend-boilerplate/internal/domain"
+	"github.com/beercut-team/backend-boilerplate/internal/repository"
+)
+
+// mergePatientRepo отдаёт пациентов из памяти; Merge только заполняет оставшуюся запись
+type mergePatientRepo struct {
+	repository.PatientRepository
+	patients map[uint]*domain.Patient
+}
+
+func (r *mergePatientRepo) FindByID(_ context.Context, id uint) (*domain.Patient, error) {
+	p := *r.patients[id]
+	return &p, nil
+}
+
+func (r *mergePatientRepo) Merge(_ context.Context, _ *domain.Patient, _ uint) (map[string]int64, error) {
+	return map[string]int64{"checklist_items": 2}, nil
+}
+
+// auditRows запоминает записи журнала аудита
+type auditRows struct {
+	repository.AuditRepository
+	rows []*domain.AuditLog
+}
+
+func (r *auditRows) Create(_ context.Context, entry *domain.AuditLog) error {
+	r.rows = append(r.rows, entry)
+	return nil
+}
+
+func TestMergeAuditHidesAccessCode(t *testing.T) {
+	repo := &mergePatientRepo{patients: map[uint]*domain.Patient{
+		1: {ID: 1, AccessCode: "surv1234", FirstName: "Иван", LastName: "Петров"},
+		2: {ID: 2, AccessCode: "dupl5678", FirstName: "Иван", LastName: "Петров", MiddleName: "Сергеевич", Phone: "+79001234567"},
+	}}
+	rows := &auditRows{}
+	svc := NewPatientDuplicateService(repo, NewAuditService(rows))
+
+	ctx := WithAuditActor(context.Background(), 9, domain.RoleAdmin, "10.0.0.1")
+	if _, err := svc.Merge(ctx, domain.MergePatientsRequest{SurvivorID: 1, DuplicateID: 2}, 9, "10.0.0.1"); err != nil {
+		t.Fatalf("Merge: %v", err)
+	}
+
+	if len(rows.rows) != 2 {
+		t.Fatalf("audit rows = %d, want 2", len(rows.rows))
+	}
+	for _, row := range rows.rows {
+		raw, _ := json.Marshal(row)
+		for _, secret := range []string{"surv1234", "dupl5678", "+79001234567"} {
+			if strings.Contains(string(raw), secret) {
+				t.Errorf("audit row for patient %d contains %q: %s", row.EntityID, secret, raw)
+			}
+		}
+		if row.Action != domain.AuditActionMerge || row.UserID != 9 {
+			t.Errorf("audit row = %s by %d, want MERGE by 9", row.Action, row.UserID)
+		}
+	}
+	if change, ok := rows.rows[0].Changes["middle_name"]; !ok || change.New != "Сергеевич" {
+		t.Errorf("survivor changes = %+v, want middle_name filled from duplicate", rows.rows[0].Changes)
+	}
+}
@@ -16,8 +16,10 @@ import (
 type PatientService interface {
 	Create(ctx context.Context, req domain.CreatePatientRequest, doctorID uint) (*domain.Patient, error)
 	GetByID(ctx context.Context, id uint) (*domain.Patient, error)
-	GetByAccessCode(ctx context.Context, code string) (*domain.PatientPublicResponse, error)
+	// GetByAccessCode — публичный статус по коду; попытки ограничиваются как при входе
+	GetByAccessCode(ctx context.Context, code string, client domain.ClientInfo, proof domain.ChallengeProof) (*domain.PatientPublicResponse, error)
 	List(ctx context.Context, filters repository.PatientFilters, offset, limit int) ([]domain.Patient, int64, error)
+	Search(ctx context.Context, filters repository.PatientFilters, offset, limit int) (*domain.PatientSearchResult, int64, error)
 	Update(ctx context.Context, id uint, req domain.UpdatePatientRequest) (*domain.Patient, error)
 	Delete(ctx context.Context, id uint) error
 	ChangeStatus(ctx context.Context, id uint, req domain.PatientStatusRequest, changedBy uint) error
@@ -30,44 +32,33 @@ type patientService struct {
 	db            *gorm.DB
 	repo          repository.PatientRepository
 	checklistRepo repository.ChecklistRepository
+	templateRepo  repository.ChecklistTemplateRepository
+	opTypeRepo    repository.OperationTypeRepository
 	notifRepo     repository.NotificationRepository
+	audit         AuditService
+	codeGuard     AccessCodeGuard
 	bot           *telegram.Bot
 }
 
-func NewPatientService(db *gorm.DB, repo repository.PatientRepository, checklistRepo repository.ChecklistRepository, notifRepo repository.NotificationRepository, bot *telegram.Bot) PatientService {
-	return &patientService{db: db, repo: repo, checklistRepo: checklistRepo, notifRepo: notifRepo, bot: bot}
+func NewPatientService(db *gorm.DB, repo repository.PatientRepository, checklistRepo repository.ChecklistRepository, templateRepo repository.ChecklistTemplateRepository, opTypeRepo repository.OperationTypeRepository, notifRepo repository.NotificationRepository, audit AuditService, codeGuard AccessCodeGuard, bot *telegram.Bot) PatientService {
+	return &patientService{db: db, repo: repo, checklistRepo: checklistRepo, templateRepo: templateRepo, opTypeRepo: opTypeRepo, notifRepo: notifRepo, audit: audit, codeGuard: codeGuard, bot: bot}
 }
 
 func (s *patientService) Create(ctx context.Context, req domain.CreatePatientRequest, doctorID uint) (*domain.Patient, error) {
-	var dob time.Time
-	if req.DateOfBirth != "" {
-		parsed, err := time.Parse("2006-01-02", req.DateOfBirth)
-		if err != nil {
-			return nil, errors.New("неверный формат даты рождения, используйте ГГГГ-ММ-ДД")
-		}
-		dob = parsed
+	if err := req.NormalizeIdentifiers(); err != nil {
+		return nil, err
+	}
+	if err := resolvePatientOperation(ctx, s.opTypeRepo, &req); err != nil {
+		return nil, err
 	}
 
-	patient := &domain.Patient{
-		AccessCode:     domain.GenerateAccessCode(),
-		FirstName:      req.FirstName,
-		LastName:       req.LastName,
-		MiddleName:     req.MiddleName,
-		DateOfBirth:    dob,
-		Phone:          req.Phone,
-		Email:          req.Email,
-		Address:        req.Address,
-		SNILs:          req.SNILs,
-		PassportSeries: req.PassportSeries,
-		PassportNumber: req.PassportNumber,
-		PolicyNumber:   req.PolicyNumber,
-		Diagnosis:      req.Diagnosis,
-		OperationType:  req.OperationType,
-		Eye:            req.Eye,
-		Status:         domain.PatientStatusDraft,
-		DoctorID:       doctorID,
-		DistrictID:     req.DistrictID,
-		Notes:          req.Notes,
+	patient, err := newPatientFromRequest(req, doctorID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkDuplicates(ctx, s.repo, patient, req.IgnoreDuplicates); err != nil {
+		return nil, err
 	}
 
 	if err := s.repo.Create(ctx, patient); err != nil {
@@ -87,6 +78,7 @@ func (s *patientService) Create(ctx context.Context, req domain.CreatePatientReq
 		ChangedBy:  doctorID,
 		Comment:    "Пациент создан, чек-лист сгенерирован",
 	})
+	s.audit.Record(ctx, domain.AuditActionCreate, domain.AuditEntityPatient, patient.ID, nil, patient)
 
 	// Уведомить врача о новом пациенте
 	if s.bot != nil {
@@ -101,28 +93,48 @@ func (s *patientService) Create(ctx context.Context, req domain.CreatePatientReq
 	return patient, nil
 }
 
-func (s *patientService) generateChecklist(ctx context.Context, patient *domain.Patient) {
-	templates := domain.GetChecklistTemplates(patient.OperationType)
-	now := time.Now()
-
-	log.Info().Uint("patient_id", patient.ID).Str("operation_type", string(patient.OperationType)).Int("templates_count", len(templates)).Msg("🔧 НАЧАЛО генерации чек-листа")
-
-	var items []domain.ChecklistItem
-	for i, t := range templates {
-		item := domain.ChecklistItem{
-			PatientID:   patient.ID,
-			Name:        t.Name,
-			Description: t.Description,
-			Category:    t.Category,
-			IsRequired:  t.IsRequired,
-			Status:      domain.ChecklistStatusPending,
-		}
-		if t.ExpiresInDays > 0 {
-			exp := now.AddDate(0, 0, t.ExpiresInDays)
-			item.ExpiresAt = &exp
+// newPatientFromRequest собирает нового пациента в статусе DRAFT из запроса на создание
+func newPatientFromRequest(req domain.CreatePatientRequest, doctorID uint) (*domain.Patient, error) {
+	var dob time.Time
+	if req.DateOfBirth != "" {
+		parsed, err := time.Parse("2006-01-02", req.DateOfBirth)
+		if err != nil {
+			return nil, errors.New("неверный формат даты рождения, используйте ГГГГ-ММ-ДД")
 		}
-		log.Info().Int("index", i).Str("name", t.Name).Bool("template_required", t.IsRequired).Bool("item_required", item.IsRequired).Msg("📝 создание пункта")
-		items = append(items, item)
+		dob = parsed
+	}
+
+	patient := &domain.Patient{
+		AccessCode:     domain.GenerateAccessCode(),
+		FirstName:      req.FirstName,
+		LastName:       req.LastName,
+		MiddleName:     req.MiddleName,
+		DateOfBirth:    dob,
+		Phone:          req.Phone,
+		Email:          req.Email,
+		Address:        req.Address,
+		SNILs:          req.SNILs,
+		PassportSeries: req.PassportSeries,
+		PassportNumber: req.PassportNumber,
+		PolicyNumber:   req.PolicyNumber,
+		OMSPolicy:      req.OMSPolicy,
+		Gender:         req.Gender,
+		Diagnosis:      req.Diagnosis,
+		OperationType:  req.OperationType,
+		Eye:            req.Eye,
+		Status:         domain.PatientStatusDraft,
+		DoctorID:       doctorID,
+		DistrictID:     req.DistrictID,
+		Notes:          req.Notes,
+	}
+	return patient, nil
+}
+
+func (s *patientService) generateChecklist(ctx context.Context, patient *domain.Patient) {
+	items, err := buildPatientChecklist(ctx, s.templateRepo, patient, time.Now())
+	if err != nil {
+		log.Error().Err(err).Uint("patient_id", patient.ID).Msg("не удалось загрузить шаблон чек-листа")
+		return
 	}
 
 	if len(items) > 0 {
@@ -142,14 +154,20 @@ func (s *patientService) GetByID(ctx context.Context, id uint) (*domain.Patient,
 		}
 		return nil, err
 	}
+	s.audit.LogRead(ctx, domain.AuditEntityPatient, p.ID)
 	p.PopulateDisplayNames()
 	return p, nil
 }
 
-func (s *patientService) GetByAccessCode(ctx context.Context, code string) (*domain.PatientPublicResponse, error) {
+func (s *patientService) GetByAccessCode(ctx context.Context, code string, client domain.ClientInfo, proof domain.ChallengeProof) (*domain.PatientPublicResponse, error) {
+	if err := s.codeGuard.Check(ctx, client.IP, code, proof); err != nil {
+		return nil, err
+	}
+
 	p, err := s.repo.FindByAccessCode(ctx, code)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
+			s.codeGuard.Fail(ctx, AccessCodeScopeStatus, client.IP, code, "not_found")
 			return nil, errors.New("пациент не найден")
 		}
 		return nil, err
@@ -182,7 +200,29 @@ func (s *patientService) List(ctx context.Context, filters repository.PatientFil
 	return patients, total, nil
 }
 
+// Search — список пациентов вместе со счётчиками по фильтрам
+func (s *patientService) Search(ctx context.Context, filters repository.PatientFilters, offset, limit int) (*domain.PatientSearchResult, int64, error) {
+	patients, total, err := s.List(ctx, filters, offset, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	facets, err := s.repo.Facets(ctx, filters)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if patients == nil {
+		patients = []domain.Patient{}
+	}
+	return &domain.PatientSearchResult{Patients: patients, Facets: *facets}, total, nil
+}
+
 func (s *patientService) Update(ctx context.Context, id uint, req domain.UpdatePatientRequest) (*domain.Patient, error) {
+	if err := req.NormalizeIdentifiers(); err != nil {
+		return nil, err
+	}
+
 	p, err := s.repo.FindByID(ctx, id)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -191,10 +231,41 @@ func (s *patientService) Update(ctx context.Context, id uint, req domain.UpdateP
 		return nil, err
 	}
 
+	before := *p
 	// Track changes for notifications
-	diagnosisChanged := false
-	oldDiagnosis := p.Diagnosis
+	diagnosisChanged := applyPatientUpdate(p, req)
+
+	if req.ChangesIdentity() {
+		if err := checkDuplicates(ctx, s.repo, p, req.IgnoreDuplicates); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.repo.Update(ctx, p); err != nil {
+		return nil, errors.New("не удалось обновить данные пациента")
+	}
+	s.audit.Record(ctx, domain.AuditActionUpdate, domain.AuditEntityPatient, id, &before, p)
+
+	// Создать уведомление врачу при изменении диагноза
+	if diagnosisChanged && s.notifRepo != nil && p.Diagnosis != "" {
+		patientName := p.LastName + " " + p.FirstName
+		s.notifRepo.Create(ctx, &domain.Notification{
+			UserID:     p.DoctorID,
+			Type:       domain.NotifStatusChange,
+			Title:      "Диагноз установлен",
+			Body:       fmt.Sprintf("Пациент %s: установлен диагноз - %s", patientName, p.Diagnosis),
+			EntityType: "patient",
+			EntityID:   id,
+		})
+	}
+
+	p.PopulateDisplayNames()
+	return p, nil
+}
 
+// applyPatientUpdate переносит заполненные поля запроса в пациента и сообщает, изменился ли диагноз
+func applyPatientUpdate(p *domain.Patient, req domain.UpdatePatientRequest) bool {
+	diagnosisChanged := false
 	if req.FirstName != nil {
 		p.FirstName = *req.FirstName
 	}
@@ -213,7 +284,7 @@ func (s *patientService) Update(ctx context.Context, id uint, req domain.UpdateP
 	if req.Address != nil {
 		p.Address = *req.Address
 	}
-	if req.Diagnosis != nil && *req.Diagnosis != oldDiagnosis {
+	if req.Diagnosis != nil && *req.Diagnosis != p.Diagnosis {
 		p.Diagnosis = *req.Diagnosis
 		diagnosisChanged = true
 	}
@@ -232,33 +303,14 @@ func (s *patientService) Update(ctx context.Context, id uint, req domain.UpdateP
 	if req.PolicyNumber != nil {
 		p.PolicyNumber = *req.PolicyNumber
 	}
-
-	if err := s.repo.Update(ctx, p); err != nil {
-		return nil, errors.New("не удалось обновить данные пациента")
-	}
-
-	// Создать уведомление врачу при изменении диагноза
-	if diagnosisChanged && s.notifRepo != nil && p.Diagnosis != "" {
-		patientName := p.LastName + " " + p.FirstName
-		s.notifRepo.Create(ctx, &domain.Notification{
-			UserID:     p.DoctorID,
-			Type:       domain.NotifStatusChange,
-			Title:      "Диагноз установлен",
-			Body:       fmt.Sprintf("Пациент %s: установлен диагноз - %s", patientName, p.Diagnosis),
-			EntityType: "patient",
-			EntityID:   id,
-		})
-	}
-
-	p.PopulateDisplayNames()
-	return p, nil
+	return diagnosisChanged
 }
 
 func (s *patientService) Delete(ctx context.Context, id uint) error {
 	log.Info().Uint("patient_id", id).Msg("удаление пациента")
 
 	// Проверяем существование пациента
-	_, err := s.repo.FindByID(ctx, id)
+	p, err := s.repo.FindByID(ctx, id)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			log.Warn().Uint("patient_id", id).Msg("попытка удалить несуществующего пациента")
@@ -272,6 +324,7 @@ func (s *patientService) Delete(ctx context.Context, id uint) error {
 		log.Error().Err(err).Uint("patient_id", id).Msg("ошибка удаления пациента")
 		return errors.New("не удалось удалить пациента")
 	}
+	s.audit.Record(ctx, domain.AuditActionDelete, domain.AuditEntityPatient, id, p, nil)
 
 	log.Info().Uint("patient_id", id).Msg("пациент успешно удалён")
 	return nil
@@ -300,6 +353,9 @@ func (s *patientService) ChangeStatus(ctx context.Context, id uint, req domain.P
 		log.Error().Err(err).Uint("patient_id", id).Msg("ошибка обновления статуса")
 		return errors.New("не удалось обновить статус")
 	}
+	after := *p
+	after.Status = req.Status
+	s.audit.Record(ctx, domain.AuditActionUpdate, domain.AuditEntityPatient, id, p, &after)
 
 	s.repo.CreateStatusHistory(ctx, &domain.PatientStatusHistory{
 		PatientID:  id,
@@ -378,10 +434,12 @@ func (s *patientService) RegenerateAccessCode(ctx context.Context, id uint) (*do
 		}
 	}
 
+	before := *p
 	p.AccessCode = newCode
 	if err := s.repo.Update(ctx, p); err != nil {
 		return nil, errors.New("не удалось обновить код доступа")
 	}
+	s.audit.Record(ctx, domain.AuditActionUpdate, domain.AuditEntityPatient, id, &before, p)
 
 	// Уведомить пациента о новом коде через Telegram
 	if s.bot != nil {
@@ -402,10 +460,17 @@ func (s *patientService) DashboardStats(ctx context.Context, doctorID *uint, rol
 func (s *patientService) BatchUpdate(ctx context.Context, id uint, req domain.BatchUpdateRequest, userID uint) (*domain.BatchUpdateResponse, error) {
 	log.Info().Uint("patient_id", id).Uint("user_id", userID).Msg("начало batch update")
 
+	if req.Patient != nil {
+		if err := req.Patient.NormalizeIdentifiers(); err != nil {
+			return nil, err
+		}
+	}
+
 	response := &domain.BatchUpdateResponse{
 		Success:   true,
 		Conflicts: []string{},
 	}
+	var audits []auditEntry
 
 	// Начинаем транзакцию для атомарности
 	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
@@ -424,6 +489,8 @@ func (s *patientService) BatchUpdate(ctx context.Context, id uint, req domain.Ba
 			}
 		}
 
+		before := *patient
+
 		// 1. Обновляем данные пациента
 		if req.Patient != nil {
 			if req.Patient.Diagnosis != nil {
@@ -454,6 +521,12 @@ func (s *patientService) BatchUpdate(ctx context.Context, id uint, req domain.Ba
 				patient.PolicyNumber = *req.Patient.PolicyNumber
 			}
 
+			if req.Patient.ChangesIdentity() {
+				if err := checkDuplicates(ctx, s.repo, patient, req.Patient.IgnoreDuplicates); err != nil {
+					return err
+				}
+			}
+
 			if err := tx.Save(patient).Error; err != nil {
 				return errors.New("не удалось обновить данные пациента: " + err.Error())
 			}
@@ -504,6 +577,8 @@ func (s *patientService) BatchUpdate(ctx context.Context, id uint, req domain.Ba
 					continue
 				}
 
+				itemBefore := item
+
 				// Применяем обновления
 				updated := false
 				if itemUpdate.Status != nil {
@@ -529,6 +604,7 @@ func (s *patientService) BatchUpdate(ctx context.Context, id uint, req domain.Ba
 						response.Conflicts = append(response.Conflicts, "Ошибка обновления элемента чек-листа")
 					} else {
 						response.UpdatedItems++
+						audits = append(audits, auditEntry{domain.AuditActionUpdate, domain.AuditEntityChecklistItem, item.ID, itemBefore, item})
 					}
 				}
 			}
@@ -563,6 +639,7 @@ func (s *patientService) BatchUpdate(ctx context.Context, id uint, req domain.Ba
 			return errors.New("не удалось перезагрузить данные пациента")
 		}
 		response.Patient = patient
+		audits = append(audits, auditEntry{domain.AuditActionUpdate, domain.AuditEntityPatient, id, &before, patient})
 
 		return nil
 	})
@@ -574,6 +651,8 @@ func (s *patientService) BatchUpdate(ctx context.Context, id uint, req domain.Ba
 		return response, err
 	}
 
+	recordAll(ctx, s.audit, audits)
+
 	// Уведомления отправляем после успешной транзакции
 	if req.Status != nil && s.notifRepo != nil && response.Patient != nil {
 		statusText := domain.GetStatusDisplayName(req.Status.Status)
@@ -5,6 +5,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"sort"
 
 	"github.com/beercut-team/backend-boilerplate/internal/domain"
 	"github.com/beercut-team/backend-boilerplate/internal/repository"
@@ -19,10 +20,12 @@ type PDFService interface {
 type pdfService struct {
 	patientRepo   repository.PatientRepository
 	checklistRepo repository.ChecklistRepository
+	examRepo      repository.EyeExamRepository
+	audit         AuditService
 }
 
-func NewPDFService(patientRepo repository.PatientRepository, checklistRepo repository.ChecklistRepository) PDFService {
-	return &pdfService{patientRepo: patientRepo, checklistRepo: checklistRepo}
+func NewPDFService(patientRepo repository.PatientRepository, checklistRepo repository.ChecklistRepository, examRepo repository.EyeExamRepository, audit AuditService) PDFService {
+	return &pdfService{patientRepo: patientRepo, checklistRepo: checklistRepo, examRepo: examRepo, audit: audit}
 }
 
 // setupPDFFont tries to load DejaVu Sans fonts, falls back to Arial if not available
@@ -45,12 +48,19 @@ func (s *pdfService) GenerateRoutingSheet(ctx context.Context, patientID uint) (
 	if err != nil {
 		return nil, fmt.Errorf("пациент не найден: %w", err)
 	}
+	// Маршрутный лист содержит паспорт, СНИЛС и полис
+	s.audit.LogRead(ctx, domain.AuditEntityPatient, patientID)
 
 	items, err := s.checklistRepo.FindItemsByPatient(ctx, patientID)
 	if err != nil {
 		return nil, fmt.Errorf("не удалось загрузить чек-лист: %w", err)
 	}
 
+	exams, err := s.examRepo.FindByPatient(ctx, patientID, "")
+	if err != nil {
+		return nil, fmt.Errorf("не удалось загрузить осмотры: %w", err)
+	}
+
 	pdf := fpdf.New("P", "mm", "A4", "")
 	pdf.AddPage()
 
@@ -79,6 +89,44 @@ func (s *pdfService) GenerateRoutingSheet(ctx context.Context, patientID uint) (
 	pdf.Cell(190, 7, fmt.Sprintf("Diagnosis: %s", patient.Diagnosis))
 	pdf.Ln(12)
 
+	// Latest examination of each eye
+	if latest := latestExams(exams); len(latest) > 0 {
+		pdf.SetFont(fontFamily, "B", 13)
+		pdf.Cell(190, 10, "Eye Examination")
+		pdf.Ln(10)
+
+		pdf.SetFont(fontFamily, "B", 9)
+		pdf.CellFormat(12, 7, "Eye", "1", 0, "", false, 0, "")
+		pdf.CellFormat(22, 7, "Date", "1", 0, "", false, 0, "")
+		pdf.CellFormat(18, 7, "UCVA", "1", 0, "", false, 0, "")
+		pdf.CellFormat(18, 7, "BCVA", "1", 0, "", false, 0, "")
+		pdf.CellFormat(45, 7, "Refraction", "1", 0, "", false, 0, "")
+		pdf.CellFormat(35, 7, "IOP", "1", 0, "", false, 0, "")
+		pdf.CellFormat(40, 7, "LOCS III", "1", 1, "", false, 0, "")
+
+		pdf.SetFont(fontFamily, "", 8)
+		for _, exam := range latest {
+			pdf.CellFormat(12, 6, exam.Eye, "1", 0, "C", false, 0, "")
+			pdf.CellFormat(22, 6, exam.ExamDate.Format("02.01.2006"), "1", 0, "C", false, 0, "")
+			pdf.CellFormat(18, 6, formatOptional(exam.UCVA, "%.2f"), "1", 0, "C", false, 0, "")
+			pdf.CellFormat(18, 6, formatOptional(exam.BCVA, "%.2f"), "1", 0, "C", false, 0, "")
+			pdf.CellFormat(45, 6, formatRefraction(exam), "1", 0, "", false, 0, "")
+			iop := formatOptional(exam.IOP, "%.0f mmHg")
+			if exam.IOP != nil && exam.IOPMethod != "" {
+				iop += " (" + string(exam.IOPMethod) + ")"
+			}
+			pdf.CellFormat(35, 6, iop, "1", 0, "", false, 0, "")
+			pdf.CellFormat(40, 6, exam.LOCS.String(), "1", 1, "", false, 0, "")
+			if exam.Biomicroscopy != "" {
+				pdf.MultiCell(190, 5, fmt.Sprintf("%s biomicroscopy: %s", exam.Eye, exam.Biomicroscopy), "", "", false)
+			}
+			if exam.Fundus != "" {
+				pdf.MultiCell(190, 5, fmt.Sprintf("%s fundus: %s", exam.Eye, exam.Fundus), "", "", false)
+			}
+		}
+		pdf.Ln(6)
+	}
+
 	// Checklist summary
 	pdf.SetFont(fontFamily, "B", 13)
 	pdf.Cell(190, 10, "Checklist Items")
@@ -115,6 +163,43 @@ func (s *pdfService) GenerateRoutingSheet(ctx context.Context, patientID uint) (
 	return &buf, nil
 }
 
+// latestExams — последний осмотр каждого глаза; exams отсортированы от новых к старым
+func latestExams(exams []domain.EyeExam) []domain.EyeExam {
+	var latest []domain.EyeExam
+	seen := map[string]bool{}
+	for _, exam := range exams {
+		if seen[exam.Eye] {
+			continue
+		}
+		seen[exam.Eye] = true
+		latest = append(latest, exam)
+	}
+	sort.Slice(latest, func(i, j int) bool { return latest[i].Eye < latest[j].Eye })
+	return latest
+}
+
+func formatOptional(v *float64, format string) string {
+	if v == nil {
+		return "-"
+	}
+	return fmt.Sprintf(format, *v)
+}
+
+// formatRefraction — рефракция в записи «сфера / цилиндр x ось»
+func formatRefraction(exam domain.EyeExam) string {
+	if exam.Sphere == nil {
+		return "-"
+	}
+	s := fmt.Sprintf("%+.2f", *exam.Sphere)
+	if exam.Cylinder != nil && *exam.Cylinder != 0 {
+		s += fmt.Sprintf(" / %+.2f", *exam.Cylinder)
+		if exam.Axis != nil {
+			s += fmt.Sprintf(" x %.0f", *exam.Axis)
+		}
+	}
+	return s
+}
+
 func (s *pdfService) GenerateChecklistReport(ctx context.Context, patientID uint) (*bytes.Buffer, error) {
 	patient, err := s.patientRepo.FindByID(ctx, patientID)
 	if err != nil {
@@ -0,0 +1,359 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/beercut-team/backend-boilerplate/internal/domain"
+	"github.com/beercut-team/backend-boilerplate/internal/repository"
+	"github.com/beercut-team/backend-boilerplate/internal/service/formulas"
+	"gorm.io/gorm"
+)
+
+// PostOpService ведёт послеоперационные осмотры и считает по ним точность
+// расчётов ИОЛ: ошибку прогноза и персональную A-константу
+type PostOpService interface {
+	Create(ctx context.Context, surgeryID uint, req domain.CreatePostOpVisitRequest, userID uint) (*domain.PostOpVisit, error)
+	GetByID(ctx context.Context, id uint) (*domain.PostOpVisit, error)
+	ListBySurgery(ctx context.Context, surgeryID uint) ([]domain.PostOpVisit, error)
+	Update(ctx context.Context, id uint, req domain.UpdatePostOpVisitRequest) (*domain.PostOpVisit, error)
+	Delete(ctx context.Context, id uint) error
+	Stats(ctx context.Context, filters repository.OutcomeFilters) (*domain.OutcomeStats, error)
+	OptimizeAConstant(ctx context.Context, surgeonID uint, lensID *uint) (*domain.AConstantOptimization, error)
+}
+
+type postOpService struct {
+	repo        repository.PostOpRepository
+	surgeryRepo repository.SurgeryRepository
+	iolRepo     repository.IOLRepository
+	lensRepo    repository.IOLLensRepository
+	userRepo    repository.UserRepository
+}
+
+func NewPostOpService(repo repository.PostOpRepository, surgeryRepo repository.SurgeryRepository, iolRepo repository.IOLRepository, lensRepo repository.IOLLensRepository, userRepo repository.UserRepository) PostOpService {
+	return &postOpService{repo: repo, surgeryRepo: surgeryRepo, iolRepo: iolRepo, lensRepo: lensRepo, userRepo: userRepo}
+}
+
+func (s *postOpService) Create(ctx context.Context, surgeryID uint, req domain.CreatePostOpVisitRequest, userID uint) (*domain.PostOpVisit, error) {
+	surgery, err := s.surgeryRepo.FindByID(ctx, surgeryID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("операция не найдена")
+		}
+		return nil, err
+	}
+	if surgery.Status == domain.SurgeryStatusCancelled {
+		return nil, errors.New("операция отменена")
+	}
+
+	visit := &domain.PostOpVisit{
+		SurgeryID:        surgery.ID,
+		PatientID:        surgery.PatientID,
+		Eye:              surgery.Eye,
+		IOLCalculationID: req.IOLCalculationID,
+		Sphere:           *req.Sphere,
+		Cylinder:         req.Cylinder,
+		Axis:             req.Axis,
+		UCVA:             req.UCVA,
+		BCVA:             req.BCVA,
+		IOP:              req.IOP,
+		ImplantedPower:   req.ImplantedPower,
+		Notes:            req.Notes,
+		RecordedBy:       userID,
+		VisitDate:        time.Now(),
+	}
+	if req.VisitDate != "" {
+		if visit.VisitDate, err = time.Parse("2006-01-02", req.VisitDate); err != nil {
+			return nil, errors.New("неверный формат даты осмотра, используйте ГГГГ-ММ-ДД")
+		}
+	}
+
+	if err := s.prepare(ctx, surgery, visit); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Create(ctx, visit); err != nil {
+		return nil, errors.New("не удалось сохранить осмотр")
+	}
+	return visit, nil
+}
+
+func (s *postOpService) GetByID(ctx context.Context, id uint) (*domain.PostOpVisit, error) {
+	visit, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, errors.New("осмотр не найден")
+	}
+	return visit, nil
+}
+
+func (s *postOpService) ListBySurgery(ctx context.Context, surgeryID uint) ([]domain.PostOpVisit, error) {
+	return s.repo.FindBySurgery(ctx, surgeryID)
+}
+
+func (s *postOpService) Update(ctx context.Context, id uint, req domain.UpdatePostOpVisitRequest) (*domain.PostOpVisit, error) {
+	visit, err := s.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	surgery, err := s.surgeryRepo.FindByID(ctx, visit.SurgeryID)
+	if err != nil {
+		return nil, errors.New("операция не найдена")
+	}
+
+	if req.IOLCalculationID != nil {
+		visit.IOLCalculationID = req.IOLCalculationID
+	}
+	if req.VisitDate != nil {
+		if visit.VisitDate, err = time.Parse("2006-01-02", *req.VisitDate); err != nil {
+			return nil, errors.New("неверный формат даты осмотра, используйте ГГГГ-ММ-ДД")
+		}
+	}
+	if req.Sphere != nil {
+		visit.Sphere = *req.Sphere
+	}
+	if req.Cylinder != nil {
+		visit.Cylinder = *req.Cylinder
+	}
+	if req.Axis != nil {
+		visit.Axis = *req.Axis
+	}
+	if req.UCVA != nil {
+		visit.UCVA = req.UCVA
+	}
+	if req.BCVA != nil {
+		visit.BCVA = req.BCVA
+	}
+	if req.IOP != nil {
+		visit.IOP = req.IOP
+	}
+	if req.ImplantedPower != nil {
+		visit.ImplantedPower = req.ImplantedPower
+	}
+	if req.Notes != nil {
+		visit.Notes = *req.Notes
+	}
+
+	if err := s.prepare(ctx, surgery, visit); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Update(ctx, visit); err != nil {
+		return nil, errors.New("не удалось обновить осмотр")
+	}
+	return visit, nil
+}
+
+func (s *postOpService) Delete(ctx context.Context, id uint) error {
+	if _, err := s.GetByID(ctx, id); err != nil {
+		return err
+	}
+	return s.repo.Delete(ctx, id)
+}
+
+// prepare проверяет осмотр, привязывает расчёт ИОЛ и считает ошибку прогноза
+func (s *postOpService) prepare(ctx context.Context, surgery *domain.Surgery, visit *domain.PostOpVisit) error {
+	if err := validatePostOpVisit(visit); err != nil {
+		return err
+	}
+	if visit.VisitDate.Before(truncateDay(surgery.ScheduledDate)) {
+		return errors.New("дата осмотра раньше даты операции")
+	}
+
+	calc, err := s.calculation(ctx, surgery, visit.IOLCalculationID)
+	if err != nil {
+		return err
+	}
+
+	visit.SphericalEquivalent = domain.SphericalEquivalentOf(visit.Sphere, visit.Cylinder)
+	visit.PredictionError = nil
+	if calc != nil {
+		visit.IOLCalculationID = &calc.ID
+		predictionError := math.Round((visit.SphericalEquivalent-calc.PredictedRefraction)*100) / 100
+		visit.PredictionError = &predictionError
+	}
+	return nil
+}
+
+// calculation возвращает расчёт ИОЛ для осмотра; без явного id — последний
+// расчёт на этот глаз, сделанный до операции
+func (s *postOpService) calculation(ctx context.Context, surgery *domain.Surgery, calcID *uint) (*domain.IOLCalculation, error) {
+	if calcID != nil {
+		calc, err := s.iolRepo.FindByID(ctx, *calcID)
+		if err != nil {
+			return nil, errors.New("расчёт ИОЛ не найден")
+		}
+		if calc.PatientID != surgery.PatientID {
+			return nil, errors.New("расчёт ИОЛ относится к другому пациенту")
+		}
+		if surgery.Eye != "" && domain.NormalizeEye(calc.Eye) != domain.NormalizeEye(surgery.Eye) {
+			return nil, errors.New("расчёт ИОЛ сделан для другого глаза")
+		}
+		return calc, nil
+	}
+
+	calcs, err := s.iolRepo.FindByPatient(ctx, surgery.PatientID)
+	if err != nil {
+		return nil, err
+	}
+	// FindByPatient отдаёт расчёты от новых к старым
+	for i := range calcs {
+		if calcs[i].CreatedAt.After(surgery.ScheduledDate) {
+			continue
+		}
+		if surgery.Eye == "" || domain.NormalizeEye(calcs[i].Eye) == domain.NormalizeEye(surgery.Eye) {
+			return &calcs[i], nil
+		}
+	}
+	return nil, nil
+}
+
+func validatePostOpVisit(v *domain.PostOpVisit) error {
+	if math.Abs(v.Sphere) > 30 {
+		return errors.New("сфера должна быть в диапазоне ±30 D")
+	}
+	if math.Abs(v.Cylinder) > 10 {
+		return errors.New("цилиндр должен быть в диапазоне ±10 D")
+	}
+	if v.Axis < 0 || v.Axis > 180 {
+		return errors.New("ось должна быть в диапазоне 0-180°")
+	}
+	for _, va := range []*float64{v.UCVA, v.BCVA} {
+		if va != nil && (*va < 0 || *va > 2) {
+			return errors.New("острота зрения должна быть в диапазоне 0-2.0")
+		}
+	}
+	if v.IOP != nil && (*v.IOP < 0 || *v.IOP > 80) {
+		return errors.New("ВГД должно быть в диапазоне 0-80 мм рт. ст.")
+	}
+	if v.ImplantedPower != nil && (*v.ImplantedPower < -10 || *v.ImplantedPower > 40) {
+		return errors.New("мощность линзы должна быть в диапазоне -10…40 D")
+	}
+	return nil
+}
+
+func truncateDay(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
+
+// Stats считает ошибку прогноза в целом, по хирургам и по моделям линз
+func (s *postOpService) Stats(ctx context.Context, filters repository.OutcomeFilters) (*domain.OutcomeStats, error) {
+	outcomes, err := s.outcomes(ctx, filters)
+	if err != nil {
+		return nil, err
+	}
+
+	var all []float64
+	bySurgeon := map[uint][]float64{}
+	byLens := map[uint][]float64{}
+	for _, o := range outcomes {
+		all = append(all, o.PredictionError())
+		bySurgeon[o.SurgeonID] = append(bySurgeon[o.SurgeonID], o.PredictionError())
+		if o.LensID != nil {
+			byLens[*o.LensID] = append(byLens[*o.LensID], o.PredictionError())
+		}
+	}
+
+	stats := &domain.OutcomeStats{
+		Overall:   domain.NewPredictionErrorStats(all),
+		BySurgeon: []domain.OutcomeGroupStats{},
+		ByLens:    []domain.OutcomeGroupStats{},
+	}
+	for _, id := range sortedKeys(bySurgeon) {
+		group := domain.OutcomeGroupStats{SurgeonID: &id, PredictionErrorStats: domain.NewPredictionErrorStats(bySurgeon[id])}
+		if user, err := s.userRepo.FindByID(ctx, id); err == nil {
+			group.SurgeonName = user.Name
+		}
+		stats.BySurgeon = append(stats.BySurgeon, group)
+	}
+	for _, id := range sortedKeys(byLens) {
+		group := domain.OutcomeGroupStats{LensID: &id, PredictionErrorStats: domain.NewPredictionErrorStats(byLens[id])}
+		if lens, err := s.lensRepo.FindByID(ctx, id); err == nil {
+			group.LensName = lens.Manufacturer + " " + lens.Model
+		}
+		stats.ByLens = append(stats.ByLens, group)
+	}
+	return stats, nil
+}
+
+// OptimizeAConstant подбирает A-константу SRK/T, обнуляющую среднюю ошибку
+// прогноза хирурга: для каждого исхода константа решается обратно по
+// имплантированной мощности и фактической рефракции, затем усредняется
+func (s *postOpService) OptimizeAConstant(ctx context.Context, surgeonID uint, lensID *uint) (*domain.AConstantOptimization, error) {
+	outcomes, err := s.outcomes(ctx, repository.OutcomeFilters{SurgeonID: &surgeonID, LensID: lensID})
+	if err != nil {
+		return nil, err
+	}
+
+	result := &domain.AConstantOptimization{SurgeonID: surgeonID, LensID: lensID}
+	var solved []domain.PostOpOutcome
+	var sumA, sumUsedA float64
+	for _, o := range outcomes {
+		a, ok := formulas.SRKTAConstantFor(o.AxialLength, o.AverageK, o.ImplantedPower, o.ActualRefraction)
+		if !ok {
+			continue
+		}
+		solved = append(solved, o)
+		sumA += a
+		sumUsedA += o.AConstant
+	}
+	if skipped := len(outcomes) - len(solved); skipped > 0 {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("Исключено исходов с неправдоподобной рефракцией: %d", skipped))
+	}
+	if len(solved) == 0 {
+		return nil, fmt.Errorf("нет исходов для оптимизации: нужны осмотры не раньше %d дней после операции со связанным расчётом ИОЛ", domain.StableRefractionDays)
+	}
+	if len(solved) < domain.MinOptimizationCases {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("Исходов %d, для надёжной константы нужно не меньше %d", len(solved), domain.MinOptimizationCases))
+	}
+
+	n := float64(len(solved))
+	result.Cases = len(solved)
+	result.CurrentAConstant = math.Round(sumUsedA/n*100) / 100
+	if lensID != nil {
+		lens, err := s.lensRepo.FindByID(ctx, *lensID)
+		if err != nil {
+			return nil, errors.New("линза не найдена")
+		}
+		result.CurrentAConstant = lens.AConstant
+	}
+	result.OptimizedAConstant = math.Round(sumA/n*100) / 100
+
+	var before, after []float64
+	for _, o := range solved {
+		before = append(before, o.ActualRefraction-formulas.SRKTResult(o.AxialLength, o.AverageK, o.AConstant, 0).RefractionFor(o.ImplantedPower))
+		after = append(after, o.ActualRefraction-formulas.SRKTResult(o.AxialLength, o.AverageK, result.OptimizedAConstant, 0).RefractionFor(o.ImplantedPower))
+	}
+	result.Before = domain.NewPredictionErrorStats(before)
+	result.After = domain.NewPredictionErrorStats(after)
+	return result, nil
+}
+
+// outcomes — последний стабильный осмотр каждой операции
+func (s *postOpService) outcomes(ctx context.Context, filters repository.OutcomeFilters) ([]domain.PostOpOutcome, error) {
+	rows, err := s.repo.FindOutcomes(ctx, filters)
+	if err != nil {
+		return nil, err
+	}
+	seen := map[uint]bool{}
+	var outcomes []domain.PostOpOutcome
+	for _, o := range rows {
+		if seen[o.SurgeryID] || !o.Stable() {
+			continue
+		}
+		seen[o.SurgeryID] = true
+		outcomes = append(outcomes, o)
+	}
+	return outcomes, nil
+}
+
+func sortedKeys(m map[uint][]float64) []uint {
+	keys := make([]uint, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	return keys
+}
@@ -16,6 +16,8 @@ type SchedulerService struct {
 	surgeryRepo   repository.SurgeryRepository
 	notifRepo     repository.NotificationRepository
 	mediaRepo     repository.MediaRepository
+	sessionRepo   repository.SessionRepository
+	userTokenRepo repository.UserTokenRepository
 }
 
 func NewSchedulerService(
@@ -23,6 +25,8 @@ func NewSchedulerService(
 	surgeryRepo repository.SurgeryRepository,
 	notifRepo repository.NotificationRepository,
 	mediaRepo repository.MediaRepository,
+	sessionRepo repository.SessionRepository,
+	userTokenRepo repository.UserTokenRepository,
 ) *SchedulerService {
 	return &SchedulerService{
 		cron:          cron.New(),
@@ -30,6 +34,8 @@ func NewSchedulerService(
 		surgeryRepo:   surgeryRepo,
 		notifRepo:     notifRepo,
 		mediaRepo:     mediaRepo,
+		sessionRepo:   sessionRepo,
+		userTokenRepo: userTokenRepo,
 	}
 }
 
@@ -43,6 +49,10 @@ func (s *SchedulerService) Start() {
 	// Daily 03:00 — cleanup orphaned media
 	s.cron.AddFunc("0 3 * * *", s.cleanupOrphanedMedia)
 
+	// Daily 04:00 — cleanup stale sessions and email link tokens
+	s.cron.AddFunc("0 4 * * *", s.cleanupSessions)
+	s.cron.AddFunc("0 4 * * *", s.cleanupUserTokens)
+
 	s.cron.Start()
 	log.Info().Msg("планировщик запущен")
 }
@@ -104,3 +114,28 @@ func (s *SchedulerService) cleanupOrphanedMedia() {
 		log.Info().Uint("media_id", m.ID).Msg("планировщик: удалён потерянный медиафайл")
 	}
 }
+
+// Завершённые сессии хранятся месяц для разбора инцидентов
+const sessionRetention = 30 * 24 * time.Hour
+
+func (s *SchedulerService) cleanupSessions() {
+	deleted, err := s.sessionRepo.DeleteStale(context.Background(), time.Now().Add(-sessionRetention))
+	if err != nil {
+		log.Error().Err(err).Msg("планировщик: не удалось удалить устаревшие сессии")
+		return
+	}
+	if deleted > 0 {
+		log.Info().Int64("count", deleted).Msg("планировщик: удалены устаревшие сессии")
+	}
+}
+
+func (s *SchedulerService) cleanupUserTokens() {
+	deleted, err := s.userTokenRepo.DeleteStale(context.Background(), time.Now().Add(-sessionRetention))
+	if err != nil {
+		log.Error().Err(err).Msg("планировщик: не удалось удалить истёкшие ссылки из писем")
+		return
+	}
+	if deleted > 0 {
+		log.Info().Int64("count", deleted).Msg("планировщик: удалены истёкшие ссылки из писем")
+	}
+}
@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
 	"time"
 
 	"github.com/beercut-team/backend-boilerplate/internal/domain"
@@ -11,7 +12,7 @@ import (
 )
 
 type SurgeryService interface {
-	Schedule(ctx context.Context, req domain.CreateSurgeryRequest, surgeonID uint) (*domain.Surgery, error)
+	Schedule(ctx context.Context, req domain.CreateSurgeryRequest, userID uint, role domain.Role) (*domain.Surgery, error)
 	GetByID(ctx context.Context, id uint) (*domain.Surgery, error)
 	ListBySurgeon(ctx context.Context, surgeonID uint, offset, limit int) ([]domain.Surgery, int64, error)
 	Update(ctx context.Context, id uint, req domain.UpdateSurgeryRequest) (*domain.Surgery, error)
@@ -19,17 +20,37 @@ type SurgeryService interface {
 }
 
 type surgeryService struct {
+	db            *gorm.DB
 	repo          repository.SurgeryRepository
 	patientRepo   repository.PatientRepository
 	checklistRepo repository.ChecklistRepository
 	notifRepo     repository.NotificationRepository
+	userRepo      repository.UserRepository
+	opTypeRepo    repository.OperationTypeRepository
+	audit         AuditService
+	loc           *time.Location
 }
 
-func NewSurgeryService(repo repository.SurgeryRepository, patientRepo repository.PatientRepository, checklistRepo repository.ChecklistRepository, notifRepo repository.NotificationRepository) SurgeryService {
-	return &surgeryService{repo: repo, patientRepo: patientRepo, checklistRepo: checklistRepo, notifRepo: notifRepo}
+func NewSurgeryService(db *gorm.DB, repo repository.SurgeryRepository, patientRepo repository.PatientRepository, checklistRepo repository.ChecklistRepository, notifRepo repository.NotificationRepository, userRepo repository.UserRepository, opTypeRepo repository.OperationTypeRepository, audit AuditService, loc *time.Location) SurgeryService {
+	if loc == nil {
+		loc = time.Local
+	}
+	return &surgeryService{db: db, repo: repo, patientRepo: patientRepo, checklistRepo: checklistRepo, notifRepo: notifRepo, userRepo: userRepo, opTypeRepo: opTypeRepo, audit: audit, loc: loc}
 }
 
-func (s *surgeryService) Schedule(ctx context.Context, req domain.CreateSurgeryRequest, surgeonID uint) (*domain.Surgery, error) {
+func (s *surgeryService) Schedule(ctx context.Context, req domain.CreateSurgeryRequest, userID uint, role domain.Role) (*domain.Surgery, error) {
+	surgeonID := userID
+	if req.SurgeonID != nil && *req.SurgeonID != userID {
+		if role != domain.RoleAdmin {
+			return nil, errors.New("записывать операцию к другому хирургу может только администратор")
+		}
+		surgeon, err := s.userRepo.FindByID(ctx, *req.SurgeonID)
+		if err != nil || surgeon.Role != domain.RoleSurgeon {
+			return nil, errors.New("хирург не найден")
+		}
+		surgeonID = surgeon.ID
+	}
+
 	patient, err := s.patientRepo.FindByID(ctx, req.PatientID)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -38,6 +59,11 @@ func (s *surgeryService) Schedule(ctx context.Context, req domain.CreateSurgeryR
 		return nil, err
 	}
 
+	opType, err := activeOperationType(ctx, s.opTypeRepo, patient.OperationType)
+	if err != nil {
+		return nil, err
+	}
+
 	// Readiness check
 	_, _, required, requiredCompleted, err := s.checklistRepo.CountByPatient(ctx, req.PatientID)
 	if err != nil {
@@ -47,24 +73,36 @@ func (s *surgeryService) Schedule(ctx context.Context, req domain.CreateSurgeryR
 		return nil, errors.New("не все обязательные пункты чек-листа выполнены")
 	}
 
-	date, err := time.Parse("2006-01-02", req.ScheduledDate)
+	start, hasTime, err := parseSurgeryTime(req.ScheduledDate, s.loc)
 	if err != nil {
-		return nil, errors.New("неверный формат даты, используйте ГГГГ-ММ-ДД")
+		return nil, err
 	}
 
 	surgery := &domain.Surgery{
-		PatientID:     req.PatientID,
-		SurgeonID:     surgeonID,
-		ScheduledDate: date,
-		OperationType: patient.OperationType,
-		Eye:           patient.Eye,
-		Status:        domain.SurgeryStatusScheduled,
-		Notes:         req.Notes,
+		PatientID:       req.PatientID,
+		SurgeonID:       surgeonID,
+		OperationType:   patient.OperationType,
+		DurationMinutes: int(opType.Duration() / time.Minute),
+		Eye:             patient.Eye,
+		Status:          domain.SurgeryStatusScheduled,
+		Notes:           req.Notes,
 	}
 
-	if err := s.repo.Create(ctx, surgery); err != nil {
-		return nil, errors.New("не удалось запланировать операцию")
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := s.reserve(ctx, repository.NewCalendarRepository(tx), surgery, start, hasTime, req.RoomID); err != nil {
+			return err
+		}
+		if err := repository.NewSurgeryRepository(tx).Create(ctx, surgery); err != nil {
+			return errors.New("не удалось запланировать операцию")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
+	s.audit.Record(ctx, domain.AuditActionCreate, domain.AuditEntitySurgery, surgery.ID, nil, surgery)
+
+	when := formatSurgeryTime(surgery.ScheduledDate, s.loc)
 
 	// Auto-transition patient
 	s.patientRepo.UpdateStatus(ctx, req.PatientID, domain.PatientStatusScheduled)
@@ -72,14 +110,19 @@ func (s *surgeryService) Schedule(ctx context.Context, req domain.CreateSurgeryR
 		PatientID:  req.PatientID,
 		FromStatus: patient.Status,
 		ToStatus:   domain.PatientStatusScheduled,
-		ChangedBy:  surgeonID,
-		Comment:    "Операция запланирована на " + req.ScheduledDate,
+		ChangedBy:  userID,
+		Comment:    "Операция запланирована на " + when,
 	})
 
-	// Update patient surgery date and surgeon
+	// Update patient surgery date and surgeon; статус уже сменён, Save не должен его откатить
+	before := *patient
+	date := surgery.ScheduledDate
+	patient.Status = domain.PatientStatusScheduled
 	patient.SurgeryDate = &date
 	patient.SurgeonID = &surgeonID
-	s.patientRepo.Update(ctx, patient)
+	if err := s.patientRepo.Update(ctx, patient); err == nil {
+		s.audit.Record(ctx, domain.AuditActionUpdate, domain.AuditEntityPatient, patient.ID, &before, patient)
+	}
 
 	// Создать уведомление для пациента о запланированной операции
 	if s.notifRepo != nil {
@@ -87,7 +130,7 @@ func (s *surgeryService) Schedule(ctx context.Context, req domain.CreateSurgeryR
 			UserID:     surgery.PatientID, // ID пациента
 			Type:       domain.NotifSurgeryScheduled,
 			Title:      "Операция запланирована",
-			Body:       "Ваша операция назначена на " + date.Format("02.01.2006"),
+			Body:       "Ваша операция назначена на " + when,
 			EntityType: "surgery",
 			EntityID:   surgery.ID,
 		})
@@ -96,6 +139,107 @@ func (s *surgeryService) Schedule(ctx context.Context, req domain.CreateSurgeryR
 	return surgery, nil
 }
 
+// reserve проверяет календарь хирурга и операционных и назначает время и операционную.
+// Без указанного времени выбирается первый свободный слот дня. Вызывается в транзакции.
+func (s *surgeryService) reserve(ctx context.Context, repo repository.CalendarRepository, surgery *domain.Surgery, start time.Time, hasTime bool, roomID *uint) error {
+	if err := repo.LockBookings(ctx); err != nil {
+		return err
+	}
+
+	duration := time.Duration(surgery.DurationMinutes) * time.Minute
+	if duration <= 0 {
+		duration = domain.OperationDuration(surgery.OperationType)
+	}
+	day := dateIn(start, s.loc)
+
+	calendar, rooms, err := loadBookingContext(ctx, repo, surgery.SurgeonID, day, day.AddDate(0, 0, 1))
+	if err != nil {
+		return err
+	}
+
+	if roomID != nil {
+		var selected []domain.RoomBookings
+		for _, room := range rooms {
+			if room.Room.ID == *roomID {
+				selected = append(selected, room)
+			}
+		}
+		if len(selected) == 0 {
+			room, err := repo.FindRoomByID(ctx, *roomID)
+			if err != nil {
+				return errors.New("операционная не найдена")
+			}
+			return fmt.Errorf("операционная %q не используется", room.Name)
+		}
+		rooms = selected
+	}
+
+	now := time.Now()
+	if !hasTime {
+		slots := domain.FindSlots(calendar, rooms, day, day, duration, domain.DefaultSlotStepMinutes*time.Minute, surgery.ID, now)
+		if len(slots) == 0 {
+			return fmt.Errorf("нет свободного времени для операции на %s", day.Format("02.01.2006"))
+		}
+		start = slots[0].Start
+		if len(slots[0].RoomIDs) > 0 {
+			rooms = []domain.RoomBookings{roomByID(rooms, slots[0].RoomIDs[0])}
+		}
+	}
+
+	if err := domain.CheckBooking(calendar, nil, start, duration, surgery.ID, now); err != nil {
+		return err
+	}
+	surgery.ScheduledDate = start
+	surgery.DurationMinutes = int(duration / time.Minute)
+	surgery.RoomID = nil
+	surgery.Room = nil
+	if len(rooms) == 0 {
+		return nil
+	}
+
+	var lastErr error
+	for i := range rooms {
+		if lastErr = domain.CheckBooking(calendar, &rooms[i], start, duration, surgery.ID, now); lastErr == nil {
+			id := rooms[i].Room.ID
+			surgery.RoomID = &id
+			return nil
+		}
+	}
+	if len(rooms) == 1 {
+		return lastErr
+	}
+	return errors.New("все операционные заняты в это время")
+}
+
+func roomByID(rooms []domain.RoomBookings, id uint) domain.RoomBookings {
+	for _, room := range rooms {
+		if room.Room.ID == id {
+			return room
+		}
+	}
+	return domain.RoomBookings{}
+}
+
+// parseSurgeryTime разбирает дату или дату со временем операции в часовом поясе клиники
+func parseSurgeryTime(value string, loc *time.Location) (time.Time, bool, error) {
+	if t, err := time.ParseInLocation("2006-01-02", value, loc); err == nil {
+		return t, false, nil
+	}
+	for _, layout := range []string{"2006-01-02T15:04", "2006-01-02 15:04", "2006-01-02T15:04:05"} {
+		if t, err := time.ParseInLocation(layout, value, loc); err == nil {
+			return t, true, nil
+		}
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t.In(loc), true, nil
+	}
+	return time.Time{}, false, errors.New("неверный формат даты, используйте ГГГГ-ММ-ДД или ГГГГ-ММ-ДДTЧЧ:ММ")
+}
+
+func formatSurgeryTime(t time.Time, loc *time.Location) string {
+	return t.In(loc).Format("02.01.2006 в 15:04")
+}
+
 func (s *surgeryService) GetByID(ctx context.Context, id uint) (*domain.Surgery, error) {
 	surgery, err := s.repo.FindByID(ctx, id)
 	if err != nil {
@@ -120,13 +264,7 @@ func (s *surgeryService) Update(ctx context.Context, id uint, req domain.UpdateS
 		return nil, err
 	}
 
-	if req.ScheduledDate != nil {
-		date, err := time.Parse("2006-01-02", *req.ScheduledDate)
-		if err != nil {
-			return nil, errors.New("неверный формат даты")
-		}
-		surgery.ScheduledDate = date
-	}
+	before := *surgery
 	if req.Status != nil {
 		surgery.Status = domain.SurgeryStatus(*req.Status)
 	}
@@ -134,9 +272,32 @@ func (s *surgeryService) Update(ctx context.Context, id uint, req domain.UpdateS
 		surgery.Notes = *req.Notes
 	}
 
-	if err := s.repo.Update(ctx, surgery); err != nil {
-		return nil, errors.New("не удалось обновить операцию")
+	// Перенос проверяется по календарю так же, как новая запись
+	rebook := (req.ScheduledDate != nil || req.RoomID != nil) && surgery.Status == domain.SurgeryStatusScheduled
+	start, hasTime := surgery.ScheduledDate.In(s.loc), true
+	if req.ScheduledDate != nil {
+		if start, hasTime, err = parseSurgeryTime(*req.ScheduledDate, s.loc); err != nil {
+			return nil, err
+		}
+		if !rebook {
+			surgery.ScheduledDate = start
+		}
+	}
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if rebook {
+			if err := s.reserve(ctx, repository.NewCalendarRepository(tx), surgery, start, hasTime, req.RoomID); err != nil {
+				return err
+			}
+		}
+		if err := repository.NewSurgeryRepository(tx).Update(ctx, surgery); err != nil {
+			return errors.New("не удалось обновить операцию")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
+	s.audit.Record(ctx, domain.AuditActionUpdate, domain.AuditEntitySurgery, id, &before, surgery)
 	return surgery, nil
 }
 
@@ -159,6 +320,7 @@ func (s *surgeryService) Delete(ctx context.Context, id uint, deletedBy uint) er
 	if err := s.repo.Delete(ctx, id); err != nil {
 		return errors.New("не удалось удалить операцию")
 	}
+	s.audit.Record(ctx, domain.AuditActionDelete, domain.AuditEntitySurgery, id, surgery, nil)
 
 	// Revert patient status to APPROVED if surgery was scheduled
 	if surgery.Status == domain.SurgeryStatusScheduled && patient.Status == domain.PatientStatusScheduled {
@@ -171,10 +333,14 @@ func (s *surgeryService) Delete(ctx context.Context, id uint, deletedBy uint) er
 			Comment:    "Операция отменена",
 		})
 
-		// Clear surgery date and surgeon
+		// Clear surgery date and surgeon; статус уже сменён, Save не должен его откатить
+		before := *patient
+		patient.Status = domain.PatientStatusApproved
 		patient.SurgeryDate = nil
 		patient.SurgeonID = nil
-		s.patientRepo.Update(ctx, patient)
+		if err := s.patientRepo.Update(ctx, patient); err == nil {
+			s.audit.Record(ctx, domain.AuditActionUpdate, domain.AuditEntityPatient, patient.ID, &before, patient)
+		}
 	}
 
 	return nil
@@ -4,63 +4,529 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"strings"
 	"time"
 
 	"github.com/beercut-team/backend-boilerplate/internal/domain"
 	"github.com/beercut-team/backend-boilerplate/internal/repository"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+const (
+	syncPullDefaultLimit = 500
+	syncPullMaxLimit     = 2000
 )
 
 type SyncService interface {
-	Push(ctx context.Context, userID uint, req domain.SyncPushRequest) error
-	Pull(ctx context.Context, userID uint, since string) (*domain.SyncPullResponse, error)
+	// Push и Pull проверяют доступ по domain.CanAccessPatient и domain.ReadScope;
+	// actor загружается через AccessPolicy.Actor
+	Push(ctx context.Context, actor domain.Actor, req domain.SyncPushRequest) (*domain.SyncPushResponse, error)
+	Pull(ctx context.Context, actor domain.Actor, cursor int64, limit int) (*domain.SyncPullResponse, error)
 }
 
 type syncService struct {
-	repo repository.SyncRepository
+	db           *gorm.DB
+	repo         repository.SyncRepository
+	checklistSvc ChecklistService
+	audit        AuditService
 }
 
-func NewSyncService(repo repository.SyncRepository) SyncService {
-	return &syncService{repo: repo}
+func NewSyncService(db *gorm.DB, repo repository.SyncRepository, checklistSvc ChecklistService, audit AuditService) SyncService {
+	return &syncService{db: db, repo: repo, checklistSvc: checklistSvc, audit: audit}
 }
 
-func (s *syncService) Push(ctx context.Context, userID uint, req domain.SyncPushRequest) error {
-	for _, m := range req.Mutations {
-		clientTime, err := time.Parse(time.RFC3339, m.ClientTime)
-		if err != nil {
-			return errors.New("неверный формат client_time, используйте ISO 8601")
-		}
+// syncAllowed — роли с офлайн-клиентом
+func syncAllowed(role domain.Role) bool {
+	switch role {
+	case domain.RoleDistrictDoctor, domain.RoleSurgeon, domain.RoleAdmin, domain.RoleCallCenter:
+		return true
+	}
+	return false
+}
+
+// Push применяет мутации клиента в одной транзакции. Каждая мутация выполняется
+// в отдельной точке сохранения: конфликт или ошибка одной не откатывает остальные.
+func (s *syncService) Push(ctx context.Context, actor domain.Actor, req domain.SyncPushRequest) (*domain.SyncPushResponse, error) {
+	if !syncAllowed(actor.Role) {
+		return nil, errors.New("синхронизация недоступна для данной роли")
+	}
+	userID := actor.ID
 
-		payload, _ := json.Marshal(m.Payload)
+	push := &syncPush{userID: userID, actor: actor, checklistPatients: map[uint]bool{}}
+	resp := &domain.SyncPushResponse{Results: make([]domain.SyncMutationResult, 0, len(req.Mutations))}
 
-		entry := &domain.SyncQueue{
-			UserID:     userID,
-			Entity:     m.Entity,
-			EntityID:   m.EntityID,
-			Action:     m.Action,
-			Payload:    string(payload),
-			ClientTime: clientTime,
-		}
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for i, m := range req.Mutations {
+			result := push.apply(tx, m)
+			result.Index = i
 
-		if err := s.repo.Create(ctx, entry); err != nil {
-			return errors.New("не удалось отправить запись синхронизации")
+			clientTime, _ := time.Parse(time.RFC3339, m.ClientTime)
+			entry := &domain.SyncQueue{
+				UserID:     userID,
+				ClientID:   m.ClientID,
+				Entity:     result.Entity,
+				EntityID:   result.EntityID,
+				Action:     result.Action,
+				Payload:    domain.RedactPII(m.Payload),
+				Status:     result.Status,
+				Error:      result.Error,
+				ClientTime: clientTime,
+				Synced:     result.Status == domain.SyncResultApplied,
+			}
+			if err := tx.Create(entry).Error; err != nil {
+				return err
+			}
+
+			switch result.Status {
+			case domain.SyncResultApplied:
+				resp.Applied++
+			case domain.SyncResultConflict:
+				resp.Conflicts++
+			default:
+				resp.Rejected++
+			}
+			resp.Results = append(resp.Results, result)
 		}
+		return nil
+	})
+	if err != nil {
+		log.Error().Err(err).Uint("user_id", userID).Msg("ошибка применения мутаций синхронизации")
+		return nil, errors.New("не удалось выполнить синхронизацию")
 	}
-	return nil
+	recordAll(ctx, s.audit, push.audits)
+
+	// Автопереход статуса после изменений чек-листа — уже после фиксации транзакции
+	for patientID := range push.checklistPatients {
+		s.checklistSvc.CheckAndTransition(ctx, patientID)
+	}
+
+	log.Info().Uint("user_id", userID).Int("applied", resp.Applied).Int("conflicts", resp.Conflicts).Int("rejected", resp.Rejected).Msg("синхронизация: мутации обработаны")
+	return resp, nil
 }
 
-func (s *syncService) Pull(ctx context.Context, userID uint, since string) (*domain.SyncPullResponse, error) {
-	sinceTime, err := time.Parse(time.RFC3339, since)
+// Pull возвращает изменения видимых пользователю сущностей с версией больше cursor
+// и ниже границы стабильных версий. Новый курсор — версия последнего отданного изменения.
+// Видимость — domain.ReadScope, то же правило, что и просмотр пациента в API.
+func (s *syncService) Pull(ctx context.Context, actor domain.Actor, cursor int64, limit int) (*domain.SyncPullResponse, error) {
+	if !syncAllowed(actor.Role) {
+		return nil, errors.New("синхронизация недоступна для данной роли")
+	}
+	scope := domain.ReadScope(actor)
+	if cursor < 0 {
+		return nil, errors.New("неверный cursor")
+	}
+	if limit <= 0 {
+		limit = syncPullDefaultLimit
+	}
+	if limit > syncPullMaxLimit {
+		limit = syncPullMaxLimit
+	}
+
+	// Версии выше границы могут соседствовать с ещё не зафиксированными меньшими версиями
+	nextVersion, inFlight, err := s.repo.VersionBounds(ctx)
 	if err != nil {
-		return nil, errors.New("неверный формат since, используйте ISO 8601")
+		return nil, err
 	}
+	watermark := domain.SyncWatermark(nextVersion, inFlight)
 
-	changes, err := s.repo.FindChangesSince(ctx, userID, sinceTime)
+	patients, err := s.repo.FindPatientsAfter(ctx, scope, cursor, watermark, limit)
+	if err != nil {
+		return nil, err
+	}
+	items, err := s.repo.FindChecklistItemsAfter(ctx, scope, cursor, watermark, limit)
+	if err != nil {
+		return nil, err
+	}
+	comments, err := s.repo.FindCommentsAfter(ctx, scope, cursor, watermark, limit)
+	if err != nil {
+		return nil, err
+	}
+	tombstones, err := s.repo.FindTombstonesAfter(ctx, scope, cursor, watermark, limit)
 	if err != nil {
 		return nil, err
 	}
 
+	changes := make([]domain.SyncChange, 0, len(patients)+len(items)+len(comments)+len(tombstones))
+	for i := range patients {
+		patients[i].PopulateDisplayNames()
+		changes = append(changes, domain.SyncChange{Entity: domain.SyncEntityPatient, EntityID: patients[i].ID, Action: domain.SyncActionUpsert, Version: patients[i].SyncVersion, Data: &patients[i]})
+	}
+	for i := range items {
+		changes = append(changes, domain.SyncChange{Entity: domain.SyncEntityChecklistItem, EntityID: items[i].ID, Action: domain.SyncActionUpsert, Version: items[i].SyncVersion, Data: &items[i]})
+	}
+	for i := range comments {
+		changes = append(changes, domain.SyncChange{Entity: domain.SyncEntityComment, EntityID: comments[i].ID, Action: domain.SyncActionUpsert, Version: comments[i].SyncVersion, Data: &comments[i]})
+	}
+	for _, t := range tombstones {
+		changes = append(changes, domain.SyncChange{Entity: t.Entity, EntityID: t.EntityID, Action: domain.SyncActionDelete, Version: t.SyncVersion})
+	}
+
+	page, hasMore := domain.PageSyncChanges(changes, limit)
+	next := cursor
+	if len(page) > 0 {
+		next = page[len(page)-1].Version
+	}
+
 	return &domain.SyncPullResponse{
-		Changes: changes,
-		Since:   since,
+		Changes: page,
+		Cursor:  next,
+		HasMore: hasMore,
 	}, nil
 }
+
+// syncPush — состояние обработки одного запроса push
+type syncPush struct {
+	userID uint
+	actor  domain.Actor
+	// Пациенты, у которых менялся чек-лист, для автоперехода статуса
+	checklistPatients map[uint]bool
+	// Применённые изменения; в аудит пишутся после фиксации транзакции
+	audits []auditEntry
+}
+
+func (p *syncPush) apply(tx *gorm.DB, m domain.SyncMutation) domain.SyncMutationResult {
+	result := domain.SyncMutationResult{
+		ClientID: m.ClientID,
+		Entity:   strings.ToLower(m.Entity),
+		EntityID: m.EntityID,
+		Action:   strings.ToUpper(m.Action),
+	}
+
+	if _, err := time.Parse(time.RFC3339, m.ClientTime); err != nil {
+		reject(&result, "неверный формат client_time, используйте ISO 8601")
+		return result
+	}
+	// Колл-центр работает только на чтение
+	if p.actor.Role == domain.RoleCallCenter {
+		reject(&result, "недостаточно прав для изменения данных")
+		return result
+	}
+
+	// Повторная отправка уже применённой мутации (клиент не получил ответ)
+	if m.ClientID != "" {
+		var prev domain.SyncQueue
+		err := tx.Where("user_id = ? AND client_id = ? AND status = ?", p.userID, m.ClientID, domain.SyncResultApplied).
+			First(&prev).Error
+		if err == nil {
+			result.EntityID = prev.EntityID
+			result.Status = domain.SyncResultApplied
+			result.Version, result.Data = loadSyncEntity(tx, result.Entity, prev.EntityID)
+			return result
+		}
+	}
+
+	err := tx.Transaction(func(sp *gorm.DB) error {
+		switch result.Entity {
+		case domain.SyncEntityPatient:
+			return p.applyPatient(sp, m, &result)
+		case domain.SyncEntityChecklistItem:
+			return p.applyChecklistItem(sp, m, &result)
+		case domain.SyncEntityComment:
+			return p.applyComment(sp, m, &result)
+		}
+		return reject(&result, "неизвестная сущность: "+m.Entity)
+	})
+	if err != nil {
+		log.Error().Err(err).Str("entity", result.Entity).Uint("entity_id", result.EntityID).Msg("синхронизация: не удалось применить мутацию")
+		reject(&result, "не удалось сохранить изменения")
+	}
+	return result
+}
+
+func (p *syncPush) applyPatient(tx *gorm.DB, m domain.SyncMutation, r *domain.SyncMutationResult) error {
+	switch r.Action {
+	case domain.SyncActionCreate:
+		if p.actor.Role != domain.RoleDistrictDoctor && p.actor.Role != domain.RoleAdmin {
+			return reject(r, "недостаточно прав для создания пациента")
+		}
+		var req domain.CreatePatientRequest
+		if err := decodeSyncPayload(m.Payload, &req); err != nil {
+			return reject(r, err.Error())
+		}
+		if req.FirstName == "" || req.LastName == "" || req.DistrictID == 0 {
+			return reject(r, "не заполнены обязательные поля пациента")
+		}
+		if err := req.NormalizeIdentifiers(); err != nil {
+			return reject(r, err.Error())
+		}
+		if err := resolvePatientOperation(tx.Statement.Context, repository.NewOperationTypeRepository(tx), &req); err != nil {
+			return reject(r, err.Error())
+		}
+		patient, err := newPatientFromRequest(req, p.userID)
+		if err != nil {
+			return reject(r, err.Error())
+		}
+		patient.Status = domain.PatientStatusInProgress
+		if err := p.checkDuplicates(tx, patient, req.IgnoreDuplicates, r); err != nil || r.Status == domain.SyncResultRejected {
+			return err
+		}
+
+		if err := tx.Create(patient).Error; err != nil {
+			return err
+		}
+		items, err := buildPatientChecklist(tx.Statement.Context, repository.NewChecklistTemplateRepository(tx), patient, time.Now())
+		if err != nil {
+			return err
+		}
+		if len(items) > 0 {
+			if err := tx.Create(&items).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Create(&domain.PatientStatusHistory{
+			PatientID:  patient.ID,
+			FromStatus: domain.PatientStatusDraft,
+			ToStatus:   domain.PatientStatusInProgress,
+			ChangedBy:  p.userID,
+			Comment:    "Пациент создан офлайн, чек-лист сгенерирован",
+		}).Error; err != nil {
+			return err
+		}
+
+		p.audits = append(p.audits, auditEntry{domain.AuditActionCreate, domain.AuditEntityPatient, patient.ID, nil, *patient})
+		patient.PopulateDisplayNames()
+		applied(r, patient.ID, patient.SyncVersion, patient)
+		return nil
+
+	case domain.SyncActionUpdate:
+		var patient domain.Patient
+		if err := tx.First(&patient, m.EntityID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return reject(r, "пациент не найден")
+			}
+			return err
+		}
+		if !domain.CanAccessPatient(p.actor, &patient, domain.PatientActionWrite) {
+			return reject(r, "нет доступа к пациенту")
+		}
+		if conflict, err := m.Conflicts(patient.SyncVersion, patient.UpdatedAt); err != nil {
+			return reject(r, err.Error())
+		} else if conflict {
+			patient.PopulateDisplayNames()
+			conflictWith(r, patient.SyncVersion, &patient)
+			return nil
+		}
+
+		var req domain.UpdatePatientRequest
+		if err := decodeSyncPayload(m.Payload, &req); err != nil {
+			return reject(r, err.Error())
+		}
+		if err := req.NormalizeIdentifiers(); err != nil {
+			return reject(r, err.Error())
+		}
+		before := patient
+		applyPatientUpdate(&patient, req)
+		if req.ChangesIdentity() {
+			if err := p.checkDuplicates(tx, &patient, req.IgnoreDuplicates, r); err != nil || r.Status == domain.SyncResultRejected {
+				return err
+			}
+		}
+		if err := tx.Save(&patient).Error; err != nil {
+			return err
+		}
+
+		p.audits = append(p.audits, auditEntry{domain.AuditActionUpdate, domain.AuditEntityPatient, patient.ID, before, patient})
+		patient.PopulateDisplayNames()
+		applied(r, patient.ID, patient.SyncVersion, &patient)
+		return nil
+	}
+	return reject(r, "действие "+m.Action+" не поддерживается для пациента")
+}
+
+// checkDuplicates отклоняет мутацию, если пациент уже зарегистрирован другой записью
+func (p *syncPush) checkDuplicates(tx *gorm.DB, patient *domain.Patient, ignoreWeak bool, r *domain.SyncMutationResult) error {
+	err := checkDuplicates(tx.Statement.Context, repository.NewPatientRepository(tx), patient, ignoreWeak)
+	var dup *DuplicatePatientError
+	if errors.As(err, &dup) {
+		return reject(r, dup.Error())
+	}
+	return err
+}
+
+func (p *syncPush) applyChecklistItem(tx *gorm.DB, m domain.SyncMutation, r *domain.SyncMutationResult) error {
+	if r.Action != domain.SyncActionUpdate {
+		return reject(r, "действие "+m.Action+" не поддерживается для пункта чек-листа")
+	}
+
+	var item domain.ChecklistItem
+	if err := tx.First(&item, m.EntityID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return reject(r, "элемент чек-листа не найден")
+		}
+		return err
+	}
+	if ok, err := p.checkPatientAccess(tx, item.PatientID, domain.PatientActionWrite, r); !ok {
+		return err
+	}
+	if conflict, err := m.Conflicts(item.SyncVersion, item.UpdatedAt); err != nil {
+		return reject(r, err.Error())
+	} else if conflict {
+		conflictWith(r, item.SyncVersion, &item)
+		return nil
+	}
+
+	var req domain.UpdateChecklistItemRequest
+	if err := decodeSyncPayload(m.Payload, &req); err != nil {
+		return reject(r, err.Error())
+	}
+	if req.Status != "" && !validChecklistStatus(domain.ChecklistItemStatus(req.Status)) {
+		return reject(r, "неверный статус пункта чек-листа: "+req.Status)
+	}
+	before := item
+	applyChecklistItemUpdate(&item, req, p.userID)
+	if err := tx.Save(&item).Error; err != nil {
+		return err
+	}
+	p.audits = append(p.audits, auditEntry{domain.AuditActionUpdate, domain.AuditEntityChecklistItem, item.ID, before, item})
+
+	p.checklistPatients[item.PatientID] = true
+	applied(r, item.ID, item.SyncVersion, &item)
+	return nil
+}
+
+func (p *syncPush) applyComment(tx *gorm.DB, m domain.SyncMutation, r *domain.SyncMutationResult) error {
+	switch r.Action {
+	case domain.SyncActionCreate:
+		var req domain.CreateCommentRequest
+		if err := decodeSyncPayload(m.Payload, &req); err != nil {
+			return reject(r, err.Error())
+		}
+		if req.PatientID == 0 || strings.TrimSpace(req.Body) == "" {
+			return reject(r, "не заполнены обязательные поля комментария")
+		}
+		if ok, err := p.checkPatientAccess(tx, req.PatientID, domain.PatientActionReview, r); !ok {
+			return err
+		}
+
+		comment := &domain.Comment{
+			PatientID: req.PatientID,
+			AuthorID:  p.userID,
+			ParentID:  req.ParentID,
+			Body:      req.Body,
+			IsUrgent:  req.IsUrgent,
+		}
+		if err := tx.Create(comment).Error; err != nil {
+			return err
+		}
+		applied(r, comment.ID, comment.SyncVersion, comment)
+		return nil
+
+	case domain.SyncActionUpdate:
+		var comment domain.Comment
+		if err := tx.First(&comment, m.EntityID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return reject(r, "комментарий не найден")
+			}
+			return err
+		}
+		if comment.AuthorID != p.userID {
+			return reject(r, "редактировать можно только свои комментарии")
+		}
+		if conflict, err := m.Conflicts(comment.SyncVersion, comment.UpdatedAt); err != nil {
+			return reject(r, err.Error())
+		} else if conflict {
+			conflictWith(r, comment.SyncVersion, &comment)
+			return nil
+		}
+
+		var req domain.UpdateCommentRequest
+		if err := decodeSyncPayload(m.Payload, &req); err != nil {
+			return reject(r, err.Error())
+		}
+		if req.Body != nil {
+			if strings.TrimSpace(*req.Body) == "" {
+				return reject(r, "текст комментария не может быть пустым")
+			}
+			comment.Body = *req.Body
+		}
+		if req.IsUrgent != nil {
+			comment.IsUrgent = *req.IsUrgent
+		}
+		if err := tx.Save(&comment).Error; err != nil {
+			return err
+		}
+		applied(r, comment.ID, comment.SyncVersion, &comment)
+		return nil
+	}
+	return reject(r, "действие "+m.Action+" не поддерживается для комментария")
+}
+
+// checkPatientAccess отклоняет мутацию, если пациент не найден или действие недоступно пользователю.
+// Пациент читается в транзакции push: он мог быть создан предыдущей мутацией.
+func (p *syncPush) checkPatientAccess(tx *gorm.DB, patientID uint, action domain.PatientAction, r *domain.SyncMutationResult) (bool, error) {
+	var patient domain.Patient
+	if err := tx.First(&patient, patientID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, reject(r, "пациент не найден")
+		}
+		return false, err
+	}
+	if !domain.CanAccessPatient(p.actor, &patient, action) {
+		return false, reject(r, "нет доступа к пациенту")
+	}
+	return true, nil
+}
+
+// loadSyncEntity возвращает текущую серверную копию записи для повторно отправленной мутации
+func loadSyncEntity(tx *gorm.DB, entity string, id uint) (int64, interface{}) {
+	switch entity {
+	case domain.SyncEntityPatient:
+		var patient domain.Patient
+		if tx.First(&patient, id).Error == nil {
+			patient.PopulateDisplayNames()
+			return patient.SyncVersion, &patient
+		}
+	case domain.SyncEntityChecklistItem:
+		var item domain.ChecklistItem
+		if tx.First(&item, id).Error == nil {
+			return item.SyncVersion, &item
+		}
+	case domain.SyncEntityComment:
+		var comment domain.Comment
+		if tx.First(&comment, id).Error == nil {
+			return comment.SyncVersion, &comment
+		}
+	}
+	return 0, nil
+}
+
+func decodeSyncPayload(raw json.RawMessage, v interface{}) error {
+	if len(raw) == 0 || string(raw) == "null" {
+		return errors.New("отсутствуют данные мутации (payload)")
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		return errors.New("некорректные данные мутации: " + err.Error())
+	}
+	return nil
+}
+
+func validChecklistStatus(status domain.ChecklistItemStatus) bool {
+	switch status {
+	case domain.ChecklistStatusPending, domain.ChecklistStatusInProgress, domain.ChecklistStatusCompleted,
+		domain.ChecklistStatusRejected, domain.ChecklistStatusExpired:
+		return true
+	}
+	return false
+}
+
+// reject помечает мутацию отклонённой; возвращает nil, чтобы точка сохранения не откатывалась с ошибкой
+func reject(r *domain.SyncMutationResult, msg string) error {
+	r.Status = domain.SyncResultRejected
+	r.Error = msg
+	r.Data = nil
+	return nil
+}
+
+func conflictWith(r *domain.SyncMutationResult, version int64, server interface{}) {
+	r.Status = domain.SyncResultConflict
+	r.Version = version
+	r.Data = server
+}
+
+func applied(r *domain.SyncMutationResult, id uint, version int64, data interface{}) {
+	r.Status = domain.SyncResultApplied
+	r.EntityID = id
+	r.Version = version
+	r.Data = data
+}
@@ -0,0 +1,122 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/beercut-team/backend-boilerplate/internal/domain"
+	"github.com/beercut-team/backend-boilerplate/internal/repository"
+	"github.com/beercut-team/backend-boilerplate/pkg/ratelimit"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+var errTokenRevoked = errors.New("токен отозван, войдите заново")
+
+// TokenRevocation — отзыв токенов доступа до истечения срока. Токен доступа
+// не хранится на сервере, поэтому отозванные jti и сессии держатся в списке
+// (Redis) ровно столько, сколько живёт токен доступа.
+type TokenRevocation interface {
+	// Check вызывается на каждом запросе после проверки подписи: токен или его
+	// сессия отозваны, сотрудник деактивирован или его роль изменилась
+	Check(ctx context.Context, claims *AccessClaims) error
+	// RevokeToken — отзыв одного токена (выход)
+	RevokeToken(ctx context.Context, claims *AccessClaims)
+	// RevokeSessions — токены доступа завершённых сессий перестают приниматься сразу
+	RevokeSessions(ctx context.Context, ids ...uint)
+	// RevokeAll завершает сессии пользователя, кроме exceptID, и отзывает их токены;
+	// при деактивации, смене роли или пароля сотрудника — все (exceptID = 0)
+	RevokeAll(ctx context.Context, userID uint, subject domain.SessionSubject, exceptID uint, reason string) error
+}
+
+type tokenRevocation struct {
+	store       ratelimit.Store
+	userRepo    repository.UserRepository
+	sessionRepo repository.SessionRepository
+	accessTTL   time.Duration
+}
+
+func NewTokenRevocation(store ratelimit.Store, userRepo repository.UserRepository, sessionRepo repository.SessionRepository, accessTTL time.Duration) TokenRevocation {
+	return &tokenRevocation{store: store, userRepo: userRepo, sessionRepo: sessionRepo, accessTTL: accessTTL}
+}
+
+func tokenDenyKey(jti string) string { return "deny:jti:" + jti }
+
+func sessionDenyKey(id uint) string { return fmt.Sprintf("deny:sid:%d", id) }
+
+func (r *tokenRevocation) Check(ctx context.Context, claims *AccessClaims) error {
+	// Без сессии токен нельзя отозвать
+	if claims.SessionID == 0 || claims.TokenID == "" {
+		return errTokenRevoked
+	}
+
+	// Ошибка хранилища не блокирует запросы: деактивацию и смену роли ловит проверка ниже
+	for _, key := range []string{tokenDenyKey(claims.TokenID), sessionDenyKey(claims.SessionID)} {
+		ttl, err := r.store.TTL(ctx, key)
+		if err != nil {
+			log.Error().Err(err).Msg("не удалось проверить список отозванных токенов")
+			continue
+		}
+		if ttl > 0 {
+			return errTokenRevoked
+		}
+	}
+
+	if claims.Role == domain.RolePatient {
+		return nil
+	}
+	user, err := r.userRepo.FindByID(ctx, claims.UserID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("пользователь не найден")
+		}
+		return errors.New("не удалось проверить пользователя")
+	}
+	if !user.IsActive {
+		return errors.New("аккаунт деактивирован")
+	}
+	if user.Role != claims.Role {
+		return errors.New("роль пользователя изменена, войдите заново")
+	}
+	return nil
+}
+
+func (r *tokenRevocation) RevokeToken(ctx context.Context, claims *AccessClaims) {
+	ttl := time.Until(claims.ExpiresAt)
+	if claims.TokenID == "" || ttl <= 0 {
+		return
+	}
+	if err := r.store.Lock(ctx, tokenDenyKey(claims.TokenID), ttl); err != nil {
+		log.Error().Err(err).Uint("user_id", claims.UserID).Msg("не удалось отозвать токен доступа")
+	}
+}
+
+func (r *tokenRevocation) RevokeSessions(ctx context.Context, ids ...uint) {
+	for _, id := range ids {
+		if err := r.store.Lock(ctx, sessionDenyKey(id), r.accessTTL); err != nil {
+			log.Error().Err(err).Uint("session_id", id).Msg("не удалось отозвать токены сессии")
+		}
+	}
+}
+
+func (r *tokenRevocation) RevokeAll(ctx context.Context, userID uint, subject domain.SessionSubject, exceptID uint, reason string) error {
+	sessions, err := r.sessionRepo.FindActive(ctx, userID, subject)
+	if err != nil {
+		return fmt.Errorf("не удалось получить сессии пользователя: %w", err)
+	}
+	if err := r.sessionRepo.RevokeAll(ctx, userID, subject, exceptID, reason); err != nil {
+		return fmt.Errorf("не удалось завершить сессии пользователя: %w", err)
+	}
+
+	ids := make([]uint, 0, len(sessions))
+	for _, s := range sessions {
+		if s.ID != exceptID {
+			ids = append(ids, s.ID)
+		}
+	}
+	r.RevokeSessions(ctx, ids...)
+	log.Info().Uint("user_id", userID).Str("subject", string(subject)).Str("reason", reason).Int("sessions", len(ids)).Msg("сессии пользователя завершены")
+	return nil
+}
@@ -1,106 +1,215 @@
 package service
 
 import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/beercut-team/backend-boilerplate/internal/config"
 	"github.com/beercut-team/backend-boilerplate/internal/domain"
+	"github.com/beercut-team/backend-boilerplate/pkg/jwtkeys"
 	"github.com/golang-jwt/jwt/v5"
 )
 
+// Время жизни токена второго шага входа (код 2FA)
+const challengeTTL = 5 * time.Minute
+
+// AccessClaims — данные токена доступа
+type AccessClaims struct {
+	UserID uint
+	Role   domain.Role
+	// Сессия, в которой выдан токен; 0 у токенов, выданных до появления сессий
+	SessionID uint
+	// Идентификатор токена (jti) и срок — для отзыва до истечения
+	TokenID   string
+	ExpiresAt time.Time
+}
+
 type TokenService interface {
-	GenerateAccessToken(userID uint, role domain.Role) (string, error)
-	GenerateRefreshToken(userID uint) (string, error)
-	ValidateAccessToken(tokenStr string) (uint, domain.Role, error)
-	ValidateRefreshToken(tokenStr string) (uint, error)
+	GenerateAccessToken(userID uint, role domain.Role, sessionID uint) (string, error)
+	GenerateRefreshToken(userID, sessionID uint) (string, error)
+	ValidateAccessToken(tokenStr string) (*AccessClaims, error)
+	ValidateRefreshToken(tokenStr string) (userID, sessionID uint, err error)
+	// Токен второго шага: пароль проверен, ожидается код 2FA
+	GenerateChallengeToken(userID uint) (string, error)
+	ValidateChallengeToken(tokenStr string) (uint, error)
+	AccessTTL() time.Duration
+	RefreshTTL() time.Duration
+	// JWKS — открытые ключи подписи для сервисов, проверяющих токены без секрета
+	JWKS() jwtkeys.JWKS
 }
 
 type tokenService struct {
 	cfg *config.Config
+	// nil — подпись HS256 секретами JWT_ACCESS_SECRET и JWT_REFRESH_SECRET без kid
+	keys *jwtkeys.Keyring
+	// До этого момента при наборе ключей принимаются токены без kid; нулевое — не принимаются
+	legacyUntil time.Time
 }
 
-func NewTokenService(cfg *config.Config) TokenService {
-	return &tokenService{cfg: cfg}
+func NewTokenService(cfg *config.Config, keys *jwtkeys.Keyring, legacyUntil time.Time) TokenService {
+	return &tokenService{cfg: cfg, keys: keys, legacyUntil: legacyUntil}
 }
 
-func (s *tokenService) GenerateAccessToken(userID uint, role domain.Role) (string, error) {
+func (s *tokenService) GenerateAccessToken(userID uint, role domain.Role, sessionID uint) (string, error) {
+	jti, err := newTokenID()
+	if err != nil {
+		return "", err
+	}
+	now := time.Now()
 	claims := jwt.MapClaims{
 		"user_id": userID,
 		"role":    string(role),
-		"exp":     time.Now().Add(time.Duration(s.cfg.JWTAccessExpiryMin) * time.Minute).Unix(),
+		"sid":     sessionID,
+		"jti":     jti,
+		"iat":     now.Unix(),
+		"exp":     now.Add(s.AccessTTL()).Unix(),
 		"type":    "access",
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(s.cfg.JWTAccessSecret))
+	return s.sign(claims, s.cfg.JWTAccessSecret)
 }
 
-func (s *tokenService) GenerateRefreshToken(userID uint) (string, error) {
+func (s *tokenService) GenerateRefreshToken(userID, sessionID uint) (string, error) {
+	// jti делает токены уникальными: два обновления в одну секунду дают разные хеши
+	jti, err := newTokenID()
+	if err != nil {
+		return "", err
+	}
 	claims := jwt.MapClaims{
 		"user_id": userID,
-		"exp":     time.Now().Add(time.Duration(s.cfg.JWTRefreshExpiryHrs) * time.Hour).Unix(),
+		"sid":     sessionID,
+		"jti":     jti,
+		"exp":     time.Now().Add(s.RefreshTTL()).Unix(),
 		"type":    "refresh",
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(s.cfg.JWTRefreshSecret))
+	return s.sign(claims, s.cfg.JWTRefreshSecret)
 }
 
-func (s *tokenService) ValidateAccessToken(tokenStr string) (uint, domain.Role, error) {
-	token, err := jwt.Parse(tokenStr, func(t *jwt.Token) (interface{}, error) {
-		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("неожиданный метод подписи: %v", t.Header["alg"])
-		}
-		return []byte(s.cfg.JWTAccessSecret), nil
-	})
-	if err != nil {
-		return 0, "", fmt.Errorf("недействительный токен: %w", err)
+func (s *tokenService) GenerateChallengeToken(userID uint) (string, error) {
+	claims := jwt.MapClaims{
+		"user_id": userID,
+		"exp":     time.Now().Add(challengeTTL).Unix(),
+		"type":    "2fa",
 	}
+	return s.sign(claims, s.cfg.JWTAccessSecret)
+}
 
-	claims, ok := token.Claims.(jwt.MapClaims)
-	if !ok || !token.Valid {
-		return 0, "", fmt.Errorf("недействительные данные токена")
+func (s *tokenService) AccessTTL() time.Duration {
+	return time.Duration(s.cfg.JWTAccessExpiryMin) * time.Minute
+}
+
+func (s *tokenService) RefreshTTL() time.Duration {
+	return time.Duration(s.cfg.JWTRefreshExpiryHrs) * time.Hour
+}
+
+func (s *tokenService) JWKS() jwtkeys.JWKS {
+	if s.keys == nil {
+		return jwtkeys.JWKS{Keys: []jwtkeys.JWK{}}
 	}
+	return s.keys.JWKS()
+}
 
-	tokenType, _ := claims["type"].(string)
-	if tokenType != "access" {
-		return 0, "", fmt.Errorf("недействительный тип токена")
+func (s *tokenService) ValidateAccessToken(tokenStr string) (*AccessClaims, error) {
+	claims, err := s.parseToken(tokenStr, s.cfg.JWTAccessSecret, "access")
+	if err != nil {
+		return nil, err
 	}
 
 	userIDFloat, ok := claims["user_id"].(float64)
 	if !ok {
-		return 0, "", fmt.Errorf("недействительный user_id в токене")
+		return nil, fmt.Errorf("недействительный user_id в токене")
 	}
 
 	roleStr, _ := claims["role"].(string)
+	sid, _ := claims["sid"].(float64)
+	jti, _ := claims["jti"].(string)
+	exp, _ := claims["exp"].(float64)
+
+	return &AccessClaims{
+		UserID:    uint(userIDFloat),
+		Role:      domain.Role(roleStr),
+		SessionID: uint(sid),
+		TokenID:   jti,
+		ExpiresAt: time.Unix(int64(exp), 0),
+	}, nil
+}
+
+func (s *tokenService) ValidateRefreshToken(tokenStr string) (uint, uint, error) {
+	claims, err := s.parseToken(tokenStr, s.cfg.JWTRefreshSecret, "refresh")
+	if err != nil {
+		return 0, 0, err
+	}
+
+	userIDFloat, ok := claims["user_id"].(float64)
+	if !ok {
+		return 0, 0, fmt.Errorf("недействительный user_id в токене")
+	}
+
+	sid, _ := claims["sid"].(float64)
 
-	return uint(userIDFloat), domain.Role(roleStr), nil
+	return uint(userIDFloat), uint(sid), nil
 }
 
-func (s *tokenService) ValidateRefreshToken(tokenStr string) (uint, error) {
+func (s *tokenService) ValidateChallengeToken(tokenStr string) (uint, error) {
+	claims, err := s.parseToken(tokenStr, s.cfg.JWTAccessSecret, "2fa")
+	if err != nil {
+		return 0, err
+	}
+
+	userIDFloat, ok := claims["user_id"].(float64)
+	if !ok {
+		return 0, fmt.Errorf("недействительный user_id в токене")
+	}
+
+	return uint(userIDFloat), nil
+}
+
+// sign подписывает активным ключом набора, без набора — секретом HS256
+func (s *tokenService) sign(claims jwt.MapClaims, secret string) (string, error) {
+	if s.keys != nil {
+		return s.keys.Sign(claims)
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+}
+
+// parseToken проверяет токен ключом из заголовка kid. Токены без kid подписаны
+// секретом HS256 до перехода на набор ключей; при наборе ключей они принимаются
+// только до JWT_LEGACY_ACCEPT_UNTIL.
+func (s *tokenService) parseToken(tokenStr, secret, tokenType string) (jwt.MapClaims, error) {
 	token, err := jwt.Parse(tokenStr, func(t *jwt.Token) (interface{}, error) {
-		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+		if _, ok := t.Header["kid"]; ok && s.keys != nil {
+			return s.keys.Keyfunc(t)
+		}
+		if s.keys != nil && !time.Now().Before(s.legacyUntil) {
+			return nil, errors.New("токены без kid больше не принимаются")
+		}
+		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok || secret == "" {
 			return nil, fmt.Errorf("неожиданный метод подписи: %v", t.Header["alg"])
 		}
-		return []byte(s.cfg.JWTRefreshSecret), nil
+		return []byte(secret), nil
 	})
 	if err != nil {
-		return 0, fmt.Errorf("недействительный токен: %w", err)
+		return nil, fmt.Errorf("недействительный токен: %w", err)
 	}
 
 	claims, ok := token.Claims.(jwt.MapClaims)
 	if !ok || !token.Valid {
-		return 0, fmt.Errorf("недействительные данные токена")
+		return nil, fmt.Errorf("недействительные данные токена")
 	}
 
-	tokenType, _ := claims["type"].(string)
-	if tokenType != "refresh" {
-		return 0, fmt.Errorf("недействительный тип токена")
+	if t, _ := claims["type"].(string); t != tokenType {
+		return nil, fmt.Errorf("недействительный тип токена")
 	}
 
-	userIDFloat, ok := claims["user_id"].(float64)
-	if !ok {
-		return 0, fmt.Errorf("недействительный user_id в токене")
-	}
+	return claims, nil
+}
 
-	return uint(userIDFloat), nil
+func newTokenID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
 }
@@ -0,0 +1,56 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/beercut-team/backend-boilerplate/internal/config"
+	"github.com/beercut-team/backend-boilerplate/internal/domain"
+	"github.com/beercut-team/backend-boilerplate/pkg/jwtkeys"
+)
+
+func TestLegacyTokensWithKeyring(t *testing.T) {
+	cfg := &config.Config{JWTAccessSecret: "legacy-access-secret", JWTAccessExpiryMin: 15}
+	key, err := jwtkeys.NewHMACKey("hs-1", []byte("0123456789abcdef0123456789abcdef"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys, err := jwtkeys.NewKeyring("hs-1", key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Токен без kid, выданный до перехода на набор ключей
+	legacy, err := NewTokenService(cfg, nil, time.Time{}).GenerateAccessToken(7, domain.RoleSurgeon, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		until      time.Time
+		wantLegacy bool
+	}{
+		{"no cutoff", time.Time{}, false},
+		{"before cutoff", time.Now().Add(time.Hour), true},
+		{"after cutoff", time.Now().Add(-time.Hour), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := NewTokenService(cfg, keys, tt.until)
+
+			_, err := svc.ValidateAccessToken(legacy)
+			if got := err == nil; got != tt.wantLegacy {
+				t.Errorf("legacy token accepted = %v (%v), want %v", got, err, tt.wantLegacy)
+			}
+
+			current, err := svc.GenerateAccessToken(7, domain.RoleSurgeon, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := svc.ValidateAccessToken(current); err != nil {
+				t.Errorf("token signed by the keyring: %v", err)
+			}
+		})
+	}
+}
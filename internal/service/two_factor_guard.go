@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/beercut-team/backend-boilerplate/internal/domain"
+	"github.com/beercut-team/backend-boilerplate/pkg/ratelimit"
+	"github.com/rs/zerolog/log"
+)
+
+// Пороги защиты кодов 2FA. Код из приложения — 6 цифр, окно проверки — три шага:
+// без ограничения попыток его подбирают за время жизни токена входа.
+// Счётчик общий для входа и действий с 2FA в уже открытой сессии.
+const (
+	twoFactorFailWindow = 15 * time.Minute
+	twoFactorLockAfter  = 5
+	twoFactorLockFor    = 15 * time.Minute
+)
+
+// TwoFactorLockedError — ввод кодов 2FA заблокирован, повторить через RetryAfter
+type TwoFactorLockedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *TwoFactorLockedError) Error() string {
+	return fmt.Sprintf("слишком много неверных кодов, повторите через %d мин", int(e.RetryAfter.Minutes())+1)
+}
+
+type TwoFactorGuard interface {
+	// Check вызывается до проверки кода: *TwoFactorLockedError при блокировке
+	Check(ctx context.Context, userID uint) error
+	// Fail учитывает неверный код и пишет его в журнал аудита (IP по умолчанию — из
+	// WithAuditActor); после twoFactorLockAfter ошибок ввод кодов блокируется
+	// и Fail возвращает *TwoFactorLockedError
+	Fail(ctx context.Context, userID uint, ip string) error
+}
+
+type twoFactorGuard struct {
+	store ratelimit.Store
+	audit AuditService
+}
+
+func NewTwoFactorGuard(store ratelimit.Store, audit AuditService) TwoFactorGuard {
+	return &twoFactorGuard{store: store, audit: audit}
+}
+
+// Ошибки хранилища не блокируют вход, как и у AccessCodeGuard
+func (g *twoFactorGuard) Check(ctx context.Context, userID uint) error {
+	ttl, err := g.store.TTL(ctx, twoFactorKey("lock", userID))
+	if err != nil {
+		log.Error().Err(err).Msg("защита 2FA: не удалось проверить блокировку")
+		return nil
+	}
+	if ttl > 0 {
+		return &TwoFactorLockedError{RetryAfter: ttl}
+	}
+	return nil
+}
+
+func (g *twoFactorGuard) Fail(ctx context.Context, userID uint, ip string) error {
+	if actor, ok := ctx.Value(auditActorKey{}).(*auditActor); ok && ip == "" {
+		ip = actor.ip
+	}
+	g.audit.LogAction(ctx, userID, domain.AuditActionLoginFailed, domain.AuditEntityUser, userID, nil,
+		map[string]string{"factor": "2fa"}, ip)
+
+	fails, err := g.store.Incr(ctx, twoFactorKey("fail", userID), twoFactorFailWindow)
+	if err != nil {
+		log.Error().Err(err).Msg("защита 2FA: не удалось увеличить счётчик")
+		return nil
+	}
+	if fails < twoFactorLockAfter {
+		return nil
+	}
+
+	if err := g.store.Lock(ctx, twoFactorKey("lock", userID), twoFactorLockFor); err != nil {
+		log.Error().Err(err).Msg("защита 2FA: не удалось установить блокировку")
+		return nil
+	}
+	log.Warn().Uint("user_id", userID).Str("ip", ip).Dur("duration", twoFactorLockFor).Msg("ввод кодов 2FA заблокирован")
+	g.audit.LogAction(ctx, userID, domain.AuditActionLockout, domain.AuditEntityUser, userID, nil,
+		map[string]string{"factor": "2fa", "duration": twoFactorLockFor.String()}, ip)
+	return &TwoFactorLockedError{RetryAfter: twoFactorLockFor}
+}
+
+func twoFactorKey(kind string, userID uint) string {
+	return "2fa:" + kind + ":" + strconv.FormatUint(uint64(userID), 10)
+}
@@ -0,0 +1,65 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/beercut-team/backend-boilerplate/internal/domain"
+	"github.com/beercut-team/backend-boilerplate/pkg/ratelimit"
+)
+
+// actionLog запоминает действия, записанные в журнал аудита
+type actionLog struct {
+	AuditService
+	actions []string
+	ips     []string
+}
+
+func (a *actionLog) LogAction(_ context.Context, _ uint, action, _ string, _ uint, _, _ interface{}, ip string) error {
+	a.actions = append(a.actions, action)
+	a.ips = append(a.ips, ip)
+	return nil
+}
+
+func TestTwoFactorGuardLocksAfterFailures(t *testing.T) {
+	ctx := context.Background()
+	audit := &actionLog{}
+	guard := NewTwoFactorGuard(ratelimit.NewMemoryStore(), audit)
+
+	for i := 1; i < twoFactorLockAfter; i++ {
+		if err := guard.Check(ctx, 7); err != nil {
+			t.Fatalf("attempt %d: Check = %v", i, err)
+		}
+		if err := guard.Fail(ctx, 7, "10.0.0.1"); err != nil {
+			t.Fatalf("attempt %d: Fail = %v", i, err)
+		}
+	}
+
+	var locked *TwoFactorLockedError
+	if err := guard.Fail(ctx, 7, "10.0.0.1"); !errors.As(err, &locked) {
+		t.Fatalf("Fail after %d attempts = %v, want *TwoFactorLockedError", twoFactorLockAfter, err)
+	}
+	if err := guard.Check(ctx, 7); !errors.As(err, &locked) || locked.RetryAfter <= 0 {
+		t.Errorf("Check after lockout = %v, want *TwoFactorLockedError", err)
+	}
+	if err := guard.Check(ctx, 8); err != nil {
+		t.Errorf("other user: Check = %v, want nil", err)
+	}
+
+	last := audit.actions[len(audit.actions)-1]
+	if len(audit.actions) != twoFactorLockAfter+1 || last != domain.AuditActionLockout {
+		t.Errorf("audit actions = %v, want %d failures and a lockout", audit.actions, twoFactorLockAfter)
+	}
+}
+
+func TestTwoFactorGuardIPFromAuditActor(t *testing.T) {
+	audit := &actionLog{}
+	guard := NewTwoFactorGuard(ratelimit.NewMemoryStore(), audit)
+
+	ctx := WithAuditActor(context.Background(), 7, domain.RoleSurgeon, "10.0.0.2")
+	_ = guard.Fail(ctx, 7, "")
+	if len(audit.ips) != 1 || audit.ips[0] != "10.0.0.2" {
+		t.Errorf("audit ip = %v, want 10.0.0.2", audit.ips)
+	}
+}
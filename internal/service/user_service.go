@@ -0,0 +1,239 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/beercut-team/backend-boilerplate/internal/domain"
+	"github.com/beercut-team/backend-boilerplate/internal/repository"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+// UserService — управление сотрудниками для администратора. Новые сотрудники
+// появляются только через приглашения (AccountService), в том числе при импорте CSV
+type UserService interface {
+	List(ctx context.Context, filters repository.UserFilters, offset, limit int) ([]domain.UserResponse, int64, error)
+	GetByID(ctx context.Context, id uint) (*domain.UserResponse, error)
+	// Update при смене роли завершает все сессии сотрудника
+	Update(ctx context.Context, id, adminID uint, req domain.UpdateUserRequest) (*domain.UserResponse, error)
+	// Deactivate — мягкое удаление: вход запрещён, токены отозваны, данные сохраняются
+	Deactivate(ctx context.Context, id, adminID uint) error
+	Activate(ctx context.Context, id uint) error
+	// Import создаёт приглашения по строкам CSV; ошибки строк не прерывают импорт
+	Import(ctx context.Context, r io.Reader, adminID uint) (*domain.UserImportResult, error)
+}
+
+type userService struct {
+	userRepo     repository.UserRepository
+	districtRepo repository.DistrictRepository
+	accounts     AccountService
+	revocation   TokenRevocation
+	audit        AuditService
+}
+
+func NewUserService(userRepo repository.UserRepository, districtRepo repository.DistrictRepository, accounts AccountService, revocation TokenRevocation, audit AuditService) UserService {
+	return &userService{
+		userRepo:     userRepo,
+		districtRepo: districtRepo,
+		accounts:     accounts,
+		revocation:   revocation,
+		audit:        audit,
+	}
+}
+
+func (s *userService) List(ctx context.Context, filters repository.UserFilters, offset, limit int) ([]domain.UserResponse, int64, error) {
+	users, total, err := s.userRepo.FindAll(ctx, filters, offset, limit)
+	if err != nil {
+		return nil, 0, errors.New("не удалось получить список пользователей")
+	}
+
+	resp := make([]domain.UserResponse, 0, len(users))
+	for _, u := range users {
+		resp = append(resp, u.ToResponse())
+	}
+	return resp, total, nil
+}
+
+func (s *userService) GetByID(ctx context.Context, id uint) (*domain.UserResponse, error) {
+	user, err := s.find(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	resp := user.ToResponse()
+	return &resp, nil
+}
+
+func (s *userService) Update(ctx context.Context, id, adminID uint, req domain.UpdateUserRequest) (*domain.UserResponse, error) {
+	user, err := s.find(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	before := *user
+
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" {
+			return nil, errors.New("имя не может быть пустым")
+		}
+		user.Name = name
+	}
+	setString(&user.FirstName, req.FirstName)
+	setString(&user.LastName, req.LastName)
+	setString(&user.MiddleName, req.MiddleName)
+	setString(&user.Phone, req.Phone)
+	setString(&user.Specialization, req.Specialization)
+	setString(&user.LicenseNumber, req.LicenseNumber)
+
+	if req.DistrictID != nil {
+		if _, err := s.districtRepo.FindByID(ctx, *req.DistrictID); err != nil {
+			return nil, errors.New("район не найден")
+		}
+		districtID := *req.DistrictID
+		user.DistrictID = &districtID
+		user.District = nil
+	}
+
+	roleChanged := req.Role != nil && *req.Role != user.Role
+	if roleChanged {
+		if !domain.ValidRole(*req.Role) || *req.Role == domain.RolePatient || user.Role == domain.RolePatient {
+			return nil, errors.New("недопустимая роль")
+		}
+		if id == adminID {
+			return nil, errors.New("нельзя изменить собственную роль")
+		}
+		if user.Role == domain.RoleAdmin {
+			if err := s.ensureOtherAdmin(ctx, id); err != nil {
+				return nil, err
+			}
+		}
+		user.Role = *req.Role
+	}
+
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return nil, errors.New("не удалось обновить пользователя")
+	}
+
+	action := domain.AuditActionUpdate
+	if roleChanged {
+		action = domain.AuditActionRoleChange
+		// Старые токены несут прежнюю роль: сотрудник входит заново
+		if err := s.revocation.RevokeAll(ctx, user.ID, domain.SessionSubjectUser, 0, domain.SessionRevokedRoleChanged); err != nil {
+			log.Error().Err(err).Uint("user_id", user.ID).Msg("не удалось завершить сессии после смены роли")
+		}
+	}
+	s.audit.Record(ctx, action, domain.AuditEntityUser, user.ID, &before, user)
+
+	resp := user.ToResponse()
+	return &resp, nil
+}
+
+func (s *userService) Deactivate(ctx context.Context, id, adminID uint) error {
+	if id == adminID {
+		return errors.New("нельзя деактивировать собственную учётную запись")
+	}
+	user, err := s.find(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !user.IsActive {
+		return nil
+	}
+	if user.Role == domain.RoleAdmin {
+		if err := s.ensureOtherAdmin(ctx, id); err != nil {
+			return err
+		}
+	}
+
+	before := *user
+	user.IsActive = false
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return errors.New("не удалось деактивировать пользователя")
+	}
+	s.audit.Record(ctx, domain.AuditActionDeactivate, domain.AuditEntityUser, user.ID, &before, user)
+
+	// Проверка IsActive на каждом запросе уже отклоняет токены; отзыв завершает и сессии
+	if err := s.revocation.RevokeAll(ctx, user.ID, domain.SessionSubjectUser, 0, domain.SessionRevokedDeactivated); err != nil {
+		log.Error().Err(err).Uint("user_id", user.ID).Msg("не удалось завершить сессии деактивированного пользователя")
+	}
+	return nil
+}
+
+func (s *userService) Activate(ctx context.Context, id uint) error {
+	user, err := s.find(ctx, id)
+	if err != nil {
+		return err
+	}
+	if user.IsActive {
+		return nil
+	}
+
+	before := *user
+	user.IsActive = true
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return errors.New("не удалось активировать пользователя")
+	}
+	s.audit.Record(ctx, domain.AuditActionActivate, domain.AuditEntityUser, user.ID, &before, user)
+	return nil
+}
+
+func (s *userService) Import(ctx context.Context, r io.Reader, adminID uint) (*domain.UserImportResult, error) {
+	rows, rowErrors, err := domain.ParseUserImportCSV(r)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &domain.UserImportResult{Total: len(rows) + len(rowErrors), Errors: rowErrors}
+	seen := make(map[string]int, len(rows))
+	for _, row := range rows {
+		if line, dup := seen[row.Email]; dup {
+			result.Errors = append(result.Errors, domain.UserImportError{Line: row.Line, Email: row.Email, Error: fmt.Sprintf("адрес уже встречался в строке %d", line)})
+			continue
+		}
+		seen[row.Email] = row.Line
+
+		if _, err := s.accounts.Invite(ctx, row.CreateInvitationRequest, adminID); err != nil {
+			result.Errors = append(result.Errors, domain.UserImportError{Line: row.Line, Email: row.Email, Error: err.Error()})
+			continue
+		}
+		result.Invited++
+	}
+	if result.Errors == nil {
+		result.Errors = []domain.UserImportError{}
+	}
+	sort.Slice(result.Errors, func(i, j int) bool { return result.Errors[i].Line < result.Errors[j].Line })
+	return result, nil
+}
+
+// ensureOtherAdmin не даёт оставить систему без активного администратора
+func (s *userService) ensureOtherAdmin(ctx context.Context, id uint) error {
+	count, err := s.userRepo.CountActiveAdmins(ctx, id)
+	if err != nil {
+		return errors.New("не удалось проверить администраторов")
+	}
+	if count == 0 {
+		return errors.New("нельзя лишить прав последнего активного администратора")
+	}
+	return nil
+}
+
+func (s *userService) find(ctx context.Context, id uint) (*domain.User, error) {
+	user, err := s.userRepo.FindByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("пользователь не найден")
+		}
+		return nil, errors.New("не удалось получить пользователя")
+	}
+	return user, nil
+}
+
+func setString(dst *string, src *string) {
+	if src != nil {
+		*dst = strings.TrimSpace(*src)
+	}
+}
@@ -0,0 +1,24 @@
+-- Очередь исходящих сообщений во внешние системы (ЕМИАС, РИАМС)
+
+CREATE TABLE IF NOT EXISTS integration_outbox (
+    id BIGSERIAL PRIMARY KEY,
+    system VARCHAR(10) NOT NULL,
+    operation VARCHAR(30) NOT NULL,
+    patient_id BIGINT NOT NULL,
+    payload TEXT,
+    status VARCHAR(20) DEFAULT 'pending' NOT NULL,
+    attempts INTEGER DEFAULT 0 NOT NULL,
+    max_attempts INTEGER DEFAULT 10 NOT NULL,
+    next_retry_at TIMESTAMPTZ NOT NULL,
+    last_error TEXT,
+    external_id TEXT,
+    created_by BIGINT,
+    completed_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_integration_outbox_system ON integration_outbox (system);
+CREATE INDEX IF NOT EXISTS idx_integration_outbox_patient_id ON integration_outbox (patient_id);
+CREATE INDEX IF NOT EXISTS idx_integration_outbox_status ON integration_outbox (status);
+CREATE INDEX IF NOT EXISTS idx_integration_outbox_next_retry_at ON integration_outbox (next_retry_at);
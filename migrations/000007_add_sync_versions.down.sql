@@ -0,0 +1,12 @@
+DROP TABLE IF EXISTS sync_tombstones;
+
+DROP INDEX IF EXISTS idx_sync_queues_client_id;
+ALTER TABLE sync_queues DROP COLUMN IF EXISTS error;
+ALTER TABLE sync_queues DROP COLUMN IF EXISTS status;
+ALTER TABLE sync_queues DROP COLUMN IF EXISTS client_id;
+
+ALTER TABLE comments DROP COLUMN IF EXISTS sync_version;
+ALTER TABLE checklist_items DROP COLUMN IF EXISTS sync_version;
+ALTER TABLE patients DROP COLUMN IF EXISTS sync_version;
+
+DROP SEQUENCE IF EXISTS sync_version_seq;
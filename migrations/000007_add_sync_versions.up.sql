@@ -0,0 +1,40 @@
+-- Версии записей для офлайн-синхронизации: монотонная последовательность служит курсором pull
+
+CREATE SEQUENCE IF NOT EXISTS sync_version_seq;
+
+ALTER TABLE patients ADD COLUMN IF NOT EXISTS sync_version BIGINT DEFAULT 0 NOT NULL;
+ALTER TABLE checklist_items ADD COLUMN IF NOT EXISTS sync_version BIGINT DEFAULT 0 NOT NULL;
+ALTER TABLE comments ADD COLUMN IF NOT EXISTS sync_version BIGINT DEFAULT 0 NOT NULL;
+
+UPDATE patients SET sync_version = nextval('sync_version_seq') WHERE sync_version = 0;
+UPDATE checklist_items SET sync_version = nextval('sync_version_seq') WHERE sync_version = 0;
+UPDATE comments SET sync_version = nextval('sync_version_seq') WHERE sync_version = 0;
+
+CREATE INDEX IF NOT EXISTS idx_patients_sync_version ON patients (sync_version);
+CREATE INDEX IF NOT EXISTS idx_checklist_items_sync_version ON checklist_items (sync_version);
+CREATE INDEX IF NOT EXISTS idx_comments_sync_version ON comments (sync_version);
+
+-- Журнал мутаций: идентификатор мутации на клиенте и результат применения
+ALTER TABLE sync_queues ADD COLUMN IF NOT EXISTS client_id VARCHAR(64);
+ALTER TABLE sync_queues ADD COLUMN IF NOT EXISTS status VARCHAR(20);
+ALTER TABLE sync_queues ADD COLUMN IF NOT EXISTS error TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_sync_queues_client_id ON sync_queues (client_id);
+
+-- Удалённые записи, о которых нужно сообщить клиентам
+CREATE TABLE IF NOT EXISTS sync_tombstones (
+    id BIGSERIAL PRIMARY KEY,
+    entity VARCHAR(50) NOT NULL,
+    entity_id BIGINT NOT NULL,
+    patient_id BIGINT NOT NULL,
+    doctor_id BIGINT,
+    surgeon_id BIGINT,
+    status VARCHAR(30),
+    sync_version BIGINT DEFAULT 0 NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_sync_tombstones_patient_id ON sync_tombstones (patient_id);
+CREATE INDEX IF NOT EXISTS idx_sync_tombstones_doctor_id ON sync_tombstones (doctor_id);
+CREATE INDEX IF NOT EXISTS idx_sync_tombstones_surgeon_id ON sync_tombstones (surgeon_id);
+CREATE INDEX IF NOT EXISTS idx_sync_tombstones_sync_version ON sync_tombstones (sync_version);
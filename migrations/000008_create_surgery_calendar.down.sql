@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS idx_surgeries_scheduled_date;
+DROP INDEX IF EXISTS idx_surgeries_room_id;
+ALTER TABLE surgeries DROP COLUMN IF EXISTS room_id;
+ALTER TABLE surgeries DROP COLUMN IF EXISTS duration_minutes;
+
+DROP TABLE IF EXISTS surgeon_time_offs;
+DROP TABLE IF EXISTS surgeon_working_hours;
+DROP TABLE IF EXISTS operating_rooms;
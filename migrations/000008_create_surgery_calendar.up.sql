@@ -0,0 +1,53 @@
+-- Календарь операционных: операционные, рабочие часы и отсутствия хирургов
+
+CREATE TABLE IF NOT EXISTS operating_rooms (
+    id BIGSERIAL PRIMARY KEY,
+    name TEXT NOT NULL,
+    description TEXT,
+    opens_at VARCHAR(5) DEFAULT '08:00' NOT NULL,
+    closes_at VARCHAR(5) DEFAULT '16:00' NOT NULL,
+    daily_capacity BIGINT DEFAULT 0 NOT NULL,
+    is_active BOOLEAN DEFAULT TRUE NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS surgeon_working_hours (
+    id BIGSERIAL PRIMARY KEY,
+    surgeon_id BIGINT NOT NULL,
+    weekday BIGINT NOT NULL,
+    start_time VARCHAR(5) NOT NULL,
+    end_time VARCHAR(5) NOT NULL,
+    max_operations BIGINT DEFAULT 0 NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_surgeon_working_hours_surgeon_id ON surgeon_working_hours (surgeon_id);
+
+CREATE TABLE IF NOT EXISTS surgeon_time_offs (
+    id BIGSERIAL PRIMARY KEY,
+    surgeon_id BIGINT NOT NULL,
+    type VARCHAR(20) NOT NULL,
+    date_from DATE NOT NULL,
+    date_to DATE NOT NULL,
+    reason TEXT,
+    created_by BIGINT,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_surgeon_time_offs_surgeon_id ON surgeon_time_offs (surgeon_id);
+
+-- Операция получает время начала, длительность и операционную
+ALTER TABLE surgeries ADD COLUMN IF NOT EXISTS duration_minutes BIGINT DEFAULT 0 NOT NULL;
+ALTER TABLE surgeries ADD COLUMN IF NOT EXISTS room_id BIGINT REFERENCES operating_rooms (id);
+
+CREATE INDEX IF NOT EXISTS idx_surgeries_room_id ON surgeries (room_id);
+CREATE INDEX IF NOT EXISTS idx_surgeries_scheduled_date ON surgeries (scheduled_date);
+
+UPDATE surgeries SET duration_minutes = CASE operation_type
+    WHEN 'PHACOEMULSIFICATION' THEN 30
+    WHEN 'ANTIGLAUCOMA' THEN 45
+    WHEN 'VITRECTOMY' THEN 90
+    ELSE 60
+END
+WHERE duration_minutes = 0;
@@ -0,0 +1,21 @@
+ALTER TABLE operation_types ADD COLUMN IF NOT EXISTS checklist_template TEXT;
+
+DROP INDEX IF EXISTS idx_checklist_items_template_item_id;
+ALTER TABLE checklist_items DROP COLUMN IF EXISTS template_item_id;
+UPDATE checklist_items SET template_id = NULL WHERE template_id IS NOT NULL;
+
+DROP TABLE IF EXISTS checklist_template_items;
+
+DELETE FROM checklist_templates;
+DROP INDEX IF EXISTS idx_checklist_templates_is_active;
+DROP INDEX IF EXISTS idx_checklist_template_version;
+ALTER TABLE checklist_templates DROP COLUMN IF EXISTS updated_at;
+ALTER TABLE checklist_templates DROP COLUMN IF EXISTS created_by;
+ALTER TABLE checklist_templates DROP COLUMN IF EXISTS is_active;
+ALTER TABLE checklist_templates DROP COLUMN IF EXISTS version;
+
+ALTER TABLE checklist_templates ADD COLUMN IF NOT EXISTS category VARCHAR(50);
+ALTER TABLE checklist_templates ADD COLUMN IF NOT EXISTS is_required BOOLEAN DEFAULT TRUE;
+ALTER TABLE checklist_templates ADD COLUMN IF NOT EXISTS expires_in_days BIGINT;
+ALTER TABLE checklist_templates ADD COLUMN IF NOT EXISTS sort_order BIGINT;
+CREATE INDEX IF NOT EXISTS idx_checklist_templates_operation_type ON checklist_templates (operation_type);
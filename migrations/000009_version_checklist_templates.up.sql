@@ -0,0 +1,40 @@
+-- Версионируемые шаблоны чек-листов: шаблон — версия для типа операции, пункты — отдельная таблица
+
+-- Прежние строки шаблонов нигде не использовались: пункты генерировались из кода
+UPDATE checklist_items SET template_id = NULL WHERE template_id IS NOT NULL;
+DELETE FROM checklist_templates;
+
+ALTER TABLE checklist_templates DROP COLUMN IF EXISTS category;
+ALTER TABLE checklist_templates DROP COLUMN IF EXISTS is_required;
+ALTER TABLE checklist_templates DROP COLUMN IF EXISTS expires_in_days;
+ALTER TABLE checklist_templates DROP COLUMN IF EXISTS sort_order;
+
+ALTER TABLE checklist_templates ADD COLUMN IF NOT EXISTS version BIGINT DEFAULT 1 NOT NULL;
+ALTER TABLE checklist_templates ADD COLUMN IF NOT EXISTS is_active BOOLEAN DEFAULT FALSE;
+ALTER TABLE checklist_templates ADD COLUMN IF NOT EXISTS created_by BIGINT;
+ALTER TABLE checklist_templates ADD COLUMN IF NOT EXISTS updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP;
+
+DROP INDEX IF EXISTS idx_checklist_templates_operation_type;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_checklist_template_version ON checklist_templates (operation_type, version);
+CREATE INDEX IF NOT EXISTS idx_checklist_templates_is_active ON checklist_templates (is_active);
+
+CREATE TABLE IF NOT EXISTS checklist_template_items (
+    id BIGSERIAL PRIMARY KEY,
+    template_id BIGINT NOT NULL REFERENCES checklist_templates (id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    description TEXT,
+    category VARCHAR(50),
+    is_required BOOLEAN,
+    expires_in_days BIGINT,
+    sort_order BIGINT,
+    include_when JSONB,
+    required_when JSONB
+);
+
+CREATE INDEX IF NOT EXISTS idx_checklist_template_items_template_id ON checklist_template_items (template_id);
+
+ALTER TABLE checklist_items ADD COLUMN IF NOT EXISTS template_item_id BIGINT;
+CREATE INDEX IF NOT EXISTS idx_checklist_items_template_item_id ON checklist_items (template_item_id);
+
+-- JSON-шаблон в справочнике типов операций заменён таблицами выше
+ALTER TABLE operation_types DROP COLUMN IF EXISTS checklist_template;
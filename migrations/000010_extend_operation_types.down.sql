@@ -0,0 +1,3 @@
+ALTER TABLE operation_types DROP COLUMN IF EXISTS procedure_codes;
+ALTER TABLE operation_types DROP COLUMN IF EXISTS duration_minutes;
+ALTER TABLE operation_types DROP COLUMN IF EXISTS default_eye;
@@ -0,0 +1,17 @@
+-- Справочник типов операций: глаз по умолчанию, длительность и коды процедур
+
+ALTER TABLE operation_types ADD COLUMN IF NOT EXISTS default_eye VARCHAR(5);
+ALTER TABLE operation_types ADD COLUMN IF NOT EXISTS duration_minutes BIGINT DEFAULT 0 NOT NULL;
+ALTER TABLE operation_types ADD COLUMN IF NOT EXISTS procedure_codes JSONB;
+
+UPDATE operation_types SET duration_minutes = 30,
+    procedure_codes = '[{"code":"231744001","display":"Факоэмульсификация катаракты","system":"http://snomed.info/sct"}]'
+WHERE code = 'PHACOEMULSIFICATION' AND duration_minutes = 0;
+
+UPDATE operation_types SET duration_minutes = 45,
+    procedure_codes = '[{"code":"46309007","display":"Трабекулэктомия","system":"http://snomed.info/sct"}]'
+WHERE code = 'ANTIGLAUCOMA' AND duration_minutes = 0;
+
+UPDATE operation_types SET duration_minutes = 90,
+    procedure_codes = '[{"code":"397193006","display":"Витрэктомия","system":"http://snomed.info/sct"}]'
+WHERE code = 'VITRECTOMY' AND duration_minutes = 0;
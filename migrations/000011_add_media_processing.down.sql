@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS idx_media_processing_status;
+
+ALTER TABLE media DROP COLUMN IF EXISTS processed_at;
+ALTER TABLE media DROP COLUMN IF EXISTS next_attempt_at;
+ALTER TABLE media DROP COLUMN IF EXISTS processing_attempts;
+ALTER TABLE media DROP COLUMN IF EXISTS processing_error;
+ALTER TABLE media DROP COLUMN IF EXISTS processing_status;
+ALTER TABLE media DROP COLUMN IF EXISTS height;
+ALTER TABLE media DROP COLUMN IF EXISTS width;
@@ -0,0 +1,15 @@
+-- Фоновая обработка изображений: статус, размеры, настоящие миниатюры
+
+ALTER TABLE media ADD COLUMN IF NOT EXISTS width BIGINT;
+ALTER TABLE media ADD COLUMN IF NOT EXISTS height BIGINT;
+ALTER TABLE media ADD COLUMN IF NOT EXISTS processing_status VARCHAR(20) DEFAULT 'READY';
+ALTER TABLE media ADD COLUMN IF NOT EXISTS processing_error TEXT;
+ALTER TABLE media ADD COLUMN IF NOT EXISTS processing_attempts BIGINT DEFAULT 0;
+ALTER TABLE media ADD COLUMN IF NOT EXISTS next_attempt_at TIMESTAMPTZ;
+ALTER TABLE media ADD COLUMN IF NOT EXISTS processed_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_media_processing_status ON media(processing_status);
+
+-- Миниатюры раньше не создавались: пути вымышленные, изображения обрабатываем заново
+UPDATE media SET processing_status = 'PENDING', thumbnail_path = ''
+WHERE content_type IN ('image/jpeg', 'image/png');
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_media_checklist_item_id;
+
+ALTER TABLE media DROP COLUMN IF EXISTS warnings;
+ALTER TABLE media DROP COLUMN IF EXISTS dicom;
+ALTER TABLE media DROP COLUMN IF EXISTS checklist_item_id;
+ALTER TABLE media DROP COLUMN IF EXISTS eye;
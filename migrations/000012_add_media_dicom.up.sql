@@ -0,0 +1,12 @@
+-- DICOM-снимки: глаз, пункт чек-листа, сведения заголовка и предупреждения
+
+ALTER TABLE media ADD COLUMN IF NOT EXISTS eye VARCHAR(5);
+ALTER TABLE media ADD COLUMN IF NOT EXISTS checklist_item_id BIGINT;
+ALTER TABLE media ADD COLUMN IF NOT EXISTS dicom JSONB;
+ALTER TABLE media ADD COLUMN IF NOT EXISTS warnings JSONB;
+
+CREATE INDEX IF NOT EXISTS idx_media_checklist_item_id ON media(checklist_item_id);
+
+-- Ранее загруженные DICOM-файлы хранились как двоичные данные — разбираем заново
+UPDATE media SET processing_status = 'PENDING'
+WHERE content_type = 'application/dicom' OR LOWER(original_name) LIKE '%.dcm';
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_iol_calculations_media_id;
+
+ALTER TABLE iol_calculations DROP COLUMN IF EXISTS media_id;
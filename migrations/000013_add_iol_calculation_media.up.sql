@@ -0,0 +1,5 @@
+-- Расчёты ИОЛ, выполненные по импортированной выгрузке биометра, ссылаются на исходный файл
+
+ALTER TABLE iol_calculations ADD COLUMN IF NOT EXISTS media_id BIGINT;
+
+CREATE INDEX IF NOT EXISTS idx_iol_calculations_media_id ON iol_calculations(media_id);
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_iol_calculations_lens_id;
+
+ALTER TABLE iol_calculations DROP COLUMN IF EXISTS lens_id;
+
+DROP TABLE IF EXISTS iol_lenses;
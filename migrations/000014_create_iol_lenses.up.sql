@@ -0,0 +1,25 @@
+-- Каталог интраокулярных линз с оптическими константами формул
+
+CREATE TABLE IF NOT EXISTS iol_lenses (
+    id BIGSERIAL PRIMARY KEY,
+    manufacturer TEXT NOT NULL,
+    model TEXT NOT NULL,
+    a_constant NUMERIC NOT NULL,
+    haigis_a0 NUMERIC,
+    haigis_a1 NUMERIC,
+    haigis_a2 NUMERIC,
+    hoffer_pacd NUMERIC,
+    surgeon_factor NUMERIC,
+    min_power NUMERIC,
+    max_power NUMERIC,
+    is_active BOOLEAN DEFAULT true NOT NULL,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_iol_lenses_model ON iol_lenses(manufacturer, model);
+
+-- Расчёт ИОЛ запоминает линзу, константы которой использованы
+ALTER TABLE iol_calculations ADD COLUMN IF NOT EXISTS lens_id BIGINT;
+
+CREATE INDEX IF NOT EXISTS idx_iol_calculations_lens_id ON iol_calculations(lens_id);
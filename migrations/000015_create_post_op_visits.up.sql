@@ -0,0 +1,27 @@
+-- Послеоперационные осмотры: фактическая рефракция для оценки расчётов ИОЛ
+
+CREATE TABLE IF NOT EXISTS post_op_visits (
+    id BIGSERIAL PRIMARY KEY,
+    surgery_id BIGINT NOT NULL,
+    patient_id BIGINT NOT NULL,
+    iol_calculation_id BIGINT,
+    eye VARCHAR(5),
+    visit_date TIMESTAMPTZ NOT NULL,
+    sphere NUMERIC NOT NULL,
+    cylinder NUMERIC,
+    axis NUMERIC,
+    spherical_equivalent NUMERIC NOT NULL,
+    ucva NUMERIC,
+    bcva NUMERIC,
+    iop NUMERIC,
+    implanted_power NUMERIC,
+    prediction_error NUMERIC,
+    notes TEXT,
+    recorded_by BIGINT,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_post_op_visits_surgery_id ON post_op_visits(surgery_id);
+CREATE INDEX IF NOT EXISTS idx_post_op_visits_patient_id ON post_op_visits(patient_id);
+CREATE INDEX IF NOT EXISTS idx_post_op_visits_iol_calculation_id ON post_op_visits(iol_calculation_id);
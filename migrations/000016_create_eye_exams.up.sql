@@ -0,0 +1,27 @@
+-- Структурированный офтальмологический осмотр: один глаз за визит
+
+CREATE TABLE IF NOT EXISTS eye_exams (
+    id BIGSERIAL PRIMARY KEY,
+    patient_id BIGINT NOT NULL,
+    eye VARCHAR(5) NOT NULL,
+    exam_date DATE NOT NULL,
+    ucva NUMERIC,
+    bcva NUMERIC,
+    sphere NUMERIC,
+    cylinder NUMERIC,
+    axis NUMERIC,
+    iop NUMERIC,
+    iop_method VARCHAR(20),
+    biomicroscopy TEXT,
+    fundus TEXT,
+    locs_no NUMERIC,
+    locs_nc NUMERIC,
+    locs_c NUMERIC,
+    locs_p NUMERIC,
+    notes TEXT,
+    examined_by BIGINT,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_eye_exams_visit ON eye_exams(patient_id, eye, exam_date);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_patients_diagnosis_fts;
+DROP INDEX IF EXISTS idx_patients_name_trgm;
@@ -0,0 +1,9 @@
+-- Поиск пациентов: ФИО с опечатками (pg_trgm) и полнотекстовый поиск по диагнозу
+
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX IF NOT EXISTS idx_patients_name_trgm ON patients USING GIN
+    (translate(lower(last_name || ' ' || first_name || ' ' || coalesce(middle_name, '')), 'ё', 'е') gin_trgm_ops);
+
+CREATE INDEX IF NOT EXISTS idx_patients_diagnosis_fts ON patients USING GIN
+    (to_tsvector('russian', coalesce(diagnosis, '')));
@@ -0,0 +1,15 @@
+-- Перед откатом значения нужно расшифровать: зашифрованные не помещаются в varchar(16)
+DROP INDEX IF EXISTS idx_patients_phone_hash;
+DROP INDEX IF EXISTS idx_patients_passport_hash;
+DROP INDEX IF EXISTS idx_patients_policy_number_hash;
+DROP INDEX IF EXISTS idx_patients_oms_policy_hash;
+DROP INDEX IF EXISTS idx_patients_snils_hash;
+
+ALTER TABLE patients
+    DROP COLUMN IF EXISTS phone_hash,
+    DROP COLUMN IF EXISTS passport_hash,
+    DROP COLUMN IF EXISTS policy_number_hash,
+    DROP COLUMN IF EXISTS oms_policy_hash,
+    DROP COLUMN IF EXISTS snils_hash;
+
+ALTER TABLE patients ALTER COLUMN oms_policy TYPE varchar(16);
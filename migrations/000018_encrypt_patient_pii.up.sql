@@ -0,0 +1,18 @@
+-- Шифрование ПДн пациентов: значения хранятся как enc:v1:<ключ>:<base64>,
+-- поиск по документам и телефону — по слепым индексам (HMAC-SHA256).
+-- Существующие открытые значения шифрует команда cmd/rotate-pii-keys.
+
+ALTER TABLE patients ALTER COLUMN oms_policy TYPE text;
+
+ALTER TABLE patients
+    ADD COLUMN IF NOT EXISTS snils_hash varchar(64),
+    ADD COLUMN IF NOT EXISTS oms_policy_hash varchar(64),
+    ADD COLUMN IF NOT EXISTS policy_number_hash varchar(64),
+    ADD COLUMN IF NOT EXISTS passport_hash varchar(64),
+    ADD COLUMN IF NOT EXISTS phone_hash varchar(64);
+
+CREATE INDEX IF NOT EXISTS idx_patients_snils_hash ON patients (snils_hash);
+CREATE INDEX IF NOT EXISTS idx_patients_oms_policy_hash ON patients (oms_policy_hash);
+CREATE INDEX IF NOT EXISTS idx_patients_policy_number_hash ON patients (policy_number_hash);
+CREATE INDEX IF NOT EXISTS idx_patients_passport_hash ON patients (passport_hash);
+CREATE INDEX IF NOT EXISTS idx_patients_phone_hash ON patients (phone_hash);
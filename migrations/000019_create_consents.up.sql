@@ -0,0 +1,22 @@
+-- Согласия пациента (152-ФЗ): не удаляются, отзыв фиксируется в revoked_at
+
+CREATE TABLE IF NOT EXISTS consents (
+    id BIGSERIAL PRIMARY KEY,
+    patient_id BIGINT NOT NULL,
+    type VARCHAR(30) NOT NULL,
+    text_version VARCHAR(20) NOT NULL,
+    channel VARCHAR(20) NOT NULL,
+    signed_at TIMESTAMPTZ NOT NULL,
+    media_id BIGINT,
+    recorded_by BIGINT,
+    revoked_at TIMESTAMPTZ,
+    revoke_channel VARCHAR(20),
+    revoked_by BIGINT,
+    revoke_reason TEXT,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_consents_patient_id ON consents(patient_id);
+CREATE INDEX IF NOT EXISTS idx_consents_type ON consents(type);
+CREATE INDEX IF NOT EXISTS idx_consents_revoked_at ON consents(revoked_at);
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_audit_logs_created_at;
+DROP INDEX IF EXISTS idx_audit_logs_entity_id;
+
+ALTER TABLE audit_logs DROP COLUMN IF EXISTS user_role;
+ALTER TABLE audit_logs DROP COLUMN IF EXISTS changes;
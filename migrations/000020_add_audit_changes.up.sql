@@ -0,0 +1,7 @@
+-- Аудит на уровне сервисов: diff изменённых полей, роль автора, индексы для выборок журнала
+
+ALTER TABLE audit_logs ADD COLUMN IF NOT EXISTS changes JSONB;
+ALTER TABLE audit_logs ADD COLUMN IF NOT EXISTS user_role VARCHAR(30);
+
+CREATE INDEX IF NOT EXISTS idx_audit_logs_entity_id ON audit_logs(entity_id);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_created_at ON audit_logs(created_at);
@@ -0,0 +1,9 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS refresh_token TEXT;
+CREATE INDEX IF NOT EXISTS idx_users_refresh_token ON users(refresh_token);
+
+ALTER TABLE users DROP COLUMN IF EXISTS totp_last_step;
+ALTER TABLE users DROP COLUMN IF EXISTS totp_enabled;
+ALTER TABLE users DROP COLUMN IF EXISTS totp_secret;
+
+DROP TABLE IF EXISTS recovery_codes;
+DROP TABLE IF EXISTS sessions;
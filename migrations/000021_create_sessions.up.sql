@@ -0,0 +1,39 @@
+-- Сессии входа и двухфакторная аутентификация сотрудников.
+-- Токен обновления привязан к сессии и меняется при каждом обновлении;
+-- единственный токен в users.refresh_token больше не используется.
+
+CREATE TABLE IF NOT EXISTS sessions (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    subject VARCHAR(10) NOT NULL,
+    refresh_hash VARCHAR(64) NOT NULL,
+    user_agent VARCHAR(255),
+    ip VARCHAR(45),
+    last_used_at TIMESTAMPTZ NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    revoked_at TIMESTAMPTZ,
+    revoke_reason VARCHAR(20),
+    created_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_sessions_subject ON sessions(user_id, subject);
+CREATE INDEX IF NOT EXISTS idx_sessions_expires_at ON sessions(expires_at);
+
+CREATE TABLE IF NOT EXISTS recovery_codes (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    code_hash VARCHAR(64) NOT NULL,
+    used_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_recovery_codes_user_id ON recovery_codes(user_id);
+
+-- Секрет TOTP шифруется как ПДн (enc:v1:...)
+ALTER TABLE users ADD COLUMN IF NOT EXISTS totp_secret TEXT;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS totp_enabled BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS totp_last_step BIGINT;
+
+-- Ранее выданные токены обновления перестают действовать: нужен повторный вход
+DROP INDEX IF EXISTS idx_users_refresh_token;
+ALTER TABLE users DROP COLUMN IF EXISTS refresh_token;
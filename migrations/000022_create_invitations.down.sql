@@ -0,0 +1,4 @@
+ALTER TABLE users DROP COLUMN IF EXISTS email_verified_at;
+
+DROP TABLE IF EXISTS user_tokens;
+DROP TABLE IF EXISTS invitations;
@@ -0,0 +1 @@
+DROP FUNCTION IF EXISTS next_sync_version();
@@ -0,0 +1,16 @@
+-- Версии синхронизации выдаются до фиксации транзакций. Первая версия транзакции
+-- регистрируется advisory-блокировкой с нижней границей, pull не отдаёт версии
+-- выше минимальной границы незафиксированных транзакций.
+
+CREATE OR REPLACE FUNCTION next_sync_version() RETURNS BIGINT AS $$
+DECLARE
+    bound BIGINT;
+BEGIN
+    IF coalesce(current_setting('sync.xact_registered', true), '') = '' THEN
+        SELECT CASE WHEN is_called THEN last_value + 1 ELSE last_value END INTO bound FROM sync_version_seq;
+        PERFORM pg_advisory_xact_lock_shared((21337::bigint << 48) | bound);
+        PERFORM set_config('sync.xact_registered', 'on', true);
+    END IF;
+    RETURN nextval('sync_version_seq');
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_sync_tombstones_district_id;
+ALTER TABLE sync_tombstones DROP COLUMN IF EXISTS district_id;
@@ -0,0 +1,10 @@
+-- Участковый врач видит пациентов своего района, поэтому надгробия хранят и район.
+-- Для удалённых пациентов район не восстановить; пункты чек-листа берут его у пациента.
+
+ALTER TABLE sync_tombstones ADD COLUMN IF NOT EXISTS district_id BIGINT NOT NULL DEFAULT 0;
+
+UPDATE sync_tombstones t SET district_id = p.district_id
+FROM patients p
+WHERE p.id = t.patient_id AND t.district_id = 0;
+
+CREATE INDEX IF NOT EXISTS idx_sync_tombstones_district_id ON sync_tombstones (district_id);
@@ -0,0 +1,233 @@
+// Package biometry разбирает выгрузки оптических биометров (IOLMaster и аналоги)
+// и текстовые протоколы А-сканирования в измерения по глазам.
+package biometry
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	FormatCSV  = "csv"
+	FormatXML  = "xml"
+	FormatText = "text"
+)
+
+// Глаза в обозначениях, принятых в domain
+const (
+	EyeRight = "OD"
+	EyeLeft  = "OS"
+)
+
+var ErrNoMeasurements = errors.New("в файле не найдено биометрических измерений")
+
+// Measurement — биометрия одного глаза; 0 — показатель не измерен
+type Measurement struct {
+	Eye         string  `json:"eye"`
+	AxialLength float64 `json:"axial_length"`
+	K1          float64 `json:"keratometry1"`
+	K2          float64 `json:"keratometry2"`
+	ACD         float64 `json:"acd,omitempty"`
+}
+
+// Report — содержимое выгрузки
+type Report struct {
+	Format      string        `json:"format"`
+	Device      string        `json:"device,omitempty"`
+	PatientName string        `json:"patient_name,omitempty"`
+	MeasuredAt  *time.Time    `json:"measured_at,omitempty"`
+	Eyes        []Measurement `json:"eyes"`
+}
+
+// Eye возвращает измерения указанного глаза
+func (r *Report) Eye(eye string) (Measurement, bool) {
+	for _, m := range r.Eyes {
+		if m.Eye == eye {
+			return m, true
+		}
+	}
+	return Measurement{}, false
+}
+
+// Parse определяет формат по расширению и содержимому и разбирает файл
+func Parse(fileName string, data []byte) (*Report, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 {
+		return nil, ErrNoMeasurements
+	}
+
+	var (
+		r   *Report
+		err error
+	)
+	ext := strings.ToLower(filepath.Ext(fileName))
+	switch {
+	case ext == ".xml" || trimmed[0] == '<':
+		r, err = parseXML(data)
+	case ext == ".csv" || looksLikeCSV(trimmed):
+		r, err = parseCSV(data)
+	default:
+		r, err = parseText(data)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := r.normalize(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// normalize переводит радиусы кривизны в диоптрии, проверяет диапазоны
+// и убирает глаза без длины оси
+func (r *Report) normalize() error {
+	eyes := r.Eyes[:0]
+	for _, m := range r.Eyes {
+		m.K1, m.K2 = keratometryDiopters(m.K1), keratometryDiopters(m.K2)
+		if m.AxialLength == 0 {
+			continue
+		}
+		if err := m.validate(); err != nil {
+			return err
+		}
+		eyes = append(eyes, m)
+	}
+	r.Eyes = eyes
+	if len(r.Eyes) == 0 {
+		return ErrNoMeasurements
+	}
+	return nil
+}
+
+// keratometryDiopters: биометры пишут K либо в диоптриях, либо радиусом в мм
+func keratometryDiopters(k float64) float64 {
+	if k > 5 && k < 11 {
+		return math.Round(337.5/k*100) / 100
+	}
+	return k
+}
+
+func (m Measurement) validate() error {
+	checks := []struct {
+		name     string
+		value    float64
+		min, max float64
+	}{
+		{"длина оси", m.AxialLength, 14, 40},
+		{"K1", m.K1, 30, 65},
+		{"K2", m.K2, 30, 65},
+		{"ACD", m.ACD, 1, 6},
+	}
+	for _, c := range checks {
+		if c.value != 0 && (c.value < c.min || c.value > c.max) {
+			return fmt.Errorf("%s: %s %.2f вне допустимого диапазона", m.Eye, c.name, c.value)
+		}
+	}
+	return nil
+}
+
+// measurementField определяет показатель по названию колонки или элемента
+func measurementField(name string) string {
+	switch key := compactKey(name); {
+	case key == "al" || strings.HasPrefix(key, "axiallength") || key == "axl" || key == "длинаоси" || key == "пзо":
+		return "AL"
+	case key == "k1" || key == "kflat" || key == "k1d" || key == "k1mm" || key == "rflat":
+		return "K1"
+	case key == "k2" || key == "ksteep" || key == "k2d" || key == "k2mm" || key == "rsteep":
+		return "K2"
+	case key == "acd" || strings.HasPrefix(key, "anteriorchamberdepth") || key == "гпк":
+		return "ACD"
+	}
+	return ""
+}
+
+// eyeFromText распознаёт обозначение глаза: OD/OS, R/L, right/left, правый/левый
+func eyeFromText(s string) string {
+	switch compactKey(s) {
+	case "od", "r", "right", "righteye", "правый", "правыйглаз":
+		return EyeRight
+	case "os", "l", "left", "lefteye", "левый", "левыйглаз":
+		return EyeLeft
+	}
+	return ""
+}
+
+// compactKey — нижний регистр без пробелов, скобок и знаков
+func compactKey(s string) string {
+	var sb strings.Builder
+	for _, r := range strings.ToLower(s) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || (r >= 'а' && r <= 'я') || r == 'ё' {
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+var numberRe = regexp.MustCompile(`-?\d+(?:[.,]\d+)?`)
+
+// parseNumber читает первое число в строке; допускает десятичную запятую и единицы
+func parseNumber(s string) (float64, bool) {
+	n := numberRe.FindString(s)
+	if n == "" {
+		return 0, false
+	}
+	v, err := strconv.ParseFloat(strings.Replace(n, ",", ".", 1), 64)
+	return v, err == nil
+}
+
+var dateLayouts = []string{"2006-01-02", "2006-01-02T15:04:05", "2006-01-02 15:04:05", "02.01.2006", "02.01.2006 15:04", "01/02/2006", "20060102"}
+
+func parseDate(s string) (*time.Time, bool) {
+	s = strings.TrimSpace(s)
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return &t, true
+		}
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return &t, true
+	}
+	return nil, false
+}
+
+// eyes собирает измерения по глазам в порядке OD, OS
+type eyes map[string]*Measurement
+
+func (e eyes) set(eye, field string, value float64) {
+	if eye == "" || field == "" {
+		return
+	}
+	m, ok := e[eye]
+	if !ok {
+		m = &Measurement{Eye: eye}
+		e[eye] = m
+	}
+	switch field {
+	case "AL":
+		m.AxialLength = value
+	case "K1":
+		m.K1 = value
+	case "K2":
+		m.K2 = value
+	case "ACD":
+		m.ACD = value
+	}
+}
+
+func (e eyes) list() []Measurement {
+	var out []Measurement
+	for _, eye := range []string{EyeRight, EyeLeft} {
+		if m, ok := e[eye]; ok {
+			out = append(out, *m)
+		}
+	}
+	return out
+}
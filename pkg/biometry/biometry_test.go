@@ -0,0 +1,187 @@
+package biometry
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		fileName string
+		data     string
+		format   string
+		patient  string
+		date     string
+		want     []Measurement
+	}{
+		{
+			name:     "CSV with per-eye columns",
+			fileName: "export.csv",
+			data: "Last Name,First Name,Exam Date,AL OD,K1 OD,K2 OD,ACD OD,AL OS,K1 OS,K2 OS,ACD OS\n" +
+				"Ivanov,Ivan,2026-03-15,23.45,43.25,44.00,3.12,23.60,43.50,44.25,3.20\n",
+			format:  FormatCSV,
+			patient: "Ivanov Ivan",
+			date:    "2026-03-15",
+			want: []Measurement{
+				{Eye: EyeRight, AxialLength: 23.45, K1: 43.25, K2: 44, ACD: 3.12},
+				{Eye: EyeLeft, AxialLength: 23.6, K1: 43.5, K2: 44.25, ACD: 3.2},
+			},
+		},
+		{
+			name:     "CSV with eye column, semicolon and decimal comma",
+			fileName: "iolmaster.txt",
+			data: "Patient;Eye;AL [mm];K1 [D];K2 [D];ACD [mm]\n" +
+				"Петров П.;OD;24,01;42,75;43,50;3,05\n" +
+				"Петров П.;OS;24,10;42,50;43,25;3,10\n",
+			format:  FormatCSV,
+			patient: "Петров П.",
+			want: []Measurement{
+				{Eye: EyeRight, AxialLength: 24.01, K1: 42.75, K2: 43.5, ACD: 3.05},
+				{Eye: EyeLeft, AxialLength: 24.1, K1: 42.5, K2: 43.25, ACD: 3.1},
+			},
+		},
+		{
+			name:     "CSV with radii in mm",
+			fileName: "export.csv",
+			data:     "Eye,AL,R flat,R steep\nR,23.00,7.80,7.50\n",
+			format:   FormatCSV,
+			want: []Measurement{
+				{Eye: EyeRight, AxialLength: 23, K1: 43.27, K2: 45},
+			},
+		},
+		{
+			name:     "XML with eye attributes",
+			fileName: "export.xml",
+			data: `<?xml version="1.0" encoding="UTF-8"?>
+<BiometryExport device="IOLMaster 700">
+  <Patient><LastName>Sidorova</LastName><FirstName>Anna</FirstName></Patient>
+  <Exam date="2026-03-15">
+    <Eye side="OD">
+      <AxialLength unit="mm">22.80</AxialLength>
+      <Value type="K1">44.00</Value>
+      <Value type="K2" value="44.75"/>
+      <ACD>2.95</ACD>
+    </Eye>
+    <Measurement>
+      <AL>22.95</AL>
+      <Eye>OS</Eye>
+      <K1>44.25</K1>
+      <K2>45.00</K2>
+    </Measurement>
+  </Exam>
+</BiometryExport>`,
+			format:  FormatXML,
+			patient: "Sidorova Anna",
+			want: []Measurement{
+				{Eye: EyeRight, AxialLength: 22.8, K1: 44, K2: 44.75, ACD: 2.95},
+				{Eye: EyeLeft, AxialLength: 22.95, K1: 44.25, K2: 45},
+			},
+		},
+		{
+			name:     "A-scan text with eye sections",
+			fileName: "ascan.txt",
+			data: "Протокол А-сканирования\n" +
+				"Пациент: Кузнецов Олег\n" +
+				"Дата: 15.03.2026\n" +
+				"\n" +
+				"Правый глаз\n" +
+				"ПЗО: 23,10 мм\n" +
+				"K1: 43.00 D  K2: 43.75 D\n" +
+				"ГПК 3,00 мм\n" +
+				"Левый глаз\n" +
+				"ПЗО: 23,30 мм\n" +
+				"K1: 43.25 D  K2: 44.00 D\n",
+			format:  FormatText,
+			patient: "Кузнецов Олег",
+			date:    "2026-03-15",
+			want: []Measurement{
+				{Eye: EyeRight, AxialLength: 23.1, K1: 43, K2: 43.75, ACD: 3},
+				{Eye: EyeLeft, AxialLength: 23.3, K1: 43.25, K2: 44},
+			},
+		},
+		{
+			name:     "A-scan text with OD/OS table",
+			fileName: "",
+			data: "A-SCAN REPORT\n" +
+				"            OD       OS\n" +
+				"AL (mm)     24.50    24.30\n" +
+				"K1          42.00    42.25\n" +
+				"K2          43.00    43.25\n" +
+				"Total       12\n",
+			format: FormatText,
+			want: []Measurement{
+				{Eye: EyeRight, AxialLength: 24.5, K1: 42, K2: 43},
+				{Eye: EyeLeft, AxialLength: 24.3, K1: 42.25, K2: 43.25},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := Parse(tt.fileName, []byte(tt.data))
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			if r.Format != tt.format {
+				t.Errorf("Format = %q, want %q", r.Format, tt.format)
+			}
+			if r.PatientName != tt.patient {
+				t.Errorf("PatientName = %q, want %q", r.PatientName, tt.patient)
+			}
+			if tt.date != "" && (r.MeasuredAt == nil || r.MeasuredAt.Format("2006-01-02") != tt.date) {
+				t.Errorf("MeasuredAt = %v, want %s", r.MeasuredAt, tt.date)
+			}
+			if len(r.Eyes) != len(tt.want) {
+				t.Fatalf("Eyes = %+v, want %+v", r.Eyes, tt.want)
+			}
+			for i, want := range tt.want {
+				if r.Eyes[i] != want {
+					t.Errorf("Eyes[%d] = %+v, want %+v", i, r.Eyes[i], want)
+				}
+			}
+		})
+	}
+}
+
+func TestParseDevice(t *testing.T) {
+	r, err := Parse("export.xml", []byte(`<Export device="IOLMaster 700"><OD><AL>23.1</AL></OD></Export>`))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if r.Device != "IOLMaster 700" {
+		t.Errorf("Device = %q", r.Device)
+	}
+	if m, ok := r.Eye(EyeRight); !ok || m.AxialLength != 23.1 {
+		t.Errorf("Eye(OD) = %+v, %v", m, ok)
+	}
+	if _, ok := r.Eye(EyeLeft); ok {
+		t.Error("Eye(OS) found, want none")
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		fileName string
+		data     string
+		noData   bool
+	}{
+		{"empty file", "export.csv", "\xef\xbb\xbf  \n", true},
+		{"no axial length", "export.csv", "Eye,K1,K2\nOD,43,44\n", true},
+		{"unrelated text", "notes.txt", "Жалоб нет.\n", true},
+		{"axial length out of range", "export.csv", "Eye,AL\nOD,2.35\n", false},
+		{"broken XML", "export.xml", "<Export><OD><AL>23</OD>", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.fileName, []byte(tt.data))
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if errors.Is(err, ErrNoMeasurements) != tt.noData {
+				t.Errorf("err = %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,382 @@
+package biometry
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Названия полей заголовка выгрузки (после compactKey)
+var (
+	patientNameKeys = map[string]bool{"patientname": true, "patient": true, "name": true, "фио": true, "пациент": true}
+	lastNameKeys    = map[string]bool{"lastname": true, "surname": true, "familyname": true, "фамилия": true}
+	firstNameKeys   = map[string]bool{"firstname": true, "givenname": true, "имя": true}
+	dateKeys        = map[string]bool{"date": true, "examdate": true, "measurementdate": true, "studydate": true, "дата": true, "датаисследования": true}
+	deviceKeys      = map[string]bool{"device": true, "devicename": true, "model": true, "instrument": true, "аппарат": true, "прибор": true}
+	eyeColumnKeys   = map[string]bool{"eye": true, "side": true, "laterality": true, "глаз": true}
+)
+
+type header struct {
+	patientName, lastName, firstName string
+	date, device                     string
+}
+
+func (h *header) setField(key, value string) bool {
+	value = strings.TrimSpace(value)
+	switch {
+	case value == "":
+		return false
+	case patientNameKeys[key]:
+		h.patientName = value
+	case lastNameKeys[key]:
+		h.lastName = value
+	case firstNameKeys[key]:
+		h.firstName = value
+	case dateKeys[key]:
+		h.date = value
+	case deviceKeys[key]:
+		h.device = value
+	default:
+		return false
+	}
+	return true
+}
+
+func (h *header) apply(r *Report) {
+	r.Device = h.device
+	r.PatientName = h.patientName
+	if r.PatientName == "" && h.lastName != "" {
+		r.PatientName = strings.TrimSpace(h.lastName + " " + h.firstName)
+	}
+	if t, ok := parseDate(h.date); ok {
+		r.MeasuredAt = t
+	}
+}
+
+// Единицы измерения в названиях колонок: «AL [mm]», «K1 (D)»
+var unitTokens = map[string]bool{"mm": true, "мм": true, "d": true, "dpt": true, "дптр": true}
+
+// splitEye отделяет обозначение глаза и единицы от названия колонки: «AL (OD) [mm]» → OD, «al».
+// Одиночные R/L считаются глазом только в конце названия, чтобы не путать с «R flat».
+func splitEye(name string) (eye, rest string) {
+	tokens := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	var kept []string
+	for i, t := range tokens {
+		e := eyeFromText(t)
+		if e != "" && eye == "" && (len(t) > 1 || i == len(tokens)-1) {
+			eye = e
+			continue
+		}
+		if unitTokens[t] {
+			continue
+		}
+		kept = append(kept, t)
+	}
+	return eye, strings.Join(kept, "")
+}
+
+// --- CSV ---
+
+func detectDelimiter(line string) rune {
+	best, count := ',', 0
+	for _, d := range []rune{';', '\t', ','} {
+		if n := strings.Count(line, string(d)); n > count {
+			best, count = d, n
+		}
+	}
+	return best
+}
+
+func firstLine(data []byte) string {
+	line, _, _ := bytes.Cut(data, []byte("\n"))
+	return strings.TrimRight(string(line), "\r")
+}
+
+// looksLikeCSV: первая строка — заголовок с разделителями и биометрическими колонками
+func looksLikeCSV(data []byte) bool {
+	line := firstLine(data)
+	d := detectDelimiter(line)
+	fields := strings.Split(line, string(d))
+	if len(fields) < 3 {
+		return false
+	}
+	for _, f := range fields {
+		if _, rest := splitEye(f); measurementField(rest) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+func parseCSV(data []byte) (*Report, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.Comma = detectDelimiter(firstLine(data))
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	reader.TrimLeadingSpace = true
+
+	columns, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("не удалось прочитать заголовок CSV: %w", err)
+	}
+
+	type column struct {
+		eye, field, key string
+	}
+	cols := make([]column, len(columns))
+	eyeColumn := -1
+	for i, name := range columns {
+		key := compactKey(name)
+		if eyeColumnKeys[key] {
+			eyeColumn = i
+			continue
+		}
+		eye, rest := splitEye(name)
+		cols[i] = column{eye: eye, field: measurementField(rest), key: key}
+	}
+
+	r := &Report{Format: FormatCSV}
+	found := eyes{}
+	var h header
+	// Если строк несколько, более поздние измерения перекрывают ранние
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("ошибка в CSV: %w", err)
+		}
+
+		rowEye := ""
+		if eyeColumn >= 0 && eyeColumn < len(record) {
+			rowEye = eyeFromText(record[eyeColumn])
+		}
+		for i, value := range record {
+			if i >= len(cols) || i == eyeColumn {
+				continue
+			}
+			c := cols[i]
+			if c.field == "" {
+				h.setField(c.key, value)
+				continue
+			}
+			eye := c.eye
+			if eye == "" {
+				eye = rowEye
+			}
+			if v, ok := parseNumber(value); ok {
+				found.set(eye, c.field, v)
+			}
+		}
+	}
+
+	h.apply(r)
+	r.Eyes = found.list()
+	return r, nil
+}
+
+// --- XML ---
+
+type xmlFrame struct {
+	name  string
+	eye   string
+	field string
+	value string
+	text  strings.Builder
+	// Измерения без глаза: глаз может указываться соседним элементом <Eye>
+	pending []pendingValue
+}
+
+type pendingValue struct {
+	field string
+	value float64
+}
+
+func parseXML(data []byte) (*Report, error) {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	dec.CharsetReader = func(charset string, input io.Reader) (io.Reader, error) {
+		// Выгрузки в однобайтовых кодировках содержат только латиницу и числа
+		return input, nil
+	}
+
+	r := &Report{Format: FormatXML}
+	found := eyes{}
+	var h header
+	var stack []*xmlFrame
+	for {
+		tok, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("некорректный XML: %w", err)
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			f := &xmlFrame{name: t.Name.Local, field: measurementField(t.Name.Local)}
+			if e := eyeFromText(t.Name.Local); e != "" {
+				f.eye = e
+			}
+			for _, a := range t.Attr {
+				key := compactKey(a.Name.Local)
+				switch {
+				case eyeColumnKeys[key]:
+					if e := eyeFromText(a.Value); e != "" {
+						f.eye = e
+					}
+				case key == "type" || key == "name" || key == "parameter":
+					if field := measurementField(a.Value); field != "" && f.field == "" {
+						f.field = field
+					}
+				case key == "value":
+					f.value = a.Value
+				case len(stack) == 0:
+					h.setField(key, a.Value)
+				}
+			}
+			stack = append(stack, f)
+
+		case xml.CharData:
+			if len(stack) > 0 {
+				stack[len(stack)-1].text.Write(t)
+			}
+
+		case xml.EndElement:
+			if len(stack) == 0 {
+				continue
+			}
+			f := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			var parent *xmlFrame
+			if len(stack) > 0 {
+				parent = stack[len(stack)-1]
+			}
+
+			text := strings.TrimSpace(f.text.String())
+			if f.value == "" {
+				f.value = text
+			}
+			switch {
+			case f.field != "":
+				if v, ok := parseNumber(f.value); ok {
+					f.pending = append(f.pending, pendingValue{field: f.field, value: v})
+				}
+			case eyeColumnKeys[compactKey(f.name)]:
+				if parent != nil && parent.eye == "" {
+					parent.eye = eyeFromText(text)
+				}
+			default:
+				h.setField(compactKey(f.name), text)
+			}
+
+			// Глаз задан этим элементом — сохраняем, иначе передаём выше
+			for _, pv := range f.pending {
+				switch {
+				case f.eye != "":
+					found.set(f.eye, pv.field, pv.value)
+				case parent != nil:
+					parent.pending = append(parent.pending, pv)
+				}
+			}
+		}
+	}
+
+	h.apply(r)
+	r.Eyes = found.list()
+	return r, nil
+}
+
+// --- Текстовый протокол А-скана ---
+
+// Метка показателя, необязательное уточнение в скобках, значение и, для таблиц OD/OS, второе значение
+var textMeasurementRe = regexp.MustCompile(`(?i)(axial\s+length|anterior\s+chamber\s+depth|al|acd|k1|k2|пзо|гпк)\s*(?:\([^)]*\))?\s*[:=]?\s*(-?\d+(?:[.,]\d+)?)(?:\s*(?:mm|мм|d|дптр)?\s*[/|\s]\s*(-?\d+(?:[.,]\d+)?))?`)
+
+func parseText(data []byte) (*Report, error) {
+	r := &Report{Format: FormatText}
+	found := eyes{}
+	var h header
+
+	currentEye := ""
+	// Для таблиц с колонками OD и OS
+	var tableEyes []string
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		if key, value, ok := strings.Cut(line, ":"); ok && h.setField(compactKey(key), value) {
+			continue
+		}
+
+		matches := textMeasurementRe.FindAllStringSubmatchIndex(line, -1)
+		lineEyes := eyesInLine(line, matches)
+		switch {
+		case len(matches) == 0 && len(lineEyes) >= 2:
+			tableEyes = lineEyes
+			continue
+		case len(lineEyes) > 0:
+			currentEye, tableEyes = lineEyes[0], nil
+		}
+
+		for _, m := range matches {
+			if m[0] > 0 && isWordRune(line, m[0]) {
+				continue
+			}
+			field := measurementField(line[m[2]:m[3]])
+			first, _ := parseNumber(line[m[4]:m[5]])
+			if len(tableEyes) >= 2 && m[6] >= 0 {
+				second, _ := parseNumber(line[m[6]:m[7]])
+				found.set(tableEyes[0], field, first)
+				found.set(tableEyes[1], field, second)
+				continue
+			}
+			found.set(currentEye, field, first)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("не удалось прочитать протокол: %w", err)
+	}
+
+	h.apply(r)
+	r.Eyes = found.list()
+	return r, nil
+}
+
+// eyesInLine находит обозначения глаз вне найденных измерений
+func eyesInLine(line string, matches [][]int) []string {
+	rest := line
+	for i := len(matches) - 1; i >= 0; i-- {
+		rest = rest[:matches[i][0]] + " " + rest[matches[i][1]:]
+	}
+	var found []string
+	for _, t := range strings.FieldsFunc(rest, func(r rune) bool { return !unicode.IsLetter(r) }) {
+		if len([]rune(t)) < 2 {
+			continue
+		}
+		if e := eyeFromText(t); e != "" {
+			found = append(found, e)
+		}
+	}
+	return found
+}
+
+// isWordRune — символ перед позицией является буквой или цифрой (метка внутри слова)
+func isWordRune(s string, pos int) bool {
+	r := []rune(s[:pos])
+	last := r[len(r)-1]
+	return unicode.IsLetter(last) || unicode.IsDigit(last)
+}
@@ -5,18 +5,42 @@ import (
 
 	"github.com/beercut-team/backend-boilerplate/internal/config"
 	"github.com/beercut-team/backend-boilerplate/internal/domain"
+	"github.com/beercut-team/backend-boilerplate/pkg/dicom"
+	"github.com/beercut-team/backend-boilerplate/pkg/pii"
 	"github.com/rs/zerolog/log"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
 )
 
+// syncVersionFunctionSQL — выдача версий синхронизации (см. domain.SyncVersionFunction).
+// Нижняя граница берётся до nextval: pull сначала читает последовательность, затем блокировки,
+// поэтому транзакция либо уже видна в pg_locks, либо получит версию не ниже прочитанной.
+var syncVersionFunctionSQL = fmt.Sprintf(`
+CREATE OR REPLACE FUNCTION %[1]s() RETURNS BIGINT AS $$
+DECLARE
+    bound BIGINT;
+BEGIN
+    IF coalesce(current_setting('sync.xact_registered', true), '') = '' THEN
+        SELECT CASE WHEN is_called THEN last_value + 1 ELSE last_value END INTO bound FROM %[2]s;
+        PERFORM pg_advisory_xact_lock_shared((%[3]d::bigint << 48) | bound);
+        PERFORM set_config('sync.xact_registered', 'on', true);
+    END IF;
+    RETURN nextval('%[2]s');
+END;
+$$ LANGUAGE plpgsql`, domain.SyncVersionFunction, domain.SyncVersionSequence, domain.SyncVersionLockSpace)
+
 func NewPostgres(cfg *config.Config) (*gorm.DB, error) {
 	dsn := fmt.Sprintf(
 		"host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
 		cfg.DBHost, cfg.DBPort, cfg.DBUser, cfg.DBPassword, cfg.DBName, cfg.DBSSLMode,
 	)
 
+	// Шифр нужен до первого запроса: поля ПДн пациентов читаются и пишутся через него
+	if err := configurePII(cfg); err != nil {
+		return nil, fmt.Errorf("не удалось настроить шифрование ПДн: %w", err)
+	}
+
 	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
 		Logger: logger.Default.LogMode(logger.Silent),
 	})
@@ -26,26 +50,217 @@ func NewPostgres(cfg *config.Config) (*gorm.DB, error) {
 
 	log.Info().Msg("подключено к PostgreSQL")
 
+	// Последовательность версий для офлайн-синхронизации (используется хуками моделей)
+	if err := db.Exec("CREATE SEQUENCE IF NOT EXISTS " + domain.SyncVersionSequence).Error; err != nil {
+		return nil, fmt.Errorf("не удалось создать последовательность версий: %w", err)
+	}
+	if err := db.Exec(syncVersionFunctionSQL).Error; err != nil {
+		return nil, fmt.Errorf("не удалось создать функцию версий синхронизации: %w", err)
+	}
+
+	if err := dropLegacyChecklistTemplates(db); err != nil {
+		return nil, fmt.Errorf("не удалось обновить таблицу шаблонов чек-листов: %w", err)
+	}
+
+	queueMedia := needsMediaProcessing(db)
+	queueDICOM := needsDICOMProcessing(db)
+
 	if err := db.AutoMigrate(
 		&domain.User{},
 		&domain.District{},
 		&domain.AuditLog{},
+		&domain.OperationTypeModel{},
 		&domain.Patient{},
 		&domain.PatientStatusHistory{},
 		&domain.ChecklistTemplate{},
+		&domain.ChecklistTemplateItem{},
 		&domain.ChecklistItem{},
 		&domain.Media{},
+		&domain.EyeExam{},
+		&domain.IOLLens{},
 		&domain.IOLCalculation{},
+		&domain.OperatingRoom{},
 		&domain.Surgery{},
+		&domain.PostOpVisit{},
+		&domain.SurgeonWorkingHours{},
+		&domain.SurgeonTimeOff{},
 		&domain.Comment{},
 		&domain.Notification{},
 		&domain.TelegramBinding{},
 		&domain.TelegramLoginToken{},
 		&domain.SyncQueue{},
+		&domain.SyncTombstone{},
+		&domain.IntegrationOutbox{},
+		&domain.Consent{},
+		&domain.Session{},
+		&domain.RecoveryCode{},
+		&domain.Invitation{},
+		&domain.UserToken{},
 	); err != nil {
 		return nil, fmt.Errorf("не удалось выполнить миграцию: %w", err)
 	}
 
+	// Записи, созданные до появления версий, получают уникальную версию,
+	// чтобы курсор pull не пропускал их и не зацикливался
+	for _, table := range []string{"patients", "checklist_items", "comments"} {
+		if err := db.Exec("UPDATE " + table + " SET sync_version = nextval('" + domain.SyncVersionSequence + "') WHERE sync_version = 0").Error; err != nil {
+			return nil, fmt.Errorf("не удалось заполнить версии синхронизации: %w", err)
+		}
+	}
+
+	if err := createPatientSearchIndexes(db); err != nil {
+		return nil, fmt.Errorf("не удалось создать индексы поиска пациентов: %w", err)
+	}
+
+	if queueMedia {
+		if err := queueLegacyMedia(db); err != nil {
+			return nil, fmt.Errorf("не удалось поставить изображения в очередь обработки: %w", err)
+		}
+	}
+	if queueDICOM {
+		if err := queueLegacyDICOM(db); err != nil {
+			return nil, fmt.Errorf("не удалось поставить DICOM-файлы в очередь обработки: %w", err)
+		}
+	}
+
+	if err := seedOperationTypes(db); err != nil {
+		return nil, fmt.Errorf("не удалось заполнить справочник типов операций: %w", err)
+	}
+
+	if err := seedChecklistTemplates(db); err != nil {
+		return nil, fmt.Errorf("не удалось создать шаблоны чек-листов: %w", err)
+	}
+
 	log.Info().Msg("миграция базы данных завершена")
 	return db, nil
 }
+
+// configurePII создаёт шифр ПДн с ключами из окружения или файла
+func configurePII(cfg *config.Config) error {
+	var keys pii.KeyProvider
+	var err error
+	switch cfg.PIIKeyProvider {
+	case "env":
+		keys, err = pii.NewEnvKeyProvider(cfg.PIIKeys, cfg.PIIActiveKey, cfg.PIIIndexKey)
+	case "file":
+		keys, err = pii.NewFileKeyProvider(cfg.PIIKeyFile)
+	default:
+		err = fmt.Errorf("неизвестный провайдер ключей %q", cfg.PIIKeyProvider)
+	}
+	if err != nil {
+		return err
+	}
+
+	c, err := pii.NewCipher(keys)
+	if err != nil {
+		return err
+	}
+	pii.Configure(c)
+	log.Info().Str("provider", cfg.PIIKeyProvider).Str("active_key", keys.ActiveKeyID()).Msg("шифрование ПДн настроено")
+	return nil
+}
+
+// dropLegacyChecklistTemplates очищает таблицу шаблонов старого формата (строка на пункт),
+// иначе уникальный индекс по (operation_type, version) не создастся
+func dropLegacyChecklistTemplates(db *gorm.DB) error {
+	m := db.Migrator()
+	if !m.HasTable(&domain.ChecklistTemplate{}) || m.HasColumn(&domain.ChecklistTemplate{}, "Version") {
+		return nil
+	}
+	if err := db.Exec("UPDATE checklist_items SET template_id = NULL WHERE template_id IS NOT NULL").Error; err != nil {
+		return err
+	}
+	if err := db.Exec("DELETE FROM checklist_templates").Error; err != nil {
+		return err
+	}
+	for _, column := range []string{"category", "is_required", "expires_in_days", "sort_order"} {
+		if m.HasColumn(&domain.ChecklistTemplate{}, column) {
+			if err := m.DropColumn(&domain.ChecklistTemplate{}, column); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// createPatientSearchIndexes — триграммный индекс по ФИО для поиска с опечатками
+// и полнотекстовый по диагнозу; выражения совпадают с запросами репозитория
+func createPatientSearchIndexes(db *gorm.DB) error {
+	for _, sql := range []string{
+		"CREATE EXTENSION IF NOT EXISTS pg_trgm",
+		`CREATE INDEX IF NOT EXISTS idx_patients_name_trgm ON patients USING GIN
+			(translate(lower(last_name || ' ' || first_name || ' ' || coalesce(middle_name, '')), 'ё', 'е') gin_trgm_ops)`,
+		"CREATE INDEX IF NOT EXISTS idx_patients_diagnosis_fts ON patients USING GIN (to_tsvector('russian', coalesce(diagnosis, '')))",
+	} {
+		if err := db.Exec(sql).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// needsMediaProcessing — таблица медиа есть, но статуса обработки ещё нет
+func needsMediaProcessing(db *gorm.DB) bool {
+	m := db.Migrator()
+	return m.HasTable(&domain.Media{}) && !m.HasColumn(&domain.Media{}, "ProcessingStatus")
+}
+
+// queueLegacyMedia ставит ранее загруженные изображения в очередь обработки:
+// их миниатюры никогда не создавались, а пути к ним были вымышленными
+func queueLegacyMedia(db *gorm.DB) error {
+	return db.Model(&domain.Media{}).Where("content_type IN ?", []string{"image/jpeg", "image/png"}).
+		Updates(map[string]interface{}{"processing_status": domain.MediaStatusPending, "thumbnail_path": ""}).Error
+}
+
+// needsDICOMProcessing — таблица медиа есть, но сведений DICOM ещё нет
+func needsDICOMProcessing(db *gorm.DB) bool {
+	m := db.Migrator()
+	return m.HasTable(&domain.Media{}) && !m.HasColumn(&domain.Media{}, "DICOM")
+}
+
+// queueLegacyDICOM ставит в очередь DICOM-файлы, загруженные как двоичные данные.
+// Файлы, которые окажутся не DICOM, обработчик просто пометит готовыми.
+func queueLegacyDICOM(db *gorm.DB) error {
+	return db.Model(&domain.Media{}).
+		Where("content_type = ? OR LOWER(original_name) LIKE ?", dicom.ContentType, "%.dcm").
+		Update("processing_status", domain.MediaStatusPending).Error
+}
+
+// seedOperationTypes добавляет встроенные типы операций, которых нет в справочнике.
+// Изменённые администратором записи не трогает.
+func seedOperationTypes(db *gorm.DB) error {
+	for _, t := range domain.DefaultOperationTypes() {
+		var count int64
+		if err := db.Model(&domain.OperationTypeModel{}).Where("code = ?", t.Code).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			continue
+		}
+		if err := db.Create(&t).Error; err != nil {
+			return err
+		}
+		log.Info().Str("code", t.Code).Msg("добавлен тип операции по умолчанию")
+	}
+	return nil
+}
+
+// seedChecklistTemplates создаёт активную первую версию шаблона для типов операций без шаблонов
+func seedChecklistTemplates(db *gorm.DB) error {
+	for _, opType := range domain.BuiltinOperationTypes {
+		var count int64
+		if err := db.Model(&domain.ChecklistTemplate{}).Where("operation_type = ?", opType).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			continue
+		}
+		t := domain.DefaultChecklistTemplate(opType)
+		t.IsActive = true
+		if err := db.Create(t).Error; err != nil {
+			return err
+		}
+		log.Info().Str("operation_type", string(opType)).Int("items", len(t.Items)).Msg("создан шаблон чек-листа по умолчанию")
+	}
+	return nil
+}
@@ -0,0 +1,482 @@
+// Package dicom читает заголовки DICOM-файлов (Part 10) и строит превью первого кадра.
+// Поддерживаются явный и неявный VR, little/big endian, deflate и JPEG baseline.
+package dicom
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// ContentType — MIME-тип DICOM-файлов
+const ContentType = "application/dicom"
+
+const (
+	preambleLen     = 128
+	undefinedLength = 0xFFFFFFFF
+	maxDepth        = 16
+	// Распакованные элементы до пикселей при deflate; сами пиксели ограничены размером из заголовка
+	maxInflatedHeader = 1 << 20
+)
+
+const (
+	TransferImplicitLittle = "1.2.840.10008.1.2"
+	TransferExplicitLittle = "1.2.840.10008.1.2.1"
+	TransferDeflated       = "1.2.840.10008.1.2.1.99"
+	TransferExplicitBig    = "1.2.840.10008.1.2.2"
+	TransferJPEGBaseline   = "1.2.840.10008.1.2.4.50"
+	TransferJPEGExtended   = "1.2.840.10008.1.2.4.51"
+)
+
+var (
+	ErrNotDICOM  = errors.New("файл не является DICOM")
+	ErrTruncated = errors.New("DICOM-файл повреждён или обрезан")
+	ErrInflated  = errors.New("распакованный DICOM больше размера, описанного в заголовке")
+)
+
+// Tag — группа и элемент в одном числе: 0xGGGGEEEE
+type Tag uint32
+
+func NewTag(group, element uint16) Tag {
+	return Tag(uint32(group)<<16 | uint32(element))
+}
+
+func (t Tag) Group() uint16 { return uint16(t >> 16) }
+
+func (t Tag) String() string {
+	return fmt.Sprintf("(%04X,%04X)", uint16(t>>16), uint16(t))
+}
+
+const (
+	TagTransferSyntax       Tag = 0x00020010
+	TagSpecificCharset      Tag = 0x00080005
+	TagStudyDate            Tag = 0x00080020
+	TagModality             Tag = 0x00080060
+	TagManufacturer         Tag = 0x00080070
+	TagStudyDescription     Tag = 0x00081030
+	TagSeriesDescription    Tag = 0x0008103E
+	TagModelName            Tag = 0x00081090
+	TagPatientName          Tag = 0x00100010
+	TagPatientID            Tag = 0x00100020
+	TagPatientBirthDate     Tag = 0x00100030
+	TagLaterality           Tag = 0x00200060
+	TagImageLaterality      Tag = 0x00200062
+	TagSamplesPerPixel      Tag = 0x00280002
+	TagPhotometric          Tag = 0x00280004
+	TagPlanarConfiguration  Tag = 0x00280006
+	TagNumberOfFrames       Tag = 0x00280008
+	TagRows                 Tag = 0x00280010
+	TagColumns              Tag = 0x00280011
+	TagBitsAllocated        Tag = 0x00280100
+	TagBitsStored           Tag = 0x00280101
+	TagPixelRepresentation  Tag = 0x00280103
+	TagWindowCenter         Tag = 0x00281050
+	TagWindowWidth          Tag = 0x00281051
+	TagRescaleIntercept     Tag = 0x00281052
+	TagRescaleSlope         Tag = 0x00281053
+	TagPixelData            Tag = 0x7FE00010
+	tagItem                 Tag = 0xFFFEE000
+	tagItemDelimitation     Tag = 0xFFFEE00D
+	tagSequenceDelimitation Tag = 0xFFFEE0DD
+)
+
+// VR, у которых длина занимает 4 байта после двух зарезервированных
+var longVR = map[string]bool{
+	"OB": true, "OD": true, "OF": true, "OL": true, "OV": true, "OW": true,
+	"SQ": true, "SV": true, "UC": true, "UN": true, "UR": true, "UT": true, "UV": true,
+}
+
+// Неявный VR: двоичные поля, которые нужны для превью
+var implicitUS = map[Tag]bool{
+	TagSamplesPerPixel: true, TagPlanarConfiguration: true, TagRows: true, TagColumns: true,
+	TagBitsAllocated: true, TagBitsStored: true, TagPixelRepresentation: true,
+}
+
+type element struct {
+	vr    string
+	value []byte
+}
+
+// File — разобранный набор данных верхнего уровня; вложенные последовательности пропускаются
+type File struct {
+	TransferSyntax string
+
+	byteOrder binary.ByteOrder
+	elements  map[Tag]element
+	// Фрагменты инкапсулированных пикселей (JPEG и т. п.) без таблицы смещений
+	fragments [][]byte
+}
+
+// IsDICOM проверяет сигнатуру DICM после 128-байтной преамбулы
+func IsDICOM(head []byte) bool {
+	return len(head) >= preambleLen+4 && string(head[preambleLen:preambleLen+4]) == "DICM"
+}
+
+// Parse разбирает DICOM-файл целиком
+func Parse(data []byte) (*File, error) {
+	if !IsDICOM(data) {
+		return nil, ErrNotDICOM
+	}
+
+	f := &File{elements: map[Tag]element{}}
+	meta := &parser{data: data, pos: preambleLen + 4, bo: binary.LittleEndian, explicit: true, file: f}
+	// Метаинформация (группа 0002) всегда в явном VR little endian
+	for meta.pos+4 <= len(data) && binary.LittleEndian.Uint16(data[meta.pos:]) == 0x0002 {
+		if err := meta.element(0); err != nil {
+			return nil, err
+		}
+	}
+	f.TransferSyntax = f.String(TagTransferSyntax)
+
+	body := data[meta.pos:]
+	p := &parser{data: body, bo: binary.LittleEndian, explicit: true, file: f}
+	switch f.TransferSyntax {
+	case TransferImplicitLittle:
+		p.explicit = false
+	case TransferExplicitBig:
+		p.bo = binary.BigEndian
+	case TransferDeflated:
+		inflated, err := inflate(body)
+		if err != nil {
+			return nil, err
+		}
+		p.data = inflated
+	}
+	f.byteOrder = p.bo
+
+	for p.pos < len(p.data) {
+		if err := p.element(0); err != nil {
+			return nil, err
+		}
+		if _, ok := f.elements[TagPixelData]; ok || f.fragments != nil {
+			break
+		}
+	}
+	return f, nil
+}
+
+// inflate распаковывает набор данных не больше чем на maxInflatedHeader плюс размер кадров
+// rows×cols×samples×bytes×frames из уже распакованного заголовка — защита от deflate-бомб
+func inflate(body []byte) ([]byte, error) {
+	r := flate.NewReader(bytes.NewReader(body))
+	data, err := io.ReadAll(io.LimitReader(r, maxInflatedHeader))
+	if err != nil {
+		return nil, fmt.Errorf("не удалось распаковать DICOM: %w", err)
+	}
+	if len(data) < maxInflatedHeader {
+		return data, nil
+	}
+
+	limit := maxInflatedHeader + pixelDataSize(data)
+	rest, err := io.ReadAll(io.LimitReader(r, limit-int64(len(data))+1))
+	if err != nil {
+		return nil, fmt.Errorf("не удалось распаковать DICOM: %w", err)
+	}
+	if int64(len(data)+len(rest)) > limit {
+		return nil, ErrInflated
+	}
+	return append(data, rest...), nil
+}
+
+// pixelDataSize — размер пикселей по элементам, которые успели разобраться из начала набора данных
+func pixelDataSize(head []byte) int64 {
+	f := &File{elements: map[Tag]element{}, byteOrder: binary.LittleEndian}
+	p := &parser{data: head, bo: binary.LittleEndian, explicit: true, file: f}
+	// Пиксели обычно не помещаются в начало — разбор на них и останавливается
+	for p.pos < len(p.data) {
+		if err := p.element(0); err != nil {
+			break
+		}
+	}
+
+	rows, _ := f.Uint16(TagRows)
+	cols, _ := f.Uint16(TagColumns)
+	samples, ok := f.Uint16(TagSamplesPerPixel)
+	if !ok {
+		samples = 1
+	}
+	bits, _ := f.Uint16(TagBitsAllocated)
+	frames, ok := f.Int(TagNumberOfFrames)
+	if !ok || frames < 1 {
+		frames = 1
+	}
+	return int64(rows) * int64(cols) * int64(samples) * int64((bits+7)/8) * int64(frames)
+}
+
+type parser struct {
+	data     []byte
+	pos      int
+	bo       binary.ByteOrder
+	explicit bool
+	file     *File
+}
+
+func (p *parser) need(n int) error {
+	if n < 0 || p.pos+n > len(p.data) {
+		return ErrTruncated
+	}
+	return nil
+}
+
+func (p *parser) tag() (Tag, error) {
+	if err := p.need(4); err != nil {
+		return 0, err
+	}
+	t := NewTag(p.bo.Uint16(p.data[p.pos:]), p.bo.Uint16(p.data[p.pos+2:]))
+	p.pos += 4
+	return t, nil
+}
+
+func (p *parser) uint32() (uint32, error) {
+	if err := p.need(4); err != nil {
+		return 0, err
+	}
+	v := p.bo.Uint32(p.data[p.pos:])
+	p.pos += 4
+	return v, nil
+}
+
+// element читает один элемент; на верхнем уровне (depth 0) сохраняет значение
+func (p *parser) element(depth int) error {
+	if depth > maxDepth {
+		return ErrTruncated
+	}
+	tag, err := p.tag()
+	if err != nil {
+		return err
+	}
+
+	var vr string
+	var length uint32
+	if p.explicit {
+		if err := p.need(4); err != nil {
+			return err
+		}
+		vr = string(p.data[p.pos : p.pos+2])
+		if longVR[vr] {
+			p.pos += 4
+			if length, err = p.uint32(); err != nil {
+				return err
+			}
+		} else {
+			length = uint32(p.bo.Uint16(p.data[p.pos+2:]))
+			p.pos += 4
+		}
+	} else {
+		if length, err = p.uint32(); err != nil {
+			return err
+		}
+		if implicitUS[tag] {
+			vr = "US"
+		}
+	}
+
+	if length == undefinedLength {
+		if tag == TagPixelData && depth == 0 {
+			return p.encapsulated()
+		}
+		// Последовательность или UN неопределённой длины — пропускаем
+		return p.skipSequence(depth + 1)
+	}
+
+	if err := p.need(int(length)); err != nil {
+		return err
+	}
+	value := p.data[p.pos : p.pos+int(length)]
+	p.pos += int(length)
+
+	if vr == "SQ" {
+		// Последовательность определённой длины: содержимое не нужно
+		return nil
+	}
+	if depth == 0 {
+		p.file.elements[tag] = element{vr: vr, value: value}
+	}
+	return nil
+}
+
+// skipSequence пропускает элементы последовательности до разделителя
+func (p *parser) skipSequence(depth int) error {
+	for {
+		tag, err := p.tag()
+		if err != nil {
+			return err
+		}
+		length, err := p.uint32()
+		if err != nil {
+			return err
+		}
+		switch tag {
+		case tagSequenceDelimitation:
+			return nil
+		case tagItem:
+			if length != undefinedLength {
+				if err := p.need(int(length)); err != nil {
+					return err
+				}
+				p.pos += int(length)
+				continue
+			}
+			if err := p.skipItem(depth); err != nil {
+				return err
+			}
+		default:
+			return ErrTruncated
+		}
+	}
+}
+
+// skipItem пропускает элемент последовательности неопределённой длины
+func (p *parser) skipItem(depth int) error {
+	for {
+		if err := p.need(8); err != nil {
+			return err
+		}
+		next := NewTag(p.bo.Uint16(p.data[p.pos:]), p.bo.Uint16(p.data[p.pos+2:]))
+		if next == tagItemDelimitation {
+			p.pos += 8
+			return nil
+		}
+		if err := p.element(depth); err != nil {
+			return err
+		}
+	}
+}
+
+// encapsulated читает фрагменты сжатых пикселей; первый элемент — таблица смещений
+func (p *parser) encapsulated() error {
+	first := true
+	fragments := [][]byte{}
+	for {
+		tag, err := p.tag()
+		if err != nil {
+			return err
+		}
+		length, err := p.uint32()
+		if err != nil {
+			return err
+		}
+		if tag == tagSequenceDelimitation {
+			p.file.fragments = fragments
+			return nil
+		}
+		if tag != tagItem || length == undefinedLength {
+			return ErrTruncated
+		}
+		if err := p.need(int(length)); err != nil {
+			return err
+		}
+		if !first {
+			fragments = append(fragments, p.data[p.pos:p.pos+int(length)])
+		}
+		first = false
+		p.pos += int(length)
+	}
+}
+
+// String возвращает первое значение строкового элемента без завершающих пробелов
+func (f *File) String(tag Tag) string {
+	values := f.Strings(tag)
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+// Strings возвращает все значения элемента (разделитель «\»)
+func (f *File) Strings(tag Tag) []string {
+	el, ok := f.elements[tag]
+	if !ok || len(el.value) == 0 {
+		return nil
+	}
+	raw := strings.TrimRight(f.decode(el.value), " \x00")
+	if raw == "" {
+		return nil
+	}
+	parts := strings.Split(raw, `\`)
+	for i := range parts {
+		parts[i] = strings.TrimSpace(parts[i])
+	}
+	return parts
+}
+
+// Uint16 читает значение VR US
+func (f *File) Uint16(tag Tag) (int, bool) {
+	el, ok := f.elements[tag]
+	if !ok || len(el.value) < 2 {
+		return 0, false
+	}
+	return int(f.byteOrder.Uint16(el.value)), true
+}
+
+// Int читает целое, записанное строкой (VR IS)
+func (f *File) Int(tag Tag) (int, bool) {
+	v, err := strconv.Atoi(f.String(tag))
+	return v, err == nil
+}
+
+// Float читает десятичное число, записанное строкой (VR DS)
+func (f *File) Float(tag Tag) (float64, bool) {
+	v, err := strconv.ParseFloat(f.String(tag), 64)
+	return v, err == nil
+}
+
+// Date читает дату (VR DA, ГГГГММДД)
+func (f *File) Date(tag Tag) (time.Time, bool) {
+	s := f.String(tag)
+	if len(s) != 8 {
+		return time.Time{}, false
+	}
+	t, err := time.Parse("20060102", s)
+	return t, err == nil
+}
+
+// decode переводит строку в UTF-8 с учётом SpecificCharacterSet
+func (f *File) decode(b []byte) string {
+	charset := ""
+	if el, ok := f.elements[TagSpecificCharset]; ok {
+		charset = strings.ToUpper(string(el.value))
+	}
+	switch {
+	case strings.Contains(charset, "IR 144"), strings.Contains(charset, "IR144"):
+		return decodeISO88595(b)
+	case utf8.Valid(b):
+		return string(b)
+	default:
+		return decodeLatin1(b)
+	}
+}
+
+// decodeISO88595 — кириллица ISO-8859-5 (ISO_IR 144)
+func decodeISO88595(b []byte) string {
+	var sb strings.Builder
+	sb.Grow(len(b) * 2)
+	for _, c := range b {
+		switch {
+		case c < 0xA1 || c == 0xAD:
+			sb.WriteRune(rune(c))
+		case c == 0xF0:
+			sb.WriteRune('№')
+		case c == 0xFD:
+			sb.WriteRune('§')
+		default:
+			sb.WriteRune(rune(c) - 0xA0 + 0x400)
+		}
+	}
+	return sb.String()
+}
+
+func decodeLatin1(b []byte) string {
+	runes := make([]rune, len(b))
+	for i, c := range b {
+		runes[i] = rune(c)
+	}
+	return string(runes)
+}
@@ -0,0 +1,319 @@
+package dicom
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"testing"
+)
+
+// builder собирает DICOM-файлы для тестов
+type builder struct {
+	buf      bytes.Buffer
+	explicit bool
+}
+
+func newBuilder(transferSyntax string) *builder {
+	b := &builder{explicit: true}
+	b.buf.Write(make([]byte, preambleLen))
+	b.buf.WriteString("DICM")
+	b.element(TagTransferSyntax, "UI", padUID(transferSyntax))
+	b.explicit = transferSyntax != TransferImplicitLittle
+	return b
+}
+
+func padUID(s string) []byte {
+	if len(s)%2 == 1 {
+		return append([]byte(s), 0)
+	}
+	return []byte(s)
+}
+
+func (b *builder) tag(tag Tag) {
+	b.buf.Write(binary.LittleEndian.AppendUint16(nil, tag.Group()))
+	b.buf.Write(binary.LittleEndian.AppendUint16(nil, uint16(tag)))
+}
+
+func (b *builder) header(tag Tag, vr string, length uint32) {
+	le := binary.LittleEndian
+	b.tag(tag)
+	if !b.explicit {
+		b.buf.Write(le.AppendUint32(nil, length))
+		return
+	}
+	b.buf.WriteString(vr)
+	if longVR[vr] {
+		b.buf.Write([]byte{0, 0})
+		b.buf.Write(le.AppendUint32(nil, length))
+	} else {
+		b.buf.Write(le.AppendUint16(nil, uint16(length)))
+	}
+}
+
+func (b *builder) element(tag Tag, vr string, value []byte) *builder {
+	if len(value)%2 == 1 {
+		value = append(value, ' ')
+	}
+	b.header(tag, vr, uint32(len(value)))
+	b.buf.Write(value)
+	return b
+}
+
+func (b *builder) str(tag Tag, vr, value string) *builder {
+	return b.element(tag, vr, []byte(value))
+}
+
+func (b *builder) us(tag Tag, v uint16) *builder {
+	return b.element(tag, "US", binary.LittleEndian.AppendUint16(nil, v))
+}
+
+// sequence добавляет последовательность неопределённой длины с одним элементом
+func (b *builder) sequence(tag Tag) *builder {
+	le := binary.LittleEndian
+	b.header(tag, "SQ", undefinedLength)
+	b.tag(tagItem)
+	b.buf.Write(le.AppendUint32(nil, undefinedLength))
+	b.str(TagPatientName, "PN", "NESTED^NAME")
+	b.tag(tagItemDelimitation)
+	b.buf.Write(le.AppendUint32(nil, 0))
+	b.tag(tagSequenceDelimitation)
+	b.buf.Write(le.AppendUint32(nil, 0))
+	return b
+}
+
+func (b *builder) fragments(frags ...[]byte) *builder {
+	le := binary.LittleEndian
+	b.header(TagPixelData, "OB", undefinedLength)
+	// Пустая таблица смещений
+	b.tag(tagItem)
+	b.buf.Write(le.AppendUint32(nil, 0))
+	for _, f := range frags {
+		if len(f)%2 == 1 {
+			f = append(f, 0)
+		}
+		b.tag(tagItem)
+		b.buf.Write(le.AppendUint32(nil, uint32(len(f))))
+		b.buf.Write(f)
+	}
+	b.tag(tagSequenceDelimitation)
+	b.buf.Write(le.AppendUint32(nil, 0))
+	return b
+}
+
+func (b *builder) bytes() []byte {
+	return b.buf.Bytes()
+}
+
+func TestParseHeader(t *testing.T) {
+	// «ИВАНОВ^ИВАН» в ISO-8859-5
+	cyrillicName := []byte{0xB8, 0xB2, 0xB0, 0xBD, 0xBE, 0xB2, '^', 0xB8, 0xB2, 0xB0, 0xBD}
+
+	data := newBuilder(TransferExplicitLittle).
+		str(TagSpecificCharset, "CS", "ISO_IR 144").
+		str(TagStudyDate, "DA", "20260315").
+		str(TagModality, "CS", "OPT").
+		str(TagManufacturer, "LO", "Carl Zeiss Meditec").
+		str(TagSeriesDescription, "LO", "Macular Cube 512x128").
+		str(TagModelName, "LO", "Cirrus HD-OCT 5000").
+		element(TagPatientName, "PN", cyrillicName).
+		str(TagPatientID, "LO", "12345").
+		sequence(0x00400275).
+		str(TagImageLaterality, "CS", "R").
+		bytes()
+
+	f, err := Parse(data)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	info := f.Info()
+
+	if info.PatientName != "ИВАНОВ^ИВАН" {
+		t.Errorf("PatientName = %q", info.PatientName)
+	}
+	if info.Modality != "OPT" || info.Eye() != "OD" {
+		t.Errorf("modality %q, eye %q", info.Modality, info.Eye())
+	}
+	if info.StudyDate == nil || info.StudyDate.Format("2006-01-02") != "2026-03-15" {
+		t.Errorf("StudyDate = %v", info.StudyDate)
+	}
+	if info.Manufacturer != "Carl Zeiss Meditec" || info.Model != "Cirrus HD-OCT 5000" {
+		t.Errorf("device = %q %q", info.Manufacturer, info.Model)
+	}
+	if info.SeriesDescription != "Macular Cube 512x128" {
+		t.Errorf("SeriesDescription = %q", info.SeriesDescription)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	valid := newBuilder(TransferExplicitLittle).str(TagModality, "CS", "OP").bytes()
+
+	tests := []struct {
+		name string
+		data []byte
+		want error
+	}{
+		{"no signature", []byte("plain text"), ErrNotDICOM},
+		{"truncated value", valid[:len(valid)-1], ErrTruncated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Parse(tt.data); err != tt.want {
+				t.Errorf("err = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+// deflated собирает файл с deflate-сжатием: монохромный кадр rows×cols и хвост из padding байт
+func deflated(t *testing.T, rows, cols uint16, padding int) []byte {
+	body := &builder{explicit: true}
+	body.us(TagSamplesPerPixel, 1).
+		us(TagRows, rows).
+		us(TagColumns, cols).
+		us(TagBitsAllocated, 8).
+		element(TagPixelData, "OB", make([]byte, int(rows)*int(cols)))
+	if padding > 0 {
+		body.element(0xFFFCFFFC, "OB", make([]byte, padding))
+	}
+
+	out := bytes.NewBuffer(newBuilder(TransferDeflated).bytes())
+	w, err := flate.NewWriter(out, flate.BestSpeed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write(body.bytes())
+	w.Close()
+	return out.Bytes()
+}
+
+func TestParseDeflated(t *testing.T) {
+	tests := []struct {
+		name       string
+		rows, cols uint16
+		padding    int
+		want       error
+	}{
+		{"small", 2, 2, 0, nil},
+		{"frame larger than header budget", 1024, 1536, 0, nil},
+		{"within header budget", 2, 2, maxInflatedHeader / 2, nil},
+		{"beyond declared size", 2, 2, 4 * maxInflatedHeader, ErrInflated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := Parse(deflated(t, tt.rows, tt.cols, tt.padding))
+			if err != tt.want {
+				t.Fatalf("err = %v, want %v", err, tt.want)
+			}
+			if err != nil {
+				return
+			}
+			img, err := f.Image()
+			if err != nil {
+				t.Fatalf("Image: %v", err)
+			}
+			if b := img.Bounds(); b.Dx() != int(tt.cols) || b.Dy() != int(tt.rows) {
+				t.Errorf("size = %v", b)
+			}
+		})
+	}
+}
+
+func TestImageMonochrome16(t *testing.T) {
+	// 2x2, 12 бит в 16: значения 0, 1000, 2000, 4095
+	pixels := []uint16{0, 1000, 2000, 4095}
+	raw := make([]byte, 0, 8)
+	for _, p := range pixels {
+		raw = binary.LittleEndian.AppendUint16(raw, p)
+	}
+
+	tests := []struct {
+		name        string
+		photometric string
+		want        []uint8
+	}{
+		{"MONOCHROME2", "MONOCHROME2", []uint8{0, 62, 125, 255}},
+		{"MONOCHROME1 inverted", "MONOCHROME1", []uint8{255, 193, 130, 0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := newBuilder(TransferExplicitLittle).
+				us(TagSamplesPerPixel, 1).
+				str(TagPhotometric, "CS", tt.photometric).
+				us(TagRows, 2).us(TagColumns, 2).
+				us(TagBitsAllocated, 16).us(TagBitsStored, 12).us(TagPixelRepresentation, 0).
+				element(TagPixelData, "OW", raw).
+				bytes()
+
+			f, err := Parse(data)
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			img, err := f.Image()
+			if err != nil {
+				t.Fatalf("Image: %v", err)
+			}
+			gray, ok := img.(*image.Gray)
+			if !ok {
+				t.Fatalf("image type %T", img)
+			}
+			if !bytes.Equal(gray.Pix, tt.want) {
+				t.Errorf("pixels = %v, want %v", gray.Pix, tt.want)
+			}
+		})
+	}
+}
+
+func TestImageRGBImplicit(t *testing.T) {
+	data := newBuilder(TransferImplicitLittle).
+		us(TagSamplesPerPixel, 3).
+		str(TagPhotometric, "CS", "RGB").
+		us(TagPlanarConfiguration, 1).
+		us(TagRows, 1).us(TagColumns, 2).
+		us(TagBitsAllocated, 8).
+		element(TagPixelData, "OB", []byte{255, 0, 0, 128, 0, 64}).
+		bytes()
+
+	f, err := Parse(data)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	img, err := f.Image()
+	if err != nil {
+		t.Fatalf("Image: %v", err)
+	}
+	if got := img.At(0, 0); got != (color.RGBA{255, 0, 0, 255}) {
+		t.Errorf("pixel 0 = %v", got)
+	}
+	if got := img.At(1, 0); got != (color.RGBA{0, 128, 64, 255}) {
+		t.Errorf("pixel 1 = %v", got)
+	}
+}
+
+func TestImageJPEGBaseline(t *testing.T) {
+	src := image.NewGray(image.Rect(0, 0, 16, 8))
+	var enc bytes.Buffer
+	if err := jpeg.Encode(&enc, src, nil); err != nil {
+		t.Fatal(err)
+	}
+	half := enc.Len() / 2
+
+	data := newBuilder(TransferJPEGBaseline).
+		str(TagModality, "CS", "OP").
+		fragments(enc.Bytes()[:half], enc.Bytes()[half:]).
+		bytes()
+
+	f, err := Parse(data)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	img, err := f.Image()
+	if err != nil {
+		t.Fatalf("Image: %v", err)
+	}
+	if b := img.Bounds(); b.Dx() != 16 || b.Dy() != 8 {
+		t.Errorf("size = %v", b)
+	}
+}
@@ -0,0 +1,74 @@
+package dicom
+
+import (
+	"strings"
+	"time"
+)
+
+// Info — сведения из заголовка, нужные для привязки снимка
+type Info struct {
+	// PatientName в формате DICOM: Фамилия^Имя^Отчество
+	PatientName string
+	PatientID   string
+	BirthDate   *time.Time
+	StudyDate   *time.Time
+	Modality    string
+	// Laterality — R, L или B (оба глаза); пусто, если не указано
+	Laterality        string
+	Manufacturer      string
+	Model             string
+	StudyDescription  string
+	SeriesDescription string
+	Rows              int
+	Columns           int
+	Frames            int
+}
+
+// Info собирает основные поля заголовка
+func (f *File) Info() Info {
+	info := Info{
+		PatientName:       f.String(TagPatientName),
+		PatientID:         f.String(TagPatientID),
+		Modality:          strings.ToUpper(f.String(TagModality)),
+		Manufacturer:      f.String(TagManufacturer),
+		Model:             f.String(TagModelName),
+		StudyDescription:  f.String(TagStudyDescription),
+		SeriesDescription: f.String(TagSeriesDescription),
+		Frames:            1,
+	}
+	if t, ok := f.Date(TagPatientBirthDate); ok {
+		info.BirthDate = &t
+	}
+	if t, ok := f.Date(TagStudyDate); ok {
+		info.StudyDate = &t
+	}
+
+	// Латеральность снимка точнее латеральности серии
+	info.Laterality = strings.ToUpper(f.String(TagImageLaterality))
+	if info.Laterality == "" || info.Laterality == "U" {
+		info.Laterality = strings.ToUpper(f.String(TagLaterality))
+	}
+	if info.Laterality == "U" {
+		info.Laterality = ""
+	}
+
+	info.Rows, _ = f.Uint16(TagRows)
+	info.Columns, _ = f.Uint16(TagColumns)
+	if n, ok := f.Int(TagNumberOfFrames); ok && n > 0 {
+		info.Frames = n
+	}
+	return info
+}
+
+// Eye переводит латеральность DICOM в обозначение глаза: OD, OS, OU
+func (i Info) Eye() string {
+	switch i.Laterality {
+	case "R":
+		return "OD"
+	case "L":
+		return "OS"
+	case "B":
+		return "OU"
+	}
+	return ""
+}
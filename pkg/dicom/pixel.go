@@ -0,0 +1,139 @@
+package dicom
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"math"
+)
+
+var ErrUnsupportedPixelData = errors.New("формат пикселей DICOM не поддерживается")
+
+// Image возвращает первый кадр. Монохромные кадры приводятся к 8 битам
+// по окну из заголовка или по диапазону значений.
+func (f *File) Image() (image.Image, error) {
+	if f.fragments != nil {
+		return f.encapsulatedImage()
+	}
+	el, ok := f.elements[TagPixelData]
+	if !ok {
+		return nil, errors.New("в DICOM-файле нет изображения")
+	}
+
+	rows, _ := f.Uint16(TagRows)
+	cols, _ := f.Uint16(TagColumns)
+	samples, ok := f.Uint16(TagSamplesPerPixel)
+	if !ok {
+		samples = 1
+	}
+	bits, _ := f.Uint16(TagBitsAllocated)
+	if rows == 0 || cols == 0 || (bits != 8 && bits != 16) {
+		return nil, ErrUnsupportedPixelData
+	}
+
+	frameLen := rows * cols * samples * bits / 8
+	if len(el.value) < frameLen {
+		return nil, ErrTruncated
+	}
+	frame := el.value[:frameLen]
+
+	photometric := f.String(TagPhotometric)
+	switch {
+	case samples == 1 && (photometric == "MONOCHROME1" || photometric == "MONOCHROME2" || photometric == ""):
+		return f.monochrome(frame, rows, cols, bits, photometric == "MONOCHROME1"), nil
+	case samples == 3 && photometric == "RGB" && bits == 8:
+		planar, _ := f.Uint16(TagPlanarConfiguration)
+		return rgb(frame, rows, cols, planar == 1), nil
+	}
+	return nil, fmt.Errorf("%w: %s, %d канала", ErrUnsupportedPixelData, photometric, samples)
+}
+
+func (f *File) encapsulatedImage() (image.Image, error) {
+	if f.TransferSyntax != TransferJPEGBaseline && f.TransferSyntax != TransferJPEGExtended {
+		return nil, fmt.Errorf("%w: синтаксис передачи %s", ErrUnsupportedPixelData, f.TransferSyntax)
+	}
+	if len(f.fragments) == 0 {
+		return nil, ErrTruncated
+	}
+
+	// Однокадровый снимок может быть разбит на несколько фрагментов
+	data := f.fragments[0]
+	if frames, ok := f.Int(TagNumberOfFrames); !ok || frames <= 1 {
+		data = bytes.Join(f.fragments, nil)
+	}
+	img, err := jpeg.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("не удалось декодировать JPEG в DICOM: %w", err)
+	}
+	return img, nil
+}
+
+func (f *File) monochrome(frame []byte, rows, cols, bits int, invert bool) *image.Gray {
+	stored, ok := f.Uint16(TagBitsStored)
+	if !ok || stored == 0 || stored > bits {
+		stored = bits
+	}
+	signed, _ := f.Uint16(TagPixelRepresentation)
+	slope, ok := f.Float(TagRescaleSlope)
+	if !ok || slope == 0 {
+		slope = 1
+	}
+	intercept, _ := f.Float(TagRescaleIntercept)
+
+	values := make([]float64, rows*cols)
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for i := range values {
+		var raw int
+		if bits == 8 {
+			raw = int(frame[i])
+		} else {
+			raw = int(f.byteOrder.Uint16(frame[i*2:]))
+		}
+		raw &= 1<<stored - 1
+		if signed == 1 && raw&(1<<(stored-1)) != 0 {
+			raw -= 1 << stored
+		}
+		v := float64(raw)*slope + intercept
+		values[i] = v
+		lo, hi = math.Min(lo, v), math.Max(hi, v)
+	}
+
+	// Окно из заголовка точнее, чем полный диапазон значений
+	if center, ok := f.Float(TagWindowCenter); ok {
+		if width, ok := f.Float(TagWindowWidth); ok && width > 1 {
+			lo, hi = center-0.5-(width-1)/2, center-0.5+(width-1)/2
+		}
+	}
+
+	img := image.NewGray(image.Rect(0, 0, cols, rows))
+	span := hi - lo
+	for i, v := range values {
+		var g float64
+		if span > 0 {
+			g = math.Max(0, math.Min(255, (v-lo)/span*255))
+		}
+		if invert {
+			g = 255 - g
+		}
+		img.Pix[i] = uint8(g + 0.5)
+	}
+	return img
+}
+
+func rgb(frame []byte, rows, cols int, planar bool) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, cols, rows))
+	n := rows * cols
+	for i := 0; i < n; i++ {
+		var c color.RGBA
+		if planar {
+			c = color.RGBA{frame[i], frame[n+i], frame[2*n+i], 0xff}
+		} else {
+			c = color.RGBA{frame[i*3], frame[i*3+1], frame[i*3+2], 0xff}
+		}
+		img.SetRGBA(i%cols, i/cols, c)
+	}
+	return img
+}
@@ -0,0 +1,177 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+)
+
+const (
+	tagGPSIFD      = 0x8825
+	ifdEntrySize   = 12
+	maxIFDsInChain = 8
+)
+
+var (
+	ErrInvalidJPEG = errors.New("повреждённый JPEG")
+	ErrInvalidPNG  = errors.New("повреждённый PNG")
+
+	exifHeader   = []byte("Exif\x00\x00")
+	pngSignature = []byte("\x89PNG\r\n\x1a\n")
+)
+
+// Размеры типов TIFF в байтах, индекс — код типа
+var tiffTypeSize = [...]int{0, 1, 1, 2, 4, 8, 1, 1, 2, 4, 8, 4, 8}
+
+// StripJPEGGPS затирает GPS-блок в EXIF (APP1). Остальные метаданные,
+// в том числе ориентация, сохраняются. Исходный срез не изменяется.
+func StripJPEGGPS(data []byte) ([]byte, bool, error) {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return nil, false, ErrInvalidJPEG
+	}
+
+	out := data
+	modified := false
+	for i := 2; i+4 <= len(data); {
+		if data[i] != 0xFF {
+			return nil, false, ErrInvalidJPEG
+		}
+		marker := data[i+1]
+		switch {
+		case marker == 0xFF:
+			// Байт-заполнитель
+			i++
+			continue
+		case marker == 0xD8 || marker == 0x01 || (marker >= 0xD0 && marker <= 0xD7):
+			i += 2
+			continue
+		case marker == 0xD9 || marker == 0xDA:
+			// Дальше идут сжатые данные — метаданных там нет
+			return out, modified, nil
+		}
+
+		length := int(binary.BigEndian.Uint16(data[i+2:]))
+		end := i + 2 + length
+		if length < 2 || end > len(data) {
+			return nil, false, ErrInvalidJPEG
+		}
+		if marker == 0xE1 && bytes.HasPrefix(data[i+4:end], exifHeader) {
+			if !modified {
+				out = bytes.Clone(data)
+			}
+			if stripTIFFGPS(out[i+4+len(exifHeader) : end]) {
+				modified = true
+			}
+		}
+		i = end
+	}
+	return out, modified, nil
+}
+
+// StripPNGGPS затирает GPS-блок в чанке eXIf и пересчитывает его CRC
+func StripPNGGPS(data []byte) ([]byte, bool, error) {
+	if !bytes.HasPrefix(data, pngSignature) {
+		return nil, false, ErrInvalidPNG
+	}
+
+	out := data
+	modified := false
+	for i := len(pngSignature); i+8 <= len(data); {
+		length := int(binary.BigEndian.Uint32(data[i:]))
+		chunkType := string(data[i+4 : i+8])
+		end := i + 8 + length + 4
+		if end > len(data) {
+			return nil, false, ErrInvalidPNG
+		}
+		if chunkType == "eXIf" {
+			if !modified {
+				out = bytes.Clone(data)
+			}
+			if stripTIFFGPS(out[i+8 : i+8+length]) {
+				modified = true
+				binary.BigEndian.PutUint32(out[i+8+length:], crc32.ChecksumIEEE(out[i+4:i+8+length]))
+			}
+		}
+		if chunkType == "IEND" {
+			break
+		}
+		i = end
+	}
+	return out, modified, nil
+}
+
+// stripTIFFGPS обнуляет все записи GPS IFD и их данные на месте.
+// Указатель на GPS IFD остаётся, но указывает на пустой каталог.
+func stripTIFFGPS(tiff []byte) bool {
+	if len(tiff) < 8 {
+		return false
+	}
+	var bo binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		bo = binary.LittleEndian
+	case "MM":
+		bo = binary.BigEndian
+	default:
+		return false
+	}
+	if bo.Uint16(tiff[2:]) != 42 {
+		return false
+	}
+
+	stripped := false
+	offset := int(bo.Uint32(tiff[4:]))
+	for n := 0; offset != 0 && n < maxIFDsInChain; n++ {
+		entries, next, ok := readIFD(tiff, bo, offset)
+		if !ok {
+			break
+		}
+		for e := 0; e < entries; e++ {
+			entry := tiff[offset+2+e*ifdEntrySize:]
+			if bo.Uint16(entry) == tagGPSIFD && clearIFD(tiff, bo, int(bo.Uint32(entry[8:]))) {
+				stripped = true
+			}
+		}
+		offset = next
+	}
+	return stripped
+}
+
+func readIFD(tiff []byte, bo binary.ByteOrder, offset int) (entries, next int, ok bool) {
+	if offset < 8 || offset+2 > len(tiff) {
+		return 0, 0, false
+	}
+	entries = int(bo.Uint16(tiff[offset:]))
+	end := offset + 2 + entries*ifdEntrySize
+	if end+4 > len(tiff) {
+		return 0, 0, false
+	}
+	return entries, int(bo.Uint32(tiff[end:])), true
+}
+
+// clearIFD затирает данные каталога, вынесенные за пределы записей, затем сами записи
+func clearIFD(tiff []byte, bo binary.ByteOrder, offset int) bool {
+	entries, _, ok := readIFD(tiff, bo, offset)
+	if !ok {
+		return false
+	}
+	for e := 0; e < entries; e++ {
+		entry := tiff[offset+2+e*ifdEntrySize:]
+		typ, count := int(bo.Uint16(entry[2:])), int64(bo.Uint32(entry[4:]))
+		if typ <= 0 || typ >= len(tiffTypeSize) {
+			continue
+		}
+		size := int64(tiffTypeSize[typ]) * count
+		if size <= 4 {
+			continue
+		}
+		start := int64(bo.Uint32(entry[8:]))
+		if start >= 8 && start+size <= int64(len(tiff)) {
+			clear(tiff[start : start+size])
+		}
+	}
+	// Количество записей, записи и ссылка на следующий каталог
+	clear(tiff[offset : offset+2+entries*ifdEntrySize+4])
+	return entries > 0
+}
@@ -0,0 +1,139 @@
+// Package imaging обрабатывает загруженные изображения: определяет реальный тип,
+// удаляет геоданные из EXIF и строит миниатюры без внешних зависимостей.
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	_ "image/png"
+	"net/http"
+	"strings"
+)
+
+const (
+	// ThumbnailSize — максимальная сторона миниатюры в пикселях
+	ThumbnailSize = 256
+	// MaxPixels ограничивает размер декодируемого изображения (защита от «бомб»)
+	MaxPixels = 60_000_000
+
+	// SniffLen — сколько первых байт достаточно для DetectContentType
+	SniffLen = 512
+
+	thumbnailQuality = 80
+)
+
+var ErrUnsupported = errors.New("формат изображения не поддерживается")
+
+// Result — итог обработки файла
+type Result struct {
+	ContentType string
+	// Data — оригинал без геоданных; совпадает с исходными данными, если Modified == false
+	Data     []byte
+	Modified bool
+	Width    int
+	Height   int
+	// Thumbnail — миниатюра в JPEG
+	Thumbnail []byte
+}
+
+// DetectContentType определяет тип по содержимому, а не по заголовку клиента
+func DetectContentType(head []byte) string {
+	if len(head) > SniffLen {
+		head = head[:SniffLen]
+	}
+	return http.DetectContentType(head)
+}
+
+// IsSupported сообщает, умеет ли пакет обрабатывать такой тип
+func IsSupported(contentType string) bool {
+	ct := strings.TrimSpace(strings.SplitN(contentType, ";", 2)[0])
+	return ct == "image/jpeg" || ct == "image/png"
+}
+
+// Process удаляет GPS из метаданных, определяет размеры и строит миниатюру
+func Process(data []byte) (*Result, error) {
+	res := &Result{ContentType: DetectContentType(data), Data: data}
+
+	var err error
+	switch res.ContentType {
+	case "image/jpeg":
+		res.Data, res.Modified, err = StripJPEGGPS(data)
+	case "image/png":
+		res.Data, res.Modified, err = StripPNGGPS(data)
+	default:
+		return nil, ErrUnsupported
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(res.Data))
+	if err != nil {
+		return nil, fmt.Errorf("не удалось прочитать изображение: %w", err)
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width*cfg.Height > MaxPixels {
+		return nil, fmt.Errorf("недопустимый размер изображения %dx%d", cfg.Width, cfg.Height)
+	}
+	res.Width, res.Height = cfg.Width, cfg.Height
+
+	img, _, err := image.Decode(bytes.NewReader(res.Data))
+	if err != nil {
+		return nil, fmt.Errorf("не удалось декодировать изображение: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, Thumbnail(img, ThumbnailSize), &jpeg.Options{Quality: thumbnailQuality}); err != nil {
+		return nil, fmt.Errorf("не удалось сохранить миниатюру: %w", err)
+	}
+	res.Thumbnail = buf.Bytes()
+	return res, nil
+}
+
+// Thumbnail уменьшает изображение так, чтобы большая сторона не превышала maxSide.
+// Пиксели усредняются по площади, прозрачность накладывается на белый фон.
+func Thumbnail(src image.Image, maxSide int) *image.RGBA {
+	b := src.Bounds()
+	sw, sh := b.Dx(), b.Dy()
+	dw, dh := sw, sh
+	if sw > maxSide || sh > maxSide {
+		if sw >= sh {
+			dw, dh = maxSide, max(1, sh*maxSide/sw)
+		} else {
+			dw, dh = max(1, sw*maxSide/sh), maxSide
+		}
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		y0, y1 := b.Min.Y+y*sh/dh, b.Min.Y+(y+1)*sh/dh
+		if y1 == y0 {
+			y1++
+		}
+		for x := 0; x < dw; x++ {
+			x0, x1 := b.Min.X+x*sw/dw, b.Min.X+(x+1)*sw/dw
+			if x1 == x0 {
+				x1++
+			}
+
+			var r, g, bl, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					pr, pg, pb, pa := src.At(sx, sy).RGBA()
+					r, g, bl, a = r+uint64(pr), g+uint64(pg), bl+uint64(pb), a+uint64(pa)
+					n++
+				}
+			}
+			// Цвета премультиплицированы: добавляем белый в долю прозрачности
+			white := n*0xffff - a
+			i := dst.PixOffset(x, y)
+			dst.Pix[i+0] = uint8(((r + white) / n) >> 8)
+			dst.Pix[i+1] = uint8(((g + white) / n) >> 8)
+			dst.Pix[i+2] = uint8(((bl + white) / n) >> 8)
+			dst.Pix[i+3] = 0xff
+		}
+	}
+	return dst
+}
@@ -0,0 +1,226 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+// testTIFF собирает EXIF с ориентацией и GPS-координатами (55°45'N)
+func testTIFF() []byte {
+	bo := binary.LittleEndian
+	buf := make([]byte, 92)
+	copy(buf, "II")
+	bo.PutUint16(buf[2:], 42)
+	bo.PutUint32(buf[4:], 8)
+
+	// IFD0: ориентация и указатель на GPS IFD
+	bo.PutUint16(buf[8:], 2)
+	putEntry(buf[10:], 0x0112, 3, 1, 6)
+	putEntry(buf[22:], tagGPSIFD, 4, 1, 38)
+
+	// GPS IFD: LatitudeRef "N" и Latitude из трёх RATIONAL, вынесенных за каталог
+	bo.PutUint16(buf[38:], 2)
+	putEntry(buf[40:], 0x0001, 2, 2, uint32('N'))
+	putEntry(buf[52:], 0x0002, 5, 3, 68)
+	for i, v := range []uint32{55, 1, 45, 1, 0, 1} {
+		bo.PutUint32(buf[68+i*4:], v)
+	}
+	return buf
+}
+
+func putEntry(b []byte, tag, typ uint16, count, value uint32) {
+	binary.LittleEndian.PutUint16(b, tag)
+	binary.LittleEndian.PutUint16(b[2:], typ)
+	binary.LittleEndian.PutUint32(b[4:], count)
+	binary.LittleEndian.PutUint32(b[8:], value)
+}
+
+func testImage(w, h int) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, color.RGBA{uint8(x), uint8(y), 128, 255})
+		}
+	}
+	return img
+}
+
+func jpegWithEXIF(t *testing.T, w, h int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, testImage(w, h), nil); err != nil {
+		t.Fatal(err)
+	}
+	payload := append(append([]byte{}, exifHeader...), testTIFF()...)
+	app1 := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(app1[2:], uint16(len(payload)+2))
+	app1 = append(app1, payload...)
+
+	data := buf.Bytes()
+	return append(append(append([]byte{}, data[:2]...), app1...), data[2:]...)
+}
+
+func pngWithEXIF(t *testing.T, w, h int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, testImage(w, h)); err != nil {
+		t.Fatal(err)
+	}
+	tiff := testTIFF()
+	chunk := make([]byte, 8, 12+len(tiff))
+	binary.BigEndian.PutUint32(chunk, uint32(len(tiff)))
+	copy(chunk[4:], "eXIf")
+	chunk = append(chunk, tiff...)
+	chunk = binary.BigEndian.AppendUint32(chunk, crc32.ChecksumIEEE(chunk[4:]))
+
+	// Сразу после IHDR: сигнатура (8) + чанк IHDR (25)
+	data := buf.Bytes()
+	return append(append(append([]byte{}, data[:33]...), chunk...), data[33:]...)
+}
+
+func assertGPSStripped(t *testing.T, tiff []byte) {
+	t.Helper()
+	bo := binary.LittleEndian
+	if got := bo.Uint16(tiff[10+8:]); got != 6 {
+		t.Errorf("orientation = %d, want 6", got)
+	}
+	if got := bo.Uint16(tiff[38:]); got != 0 {
+		t.Errorf("GPS IFD entries = %d, want 0", got)
+	}
+	if !bytes.Equal(tiff[38:92], make([]byte, 54)) {
+		t.Error("GPS data not cleared")
+	}
+}
+
+func TestStripJPEGGPS(t *testing.T) {
+	src := jpegWithEXIF(t, 40, 30)
+	orig := bytes.Clone(src)
+
+	out, modified, err := StripJPEGGPS(src)
+	if err != nil {
+		t.Fatalf("StripJPEGGPS: %v", err)
+	}
+	if !modified {
+		t.Fatal("GPS not reported as removed")
+	}
+	if !bytes.Equal(src, orig) {
+		t.Error("source slice modified")
+	}
+	if len(out) != len(src) {
+		t.Fatalf("length changed: %d -> %d", len(src), len(out))
+	}
+	assertGPSStripped(t, out[2+4+len(exifHeader):])
+
+	if _, err := jpeg.Decode(bytes.NewReader(out)); err != nil {
+		t.Errorf("stripped JPEG does not decode: %v", err)
+	}
+
+	// Повторная обработка ничего не меняет
+	if _, modified, _ := StripJPEGGPS(out); modified {
+		t.Error("second pass reported changes")
+	}
+}
+
+func TestStripPNGGPS(t *testing.T) {
+	src := pngWithEXIF(t, 20, 20)
+
+	out, modified, err := StripPNGGPS(src)
+	if err != nil {
+		t.Fatalf("StripPNGGPS: %v", err)
+	}
+	if !modified {
+		t.Fatal("GPS not reported as removed")
+	}
+	assertGPSStripped(t, out[33+8:])
+
+	// png.Decode проверяет CRC всех чанков
+	if _, err := png.Decode(bytes.NewReader(out)); err != nil {
+		t.Errorf("stripped PNG does not decode: %v", err)
+	}
+}
+
+func TestStripInvalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		strip func([]byte) ([]byte, bool, error)
+		data  []byte
+	}{
+		{"jpeg without SOI", StripJPEGGPS, []byte("not a jpeg")},
+		{"jpeg truncated segment", StripJPEGGPS, []byte{0xFF, 0xD8, 0xFF, 0xE1, 0x10, 0x00, 'E'}},
+		{"png without signature", StripPNGGPS, []byte("not a png")},
+		{"png truncated chunk", StripPNGGPS, append(append([]byte{}, pngSignature...), 0, 0, 1, 0, 'I', 'H', 'D', 'R')},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := tt.strip(tt.data); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
+func TestProcess(t *testing.T) {
+	tests := []struct {
+		name         string
+		data         []byte
+		wantType     string
+		wantW, wantH int
+		wantThumbW   int
+		wantThumbH   int
+	}{
+		{"jpeg landscape", jpegWithEXIF(t, 600, 300), "image/jpeg", 600, 300, 256, 128},
+		{"png portrait", pngWithEXIF(t, 100, 400), "image/png", 100, 400, 64, 256},
+		{"small image is not upscaled", pngWithEXIF(t, 50, 40), "image/png", 50, 40, 50, 40},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := Process(tt.data)
+			if err != nil {
+				t.Fatalf("Process: %v", err)
+			}
+			if res.ContentType != tt.wantType {
+				t.Errorf("content type = %q, want %q", res.ContentType, tt.wantType)
+			}
+			if !res.Modified {
+				t.Error("GPS not removed")
+			}
+			if res.Width != tt.wantW || res.Height != tt.wantH {
+				t.Errorf("size = %dx%d, want %dx%d", res.Width, res.Height, tt.wantW, tt.wantH)
+			}
+
+			cfg, err := jpeg.DecodeConfig(bytes.NewReader(res.Thumbnail))
+			if err != nil {
+				t.Fatalf("thumbnail is not JPEG: %v", err)
+			}
+			if cfg.Width != tt.wantThumbW || cfg.Height != tt.wantThumbH {
+				t.Errorf("thumbnail = %dx%d, want %dx%d", cfg.Width, cfg.Height, tt.wantThumbW, tt.wantThumbH)
+			}
+		})
+	}
+}
+
+func TestProcessRejectsNonImages(t *testing.T) {
+	// Клиент может прислать PDF с заголовком image/jpeg — тип определяется по содержимому
+	if _, err := Process([]byte("%PDF-1.4\n%âãÏÓ\n1 0 obj")); err != ErrUnsupported {
+		t.Errorf("err = %v, want ErrUnsupported", err)
+	}
+	if IsSupported(DetectContentType([]byte("%PDF-1.4"))) {
+		t.Error("PDF reported as supported image")
+	}
+}
+
+func TestThumbnailTransparency(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 4, 4))
+	thumb := Thumbnail(src, 2)
+	if got := thumb.RGBAAt(0, 0); got != (color.RGBA{255, 255, 255, 255}) {
+		t.Errorf("transparent pixel = %v, want white", got)
+	}
+}
@@ -0,0 +1,104 @@
+package integrations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"time"
+)
+
+const (
+	SystemEMIAS = "emias"
+	SystemRIAMS = "riams"
+)
+
+// IntegrationClient — транспорт во внешнюю медицинскую систему (ЕМИАС, РИАМС)
+type IntegrationClient interface {
+	System() string
+	ExportPatient(ctx context.Context, p PatientPayload) (*Result, error)
+	CreateCase(ctx context.Context, c CasePayload) (*Result, error)
+}
+
+type PatientPayload struct {
+	IdempotencyKey string `json:"-"`
+	LocalID        uint   `json:"local_id"`
+	ExternalID     string `json:"external_id,omitempty"`
+	LastName       string `json:"last_name"`
+	FirstName      string `json:"first_name"`
+	MiddleName     string `json:"middle_name,omitempty"`
+	BirthDate      string `json:"birth_date"`
+	Gender         string `json:"gender,omitempty"`
+	SNILS          string `json:"snils,omitempty"`
+	OMSPolicy      string `json:"oms_policy,omitempty"`
+	RegionCode     string `json:"region_code,omitempty"`
+}
+
+type CasePayload struct {
+	IdempotencyKey    string `json:"-"`
+	LocalPatientID    uint   `json:"local_patient_id"`
+	PatientExternalID string `json:"patient_id"`
+	DiagnosisCode     string `json:"diagnosis_code,omitempty"`
+	ProcedureCode     string `json:"procedure_code,omitempty"`
+	SurgeryDate       string `json:"surgery_date,omitempty"`
+}
+
+type Result struct {
+	ExternalID string `json:"id"`
+}
+
+// Error — ошибка внешней системы. Retryable=false означает, что повтор не поможет (4xx)
+type Error struct {
+	System     string
+	StatusCode int
+	Message    string
+	Retryable  bool
+}
+
+func (e *Error) Error() string {
+	if e.StatusCode != 0 {
+		return fmt.Sprintf("%s: HTTP %d: %s", e.System, e.StatusCode, e.Message)
+	}
+	return fmt.Sprintf("%s: %s", e.System, e.Message)
+}
+
+// ErrNotSupported возвращается, если операция не поддерживается системой
+var ErrNotSupported = errors.New("операция не поддерживается внешней системой")
+
+// IsRetryable сообщает, имеет ли смысл повторить запрос
+func IsRetryable(err error) bool {
+	if err == nil || errors.Is(err, ErrNotSupported) {
+		return false
+	}
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Retryable
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	// Неизвестные ошибки (обрыв соединения, таймаут контекста) считаем временными
+	return true
+}
+
+const (
+	backoffBase = 30 * time.Second
+	backoffMax  = 6 * time.Hour
+)
+
+// Backoff возвращает задержку перед повтором: 30с, 1м, 2м, 4м... до 6ч, с джиттером до 10%
+func Backoff(attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+	d := backoffBase
+	for i := 1; i < attempt && d < backoffMax; i++ {
+		d *= 2
+	}
+	if d > backoffMax {
+		d = backoffMax
+	}
+	return d + time.Duration(rand.Int63n(int64(d)/10+1))
+}
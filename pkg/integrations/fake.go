@@ -0,0 +1,111 @@
+package integrations
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// FakeServer — локальный сервер, имитирующий API ЕМИАС/РИАМС.
+// Используется в тестах и при запуске без настроенных адресов интеграций.
+type FakeServer struct {
+	server *httptest.Server
+
+	mu       sync.Mutex
+	failures []int             // коды ответов для ближайших запросов
+	issued   map[string]string // Idempotency-Key -> выданный id
+	requests int
+}
+
+func NewFakeServer() *FakeServer {
+	f := &FakeServer{issued: map[string]string{}}
+	f.server = httptest.NewServer(http.HandlerFunc(f.handle))
+	return f
+}
+
+func (f *FakeServer) URL() string {
+	return f.server.URL
+}
+
+func (f *FakeServer) Close() {
+	f.server.Close()
+}
+
+// FailNext заставляет следующие n запросов завершиться с указанным кодом
+func (f *FakeServer) FailNext(n, status int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i := 0; i < n; i++ {
+		f.failures = append(f.failures, status)
+	}
+}
+
+// Requests возвращает количество полученных запросов
+func (f *FakeServer) Requests() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.requests
+}
+
+func (f *FakeServer) handle(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	f.requests++
+	var fail int
+	if len(f.failures) > 0 {
+		fail, f.failures = f.failures[0], f.failures[1:]
+	}
+	f.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	if fail != 0 {
+		w.WriteHeader(fail)
+		json.NewEncoder(w).Encode(map[string]string{"error": http.StatusText(fail)})
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	var prefix string
+	switch {
+	case r.URL.Path == "/api/v1/patients":
+		prefix = "EMIAS"
+	case r.URL.Path == "/api/v1/cases":
+		prefix = "CASE"
+	case strings.HasPrefix(r.URL.Path, "/api/v1/regions/") && strings.HasSuffix(r.URL.Path, "/patients"):
+		region := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/v1/regions/"), "/patients")
+		prefix = "RIAMS-" + region
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "not found"})
+		return
+	}
+
+	var body map[string]interface{}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "invalid json"})
+		return
+	}
+
+	// Повтор с тем же ключом возвращает тот же id
+	key := r.Header.Get("Idempotency-Key")
+	f.mu.Lock()
+	id, ok := f.issued[key]
+	if !ok || key == "" {
+		id = fmt.Sprintf("%s-%s", prefix, uuid.New().String()[:8])
+		if key != "" {
+			f.issued[key] = id
+		}
+	}
+	f.mu.Unlock()
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(Result{ExternalID: id})
+}
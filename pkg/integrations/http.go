@@ -0,0 +1,132 @@
+package integrations
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+type httpClient struct {
+	system  string
+	baseURL string
+	token   string
+	client  *http.Client
+}
+
+// NewEMIASClient создаёт HTTP-клиент ЕМИАС
+func NewEMIASClient(baseURL, token string, timeout time.Duration) IntegrationClient {
+	return newHTTPClient(SystemEMIAS, baseURL, token, timeout)
+}
+
+// NewRIAMSClient создаёт HTTP-клиент РИАМС
+func NewRIAMSClient(baseURL, token string, timeout time.Duration) IntegrationClient {
+	return &riamsClient{newHTTPClient(SystemRIAMS, baseURL, token, timeout)}
+}
+
+func newHTTPClient(system, baseURL, token string, timeout time.Duration) *httpClient {
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	return &httpClient{
+		system:  system,
+		baseURL: strings.TrimRight(baseURL, "/"),
+		token:   token,
+		client:  &http.Client{Timeout: timeout},
+	}
+}
+
+func (c *httpClient) System() string {
+	return c.system
+}
+
+func (c *httpClient) ExportPatient(ctx context.Context, p PatientPayload) (*Result, error) {
+	return c.post(ctx, "/api/v1/patients", p.IdempotencyKey, p)
+}
+
+func (c *httpClient) CreateCase(ctx context.Context, cs CasePayload) (*Result, error) {
+	return c.post(ctx, "/api/v1/cases", cs.IdempotencyKey, cs)
+}
+
+func (c *httpClient) post(ctx context.Context, path, idempotencyKey string, body interface{}) (*Result, error) {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, &Error{System: c.system, Message: "не удалось сериализовать запрос: " + err.Error()}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+path, bytes.NewReader(payload))
+	if err != nil {
+		return nil, &Error{System: c.system, Message: err.Error()}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	if idempotencyKey != "" {
+		req.Header.Set("Idempotency-Key", idempotencyKey)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, &Error{
+			System:     c.system,
+			StatusCode: resp.StatusCode,
+			Message:    errorMessage(data),
+			Retryable:  resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusRequestTimeout,
+		}
+	}
+
+	var result Result
+	if err := json.Unmarshal(data, &result); err != nil || result.ExternalID == "" {
+		return nil, &Error{System: c.system, StatusCode: resp.StatusCode, Message: "некорректный ответ внешней системы", Retryable: true}
+	}
+	return &result, nil
+}
+
+func errorMessage(data []byte) string {
+	var body struct {
+		Error   string `json:"error"`
+		Message string `json:"message"`
+	}
+	if json.Unmarshal(data, &body) == nil {
+		if body.Error != "" {
+			return body.Error
+		}
+		if body.Message != "" {
+			return body.Message
+		}
+	}
+	msg := strings.TrimSpace(string(data))
+	if len(msg) > 200 {
+		msg = msg[:200]
+	}
+	return msg
+}
+
+// riamsClient — РИАМС принимает пациентов по региону и не поддерживает случаи
+type riamsClient struct {
+	*httpClient
+}
+
+func (c *riamsClient) ExportPatient(ctx context.Context, p PatientPayload) (*Result, error) {
+	if p.RegionCode == "" {
+		return nil, &Error{System: c.system, Message: "не указан код региона"}
+	}
+	return c.post(ctx, fmt.Sprintf("/api/v1/regions/%s/patients", url.PathEscape(p.RegionCode)), p.IdempotencyKey, p)
+}
+
+func (c *riamsClient) CreateCase(ctx context.Context, cs CasePayload) (*Result, error) {
+	return nil, ErrNotSupported
+}
@@ -0,0 +1,123 @@
+package integrations
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEMIASClientExportPatient(t *testing.T) {
+	fake := NewFakeServer()
+	defer fake.Close()
+
+	client := NewEMIASClient(fake.URL(), "token", time.Second)
+	p := PatientPayload{IdempotencyKey: "job-1", LocalID: 1, LastName: "Петров", FirstName: "Иван", BirthDate: "1955-03-14"}
+
+	res, err := client.ExportPatient(context.Background(), p)
+	if err != nil {
+		t.Fatalf("ExportPatient: %v", err)
+	}
+	if !strings.HasPrefix(res.ExternalID, "EMIAS-") {
+		t.Errorf("unexpected external id %q", res.ExternalID)
+	}
+
+	// Повтор с тем же ключом идемпотентности возвращает тот же id
+	again, err := client.ExportPatient(context.Background(), p)
+	if err != nil {
+		t.Fatalf("ExportPatient retry: %v", err)
+	}
+	if again.ExternalID != res.ExternalID {
+		t.Errorf("idempotent retry returned %q, want %q", again.ExternalID, res.ExternalID)
+	}
+}
+
+func TestClientErrorsClassification(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    int
+		retryable bool
+	}{
+		{"server error", http.StatusInternalServerError, true},
+		{"unavailable", http.StatusServiceUnavailable, true},
+		{"rate limited", http.StatusTooManyRequests, true},
+		{"bad request", http.StatusBadRequest, false},
+		{"unauthorized", http.StatusUnauthorized, false},
+	}
+
+	fake := NewFakeServer()
+	defer fake.Close()
+	client := NewEMIASClient(fake.URL(), "", time.Second)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake.FailNext(1, tt.status)
+			_, err := client.CreateCase(context.Background(), CasePayload{PatientExternalID: "EMIAS-1"})
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			var e *Error
+			if !errors.As(err, &e) || e.StatusCode != tt.status {
+				t.Fatalf("expected *Error with status %d, got %v", tt.status, err)
+			}
+			if IsRetryable(err) != tt.retryable {
+				t.Errorf("IsRetryable = %v, want %v", IsRetryable(err), tt.retryable)
+			}
+		})
+	}
+}
+
+func TestRIAMSClient(t *testing.T) {
+	fake := NewFakeServer()
+	defer fake.Close()
+	client := NewRIAMSClient(fake.URL(), "", time.Second)
+
+	res, err := client.ExportPatient(context.Background(), PatientPayload{LocalID: 1, RegionCode: "77"})
+	if err != nil {
+		t.Fatalf("ExportPatient: %v", err)
+	}
+	if !strings.HasPrefix(res.ExternalID, "RIAMS-77-") {
+		t.Errorf("unexpected external id %q", res.ExternalID)
+	}
+
+	if _, err := client.ExportPatient(context.Background(), PatientPayload{LocalID: 1}); err == nil || IsRetryable(err) {
+		t.Errorf("missing region should be a permanent error, got %v", err)
+	}
+	if _, err := client.CreateCase(context.Background(), CasePayload{}); !errors.Is(err, ErrNotSupported) || IsRetryable(err) {
+		t.Errorf("CreateCase should be unsupported, got %v", err)
+	}
+}
+
+func TestUnreachableServerIsRetryable(t *testing.T) {
+	fake := NewFakeServer()
+	url := fake.URL()
+	fake.Close()
+
+	client := NewEMIASClient(url, "", time.Second)
+	_, err := client.ExportPatient(context.Background(), PatientPayload{LocalID: 1})
+	if err == nil || !IsRetryable(err) {
+		t.Errorf("connection error should be retryable, got %v", err)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		attempt int
+		min     time.Duration
+	}{
+		{0, 30 * time.Second},
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{3, 2 * time.Minute},
+		{6, 16 * time.Minute},
+		{50, 6 * time.Hour},
+	}
+	for _, tt := range tests {
+		d := Backoff(tt.attempt)
+		if d < tt.min || d > tt.min+tt.min/10 {
+			t.Errorf("Backoff(%d) = %v, want in [%v, %v]", tt.attempt, d, tt.min, tt.min+tt.min/10)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package jwtkeys
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+)
+
+// JWK — открытый ключ в формате RFC 7517
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// OKP (Ed25519)
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JWKS — набор открытых ключей для /.well-known/jwks.json
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+var b64 = base64.RawURLEncoding
+
+// JWKS — открытые ключи набора; секреты HS256 не публикуются
+func (k *Keyring) JWKS() JWKS {
+	set := JWKS{Keys: []JWK{}}
+	for _, key := range k.keys {
+		switch pub := key.verify.(type) {
+		case *rsa.PublicKey:
+			set.Keys = append(set.Keys, JWK{
+				Kty: "RSA", Kid: key.ID, Alg: key.Method.Alg(), Use: "sig",
+				N: b64.EncodeToString(pub.N.Bytes()),
+				E: b64.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+			})
+		case ed25519.PublicKey:
+			set.Keys = append(set.Keys, JWK{
+				Kty: "OKP", Kid: key.ID, Alg: key.Method.Alg(), Use: "sig",
+				Crv: "Ed25519", X: b64.EncodeToString(pub),
+			})
+		}
+	}
+	sort.Slice(set.Keys, func(i, j int) bool { return set.Keys[i].Kid < set.Keys[j].Kid })
+	return set
+}
+
+// ParseJWKS — набор только для проверки из опубликованного JWKS
+func ParseJWKS(data []byte) (*Keyring, error) {
+	var set JWKS
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("неверный формат JWKS: %w", err)
+	}
+	keys := make([]*Key, 0, len(set.Keys))
+	for _, j := range set.Keys {
+		key, err := j.key()
+		if err != nil {
+			return nil, fmt.Errorf("ключ %s: %w", j.Kid, err)
+		}
+		keys = append(keys, key)
+	}
+	return NewKeyring("", keys...)
+}
+
+func (j JWK) key() (*Key, error) {
+	switch j.Kty {
+	case "RSA":
+		n, err := b64.DecodeString(j.N)
+		if err != nil {
+			return nil, errors.New("неверный модуль RSA")
+		}
+		e, err := b64.DecodeString(j.E)
+		if err != nil || len(e) == 0 || len(e) > 4 {
+			return nil, errors.New("неверная экспонента RSA")
+		}
+		pub := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+		return newAsymmetricKey(j.Kid, "RS256", pub)
+	case "OKP":
+		x, err := b64.DecodeString(j.X)
+		if err != nil || j.Crv != "Ed25519" || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("неверный ключ Ed25519")
+		}
+		return newAsymmetricKey(j.Kid, "EdDSA", ed25519.PublicKey(x))
+	}
+	return nil, fmt.Errorf("неподдерживаемый тип ключа %q", j.Kty)
+}
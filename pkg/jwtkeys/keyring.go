@@ -0,0 +1,245 @@
+// Package jwtkeys — ключи подписи JWT с идентификаторами (kid) для ротации.
+// Токен подписывается активным ключом, проверяется любым ключом из набора;
+// открытые ключи RS256/EdDSA публикуются в JWKS, чтобы другие сервисы
+// проверяли токены без секрета.
+package jwtkeys
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// ErrUnknownKey — токен подписан ключом, которого нет в наборе
+var ErrUnknownKey = errors.New("неизвестный ключ подписи")
+
+// Key — ключ подписи; без закрытой части годится только для проверки
+type Key struct {
+	ID     string
+	Method jwt.SigningMethod
+	sign   interface{}
+	verify interface{}
+}
+
+// CanSign — есть закрытый ключ или секрет
+func (k *Key) CanSign() bool { return k.sign != nil }
+
+// Keyring — набор ключей и активный ключ подписи
+type Keyring struct {
+	active string
+	keys   map[string]*Key
+}
+
+// NewKeyring собирает набор; active пустой — набор только для проверки
+func NewKeyring(active string, keys ...*Key) (*Keyring, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("не заданы ключи подписи JWT")
+	}
+	k := &Keyring{active: active, keys: make(map[string]*Key, len(keys))}
+	for _, key := range keys {
+		if key.ID == "" {
+			return nil, errors.New("у ключа подписи нет идентификатора")
+		}
+		if _, dup := k.keys[key.ID]; dup {
+			return nil, fmt.Errorf("ключ %q задан дважды", key.ID)
+		}
+		k.keys[key.ID] = key
+	}
+	if active != "" {
+		key, ok := k.keys[active]
+		if !ok {
+			return nil, fmt.Errorf("активный ключ %q не найден среди ключей", active)
+		}
+		if !key.CanSign() {
+			return nil, fmt.Errorf("у активного ключа %q нет закрытой части", active)
+		}
+	}
+	return k, nil
+}
+
+// ActiveKeyID — ключ, которым подписываются новые токены
+func (k *Keyring) ActiveKeyID() string { return k.active }
+
+// Sign подписывает claims активным ключом и ставит kid в заголовок
+func (k *Keyring) Sign(claims jwt.Claims) (string, error) {
+	if k.active == "" {
+		return "", errors.New("набор ключей только для проверки")
+	}
+	key := k.keys[k.active]
+	token := jwt.NewWithClaims(key.Method, claims)
+	token.Header["kid"] = key.ID
+	return token.SignedString(key.sign)
+}
+
+// Keyfunc для jwt.Parse: ключ по kid, алгоритм токена должен совпадать
+// с алгоритмом ключа — иначе открытый RSA-ключ можно подсунуть как секрет HS256
+func (k *Keyring) Keyfunc(t *jwt.Token) (interface{}, error) {
+	kid, _ := t.Header["kid"].(string)
+	key, ok := k.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownKey, kid)
+	}
+	if t.Method.Alg() != key.Method.Alg() {
+		return nil, fmt.Errorf("ключ %q не для алгоритма %s", kid, t.Method.Alg())
+	}
+	return key.verify, nil
+}
+
+// Verify проверяет подпись и срок токена — для сервисов, которым нужны только claims
+func (k *Keyring) Verify(tokenStr string) (jwt.MapClaims, error) {
+	claims := jwt.MapClaims{}
+	if _, err := jwt.ParseWithClaims(tokenStr, claims, k.Keyfunc); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// keyFile — формат файла ключей:
+//
+//	{"active": "2026-10", "keys": [
+//	  {"kid": "2026-10", "alg": "EdDSA", "private_key_file": "/run/secrets/jwt-2026-10.pem"},
+//	  {"kid": "2026-04", "alg": "RS256", "public_key": "-----BEGIN PUBLIC KEY-----..."},
+//	  {"kid": "hs-1", "alg": "HS256", "secret": "base64"}
+//	]}
+//
+// Выведенный из ротации асимметричный ключ можно оставить только открытой частью.
+type keyFile struct {
+	Active string     `json:"active"`
+	Keys   []keyEntry `json:"keys"`
+}
+
+type keyEntry struct {
+	ID             string `json:"kid"`
+	Alg            string `json:"alg"`
+	Secret         string `json:"secret"`
+	PrivateKey     string `json:"private_key"`
+	PrivateKeyFile string `json:"private_key_file"`
+	PublicKey      string `json:"public_key"`
+}
+
+// LoadFile читает набор ключей из JSON-файла (например, смонтированного секрета)
+func LoadFile(path string) (*Keyring, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось прочитать файл ключей JWT: %w", err)
+	}
+	var f keyFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("неверный формат файла ключей JWT: %w", err)
+	}
+
+	keys := make([]*Key, 0, len(f.Keys))
+	for _, e := range f.Keys {
+		key, err := e.parse()
+		if err != nil {
+			return nil, fmt.Errorf("ключ %s: %w", e.ID, err)
+		}
+		keys = append(keys, key)
+	}
+	return NewKeyring(f.Active, keys...)
+}
+
+func (e keyEntry) parse() (*Key, error) {
+	switch e.Alg {
+	case "HS256":
+		secret, err := base64.StdEncoding.DecodeString(e.Secret)
+		if err != nil {
+			return nil, errors.New("секрет HS256: ожидается base64")
+		}
+		return NewHMACKey(e.ID, secret)
+	case "RS256", "EdDSA":
+	default:
+		return nil, fmt.Errorf("неподдерживаемый алгоритм %q", e.Alg)
+	}
+
+	privatePEM := e.PrivateKey
+	if e.PrivateKeyFile != "" {
+		data, err := os.ReadFile(e.PrivateKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("не удалось прочитать закрытый ключ: %w", err)
+		}
+		privatePEM = string(data)
+	}
+	if privatePEM != "" {
+		private, err := parsePrivateKey(privatePEM)
+		if err != nil {
+			return nil, err
+		}
+		return newAsymmetricKey(e.ID, e.Alg, private)
+	}
+	if e.PublicKey == "" {
+		return nil, errors.New("не задан ни закрытый, ни открытый ключ")
+	}
+	public, err := parsePublicKey(e.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	return newAsymmetricKey(e.ID, e.Alg, public)
+}
+
+// NewHMACKey — симметричный ключ HS256: проверить им токен может только владелец секрета
+func NewHMACKey(id string, secret []byte) (*Key, error) {
+	if len(secret) < 32 {
+		return nil, fmt.Errorf("секрет HS256 %d байт, нужно не меньше 32", len(secret))
+	}
+	return &Key{ID: id, Method: jwt.SigningMethodHS256, sign: secret, verify: secret}, nil
+}
+
+// newAsymmetricKey принимает закрытый или открытый ключ RSA/Ed25519
+func newAsymmetricKey(id, alg string, k interface{}) (*Key, error) {
+	switch alg {
+	case "RS256":
+		switch v := k.(type) {
+		case *rsa.PrivateKey:
+			if v.N.BitLen() < 2048 {
+				return nil, errors.New("ключ RSA короче 2048 бит")
+			}
+			return &Key{ID: id, Method: jwt.SigningMethodRS256, sign: v, verify: &v.PublicKey}, nil
+		case *rsa.PublicKey:
+			return &Key{ID: id, Method: jwt.SigningMethodRS256, verify: v}, nil
+		}
+	case "EdDSA":
+		switch v := k.(type) {
+		case ed25519.PrivateKey:
+			return &Key{ID: id, Method: jwt.SigningMethodEdDSA, sign: v, verify: v.Public()}, nil
+		case ed25519.PublicKey:
+			return &Key{ID: id, Method: jwt.SigningMethodEdDSA, verify: v}, nil
+		}
+	}
+	return nil, fmt.Errorf("ключ не подходит для алгоритма %s", alg)
+}
+
+func parsePrivateKey(data string) (crypto.PrivateKey, error) {
+	block, _ := pem.Decode([]byte(data))
+	if block == nil {
+		return nil, errors.New("закрытый ключ: ожидается PEM")
+	}
+	if k, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		return k, nil
+	}
+	if k, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return k, nil
+	}
+	return nil, errors.New("закрытый ключ: ожидается PKCS#8 или PKCS#1")
+}
+
+func parsePublicKey(data string) (crypto.PublicKey, error) {
+	block, _ := pem.Decode([]byte(data))
+	if block == nil {
+		return nil, errors.New("открытый ключ: ожидается PEM")
+	}
+	k, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("открытый ключ: %w", err)
+	}
+	return k, nil
+}
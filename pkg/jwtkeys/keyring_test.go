@@ -0,0 +1,183 @@
+package jwtkeys
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func testClaims() jwt.MapClaims {
+	return jwt.MapClaims{"user_id": 7, "exp": time.Now().Add(time.Minute).Unix()}
+}
+
+func mustKeyring(t *testing.T, active string, keys ...*Key) *Keyring {
+	t.Helper()
+	k, err := NewKeyring(active, keys...)
+	if err != nil {
+		t.Fatalf("keyring: %v", err)
+	}
+	return k
+}
+
+func testKeys(t *testing.T) (hs, rs, ed *Key) {
+	t.Helper()
+	hs, err := NewHMACKey("hs", []byte(strings.Repeat("s", 32)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rs, err = newAsymmetricKey("rs", "RS256", rsaKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ed, err = newAsymmetricKey("ed", "EdDSA", edKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return hs, rs, ed
+}
+
+func TestSignVerifyRotation(t *testing.T) {
+	hs, rs, ed := testKeys(t)
+
+	for _, active := range []string{"hs", "rs", "ed"} {
+		signer := mustKeyring(t, active, hs, rs, ed)
+		token, err := signer.Sign(testClaims())
+		if err != nil {
+			t.Fatalf("%s: sign: %v", active, err)
+		}
+		// После ротации старый ключ остаётся для проверки
+		rotated := mustKeyring(t, "ed", hs, rs, ed)
+		claims, err := rotated.Verify(token)
+		if err != nil {
+			t.Fatalf("%s: verify: %v", active, err)
+		}
+		if claims["user_id"].(float64) != 7 {
+			t.Errorf("%s: claims = %v", active, claims)
+		}
+	}
+
+	token, _ := mustKeyring(t, "rs", rs).Sign(testClaims())
+	if _, err := mustKeyring(t, "ed", ed).Verify(token); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("removed key: err = %v, want ErrUnknownKey", err)
+	}
+}
+
+func TestAlgorithmConfusion(t *testing.T) {
+	_, rs, _ := testKeys(t)
+	verifier := mustKeyring(t, "", &Key{ID: "rs", Method: rs.Method, verify: rs.verify})
+
+	// HS256, подписанный DER открытого ключа RSA с kid RSA-ключа
+	der, _ := x509.MarshalPKIXPublicKey(rs.verify)
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, testClaims())
+	forged.Header["kid"] = "rs"
+	token, err := forged.SignedString(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := verifier.Verify(token); err == nil {
+		t.Error("HS256 token accepted for RSA key")
+	}
+
+	if _, err := verifier.Sign(testClaims()); err == nil {
+		t.Error("verify-only keyring must not sign")
+	}
+}
+
+func TestJWKSRoundTrip(t *testing.T) {
+	hs, rs, ed := testKeys(t)
+	signer := mustKeyring(t, "ed", hs, rs, ed)
+
+	set := signer.JWKS()
+	if len(set.Keys) != 2 {
+		t.Fatalf("JWKS keys = %d, want 2 (HS256 secret must not be published)", len(set.Keys))
+	}
+	data, _ := json.Marshal(set)
+	verifier, err := ParseJWKS(data)
+	if err != nil {
+		t.Fatalf("ParseJWKS: %v", err)
+	}
+
+	for _, active := range []string{"rs", "ed"} {
+		token, _ := mustKeyring(t, active, hs, rs, ed).Sign(testClaims())
+		if _, err := verifier.Verify(token); err != nil {
+			t.Errorf("%s: verify via JWKS: %v", active, err)
+		}
+	}
+	token, _ := mustKeyring(t, "hs", hs).Sign(testClaims())
+	if _, err := verifier.Verify(token); err == nil {
+		t.Error("HS256 token verified without the secret")
+	}
+}
+
+func TestLoadFile(t *testing.T) {
+	_, edKey, _ := ed25519.GenerateKey(rand.Reader)
+	der, _ := x509.MarshalPKCS8PrivateKey(edKey)
+	dir := t.TempDir()
+	keyPath := filepath.Join(dir, "ed.pem")
+	os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600)
+
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	pubDER, _ := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+
+	file := map[string]interface{}{
+		"active": "2026-10",
+		"keys": []map[string]string{
+			{"kid": "2026-10", "alg": "EdDSA", "private_key_file": keyPath},
+			{"kid": "2026-04", "alg": "RS256", "public_key": string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDER}))},
+			{"kid": "hs-1", "alg": "HS256", "secret": base64.StdEncoding.EncodeToString([]byte(strings.Repeat("k", 32)))},
+		},
+	}
+	data, _ := json.Marshal(file)
+	path := filepath.Join(dir, "keys.json")
+	os.WriteFile(path, data, 0o600)
+
+	k, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile: %v", err)
+	}
+	token, err := k.Sign(testClaims())
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	if _, err := k.Verify(token); err != nil {
+		t.Errorf("verify: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		file string
+	}{
+		{"active is public only", `{"active":"rs","keys":[{"kid":"rs","alg":"RS256","public_key":"` + strings.ReplaceAll(string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDER})), "\n", `\n`) + `"}]}`},
+		{"unknown active", `{"active":"x","keys":[{"kid":"hs","alg":"HS256","secret":"` + base64.StdEncoding.EncodeToString([]byte(strings.Repeat("k", 32))) + `"}]}`},
+		{"short secret", `{"active":"hs","keys":[{"kid":"hs","alg":"HS256","secret":"c2hvcnQ="}]}`},
+		{"unsupported alg", `{"active":"x","keys":[{"kid":"x","alg":"none"}]}`},
+		{"no keys", `{"active":"","keys":[]}`},
+	}
+	for _, tt := range tests {
+		p := filepath.Join(dir, "bad.json")
+		os.WriteFile(p, []byte(tt.file), 0o600)
+		if _, err := LoadFile(p); err == nil {
+			t.Errorf("%s: expected error", tt.name)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+// Package mail — отправка писем сотрудникам: приглашения, сброс пароля,
+// подтверждение адреса. SMTP в рабочем окружении, без него — MemorySender.
+package mail
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Message — текстовое письмо одному получателю
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Sender — транспорт писем
+type Sender interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// build собирает письмо RFC 5322: тема кодируется для кириллицы, тело — UTF-8
+func build(from string, msg Message, now time.Time) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: 8bit\r\n\r\n")
+	b.WriteString(strings.ReplaceAll(strings.ReplaceAll(msg.Body, "\r\n", "\n"), "\n", "\r\n"))
+	return []byte(b.String())
+}
+
+// MemorySender — письма остаются в памяти и пишутся в лог: для разработки
+// без почтового сервера и для тестов
+type MemorySender struct {
+	mu       sync.Mutex
+	messages []Message
+}
+
+func NewMemorySender() *MemorySender {
+	return &MemorySender{}
+}
+
+func (s *MemorySender) Send(_ context.Context, msg Message) error {
+	s.mu.Lock()
+	s.messages = append(s.messages, msg)
+	s.mu.Unlock()
+	// Ссылки из писем нужны при локальной разработке, поэтому тело — в debug
+	log.Info().Str("to", msg.To).Str("subject", msg.Subject).Msg("письмо не отправлено: SMTP не настроен")
+	log.Debug().Str("to", msg.To).Str("body", msg.Body).Msg("текст письма")
+	return nil
+}
+
+// Messages — отправленные письма по порядку
+func (s *MemorySender) Messages() []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Message(nil), s.messages...)
+}
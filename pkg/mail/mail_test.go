@@ -0,0 +1,112 @@
+package mail
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuild(t *testing.T) {
+	msg := build("noreply@clinic.ru", Message{To: "doc@clinic.ru", Subject: "Приглашение", Body: "строка 1\nстрока 2"}, time.Unix(0, 0).UTC())
+	s := string(msg)
+	for _, want := range []string{
+		"From: noreply@clinic.ru\r\n",
+		"To: doc@clinic.ru\r\n",
+		"Subject: =?utf-8?q?",
+		"Content-Type: text/plain; charset=utf-8\r\n",
+		"\r\n\r\nстрока 1\r\nстрока 2",
+	} {
+		if !strings.Contains(s, want) {
+			t.Errorf("message missing %q:\n%s", want, s)
+		}
+	}
+}
+
+// fakeSMTP — минимальный SMTP-сервер без TLS и авторизации; возвращает текст DATA
+func fakeSMTP(t *testing.T) (port string, data <-chan string) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	out := make(chan string, 1)
+
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		reply := func(s string) { conn.Write([]byte(s + "\r\n")) }
+		reply("220 fake")
+		var body strings.Builder
+		inData := false
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			if inData {
+				if line == ".\r\n" {
+					inData = false
+					out <- body.String()
+					reply("250 ok")
+					continue
+				}
+				body.WriteString(line)
+				continue
+			}
+			switch cmd := strings.ToUpper(strings.TrimSpace(line)); {
+			case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+				reply("250 fake")
+			case cmd == "DATA":
+				inData = true
+				reply("354 go ahead")
+			case cmd == "QUIT":
+				reply("221 bye")
+				return
+			default:
+				reply("250 ok")
+			}
+		}
+	}()
+
+	_, port, _ = net.SplitHostPort(ln.Addr().String())
+	return port, out
+}
+
+func TestSMTPSender(t *testing.T) {
+	port, data := fakeSMTP(t)
+	s := NewSMTPSender("127.0.0.1", port, "", "", "noreply@clinic.ru", 5*time.Second)
+
+	if err := s.Send(context.Background(), Message{To: "doc@clinic.ru", Subject: "Сброс пароля", Body: "ссылка"}); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	select {
+	case got := <-data:
+		if !strings.Contains(got, "To: doc@clinic.ru") || !strings.Contains(got, "ссылка") {
+			t.Errorf("unexpected message:\n%s", got)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("message not received")
+	}
+
+	if err := s.Send(context.Background(), Message{To: "a@b.ru\r\nBcc: x@y.ru", Subject: "x"}); err == nil {
+		t.Error("header injection accepted")
+	}
+}
+
+func TestMemorySender(t *testing.T) {
+	s := NewMemorySender()
+	s.Send(context.Background(), Message{To: "a@b.ru", Subject: "1"})
+	s.Send(context.Background(), Message{To: "c@d.ru", Subject: "2"})
+	msgs := s.Messages()
+	if len(msgs) != 2 || msgs[1].To != "c@d.ru" {
+		t.Errorf("Messages() = %+v", msgs)
+	}
+}
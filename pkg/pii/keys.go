@@ -0,0 +1,120 @@
+package pii
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// KeyProvider — источник ключей шифрования персональных данных
+type KeyProvider interface {
+	// ActiveKeyID — ключ, которым шифруются новые значения
+	ActiveKeyID() string
+	// Key — ключ по идентификатору; старые ключи нужны для расшифровки до ротации
+	Key(id string) ([]byte, error)
+	// IndexKey — ключ HMAC для слепых индексов
+	IndexKey() []byte
+}
+
+// ErrUnknownKey — значение зашифровано ключом, которого нет у провайдера
+var ErrUnknownKey = errors.New("неизвестный ключ шифрования ПДн")
+
+type staticKeys struct {
+	active string
+	keys   map[string][]byte
+	index  []byte
+}
+
+func (k *staticKeys) ActiveKeyID() string { return k.active }
+
+func (k *staticKeys) Key(id string) ([]byte, error) {
+	key, ok := k.keys[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownKey, id)
+	}
+	return key, nil
+}
+
+func (k *staticKeys) IndexKey() []byte { return k.index }
+
+// NewEnvKeyProvider разбирает ключи из переменных окружения:
+// keys — «id:base64,id:base64», active — идентификатор активного ключа,
+// indexKey — ключ слепых индексов в base64. Все ключи — 32 байта.
+func NewEnvKeyProvider(keys, active, indexKey string) (KeyProvider, error) {
+	parsed := make(map[string]string)
+	for _, pair := range strings.Split(keys, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		id, key, ok := strings.Cut(pair, ":")
+		if !ok {
+			return nil, fmt.Errorf("ключ %q: ожидается формат id:base64", pair)
+		}
+		parsed[strings.TrimSpace(id)] = strings.TrimSpace(key)
+	}
+	return newStaticKeys(parsed, active, indexKey)
+}
+
+// keyFile — формат файла ключей:
+// {"active": "2026-10", "keys": {"2026-01": "base64", "2026-10": "base64"}, "index_key": "base64"}
+type keyFile struct {
+	Active   string            `json:"active"`
+	Keys     map[string]string `json:"keys"`
+	IndexKey string            `json:"index_key"`
+}
+
+// NewFileKeyProvider читает ключи из JSON-файла (например, смонтированного секрета)
+func NewFileKeyProvider(path string) (KeyProvider, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось прочитать файл ключей: %w", err)
+	}
+	var f keyFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("неверный формат файла ключей: %w", err)
+	}
+	return newStaticKeys(f.Keys, f.Active, f.IndexKey)
+}
+
+func newStaticKeys(encoded map[string]string, active, indexKey string) (*staticKeys, error) {
+	if len(encoded) == 0 {
+		return nil, errors.New("не заданы ключи шифрования ПДн")
+	}
+
+	k := &staticKeys{active: active, keys: make(map[string][]byte, len(encoded))}
+	for id, value := range encoded {
+		if id == "" || strings.Contains(id, ":") {
+			return nil, fmt.Errorf("недопустимый идентификатор ключа %q", id)
+		}
+		key, err := decodeKey(value)
+		if err != nil {
+			return nil, fmt.Errorf("ключ %s: %w", id, err)
+		}
+		k.keys[id] = key
+	}
+	if _, ok := k.keys[active]; !ok {
+		return nil, fmt.Errorf("активный ключ %q не найден среди ключей", active)
+	}
+
+	index, err := decodeKey(indexKey)
+	if err != nil {
+		return nil, fmt.Errorf("ключ слепых индексов: %w", err)
+	}
+	k.index = index
+	return k, nil
+}
+
+func decodeKey(value string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return nil, errors.New("ожидается base64")
+	}
+	if len(key) != 32 {
+		return nil, fmt.Errorf("длина ключа %d байт, нужно 32", len(key))
+	}
+	return key, nil
+}
@@ -0,0 +1,173 @@
+// Package pii шифрует персональные данные на уровне полей (AES-256-GCM) и строит
+// слепые индексы (HMAC-SHA256) для поиска по точному совпадению.
+//
+// Зашифрованное значение имеет вид enc:v1:<id ключа>:<base64(nonce|шифротекст)>.
+// Значения без префикса считаются записанными до включения шифрования и
+// возвращаются как есть — их перешифровывает команда cmd/rotate-pii-keys.
+package pii
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+const prefix = "enc:v1:"
+
+// ErrMalformed — значение с префиксом шифрования, которое не удаётся разобрать
+var ErrMalformed = errors.New("повреждённое зашифрованное значение ПДн")
+
+// Cipher шифрует и расшифровывает значения ключами провайдера
+type Cipher struct {
+	keys  KeyProvider
+	mu    sync.RWMutex
+	aeads map[string]cipher.AEAD
+}
+
+func NewCipher(keys KeyProvider) (*Cipher, error) {
+	c := &Cipher{keys: keys, aeads: make(map[string]cipher.AEAD)}
+	if _, err := c.aead(keys.ActiveKeyID()); err != nil {
+		return nil, err
+	}
+	if len(keys.IndexKey()) == 0 {
+		return nil, errors.New("не задан ключ слепых индексов")
+	}
+	return c, nil
+}
+
+// ActiveKeyID — ключ, которым шифруются новые значения
+func (c *Cipher) ActiveKeyID() string {
+	return c.keys.ActiveKeyID()
+}
+
+// aead возвращает AES-GCM для ключа; на старте заполняется активным ключом,
+// остальные создаются при первой расшифровке
+func (c *Cipher) aead(id string) (cipher.AEAD, error) {
+	c.mu.RLock()
+	a, ok := c.aeads[id]
+	c.mu.RUnlock()
+	if ok {
+		return a, nil
+	}
+	key, err := c.keys.Key(id)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	a, err = cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	c.aeads[id] = a
+	c.mu.Unlock()
+	return a, nil
+}
+
+// Encrypt шифрует значение активным ключом; пустая строка остаётся пустой
+func (c *Cipher) Encrypt(plain string) (string, error) {
+	if plain == "" {
+		return "", nil
+	}
+	id := c.keys.ActiveKeyID()
+	a, err := c.aead(id)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, a.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := a.Seal(nonce, nonce, []byte(plain), []byte(id))
+	return prefix + id + ":" + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt расшифровывает значение любым известным ключом.
+// Незашифрованные значения возвращаются без изменений.
+func (c *Cipher) Decrypt(value string) (string, error) {
+	if !strings.HasPrefix(value, prefix) {
+		return value, nil
+	}
+	id, data, ok := strings.Cut(strings.TrimPrefix(value, prefix), ":")
+	if !ok {
+		return "", ErrMalformed
+	}
+	sealed, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return "", ErrMalformed
+	}
+	a, err := c.aead(id)
+	if err != nil {
+		return "", err
+	}
+	if len(sealed) < a.NonceSize() {
+		return "", ErrMalformed
+	}
+	plain, err := a.Open(nil, sealed[:a.NonceSize()], sealed[a.NonceSize():], []byte(id))
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrMalformed, err)
+	}
+	return string(plain), nil
+}
+
+// KeyID — идентификатор ключа, которым зашифровано значение; пусто для открытого текста
+func KeyID(value string) string {
+	if !strings.HasPrefix(value, prefix) {
+		return ""
+	}
+	id, _, _ := strings.Cut(strings.TrimPrefix(value, prefix), ":")
+	return id
+}
+
+// BlindIndex — HMAC-SHA256 нормализованного значения в hex; пустое значение даёт пустой индекс
+func (c *Cipher) BlindIndex(value string) string {
+	if value == "" {
+		return ""
+	}
+	mac := hmac.New(sha256.New, c.keys.IndexKey())
+	mac.Write([]byte(value))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+var current atomic.Pointer[Cipher]
+
+// Configure задаёт шифр для сериализатора GORM и слепых индексов; вызывается при старте
+func Configure(c *Cipher) {
+	current.Store(c)
+}
+
+// Default — шифр, заданный Configure
+func Default() (*Cipher, error) {
+	c := current.Load()
+	if c == nil {
+		return nil, errors.New("шифрование ПДн не настроено")
+	}
+	return c, nil
+}
+
+// BlindIndex строит слепой индекс шифром по умолчанию.
+// Без настроенного шифра паникует: поиск по открытому значению недопустим.
+func BlindIndex(value string) string {
+	c, err := Default()
+	if err != nil {
+		panic(err)
+	}
+	return c.BlindIndex(value)
+}
+
+// KeyPrefix — начало значений, зашифрованных ключом id; для поиска устаревших значений в SQL
+func KeyPrefix(id string) string {
+	return prefix + id + ":"
+}
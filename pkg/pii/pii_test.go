@@ -0,0 +1,138 @@
+package pii
+
+import (
+	"encoding/base64"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func testKey(b byte) string {
+	return base64.StdEncoding.EncodeToString([]byte(strings.Repeat(string(rune(b)), 32)))
+}
+
+func newTestCipher(t *testing.T, keys, active string) *Cipher {
+	t.Helper()
+	provider, err := NewEnvKeyProvider(keys, active, testKey('i'))
+	if err != nil {
+		t.Fatalf("provider: %v", err)
+	}
+	c, err := NewCipher(provider)
+	if err != nil {
+		t.Fatalf("cipher: %v", err)
+	}
+	return c
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	c := newTestCipher(t, "v1:"+testKey('a'), "v1")
+
+	enc, err := c.Encrypt("112-233-445 95")
+	if err != nil {
+		t.Fatalf("encrypt: %v", err)
+	}
+	if !strings.HasPrefix(enc, "enc:v1:v1:") || strings.Contains(enc, "112-233") {
+		t.Fatalf("unexpected ciphertext %q", enc)
+	}
+	if again, _ := c.Encrypt("112-233-445 95"); again == enc {
+		t.Error("ciphertext must differ between calls (random nonce)")
+	}
+
+	plain, err := c.Decrypt(enc)
+	if err != nil || plain != "112-233-445 95" {
+		t.Errorf("Decrypt = %q, %v", plain, err)
+	}
+
+	if empty, _ := c.Encrypt(""); empty != "" {
+		t.Errorf("empty value encrypted to %q", empty)
+	}
+	if legacy, err := c.Decrypt("+7 900 000-00-00"); err != nil || legacy != "+7 900 000-00-00" {
+		t.Errorf("legacy plaintext = %q, %v", legacy, err)
+	}
+}
+
+func TestDecryptErrors(t *testing.T) {
+	c := newTestCipher(t, "v1:"+testKey('a'), "v1")
+	enc, _ := c.Encrypt("secret")
+
+	tampered := enc[:len(enc)-4] + "AAAA"
+	if _, err := c.Decrypt(tampered); !errors.Is(err, ErrMalformed) {
+		t.Errorf("tampered: err = %v, want ErrMalformed", err)
+	}
+	if _, err := c.Decrypt("enc:v1:v1"); !errors.Is(err, ErrMalformed) {
+		t.Errorf("no payload: err = %v, want ErrMalformed", err)
+	}
+	if _, err := c.Decrypt(strings.Replace(enc, "enc:v1:v1:", "enc:v1:v9:", 1)); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("unknown key: err = %v, want ErrUnknownKey", err)
+	}
+}
+
+func TestRotation(t *testing.T) {
+	old := newTestCipher(t, "v1:"+testKey('a'), "v1")
+	enc, _ := old.Encrypt("4510 123456")
+
+	rotated := newTestCipher(t, "v1:"+testKey('a')+", v2:"+testKey('b'), "v2")
+	plain, err := rotated.Decrypt(enc)
+	if err != nil || plain != "4510 123456" {
+		t.Fatalf("old value after rotation = %q, %v", plain, err)
+	}
+
+	reenc, _ := rotated.Encrypt(plain)
+	if KeyID(enc) != "v1" || KeyID(reenc) != "v2" || KeyID("plain") != "" {
+		t.Errorf("KeyID: %q, %q", KeyID(enc), KeyID(reenc))
+	}
+	if old.BlindIndex("11223344595") != rotated.BlindIndex("11223344595") {
+		t.Error("blind index must not depend on the encryption key")
+	}
+}
+
+func TestBlindIndex(t *testing.T) {
+	c := newTestCipher(t, "v1:"+testKey('a'), "v1")
+
+	if c.BlindIndex("") != "" {
+		t.Error("empty value must give empty index")
+	}
+	a, b := c.BlindIndex("11223344595"), c.BlindIndex("11223344596")
+	if len(a) != 64 || a == b || a != c.BlindIndex("11223344595") {
+		t.Errorf("unexpected indexes %q, %q", a, b)
+	}
+}
+
+func TestKeyProviders(t *testing.T) {
+	tests := []struct {
+		name   string
+		keys   string
+		active string
+	}{
+		{"empty", "", "v1"},
+		{"no separator", testKey('a'), "v1"},
+		{"short key", "v1:" + base64.StdEncoding.EncodeToString([]byte("short")), "v1"},
+		{"missing active", "v1:" + testKey('a'), "v2"},
+	}
+	for _, tt := range tests {
+		if _, err := NewEnvKeyProvider(tt.keys, tt.active, testKey('i')); err == nil {
+			t.Errorf("%s: expected error", tt.name)
+		}
+	}
+	if _, err := NewEnvKeyProvider("v1:"+testKey('a'), "v1", ""); err == nil {
+		t.Error("missing index key: expected error")
+	}
+
+	path := filepath.Join(t.TempDir(), "keys.json")
+	data := `{"active": "v2", "keys": {"v1": "` + testKey('a') + `", "v2": "` + testKey('b') + `"}, "index_key": "` + testKey('i') + `"}`
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	provider, err := NewFileKeyProvider(path)
+	if err != nil {
+		t.Fatalf("file provider: %v", err)
+	}
+	if provider.ActiveKeyID() != "v2" {
+		t.Errorf("active = %q, want v2", provider.ActiveKeyID())
+	}
+	if _, err := provider.Key("v1"); err != nil {
+		t.Errorf("Key(v1): %v", err)
+	}
+}
@@ -0,0 +1,62 @@
+package pii
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"gorm.io/gorm/schema"
+)
+
+// SerializerName — имя сериализатора для тега поля: gorm:"serializer:pii"
+const SerializerName = "pii"
+
+func init() {
+	schema.RegisterSerializer(SerializerName, Serializer{})
+}
+
+// Serializer шифрует строковое поле при записи и расшифровывает при чтении
+// шифром, заданным Configure. Условия WHERE по таким полям не работают —
+// для поиска используются слепые индексы.
+type Serializer struct{}
+
+func (Serializer) Scan(ctx context.Context, field *schema.Field, dst reflect.Value, dbValue interface{}) error {
+	var stored string
+	switch v := dbValue.(type) {
+	case nil:
+	case string:
+		stored = v
+	case []byte:
+		stored = string(v)
+	default:
+		return fmt.Errorf("поле %s: неожиданный тип %T", field.Name, dbValue)
+	}
+
+	plain := stored
+	if stored != "" {
+		c, err := Default()
+		if err != nil {
+			return err
+		}
+		if plain, err = c.Decrypt(stored); err != nil {
+			return fmt.Errorf("поле %s: %w", field.Name, err)
+		}
+	}
+	field.ReflectValueOf(ctx, dst).SetString(plain)
+	return nil
+}
+
+func (Serializer) Value(ctx context.Context, field *schema.Field, dst reflect.Value, fieldValue interface{}) (interface{}, error) {
+	plain, ok := fieldValue.(string)
+	if !ok {
+		return nil, fmt.Errorf("поле %s: шифруются только строки", field.Name)
+	}
+	if plain == "" {
+		return "", nil
+	}
+	c, err := Default()
+	if err != nil {
+		return nil, err
+	}
+	return c.Encrypt(plain)
+}
@@ -0,0 +1,42 @@
+package ratelimit
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"math/bits"
+)
+
+// Задача proof-of-work: найти solution, при котором SHA-256("nonce:solution")
+// начинается с difficulty нулевых бит. Клиенту это стоит около 2^difficulty
+// хешей, проверка — один хеш; перебор кодов становится дорогим без капчи.
+
+// NewNonce — случайная строка задачи
+func NewNonce() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// VerifyWork проверяет решение задачи
+func VerifyWork(nonce, solution string, difficulty int) bool {
+	if solution == "" || len(solution) > 32 {
+		return false
+	}
+	sum := sha256.Sum256([]byte(nonce + ":" + solution))
+	return LeadingZeroBits(sum[:]) >= difficulty
+}
+
+// LeadingZeroBits — число нулевых бит в начале b
+func LeadingZeroBits(b []byte) int {
+	n := 0
+	for _, x := range b {
+		if x != 0 {
+			return n + bits.LeadingZeros8(x)
+		}
+		n += 8
+	}
+	return n
+}
@@ -0,0 +1,95 @@
+package ratelimit
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestMemoryStoreCounters(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(1000, 0)
+	s := NewMemoryStore()
+	s.now = func() time.Time { return now }
+
+	for i := int64(1); i <= 3; i++ {
+		if n, _ := s.Incr(ctx, "ip", time.Minute); n != i {
+			t.Fatalf("Incr #%d = %d", i, n)
+		}
+	}
+	// Окно не продлевается последующими попытками
+	now = now.Add(61 * time.Second)
+	if n, _ := s.Count(ctx, "ip"); n != 0 {
+		t.Errorf("Count after window = %d, want 0", n)
+	}
+	if n, _ := s.Incr(ctx, "ip", time.Minute); n != 1 {
+		t.Errorf("Incr after window = %d, want 1", n)
+	}
+}
+
+func TestMemoryStoreLockAndTake(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(1000, 0)
+	s := NewMemoryStore()
+	s.now = func() time.Time { return now }
+
+	if ttl, _ := s.TTL(ctx, "lock"); ttl != 0 {
+		t.Errorf("TTL without lock = %v", ttl)
+	}
+	s.Lock(ctx, "lock", 10*time.Minute)
+	now = now.Add(4 * time.Minute)
+	if ttl, _ := s.TTL(ctx, "lock"); ttl != 6*time.Minute {
+		t.Errorf("TTL = %v, want 6m", ttl)
+	}
+
+	s.Put(ctx, "challenge", "nonce", time.Minute)
+	if v, ok, _ := s.Take(ctx, "challenge"); !ok || v != "nonce" {
+		t.Errorf("Take = %q, %v", v, ok)
+	}
+	if _, ok, _ := s.Take(ctx, "challenge"); ok {
+		t.Error("challenge must be single-use")
+	}
+	s.Put(ctx, "expired", "x", time.Minute)
+	now = now.Add(2 * time.Minute)
+	if _, ok, _ := s.Take(ctx, "expired"); ok {
+		t.Error("expired value returned")
+	}
+}
+
+func TestVerifyWork(t *testing.T) {
+	const nonce, difficulty = "abc", 8
+	solution := ""
+	for i := 0; i < 1<<16; i++ {
+		if VerifyWork(nonce, strconv.Itoa(i), difficulty) {
+			solution = strconv.Itoa(i)
+			break
+		}
+	}
+	if solution == "" {
+		t.Fatal("no solution found")
+	}
+	if VerifyWork("other", solution, 32) {
+		t.Error("solution accepted for another nonce")
+	}
+	if VerifyWork(nonce, "", 0) {
+		t.Error("empty solution accepted")
+	}
+}
+
+func TestLeadingZeroBits(t *testing.T) {
+	tests := []struct {
+		in   []byte
+		want int
+	}{
+		{[]byte{0x80}, 0},
+		{[]byte{0x00, 0x10}, 11},
+		{[]byte{0x01}, 7},
+		{[]byte{0x00, 0x00}, 16},
+	}
+	for _, tt := range tests {
+		if got := LeadingZeroBits(tt.in); got != tt.want {
+			t.Errorf("LeadingZeroBits(%x) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+}
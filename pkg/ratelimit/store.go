@@ -0,0 +1,116 @@
+// Package ratelimit — счётчики попыток, блокировки и одноразовые задачи
+// для защиты входа от перебора; блокировки служат и списком отозванных токенов.
+// Хранилище — Redis, без него — память процесса.
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Store — счётчики и значения с истечением срока
+type Store interface {
+	// Incr увеличивает счётчик; окно отсчитывается от первого увеличения
+	Incr(ctx context.Context, key string, window time.Duration) (int64, error)
+	Count(ctx context.Context, key string) (int64, error)
+	// Lock ставит блокировку; TTL — сколько она ещё действует (0 — не заблокировано)
+	Lock(ctx context.Context, key string, ttl time.Duration) error
+	TTL(ctx context.Context, key string) (time.Duration, error)
+	// Put сохраняет значение, Take возвращает и удаляет его — для одноразовых задач
+	Put(ctx context.Context, key, value string, ttl time.Duration) error
+	Take(ctx context.Context, key string) (string, bool, error)
+}
+
+type memoryEntry struct {
+	count   int64
+	value   string
+	expires time.Time
+}
+
+// MemoryStore — хранилище в памяти: счётчики не общие для нескольких экземпляров
+// сервера и сбрасываются при перезапуске
+type MemoryStore struct {
+	mu      sync.Mutex
+	entries map[string]*memoryEntry
+	now     func() time.Time
+	ops     int
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{entries: make(map[string]*memoryEntry), now: time.Now}
+}
+
+// get возвращает действующую запись; вызывается под mu
+func (s *MemoryStore) get(key string) *memoryEntry {
+	now := s.now()
+	// Истёкшие записи чистятся раз в 1000 операций
+	if s.ops++; s.ops%1000 == 0 {
+		for k, e := range s.entries {
+			if !now.Before(e.expires) {
+				delete(s.entries, k)
+			}
+		}
+	}
+	e, ok := s.entries[key]
+	if !ok || !now.Before(e.expires) {
+		delete(s.entries, key)
+		return nil
+	}
+	return e
+}
+
+func (s *MemoryStore) Incr(_ context.Context, key string, window time.Duration) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e := s.get(key)
+	if e == nil {
+		e = &memoryEntry{expires: s.now().Add(window)}
+		s.entries[key] = e
+	}
+	e.count++
+	return e.count, nil
+}
+
+func (s *MemoryStore) Count(_ context.Context, key string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if e := s.get(key); e != nil {
+		return e.count, nil
+	}
+	return 0, nil
+}
+
+func (s *MemoryStore) Lock(_ context.Context, key string, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries[key] = &memoryEntry{count: 1, expires: s.now().Add(ttl)}
+	return nil
+}
+
+func (s *MemoryStore) TTL(_ context.Context, key string) (time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if e := s.get(key); e != nil {
+		return e.expires.Sub(s.now()), nil
+	}
+	return 0, nil
+}
+
+func (s *MemoryStore) Put(_ context.Context, key, value string, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries[key] = &memoryEntry{value: value, expires: s.now().Add(ttl)}
+	return nil
+}
+
+func (s *MemoryStore) Take(_ context.Context, key string) (string, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e := s.get(key)
+	if e == nil {
+		return "", false, nil
+	}
+	delete(s.entries, key)
+	return e.value, true, nil
+}
@@ -10,20 +10,26 @@ import (
 
 	"github.com/beercut-team/backend-boilerplate/internal/domain"
 	"github.com/beercut-team/backend-boilerplate/internal/repository"
+	"github.com/beercut-team/backend-boilerplate/pkg/ratelimit"
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/rs/zerolog/log"
 )
 
+// Неверных кодов в /start за час на один чат
+const startAttempts = 5
+
 type Bot struct {
 	api          *tgbotapi.BotAPI
 	patientRepo  repository.PatientRepository
 	telegramRepo repository.TelegramRepository
 	tokenRepo    repository.TelegramTokenRepository
 	userRepo     repository.UserRepository
+	consentRepo  repository.ConsentRepository
+	limits       ratelimit.Store
 	baseURL      string
 }
 
-func NewBot(token string, baseURL string, patientRepo repository.PatientRepository, telegramRepo repository.TelegramRepository, tokenRepo repository.TelegramTokenRepository, userRepo repository.UserRepository) (*Bot, error) {
+func NewBot(token string, baseURL string, patientRepo repository.PatientRepository, telegramRepo repository.TelegramRepository, tokenRepo repository.TelegramTokenRepository, userRepo repository.UserRepository, consentRepo repository.ConsentRepository, limits ratelimit.Store) (*Bot, error) {
 	if token == "" {
 		return nil, nil
 	}
@@ -45,6 +51,8 @@ func NewBot(token string, baseURL string, patientRepo repository.PatientReposito
 		telegramRepo: telegramRepo,
 		tokenRepo:    tokenRepo,
 		userRepo:     userRepo,
+		consentRepo:  consentRepo,
+		limits:       limits,
 		baseURL:      baseURL,
 	}, nil
 }
@@ -99,6 +107,12 @@ func (b *Bot) handleMessage(msg *tgbotapi.Message) {
 		b.handleRebind(ctx, msg)
 	case text == "/login":
 		b.handleLogin(ctx, msg)
+	case text == "/consent":
+		b.handleConsent(ctx, msg)
+	case strings.HasPrefix(text, "/agree"):
+		b.handleAgree(ctx, msg)
+	case strings.HasPrefix(text, "/revoke"):
+		b.handleRevoke(ctx, msg)
 	case text == "/help":
 		b.sendMessage(msg.Chat.ID, `Доступные команды:
 
@@ -107,6 +121,9 @@ func (b *Bot) handleMessage(msg *tgbotapi.Message) {
 /status — Проверить статус подготовки
 /login — Получить ссылку для входа в личный кабинет
 /rebind — Отвязать текущего пациента и привязать нового
+/consent — Мои согласия
+/agree <pd|telegram|emias|riams> — Дать согласие
+/revoke <pd|telegram|emias|riams> — Отозвать согласие
 
 Для врачей:
 /register <email> — Привязать аккаунт врача
@@ -126,11 +143,21 @@ func (b *Bot) handleStart(ctx context.Context, msg *tgbotapi.Message) {
 
 	// Normalize access code: trim whitespace and convert to lowercase
 	accessCode := strings.ToLower(strings.TrimSpace(parts[1]))
-	log.Info().Str("access_code", accessCode).Int64("chat_id", msg.Chat.ID).Msg("Попытка привязки пациента")
+	log.Info().Str("access_code", domain.MaskAccessCode(accessCode)).Int64("chat_id", msg.Chat.ID).Msg("Попытка привязки пациента")
+
+	// Перебор кодов через бота ограничен по чату
+	attemptsKey := fmt.Sprintf("tg:start:%d", msg.Chat.ID)
+	if failed, _ := b.limits.Count(ctx, attemptsKey); failed >= startAttempts {
+		b.sendMessage(msg.Chat.ID, "Слишком много неверных кодов. Попробуйте через час или уточните код у врача.")
+		return
+	}
 
 	patient, err := b.patientRepo.FindByAccessCode(ctx, accessCode)
 	if err != nil {
-		log.Error().Err(err).Str("access_code", accessCode).Msg("Код доступа не найден")
+		log.Warn().Err(err).Str("access_code", domain.MaskAccessCode(accessCode)).Int64("chat_id", msg.Chat.ID).Msg("Код доступа не найден")
+		if _, err := b.limits.Incr(ctx, attemptsKey, time.Hour); err != nil {
+			log.Error().Err(err).Msg("не удалось учесть попытку привязки")
+		}
 		b.sendMessage(msg.Chat.ID, "Неверный код доступа. Пожалуйста, проверьте и попробуйте снова.")
 		return
 	}
@@ -157,7 +184,8 @@ func (b *Bot) handleStart(ctx context.Context, msg *tgbotapi.Message) {
 
 	statusName := domain.GetStatusDisplayName(patient.Status)
 	b.sendMessage(msg.Chat.ID, fmt.Sprintf(
-		"✅ Успешно привязано!\nПациент: %s %s\nСтатус: %s\n\nИспользуйте /status для проверки прогресса подготовки.",
+		"✅ Успешно привязано!\nПациент: %s %s\nСтатус: %s\n\nИспользуйте /status для проверки прогресса подготовки.\n"+
+			"Уведомления приходят только при действующих согласиях — см. /consent.",
 		patient.FirstName, patient.LastName, statusName,
 	))
 }
@@ -354,7 +382,7 @@ func (b *Bot) NotifyPatientStatusChange(ctx context.Context, patientID uint, new
 	}
 
 	binding, err := b.telegramRepo.FindByPatientID(ctx, patientID)
-	if err != nil || !binding.IsActive {
+	if err != nil || !binding.IsActive || !b.notificationsAllowed(ctx, patientID) {
 		return
 	}
 
@@ -422,8 +450,9 @@ func (b *Bot) NotifySurgeonReviewNeeded(ctx context.Context, patientID uint) {
 		return
 	}
 
-	// Найти всех хирургов с привязанным Telegram
-	surgeons, err := b.userRepo.FindAll(ctx)
+	// Найти активных хирургов с привязанным Telegram
+	active := true
+	surgeons, _, err := b.userRepo.FindAll(ctx, repository.UserFilters{Role: domain.RoleSurgeon, IsActive: &active}, 0, -1)
 	if err != nil {
 		log.Error().Err(err).Msg("не удалось найти хирургов")
 		return
@@ -442,7 +471,7 @@ func (b *Bot) NotifySurgeonReviewNeeded(ctx context.Context, patientID uint) {
 
 	sentCount := 0
 	for _, surgeon := range surgeons {
-		if surgeon.Role == domain.RoleSurgeon && surgeon.TelegramChatID != nil {
+		if surgeon.TelegramChatID != nil {
 			b.sendMessage(*surgeon.TelegramChatID, message)
 			sentCount++
 		}
@@ -458,7 +487,7 @@ func (b *Bot) NotifyPatientNewAccessCode(ctx context.Context, patientID uint, ne
 	}
 
 	binding, err := b.telegramRepo.FindByPatientID(ctx, patientID)
-	if err != nil || !binding.IsActive {
+	if err != nil || !binding.IsActive || !b.notificationsAllowed(ctx, patientID) {
 		return
 	}
 
@@ -491,6 +520,10 @@ func (b *Bot) NotifyPatient(ctx context.Context, patientID uint, message string)
 		return
 	}
 
+	if !b.notificationsAllowed(ctx, patientID) {
+		return
+	}
+
 	b.sendMessage(binding.ChatID, message)
 	log.Info().Uint("patient_id", patientID).Int64("chat_id", binding.ChatID).Msg("уведомление отправлено в Telegram")
 }
@@ -0,0 +1,165 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/beercut-team/backend-boilerplate/internal/domain"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/rs/zerolog/log"
+)
+
+// Короткие имена согласий для команд /agree и /revoke
+var consentAliases = map[string]domain.ConsentType{
+	"pd":       domain.ConsentPersonalData,
+	"telegram": domain.ConsentTelegram,
+	"emias":    domain.ConsentEMIAS,
+	"riams":    domain.ConsentRIAMS,
+}
+
+func consentAlias(t domain.ConsentType) string {
+	for alias, ct := range consentAliases {
+		if ct == t {
+			return alias
+		}
+	}
+	return string(t)
+}
+
+// notificationsAllowed — пациенту можно писать, только пока действуют
+// согласия на обработку ПДн и на уведомления в Telegram
+func (b *Bot) notificationsAllowed(ctx context.Context, patientID uint) bool {
+	if b.consentRepo == nil {
+		return false
+	}
+	active, err := b.consentRepo.FindActive(ctx, patientID)
+	if err != nil {
+		log.Error().Err(err).Uint("patient_id", patientID).Msg("не удалось проверить согласия пациента")
+		return false
+	}
+	if missing := domain.MissingConsents(active, domain.ConsentsForTelegram); len(missing) > 0 {
+		log.Debug().Uint("patient_id", patientID).Interface("missing", missing).Msg("нет согласия на уведомления, сообщение не отправлено")
+		return false
+	}
+	return true
+}
+
+// boundPatientID — пациент, привязанный к чату; иначе отвечает подсказкой
+func (b *Bot) boundPatientID(ctx context.Context, msg *tgbotapi.Message) (uint, bool) {
+	binding, err := b.telegramRepo.FindByChatID(ctx, msg.Chat.ID)
+	if err != nil || !binding.IsActive {
+		b.sendMessage(msg.Chat.ID, "❌ Вы не привязаны к пациенту.\n\nИспользуйте /start <код_доступа> для привязки.")
+		return 0, false
+	}
+	return binding.PatientID, true
+}
+
+func (b *Bot) handleConsent(ctx context.Context, msg *tgbotapi.Message) {
+	patientID, ok := b.boundPatientID(ctx, msg)
+	if !ok {
+		return
+	}
+
+	active, err := b.consentRepo.FindActive(ctx, patientID)
+	if err != nil {
+		b.sendMessage(msg.Chat.ID, "Не удалось получить согласия. Попробуйте позже.")
+		return
+	}
+
+	var sb strings.Builder
+	sb.WriteString("📄 Ваши согласия\n")
+	for _, text := range domain.ConsentTexts {
+		mark := "❌"
+		if len(domain.MissingConsents(active, []domain.ConsentType{text.Type})) == 0 {
+			mark = "✅"
+		}
+		sb.WriteString(fmt.Sprintf("\n%s %s (/agree %s)\n%s\n", mark, text.Title, consentAlias(text.Type), text.Text))
+	}
+	sb.WriteString("\nОтозвать согласие: /revoke <pd|telegram|emias|riams>")
+
+	b.sendMessage(msg.Chat.ID, sb.String())
+}
+
+func (b *Bot) handleAgree(ctx context.Context, msg *tgbotapi.Message) {
+	consentType, ok := b.parseConsentArg(msg, "/agree")
+	if !ok {
+		return
+	}
+	patientID, ok := b.boundPatientID(ctx, msg)
+	if !ok {
+		return
+	}
+
+	text, _ := domain.CurrentConsentText(consentType)
+	_, created, err := b.consentRepo.Capture(ctx, &domain.Consent{
+		PatientID:   patientID,
+		Type:        consentType,
+		TextVersion: text.Version,
+		Channel:     domain.ConsentChannelTelegram,
+		SignedAt:    time.Now(),
+	})
+	if err != nil {
+		log.Error().Err(err).Uint("patient_id", patientID).Msg("не удалось сохранить согласие из Telegram")
+		b.sendMessage(msg.Chat.ID, "Не удалось сохранить согласие. Попробуйте позже.")
+		return
+	}
+	if !created {
+		b.sendMessage(msg.Chat.ID, fmt.Sprintf("%s уже действует.", text.Title))
+		return
+	}
+
+	b.sendMessage(msg.Chat.ID, fmt.Sprintf("✅ %s (редакция %s) принято.\n\n%s", text.Title, text.Version, text.Text))
+}
+
+func (b *Bot) handleRevoke(ctx context.Context, msg *tgbotapi.Message) {
+	consentType, ok := b.parseConsentArg(msg, "/revoke")
+	if !ok {
+		return
+	}
+	patientID, ok := b.boundPatientID(ctx, msg)
+	if !ok {
+		return
+	}
+
+	active, err := b.consentRepo.FindActive(ctx, patientID)
+	if err != nil {
+		b.sendMessage(msg.Chat.ID, "Не удалось получить согласия. Попробуйте позже.")
+		return
+	}
+
+	text, _ := domain.CurrentConsentText(consentType)
+	revoked := 0
+	for i := range active {
+		if active[i].Type != consentType {
+			continue
+		}
+		if err := active[i].Revoke(domain.ConsentChannelTelegram, nil, "отозвано в Telegram", time.Now()); err != nil {
+			continue
+		}
+		if err := b.consentRepo.Update(ctx, &active[i]); err != nil {
+			log.Error().Err(err).Uint("consent_id", active[i].ID).Msg("не удалось отозвать согласие из Telegram")
+			b.sendMessage(msg.Chat.ID, "Не удалось отозвать согласие. Попробуйте позже.")
+			return
+		}
+		revoked++
+	}
+	if revoked == 0 {
+		b.sendMessage(msg.Chat.ID, fmt.Sprintf("%s не действует.", text.Title))
+		return
+	}
+
+	b.sendMessage(msg.Chat.ID, fmt.Sprintf("%s отозвано.", text.Title))
+}
+
+func (b *Bot) parseConsentArg(msg *tgbotapi.Message, command string) (domain.ConsentType, bool) {
+	parts := strings.Fields(msg.Text)
+	if len(parts) >= 2 {
+		if t, ok := consentAliases[strings.ToLower(parts[1])]; ok {
+			return t, true
+		}
+	}
+	b.sendMessage(msg.Chat.ID, fmt.Sprintf("Укажите согласие: %s <pd|telegram|emias|riams>\n\nСписок согласий: /consent", command))
+	return "", false
+}
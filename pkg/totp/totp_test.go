@@ -0,0 +1,90 @@
+package totp
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// Секрет из RFC 6238 (ASCII "12345678901234567890")
+var rfcSecret = encoding.EncodeToString([]byte("12345678901234567890"))
+
+func TestCodeRFC6238(t *testing.T) {
+	// Эталонные 8-значные коды из приложения B RFC 6238 (SHA1)
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "94287082"},
+		{1111111109, "07081804"},
+		{1111111111, "14050471"},
+		{1234567890, "89005924"},
+		{2000000000, "69279037"},
+		{20000000000, "65353130"},
+	}
+	key, err := decodeSecret(rfcSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		step := Step(time.Unix(tt.unix, 0))
+		if got := code(key, step, 8); got != tt.want {
+			t.Errorf("t=%d: code = %s, want %s", tt.unix, got, tt.want)
+		}
+		got, err := Code(rfcSecret, step)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := tt.want[2:]; got != want {
+			t.Errorf("t=%d: Code = %s, want %s", tt.unix, got, want)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	current := Step(now)
+	prev, _ := Code(rfcSecret, current-1)
+	old, _ := Code(rfcSecret, current-3)
+
+	tests := []struct {
+		name     string
+		passcode string
+		wantOK   bool
+		wantStep int64
+	}{
+		{"current", "050471", true, current},
+		{"with spaces", " 050 471 ", true, current},
+		{"previous step within skew", prev, true, current - 1},
+		{"outside skew", old, false, 0},
+		{"wrong", "000000", false, 0},
+		{"short", "12345", false, 0},
+	}
+	for _, tt := range tests {
+		step, ok := Validate(rfcSecret, tt.passcode, now, 1)
+		if ok != tt.wantOK || step != tt.wantStep {
+			t.Errorf("%s: Validate = (%d, %v), want (%d, %v)", tt.name, step, ok, tt.wantStep, tt.wantOK)
+		}
+	}
+}
+
+func TestGenerateSecret(t *testing.T) {
+	a, err := GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := GenerateSecret()
+	if a == b || len(a) != 32 {
+		t.Errorf("unexpected secrets %q, %q", a, b)
+	}
+	if _, err := Code(strings.ToLower(a), 1); err != nil {
+		t.Errorf("lowercase secret: %v", err)
+	}
+}
+
+func TestURL(t *testing.T) {
+	got := URL("Oculus", "doc@example.com", "ABC")
+	if !strings.HasPrefix(got, "otpauth://totp/Oculus:doc@example.com?") || !strings.Contains(got, "secret=ABC") {
+		t.Errorf("URL = %s", got)
+	}
+}